@@ -152,6 +152,14 @@ func main() {
 		pay.PayWalletTransaction{},
 		pay.PayWalletRechargePackage{},
 		pay.PayTransfer{},
+		pay.PayReconcileBill{},
+		pay.PayReconcileDiscrepancy{},
+		pay.PayProfitSharingReceiver{},
+		pay.PayProfitSharingRule{},
+		pay.PayProfitSharingOrder{},
+		pay.PayProfitSharingOrderItem{},
+		pay.PayProfitSharingReturn{},
+		pay.PayChannelRouteRule{},
 		// Iot
 		model.IotProductDO{},
 		model.IotDeviceDO{},
@@ -164,10 +172,18 @@ func main() {
 		model.IotAlertRecordDO{},
 		model.IotDataRuleDO{},
 		model.IotDataSinkDO{},
+		model.IotDataSinkDeadLetterDO{},
 		model.IotSceneRuleDO{},
 		model.IotProductCategoryDO{},
 		model.IotDeviceMessageDO{},
 		model.IotDevicePropertyDO{},
+		model.IotDeviceShadowDO{},
+		model.IotDevicePropertyRollupDO{},
+		model.IotDeviceRegisterLogDO{},
+		model.IotDeviceCertificateDO{},
+		model.IotGeofenceDO{},
+		model.IotDeviceLocationDO{},
+		model.IotThingModelVersionDO{},
 	)
 
 	// 4. 执行生成
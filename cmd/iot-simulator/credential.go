@@ -0,0 +1,223 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
+)
+
+// adminPageSize 从管理端分页拉取设备时的每页条数
+const adminPageSize = 100
+
+// deviceCredential 虚拟设备的接入凭证
+type deviceCredential struct {
+	ProductID    int64  `json:"productId"`
+	ProductKey   string `json:"productKey"`
+	DeviceName   string `json:"deviceName"`
+	DeviceSecret string `json:"deviceSecret"`
+}
+
+// loadCredentialsFromFile 从文件加载设备凭证
+// .json 为凭证数组；.csv 首行为表头，需包含 productKey、deviceName、deviceSecret 列（productId 可选）
+func loadCredentialsFromFile(path string) ([]*deviceCredential, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return parseCredentialsCSV(data)
+	}
+	var credentials []*deviceCredential
+	if err := json.Unmarshal(data, &credentials); err != nil {
+		return nil, fmt.Errorf("parse credential file failed: %w", err)
+	}
+	return credentials, validateCredentials(credentials)
+}
+
+// parseCredentialsCSV 解析 CSV 格式的设备凭证
+func parseCredentialsCSV(data []byte) ([]*deviceCredential, error) {
+	records, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(data), "\ufeff"))).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parse credential csv failed: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	columns := make(map[string]int, len(records[0]))
+	for i, header := range records[0] {
+		columns[strings.TrimSpace(header)] = i
+	}
+	for _, name := range []string{"productKey", "deviceName", "deviceSecret"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("credential csv missing column: %s", name)
+		}
+	}
+	column := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	credentials := make([]*deviceCredential, 0, len(records)-1)
+	for _, record := range records[1:] {
+		credential := &deviceCredential{
+			ProductKey:   column(record, "productKey"),
+			DeviceName:   column(record, "deviceName"),
+			DeviceSecret: column(record, "deviceSecret"),
+		}
+		credential.ProductID, _ = strconv.ParseInt(column(record, "productId"), 10, 64)
+		credentials = append(credentials, credential)
+	}
+	return credentials, validateCredentials(credentials)
+}
+
+// validateCredentials 校验凭证必填项
+func validateCredentials(credentials []*deviceCredential) error {
+	for i, credential := range credentials {
+		if credential.ProductKey == "" || credential.DeviceName == "" || credential.DeviceSecret == "" {
+			return fmt.Errorf("credential #%d: productKey, deviceName and deviceSecret are required", i+1)
+		}
+	}
+	return nil
+}
+
+// loadTSLFromFile 从文件加载物模型，内容为 get-tsl 接口返回的 TSL 对象或其数组，按 productKey 索引
+func loadTSLFromFile(path string) (map[string]*iot2.IotThingModelTSLRespVO, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var list []*iot2.IotThingModelTSLRespVO
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		err = json.Unmarshal(data, &list)
+	} else {
+		tsl := &iot2.IotThingModelTSLRespVO{}
+		err = json.Unmarshal(data, tsl)
+		list = append(list, tsl)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse tsl file failed: %w", err)
+	}
+	tsls := make(map[string]*iot2.IotThingModelTSLRespVO, len(list))
+	for _, tsl := range list {
+		tsls[tsl.ProductKey] = tsl
+	}
+	return tsls, nil
+}
+
+// adminClient 管理端 API 客户端，用于拉取设备凭证与物模型
+type adminClient struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+func newAdminClient(baseURL, token string) *adminClient {
+	return &adminClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// listCredentials 分页拉取产品下的设备凭证，limit 大于 0 时最多拉取 limit 个
+func (c *adminClient) listCredentials(productID int64, limit int) ([]*deviceCredential, error) {
+	var credentials []*deviceCredential
+	for pageNo := 1; ; pageNo++ {
+		query := url.Values{}
+		query.Set("pageNo", strconv.Itoa(pageNo))
+		query.Set("pageSize", strconv.Itoa(adminPageSize))
+		if productID > 0 {
+			query.Set("productId", strconv.FormatInt(productID, 10))
+		}
+		var page pagination.PageResult[*iot2.IotDeviceRespVO]
+		if err := c.get("/admin-api/iot/device/page", query, &page); err != nil {
+			return nil, err
+		}
+		for _, device := range page.List {
+			credentials = append(credentials, &deviceCredential{
+				ProductID:    device.ProductID,
+				ProductKey:   device.ProductKey,
+				DeviceName:   device.DeviceName,
+				DeviceSecret: device.DeviceSecret,
+			})
+			if limit > 0 && len(credentials) >= limit {
+				return credentials, nil
+			}
+		}
+		if len(page.List) < adminPageSize || int64(pageNo*adminPageSize) >= page.Total {
+			return credentials, nil
+		}
+	}
+}
+
+// getTSL 获取产品的物模型
+func (c *adminClient) getTSL(productID int64) (*iot2.IotThingModelTSLRespVO, error) {
+	query := url.Values{}
+	query.Set("productId", strconv.FormatInt(productID, 10))
+	tsl := &iot2.IotThingModelTSLRespVO{}
+	if err := c.get("/admin-api/iot/thing-model/get-tsl", query, tsl); err != nil {
+		return nil, err
+	}
+	return tsl, nil
+}
+
+// getProductID 根据 productKey 获取产品编号
+func (c *adminClient) getProductID(productKey string) (int64, error) {
+	query := url.Values{}
+	query.Set("productKey", productKey)
+	product := &iot2.IotProductRespVO{}
+	if err := c.get("/admin-api/iot/product/get-by-key", query, product); err != nil {
+		return 0, err
+	}
+	return product.ID, nil
+}
+
+// get 调用管理端 GET 接口，解析统一返回结果中的 data
+func (c *adminClient) get(path string, query url.Values, data any) error {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: http status %d", path, resp.StatusCode)
+	}
+
+	result := struct {
+		Code int             `json:"code"`
+		Msg  string          `json:"msg"`
+		Data json.RawMessage `json:"data"`
+	}{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("GET %s: %w", path, err)
+	}
+	if result.Code != errors.SuccessCode {
+		return fmt.Errorf("GET %s: code=%d, msg=%s", path, result.Code, result.Msg)
+	}
+	return json.Unmarshal(result.Data, data)
+}
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/google/uuid"
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/gateway/codec"
+)
+
+// downstreamMethods 虚拟设备订阅的下行指令
+var downstreamMethods = []string{
+	consts.IotDeviceMessageMethodServiceInvoke,
+	consts.IotDeviceMessageMethodPropertySet,
+	consts.IotDeviceMessageMethodOtaUpgrade,
+	consts.IotDeviceMessageMethodShadowDelta,
+}
+
+// trackedReplyMethods 统计回复时延的上行消息，平台处理后回复 {method}_reply
+var trackedReplyMethods = []string{
+	consts.IotDeviceMessageMethodPropertyPost,
+	consts.IotDeviceMessageMethodEventPost,
+	consts.IotDeviceMessageMethodOtaProgress,
+	consts.IotDeviceMessageMethodOtaInform,
+}
+
+// virtualDevice 虚拟设备：按 Alink Topic 接入 MQTT Broker，定时上报属性与事件，并应答服务调用、属性设置与 OTA 升级
+type virtualDevice struct {
+	credential *deviceCredential
+	tsl        *iot2.IotThingModelTSLRespVO
+	config     *simulatorConfig
+	stats      *simulatorStats
+	codec      *codec.AlinkCodec
+	client     mqtt.Client
+
+	mu        sync.Mutex
+	generator *payloadGenerator
+	overrides map[string]any // 平台设置的属性值，上报时覆盖随机值
+	firmware  string         // 当前固件版本
+	upgrading bool           // 是否正在 OTA 升级
+
+	pending sync.Map // 等待平台回复的上行消息，key: requestId, value: 发送时间
+	online  atomic.Bool
+}
+
+func newVirtualDevice(credential *deviceCredential, tsl *iot2.IotThingModelTSLRespVO, config *simulatorConfig, stats *simulatorStats, seed int64) *virtualDevice {
+	return &virtualDevice{
+		credential: credential,
+		tsl:        tsl,
+		config:     config,
+		stats:      stats,
+		codec:      codec.NewAlinkCodec(),
+		generator:  newPayloadGenerator(seed),
+		overrides:  make(map[string]any),
+		firmware:   config.firmware,
+	}
+}
+
+// run 连接 Broker 并按周期上报，直到 ctx 取消
+func (d *virtualDevice) run(ctx context.Context) {
+	authUtils := core.NewDeviceAuthUtils()
+	opts := mqtt.NewClientOptions().
+		AddBroker(d.config.broker).
+		SetClientID(fmt.Sprintf("%s.%s", d.credential.ProductKey, d.credential.DeviceName)).
+		SetUsername(fmt.Sprintf("%s&%s", d.credential.ProductKey, d.credential.DeviceName)).
+		SetPassword(authUtils.BuildPassword(d.credential.DeviceSecret,
+			authUtils.BuildAuthContent(d.credential.DeviceName, d.credential.ProductKey))).
+		SetKeepAlive(60 * time.Second).
+		SetConnectTimeout(10 * time.Second).
+		SetAutoReconnect(true).
+		SetCleanSession(true).
+		// 下行指令的处理中会同步发布回复，不能阻塞 paho 的消息分发
+		SetOrderMatters(false).
+		SetOnConnectHandler(d.onConnect).
+		SetConnectionLostHandler(d.onConnectionLost)
+	d.client = mqtt.NewClient(opts)
+
+	token := d.client.Connect()
+	if token.Wait() && token.Error() != nil {
+		d.stats.connectFail.Add(1)
+		log.Printf("[Simulator] Device %s connect failed: %v", d.credential.DeviceName, token.Error())
+		return
+	}
+	defer func() {
+		if d.online.Swap(false) {
+			d.stats.connected.Add(-1)
+		}
+		d.client.Disconnect(250)
+	}()
+
+	// 随机错开首次上报，避免所有设备同时上报
+	if d.config.propertyInterval > 0 {
+		d.mu.Lock()
+		offset := time.Duration(d.generator.rnd.Int63n(int64(d.config.propertyInterval)))
+		d.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(offset):
+		}
+	}
+	propertyTicker := newTicker(d.config.propertyInterval)
+	defer stopTicker(propertyTicker)
+	eventTicker := newTicker(d.config.eventInterval)
+	defer stopTicker(eventTicker)
+	sweepTicker := time.NewTicker(d.config.replyTimeout)
+	defer sweepTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tickerC(propertyTicker):
+			d.postProperties(nil)
+		case <-tickerC(eventTicker):
+			d.postEvent()
+		case <-sweepTicker.C:
+			d.sweepPending()
+		}
+	}
+}
+
+// onConnect 连接（含重连）成功后订阅下行 Topic，并上报当前固件版本
+func (d *virtualDevice) onConnect(client mqtt.Client) {
+	filters := make(map[string]byte, len(downstreamMethods)+len(trackedReplyMethods))
+	for _, method := range downstreamMethods {
+		filters[d.topic(method)] = 1
+	}
+	for _, method := range trackedReplyMethods {
+		filters[d.topic(method+"_reply")] = 1
+	}
+	token := client.SubscribeMultiple(filters, d.onMessage)
+	if token.Wait() && token.Error() != nil {
+		log.Printf("[Simulator] Device %s subscribe failed: %v", d.credential.DeviceName, token.Error())
+	}
+	if !d.online.Swap(true) {
+		d.stats.connected.Add(1)
+	}
+
+	d.mu.Lock()
+	firmware := d.firmware
+	d.mu.Unlock()
+	if firmware != "" {
+		d.publish(consts.IotDeviceMessageMethodOtaInform, map[string]any{"version": firmware})
+	}
+}
+
+// onConnectionLost 连接断开，等待自动重连
+func (d *virtualDevice) onConnectionLost(_ mqtt.Client, err error) {
+	if d.online.Swap(false) {
+		d.stats.connected.Add(-1)
+	}
+	log.Printf("[Simulator] Device %s connection lost: %v", d.credential.DeviceName, err)
+}
+
+// onMessage 处理平台下行指令与上行消息的回复
+func (d *virtualDevice) onMessage(_ mqtt.Client, msg mqtt.Message) {
+	message, err := d.codec.Decode(msg.Payload())
+	if err != nil {
+		log.Printf("[Simulator] Device %s decode message failed: topic=%s, err=%v", d.credential.DeviceName, msg.Topic(), err)
+		return
+	}
+	if strings.HasSuffix(message.Method, "_reply") {
+		d.handleReply(message)
+		return
+	}
+
+	d.stats.downstream.Add(1)
+	switch message.Method {
+	case consts.IotDeviceMessageMethodServiceInvoke:
+		go d.handleServiceInvoke(message)
+	case consts.IotDeviceMessageMethodPropertySet:
+		d.handlePropertySet(message)
+	case consts.IotDeviceMessageMethodOtaUpgrade:
+		d.handleOtaUpgrade(message)
+	case consts.IotDeviceMessageMethodShadowDelta:
+		d.handleShadowDelta(message)
+	}
+}
+
+// handleReply 平台回复：按 requestId 计算时延
+func (d *virtualDevice) handleReply(message *core.IotDeviceMessage) {
+	value, ok := d.pending.LoadAndDelete(message.RequestID)
+	if !ok {
+		return
+	}
+	d.stats.replied.Add(1)
+	d.stats.observeLatency(time.Since(value.(time.Time)))
+	if message.Code != nil && *message.Code != 0 {
+		d.stats.replyError.Add(1)
+		if d.config.verbose {
+			log.Printf("[Simulator] Device %s %s failed: code=%d, msg=%s",
+				d.credential.DeviceName, message.Method, *message.Code, message.Msg)
+		}
+	}
+}
+
+// handleServiceInvoke 服务调用：模拟处理耗时后按物模型回复输出参数
+// 报文格式: {"identifier": "reboot", "inputParams": {...}}
+func (d *virtualDevice) handleServiceInvoke(message *core.IotDeviceMessage) {
+	identifier, _ := message.Params["identifier"].(string)
+	if d.config.serviceDelay > 0 {
+		time.Sleep(d.config.serviceDelay)
+	}
+	d.mu.Lock()
+	output := d.generator.serviceOutput(d.tsl, identifier)
+	d.mu.Unlock()
+	if d.config.verbose {
+		log.Printf("[Simulator] Device %s invoke service %s: input=%v, output=%v",
+			d.credential.DeviceName, identifier, message.Params["inputParams"], output)
+	}
+	d.reply(message, output)
+}
+
+// handlePropertySet 属性设置：保存设置值并回复，随后上报设置后的属性
+func (d *virtualDevice) handlePropertySet(message *core.IotDeviceMessage) {
+	d.applyProperties(message.Params)
+	d.reply(message, nil)
+	d.postProperties(message.Params)
+}
+
+// handleShadowDelta 影子差量：应用期望值后上报，平台据此清除已达成的期望值
+// 报文格式: {"state": {"temperature": 26}, "version": 3}
+func (d *virtualDevice) handleShadowDelta(message *core.IotDeviceMessage) {
+	state, _ := message.Params["state"].(map[string]any)
+	if len(state) == 0 {
+		return
+	}
+	d.applyProperties(state)
+	d.postProperties(state)
+}
+
+// handleOtaUpgrade OTA 升级：依次上报下载、校验、升级进度，成功后上报新固件版本
+// 报文格式: {"version": "1.0.1", "fileUrl": "...", "fileSize": 1024, ...}
+func (d *virtualDevice) handleOtaUpgrade(message *core.IotDeviceMessage) {
+	version, _ := message.Params["version"].(string)
+	if version == "" {
+		return
+	}
+	d.mu.Lock()
+	if d.upgrading {
+		d.mu.Unlock()
+		log.Printf("[Simulator] Device %s is upgrading, ignore upgrade to %s", d.credential.DeviceName, version)
+		return
+	}
+	d.upgrading = true
+	d.mu.Unlock()
+
+	go func() {
+		defer func() {
+			d.mu.Lock()
+			d.upgrading = false
+			d.mu.Unlock()
+		}()
+		progress := func(status, progress int, description string) {
+			d.publish(consts.IotDeviceMessageMethodOtaProgress, map[string]any{
+				"version":     version,
+				"status":      status,
+				"progress":    progress,
+				"description": description,
+			})
+			time.Sleep(d.config.otaStep)
+		}
+		for p := 0; p <= 100; p += 25 {
+			progress(consts.IotOtaRecordStatusDownloading, p, "downloading")
+		}
+		progress(consts.IotOtaRecordStatusVerifying, 100, "verifying")
+		progress(consts.IotOtaRecordStatusUpgrading, 100, "upgrading")
+		if d.config.otaFailRate > 0 && rand.Float64() < d.config.otaFailRate {
+			progress(consts.IotOtaRecordStatusFail, 100, "simulated upgrade failure")
+			return
+		}
+		progress(consts.IotOtaRecordStatusSuccess, 100, "success")
+
+		d.mu.Lock()
+		d.firmware = version
+		d.mu.Unlock()
+		d.publish(consts.IotDeviceMessageMethodOtaInform, map[string]any{"version": version})
+		log.Printf("[Simulator] Device %s upgraded to %s", d.credential.DeviceName, version)
+	}()
+}
+
+// applyProperties 保存平台设置的属性值，后续上报时保持该值
+func (d *virtualDevice) applyProperties(values map[string]any) {
+	d.mu.Lock()
+	for identifier, value := range values {
+		d.overrides[identifier] = value
+	}
+	d.mu.Unlock()
+}
+
+// postProperties 上报属性：params 为空时按物模型生成随机值（平台设置过的属性保持设置值）
+func (d *virtualDevice) postProperties(params map[string]any) {
+	if params == nil {
+		if d.tsl == nil || len(d.tsl.Properties) == 0 {
+			return
+		}
+		d.mu.Lock()
+		params = d.generator.properties(d.tsl)
+		for identifier, value := range d.overrides {
+			params[identifier] = value
+		}
+		d.mu.Unlock()
+	}
+	d.publish(consts.IotDeviceMessageMethodPropertyPost, params)
+}
+
+// postEvent 按物模型随机上报一个事件
+func (d *virtualDevice) postEvent() {
+	if d.tsl == nil {
+		return
+	}
+	d.mu.Lock()
+	params := d.generator.event(d.tsl)
+	d.mu.Unlock()
+	if params != nil {
+		d.publish(consts.IotDeviceMessageMethodEventPost, params)
+	}
+}
+
+// publish 发布上行消息，需要平台回复的消息登记发送时间用于统计时延
+func (d *virtualDevice) publish(method string, params map[string]any) {
+	requestID := strings.ReplaceAll(uuid.New().String(), "-", "")
+	payload, err := d.codec.Encode(&core.IotDeviceMessage{
+		RequestID: requestID,
+		Method:    method,
+		Params:    params,
+	})
+	if err != nil {
+		d.stats.publishFail.Add(1)
+		log.Printf("[Simulator] Device %s encode %s failed: %v", d.credential.DeviceName, method, err)
+		return
+	}
+
+	d.pending.Store(requestID, time.Now())
+	token := d.client.Publish(d.topic(method), 1, false, payload)
+	if token.Wait() && token.Error() != nil {
+		d.pending.Delete(requestID)
+		d.stats.publishFail.Add(1)
+		if d.config.verbose {
+			log.Printf("[Simulator] Device %s publish %s failed: %v", d.credential.DeviceName, method, token.Error())
+		}
+		return
+	}
+	d.stats.published.Add(1)
+}
+
+// reply 回复下行指令：{method}_reply，错误码 0
+func (d *virtualDevice) reply(request *core.IotDeviceMessage, data any) {
+	code := 0
+	method := request.Method + "_reply"
+	payload, err := d.codec.Encode(&core.IotDeviceMessage{
+		RequestID: request.RequestID,
+		Method:    method,
+		Data:      data,
+		Code:      &code,
+	})
+	if err != nil {
+		log.Printf("[Simulator] Device %s encode %s failed: %v", d.credential.DeviceName, method, err)
+		return
+	}
+	token := d.client.Publish(d.topic(method), 1, false, payload)
+	if token.Wait() && token.Error() != nil {
+		d.stats.publishFail.Add(1)
+		log.Printf("[Simulator] Device %s publish %s failed: %v", d.credential.DeviceName, method, token.Error())
+		return
+	}
+	d.stats.published.Add(1)
+}
+
+// sweepPending 清理超时未收到回复的上行消息
+func (d *virtualDevice) sweepPending() {
+	deadline := time.Now().Add(-d.config.replyTimeout)
+	d.pending.Range(func(key, value any) bool {
+		if value.(time.Time).Before(deadline) {
+			d.pending.Delete(key)
+			d.stats.replyTimeout.Add(1)
+		}
+		return true
+	})
+}
+
+// topic 构建设备 Topic：{TopicPrefix}/{productKey}/{deviceName}/ + method 中的 . 替换为 /
+func (d *virtualDevice) topic(method string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", d.config.topicPrefix, d.credential.ProductKey, d.credential.DeviceName,
+		strings.ReplaceAll(method, ".", "/"))
+}
+
+// newTicker 创建定时器，interval 不大于 0 时返回 nil（不触发）
+func newTicker(interval time.Duration) *time.Ticker {
+	if interval <= 0 {
+		return nil
+	}
+	return time.NewTicker(interval)
+}
+
+// tickerC 返回定时器通道，nil 定时器返回永不触发的通道
+func tickerC(ticker *time.Ticker) <-chan time.Time {
+	if ticker == nil {
+		return nil
+	}
+	return ticker.C
+}
+
+func stopTicker(ticker *time.Ticker) {
+	if ticker != nil {
+		ticker.Stop()
+	}
+}
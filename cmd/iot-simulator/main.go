@@ -0,0 +1,186 @@
+// iot-simulator IoT 设备模拟器
+//
+// 按 Alink Topic 接入 MQTT Broker（内置 Broker 或 EMQX），模拟 N 个虚拟设备：
+// 按各产品的物模型（TSL）定时上报随机属性与事件，应答服务调用、属性设置、影子差量与 OTA 升级，
+// 并周期性输出上下行吞吐与上行消息到平台回复的时延。
+//
+// 设备凭证来源（二选一）：
+//
+//	-file devices.json|devices.csv         从文件读取 productKey/deviceName/deviceSecret
+//	-api http://127.0.0.1:48080 -token xxx 从管理端分页拉取设备（可用 -product-id 过滤）
+//
+// 物模型来源：-tsl 指定的文件（get-tsl 接口返回的 TSL 对象或数组），未指定时从管理端获取。
+//
+// 示例：
+//
+//	go run ./cmd/iot-simulator -api http://127.0.0.1:48080 -token xxx -product-id 1 -count 100 -interval 5s
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+)
+
+// simulatorConfig 模拟器配置
+type simulatorConfig struct {
+	broker           string        // MQTT Broker 地址
+	topicPrefix      string        // Topic 前缀
+	propertyInterval time.Duration // 属性上报间隔，0 不上报
+	eventInterval    time.Duration // 事件上报间隔，0 不上报
+	serviceDelay     time.Duration // 服务调用模拟处理耗时
+	otaStep          time.Duration // OTA 每个进度步骤的耗时
+	otaFailRate      float64       // OTA 模拟失败的比例 [0, 1]
+	replyTimeout     time.Duration // 等待平台回复的超时时间
+	firmware         string        // 初始固件版本，上线时上报
+	verbose          bool          // 输出每条指令的处理日志
+}
+
+func main() {
+	config := &simulatorConfig{}
+	flag.StringVar(&config.broker, "broker", "tcp://127.0.0.1:1883", "MQTT broker address")
+	flag.StringVar(&config.topicPrefix, "topic-prefix", "/sys", "Alink topic prefix")
+	flag.DurationVar(&config.propertyInterval, "interval", 10*time.Second, "property report interval, 0 to disable")
+	flag.DurationVar(&config.eventInterval, "event-interval", time.Minute, "event report interval, 0 to disable")
+	flag.DurationVar(&config.serviceDelay, "service-delay", 100*time.Millisecond, "simulated service invocation processing time")
+	flag.DurationVar(&config.otaStep, "ota-step", time.Second, "duration of each OTA progress step")
+	flag.Float64Var(&config.otaFailRate, "ota-fail-rate", 0, "ratio of simulated OTA upgrade failures [0, 1]")
+	flag.DurationVar(&config.replyTimeout, "reply-timeout", 10*time.Second, "timeout waiting for platform replies")
+	flag.StringVar(&config.firmware, "firmware", "1.0.0", "initial firmware version reported on connect, empty to disable")
+	flag.BoolVar(&config.verbose, "v", false, "log every downstream command")
+	file := flag.String("file", "", "device credential file (.json or .csv)")
+	tslFile := flag.String("tsl", "", "thing model file (get-tsl response object or array)")
+	apiURL := flag.String("api", "", "admin API base url, e.g. http://127.0.0.1:48080")
+	token := flag.String("token", "", "admin API access token")
+	productID := flag.Int64("product-id", 0, "only simulate devices of this product (admin API mode)")
+	count := flag.Int("count", 0, "number of devices to simulate, 0 for all loaded credentials")
+	rampUp := flag.Duration("ramp-up", 10*time.Millisecond, "delay between device connections")
+	statsInterval := flag.Duration("stats-interval", 5*time.Second, "stats print interval")
+	flag.Parse()
+
+	if *file == "" && *apiURL == "" {
+		log.Fatal("[Simulator] either -file or -api is required")
+	}
+	if config.replyTimeout <= 0 {
+		config.replyTimeout = 10 * time.Second
+	}
+	var admin *adminClient
+	if *apiURL != "" {
+		admin = newAdminClient(*apiURL, *token)
+	}
+
+	// 1. 加载设备凭证
+	var credentials []*deviceCredential
+	var err error
+	if *file != "" {
+		credentials, err = loadCredentialsFromFile(*file)
+	} else {
+		credentials, err = admin.listCredentials(*productID, *count)
+	}
+	if err != nil {
+		log.Fatalf("[Simulator] Load device credentials failed: %v", err)
+	}
+	if *count > 0 && len(credentials) > *count {
+		credentials = credentials[:*count]
+	}
+	if len(credentials) == 0 {
+		log.Fatal("[Simulator] No device to simulate")
+	}
+
+	// 2. 加载物模型
+	tsls, err := loadTSLs(credentials, *tslFile, admin)
+	if err != nil {
+		log.Fatalf("[Simulator] Load thing models failed: %v", err)
+	}
+	log.Printf("[Simulator] Simulating %d devices of %d products, broker=%s", len(credentials), len(tsls), config.broker)
+
+	// 3. 启动虚拟设备
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	stats := &simulatorStats{}
+	var wg sync.WaitGroup
+	go func() {
+		for i, credential := range credentials {
+			if ctx.Err() != nil {
+				return
+			}
+			device := newVirtualDevice(credential, tsls[credential.ProductKey], config, stats, time.Now().UnixNano()+int64(i))
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				device.run(ctx)
+			}()
+			if *rampUp > 0 {
+				time.Sleep(*rampUp)
+			}
+		}
+	}()
+
+	// 4. 周期输出统计
+	ticker := time.NewTicker(*statsInterval)
+	defer ticker.Stop()
+	prev, last := stats.snapshot(), time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Printf("[Simulator] Stopping...")
+			wg.Wait()
+			line, _ := stats.report(prev, time.Since(last))
+			log.Printf("[Simulator] %s", line)
+			return
+		case now := <-ticker.C:
+			var line string
+			line, prev = stats.report(prev, now.Sub(last))
+			last = now
+			log.Printf("[Simulator] %s", line)
+		}
+	}
+}
+
+// loadTSLs 加载凭证涉及产品的物模型，key: productKey
+// 优先使用物模型文件；文件中没有的产品从管理端获取，均不可用时该产品的设备只应答下行指令
+func loadTSLs(credentials []*deviceCredential, tslFile string, admin *adminClient) (map[string]*iot2.IotThingModelTSLRespVO, error) {
+	tsls := make(map[string]*iot2.IotThingModelTSLRespVO)
+	if tslFile != "" {
+		loaded, err := loadTSLFromFile(tslFile)
+		if err != nil {
+			return nil, err
+		}
+		tsls = loaded
+	}
+
+	productIDs := make(map[string]int64)
+	for _, credential := range credentials {
+		if _, ok := tsls[credential.ProductKey]; !ok {
+			if _, ok := productIDs[credential.ProductKey]; !ok || credential.ProductID > 0 {
+				productIDs[credential.ProductKey] = credential.ProductID
+			}
+		}
+	}
+	for productKey, productID := range productIDs {
+		if admin == nil {
+			log.Printf("[Simulator] No thing model for product %s, its devices only answer downstream commands", productKey)
+			continue
+		}
+		if productID == 0 {
+			id, err := admin.getProductID(productKey)
+			if err != nil {
+				return nil, err
+			}
+			productID = id
+		}
+		tsl, err := admin.getTSL(productID)
+		if err != nil {
+			return nil, err
+		}
+		tsls[productKey] = tsl
+	}
+	return tsls, nil
+}
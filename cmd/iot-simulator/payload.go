@@ -0,0 +1,183 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/dto"
+)
+
+const (
+	// payloadDefaultMin / payloadDefaultMax 物模型未声明范围时数值的取值范围
+	payloadDefaultMin = 0
+	payloadDefaultMax = 100
+	// payloadDefaultArraySize 物模型未声明 size 时数组的元素个数
+	payloadDefaultArraySize = 3
+	// payloadMaxTextLength 随机文本的最大长度
+	payloadMaxTextLength = 16
+)
+
+// payloadGenerator 按物模型（TSL）生成随机的属性、事件与服务输出参数
+// 生成的值满足物模型的数据规范（数值范围、枚举值、文本长度、结构体成员、数组大小）
+type payloadGenerator struct {
+	rnd *rand.Rand
+}
+
+func newPayloadGenerator(seed int64) *payloadGenerator {
+	return &payloadGenerator{rnd: rand.New(rand.NewSource(seed))}
+}
+
+// properties 生成属性上报参数: {"temperature": 26.5, "switch": 1}
+func (g *payloadGenerator) properties(tsl *iot2.IotThingModelTSLRespVO) map[string]any {
+	params := make(map[string]any, len(tsl.Properties))
+	for _, property := range tsl.Properties {
+		params[property.Identifier] = g.value(property.DataType, property.DataSpecs, property.DataSpecsList)
+	}
+	return params
+}
+
+// event 随机选择一个事件，生成事件上报参数: {"identifier": "alarm", "value": {...}, "time": 1700000000000}
+// 产品未定义事件时返回 nil
+func (g *payloadGenerator) event(tsl *iot2.IotThingModelTSLRespVO) map[string]any {
+	if len(tsl.Events) == 0 {
+		return nil
+	}
+	event := tsl.Events[g.rnd.Intn(len(tsl.Events))]
+	return map[string]any{
+		"identifier": event.Identifier,
+		"value":      g.params(event.OutputParams),
+		"time":       time.Now().UnixMilli(),
+	}
+}
+
+// serviceOutput 生成服务调用的输出参数，服务未定义时返回空参数
+func (g *payloadGenerator) serviceOutput(tsl *iot2.IotThingModelTSLRespVO, identifier string) map[string]any {
+	if tsl == nil {
+		return map[string]any{}
+	}
+	for _, service := range tsl.Services {
+		if service.Identifier == identifier {
+			return g.params(service.OutputParams)
+		}
+	}
+	return map[string]any{}
+}
+
+// params 生成一组参数
+func (g *payloadGenerator) params(params []dto.ThingModelParam) map[string]any {
+	values := make(map[string]any, len(params))
+	for _, param := range params {
+		values[param.Identifier] = g.value(param.DataType, param.DataSpecs, param.DataSpecsList)
+	}
+	return values
+}
+
+// value 按数据类型与数据规范生成单个值
+func (g *payloadGenerator) value(dataType string, specs *dto.ThingModelDataSpecs, specsList []dto.ThingModelDataSpecs) any {
+	switch dataType {
+	case consts.IotDataSpecsDataTypeInt:
+		min, max := g.valueRange(specs)
+		low, high := int64(math.Ceil(min)), int64(math.Floor(max))
+		if high < low {
+			return low
+		}
+		return low + g.rnd.Int63n(high-low+1)
+	case consts.IotDataSpecsDataTypeFloat, consts.IotDataSpecsDataTypeDouble:
+		min, max := g.valueRange(specs)
+		return math.Round((min+g.rnd.Float64()*(max-min))*100) / 100
+	case consts.IotDataSpecsDataTypeBool, consts.IotDataSpecsDataTypeEnum:
+		var values []int
+		for _, item := range specsList {
+			if item.Value != nil {
+				values = append(values, item.Value.Value)
+			}
+		}
+		if len(values) == 0 {
+			return g.rnd.Intn(2)
+		}
+		return values[g.rnd.Intn(len(values))]
+	case consts.IotDataSpecsDataTypeText:
+		length := payloadMaxTextLength
+		if specs != nil && specs.MaxLength != nil && specs.MaxLength.Value > 0 && specs.MaxLength.Value < length {
+			length = specs.MaxLength.Value
+		}
+		return g.text(1 + g.rnd.Intn(length))
+	case consts.IotDataSpecsDataTypeDate:
+		return strconv.FormatInt(time.Now().UnixMilli(), 10)
+	case consts.IotDataSpecsDataTypeStruct:
+		return g.structValue(specsList)
+	case consts.IotDataSpecsDataTypeArray:
+		return g.arrayValue(specs)
+	default:
+		return nil
+	}
+}
+
+// valueRange 数值取值范围，未声明时使用默认范围
+func (g *payloadGenerator) valueRange(specs *dto.ThingModelDataSpecs) (float64, float64) {
+	min, max := float64(payloadDefaultMin), float64(payloadDefaultMax)
+	if specs != nil && specs.Min != nil {
+		min = specs.Min.Value
+		if specs.Max == nil {
+			max = min + payloadDefaultMax
+		}
+	}
+	if specs != nil && specs.Max != nil {
+		max = specs.Max.Value
+		if specs.Min == nil && max < min {
+			min = max - payloadDefaultMax
+		}
+	}
+	return min, max
+}
+
+// structValue 生成结构体，成员的数据规范可能声明在 dataSpecs 中，也可能直接平铺在成员上
+func (g *payloadGenerator) structValue(members []dto.ThingModelDataSpecs) map[string]any {
+	value := make(map[string]any, len(members))
+	for i := range members {
+		member := &members[i]
+		specs := member.DataSpecs
+		if specs == nil {
+			specs = member
+		}
+		dataType := member.ChildDataType
+		if dataType == "" && member.DataSpecs != nil {
+			dataType = member.DataSpecs.DataType
+		}
+		if dataType == "" {
+			dataType = member.DataType
+		}
+		value[member.Identifier] = g.value(dataType, specs, member.DataSpecsList)
+	}
+	return value
+}
+
+// arrayValue 生成数组，元素个数不超过 size
+func (g *payloadGenerator) arrayValue(specs *dto.ThingModelDataSpecs) []any {
+	if specs == nil {
+		return []any{}
+	}
+	size := payloadDefaultArraySize
+	if specs.Size != nil && specs.Size.Value > 0 && specs.Size.Value < size {
+		size = specs.Size.Value
+	}
+	items := make([]any, 0, size)
+	for i := 0; i < size; i++ {
+		items = append(items, g.value(specs.ChildDataType, nil, specs.DataSpecsList))
+	}
+	return items
+}
+
+// text 生成随机文本
+func (g *payloadGenerator) text(length int) string {
+	const letters = "abcdefghijklmnopqrstuvwxyz0123456789"
+	b := make([]byte, length)
+	for i := range b {
+		b[i] = letters[g.rnd.Intn(len(letters))]
+	}
+	return string(b)
+}
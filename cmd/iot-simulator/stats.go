@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// simulatorStats 模拟器运行统计：连接数、上下行吞吐、上行消息到平台回复的时延
+type simulatorStats struct {
+	connected    atomic.Int64 // 当前在线的虚拟设备数
+	connectFail  atomic.Int64 // 连接失败次数
+	published    atomic.Int64 // 上行消息数
+	publishFail  atomic.Int64 // 上行发布失败数
+	replied      atomic.Int64 // 收到的平台回复数
+	replyError   atomic.Int64 // 错误码非 0 的平台回复数
+	replyTimeout atomic.Int64 // 超时未收到平台回复数
+	downstream   atomic.Int64 // 收到的下行指令数
+
+	mu        sync.Mutex
+	latencies []time.Duration // 统计周期内的回复时延
+}
+
+// statsSnapshot 统计周期内的累计值
+type statsSnapshot struct {
+	published  int64
+	replied    int64
+	downstream int64
+}
+
+// observeLatency 记录一次上行消息到平台回复的时延
+func (s *simulatorStats) observeLatency(latency time.Duration) {
+	s.mu.Lock()
+	s.latencies = append(s.latencies, latency)
+	s.mu.Unlock()
+}
+
+// snapshot 当前累计值
+func (s *simulatorStats) snapshot() statsSnapshot {
+	return statsSnapshot{
+		published:  s.published.Load(),
+		replied:    s.replied.Load(),
+		downstream: s.downstream.Load(),
+	}
+}
+
+// report 输出统计周期内的吞吐与时延分位数，并清空周期内的时延样本
+func (s *simulatorStats) report(prev statsSnapshot, elapsed time.Duration) (string, statsSnapshot) {
+	s.mu.Lock()
+	latencies := s.latencies
+	s.latencies = nil
+	s.mu.Unlock()
+
+	cur := s.snapshot()
+	seconds := elapsed.Seconds()
+	if seconds <= 0 {
+		seconds = 1
+	}
+	line := fmt.Sprintf("online=%d connectFail=%d | up=%.1f/s down=%.1f/s reply=%.1f/s | total up=%d fail=%d reply=%d replyErr=%d replyTimeout=%d down=%d | latency %s",
+		s.connected.Load(), s.connectFail.Load(),
+		float64(cur.published-prev.published)/seconds,
+		float64(cur.downstream-prev.downstream)/seconds,
+		float64(cur.replied-prev.replied)/seconds,
+		cur.published, s.publishFail.Load(), cur.replied, s.replyError.Load(), s.replyTimeout.Load(), cur.downstream,
+		formatLatencies(latencies))
+	return line, cur
+}
+
+// formatLatencies 计算时延的平均值与分位数
+func formatLatencies(latencies []time.Duration) string {
+	if len(latencies) == 0 {
+		return "n/a"
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+	percentile := func(p float64) time.Duration {
+		return latencies[int(float64(len(latencies)-1)*p)]
+	}
+	return fmt.Sprintf("avg=%s p50=%s p95=%s p99=%s max=%s",
+		(total / time.Duration(len(latencies))).Round(time.Microsecond),
+		percentile(0.50).Round(time.Microsecond),
+		percentile(0.95).Round(time.Microsecond),
+		percentile(0.99).Round(time.Microsecond),
+		latencies[len(latencies)-1].Round(time.Microsecond))
+}
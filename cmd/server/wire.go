@@ -16,6 +16,7 @@ import (
 	tradeRepo "github.com/wxlbd/ruoyi-mall-go/internal/repo/trade"
 	"github.com/wxlbd/ruoyi-mall-go/internal/service/infra"
 	iotSvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+	iotJob "github.com/wxlbd/ruoyi-mall-go/internal/service/iot/job"
 	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay/job"
 	"github.com/wxlbd/ruoyi-mall-go/internal/service/system"
 
@@ -30,6 +31,7 @@ import (
 	paySvc "github.com/wxlbd/ruoyi-mall-go/internal/service/pay"
 	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client"
 	_ "github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client/alipay"
+	_ "github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client/mock"
 	_ "github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client/weixin"
 	payWalletSvc "github.com/wxlbd/ruoyi-mall-go/internal/service/pay/wallet"
 
@@ -142,6 +144,7 @@ func InitApp() (*gin.Engine, error) {
 		// Pay Repositories
 		payRepo.NewPayTransferRepository,
 		payRepo.NewPayNoRedisDAO,
+		payRepo.NewPayChannelRouteRedisDAO,
 		// Trade Repositories
 		tradeRepo.NewTradeNoRedisDAO,
 		// System Repositories
@@ -171,6 +174,11 @@ func InitApp() (*gin.Engine, error) {
 		job.NewPayOrderSyncJob,    // Added PayOrderSyncJob
 		job.NewPayOrderExpireJob,  // Added PayOrderExpireJob
 		job.NewPayRefundSyncJob,   // Added PayRefundSyncJob
+		job.NewPayReconcileJob,
+		job.NewPayProfitSharingJob,
+		iotJob.NewIotOtaUpgradeJob,
+		iotJob.NewIotDevicePropertyRollupJob,
+		iotJob.NewIotDevicePropertyPurgeJob,
 
 		// Promotion
 		promotionSvc.NewCouponService,
@@ -197,6 +205,7 @@ func InitApp() (*gin.Engine, error) {
 		// Casbin
 		permission.InitEnforcer,
 		middleware.NewCasbinMiddleware,
+		wire.Bind(new(iotSvc.PermissionChecker), new(*middleware.CasbinMiddleware)),
 
 		// Pay
 		paySvc.NewPayAppService,
@@ -205,6 +214,9 @@ func InitApp() (*gin.Engine, error) {
 		paySvc.NewPayRefundService,
 		paySvc.NewPayNotifyService,
 		paySvc.NewPayTransferService,
+		paySvc.NewPayReconcileService,
+		paySvc.NewPayProfitSharingService,
+		paySvc.NewPayChannelRouteService,
 		client.NewPayClientFactory,
 
 		deliveryClient.NewExpressClientFactory, // Added ExpressClientFactory
@@ -294,6 +306,11 @@ func ProvideJobHandlers(
 	h3 *job.PayOrderSyncJob,
 	h4 *job.PayOrderExpireJob,
 	h5 *job.PayRefundSyncJob,
+	h6 *iotJob.IotOtaUpgradeJob,
+	h7 *iotJob.IotDevicePropertyRollupJob,
+	h8 *iotJob.IotDevicePropertyPurgeJob,
+	h9 *job.PayReconcileJob,
+	h10 *job.PayProfitSharingJob,
 ) []infra.JobHandler {
-	return []infra.JobHandler{h1, h2, h3, h4, h5}
+	return []infra.JobHandler{h1, h2, h3, h4, h5, h6, h7, h8, h9, h10}
 }
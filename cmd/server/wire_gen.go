@@ -32,6 +32,8 @@ import (
 	system3 "github.com/wxlbd/ruoyi-mall-go/internal/api/handler/app/system"
 	"github.com/wxlbd/ruoyi-mall-go/internal/api/router"
 	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/gateway/codec"
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/sink"
 	"github.com/wxlbd/ruoyi-mall-go/internal/middleware"
 	"github.com/wxlbd/ruoyi-mall-go/internal/pkg/permission"
 	"github.com/wxlbd/ruoyi-mall-go/internal/pkg/websocket"
@@ -42,6 +44,7 @@ import (
 	trade2 "github.com/wxlbd/ruoyi-mall-go/internal/repo/trade"
 	infra2 "github.com/wxlbd/ruoyi-mall-go/internal/service/infra"
 	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+	job2 "github.com/wxlbd/ruoyi-mall-go/internal/service/iot/job"
 	"github.com/wxlbd/ruoyi-mall-go/internal/service/mall/product"
 	"github.com/wxlbd/ruoyi-mall-go/internal/service/mall/promotion"
 	"github.com/wxlbd/ruoyi-mall-go/internal/service/mall/trade"
@@ -61,6 +64,7 @@ import (
 
 import (
 	_ "github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client/alipay"
+	_ "github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client/mock"
 	_ "github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client/weixin"
 )
 
@@ -90,44 +94,45 @@ func InitApp() (*gin.Engine, error) {
 	payTransferService := pay2.NewPayTransferService(payTransferRepository, payAppService, payChannelService, payNotifyService, payClientFactory, payNoRedisDAO, zapLogger)
 	payTransferSyncJob := job.NewPayTransferSyncJob(payTransferService, zapLogger)
 	payNotifyJob := job.NewPayNotifyJob(payNotifyService)
-	payOrderService := pay2.NewPayOrderService(query, payAppService, payChannelService, payClientFactory, payNotifyService, payNoRedisDAO)
+	payChannelRouteRedisDAO := pay.NewPayChannelRouteRedisDAO(redisClient)
+	payChannelRouteService := pay2.NewPayChannelRouteService(query, payAppService, payChannelService, payChannelRouteRedisDAO)
+	payOrderService := pay2.NewPayOrderService(query, payAppService, payChannelService, payClientFactory, payNotifyService, payNoRedisDAO, payChannelRouteService)
 	payOrderSyncJob := job.NewPayOrderSyncJob(payOrderService)
 	payOrderExpireJob := job.NewPayOrderExpireJob(payOrderService)
 	payRefundService := pay2.NewPayRefundService(query, payAppService, payChannelService, payOrderService, payNotifyService, payNoRedisDAO)
 	payRefundSyncJob := job.NewPayRefundSyncJob(payRefundService)
-	v := ProvideJobHandlers(payTransferSyncJob, payNotifyJob, payOrderSyncJob, payOrderExpireJob, payRefundSyncJob)
-	scheduler, err := infra2.NewScheduler(query, zapLogger, v)
-	if err != nil {
-		return nil, err
-	}
-	jobService := infra2.NewJobService(query, scheduler)
-	jobHandler := infra.NewJobHandler(jobService)
-	jobLogService := infra2.NewJobLogService(query)
-	jobLogHandler := infra.NewJobLogHandler(jobLogService)
-	manager := websocket.NewManager()
-	webSocketHandler := infra.NewWebSocketHandler(manager, zapLogger)
-	handlers := infra.NewHandlers(configHandler, fileConfigHandler, fileHandler, apiAccessLogHandler, apiErrorLogHandler, jobHandler, jobLogHandler, webSocketHandler)
+	payReconcileService := pay2.NewPayReconcileService(query, payAppService, payChannelService, payOrderService, payRefundService)
+	payReconcileJob := job.NewPayReconcileJob(payReconcileService)
+	payProfitSharingService := pay2.NewPayProfitSharingService(query, payAppService, payChannelService, payNoRedisDAO)
+	payProfitSharingJob := job.NewPayProfitSharingJob(payProfitSharingService)
 	productRepository := iot.NewProductRepository(query)
 	deviceRepository := iot.NewDeviceRepository(query)
-	productService := iot2.NewProductService(productRepository, deviceRepository)
+	codecRegistry := codec.DefaultRegistry()
+	productService := iot2.NewProductService(productRepository, deviceRepository, codecRegistry)
 	productCategoryRepository := iot.NewProductCategoryRepository(query)
 	productCategoryService := iot2.NewProductCategoryService(productCategoryRepository)
 	productHandler := iot3.NewProductHandler(productService, productCategoryService)
+	deviceRegisterLogRepository := iot.NewDeviceRegisterLogRepository(query)
 	deviceAuthUtils := core.NewDeviceAuthUtils()
-	deviceService := iot2.NewDeviceService(productRepository, deviceRepository, deviceAuthUtils)
+	deviceCertificateRepository := iot.NewDeviceCertificateRepository(query)
+	deviceCA, err := core.ProvideDeviceCA()
+	if err != nil {
+		return nil, err
+	}
+	deviceGroupRepository := iot.NewDeviceGroupRepository(query)
+	deviceService := iot2.NewDeviceService(productRepository, deviceRepository, deviceRegisterLogRepository, deviceCertificateRepository, deviceGroupRepository, deviceAuthUtils, deviceCA)
 	deviceHandler := iot3.NewDeviceHandler(deviceService)
 	thingModelRepository := iot.NewThingModelRepository(query)
-	thingModelService := iot2.NewThingModelService(productRepository, thingModelRepository)
+	thingModelVersionRepository := iot.NewThingModelVersionRepository(query)
+	thingModelService := iot2.NewThingModelService(productRepository, thingModelRepository, thingModelVersionRepository)
 	thingModelHandler := iot3.NewThingModelHandler(thingModelService)
-	deviceGroupRepository := iot.NewDeviceGroupRepository(query)
 	deviceGroupService := iot2.NewDeviceGroupService(deviceGroupRepository)
-	deviceGroupHandler := iot3.NewDeviceGroupHandler(deviceGroupService)
 	otaFirmwareRepository := iot.NewOtaFirmwareRepository(query)
 	otaFirmwareService := iot2.NewOtaFirmwareService(otaFirmwareRepository)
 	otaFirmwareHandler := iot3.NewOtaFirmwareHandler(otaFirmwareService, productService)
 	otaTaskRepository := iot.NewOtaTaskRepository(query)
 	otaTaskRecordRepository := iot.NewOtaTaskRecordRepository(query)
-	otaTaskService := iot2.NewOtaTaskService(otaFirmwareRepository, otaTaskRepository, otaTaskRecordRepository, deviceService)
+	otaTaskService := iot2.NewOtaTaskService(otaFirmwareRepository, otaTaskRepository, otaTaskRecordRepository, deviceService, fileService)
 	otaTaskHandler := iot3.NewOtaTaskHandler(otaTaskService)
 	alertConfigRepository := iot.NewAlertConfigRepository(query)
 	alertConfigService := iot2.NewAlertConfigService(alertConfigRepository)
@@ -139,23 +144,77 @@ func InitApp() (*gin.Engine, error) {
 	dataSinkService := iot2.NewDataSinkService(dataSinkRepository)
 	dataSinkHandler := iot3.NewDataSinkHandler(dataSinkService)
 	dataRuleRepository := iot.NewDataRuleRepository(query)
-	dataRuleService := iot2.NewDataRuleService(dataRuleRepository)
+	dataSinkDeadLetterRepository := iot.NewDataSinkDeadLetterRepository(query)
+	dataSinkRegistry := sink.DefaultRegistry(redisClient)
+	dataRuleService := iot2.NewDataRuleService(dataRuleRepository, deviceRepository, dataSinkDeadLetterRepository, thingModelService, dataSinkService, dataSinkRegistry)
 	dataRuleHandler := iot3.NewDataRuleHandler(dataRuleService)
-	sceneRuleRepository := iot.NewSceneRuleRepository(query)
-	sceneRuleService := iot2.NewSceneRuleService(sceneRuleRepository)
-	sceneRuleHandler := iot3.NewSceneRuleHandler(sceneRuleService)
 	productCategoryHandler := iot3.NewProductCategoryHandler(productCategoryService)
 	deviceMessageRepository := iot.NewDeviceMessageRepository(query)
 	statisticsService := iot2.NewStatisticsService(productCategoryRepository, productRepository, deviceRepository, deviceMessageRepository)
 	statisticsHandler := iot3.NewStatisticsHandler(statisticsService)
 	devicePropertyRepository := iot.NewDevicePropertyRepository(query)
 	devicePropertyService := iot2.NewDevicePropertyService(devicePropertyRepository)
-	localMessageBusConfig := core.ProvideLocalMessageBusConfig()
-	localMessageBus := core.NewLocalMessageBus(localMessageBusConfig)
-	deviceMessageService := iot2.NewDeviceMessageService(deviceMessageRepository, deviceRepository, devicePropertyService, otaTaskService, localMessageBus)
+	messageBus := core.ProvideMessageBus(redisClient)
+	geofenceRepository := iot.NewGeofenceRepository(query)
+	geofenceService := iot2.NewGeofenceService(geofenceRepository)
+	deviceLocationRepository := iot.NewDeviceLocationRepository(query)
+	deviceLocationService := iot2.NewDeviceLocationService(deviceLocationRepository, deviceRepository, geofenceService, messageBus)
+	deviceShadowRepository := iot.NewDeviceShadowRepository(query)
+	deviceShadowService := iot2.NewDeviceShadowService(deviceShadowRepository, thingModelService)
+	manager := websocket.NewManager()
+	roleService := system.NewRoleService(query)
+	permissionService := system.NewPermissionService(query, roleService)
+	enforcer, err := permission.InitEnforcer(db)
+	if err != nil {
+		return nil, err
+	}
+	casbinMiddleware := middleware.NewCasbinMiddleware(enforcer, permissionService)
+	deviceMessageStreamService := iot2.NewDeviceMessageStreamService(manager, casbinMiddleware, messageBus)
+	deviceMessageService := iot2.NewDeviceMessageService(deviceMessageRepository, deviceRepository, deviceService, devicePropertyService, thingModelService, deviceShadowService, otaTaskService, deviceLocationService, deviceMessageStreamService, messageBus)
 	deviceMessageHandler := iot3.NewDeviceMessageHandler(deviceMessageService)
-	devicePropertyHandler := iot3.NewDevicePropertyHandler(devicePropertyService, deviceService, thingModelService)
-	iotHandlers := iot3.NewHandlers(productHandler, deviceHandler, thingModelHandler, deviceGroupHandler, otaFirmwareHandler, otaTaskHandler, alertConfigHandler, alertRecordHandler, dataSinkHandler, dataRuleHandler, sceneRuleHandler, productCategoryHandler, statisticsHandler, deviceMessageHandler, devicePropertyHandler)
+	deviceGroupBatchService := iot2.NewDeviceGroupBatchService(deviceGroupRepository, deviceRepository, deviceMessageService)
+	deviceGroupHandler := iot3.NewDeviceGroupHandler(deviceGroupService, deviceGroupBatchService)
+	iotOtaUpgradeJob := job2.NewIotOtaUpgradeJob(otaTaskService, deviceMessageService)
+	devicePropertyRollupRepository := iot.NewDevicePropertyRollupRepository(query)
+	devicePropertyRollupService := iot2.NewDevicePropertyRollupService(devicePropertyRepository, devicePropertyRollupRepository, productRepository, deviceRepository, thingModelService)
+	iotDevicePropertyRollupJob := job2.NewIotDevicePropertyRollupJob(devicePropertyRollupService)
+	iotDevicePropertyPurgeJob := job2.NewIotDevicePropertyPurgeJob(devicePropertyRollupService)
+	v := ProvideJobHandlers(payTransferSyncJob, payNotifyJob, payOrderSyncJob, payOrderExpireJob, payRefundSyncJob, iotOtaUpgradeJob, iotDevicePropertyRollupJob, iotDevicePropertyPurgeJob, payReconcileJob, payProfitSharingJob)
+	scheduler, err := infra2.NewScheduler(query, zapLogger, v)
+	if err != nil {
+		return nil, err
+	}
+	jobService := infra2.NewJobService(query, scheduler)
+	jobHandler := infra.NewJobHandler(jobService)
+	jobLogService := infra2.NewJobLogService(query)
+	jobLogHandler := infra.NewJobLogHandler(jobLogService)
+	webSocketHandler := infra.NewWebSocketHandler(manager, zapLogger)
+	handlers := infra.NewHandlers(configHandler, fileConfigHandler, fileHandler, apiAccessLogHandler, apiErrorLogHandler, jobHandler, jobLogHandler, webSocketHandler)
+	sceneRuleRepository := iot.NewSceneRuleRepository(query)
+	devicePropertySetSceneRuleAction := iot2.NewDevicePropertySetSceneRuleAction(deviceService, deviceMessageService)
+	deviceServiceInvokeSceneRuleAction := iot2.NewDeviceServiceInvokeSceneRuleAction(deviceService, deviceMessageService)
+	deviceShadowDesiredSceneRuleAction := iot2.NewDeviceShadowDesiredSceneRuleAction(deviceService, deviceShadowService, deviceMessageService)
+	smsTemplateService := system.NewSmsTemplateService(query)
+	smsLogService := system.NewSmsLogService(query)
+	smsClientFactory := system.NewSmsClientFactory()
+	smsSendService := system.NewSmsSendService(query, smsTemplateService, smsLogService, smsClientFactory)
+	deptService := system.NewDeptService(query)
+	userService := system.NewUserService(query, deptService)
+	mailService := system.NewMailService(db)
+	notifyTemplateRepositoryImpl := repo.NewNotifyTemplateRepository(query)
+	notifyMessageRepositoryImpl := repo.NewNotifyMessageRepository(query)
+	notifyService := system.NewNotifyService(notifyTemplateRepositoryImpl, notifyMessageRepositoryImpl)
+	alertNotifyService := iot2.NewAlertNotifyService(redisClient, notifyService, mailService, smsSendService, userService)
+	alertTriggerSceneRuleAction := iot2.NewAlertTriggerSceneRuleAction(alertConfigService, alertRecordService, alertNotifyService)
+	alertRecoverSceneRuleAction := iot2.NewAlertRecoverSceneRuleAction(alertRecordService, alertNotifyService)
+	v2 := iot2.ProvideSceneRuleActions(devicePropertySetSceneRuleAction, deviceServiceInvokeSceneRuleAction, deviceShadowDesiredSceneRuleAction, alertTriggerSceneRuleAction, alertRecoverSceneRuleAction)
+	sceneRuleService := iot2.NewSceneRuleService(sceneRuleRepository, deviceRepository, deviceMessageRepository, devicePropertyService, thingModelService, messageBus, v2)
+	sceneRuleHandler := iot3.NewSceneRuleHandler(sceneRuleService)
+	devicePropertyHandler := iot3.NewDevicePropertyHandler(devicePropertyService, devicePropertyRollupService, deviceService, thingModelService)
+	deviceShadowHandler := iot3.NewDeviceShadowHandler(deviceShadowService, deviceService, deviceMessageService)
+	geofenceHandler := iot3.NewGeofenceHandler(geofenceService)
+	deviceLocationHandler := iot3.NewDeviceLocationHandler(deviceLocationService)
+	iotHandlers := iot3.NewHandlers(productHandler, deviceHandler, thingModelHandler, deviceGroupHandler, otaFirmwareHandler, otaTaskHandler, alertConfigHandler, alertRecordHandler, dataSinkHandler, dataRuleHandler, sceneRuleHandler, productCategoryHandler, statisticsHandler, deviceMessageHandler, devicePropertyHandler, deviceShadowHandler, geofenceHandler, deviceLocationHandler)
 	productBrandService := product.NewProductBrandService(query)
 	productBrandHandler := product2.NewProductBrandHandler(productBrandService)
 	productPropertyValueService := product.NewProductPropertyValueService(query)
@@ -183,10 +242,6 @@ func InitApp() (*gin.Engine, error) {
 	bargainRecordService := promotion.NewBargainRecordService(query)
 	bargainHelpService := promotion.NewBargainHelpService(query)
 	bargainActivityHandler := promotion2.NewBargainActivityHandler(bargainActivityService, bargainRecordService, bargainHelpService, productSpuService)
-	smsTemplateService := system.NewSmsTemplateService(query)
-	smsLogService := system.NewSmsLogService(query)
-	smsClientFactory := system.NewSmsClientFactory()
-	smsSendService := system.NewSmsSendService(query, smsTemplateService, smsLogService, smsClientFactory)
 	smsCodeService := system.NewSmsCodeService(query, redisClient, smsSendService)
 	memberLevelService := member.NewMemberLevelService(query)
 	socialUserService := system.NewSocialUserService(query)
@@ -214,8 +269,8 @@ func InitApp() (*gin.Engine, error) {
 	seckillConfigService := promotion.NewSeckillConfigService(query)
 	seckillActivityService := promotion.NewSeckillActivityService(query, seckillConfigService, productSpuService, productSkuService)
 	seckillActivityPriceCalculator := calculators.NewSeckillActivityPriceCalculator(seckillActivityService, priceCalculatorHelper, zapLogger)
-	v2 := ProvidePriceCalculators(bargainActivityPriceCalculator, combinationActivityPriceCalculator, couponPriceCalculator, deliveryPriceCalculator, discountActivityPriceCalculator, pointActivityPriceCalculator, pointGivePriceCalculator, pointUsePriceCalculator, rewardActivityPriceCalculator, seckillActivityPriceCalculator)
-	tradePriceService := trade.NewTradePriceService(v2, priceCalculatorHelper, productSkuService, productSpuService, rewardActivityService, discountActivityPriceCalculator, discountActivityService, memberUserService, memberLevelService, zapLogger)
+	v3 := ProvidePriceCalculators(bargainActivityPriceCalculator, combinationActivityPriceCalculator, couponPriceCalculator, deliveryPriceCalculator, discountActivityPriceCalculator, pointActivityPriceCalculator, pointGivePriceCalculator, pointUsePriceCalculator, rewardActivityPriceCalculator, seckillActivityPriceCalculator)
+	tradePriceService := trade.NewTradePriceService(v3, priceCalculatorHelper, productSkuService, productSpuService, rewardActivityService, discountActivityPriceCalculator, discountActivityService, memberUserService, memberLevelService, zapLogger)
 	cartService := trade.NewCartService(query, productSkuService, productSpuService)
 	tradeConfigService := trade.NewTradeConfigService(query)
 	tradeOrderLogRepository := repo.NewTradeOrderLogRepository(query)
@@ -233,8 +288,6 @@ func InitApp() (*gin.Engine, error) {
 	diyPageService := promotion.NewDiyPageService(query, diyTemplateService)
 	diyPageHandler := promotion2.NewDiyPageHandler(diyPageService)
 	diyTemplateHandler := promotion2.NewDiyTemplateHandler(diyTemplateService)
-	deptService := system.NewDeptService(query)
-	userService := system.NewUserService(query, deptService)
 	kefuService := promotion.NewKefuService(query, memberUserService, userService, manager)
 	kefuHandler := promotion2.NewKefuHandler(kefuService)
 	pointActivityService := promotion.NewPointActivityService(query, productSpuService, productSkuService)
@@ -287,6 +340,8 @@ func InitApp() (*gin.Engine, error) {
 	payOrderHandler := pay3.NewPayOrderHandler(payOrderService, payAppService, payWalletService)
 	payRefundHandler := pay3.NewPayRefundHandler(payRefundService, payAppService, payOrderService)
 	payTransferHandler := pay3.NewPayTransferHandler(payTransferService)
+	payReconcileHandler := pay3.NewPayReconcileHandler(payReconcileService, payAppService)
+	payProfitSharingHandler := pay3.NewPayProfitSharingHandler(payProfitSharingService, payAppService)
 	payWalletRechargePackageService := wallet.NewPayWalletRechargePackageService(query)
 	payWalletRechargeService := wallet.NewPayWalletRechargeService(query, payWalletService, payWalletTransactionService, payWalletRechargePackageService, payOrderService, payRefundService, payNotifyService, payChannelService)
 	payWalletRechargeHandler := wallet2.NewPayWalletRechargeHandler(payWalletRechargeService)
@@ -294,7 +349,8 @@ func InitApp() (*gin.Engine, error) {
 	payWalletTransactionHandler := wallet2.NewPayWalletTransactionHandler(payWalletTransactionService)
 	payWalletHandler := wallet2.NewPayWalletHandler(payWalletService)
 	walletHandlers := wallet2.NewHandlers(payWalletRechargeHandler, payWalletRechargePackageHandler, payWalletTransactionHandler, payWalletHandler)
-	payHandlers := pay3.NewHandlers(payAppHandler, payChannelHandler, payNotifyHandler, payOrderHandler, payRefundHandler, payTransferHandler, walletHandlers)
+	payChannelRouteHandler := pay3.NewPayChannelRouteHandler(payChannelRouteService, payAppService, payChannelService)
+	payHandlers := pay3.NewHandlers(payAppHandler, payChannelHandler, payNotifyHandler, payOrderHandler, payRefundHandler, payTransferHandler, payReconcileHandler, payProfitSharingHandler, payChannelRouteHandler, walletHandlers)
 	memberStatisticsRepositoryImpl := repo.NewMemberStatisticsRepository(query, db)
 	memberStatisticsService := member.NewMemberStatisticsService(memberStatisticsRepositoryImpl)
 	tradeOrderStatisticsRepositoryImpl := repo.NewTradeOrderStatisticsRepository(query)
@@ -317,8 +373,6 @@ func InitApp() (*gin.Engine, error) {
 	tradeStatisticsHandler := statistics.NewTradeStatisticsHandler(tradeStatisticsService, tradeOrderStatisticsService, afterSaleStatisticsService, brokerageStatisticsService)
 	statisticsHandlers := statistics.NewHandlers(memberStatisticsHandler, payStatisticsHandler, productStatisticsHandler, tradeStatisticsHandler)
 	areaHandler := system2.NewAreaHandler()
-	roleService := system.NewRoleService(query)
-	permissionService := system.NewPermissionService(query, roleService)
 	menuService := system.NewMenuService(query)
 	oAuth2TokenService := system.NewOAuth2TokenService()
 	loginLogService := system.NewLoginLogService(query)
@@ -330,14 +384,10 @@ func InitApp() (*gin.Engine, error) {
 	dictService := system.NewDictService(query)
 	dictHandler := system2.NewDictHandler(dictService)
 	loginLogHandler := system2.NewLoginLogHandler(loginLogService)
-	mailService := system.NewMailService(db)
 	mailHandler := system2.NewMailHandler(mailService)
 	menuHandler := system2.NewMenuHandler(menuService)
 	noticeService := system.NewNoticeService(query)
 	noticeHandler := system2.NewNoticeHandler(noticeService, webSocketHandler)
-	notifyTemplateRepositoryImpl := repo.NewNotifyTemplateRepository(query)
-	notifyMessageRepositoryImpl := repo.NewNotifyMessageRepository(query)
-	notifyService := system.NewNotifyService(notifyTemplateRepositoryImpl, notifyMessageRepositoryImpl)
 	notifyHandler := system2.NewNotifyHandler(notifyService)
 	oAuth2ClientService := system.NewOAuth2ClientService(db)
 	oAuth2ClientHandler := system2.NewOAuth2ClientHandler(oAuth2ClientService)
@@ -426,11 +476,6 @@ func InitApp() (*gin.Engine, error) {
 		Pay:    handlers8,
 		System: handlers9,
 	}
-	enforcer, err := permission.InitEnforcer(db)
-	if err != nil {
-		return nil, err
-	}
-	casbinMiddleware := middleware.NewCasbinMiddleware(enforcer, permissionService)
 	engine := router.InitRouter(db, redisClient, adminHandlers, appHandlers, casbinMiddleware)
 	return engine, nil
 }
@@ -471,6 +516,11 @@ func ProvideJobHandlers(
 	h3 *job.PayOrderSyncJob,
 	h4 *job.PayOrderExpireJob,
 	h5 *job.PayRefundSyncJob,
+	h6 *job2.IotOtaUpgradeJob,
+	h7 *job2.IotDevicePropertyRollupJob,
+	h8 *job2.IotDevicePropertyPurgeJob,
+	h9 *job.PayReconcileJob,
+	h10 *job.PayProfitSharingJob,
 ) []infra2.JobHandler {
-	return []infra2.JobHandler{h1, h2, h3, h4, h5}
+	return []infra2.JobHandler{h1, h2, h3, h4, h5, h6, h7, h8, h9, h10}
 }
@@ -23,6 +23,11 @@ type IotProductSaveReqVO struct {
 	NetType      int8   `json:"netType"`
 	LocationType int8   `json:"locationType"`
 	CodecType    string `json:"codecType" binding:"required"`
+	CodecConfig  string `json:"codecConfig"` // 编解码配置，可配置编解码器（如 Binary）必填
+	// PropertyRetentionDays 属性原始数据保留天数，0 表示永久保留
+	PropertyRetentionDays int `json:"propertyRetentionDays" binding:"min=0"`
+	// RegisterEnabled 是否允许设备动态注册（一型一密）
+	RegisterEnabled bool `json:"registerEnabled"`
 }
 
 // IotProductRespVO 产品响应信息
@@ -40,7 +45,12 @@ type IotProductRespVO struct {
 	NetType      int8      `json:"netType"`
 	LocationType int8      `json:"locationType"`
 	CodecType    string    `json:"codecType"`
+	CodecConfig  string    `json:"codecConfig"`
 	CreateTime   time.Time `json:"createTime"`
+
+	PropertyRetentionDays int    `json:"propertyRetentionDays"`
+	ProductSecret         string `json:"productSecret"`
+	RegisterEnabled       bool   `json:"registerEnabled"`
 }
 
 // IotProductPageReqVO 产品分页请求
@@ -51,6 +61,26 @@ type IotProductPageReqVO struct {
 	ProductKey string `form:"productKey"`
 }
 
+// IotProductCodecDebugReqVO 编解码调试请求
+// 未传 codecType 时使用产品当前的编解码配置
+type IotProductCodecDebugReqVO struct {
+	ProductID   int64          `json:"productId"`
+	CodecType   string         `json:"codecType"`
+	CodecConfig string         `json:"codecConfig"`
+	Direction   string         `json:"direction" binding:"required,oneof=decode encode"` // decode: 十六进制报文 -> 消息；encode: 消息 -> 十六进制报文
+	Payload     string         `json:"payload"`                                          // 十六进制报文，decode 时必填
+	Method      string         `json:"method"`                                           // 消息方法，encode 时使用
+	Params      map[string]any `json:"params"`                                           // 消息参数，encode 时使用
+}
+
+// IotProductCodecDebugRespVO 编解码调试响应
+type IotProductCodecDebugRespVO struct {
+	CodecType string         `json:"codecType"`
+	Payload   string         `json:"payload"` // 十六进制报文
+	Method    string         `json:"method"`
+	Params    map[string]any `json:"params"`
+}
+
 // ================= Iot Device =================
 
 // IotDeviceSaveReqVO 设备保存请求
@@ -63,6 +93,7 @@ type IotDeviceSaveReqVO struct {
 	GroupIDs     []int64          `json:"groupIds"`
 	ProductID    int64            `json:"productId"`
 	GatewayID    int64            `json:"gatewayId"`
+	AuthType     string           `json:"authType"` // 认证类型：secret（默认）、x509
 	Config       string           `json:"config"`
 	LocationType int8             `json:"locationType" binding:"required"`
 	Latitude     *decimal.Decimal `json:"latitude"`
@@ -113,12 +144,20 @@ type IotDeviceUpdateGroupReqVO struct {
 }
 
 // IotDeviceAuthInfoRespVO 设备认证信息响应
+// X.509 认证的设备额外返回客户端证书、私钥与 CA 证书（PEM），用于双向 TLS 接入
 type IotDeviceAuthInfoRespVO struct {
-	ProductKey   string `json:"productKey"`
-	DeviceName   string `json:"deviceName"`
-	DeviceSecret string `json:"deviceSecret"`
-	MqttHost     string `json:"mqttHost"`
-	MqttPort     int    `json:"mqttPort"`
+	ProductKey           string     `json:"productKey"`
+	DeviceName           string     `json:"deviceName"`
+	DeviceSecret         string     `json:"deviceSecret"`
+	MqttHost             string     `json:"mqttHost"`
+	MqttPort             int        `json:"mqttPort"`
+	AuthType             string     `json:"authType"`
+	MqttTlsPort          int        `json:"mqttTlsPort,omitempty"`
+	Certificate          string     `json:"certificate,omitempty"`
+	PrivateKey           string     `json:"privateKey,omitempty"`
+	CaCertificate        string     `json:"caCertificate,omitempty"`
+	CertificateSerial    string     `json:"certificateSerial,omitempty"`
+	CertificateExpiresAt *time.Time `json:"certificateExpiresAt,omitempty"`
 }
 
 // IotDeviceByProductKeyAndNamesReqVO 根据产品Key和设备名称查询请求
@@ -128,19 +167,59 @@ type IotDeviceByProductKeyAndNamesReqVO struct {
 }
 
 // IotDeviceImportExcelVO 设备 Excel 导入 VO
+// 分组填写分组名称，多个以逗号分隔；经纬度需同时填写，填写后设备定位方式为手动定位
 type IotDeviceImportExcelVO struct {
-	DeviceName       string `json:"deviceName"`
-	ParentDeviceName string `json:"parentDeviceName"`
-	ProductKey       string `json:"productKey"`
-	GroupNames       string `json:"groupNames"`
-	LocationType     int8   `json:"locationType"`
+	ProductKey   string `json:"productKey" label:"产品标识"`
+	DeviceName   string `json:"deviceName" label:"设备名称"`
+	Nickname     string `json:"nickname" label:"备注名称"`
+	SerialNumber string `json:"serialNumber" label:"设备序列号"`
+	GroupNames   string `json:"groupNames" label:"设备分组"`
+	Latitude     string `json:"latitude" label:"纬度"`
+	Longitude    string `json:"longitude" label:"经度"`
 }
 
 // IotDeviceImportRespVO 设备导入响应
 type IotDeviceImportRespVO struct {
-	CreateDeviceNames  []string          `json:"createDeviceNames"`
-	UpdateDeviceNames  []string          `json:"updateDeviceNames"`
-	FailureDeviceNames map[string]string `json:"failureDeviceNames"`
+	CreateDeviceNames  []string                    `json:"createDeviceNames"`
+	UpdateDeviceNames  []string                    `json:"updateDeviceNames"`
+	FailureDeviceNames map[string]string           `json:"failureDeviceNames"`
+	Rows               []*IotDeviceImportRowRespVO `json:"rows"` // 逐行校验结果，按 Excel 行号升序
+}
+
+// IotDeviceImportRowRespVO 设备导入逐行结果
+type IotDeviceImportRowRespVO struct {
+	RowNo      int    `json:"rowNo"` // Excel 行号（表头为第 1 行）
+	ProductKey string `json:"productKey"`
+	DeviceName string `json:"deviceName"`
+	Result     string `json:"result"` // 导入结果：create、update、failure
+	Msg        string `json:"msg"`    // 失败原因
+}
+
+// IotDeviceExportReqVO 设备导出请求，筛选条件与分页查询一致
+type IotDeviceExportReqVO struct {
+	DeviceName string `form:"deviceName"`
+	Nickname   string `form:"nickname"`
+	ProductID  int64  `form:"productId"`
+	DeviceType int8   `form:"deviceType"`
+	Status     int8   `form:"status"`
+	GroupID    int64  `form:"groupId"`
+}
+
+// IotDeviceExcelVO 设备 Excel 导出 VO，包含设备密钥用于烧录
+type IotDeviceExcelVO struct {
+	ID           int64      `json:"id" label:"设备编号"`
+	ProductKey   string     `json:"productKey" label:"产品标识"`
+	DeviceName   string     `json:"deviceName" label:"设备名称"`
+	Nickname     string     `json:"nickname" label:"备注名称"`
+	SerialNumber string     `json:"serialNumber" label:"设备序列号"`
+	DeviceSecret string     `json:"deviceSecret" label:"设备密钥"`
+	AuthType     string     `json:"authType" label:"认证类型"`
+	GroupNames   string     `json:"groupNames" label:"设备分组"`
+	State        string     `json:"state" label:"设备状态"`
+	Latitude     string     `json:"latitude" label:"纬度"`
+	Longitude    string     `json:"longitude" label:"经度"`
+	ActiveTime   *time.Time `json:"activeTime" label:"激活时间"`
+	CreateTime   time.Time  `json:"createTime" label:"创建时间"`
 }
 
 // IotDeviceAuthReqDTO 设备认证请求 DTO (内部业务逻辑使用)
@@ -150,6 +229,46 @@ type IotDeviceAuthReqDTO struct {
 	DeviceSecret string `json:"deviceSecret"`
 }
 
+// IotDeviceRegisterReqDTO 设备动态注册请求（一型一密）
+// sign = HMAC-SHA256(productSecret, deviceName{deviceName}productKey{productKey})，产品密钥不在网络上传输
+type IotDeviceRegisterReqDTO struct {
+	ProductKey string `json:"productKey" binding:"required"`
+	DeviceName string `json:"deviceName" binding:"required"`
+	Sign       string `json:"sign" binding:"required"`
+}
+
+// IotDeviceRegisterRespDTO 设备动态注册响应
+type IotDeviceRegisterRespDTO struct {
+	ProductKey   string `json:"productKey"`
+	DeviceName   string `json:"deviceName"`
+	DeviceSecret string `json:"deviceSecret"`
+}
+
+// IotDeviceRegisterLogPageReqVO 设备动态注册日志分页请求
+type IotDeviceRegisterLogPageReqVO struct {
+	PageNo     int    `form:"pageNo" binding:"required"`
+	PageSize   int    `form:"pageSize" binding:"required"`
+	ProductKey string `form:"productKey"`
+	DeviceName string `form:"deviceName"`
+	Protocol   string `form:"protocol"`
+	Success    *bool  `form:"success"`
+}
+
+// IotDeviceRegisterLogRespVO 设备动态注册日志响应信息
+type IotDeviceRegisterLogRespVO struct {
+	ID         int64     `json:"id"`
+	ProductID  int64     `json:"productId"`
+	ProductKey string    `json:"productKey"`
+	DeviceName string    `json:"deviceName"`
+	DeviceID   int64     `json:"deviceId"`
+	Protocol   string    `json:"protocol"`
+	ClientIP   string    `json:"clientIp"`
+	Success    bool      `json:"success"`
+	Created    bool      `json:"created"`
+	ErrorMsg   string    `json:"errorMsg"`
+	CreateTime time.Time `json:"createTime"`
+}
+
 // ================= Iot ThingModel =================
 
 // IotThingModelSaveReqVO 物模型保存请求
@@ -202,6 +321,66 @@ type IotThingModelListReqVO struct {
 	ProductID int64 `form:"productId" binding:"required"`
 }
 
+// IotThingModelTSLExportReqVO 物模型 TSL 导出请求
+type IotThingModelTSLExportReqVO struct {
+	ProductID int64 `form:"productId" binding:"required"`
+	VersionID int64 `form:"versionId"` // 版本编号，不传时导出草稿
+}
+
+// IotThingModelTSLImportReqVO 物模型 TSL 导入请求，导入内容整体覆盖产品的物模型草稿
+type IotThingModelTSLImportReqVO struct {
+	ProductID int64                   `json:"productId" binding:"required"`
+	TSL       *IotThingModelTSLRespVO `json:"tsl" binding:"required"` // 导出的 TSL 文件内容，其中的产品信息忽略
+}
+
+// IotThingModelVersionPublishReqVO 物模型版本发布请求
+type IotThingModelVersionPublishReqVO struct {
+	ProductID int64  `json:"productId" binding:"required"`
+	Remark    string `json:"remark"`
+}
+
+// IotThingModelVersionRollbackReqVO 物模型版本回滚请求
+type IotThingModelVersionRollbackReqVO struct {
+	ID int64 `json:"id" binding:"required"`
+}
+
+// IotThingModelVersionRespVO 物模型版本响应
+type IotThingModelVersionRespVO struct {
+	ID          int64                   `json:"id"`
+	ProductID   int64                   `json:"productId"`
+	ProductKey  string                  `json:"productKey"`
+	Version     int                     `json:"version"`
+	Status      int8                    `json:"status"`
+	Remark      string                  `json:"remark"`
+	PublishTime time.Time               `json:"publishTime"`
+	Creator     string                  `json:"creator"`
+	TSL         *IotThingModelTSLRespVO `json:"tsl,omitempty"`
+}
+
+// IotThingModelVersionDiffReqVO 物模型版本对比请求
+type IotThingModelVersionDiffReqVO struct {
+	ProductID       int64 `form:"productId" binding:"required"`
+	BaseVersionID   int64 `form:"baseVersionId"`   // 对比基准版本，不传时为生效版本
+	TargetVersionID int64 `form:"targetVersionId"` // 对比目标版本，不传时为草稿
+}
+
+// IotThingModelVersionDiffRespVO 物模型版本对比响应
+type IotThingModelVersionDiffRespVO struct {
+	BaseVersion   int                               `json:"baseVersion"`   // 0 表示无生效版本
+	TargetVersion int                               `json:"targetVersion"` // 0 表示草稿
+	Items         []*IotThingModelVersionDiffItemVO `json:"items"`
+}
+
+// IotThingModelVersionDiffItemVO 物模型功能变更项
+type IotThingModelVersionDiffItemVO struct {
+	Type       int8   `json:"type"` // 功能类型：1 属性、2 服务、3 事件
+	Identifier string `json:"identifier"`
+	Name       string `json:"name"`
+	DiffType   string `json:"diffType"` // 变更类型：create、update、delete
+	Before     any    `json:"before,omitempty"`
+	After      any    `json:"after,omitempty"`
+}
+
 // ================= Iot Device Group =================
 
 // IotDeviceGroupSaveReqVO 设备分组保存请求
@@ -229,6 +408,35 @@ type IotDeviceGroupPageReqVO struct {
 	Status   int8   `form:"status"`
 }
 
+// IotDeviceGroupBatchReqVO 设备分组批量下发请求
+// 属性设置时 params 为属性值；服务调用时 identifier 为服务标识符，params 为输入参数
+type IotDeviceGroupBatchReqVO struct {
+	GroupID    int64          `json:"groupId" binding:"required"`
+	Method     string         `json:"method" binding:"required"` // thing.property.set 或 thing.service.invoke
+	Identifier string         `json:"identifier"`
+	Params     map[string]any `json:"params"`
+	Timeout    int            `json:"timeout"` // 每台设备等待回复的超时时间（秒），默认 10 秒，最大 60 秒
+}
+
+// IotDeviceGroupBatchRespVO 设备分组批量下发结果
+type IotDeviceGroupBatchRespVO struct {
+	Total        int                            `json:"total"`
+	SuccessCount int                            `json:"successCount"`
+	FailureCount int                            `json:"failureCount"`
+	Results      []*IotDeviceGroupBatchResultVO `json:"results"`
+}
+
+// IotDeviceGroupBatchResultVO 单台设备的下发结果
+type IotDeviceGroupBatchResultVO struct {
+	DeviceID   int64  `json:"deviceId"`
+	DeviceName string `json:"deviceName"`
+	Success    bool   `json:"success"`
+	RequestID  string `json:"requestId,omitempty"`
+	Code       int    `json:"code"`
+	Msg        string `json:"msg,omitempty"`
+	Output     any    `json:"output,omitempty"` // 设备回复的数据（服务调用为输出参数）
+}
+
 // ================= Iot OTA Firmware =================
 
 // IotOtaFirmwareSaveReqVO 固件保存请求 (创建/更新)
@@ -276,6 +484,10 @@ type IotOtaTaskCreateReqVO struct {
 	FirmwareID  int64   `json:"firmwareId" binding:"required"`
 	DeviceScope int8    `json:"deviceScope" binding:"required"`
 	DeviceIDs   []int64 `json:"deviceIds"`
+
+	MaxConcurrency int32 `json:"maxConcurrency"` // 最大并发升级设备数，0 表示不限制
+	RolloutRate    int32 `json:"rolloutRate"`    // 每分钟最大推送设备数，0 表示不限制
+	Timeout        int32 `json:"timeout"`        // 升级超时时间（秒），0 表示使用默认值
 }
 
 // IotOtaTaskRespVO 固件任务响应信息
@@ -288,6 +500,9 @@ type IotOtaTaskRespVO struct {
 	DeviceScope        int8      `json:"deviceScope"`
 	DeviceTotalCount   int32     `json:"deviceTotalCount"`
 	DeviceSuccessCount int32     `json:"deviceSuccessCount"`
+	MaxConcurrency     int32     `json:"maxConcurrency"`
+	RolloutRate        int32     `json:"rolloutRate"`
+	Timeout            int32     `json:"timeout"`
 	CreateTime         time.Time `json:"createTime"`
 }
 
@@ -300,15 +515,16 @@ type IotOtaTaskPageReqVO struct {
 
 // IotOtaTaskRecordRespVO 固件任务记录响应信息
 type IotOtaTaskRecordRespVO struct {
-	ID             int64     `json:"id"`
-	FirmwareID     int64     `json:"firmwareId"`
-	TaskID         int64     `json:"taskId"`
-	DeviceID       int64     `json:"deviceId"`
-	FromFirmwareID int64     `json:"fromFirmwareId"`
-	Status         int8      `json:"status"`
-	Progress       int32     `json:"progress"`
-	Description    string    `json:"description"`
-	CreateTime     time.Time `json:"createTime"`
+	ID             int64      `json:"id"`
+	FirmwareID     int64      `json:"firmwareId"`
+	TaskID         int64      `json:"taskId"`
+	DeviceID       int64      `json:"deviceId"`
+	FromFirmwareID int64      `json:"fromFirmwareId"`
+	Status         int8       `json:"status"`
+	Progress       int32      `json:"progress"`
+	Description    string     `json:"description"`
+	PushTime       *time.Time `json:"pushTime"`
+	CreateTime     time.Time  `json:"createTime"`
 }
 
 // IotOtaTaskRecordPageReqVO 固件任务记录分页请求
@@ -331,6 +547,8 @@ type IotAlertConfigSaveReqVO struct {
 	SceneRuleIDs   []int64 `json:"sceneRuleIds"`
 	ReceiveUserIDs []int64 `json:"receiveUserIds"`
 	ReceiveTypes   []int   `json:"receiveTypes"`
+	DedupWindow    int32   `json:"dedupWindow" binding:"min=0"`   // 去重窗口（秒），0 表示不去重
+	SilenceWindow  int32   `json:"silenceWindow" binding:"min=0"` // 通知静默窗口（秒），0 表示使用默认窗口
 }
 
 // IotAlertConfigRespVO 告警配置响应信息
@@ -344,6 +562,8 @@ type IotAlertConfigRespVO struct {
 	ReceiveUserIDs   []int64   `json:"receiveUserIds"`
 	ReceiveUserNames []string  `json:"receiveUserNames"`
 	ReceiveTypes     []int     `json:"receiveTypes"`
+	DedupWindow      int32     `json:"dedupWindow"`
+	SilenceWindow    int32     `json:"silenceWindow"`
 	CreateTime       time.Time `json:"createTime"`
 }
 
@@ -454,6 +674,29 @@ type IotDataSinkPageReqVO struct {
 	Status   int8   `form:"status"`
 }
 
+// IotDataSinkDeadLetterPageReqVO 数据流转死信分页请求
+type IotDataSinkDeadLetterPageReqVO struct {
+	PageNo   int   `form:"pageNo" binding:"required"`
+	PageSize int   `form:"pageSize" binding:"required"`
+	RuleID   int64 `form:"ruleId"`
+	SinkID   int64 `form:"sinkId"`
+	DeviceID int64 `form:"deviceId"`
+}
+
+// IotDataSinkDeadLetterRespVO 数据流转死信响应信息
+type IotDataSinkDeadLetterRespVO struct {
+	ID         int64     `json:"id"`
+	RuleID     int64     `json:"ruleId"`
+	SinkID     int64     `json:"sinkId"`
+	SinkType   int8      `json:"sinkType"`
+	DeviceID   int64     `json:"deviceId"`
+	MessageID  string    `json:"messageId"`
+	Message    string    `json:"message"`
+	RetryCount int32     `json:"retryCount"`
+	ErrorMsg   string    `json:"errorMsg"`
+	CreateTime time.Time `json:"createTime"`
+}
+
 // ================= Iot Scene Rule =================
 
 // IotSceneRuleTriggerCondition 触发条件
@@ -608,6 +851,14 @@ type IotDeviceMessageRespVO struct {
 	Msg       string `json:"msg"`
 }
 
+// IotDeviceMessageStreamSubscribeReqVO 设备消息实时流订阅请求（WebSocket）
+// 各过滤条件为空时不限
+type IotDeviceMessageStreamSubscribeReqVO struct {
+	ProductID int64  `json:"productId"`
+	DeviceID  int64  `json:"deviceId"`
+	Method    string `json:"method"`
+}
+
 // IotDeviceMessageRespPairVO IoT 设备消息对 Response VO
 type IotDeviceMessageRespPairVO struct {
 	Request *IotDeviceMessageRespVO `json:"request"`
@@ -621,6 +872,20 @@ type IotDeviceMessageSendReqVO struct {
 	DeviceID int64  `json:"deviceId" binding:"required"`
 }
 
+// IotDeviceServiceInvokeReqVO IoT 设备服务同步调用 Request VO
+type IotDeviceServiceInvokeReqVO struct {
+	DeviceID    int64          `json:"deviceId" binding:"required"`
+	Identifier  string         `json:"identifier" binding:"required"` // 服务标识符
+	InputParams map[string]any `json:"inputParams"`                   // 输入参数
+	Timeout     int            `json:"timeout"`                       // 等待回复的超时时间（秒），默认 10 秒，最大 60 秒
+}
+
+// IotDeviceServiceInvokeRespVO IoT 设备服务同步调用 Response VO
+type IotDeviceServiceInvokeRespVO struct {
+	RequestID    string `json:"requestId"`
+	OutputParams any    `json:"outputParams"` // 设备回复的输出参数
+}
+
 // ================= Iot Device Property =================
 
 // IotDevicePropertyRespVO IoT 设备属性 Response VO
@@ -639,9 +904,116 @@ type IotDevicePropertyDetailRespVO struct {
 	DataSpecsList []dto.ThingModelDataSpecs `json:"dataSpecsList"`
 }
 
+// ================= Iot Device Shadow =================
+
+// IotDeviceShadowRespVO IoT 设备影子 Response VO
+type IotDeviceShadowRespVO struct {
+	DeviceID   int64          `json:"deviceId"`
+	Desired    map[string]any `json:"desired"`
+	Reported   map[string]any `json:"reported"`
+	Delta      map[string]any `json:"delta"` // 期望状态中与上报状态不一致的部分
+	Version    int64          `json:"version"`
+	UpdateTime *time.Time     `json:"updateTime"`
+}
+
+// IotDeviceShadowUpdateDesiredReqVO IoT 设备影子期望状态更新 Request VO
+type IotDeviceShadowUpdateDesiredReqVO struct {
+	DeviceID int64          `json:"deviceId" binding:"required"`
+	Desired  map[string]any `json:"desired" binding:"required"` // 值为 null 表示清除该属性的期望值
+	Version  int64          `json:"version"`                    // 当前版本号，用于乐观锁校验；0 表示不校验
+}
+
 // IotDevicePropertyHistoryListReqVO IoT 设备属性历史列表 Request VO
 type IotDevicePropertyHistoryListReqVO struct {
 	DeviceID   int64        `form:"deviceId" binding:"required"`
 	Identifier string       `form:"identifier" binding:"required"`
 	Times      []*time.Time `form:"times" time_format:"2006-01-02 15:04:05"`
 }
+
+// IotDevicePropertyAggregateListReqVO IoT 设备属性聚合查询 Request VO
+type IotDevicePropertyAggregateListReqVO struct {
+	DeviceID    int64        `form:"deviceId" binding:"required"`
+	Identifier  string       `form:"identifier" binding:"required"`
+	Granularity string       `form:"granularity" binding:"required,oneof=minute hour day"` // 聚合粒度
+	Times       []*time.Time `form:"times" time_format:"2006-01-02 15:04:05"`
+}
+
+// IotDevicePropertyAggregateRespVO IoT 设备属性聚合 Response VO
+type IotDevicePropertyAggregateRespVO struct {
+	Time      int64   `json:"time"` // 分桶开始时间（毫秒）
+	MinValue  float64 `json:"minValue"`
+	MaxValue  float64 `json:"maxValue"`
+	AvgValue  float64 `json:"avgValue"`
+	LastValue float64 `json:"lastValue"`
+	Count     int64   `json:"count"`
+}
+
+// IotDevicePropertyExportReqVO IoT 设备属性导出 Request VO
+// 未指定聚合粒度时导出原始数据
+type IotDevicePropertyExportReqVO struct {
+	DeviceID    int64        `form:"deviceId" binding:"required"`
+	Identifier  string       `form:"identifier" binding:"required"`
+	Granularity string       `form:"granularity" binding:"omitempty,oneof=minute hour day"`
+	Times       []*time.Time `form:"times" time_format:"2006-01-02 15:04:05"`
+}
+
+// IotGeofencePoint 地理围栏顶点
+type IotGeofencePoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// IotGeofenceSaveReqVO 地理围栏保存请求
+// 圆形围栏需要中心点与半径（米），多边形围栏需要至少 3 个顶点
+type IotGeofenceSaveReqVO struct {
+	ID              int64              `json:"id"`
+	Name            string             `json:"name" binding:"required"`
+	Type            int8               `json:"type" binding:"required"`
+	ProductID       int64              `json:"productId"`
+	GroupID         int64              `json:"groupId"`
+	CenterLatitude  float64            `json:"centerLatitude"`
+	CenterLongitude float64            `json:"centerLongitude"`
+	Radius          float64            `json:"radius"`
+	Points          []IotGeofencePoint `json:"points"`
+	Status          int8               `json:"status"`
+	Description     string             `json:"description"`
+}
+
+// IotGeofenceRespVO 地理围栏响应信息
+type IotGeofenceRespVO struct {
+	ID              int64              `json:"id"`
+	Name            string             `json:"name"`
+	Type            int8               `json:"type"`
+	ProductID       int64              `json:"productId"`
+	GroupID         int64              `json:"groupId"`
+	CenterLatitude  float64            `json:"centerLatitude"`
+	CenterLongitude float64            `json:"centerLongitude"`
+	Radius          float64            `json:"radius"`
+	Points          []IotGeofencePoint `json:"points"`
+	Status          int8               `json:"status"`
+	Description     string             `json:"description"`
+	CreateTime      time.Time          `json:"createTime"`
+}
+
+// IotGeofencePageReqVO 地理围栏分页请求
+type IotGeofencePageReqVO struct {
+	PageNo    int    `form:"pageNo" binding:"required"`
+	PageSize  int    `form:"pageSize" binding:"required"`
+	Name      string `form:"name"`
+	Type      int8   `form:"type"`
+	ProductID int64  `form:"productId"`
+	Status    *int8  `form:"status"`
+}
+
+// IotDeviceLocationTrackReqVO 设备位置轨迹查询请求
+type IotDeviceLocationTrackReqVO struct {
+	DeviceID int64        `form:"deviceId" binding:"required"`
+	Times    []*time.Time `form:"times" time_format:"2006-01-02 15:04:05"`
+}
+
+// IotDeviceLocationRespVO 设备位置轨迹点
+type IotDeviceLocationRespVO struct {
+	Latitude   float64   `json:"latitude"`
+	Longitude  float64   `json:"longitude"`
+	ReportTime time.Time `json:"reportTime"`
+}
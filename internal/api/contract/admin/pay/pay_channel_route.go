@@ -0,0 +1,98 @@
+package pay
+
+import (
+	"time"
+
+	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
+)
+
+// PayChannelRouteTargetReq 路由规则中的候选渠道
+type PayChannelRouteTargetReq struct {
+	ChannelID int64 `json:"channelId" binding:"required"`
+	Weight    int   `json:"weight" binding:"required,min=1,max=100"` // 轮询权重
+}
+
+// PayChannelRouteRuleCreateReq 创建路由规则 Request
+type PayChannelRouteRuleCreateReq struct {
+	AppID       int64                       `json:"appId" binding:"required"`
+	Name        string                      `json:"name" binding:"required"`
+	ChannelCode string                      `json:"channelCode" binding:"required"`
+	Sort        int                         `json:"sort"`
+	MinPrice    int                         `json:"minPrice" binding:"min=0"` // 单位：分，0 表示不限
+	MaxPrice    int                         `json:"maxPrice" binding:"min=0"` // 单位：分，0 表示不限
+	StartTime   string                      `json:"startTime"`                // 格式 HH:mm，与结束时间同时为空表示全天
+	EndTime     string                      `json:"endTime"`
+	Terminals   []int                       `json:"terminals"`
+	TenantIDs   []int64                     `json:"tenantIds"`
+	Targets     []*PayChannelRouteTargetReq `json:"targets" binding:"required,min=1,dive"`
+	Status      *int                        `json:"status" binding:"required"`
+	Remark      string                      `json:"remark"`
+}
+
+// PayChannelRouteRuleUpdateReq 更新路由规则 Request，应用与渠道编码不可修改
+type PayChannelRouteRuleUpdateReq struct {
+	ID        int64                       `json:"id" binding:"required"`
+	Name      string                      `json:"name" binding:"required"`
+	Sort      int                         `json:"sort"`
+	MinPrice  int                         `json:"minPrice" binding:"min=0"`
+	MaxPrice  int                         `json:"maxPrice" binding:"min=0"`
+	StartTime string                      `json:"startTime"`
+	EndTime   string                      `json:"endTime"`
+	Terminals []int                       `json:"terminals"`
+	TenantIDs []int64                     `json:"tenantIds"`
+	Targets   []*PayChannelRouteTargetReq `json:"targets" binding:"required,min=1,dive"`
+	Status    *int                        `json:"status" binding:"required"`
+	Remark    string                      `json:"remark"`
+}
+
+// PayChannelRouteRulePageReq 路由规则分页 Request
+type PayChannelRouteRulePageReq struct {
+	pagination.PageParam
+	AppID       int64  `form:"appId"`
+	Name        string `form:"name"`
+	ChannelCode string `form:"channelCode"`
+	Status      *int   `form:"status"`
+}
+
+// PayChannelRouteRuleResp 路由规则 Response
+type PayChannelRouteRuleResp struct {
+	ID          int64                        `json:"id"`
+	AppID       int64                        `json:"appId"`
+	AppName     string                       `json:"appName"`
+	Name        string                       `json:"name"`
+	ChannelCode string                       `json:"channelCode"`
+	Sort        int                          `json:"sort"`
+	MinPrice    int                          `json:"minPrice"`
+	MaxPrice    int                          `json:"maxPrice"`
+	StartTime   string                       `json:"startTime"`
+	EndTime     string                       `json:"endTime"`
+	Terminals   []int                        `json:"terminals"`
+	TenantIDs   []int64                      `json:"tenantIds"`
+	Targets     []*PayChannelRouteTargetResp `json:"targets"`
+	Status      int                          `json:"status"`
+	Remark      string                       `json:"remark"`
+	CreateTime  time.Time                    `json:"createTime"`
+}
+
+// PayChannelRouteTargetResp 路由规则中的候选渠道 Response
+type PayChannelRouteTargetResp struct {
+	ChannelID     int64  `json:"channelId"`
+	ChannelRemark string `json:"channelRemark"`
+	Weight        int    `json:"weight"`
+}
+
+// PayChannelHealthResp 支付渠道健康状况 Response
+type PayChannelHealthResp struct {
+	ChannelID          int64      `json:"channelId"`
+	AppID              int64      `json:"appId"`
+	Code               string     `json:"code"`
+	Remark             string     `json:"remark"`
+	Status             int        `json:"status"`
+	SuccessCount       int64      `json:"successCount"`
+	FailureCount       int64      `json:"failureCount"`
+	ContinuousFailures int64      `json:"continuousFailures"`
+	LastSuccessTime    *time.Time `json:"lastSuccessTime"`
+	LastFailureTime    *time.Time `json:"lastFailureTime"`
+	LastFailureMsg     string     `json:"lastFailureMsg"`
+	Available          bool       `json:"available"` // 是否可用：连续失败达到阈值后暂停路由，冷却期过后恢复
+}
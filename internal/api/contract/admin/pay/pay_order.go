@@ -33,6 +33,8 @@ type PayOrderSubmitReq struct {
 	ChannelExtras map[string]string `json:"channelExtras"`
 	DisplayMode   string            `json:"displayMode"` // PayOrderDisplayModeEnum
 	ReturnUrl     string            `json:"returnUrl"`
+	WalletPrice   int               `json:"walletPrice" binding:"min=0"` // 组合支付时钱包支付的金额，其余金额由所选渠道支付
+	Terminal      int               `json:"terminal"`                    // 提交支付的终端，参与渠道路由，参见 consts.Terminal
 }
 
 type PayOrderCreateReq struct {
@@ -72,6 +74,7 @@ type PayOrderResp struct {
 	RefundPrice     int64      `json:"refundPrice"` // 改为 int64
 	ChannelUserID   string     `json:"channelUserId"`
 	ChannelOrderNo  string     `json:"channelOrderNo"`
+	WalletPrice     int        `json:"walletPrice"`
 	CreateTime      time.Time  `json:"createTime"`
 	UpdateTime      time.Time  `json:"updateTime"`
 	Creator         string     `json:"creator"`
@@ -96,6 +99,9 @@ type PayOrderExtensionResp struct {
 	ChannelErrorCode  string    `json:"channelErrorCode"`
 	ChannelErrorMsg   string    `json:"channelErrorMsg"`
 	ChannelNotifyData string    `json:"channelNotifyData"`
+	WalletID          int64     `json:"walletId"`
+	WalletPrice       int       `json:"walletPrice"`
+	WalletStatus      int       `json:"walletStatus"`
 	CreateTime        time.Time `json:"createTime"`
 }
 
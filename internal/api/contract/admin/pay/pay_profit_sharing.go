@@ -0,0 +1,173 @@
+package pay
+
+import (
+	"time"
+
+	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
+)
+
+// PayProfitSharingReceiverCreateReq 创建分账接收方 Request
+type PayProfitSharingReceiverCreateReq struct {
+	AppID        int64  `json:"appId" binding:"required"`
+	Name         string `json:"name" binding:"required"`
+	Type         int    `json:"type" binding:"required"`
+	Account      string `json:"account" binding:"required"` // 钱包编号、微信商户号或 openid、支付宝 userId
+	RealName     string `json:"realName"`                   // 微信商户接收方必填商户全称
+	RelationType string `json:"relationType"`               // 微信接收方必填
+	Status       *int   `json:"status" binding:"required"`
+	Remark       string `json:"remark"`
+}
+
+// PayProfitSharingReceiverUpdateReq 更新分账接收方 Request，接收方类型与账号不可修改
+type PayProfitSharingReceiverUpdateReq struct {
+	ID           int64  `json:"id" binding:"required"`
+	Name         string `json:"name" binding:"required"`
+	RealName     string `json:"realName"`
+	RelationType string `json:"relationType"`
+	Status       *int   `json:"status" binding:"required"`
+	Remark       string `json:"remark"`
+}
+
+// PayProfitSharingReceiverPageReq 分账接收方分页 Request
+type PayProfitSharingReceiverPageReq struct {
+	pagination.PageParam
+	AppID   int64  `form:"appId"`
+	Name    string `form:"name"`
+	Type    *int   `form:"type"`
+	Account string `form:"account"`
+	Status  *int   `form:"status"`
+}
+
+// PayProfitSharingReceiverResp 分账接收方 Response
+type PayProfitSharingReceiverResp struct {
+	ID           int64     `json:"id"`
+	AppID        int64     `json:"appId"`
+	AppName      string    `json:"appName"`
+	Name         string    `json:"name"`
+	Type         int       `json:"type"`
+	Account      string    `json:"account"`
+	RealName     string    `json:"realName"`
+	RelationType string    `json:"relationType"`
+	Status       int       `json:"status"`
+	Remark       string    `json:"remark"`
+	CreateTime   time.Time `json:"createTime"`
+}
+
+// PayProfitSharingRuleReceiverReq 分账规则中的接收方
+type PayProfitSharingRuleReceiverReq struct {
+	ReceiverID int64 `json:"receiverId" binding:"required"`
+	Ratio      int   `json:"ratio" binding:"required,min=1,max=10000"` // 分账比例，单位：万分之一
+}
+
+// PayProfitSharingRuleCreateReq 创建分账规则 Request
+type PayProfitSharingRuleCreateReq struct {
+	AppID     int64                              `json:"appId" binding:"required"`
+	Name      string                             `json:"name" binding:"required"`
+	Receivers []*PayProfitSharingRuleReceiverReq `json:"receivers" binding:"required,min=1,dive"`
+	Status    *int                               `json:"status" binding:"required"`
+	Remark    string                             `json:"remark"`
+}
+
+// PayProfitSharingRuleUpdateReq 更新分账规则 Request，只影响此后创建的分账单
+type PayProfitSharingRuleUpdateReq struct {
+	ID        int64                              `json:"id" binding:"required"`
+	Name      string                             `json:"name" binding:"required"`
+	Receivers []*PayProfitSharingRuleReceiverReq `json:"receivers" binding:"required,min=1,dive"`
+	Status    *int                               `json:"status" binding:"required"`
+	Remark    string                             `json:"remark"`
+}
+
+// PayProfitSharingRulePageReq 分账规则分页 Request
+type PayProfitSharingRulePageReq struct {
+	pagination.PageParam
+	AppID  int64  `form:"appId"`
+	Name   string `form:"name"`
+	Status *int   `form:"status"`
+}
+
+// PayProfitSharingRuleResp 分账规则 Response
+type PayProfitSharingRuleResp struct {
+	ID         int64                               `json:"id"`
+	AppID      int64                               `json:"appId"`
+	AppName    string                              `json:"appName"`
+	Name       string                              `json:"name"`
+	Receivers  []*PayProfitSharingRuleReceiverResp `json:"receivers"`
+	Status     int                                 `json:"status"`
+	Remark     string                              `json:"remark"`
+	CreateTime time.Time                           `json:"createTime"`
+}
+
+// PayProfitSharingRuleReceiverResp 分账规则中的接收方 Response
+type PayProfitSharingRuleReceiverResp struct {
+	ReceiverID   int64  `json:"receiverId"`
+	ReceiverName string `json:"receiverName"`
+	ReceiverType int    `json:"receiverType"`
+	Ratio        int    `json:"ratio"`
+}
+
+// PayProfitSharingOrderPageReq 分账单分页 Request
+type PayProfitSharingOrderPageReq struct {
+	pagination.PageParam
+	AppID       int64  `form:"appId"`
+	OrderID     int64  `form:"orderId"`
+	No          string `form:"no"`
+	OutTradeNo  string `form:"outTradeNo"`
+	ChannelCode string `form:"channelCode"`
+	Status      *int   `form:"status"`
+}
+
+// PayProfitSharingOrderResp 分账单 Response
+type PayProfitSharingOrderResp struct {
+	ID               int64                            `json:"id"`
+	No               string                           `json:"no"`
+	AppID            int64                            `json:"appId"`
+	AppName          string                           `json:"appName"`
+	RuleID           int64                            `json:"ruleId"`
+	OrderID          int64                            `json:"orderId"`
+	ChannelID        int64                            `json:"channelId"`
+	ChannelCode      string                           `json:"channelCode"`
+	OutTradeNo       string                           `json:"outTradeNo"`
+	ChannelOrderNo   string                           `json:"channelOrderNo"`
+	Price            int                              `json:"price"`
+	SharingPrice     int                              `json:"sharingPrice"`
+	RefundPrice      int                              `json:"refundPrice"`
+	Status           int                              `json:"status"`
+	ChannelSharingNo string                           `json:"channelSharingNo"`
+	SuccessTime      *time.Time                       `json:"successTime"`
+	ErrorMsg         string                           `json:"errorMsg"`
+	CreateTime       time.Time                        `json:"createTime"`
+	Items            []*PayProfitSharingOrderItemResp `json:"items,omitempty"`
+	Returns          []*PayProfitSharingReturnResp    `json:"returns,omitempty"`
+}
+
+// PayProfitSharingOrderItemResp 分账明细 Response
+type PayProfitSharingOrderItemResp struct {
+	ID              int64      `json:"id"`
+	OutSharingNo    string     `json:"outSharingNo"`
+	ReceiverID      int64      `json:"receiverId"`
+	ReceiverType    int        `json:"receiverType"`
+	Account         string     `json:"account"`
+	Name            string     `json:"name"`
+	Ratio           int        `json:"ratio"`
+	Price           int        `json:"price"`
+	ReturnPrice     int        `json:"returnPrice"`
+	Status          int        `json:"status"`
+	ChannelDetailNo string     `json:"channelDetailNo"`
+	FailReason      string     `json:"failReason"`
+	SuccessTime     *time.Time `json:"successTime"`
+}
+
+// PayProfitSharingReturnResp 分账回退单 Response
+type PayProfitSharingReturnResp struct {
+	ID              int64      `json:"id"`
+	No              string     `json:"no"`
+	ItemID          int64      `json:"itemId"`
+	ReceiverType    int        `json:"receiverType"`
+	Account         string     `json:"account"`
+	Price           int        `json:"price"`
+	Status          int        `json:"status"`
+	ChannelReturnNo string     `json:"channelReturnNo"`
+	FailReason      string     `json:"failReason"`
+	SuccessTime     *time.Time `json:"successTime"`
+	CreateTime      time.Time  `json:"createTime"`
+}
@@ -0,0 +1,119 @@
+package pay
+
+import (
+	"time"
+
+	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
+)
+
+// PayReconcileBillPageReq 对账单分页 Request
+type PayReconcileBillPageReq struct {
+	pagination.PageParam
+	AppID       int64    `form:"appId"`
+	ChannelID   int64    `form:"channelId"`
+	ChannelType string   `form:"channelType"`
+	Status      *int     `form:"status"`
+	BillDate    []string `form:"billDate[]"` // 账单日期范围，格式 yyyy-MM-dd
+}
+
+// PayReconcileBillDownloadReq 从渠道下载对账单并对账 Request
+type PayReconcileBillDownloadReq struct {
+	ChannelID int64  `json:"channelId" binding:"required"` // 支付渠道（商户号）编号
+	BillDate  string `json:"billDate" binding:"required"`  // 格式 yyyy-MM-dd
+}
+
+// PayReconcileBillUploadReq 上传对账单并对账 Request，账单文件通过 file 字段上传
+type PayReconcileBillUploadReq struct {
+	ChannelID int64  `form:"channelId" binding:"required"` // 支付渠道（商户号）编号
+	BillDate  string `form:"billDate" binding:"required"`  // 格式 yyyy-MM-dd
+}
+
+// PayReconcileBillResp 对账单 Response
+type PayReconcileBillResp struct {
+	ID               int64     `json:"id"`
+	AppID            int64     `json:"appId"`
+	AppName          string    `json:"appName"`
+	ChannelID        int64     `json:"channelId"`
+	ChannelType      string    `json:"channelType"`
+	BillDate         string    `json:"billDate"`
+	FileName         string    `json:"fileName"`
+	Source           int       `json:"source"`
+	Status           int       `json:"status"`
+	ChannelCount     int       `json:"channelCount"`
+	ChannelAmount    int64     `json:"channelAmount"`
+	LocalCount       int       `json:"localCount"`
+	LocalAmount      int64     `json:"localAmount"`
+	MatchedCount     int       `json:"matchedCount"`
+	DiscrepancyCount int       `json:"discrepancyCount"`
+	ErrorMsg         string    `json:"errorMsg"`
+	CreateTime       time.Time `json:"createTime"`
+}
+
+// PayReconcileDiscrepancyPageReq 对账差异分页 Request
+type PayReconcileDiscrepancyPageReq struct {
+	pagination.PageParam
+	PayReconcileDiscrepancyExportReq
+}
+
+// PayReconcileDiscrepancyExportReq 对账差异导出 Request
+type PayReconcileDiscrepancyExportReq struct {
+	BillID         int64    `form:"billId"`
+	AppID          int64    `form:"appId"`
+	ChannelType    string   `form:"channelType"`
+	BizType        *int     `form:"bizType"`
+	Type           *int     `form:"type"`
+	Status         *int     `form:"status"`
+	OutTradeNo     string   `form:"outTradeNo"`
+	ChannelOrderNo string   `form:"channelOrderNo"`
+	BillDate       []string `form:"billDate[]"` // 账单日期范围，格式 yyyy-MM-dd
+}
+
+// PayReconcileDiscrepancyIgnoreReq 忽略对账差异 Request
+type PayReconcileDiscrepancyIgnoreReq struct {
+	ID     int64  `json:"id" binding:"required"`
+	Remark string `json:"remark" binding:"required"`
+}
+
+// PayReconcileDiscrepancyResp 对账差异 Response
+type PayReconcileDiscrepancyResp struct {
+	ID              int64      `json:"id"`
+	BillID          int64      `json:"billId"`
+	AppID           int64      `json:"appId"`
+	AppName         string     `json:"appName"`
+	ChannelType     string     `json:"channelType"`
+	BillDate        string     `json:"billDate"`
+	BizType         int        `json:"bizType"`
+	Type            int        `json:"type"`
+	LocalID         int64      `json:"localId"`
+	ChannelID       int64      `json:"channelId"`
+	OutTradeNo      string     `json:"outTradeNo"`
+	ChannelOrderNo  string     `json:"channelOrderNo"`
+	OutRefundNo     string     `json:"outRefundNo"`
+	ChannelRefundNo string     `json:"channelRefundNo"`
+	LocalAmount     *int       `json:"localAmount"`
+	ChannelAmount   *int       `json:"channelAmount"`
+	LocalStatus     *int       `json:"localStatus"`
+	Status          int        `json:"status"`
+	ResolveRemark   string     `json:"resolveRemark"`
+	ResolveTime     *time.Time `json:"resolveTime"`
+	CreateTime      time.Time  `json:"createTime"`
+}
+
+// PayReconcileDiscrepancyExcelVO 对账差异报表 Excel 行
+type PayReconcileDiscrepancyExcelVO struct {
+	ID              int64  `label:"差异编号"`
+	BillDate        string `label:"账单日期"`
+	AppName         string `label:"支付应用"`
+	ChannelType     string `label:"对账渠道"`
+	BizType         string `label:"业务类型"`
+	Type            string `label:"差异类型"`
+	OutTradeNo      string `label:"外部订单号"`
+	ChannelOrderNo  string `label:"渠道订单号"`
+	OutRefundNo     string `label:"外部退款号"`
+	ChannelRefundNo string `label:"渠道退款单号"`
+	LocalAmount     string `label:"本地金额（元）"`
+	ChannelAmount   string `label:"渠道金额（元）"`
+	Status          string `label:"处理状态"`
+	ResolveRemark   string `label:"处理说明"`
+	ResolveTime     string `label:"处理时间"`
+}
@@ -62,6 +62,7 @@ type PayRefundResp struct {
 	Status            int        `json:"status"`
 	PayPrice          int64      `json:"payPrice"`    // 改为 int64
 	RefundPrice       int64      `json:"refundPrice"` // 改为 int64
+	WalletRefundPrice int        `json:"walletRefundPrice"`
 	Reason            string     `json:"reason"`
 	UserIP            string     `json:"userIp"`
 	ChannelOrderNo    string     `json:"channelOrderNo"`
@@ -140,14 +140,18 @@ func (h *WebSocketHandler) readLoop(session *ws.Session) {
 			continue
 		}
 
-		// TODO: 根据 Type 路由到不同的 MessageListener
+		// 根据 Type 路由到对应的 MessageListener
 		// Java: WebSocketMessageListener<Object> messageListener = listeners.get(jsonMessage.getType());
-		// 目前仅记录日志，后续可扩展消息监听器框架
-		h.logger.Info("收到 WebSocket 消息",
-			zap.String("sessionId", session.ID),
-			zap.String("type", wsMsg.Type),
-			zap.String("content", func() string { contentJSON, _ := json.Marshal(wsMsg.Content); return string(contentJSON) }()),
-		)
+		listener := h.manager.GetListener(wsMsg.Type)
+		if listener == nil {
+			h.logger.Info("收到 WebSocket 消息",
+				zap.String("sessionId", session.ID),
+				zap.String("type", wsMsg.Type),
+				zap.String("content", func() string { contentJSON, _ := json.Marshal(wsMsg.Content); return string(contentJSON) }()),
+			)
+			continue
+		}
+		listener(session, wsMsg)
 	}
 }
 
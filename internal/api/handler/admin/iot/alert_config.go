@@ -90,6 +90,8 @@ func (h *AlertConfigHandler) Get(c *gin.Context) {
 		ReceiveUserIDs:   receiveUserIDs,
 		ReceiveUserNames: receiveUserNames,
 		ReceiveTypes:     receiveTypes,
+		DedupWindow:      config.DedupWindow,
+		SilenceWindow:    config.SilenceWindow,
 		CreateTime:       config.CreateTime,
 	}
 	response.WriteSuccess(c, resp)
@@ -126,6 +128,8 @@ func (h *AlertConfigHandler) Page(c *gin.Context) {
 			SceneRuleIDs:   sceneRuleIDs,
 			ReceiveUserIDs: receiveUserIDs,
 			ReceiveTypes:   receiveTypes,
+			DedupWindow:    item.DedupWindow,
+			SilenceWindow:  item.SilenceWindow,
 			CreateTime:     item.CreateTime,
 		})
 	}
@@ -113,3 +113,34 @@ func (h *DataRuleHandler) Page(c *gin.Context) {
 	}
 	response.WritePage(c, page.Total, list)
 }
+
+// DeadLetterPage 获取数据流转死信分页
+func (h *DataRuleHandler) DeadLetterPage(c *gin.Context) {
+	var r iot2.IotDataSinkDeadLetterPageReqVO
+	if err := c.ShouldBindQuery(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	page, err := h.svc.GetDeadLetterPage(c, &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+
+	list := make([]*iot2.IotDataSinkDeadLetterRespVO, 0, len(page.List))
+	for _, item := range page.List {
+		list = append(list, &iot2.IotDataSinkDeadLetterRespVO{
+			ID:         item.ID,
+			RuleID:     item.RuleID,
+			SinkID:     item.SinkID,
+			SinkType:   item.SinkType,
+			DeviceID:   item.DeviceID,
+			MessageID:  item.MessageID,
+			Message:    item.Message,
+			RetryCount: item.RetryCount,
+			ErrorMsg:   item.ErrorMsg,
+			CreateTime: item.CreateTime,
+		})
+	}
+	response.WritePage(c, page.Total, list)
+}
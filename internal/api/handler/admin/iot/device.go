@@ -2,11 +2,13 @@ package iot
 
 import (
 	"encoding/json"
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/excel"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/response"
 )
 
@@ -239,3 +241,114 @@ func (h *DeviceHandler) SimpleList(c *gin.Context) {
 	}
 	response.WriteSuccess(c, respList)
 }
+
+// GetImportTemplate 获得设备导入模板
+func (h *DeviceHandler) GetImportTemplate(c *gin.Context) {
+	if err := excel.WriteExcel(c, "设备导入模板.xlsx", "设备列表", h.svc.GetImportTemplate()); err != nil {
+		response.WriteBizError(c, err)
+	}
+}
+
+// Import 导入设备，返回逐行导入结果
+func (h *DeviceHandler) Import(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	updateSupport, _ := strconv.ParseBool(c.DefaultPostForm("updateSupport", c.Query("updateSupport")))
+	f, err := file.Open()
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	defer f.Close()
+
+	var list []iot2.IotDeviceImportExcelVO
+	if err := excel.ReadExcel(f, &list); err != nil {
+		response.WriteBizError(c, errors.NewBizError(errors.ParamErrCode, "Excel 解析失败: "+err.Error()))
+		return
+	}
+	resp, err := h.svc.ImportDevices(c.Request.Context(), list, updateSupport)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, resp)
+}
+
+// ExportExcel 导出设备 Excel，包含设备密钥
+func (h *DeviceHandler) ExportExcel(c *gin.Context) {
+	var r iot2.IotDeviceExportReqVO
+	if err := c.ShouldBindQuery(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	list, err := h.svc.GetExportList(c.Request.Context(), &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	if err := excel.WriteExcel(c, "设备.xlsx", "数据", list); err != nil {
+		response.WriteBizError(c, err)
+	}
+}
+
+// RegisterLogPage 获取设备动态注册日志分页
+func (h *DeviceHandler) RegisterLogPage(c *gin.Context) {
+	var r iot2.IotDeviceRegisterLogPageReqVO
+	if err := c.ShouldBindQuery(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	page, err := h.svc.GetRegisterLogPage(c, &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+
+	list := make([]*iot2.IotDeviceRegisterLogRespVO, 0, len(page.List))
+	for _, item := range page.List {
+		list = append(list, &iot2.IotDeviceRegisterLogRespVO{
+			ID:         item.ID,
+			ProductID:  item.ProductID,
+			ProductKey: item.ProductKey,
+			DeviceName: item.DeviceName,
+			DeviceID:   item.DeviceID,
+			Protocol:   item.Protocol,
+			ClientIP:   item.ClientIP,
+			Success:    item.Success,
+			Created:    item.Created,
+			ErrorMsg:   item.ErrorMsg,
+			CreateTime: item.CreateTime,
+		})
+	}
+	response.WritePage(c, page.Total, list)
+}
+
+// RenewCertificate 重新签发设备证书，旧证书吊销
+func (h *DeviceHandler) RenewCertificate(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err := h.svc.RenewCertificate(c, id); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
+// DownloadCACertificate 下载设备 CA 证书
+func (h *DeviceHandler) DownloadCACertificate(c *gin.Context) {
+	c.Header("Content-Disposition", "attachment; filename=iot-device-ca.crt")
+	c.Data(http.StatusOK, "application/x-pem-file", []byte(h.svc.GetCACertificate()))
+}
+
+// DownloadCRL 下载设备证书吊销列表
+func (h *DeviceHandler) DownloadCRL(c *gin.Context) {
+	crl, err := h.svc.GetCRL(c)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename=iot-device.crl")
+	c.Data(http.StatusOK, "application/pkix-crl", crl)
+}
@@ -117,3 +117,18 @@ func (h *DeviceGroupHandler) SimpleList(c *gin.Context) {
 	}
 	response.WriteSuccess(c, result)
 }
+
+// BatchSend 向分组内的设备批量下发属性设置或服务调用，返回逐台设备的结果
+func (h *DeviceGroupHandler) BatchSend(c *gin.Context) {
+	var r iot2.IotDeviceGroupBatchReqVO
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.BindingErr(err))
+		return
+	}
+	resp, err := h.batchSvc.BatchSend(c.Request.Context(), &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, resp)
+}
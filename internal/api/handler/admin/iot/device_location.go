@@ -0,0 +1,33 @@
+package iot
+
+import (
+	"github.com/gin-gonic/gin"
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/response"
+)
+
+// DeviceLocationHandler 设备位置处理器
+type DeviceLocationHandler struct {
+	svc *iotsvc.DeviceLocationService
+}
+
+func NewDeviceLocationHandler(svc *iotsvc.DeviceLocationService) *DeviceLocationHandler {
+	return &DeviceLocationHandler{svc: svc}
+}
+
+// GetTrack 获取设备位置轨迹
+func (h *DeviceLocationHandler) GetTrack(c *gin.Context) {
+	var r iot2.IotDeviceLocationTrackReqVO
+	if err := c.ShouldBindQuery(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	list, err := h.svc.GetTrack(c, &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, list)
+}
@@ -117,6 +117,25 @@ func (h *DeviceMessageHandler) Send(c *gin.Context) {
 	response.WriteSuccess(c, true)
 }
 
+// Invoke 同步调用设备服务，等待设备回复后返回输出参数
+func (h *DeviceMessageHandler) Invoke(c *gin.Context) {
+	var req iot2.IotDeviceServiceInvokeReqVO
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	timeout := time.Duration(req.Timeout) * time.Second
+	requestID, output, err := h.svc.InvokeDeviceService(c.Request.Context(), req.DeviceID, req.Identifier, req.InputParams, timeout)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, &iot2.IotDeviceServiceInvokeRespVO{
+		RequestID:    requestID,
+		OutputParams: output,
+	})
+}
+
 func (h *DeviceMessageHandler) toVO(do *model.IotDeviceMessageDO) *iot2.IotDeviceMessageRespVO {
 	if do == nil {
 		return nil
@@ -3,26 +3,32 @@ package iot
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
 	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/excel"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/response"
 )
 
 type DevicePropertyHandler struct {
 	svc           *iotsvc.DevicePropertyService
+	rollupSvc     *iotsvc.DevicePropertyRollupService
 	deviceSvc     *iotsvc.DeviceService
 	thingModelSvc *iotsvc.ThingModelService
 }
 
 func NewDevicePropertyHandler(
 	svc *iotsvc.DevicePropertyService,
+	rollupSvc *iotsvc.DevicePropertyRollupService,
 	deviceSvc *iotsvc.DeviceService,
 	thingModelSvc *iotsvc.ThingModelService,
 ) *DevicePropertyHandler {
 	return &DevicePropertyHandler{
 		svc:           svc,
+		rollupSvc:     rollupSvc,
 		deviceSvc:     deviceSvc,
 		thingModelSvc: thingModelSvc,
 	}
@@ -127,3 +133,79 @@ func (h *DevicePropertyHandler) GetHistoryList(c *gin.Context) {
 
 	response.WriteSuccess(c, results)
 }
+
+// GetAggregateList 按分钟、小时、天查询数值型属性的聚合曲线
+func (h *DevicePropertyHandler) GetAggregateList(c *gin.Context) {
+	var req iot2.IotDevicePropertyAggregateListReqVO
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	list, err := h.rollupSvc.GetPropertyAggregateList(c.Request.Context(), &req)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, list)
+}
+
+// ExportCsv 导出属性序列 CSV：指定聚合粒度时导出聚合数据，否则导出原始数据
+func (h *DevicePropertyHandler) ExportCsv(c *gin.Context) {
+	var req iot2.IotDevicePropertyExportReqVO
+	if err := c.ShouldBindQuery(&req); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	ctx := c.Request.Context()
+	fileName := fmt.Sprintf("device_%d_%s.csv", req.DeviceID, req.Identifier)
+
+	if req.Granularity != "" {
+		list, err := h.rollupSvc.GetPropertyAggregateList(ctx, &iot2.IotDevicePropertyAggregateListReqVO{
+			DeviceID:    req.DeviceID,
+			Identifier:  req.Identifier,
+			Granularity: req.Granularity,
+			Times:       req.Times,
+		})
+		if err != nil {
+			response.WriteBizError(c, err)
+			return
+		}
+		rows := make([][]string, 0, len(list))
+		for _, item := range list {
+			rows = append(rows, []string{
+				time.UnixMilli(item.Time).Format(time.DateTime),
+				formatCsvFloat(item.MinValue),
+				formatCsvFloat(item.MaxValue),
+				formatCsvFloat(item.AvgValue),
+				formatCsvFloat(item.LastValue),
+				strconv.FormatInt(item.Count, 10),
+			})
+		}
+		if err := excel.WriteCSV(c, fileName, []string{"时间", "最小值", "最大值", "平均值", "最后值", "数据点数"}, rows); err != nil {
+			response.WriteBizError(c, err)
+		}
+		return
+	}
+
+	list, err := h.svc.GetHistoryDevicePropertyList(ctx, &iot2.IotDevicePropertyHistoryListReqVO{
+		DeviceID:   req.DeviceID,
+		Identifier: req.Identifier,
+		Times:      req.Times,
+	})
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	// 历史记录按时间倒序返回，导出按时间正序
+	rows := make([][]string, 0, len(list))
+	for i := len(list) - 1; i >= 0; i-- {
+		rows = append(rows, []string{list[i].UpdateTime.Format(time.DateTime), list[i].Value})
+	}
+	if err := excel.WriteCSV(c, fileName, []string{"时间", "值"}, rows); err != nil {
+		response.WriteBizError(c, err)
+	}
+}
+
+func formatCsvFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,77 @@
+package iot
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/response"
+)
+
+type DeviceShadowHandler struct {
+	svc              *iotsvc.DeviceShadowService
+	deviceSvc        *iotsvc.DeviceService
+	deviceMessageSvc *iotsvc.DeviceMessageService
+}
+
+func NewDeviceShadowHandler(
+	svc *iotsvc.DeviceShadowService,
+	deviceSvc *iotsvc.DeviceService,
+	deviceMessageSvc *iotsvc.DeviceMessageService,
+) *DeviceShadowHandler {
+	return &DeviceShadowHandler{
+		svc:              svc,
+		deviceSvc:        deviceSvc,
+		deviceMessageSvc: deviceMessageSvc,
+	}
+}
+
+// Get 获取设备影子
+func (h *DeviceShadowHandler) Get(c *gin.Context) {
+	deviceID, err := strconv.ParseInt(c.Query("deviceId"), 10, 64)
+	if err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	device, err := h.deviceSvc.Get(c, deviceID)
+	if err != nil || device == nil {
+		response.WriteBizError(c, model.ErrDeviceNotExists)
+		return
+	}
+	shadow, err := h.svc.GetShadow(c, device)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, h.svc.BuildShadowRespVO(shadow))
+}
+
+// UpdateDesired 更新设备影子期望状态，设备在线时立即下发差量
+func (h *DeviceShadowHandler) UpdateDesired(c *gin.Context) {
+	var r iot2.IotDeviceShadowUpdateDesiredReqVO
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.BindingErr(err))
+		return
+	}
+	device, err := h.deviceSvc.Get(c, r.DeviceID)
+	if err != nil || device == nil {
+		response.WriteBizError(c, model.ErrDeviceNotExists)
+		return
+	}
+	shadow, err := h.svc.UpdateDesired(c, device, r.Desired, r.Version)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	if device.State == consts.IotDeviceStateOnline {
+		if err := h.deviceMessageSvc.SendShadowDelta(c, device); err != nil {
+			response.WriteBizError(c, err)
+			return
+		}
+	}
+	response.WriteSuccess(c, h.svc.BuildShadowRespVO(shadow))
+}
@@ -0,0 +1,85 @@
+package iot
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/response"
+)
+
+// GeofenceHandler 地理围栏处理器
+type GeofenceHandler struct {
+	svc *iotsvc.GeofenceService
+}
+
+func NewGeofenceHandler(svc *iotsvc.GeofenceService) *GeofenceHandler {
+	return &GeofenceHandler{svc: svc}
+}
+
+// Create 创建地理围栏
+func (h *GeofenceHandler) Create(c *gin.Context) {
+	var r iot2.IotGeofenceSaveReqVO
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.BindingErr(err))
+		return
+	}
+	id, err := h.svc.Create(c, &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, id)
+}
+
+// Update 更新地理围栏
+func (h *GeofenceHandler) Update(c *gin.Context) {
+	var r iot2.IotGeofenceSaveReqVO
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.BindingErr(err))
+		return
+	}
+	if err := h.svc.Update(c, &r); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
+// Delete 删除地理围栏
+func (h *GeofenceHandler) Delete(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Query("id"), 10, 64)
+	if err := h.svc.Delete(c, id); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
+// Get 获取地理围栏
+func (h *GeofenceHandler) Get(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Query("id"), 10, 64)
+	geofence, err := h.svc.Get(c, id)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, geofence)
+}
+
+// Page 获取地理围栏分页
+func (h *GeofenceHandler) Page(c *gin.Context) {
+	var r iot2.IotGeofencePageReqVO
+	if err := c.ShouldBindQuery(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	page, err := h.svc.GetPage(c, &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WritePage(c, page.Total, page.List)
+}
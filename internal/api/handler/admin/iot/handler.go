@@ -21,6 +21,9 @@ var ProviderSet = wire.NewSet(
 	NewStatisticsHandler,
 	NewDeviceMessageHandler,
 	NewDevicePropertyHandler,
+	NewDeviceShadowHandler,
+	NewGeofenceHandler,
+	NewDeviceLocationHandler,
 	NewHandlers,
 )
 
@@ -40,6 +43,9 @@ type Handlers struct {
 	Statistics      *StatisticsHandler
 	DeviceMessage   *DeviceMessageHandler
 	DeviceProperty  *DevicePropertyHandler
+	DeviceShadow    *DeviceShadowHandler
+	Geofence        *GeofenceHandler
+	DeviceLocation  *DeviceLocationHandler
 }
 
 func NewHandlers(
@@ -58,6 +64,9 @@ func NewHandlers(
 	statistics *StatisticsHandler,
 	deviceMessage *DeviceMessageHandler,
 	deviceProperty *DevicePropertyHandler,
+	deviceShadow *DeviceShadowHandler,
+	geofence *GeofenceHandler,
+	deviceLocation *DeviceLocationHandler,
 ) *Handlers {
 	return &Handlers{
 		Product:         product,
@@ -75,6 +84,9 @@ func NewHandlers(
 		Statistics:      statistics,
 		DeviceMessage:   deviceMessage,
 		DeviceProperty:  deviceProperty,
+		DeviceShadow:    deviceShadow,
+		Geofence:        geofence,
+		DeviceLocation:  deviceLocation,
 	}
 }
 
@@ -111,11 +123,15 @@ func NewThingModelHandler(svc *iotsvc.ThingModelService) *ThingModelHandler {
 
 // DeviceGroupHandler 设备分组处理器
 type DeviceGroupHandler struct {
-	svc *iotsvc.DeviceGroupService
+	svc      *iotsvc.DeviceGroupService
+	batchSvc *iotsvc.DeviceGroupBatchService
 }
 
-func NewDeviceGroupHandler(svc *iotsvc.DeviceGroupService) *DeviceGroupHandler {
-	return &DeviceGroupHandler{svc: svc}
+func NewDeviceGroupHandler(svc *iotsvc.DeviceGroupService, batchSvc *iotsvc.DeviceGroupBatchService) *DeviceGroupHandler {
+	return &DeviceGroupHandler{
+		svc:      svc,
+		batchSvc: batchSvc,
+	}
 }
 
 // OtaFirmwareHandler OTA固件处理器
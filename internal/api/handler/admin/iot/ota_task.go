@@ -46,6 +46,9 @@ func (h *OtaTaskHandler) Get(c *gin.Context) {
 		DeviceScope:        task.DeviceScope,
 		DeviceTotalCount:   task.DeviceTotalCount,
 		DeviceSuccessCount: task.DeviceSuccessCount,
+		MaxConcurrency:     task.MaxConcurrency,
+		RolloutRate:        task.RolloutRate,
+		Timeout:            task.Timeout,
 		CreateTime:         task.CreateTime,
 	}
 	response.WriteSuccess(c, resp)
@@ -86,6 +89,9 @@ func (h *OtaTaskHandler) Page(c *gin.Context) {
 			DeviceScope:        item.DeviceScope,
 			DeviceTotalCount:   item.DeviceTotalCount,
 			DeviceSuccessCount: item.DeviceSuccessCount,
+			MaxConcurrency:     item.MaxConcurrency,
+			RolloutRate:        item.RolloutRate,
+			Timeout:            item.Timeout,
 			CreateTime:         item.CreateTime,
 		})
 	}
@@ -116,6 +122,7 @@ func (h *OtaTaskHandler) RecordPage(c *gin.Context) {
 			Status:         item.Status,
 			Progress:       item.Progress,
 			Description:    item.Description,
+			PushTime:       item.PushTime,
 			CreateTime:     item.CreateTime,
 		})
 	}
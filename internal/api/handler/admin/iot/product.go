@@ -53,6 +53,18 @@ func (h *ProductHandler) UpdateStatus(c *gin.Context) {
 	response.WriteSuccess(c, true)
 }
 
+// ResetSecret 重置产品密钥（一型一密）
+func (h *ProductHandler) ResetSecret(c *gin.Context) {
+	idStr := c.Query("id")
+	id, _ := strconv.ParseInt(idStr, 10, 64)
+	secret, err := h.svc.ResetSecret(c, id)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, secret)
+}
+
 // Delete 删除产品
 func (h *ProductHandler) Delete(c *gin.Context) {
 	idStr := c.Query("id")
@@ -136,6 +148,21 @@ func (h *ProductHandler) Page(c *gin.Context) {
 	response.WritePage(c, page.Total, list)
 }
 
+// DebugCodec 编解码调试
+func (h *ProductHandler) DebugCodec(c *gin.Context) {
+	var r iot2.IotProductCodecDebugReqVO
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.BindingErr(err))
+		return
+	}
+	res, err := h.svc.DebugCodec(c, &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, res)
+}
+
 func convertProductToRespVO(product *model.IotProductDO, category *model.IotProductCategoryDO) *iot2.IotProductRespVO {
 	resp := &iot2.IotProductRespVO{
 		ID:           product.ID,
@@ -150,7 +177,12 @@ func convertProductToRespVO(product *model.IotProductDO, category *model.IotProd
 		NetType:      product.NetType,
 		LocationType: product.LocationType,
 		CodecType:    product.CodecType,
+		CodecConfig:  product.CodecConfig,
 		CreateTime:   product.CreateTime,
+
+		PropertyRetentionDays: product.PropertyRetentionDays,
+		ProductSecret:         product.ProductSecret,
+		RegisterEnabled:       product.RegisterEnabled,
 	}
 	if category != nil {
 		resp.CategoryName = category.Name
@@ -1,6 +1,9 @@
 package iot
 
 import (
+	"encoding/json"
+	"fmt"
+	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -141,6 +144,111 @@ func (h *ThingModelHandler) GetTSL(c *gin.Context) {
 	response.WriteSuccess(c, tsl)
 }
 
+// ExportTSL 导出物模型 TSL 文件（JSON）
+func (h *ThingModelHandler) ExportTSL(c *gin.Context) {
+	var r iot2.IotThingModelTSLExportReqVO
+	if err := c.ShouldBindQuery(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	tsl, err := h.svc.ExportTSL(c.Request.Context(), r.ProductID, r.VersionID)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	data, err := json.MarshalIndent(tsl, "", "  ")
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-tsl.json", tsl.ProductKey))
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// ImportTSL 导入物模型 TSL，覆盖产品的物模型草稿
+func (h *ThingModelHandler) ImportTSL(c *gin.Context) {
+	var r iot2.IotThingModelTSLImportReqVO
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.BindingErr(err))
+		return
+	}
+	if err := h.svc.ImportTSL(c.Request.Context(), &r); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
+// PublishVersion 发布物模型版本
+func (h *ThingModelHandler) PublishVersion(c *gin.Context) {
+	var r iot2.IotThingModelVersionPublishReqVO
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.BindingErr(err))
+		return
+	}
+	id, err := h.svc.PublishVersion(c.Request.Context(), &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, id)
+}
+
+// RollbackVersion 回滚到历史物模型版本
+func (h *ThingModelHandler) RollbackVersion(c *gin.Context) {
+	var r iot2.IotThingModelVersionRollbackReqVO
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.BindingErr(err))
+		return
+	}
+	if err := h.svc.RollbackVersion(c.Request.Context(), r.ID); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
+// GetVersion 获取物模型版本
+func (h *ThingModelHandler) GetVersion(c *gin.Context) {
+	id, _ := strconv.ParseInt(c.Query("id"), 10, 64)
+	version, err := h.svc.GetVersion(c.Request.Context(), id)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, version)
+}
+
+// VersionList 获取产品的物模型版本列表
+func (h *ThingModelHandler) VersionList(c *gin.Context) {
+	var r iot2.IotThingModelListReqVO
+	if err := c.ShouldBindQuery(&r); err != nil {
+		response.WriteBizError(c, errors.BindingErr(err))
+		return
+	}
+	list, err := h.svc.GetVersionList(c.Request.Context(), r.ProductID)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, list)
+}
+
+// DiffVersion 对比物模型版本
+func (h *ThingModelHandler) DiffVersion(c *gin.Context) {
+	var r iot2.IotThingModelVersionDiffReqVO
+	if err := c.ShouldBindQuery(&r); err != nil {
+		response.WriteBizError(c, errors.BindingErr(err))
+		return
+	}
+	diff, err := h.svc.DiffVersion(c.Request.Context(), &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, diff)
+}
+
 
 // convertToRespVO 转换数据库实体为响应 VO
 func convertToRespVO(tm *model.IotThingModelDO) *iot2.IotThingModelRespVO {
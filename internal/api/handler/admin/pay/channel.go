@@ -1,6 +1,7 @@
 package pay
 
 import (
+	"slices"
 	"strconv"
 
 	"github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/pay"
@@ -121,13 +122,36 @@ func (h *PayChannelHandler) GetEnableChannelCodeList(c *gin.Context) {
 		return
 	}
 
+	// 同一渠道编码可配置多个商户号，编码去重
 	codes := make([]string, 0, len(channels))
 	for _, ch := range channels {
-		codes = append(codes, ch.Code)
+		if !slices.Contains(codes, ch.Code) {
+			codes = append(codes, ch.Code)
+		}
 	}
 	response.WriteSuccess(c, codes)
 }
 
+// GetChannelList 获得指定应用的支付渠道列表，可按渠道编码过滤，用于配置多商户号与路由规则
+func (h *PayChannelHandler) GetChannelList(c *gin.Context) {
+	appId, err := strconv.ParseInt(c.Query("appId"), 10, 64)
+	if err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	channels, err := h.svc.GetChannelList(c, appId, c.Query("code"))
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+
+	list := make([]*pay.PayChannelResp, 0, len(channels))
+	for _, ch := range channels {
+		list = append(list, convertChannelResp(ch))
+	}
+	response.WriteSuccess(c, list)
+}
+
 func convertChannelResp(channel *payModel.PayChannel) *pay.PayChannelResp {
 	if channel == nil {
 		return nil
@@ -0,0 +1,217 @@
+package pay
+
+import (
+	pay2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/pay"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model/pay"
+	paySvc "github.com/wxlbd/ruoyi-mall-go/internal/service/pay"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/response"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PayChannelRouteHandler struct {
+	svc        *paySvc.PayChannelRouteService
+	appSvc     *paySvc.PayAppService
+	channelSvc *paySvc.PayChannelService
+}
+
+func NewPayChannelRouteHandler(svc *paySvc.PayChannelRouteService, appSvc *paySvc.PayAppService, channelSvc *paySvc.PayChannelService) *PayChannelRouteHandler {
+	return &PayChannelRouteHandler{
+		svc:        svc,
+		appSvc:     appSvc,
+		channelSvc: channelSvc,
+	}
+}
+
+// ========== 路由规则 ==========
+
+// CreateRule 创建路由规则
+func (h *PayChannelRouteHandler) CreateRule(c *gin.Context) {
+	var r pay2.PayChannelRouteRuleCreateReq
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	id, err := h.svc.CreateRule(c, &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, id)
+}
+
+// UpdateRule 更新路由规则
+func (h *PayChannelRouteHandler) UpdateRule(c *gin.Context) {
+	var r pay2.PayChannelRouteRuleUpdateReq
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	if err := h.svc.UpdateRule(c, &r); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
+// DeleteRule 删除路由规则
+func (h *PayChannelRouteHandler) DeleteRule(c *gin.Context) {
+	id := utils.ParseInt64(c.Query("id"))
+	if err := h.svc.DeleteRule(c, id); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
+// GetRule 获得路由规则
+func (h *PayChannelRouteHandler) GetRule(c *gin.Context) {
+	id := utils.ParseInt64(c.Query("id"))
+	rule, err := h.svc.GetRule(c, id)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	list, err := h.convertChannelRouteRuleList(c, []*pay.PayChannelRouteRule{rule})
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, list[0])
+}
+
+// GetRulePage 获得路由规则分页
+func (h *PayChannelRouteHandler) GetRulePage(c *gin.Context) {
+	var r pay2.PayChannelRouteRulePageReq
+	if err := c.ShouldBindQuery(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	pageResult, err := h.svc.GetRulePage(c, &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	list, err := h.convertChannelRouteRuleList(c, pageResult.List)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, pagination.PageResult[*pay2.PayChannelRouteRuleResp]{
+		List:  list,
+		Total: pageResult.Total,
+	})
+}
+
+// ========== 渠道健康 ==========
+
+// GetHealthList 获得支付应用下渠道的健康状况，可按渠道编码过滤
+func (h *PayChannelRouteHandler) GetHealthList(c *gin.Context) {
+	appID := utils.ParseInt64(c.Query("appId"))
+	if appID == 0 {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	channels, err := h.channelSvc.GetChannelList(c, appID, c.Query("code"))
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	channelIDs := make([]int64, 0, len(channels))
+	for _, channel := range channels {
+		channelIDs = append(channelIDs, channel.ID)
+	}
+	healthMap, err := h.svc.GetChannelHealthMap(c, channelIDs)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+
+	list := make([]*pay2.PayChannelHealthResp, 0, len(channels))
+	for _, channel := range channels {
+		resp := &pay2.PayChannelHealthResp{
+			ChannelID: channel.ID,
+			AppID:     channel.AppID,
+			Code:      channel.Code,
+			Remark:    channel.Remark,
+			Status:    channel.Status,
+			Available: paySvc.IsPayChannelAvailable(healthMap[channel.ID]),
+		}
+		if health := healthMap[channel.ID]; health != nil {
+			resp.SuccessCount = health.SuccessCount
+			resp.FailureCount = health.FailureCount
+			resp.ContinuousFailures = health.ContinuousFailures
+			resp.LastSuccessTime = health.LastSuccessTime
+			resp.LastFailureTime = health.LastFailureTime
+			resp.LastFailureMsg = health.LastFailureMsg
+		}
+		list = append(list, resp)
+	}
+	response.WriteSuccess(c, list)
+}
+
+// ResetHealth 清空渠道的健康计数，暂停路由的渠道立即恢复
+func (h *PayChannelRouteHandler) ResetHealth(c *gin.Context) {
+	channelID := utils.ParseInt64(c.Query("channelId"))
+	if err := h.svc.ResetChannelHealth(c, channelID); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
+// convertChannelRouteRuleList 转换路由规则，填充应用名称与候选渠道备注
+func (h *PayChannelRouteHandler) convertChannelRouteRuleList(c *gin.Context, rules []*pay.PayChannelRouteRule) ([]*pay2.PayChannelRouteRuleResp, error) {
+	appIds := make([]int64, 0, len(rules))
+	for _, rule := range rules {
+		appIds = append(appIds, rule.AppID)
+	}
+	appMap, _ := h.appSvc.GetAppMap(c, appIds)
+	channels, err := h.channelSvc.GetChannelListByAppIds(c, appIds)
+	if err != nil {
+		return nil, err
+	}
+	channelMap := make(map[int64]*pay.PayChannel, len(channels))
+	for _, channel := range channels {
+		channelMap[channel.ID] = channel
+	}
+
+	list := make([]*pay2.PayChannelRouteRuleResp, 0, len(rules))
+	for _, rule := range rules {
+		resp := &pay2.PayChannelRouteRuleResp{
+			ID:          rule.ID,
+			AppID:       rule.AppID,
+			Name:        rule.Name,
+			ChannelCode: rule.ChannelCode,
+			Sort:        rule.Sort,
+			MinPrice:    rule.MinPrice,
+			MaxPrice:    rule.MaxPrice,
+			StartTime:   rule.StartTime,
+			EndTime:     rule.EndTime,
+			Terminals:   rule.Terminals,
+			TenantIDs:   rule.TenantIDs,
+			Targets:     make([]*pay2.PayChannelRouteTargetResp, 0, len(rule.Targets)),
+			Status:      rule.Status,
+			Remark:      rule.Remark,
+			CreateTime:  rule.CreateTime,
+		}
+		if app, ok := appMap[rule.AppID]; ok {
+			resp.AppName = app.Name
+		}
+		for _, target := range rule.Targets {
+			targetResp := &pay2.PayChannelRouteTargetResp{
+				ChannelID: target.ChannelID,
+				Weight:    target.Weight,
+			}
+			if channel, ok := channelMap[target.ChannelID]; ok {
+				targetResp.ChannelRemark = channel.Remark
+			}
+			resp.Targets = append(resp.Targets, targetResp)
+		}
+		list = append(list, resp)
+	}
+	return list, nil
+}
@@ -12,18 +12,24 @@ var ProviderSet = wire.NewSet(
 	NewPayOrderHandler,
 	NewPayRefundHandler,
 	NewPayTransferHandler,
+	NewPayReconcileHandler,
+	NewPayProfitSharingHandler,
+	NewPayChannelRouteHandler,
 	NewHandlers,
 	wallet.ProviderSet,
 )
 
 type Handlers struct {
-	App      *PayAppHandler
-	Channel  *PayChannelHandler
-	Notify   *PayNotifyHandler
-	Order    *PayOrderHandler
-	Refund   *PayRefundHandler
-	Transfer *PayTransferHandler
-	Wallet   *wallet.Handlers
+	App           *PayAppHandler
+	Channel       *PayChannelHandler
+	Notify        *PayNotifyHandler
+	Order         *PayOrderHandler
+	Refund        *PayRefundHandler
+	Transfer      *PayTransferHandler
+	Reconcile     *PayReconcileHandler
+	ProfitSharing *PayProfitSharingHandler
+	ChannelRoute  *PayChannelRouteHandler
+	Wallet        *wallet.Handlers
 }
 
 func NewHandlers(
@@ -33,15 +39,21 @@ func NewHandlers(
 	order *PayOrderHandler,
 	refund *PayRefundHandler,
 	transfer *PayTransferHandler,
+	reconcile *PayReconcileHandler,
+	profitSharing *PayProfitSharingHandler,
+	channelRoute *PayChannelRouteHandler,
 	wallet *wallet.Handlers,
 ) *Handlers {
 	return &Handlers{
-		App:      app,
-		Channel:  channel,
-		Notify:   notify,
-		Order:    order,
-		Refund:   refund,
-		Transfer: transfer,
-		Wallet:   wallet,
+		App:           app,
+		Channel:       channel,
+		Notify:        notify,
+		Order:         order,
+		Refund:        refund,
+		Transfer:      transfer,
+		Reconcile:     reconcile,
+		ProfitSharing: profitSharing,
+		ChannelRoute:  channelRoute,
+		Wallet:        wallet,
 	}
 }
@@ -140,7 +140,7 @@ func (h *PayOrderHandler) SubmitPayOrder(c *gin.Context) {
 	}
 
 	// 1. Wallet payment case
-	if r.ChannelCode == "wallet" { // Assuming "wallet" is the code
+	if r.ChannelCode == consts.PayChannelWallet {
 		if r.ChannelExtras == nil {
 			r.ChannelExtras = make(map[string]string)
 		}
@@ -155,7 +155,7 @@ func (h *PayOrderHandler) SubmitPayOrder(c *gin.Context) {
 			response.WriteBizError(c, err)
 			return
 		}
-		r.ChannelExtras["wallet_id"] = strconv.FormatInt(wallet.ID, 10)
+		r.ChannelExtras[consts.PayChannelExtrasWalletID] = strconv.FormatInt(wallet.ID, 10)
 	}
 
 	// 2. Submit Order
@@ -284,6 +284,7 @@ func convertOrderResp(order *pay.PayOrder) *pay2.PayOrderResp {
 		RefundPrice:     int64(order.RefundPrice), // 转换为 int64
 		ChannelUserID:   order.ChannelUserID,
 		ChannelOrderNo:  order.ChannelOrderNo,
+		WalletPrice:     order.WalletPrice,
 		CreateTime:      order.CreateTime,
 		UpdateTime:      order.UpdateTime,
 		Creator:         order.Creator,
@@ -307,6 +308,9 @@ func convertExtensionResp(ext *pay.PayOrderExtension) *pay2.PayOrderExtensionRes
 		ChannelErrorCode:  ext.ChannelErrorCode,
 		ChannelErrorMsg:   ext.ChannelErrorMsg,
 		ChannelNotifyData: ext.ChannelNotifyData,
+		WalletID:          ext.WalletID,
+		WalletPrice:       ext.WalletPrice,
+		WalletStatus:      ext.WalletStatus,
 		CreateTime:        ext.CreateTime,
 	}
 }
@@ -0,0 +1,330 @@
+package pay
+
+import (
+	pay2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/pay"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model/pay"
+	paySvc "github.com/wxlbd/ruoyi-mall-go/internal/service/pay"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/response"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+)
+
+type PayProfitSharingHandler struct {
+	svc    *paySvc.PayProfitSharingService
+	appSvc *paySvc.PayAppService
+}
+
+func NewPayProfitSharingHandler(svc *paySvc.PayProfitSharingService, appSvc *paySvc.PayAppService) *PayProfitSharingHandler {
+	return &PayProfitSharingHandler{
+		svc:    svc,
+		appSvc: appSvc,
+	}
+}
+
+// ========== 分账接收方 ==========
+
+// CreateReceiver 创建分账接收方
+func (h *PayProfitSharingHandler) CreateReceiver(c *gin.Context) {
+	var r pay2.PayProfitSharingReceiverCreateReq
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	id, err := h.svc.CreateReceiver(c, &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, id)
+}
+
+// UpdateReceiver 更新分账接收方
+func (h *PayProfitSharingHandler) UpdateReceiver(c *gin.Context) {
+	var r pay2.PayProfitSharingReceiverUpdateReq
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	if err := h.svc.UpdateReceiver(c, &r); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
+// DeleteReceiver 删除分账接收方
+func (h *PayProfitSharingHandler) DeleteReceiver(c *gin.Context) {
+	id := utils.ParseInt64(c.Query("id"))
+	if err := h.svc.DeleteReceiver(c, id); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
+// GetReceiver 获得分账接收方
+func (h *PayProfitSharingHandler) GetReceiver(c *gin.Context) {
+	id := utils.ParseInt64(c.Query("id"))
+	receiver, err := h.svc.GetReceiver(c, id)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	app, _ := h.appSvc.GetApp(c, receiver.AppID)
+	response.WriteSuccess(c, convertProfitSharingReceiverResp(receiver, app))
+}
+
+// GetReceiverPage 获得分账接收方分页
+func (h *PayProfitSharingHandler) GetReceiverPage(c *gin.Context) {
+	var r pay2.PayProfitSharingReceiverPageReq
+	if err := c.ShouldBindQuery(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	pageResult, err := h.svc.GetReceiverPage(c, &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+
+	appIds := make([]int64, 0, len(pageResult.List))
+	for _, item := range pageResult.List {
+		appIds = append(appIds, item.AppID)
+	}
+	appMap, _ := h.appSvc.GetAppMap(c, appIds)
+
+	list := make([]*pay2.PayProfitSharingReceiverResp, 0, len(pageResult.List))
+	for _, item := range pageResult.List {
+		list = append(list, convertProfitSharingReceiverResp(item, appMap[item.AppID]))
+	}
+	response.WriteSuccess(c, pagination.PageResult[*pay2.PayProfitSharingReceiverResp]{
+		List:  list,
+		Total: pageResult.Total,
+	})
+}
+
+// ========== 分账规则 ==========
+
+// CreateRule 创建分账规则
+func (h *PayProfitSharingHandler) CreateRule(c *gin.Context) {
+	var r pay2.PayProfitSharingRuleCreateReq
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	id, err := h.svc.CreateRule(c, &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, id)
+}
+
+// UpdateRule 更新分账规则
+func (h *PayProfitSharingHandler) UpdateRule(c *gin.Context) {
+	var r pay2.PayProfitSharingRuleUpdateReq
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	if err := h.svc.UpdateRule(c, &r); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
+// DeleteRule 删除分账规则
+func (h *PayProfitSharingHandler) DeleteRule(c *gin.Context) {
+	id := utils.ParseInt64(c.Query("id"))
+	if err := h.svc.DeleteRule(c, id); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
+// GetRule 获得分账规则
+func (h *PayProfitSharingHandler) GetRule(c *gin.Context) {
+	id := utils.ParseInt64(c.Query("id"))
+	rule, err := h.svc.GetRule(c, id)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	list, err := h.convertProfitSharingRuleList(c, []*pay.PayProfitSharingRule{rule})
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, list[0])
+}
+
+// GetRulePage 获得分账规则分页
+func (h *PayProfitSharingHandler) GetRulePage(c *gin.Context) {
+	var r pay2.PayProfitSharingRulePageReq
+	if err := c.ShouldBindQuery(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	pageResult, err := h.svc.GetRulePage(c, &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	list, err := h.convertProfitSharingRuleList(c, pageResult.List)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, pagination.PageResult[*pay2.PayProfitSharingRuleResp]{
+		List:  list,
+		Total: pageResult.Total,
+	})
+}
+
+// ========== 分账单 ==========
+
+// GetOrder 获得分账单，包含分账明细与回退单
+func (h *PayProfitSharingHandler) GetOrder(c *gin.Context) {
+	id := utils.ParseInt64(c.Query("id"))
+	sharingOrder, err := h.svc.GetSharingOrder(c, id)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	items, err := h.svc.GetSharingOrderItems(c, id)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	returns, err := h.svc.GetSharingReturns(c, id)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+
+	app, _ := h.appSvc.GetApp(c, sharingOrder.AppID)
+	resp := convertProfitSharingOrderResp(sharingOrder, app)
+	resp.Items = make([]*pay2.PayProfitSharingOrderItemResp, 0, len(items))
+	for _, item := range items {
+		itemResp := &pay2.PayProfitSharingOrderItemResp{}
+		copier.Copy(itemResp, item)
+		resp.Items = append(resp.Items, itemResp)
+	}
+	resp.Returns = make([]*pay2.PayProfitSharingReturnResp, 0, len(returns))
+	for _, r := range returns {
+		returnResp := &pay2.PayProfitSharingReturnResp{}
+		copier.Copy(returnResp, r)
+		resp.Returns = append(resp.Returns, returnResp)
+	}
+	response.WriteSuccess(c, resp)
+}
+
+// GetOrderPage 获得分账单分页
+func (h *PayProfitSharingHandler) GetOrderPage(c *gin.Context) {
+	var r pay2.PayProfitSharingOrderPageReq
+	if err := c.ShouldBindQuery(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	pageResult, err := h.svc.GetSharingOrderPage(c, &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+
+	appIds := make([]int64, 0, len(pageResult.List))
+	for _, item := range pageResult.List {
+		appIds = append(appIds, item.AppID)
+	}
+	appMap, _ := h.appSvc.GetAppMap(c, appIds)
+
+	list := make([]*pay2.PayProfitSharingOrderResp, 0, len(pageResult.List))
+	for _, item := range pageResult.List {
+		list = append(list, convertProfitSharingOrderResp(item, appMap[item.AppID]))
+	}
+	response.WriteSuccess(c, pagination.PageResult[*pay2.PayProfitSharingOrderResp]{
+		List:  list,
+		Total: pageResult.Total,
+	})
+}
+
+// RetryOrder 重试分账失败的分账单
+func (h *PayProfitSharingHandler) RetryOrder(c *gin.Context) {
+	id := utils.ParseInt64(c.Query("id"))
+	if err := h.svc.RetrySharingOrder(c.Request.Context(), id); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
+// Helpers
+
+func (h *PayProfitSharingHandler) convertProfitSharingRuleList(c *gin.Context, rules []*pay.PayProfitSharingRule) ([]*pay2.PayProfitSharingRuleResp, error) {
+	appIds := make([]int64, 0, len(rules))
+	var receiverIds []int64
+	for _, rule := range rules {
+		appIds = append(appIds, rule.AppID)
+		for _, ruleReceiver := range rule.Receivers {
+			receiverIds = append(receiverIds, ruleReceiver.ReceiverID)
+		}
+	}
+	appMap, _ := h.appSvc.GetAppMap(c, appIds)
+	receiverMap, err := h.svc.GetReceiverMap(c, receiverIds)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*pay2.PayProfitSharingRuleResp, 0, len(rules))
+	for _, rule := range rules {
+		resp := &pay2.PayProfitSharingRuleResp{
+			ID:         rule.ID,
+			AppID:      rule.AppID,
+			Name:       rule.Name,
+			Status:     rule.Status,
+			Remark:     rule.Remark,
+			CreateTime: rule.CreateTime,
+			Receivers:  make([]*pay2.PayProfitSharingRuleReceiverResp, 0, len(rule.Receivers)),
+		}
+		if app, ok := appMap[rule.AppID]; ok {
+			resp.AppName = app.Name
+		}
+		for _, ruleReceiver := range rule.Receivers {
+			receiverResp := &pay2.PayProfitSharingRuleReceiverResp{
+				ReceiverID: ruleReceiver.ReceiverID,
+				Ratio:      ruleReceiver.Ratio,
+			}
+			if receiver, ok := receiverMap[ruleReceiver.ReceiverID]; ok {
+				receiverResp.ReceiverName = receiver.Name
+				receiverResp.ReceiverType = receiver.Type
+			}
+			resp.Receivers = append(resp.Receivers, receiverResp)
+		}
+		list = append(list, resp)
+	}
+	return list, nil
+}
+
+func convertProfitSharingReceiverResp(receiver *pay.PayProfitSharingReceiver, app *pay.PayApp) *pay2.PayProfitSharingReceiverResp {
+	r := &pay2.PayProfitSharingReceiverResp{}
+	copier.Copy(r, receiver)
+	if app != nil {
+		r.AppName = app.Name
+	}
+	return r
+}
+
+func convertProfitSharingOrderResp(sharingOrder *pay.PayProfitSharingOrder, app *pay.PayApp) *pay2.PayProfitSharingOrderResp {
+	r := &pay2.PayProfitSharingOrderResp{}
+	copier.Copy(r, sharingOrder)
+	if app != nil {
+		r.AppName = app.Name
+	}
+	return r
+}
@@ -0,0 +1,299 @@
+package pay
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	pay2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/pay"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model/pay"
+	paySvc "github.com/wxlbd/ruoyi-mall-go/internal/service/pay"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/excel"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/response"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jinzhu/copier"
+)
+
+// reconcileBillMaxSize 上传对账单文件的大小上限
+const reconcileBillMaxSize = 50 << 20
+
+type PayReconcileHandler struct {
+	svc    *paySvc.PayReconcileService
+	appSvc *paySvc.PayAppService
+}
+
+func NewPayReconcileHandler(svc *paySvc.PayReconcileService, appSvc *paySvc.PayAppService) *PayReconcileHandler {
+	return &PayReconcileHandler{
+		svc:    svc,
+		appSvc: appSvc,
+	}
+}
+
+// UploadBill 上传渠道对账单并对账
+func (h *PayReconcileHandler) UploadBill(c *gin.Context) {
+	var r pay2.PayReconcileBillUploadReq
+	if err := c.ShouldBind(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	if file.Size > reconcileBillMaxSize {
+		response.WriteBizError(c, errors.NewBizError(errors.ParamErrCode, "对账单文件不能超过 50MB"))
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+
+	id, err := h.svc.UploadBill(c.Request.Context(), &r, file.Filename, data)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, id)
+}
+
+// DownloadBill 从渠道下载对账单并对账
+func (h *PayReconcileHandler) DownloadBill(c *gin.Context) {
+	var r pay2.PayReconcileBillDownloadReq
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	id, err := h.svc.DownloadBill(c.Request.Context(), &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, id)
+}
+
+// GetBill 获得对账单
+func (h *PayReconcileHandler) GetBill(c *gin.Context) {
+	id := utils.ParseInt64(c.Query("id"))
+	bill, err := h.svc.GetBill(c, id)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	app, _ := h.appSvc.GetApp(c, bill.AppID)
+	response.WriteSuccess(c, convertReconcileBillResp(bill, app))
+}
+
+// GetBillPage 获得对账单分页
+func (h *PayReconcileHandler) GetBillPage(c *gin.Context) {
+	var r pay2.PayReconcileBillPageReq
+	if err := c.ShouldBindQuery(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	pageResult, err := h.svc.GetBillPage(c, &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+
+	appIds := make([]int64, 0, len(pageResult.List))
+	for _, item := range pageResult.List {
+		appIds = append(appIds, item.AppID)
+	}
+	appMap, _ := h.appSvc.GetAppMap(c, appIds)
+
+	list := make([]*pay2.PayReconcileBillResp, 0, len(pageResult.List))
+	for _, item := range pageResult.List {
+		list = append(list, convertReconcileBillResp(item, appMap[item.AppID]))
+	}
+	response.WriteSuccess(c, pagination.PageResult[*pay2.PayReconcileBillResp]{
+		List:  list,
+		Total: pageResult.Total,
+	})
+}
+
+// GetDiscrepancyPage 获得对账差异分页
+func (h *PayReconcileHandler) GetDiscrepancyPage(c *gin.Context) {
+	var r pay2.PayReconcileDiscrepancyPageReq
+	if err := c.ShouldBindQuery(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	pageResult, err := h.svc.GetDiscrepancyPage(c, &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+
+	appIds := make([]int64, 0, len(pageResult.List))
+	for _, item := range pageResult.List {
+		appIds = append(appIds, item.AppID)
+	}
+	appMap, _ := h.appSvc.GetAppMap(c, appIds)
+
+	list := make([]*pay2.PayReconcileDiscrepancyResp, 0, len(pageResult.List))
+	for _, item := range pageResult.List {
+		list = append(list, convertReconcileDiscrepancyResp(item, appMap[item.AppID]))
+	}
+	response.WriteSuccess(c, pagination.PageResult[*pay2.PayReconcileDiscrepancyResp]{
+		List:  list,
+		Total: pageResult.Total,
+	})
+}
+
+// ExportDiscrepancyExcel 导出对账差异报表 Excel
+func (h *PayReconcileHandler) ExportDiscrepancyExcel(c *gin.Context) {
+	var r pay2.PayReconcileDiscrepancyExportReq
+	if err := c.ShouldBindQuery(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	list, err := h.svc.GetDiscrepancyList(c, &r)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+
+	appIds := make([]int64, 0, len(list))
+	for _, item := range list {
+		appIds = append(appIds, item.AppID)
+	}
+	appMap, _ := h.appSvc.GetAppMap(c, appIds)
+
+	rows := make([]*pay2.PayReconcileDiscrepancyExcelVO, 0, len(list))
+	for _, item := range list {
+		row := &pay2.PayReconcileDiscrepancyExcelVO{
+			ID:              item.ID,
+			BillDate:        item.BillDate.Format(time.DateOnly),
+			ChannelType:     reconcileChannelTypeName(item.ChannelType),
+			BizType:         reconcileBizTypeName(item.BizType),
+			Type:            reconcileDiscrepancyTypeName(item.Type),
+			OutTradeNo:      item.OutTradeNo,
+			ChannelOrderNo:  item.ChannelOrderNo,
+			OutRefundNo:     item.OutRefundNo,
+			ChannelRefundNo: item.ChannelRefundNo,
+			LocalAmount:     formatReconcileAmount(item.LocalAmount),
+			ChannelAmount:   formatReconcileAmount(item.ChannelAmount),
+			Status:          reconcileDiscrepancyStatusName(item.Status),
+			ResolveRemark:   item.ResolveRemark,
+		}
+		if app, ok := appMap[item.AppID]; ok {
+			row.AppName = app.Name
+		}
+		if item.ResolveTime != nil {
+			row.ResolveTime = item.ResolveTime.Format(time.DateTime)
+		}
+		rows = append(rows, row)
+	}
+	if err := excel.WriteExcel(c, "对账差异.xlsx", "数据", rows); err != nil {
+		response.WriteBizError(c, err)
+	}
+}
+
+// SyncDiscrepancy 从渠道同步单据状态，修复对账差异
+func (h *PayReconcileHandler) SyncDiscrepancy(c *gin.Context) {
+	id := utils.ParseInt64(c.Query("id"))
+	if err := h.svc.SyncDiscrepancy(c.Request.Context(), id); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
+// IgnoreDiscrepancy 忽略对账差异
+func (h *PayReconcileHandler) IgnoreDiscrepancy(c *gin.Context) {
+	var r pay2.PayReconcileDiscrepancyIgnoreReq
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, errors.ErrParam)
+		return
+	}
+	if err := h.svc.IgnoreDiscrepancy(c.Request.Context(), &r); err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, true)
+}
+
+// Helpers
+
+func convertReconcileBillResp(bill *pay.PayReconcileBill, app *pay.PayApp) *pay2.PayReconcileBillResp {
+	r := &pay2.PayReconcileBillResp{}
+	copier.Copy(r, bill)
+	r.BillDate = bill.BillDate.Format(time.DateOnly)
+	if app != nil {
+		r.AppName = app.Name
+	}
+	return r
+}
+
+func convertReconcileDiscrepancyResp(d *pay.PayReconcileDiscrepancy, app *pay.PayApp) *pay2.PayReconcileDiscrepancyResp {
+	r := &pay2.PayReconcileDiscrepancyResp{}
+	copier.Copy(r, d)
+	r.BillDate = d.BillDate.Format(time.DateOnly)
+	if app != nil {
+		r.AppName = app.Name
+	}
+	return r
+}
+
+func formatReconcileAmount(amount *int) string {
+	if amount == nil {
+		return ""
+	}
+	return fmt.Sprintf("%.2f", float64(*amount)/100.0)
+}
+
+func reconcileChannelTypeName(channelType string) string {
+	switch channelType {
+	case consts.PayReconcileChannelAlipay:
+		return "支付宝"
+	case consts.PayReconcileChannelWeixin:
+		return "微信支付"
+	}
+	return channelType
+}
+
+func reconcileBizTypeName(bizType int) string {
+	if bizType == consts.PayReconcileBizTypeRefund {
+		return "退款"
+	}
+	return "支付"
+}
+
+func reconcileDiscrepancyTypeName(t int) string {
+	switch t {
+	case consts.PayReconcileDiscrepancyTypeLocalMissing:
+		return "本地缺失"
+	case consts.PayReconcileDiscrepancyTypeChannelMissing:
+		return "渠道缺失"
+	case consts.PayReconcileDiscrepancyTypeAmountMismatch:
+		return "金额不一致"
+	case consts.PayReconcileDiscrepancyTypeStatusMismatch:
+		return "状态不一致"
+	}
+	return "未知"
+}
+
+func reconcileDiscrepancyStatusName(status int) string {
+	switch status {
+	case consts.PayReconcileDiscrepancyStatusRepaired:
+		return "已修复"
+	case consts.PayReconcileDiscrepancyStatusIgnored:
+		return "已忽略"
+	}
+	return "待处理"
+}
@@ -2,6 +2,7 @@ package wallet
 
 import (
 	"strconv"
+	"time"
 
 	pay2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/pay"
 	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
@@ -80,8 +81,9 @@ func (h *PayWalletHandler) UpdateWalletBalance(c *gin.Context) {
 	}
 
 	// 更新钱包余额
-	// walletID, bizID, bizType, price
-	err = h.svc.AddWalletBalance(c, wallet.ID, strconv.FormatInt(r.UserID, 10), consts.PayWalletBizTypeUpdateBalance, r.Balance)
+	// 管理员每次修改都是独立的业务，业务编号追加时间戳，避免被视为重复入账
+	bizID := strconv.FormatInt(r.UserID, 10) + "_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	_, err = h.svc.AddWalletBalance(c, wallet.ID, bizID, consts.PayWalletBizTypeUpdateBalance, r.Balance)
 	if err != nil {
 		response.WriteBizError(c, err)
 		return
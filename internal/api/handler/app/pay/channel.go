@@ -1,6 +1,8 @@
 package pay
 
 import (
+	"slices"
+
 	"github.com/gin-gonic/gin"
 	paySvc "github.com/wxlbd/ruoyi-mall-go/internal/service/pay"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/response"
@@ -29,10 +31,12 @@ func (h *AppPayChannelHandler) GetEnableChannelCodeList(c *gin.Context) {
 		return
 	}
 
-	// 提取 code 集合
+	// 提取 code 集合，同一渠道编码可配置多个商户号，需要去重
 	codes := make([]string, 0, len(channels))
 	for _, channel := range channels {
-		codes = append(codes, channel.Code)
+		if !slices.Contains(codes, channel.Code) {
+			codes = append(codes, channel.Code)
+		}
 	}
 
 	response.WriteSuccess(c, codes)
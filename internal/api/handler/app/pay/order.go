@@ -5,6 +5,7 @@ import (
 
 	adminPay "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/pay"
 	"github.com/wxlbd/ruoyi-mall-go/internal/api/contract/app/pay"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
 	paySvc "github.com/wxlbd/ruoyi-mall-go/internal/service/pay"
 	payWalletSvc "github.com/wxlbd/ruoyi-mall-go/internal/service/pay/wallet"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/context"
@@ -82,8 +83,8 @@ func (h *AppPayOrderHandler) Submit(c *gin.Context) {
 		return
 	}
 
-	// 1. 钱包支付处理
-	if r.ChannelCode == "wallet" {
+	// 1. 钱包支付、钱包组合支付处理：使用当前用户的钱包
+	if r.ChannelCode == consts.PayChannelWallet || r.WalletPrice > 0 {
 		if r.ChannelExtras == nil {
 			r.ChannelExtras = make(map[string]string)
 		}
@@ -94,10 +95,15 @@ func (h *AppPayOrderHandler) Submit(c *gin.Context) {
 			response.WriteBizError(c, err)
 			return
 		}
-		r.ChannelExtras["walletId"] = strconv.FormatInt(wallet.ID, 10)
+		r.ChannelExtras[consts.PayChannelExtrasWalletID] = strconv.FormatInt(wallet.ID, 10)
 	}
 
-	// 2. 提交逻辑
+	// 2. 未传终端时取请求头中的终端，用于匹配支付路由规则
+	if r.Terminal == 0 {
+		r.Terminal, _ = strconv.Atoi(c.GetHeader("terminal"))
+	}
+
+	// 3. 提交逻辑
 	respVO, err := h.svc.SubmitOrder(c, &r.PayOrderSubmitReq, c.ClientIP())
 	if err != nil {
 		response.WriteBizError(c, err)
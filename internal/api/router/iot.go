@@ -21,6 +21,13 @@ func RegisterIotRoutes(r *gin.Engine, h *iot.Handlers, casbin *middleware.Casbin
 			thingModel.GET("/get-tsl", casbin.RequirePermission("iot:thing-model:query"), h.ThingModel.GetTSL)
 			thingModel.GET("/list", casbin.RequirePermission("iot:thing-model:query"), h.ThingModel.List)
 			thingModel.GET("/page", casbin.RequirePermission("iot:thing-model:query"), h.ThingModel.Page)
+			thingModel.GET("/export-tsl", casbin.RequirePermission("iot:thing-model:export"), h.ThingModel.ExportTSL)
+			thingModel.POST("/import-tsl", casbin.RequirePermission("iot:thing-model:import"), h.ThingModel.ImportTSL)
+			thingModel.POST("/version/publish", casbin.RequirePermission("iot:thing-model:publish"), h.ThingModel.PublishVersion)
+			thingModel.PUT("/version/rollback", casbin.RequirePermission("iot:thing-model:publish"), h.ThingModel.RollbackVersion)
+			thingModel.GET("/version/get", casbin.RequirePermission("iot:thing-model:query"), h.ThingModel.GetVersion)
+			thingModel.GET("/version/list", casbin.RequirePermission("iot:thing-model:query"), h.ThingModel.VersionList)
+			thingModel.GET("/version/diff", casbin.RequirePermission("iot:thing-model:query"), h.ThingModel.DiffVersion)
 		}
 
 		// 产品管理
@@ -29,11 +36,13 @@ func RegisterIotRoutes(r *gin.Engine, h *iot.Handlers, casbin *middleware.Casbin
 			product.POST("/create", casbin.RequirePermission("iot:product:create"), h.Product.Create)
 			product.PUT("/update", casbin.RequirePermission("iot:product:update"), h.Product.Update)
 			product.PUT("/update-status", casbin.RequirePermission("iot:product:update"), h.Product.UpdateStatus)
+			product.PUT("/reset-secret", casbin.RequirePermission("iot:product:update"), h.Product.ResetSecret)
 			product.DELETE("/delete", casbin.RequirePermission("iot:product:delete"), h.Product.Delete)
 			product.GET("/get", casbin.RequirePermission("iot:product:query"), h.Product.Get)
 			product.GET("/get-by-key", casbin.RequirePermission("iot:product:query"), h.Product.GetByKey)
 			product.GET("/simple-list", casbin.RequirePermission("iot:product:query"), h.Product.SimpleList)
 			product.GET("/page", casbin.RequirePermission("iot:product:query"), h.Product.Page)
+			product.POST("/codec-debug", casbin.RequirePermission("iot:product:query"), h.Product.DebugCodec)
 		}
 
 		// 设备管理
@@ -50,6 +59,16 @@ func RegisterIotRoutes(r *gin.Engine, h *iot.Handlers, casbin *middleware.Casbin
 			device.GET("/page", casbin.RequirePermission("iot:device:query"), h.Device.Page)
 			device.GET("/list-by-product-key-and-names", casbin.RequirePermission("iot:device:query"), h.Device.GetListByProductKeyAndNames)
 			device.GET("/simple-list", casbin.RequirePermission("iot:device:query"), h.Device.SimpleList)
+			device.GET("/get-import-template", casbin.RequirePermission("iot:device:import"), h.Device.GetImportTemplate)
+			device.POST("/import", casbin.RequirePermission("iot:device:import"), h.Device.Import)
+			device.GET("/export-excel", casbin.RequirePermission("iot:device:export"), h.Device.ExportExcel)
+			device.GET("/register-log/page", casbin.RequirePermission("iot:device:query"), h.Device.RegisterLogPage)
+			device.PUT("/certificate/renew", casbin.RequirePermission("iot:device:update"), h.Device.RenewCertificate)
+			device.GET("/certificate/ca", casbin.RequirePermission("iot:device:query"), h.Device.DownloadCACertificate)
+			device.GET("/certificate/crl", casbin.RequirePermission("iot:device:query"), h.Device.DownloadCRL)
+			device.GET("/shadow/get", casbin.RequirePermission("iot:device:query"), h.DeviceShadow.Get)
+			device.PUT("/shadow/update-desired", casbin.RequirePermission("iot:device:update"), h.DeviceShadow.UpdateDesired)
+			device.GET("/location/track", casbin.RequirePermission("iot:device:query"), h.DeviceLocation.GetTrack)
 		}
 
 		// 设备分组管理
@@ -61,6 +80,17 @@ func RegisterIotRoutes(r *gin.Engine, h *iot.Handlers, casbin *middleware.Casbin
 			deviceGroup.GET("/get", casbin.RequirePermission("iot:device-group:query"), h.DeviceGroup.Get)
 			deviceGroup.GET("/page", casbin.RequirePermission("iot:device-group:query"), h.DeviceGroup.Page)
 			deviceGroup.GET("/simple-list", h.DeviceGroup.SimpleList)
+			deviceGroup.POST("/batch-send", casbin.RequirePermission("iot:device:message-end"), h.DeviceGroup.BatchSend)
+		}
+
+		// 地理围栏管理
+		geofence := adminGroup.Group("/geofence")
+		{
+			geofence.POST("/create", casbin.RequirePermission("iot:geofence:create"), h.Geofence.Create)
+			geofence.PUT("/update", casbin.RequirePermission("iot:geofence:update"), h.Geofence.Update)
+			geofence.DELETE("/delete", casbin.RequirePermission("iot:geofence:delete"), h.Geofence.Delete)
+			geofence.GET("/get", casbin.RequirePermission("iot:geofence:query"), h.Geofence.Get)
+			geofence.GET("/page", casbin.RequirePermission("iot:geofence:query"), h.Geofence.Page)
 		}
 
 		// OTA 固件管理
@@ -77,6 +107,8 @@ func RegisterIotRoutes(r *gin.Engine, h *iot.Handlers, casbin *middleware.Casbin
 		otaTask := adminGroup.Group("/ota-task")
 		{
 			otaTask.POST("/create", casbin.RequirePermission("iot:ota-task:create"), h.OtaTask.Create)
+			otaTask.POST("/cancel", casbin.RequirePermission("iot:ota-task:cancel"), h.OtaTask.Cancel)
+			otaTask.GET("/get", casbin.RequirePermission("iot:ota-task:query"), h.OtaTask.Get)
 			otaTask.GET("/page", casbin.RequirePermission("iot:ota-task:query"), h.OtaTask.Page)
 		}
 
@@ -124,6 +156,7 @@ func RegisterIotRoutes(r *gin.Engine, h *iot.Handlers, casbin *middleware.Casbin
 			dataRule.DELETE("/delete", casbin.RequirePermission("iot:data-rule:delete"), h.DataRule.Delete)
 			dataRule.GET("/get", casbin.RequirePermission("iot:data-rule:query"), h.DataRule.Get)
 			dataRule.GET("/page", casbin.RequirePermission("iot:data-rule:query"), h.DataRule.Page)
+			dataRule.GET("/dead-letter/page", casbin.RequirePermission("iot:data-rule:query"), h.DataRule.DeadLetterPage)
 		}
 
 		// 场景联动管理
@@ -162,6 +195,7 @@ func RegisterIotRoutes(r *gin.Engine, h *iot.Handlers, casbin *middleware.Casbin
 			deviceMessage.GET("/page", casbin.RequirePermission("iot:device:message-query"), h.DeviceMessage.GetPage)
 			deviceMessage.GET("/pair-page", casbin.RequirePermission("iot:device:message-query"), h.DeviceMessage.GetPairPage)
 			deviceMessage.POST("/send", casbin.RequirePermission("iot:device:message-end"), h.DeviceMessage.Send)
+			deviceMessage.POST("/invoke", casbin.RequirePermission("iot:device:message-end"), h.DeviceMessage.Invoke)
 		}
 
 		// 设备属性
@@ -169,6 +203,8 @@ func RegisterIotRoutes(r *gin.Engine, h *iot.Handlers, casbin *middleware.Casbin
 		{
 			deviceProperty.GET("/get-latest", casbin.RequirePermission("iot:device:property-query"), h.DeviceProperty.GetLatest)
 			deviceProperty.GET("/history-list", casbin.RequirePermission("iot:device:property-query"), h.DeviceProperty.GetHistoryList)
+			deviceProperty.GET("/aggregate-list", casbin.RequirePermission("iot:device:property-query"), h.DeviceProperty.GetAggregateList)
+			deviceProperty.GET("/export-csv", casbin.RequirePermission("iot:device:property-query"), h.DeviceProperty.ExportCsv)
 		}
 	}
 }
@@ -35,6 +35,19 @@ func RegisterPayRoutes(engine *gin.Engine,
 			payChannel.DELETE("/delete", casbinMiddleware.RequirePermission("pay:channel:delete"), handlers.Channel.DeleteChannel)
 			payChannel.GET("/get", casbinMiddleware.RequirePermission("pay:channel:query"), handlers.Channel.GetChannel)
 			payChannel.GET("/get-enable-code-list", casbinMiddleware.RequirePermission("pay:channel:query"), handlers.Channel.GetEnableChannelCodeList)
+			payChannel.GET("/list", casbinMiddleware.RequirePermission("pay:channel:query"), handlers.Channel.GetChannelList)
+		}
+
+		// Pay Channel Route
+		payChannelRoute := payGroup.Group("/channel-route")
+		{
+			payChannelRoute.POST("/rule/create", casbinMiddleware.RequirePermission("pay:channel-route:create"), handlers.ChannelRoute.CreateRule)
+			payChannelRoute.PUT("/rule/update", casbinMiddleware.RequirePermission("pay:channel-route:update"), handlers.ChannelRoute.UpdateRule)
+			payChannelRoute.DELETE("/rule/delete", casbinMiddleware.RequirePermission("pay:channel-route:delete"), handlers.ChannelRoute.DeleteRule)
+			payChannelRoute.GET("/rule/get", casbinMiddleware.RequirePermission("pay:channel-route:query"), handlers.ChannelRoute.GetRule)
+			payChannelRoute.GET("/rule/page", casbinMiddleware.RequirePermission("pay:channel-route:query"), handlers.ChannelRoute.GetRulePage)
+			payChannelRoute.GET("/health/list", casbinMiddleware.RequirePermission("pay:channel-route:query"), handlers.ChannelRoute.GetHealthList)
+			payChannelRoute.PUT("/health/reset", casbinMiddleware.RequirePermission("pay:channel-route:update"), handlers.ChannelRoute.ResetHealth)
 		}
 
 		// Pay Order
@@ -69,6 +82,37 @@ func RegisterPayRoutes(engine *gin.Engine,
 			payTransfer.GET("/page", casbinMiddleware.RequirePermission("pay:transfer:query"), handlers.Transfer.GetTransferPage)
 		}
 
+		// Pay Reconcile
+		payReconcile := payGroup.Group("/reconcile")
+		{
+			payReconcile.POST("/upload-bill", casbinMiddleware.RequirePermission("pay:reconcile:create"), handlers.Reconcile.UploadBill)
+			payReconcile.POST("/download-bill", casbinMiddleware.RequirePermission("pay:reconcile:create"), handlers.Reconcile.DownloadBill)
+			payReconcile.GET("/get-bill", casbinMiddleware.RequirePermission("pay:reconcile:query"), handlers.Reconcile.GetBill)
+			payReconcile.GET("/bill-page", casbinMiddleware.RequirePermission("pay:reconcile:query"), handlers.Reconcile.GetBillPage)
+			payReconcile.GET("/discrepancy-page", casbinMiddleware.RequirePermission("pay:reconcile:query"), handlers.Reconcile.GetDiscrepancyPage)
+			payReconcile.GET("/discrepancy-export-excel", casbinMiddleware.RequirePermission("pay:reconcile:export"), handlers.Reconcile.ExportDiscrepancyExcel)
+			payReconcile.PUT("/sync-discrepancy", casbinMiddleware.RequirePermission("pay:reconcile:update"), handlers.Reconcile.SyncDiscrepancy)
+			payReconcile.PUT("/ignore-discrepancy", casbinMiddleware.RequirePermission("pay:reconcile:update"), handlers.Reconcile.IgnoreDiscrepancy)
+		}
+
+		// Pay Profit Sharing
+		payProfitSharing := payGroup.Group("/profit-sharing")
+		{
+			payProfitSharing.POST("/receiver/create", casbinMiddleware.RequirePermission("pay:profit-sharing:create"), handlers.ProfitSharing.CreateReceiver)
+			payProfitSharing.PUT("/receiver/update", casbinMiddleware.RequirePermission("pay:profit-sharing:update"), handlers.ProfitSharing.UpdateReceiver)
+			payProfitSharing.DELETE("/receiver/delete", casbinMiddleware.RequirePermission("pay:profit-sharing:delete"), handlers.ProfitSharing.DeleteReceiver)
+			payProfitSharing.GET("/receiver/get", casbinMiddleware.RequirePermission("pay:profit-sharing:query"), handlers.ProfitSharing.GetReceiver)
+			payProfitSharing.GET("/receiver/page", casbinMiddleware.RequirePermission("pay:profit-sharing:query"), handlers.ProfitSharing.GetReceiverPage)
+			payProfitSharing.POST("/rule/create", casbinMiddleware.RequirePermission("pay:profit-sharing:create"), handlers.ProfitSharing.CreateRule)
+			payProfitSharing.PUT("/rule/update", casbinMiddleware.RequirePermission("pay:profit-sharing:update"), handlers.ProfitSharing.UpdateRule)
+			payProfitSharing.DELETE("/rule/delete", casbinMiddleware.RequirePermission("pay:profit-sharing:delete"), handlers.ProfitSharing.DeleteRule)
+			payProfitSharing.GET("/rule/get", casbinMiddleware.RequirePermission("pay:profit-sharing:query"), handlers.ProfitSharing.GetRule)
+			payProfitSharing.GET("/rule/page", casbinMiddleware.RequirePermission("pay:profit-sharing:query"), handlers.ProfitSharing.GetRulePage)
+			payProfitSharing.GET("/order/get", casbinMiddleware.RequirePermission("pay:profit-sharing:query"), handlers.ProfitSharing.GetOrder)
+			payProfitSharing.GET("/order/page", casbinMiddleware.RequirePermission("pay:profit-sharing:query"), handlers.ProfitSharing.GetOrderPage)
+			payProfitSharing.PUT("/order/retry", casbinMiddleware.RequirePermission("pay:profit-sharing:update"), handlers.ProfitSharing.RetryOrder)
+		}
+
 		// Pay Wallet
 		payWallet := payGroup.Group("/wallet")
 		{
@@ -30,14 +30,70 @@ const (
 	IotDeviceMessageMethodEventPost = "thing.event.post" // 事件上报
 
 	// ========== 设备服务调用 ==========
-	IotDeviceMessageMethodServiceInvoke = "thing.service.invoke" // 服务调用
+	IotDeviceMessageMethodServiceInvoke      = "thing.service.invoke"       // 服务调用
+	IotDeviceMessageMethodServiceInvokeReply = "thing.service.invoke_reply" // 服务调用回复
 
 	// ========== 设备配置 ==========
 	IotDeviceMessageMethodConfigPush = "thing.config.push" // 配置推送
 
+	// ========== 拓扑管理 ==========
+	IotDeviceMessageMethodTopoAdd    = "thing.topo.add"    // 添加拓扑关系
+	IotDeviceMessageMethodTopoDelete = "thing.topo.delete" // 删除拓扑关系
+	IotDeviceMessageMethodTopoGet    = "thing.topo.get"    // 获取拓扑关系
+
+	// ========== 子设备会话 ==========
+	IotDeviceMessageMethodSubLogin  = "thing.sub.login"  // 子设备上线
+	IotDeviceMessageMethodSubLogout = "thing.sub.logout" // 子设备下线
+
+	// ========== 设备影子 ==========
+	IotDeviceMessageMethodShadowGet   = "thing.shadow.get"   // 设备获取影子
+	IotDeviceMessageMethodShadowDelta = "thing.shadow.delta" // 影子差量下发
+
 	// ========== OTA 固件 ==========
 	IotDeviceMessageMethodOtaUpgrade  = "thing.ota.upgrade"  // OTA 固定信息推送
 	IotDeviceMessageMethodOtaProgress = "thing.ota.progress" // OTA 升级进度上报
+	IotDeviceMessageMethodOtaInform   = "thing.ota.inform"   // OTA 固件版本上报
+
+	// ========== 设备定位 ==========
+	IotDeviceMessageMethodLocationPost = "thing.location.post" // 位置上报
+	IotDeviceMessageMethodGeofencePost = "thing.geofence.post" // 进出地理围栏（平台生成）
+)
+
+// IotLocationTypeEnum 设备定位方式
+const (
+	IotLocationTypeIP     = 1 // IP 定位
+	IotLocationTypeDevice = 2 // 设备上报
+	IotLocationTypeManual = 3 // 手动定位
+)
+
+// IotGeofenceTypeEnum 地理围栏类型
+const (
+	IotGeofenceTypeCircle  = 1 // 圆形
+	IotGeofenceTypePolygon = 2 // 多边形
+)
+
+// IotGeofenceEventEnum 地理围栏事件类型
+const (
+	IotGeofenceEventEnter = "enter" // 进入围栏
+	IotGeofenceEventExit  = "exit"  // 离开围栏
+)
+
+// IotDeviceAuthTypeEnum 设备认证类型
+const (
+	IotDeviceAuthTypeSecret = "secret" // 设备密钥（HMAC-SHA256 签名），默认
+	IotDeviceAuthTypeX509   = "x509"   // X.509 客户端证书（双向 TLS）
+)
+
+// IotDeviceCertificateStatusEnum 设备证书状态
+const (
+	IotDeviceCertificateStatusValid   = 0 // 有效
+	IotDeviceCertificateStatusRevoked = 1 // 已吊销
+)
+
+// IotDeviceRegisterProtocolEnum 设备动态注册协议
+const (
+	IotDeviceRegisterProtocolMqtt = "mqtt" // MQTT（内置 Broker）
+	IotDeviceRegisterProtocolHttp = "http" // HTTP
 )
 
 // IotOtaTaskStatusEnum OTA 升级任务状态
@@ -80,6 +136,39 @@ const (
 	IotThingModelTypeEvent    = 3 // 事件
 )
 
+// IotThingModelVersionStatusEnum IoT 物模型版本状态
+const (
+	IotThingModelVersionStatusHistory = 0 // 历史版本
+	IotThingModelVersionStatusActive  = 1 // 生效中
+)
+
+// IotThingModelDiffTypeEnum IoT 物模型版本对比的变更类型
+const (
+	IotThingModelDiffTypeCreate = "create" // 新增
+	IotThingModelDiffTypeUpdate = "update" // 修改
+	IotThingModelDiffTypeDelete = "delete" // 删除
+)
+
+// IotDataSpecsDataTypeEnum IoT 物模型数据类型
+const (
+	IotDataSpecsDataTypeInt    = "int"    // 整数型
+	IotDataSpecsDataTypeFloat  = "float"  // 单精度浮点型
+	IotDataSpecsDataTypeDouble = "double" // 双精度浮点型
+	IotDataSpecsDataTypeEnum   = "enum"   // 枚举型
+	IotDataSpecsDataTypeBool   = "bool"   // 布尔型
+	IotDataSpecsDataTypeText   = "text"   // 文本型
+	IotDataSpecsDataTypeDate   = "date"   // 时间型（毫秒时间戳）
+	IotDataSpecsDataTypeStruct = "struct" // 结构体
+	IotDataSpecsDataTypeArray  = "array"  // 数组
+)
+
+// IotDevicePropertyGranularityEnum IoT 设备属性聚合粒度
+const (
+	IotDevicePropertyGranularityMinute = "minute" // 分钟
+	IotDevicePropertyGranularityHour   = "hour"   // 小时
+	IotDevicePropertyGranularityDay    = "day"    // 天
+)
+
 // IotProductDeviceTypeEnum IoT 产品的设备类型
 const (
 	IotProductDeviceTypeDirect  = 0 // 直连设备
@@ -104,9 +193,60 @@ const (
 // IotDataSinkTypeEnum IoT 数据目的类型
 const (
 	IotDataSinkTypeHttp     = 1  // HTTP
+	IotDataSinkTypeMqtt     = 10 // MQTT
 	IotDataSinkTypeDatabase = 20 // Database
 	IotDataSinkTypeRedis    = 21 // Redis
 	IotDataSinkTypeRocketMQ = 30 // RocketMQ
 	IotDataSinkTypeRabbitMQ = 31 // RabbitMQ
 	IotDataSinkTypeKafka    = 32 // Kafka
+	IotDataSinkTypeFile     = 40 // 本地文件
+)
+
+// IotSceneRuleTriggerTypeEnum IoT 场景联动触发器类型
+const (
+	IotSceneRuleTriggerTypeDeviceStateUpdate   = 1   // 设备上下线变更
+	IotSceneRuleTriggerTypeDevicePropertyPost  = 2   // 物模型属性上报
+	IotSceneRuleTriggerTypeDeviceEventPost     = 3   // 设备事件上报
+	IotSceneRuleTriggerTypeDeviceServiceInvoke = 4   // 设备服务调用
+	IotSceneRuleTriggerTypeDeviceGeofence      = 5   // 设备进出地理围栏
+	IotSceneRuleTriggerTypeTimer               = 100 // 定时触发
+)
+
+// IotSceneRuleConditionTypeEnum IoT 场景联动条件类型
+const (
+	IotSceneRuleConditionTypeDeviceState    = 1 // 设备状态
+	IotSceneRuleConditionTypeDeviceProperty = 2 // 设备属性
+	IotSceneRuleConditionTypeCurrentTime    = 3 // 当前时间
+)
+
+// IotSceneRuleConditionOperatorEnum IoT 场景联动条件运算符
+const (
+	IotSceneRuleConditionOperatorEquals     = "="
+	IotSceneRuleConditionOperatorNotEquals  = "!="
+	IotSceneRuleConditionOperatorGt         = ">"
+	IotSceneRuleConditionOperatorGte        = ">="
+	IotSceneRuleConditionOperatorLt         = "<"
+	IotSceneRuleConditionOperatorLte        = "<="
+	IotSceneRuleConditionOperatorIn         = "in"
+	IotSceneRuleConditionOperatorNotIn      = "not in"
+	IotSceneRuleConditionOperatorBetween    = "between"
+	IotSceneRuleConditionOperatorNotBetween = "not between"
+	IotSceneRuleConditionOperatorLike       = "like"
+	IotSceneRuleConditionOperatorNotNull    = "not null"
+)
+
+// IotSceneRuleActionTypeEnum IoT 场景联动执行器类型
+const (
+	IotSceneRuleActionTypeDevicePropertySet   = 1   // 设备属性设置
+	IotSceneRuleActionTypeDeviceServiceInvoke = 2   // 设备服务调用
+	IotSceneRuleActionTypeDeviceShadowDesired = 3   // 设备影子期望状态设置
+	IotSceneRuleActionTypeAlertTrigger        = 100 // 告警触发
+	IotSceneRuleActionTypeAlertRecover        = 101 // 告警恢复
+)
+
+// IotWebSocketMessageTypeEnum IoT WebSocket 消息类型
+const (
+	IotWebSocketMessageTypeDeviceMessageSubscribe   = "IOT_DEVICE_MESSAGE_SUBSCRIBE"   // 订阅设备消息实时流（客户端发送，服务端回复订阅结果）
+	IotWebSocketMessageTypeDeviceMessageUnsubscribe = "IOT_DEVICE_MESSAGE_UNSUBSCRIBE" // 取消订阅设备消息实时流
+	IotWebSocketMessageTypeDeviceMessage            = "IOT_DEVICE_MESSAGE"             // 设备消息推送
 )
@@ -61,14 +61,24 @@ const (
 	PayOrderStatusClosed  = 20 // 支付关闭
 )
 
+// PayOrderWalletStatus 组合支付中钱包部分的状态
+const (
+	PayOrderWalletStatusNone     = 0  // 未使用钱包
+	PayOrderWalletStatusFrozen   = 10 // 已冻结：提交时冻结钱包余额，等待渠道支付结果
+	PayOrderWalletStatusPaid     = 20 // 已扣款：渠道支付成功后，冻结金额转为支出
+	PayOrderWalletStatusUnfrozen = 30 // 已解冻：渠道支付关闭或过期后，冻结金额退回余额
+)
+
 // PayWalletBizType 钱包业务类型 (对齐 Java: PayWalletBizTypeEnum)
 const (
-	PayWalletBizTypeRecharge       = 1 // 充值
-	PayWalletBizTypeRechargeRefund = 2 // 充值退款
-	PayWalletBizTypePayment        = 3 // 支付
-	PayWalletBizTypePaymentRefund  = 4 // 支付退款
-	PayWalletBizTypeUpdateBalance  = 5 // 更新余额 (Admin)
-	PayWalletBizTypeTransfer       = 6 // 转账
+	PayWalletBizTypeRecharge            = 1 // 充值
+	PayWalletBizTypeRechargeRefund      = 2 // 充值退款
+	PayWalletBizTypePayment             = 3 // 支付
+	PayWalletBizTypePaymentRefund       = 4 // 支付退款
+	PayWalletBizTypeUpdateBalance       = 5 // 更新余额 (Admin)
+	PayWalletBizTypeTransfer            = 6 // 转账
+	PayWalletBizTypeProfitSharing       = 7 // 分账收入
+	PayWalletBizTypeProfitSharingReturn = 8 // 分账回退
 )
 
 // PayChannel 支付渠道编码 (对齐 Java: PayChannelEnum)
@@ -89,6 +99,9 @@ const (
 	PayChannelWallet       = "wallet"        // 钱包支付
 )
 
+// PayChannelExtrasWalletID 钱包支付时，渠道额外参数中的钱包编号
+const PayChannelExtrasWalletID = "walletId"
+
 // IsPayChannelAlipay 判断是否为支付宝渠道
 func IsPayChannelAlipay(channelCode string) bool {
 	return len(channelCode) >= 7 && channelCode[:7] == "alipay_"
@@ -122,3 +135,89 @@ const (
 	// PayNotifyTypeTransfer 转账单
 	PayNotifyTypeTransfer = 3
 )
+
+// PayReconcileChannel 对账渠道类型，同一类型的渠道共用一份商户对账单
+const (
+	PayReconcileChannelAlipay = "alipay" // 支付宝
+	PayReconcileChannelWeixin = "wx"     // 微信支付
+)
+
+// GetPayReconcileChannel 获得渠道编码对应的对账渠道类型，不支持对账的渠道返回空
+func GetPayReconcileChannel(channelCode string) string {
+	switch {
+	case IsPayChannelAlipay(channelCode):
+		return PayReconcileChannelAlipay
+	case IsPayChannelWeixin(channelCode):
+		return PayReconcileChannelWeixin
+	default:
+		return ""
+	}
+}
+
+// PayReconcileBillStatus 对账单状态
+const (
+	PayReconcileBillStatusProcessing = 0  // 对账中
+	PayReconcileBillStatusSuccess    = 10 // 对账完成
+	PayReconcileBillStatusFailure    = 20 // 对账失败
+)
+
+// PayReconcileBillSource 对账单来源
+const (
+	PayReconcileBillSourceUpload   = 1 // 手动上传
+	PayReconcileBillSourceDownload = 2 // 渠道下载
+)
+
+// PayReconcileBizType 对账业务类型
+const (
+	PayReconcileBizTypeOrder  = 1 // 支付
+	PayReconcileBizTypeRefund = 2 // 退款
+)
+
+// PayReconcileDiscrepancyType 对账差异类型
+const (
+	PayReconcileDiscrepancyTypeLocalMissing   = 1 // 本地缺失：渠道有记录，本地无成功记录
+	PayReconcileDiscrepancyTypeChannelMissing = 2 // 渠道缺失：本地成功，渠道无记录
+	PayReconcileDiscrepancyTypeAmountMismatch = 3 // 金额不一致
+	PayReconcileDiscrepancyTypeStatusMismatch = 4 // 状态不一致：渠道已成功，本地未成功
+)
+
+// PayReconcileDiscrepancyStatus 对账差异处理状态
+const (
+	PayReconcileDiscrepancyStatusPending  = 0  // 待处理
+	PayReconcileDiscrepancyStatusRepaired = 10 // 已修复
+	PayReconcileDiscrepancyStatusIgnored  = 20 // 已忽略
+)
+
+// PayProfitSharingReceiverType 分账接收方类型
+const (
+	PayProfitSharingReceiverTypeWallet     = 1 // 会员钱包：内部接收方，分账金额记入钱包余额，适用于所有支付渠道
+	PayProfitSharingReceiverTypeWxMerchant = 2 // 微信商户号
+	PayProfitSharingReceiverTypeWxPersonal = 3 // 微信个人 openid
+	PayProfitSharingReceiverTypeAlipayUser = 4 // 支付宝账号，2088 开头的 userId
+)
+
+// IsPayProfitSharingReceiverMatchChannel 判断分账接收方能否参与该渠道支付订单的分账
+func IsPayProfitSharingReceiverMatchChannel(receiverType int, channelCode string) bool {
+	switch receiverType {
+	case PayProfitSharingReceiverTypeWallet:
+		return true
+	case PayProfitSharingReceiverTypeWxMerchant, PayProfitSharingReceiverTypeWxPersonal:
+		return IsPayChannelWeixin(channelCode)
+	case PayProfitSharingReceiverTypeAlipayUser:
+		return IsPayChannelAlipay(channelCode)
+	default:
+		return false
+	}
+}
+
+// PayProfitSharingStatus 分账状态，分账单、分账明细、分账回退单共用
+const (
+	PayProfitSharingStatusWaiting    = 0  // 待分账
+	PayProfitSharingStatusProcessing = 5  // 分账中
+	PayProfitSharingStatusSuccess    = 10 // 分账成功
+	PayProfitSharingStatusFailure    = 20 // 分账失败
+	PayProfitSharingStatusClosed     = 30 // 已关闭：支付订单全额退款，无需分账
+)
+
+// PayProfitSharingRatioBase 分账比例的基数，分账比例以万分比表示
+const PayProfitSharingRatioBase = 10000
@@ -6,6 +6,9 @@ import (
 	"encoding/hex"
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
 )
 
 // DeviceAuthUtils 设备认证工具
@@ -55,3 +58,53 @@ func (u *DeviceAuthUtils) ValidatePassword(deviceSecret, deviceName, productKey,
 	expectedPassword := u.BuildPassword(deviceSecret, content)
 	return expectedPassword == password
 }
+
+// DeviceTokenClaims 设备 Token 声明
+type DeviceTokenClaims struct {
+	ProductKey string `json:"productKey"`
+	DeviceName string `json:"deviceName"`
+	jwt.RegisteredClaims
+}
+
+// GenerateToken 生成设备 Token（用于 HTTP 等无长连接的协议）
+// 使用设备密钥签名，重置设备密钥即可使已签发的 Token 失效
+func (u *DeviceAuthUtils) GenerateToken(productKey, deviceName, deviceSecret string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := DeviceTokenClaims{
+		ProductKey: productKey,
+		DeviceName: deviceName,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    "iot-gateway",
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(deviceSecret))
+}
+
+// ParseTokenDevice 解析 Token 中的设备信息（不校验签名，用于查询设备密钥）
+func (u *DeviceAuthUtils) ParseTokenDevice(token string) (*DeviceInfo, error) {
+	claims := &DeviceTokenClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+		return nil, err
+	}
+	if claims.ProductKey == "" || claims.DeviceName == "" {
+		return nil, fmt.Errorf("invalid device token")
+	}
+	return &DeviceInfo{ProductKey: claims.ProductKey, DeviceName: claims.DeviceName}, nil
+}
+
+// VerifyToken 使用设备密钥校验 Token 签名与有效期
+func (u *DeviceAuthUtils) VerifyToken(token, deviceSecret string) (*DeviceTokenClaims, error) {
+	claims := &DeviceTokenClaims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(deviceSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+	if !parsed.Valid {
+		return nil, fmt.Errorf("invalid device token")
+	}
+	return claims, nil
+}
@@ -0,0 +1,264 @@
+package core
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DeviceCA 设备 CA
+// 为 X.509 认证设备签发客户端证书（CN = {productKey}&{deviceName}，与 MQTT 用户名格式一致），
+// 并按吊销记录生成证书吊销列表（CRL）
+type DeviceCA struct {
+	cert        *x509.Certificate
+	certPEM     string
+	key         crypto.Signer
+	validity    time.Duration // 设备证书有效期
+	crlValidity time.Duration // CRL 有效期
+}
+
+// DeviceCertificate 签发的设备证书
+type DeviceCertificate struct {
+	SerialNumber   string // 序列号（十六进制）
+	Fingerprint    string // SHA-256 指纹（十六进制）
+	Subject        string // 主题 CN
+	CertificatePEM string
+	PrivateKeyPEM  string
+	NotBefore      time.Time
+	NotAfter       time.Time
+}
+
+// LoadOrCreateDeviceCA 从文件加载设备 CA，文件不存在时生成自签名 CA 并写入文件
+func LoadOrCreateDeviceCA(certFile, keyFile string, validity, crlValidity time.Duration) (*DeviceCA, error) {
+	certPEM, certErr := os.ReadFile(certFile)
+	keyPEM, keyErr := os.ReadFile(keyFile)
+	if errors.Is(certErr, os.ErrNotExist) && errors.Is(keyErr, os.ErrNotExist) {
+		var err error
+		if certPEM, keyPEM, err = generateDeviceCA(); err != nil {
+			return nil, err
+		}
+		if err := writePEMFile(certFile, certPEM, 0o644); err != nil {
+			return nil, err
+		}
+		if err := writePEMFile(keyFile, keyPEM, 0o600); err != nil {
+			return nil, err
+		}
+		log.Printf("[DeviceCA] Generated self-signed device CA: %s", certFile)
+	} else if certErr != nil {
+		return nil, fmt.Errorf("read device ca cert failed: %w", certErr)
+	} else if keyErr != nil {
+		return nil, fmt.Errorf("read device ca key failed: %w", keyErr)
+	}
+	return NewDeviceCA(certPEM, keyPEM, validity, crlValidity)
+}
+
+// NewDeviceCA 使用 PEM 格式的 CA 证书与私钥创建设备 CA
+func NewDeviceCA(certPEM, keyPEM []byte, validity, crlValidity time.Duration) (*DeviceCA, error) {
+	certBlock, _ := pem.Decode(certPEM)
+	if certBlock == nil {
+		return nil, fmt.Errorf("invalid device ca cert pem")
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse device ca cert failed: %w", err)
+	}
+	if !cert.IsCA {
+		return nil, fmt.Errorf("device ca cert is not a CA certificate")
+	}
+	key, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &DeviceCA{
+		cert:        cert,
+		certPEM:     string(certPEM),
+		key:         key,
+		validity:    validity,
+		crlValidity: crlValidity,
+	}, nil
+}
+
+// CertificatePEM CA 证书（PEM），设备与服务端校验对端证书时使用
+func (ca *DeviceCA) CertificatePEM() string {
+	return ca.certPEM
+}
+
+// CertPool 仅包含设备 CA 的证书池，用于校验设备客户端证书
+func (ca *DeviceCA) CertPool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+// Issue 为设备签发客户端证书，私钥为 ECDSA P-256
+func (ca *DeviceCA) Issue(productKey, deviceName string) (*DeviceCertificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := randomSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	notAfter := now.Add(ca.validity)
+	if notAfter.After(ca.cert.NotAfter) {
+		notAfter = ca.cert.NotAfter
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   BuildCertificateSubject(productKey, deviceName),
+			Organization: ca.cert.Subject.Organization,
+		},
+		NotBefore:   now.Add(-5 * time.Minute), // 容忍设备时钟偏差
+		NotAfter:    notAfter,
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, fmt.Errorf("issue device certificate failed: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &DeviceCertificate{
+		SerialNumber:   serial.Text(16),
+		Fingerprint:    CertificateFingerprint(cert),
+		Subject:        template.Subject.CommonName,
+		CertificatePEM: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		PrivateKeyPEM:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})),
+		NotBefore:      cert.NotBefore,
+		NotAfter:       cert.NotAfter,
+	}, nil
+}
+
+// Verify 校验设备证书由本 CA 签发、在有效期内且可用于客户端认证
+func (ca *DeviceCA) Verify(cert *x509.Certificate) error {
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:     ca.CertPool(),
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err
+}
+
+// CreateCRL 生成证书吊销列表（PEM），number 为 CRL 序号，应单调递增
+func (ca *DeviceCA) CreateCRL(entries []x509.RevocationListEntry, number int64) ([]byte, error) {
+	now := time.Now()
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		RevokedCertificateEntries: entries,
+		Number:                    big.NewInt(number),
+		ThisUpdate:                now,
+		NextUpdate:                now.Add(ca.crlValidity),
+	}, ca.cert, ca.key)
+	if err != nil {
+		return nil, fmt.Errorf("create crl failed: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), nil
+}
+
+// BuildCertificateSubject 构建设备证书主题 CN: {productKey}&{deviceName}
+func BuildCertificateSubject(productKey, deviceName string) string {
+	return productKey + "&" + deviceName
+}
+
+// CertificateFingerprint 证书 SHA-256 指纹（十六进制小写）
+func CertificateFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// ParseSerialNumber 解析十六进制证书序列号
+func ParseSerialNumber(serial string) (*big.Int, bool) {
+	return new(big.Int).SetString(strings.TrimPrefix(serial, "0x"), 16)
+}
+
+// generateDeviceCA 生成自签名设备 CA（ECDSA P-256，有效期 20 年）
+func generateDeviceCA() (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := randomSerialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   "IoT Device CA",
+			Organization: []string{"ruoyi-mall-go"},
+		},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(20, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("generate device ca failed: %w", err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+// parsePrivateKey 解析 PKCS#8 / PKCS#1 / SEC1 格式的私钥
+func parsePrivateKey(keyPEM []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("invalid device ca key pem")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, fmt.Errorf("unsupported device ca key type")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("parse device ca key failed")
+}
+
+// randomSerialNumber 生成 128 位随机证书序列号
+func randomSerialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+// writePEMFile 写入 PEM 文件，目录不存在时创建
+func writePEMFile(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, perm)
+}
@@ -29,6 +29,15 @@ type MessageSubscriber interface {
 	OnMessage(message any)
 }
 
+// BroadcastSubscriber 广播订阅者
+// 不参与分组负载均衡，每个节点都收到订阅之后发布的全部消息；消息不确认、不重新投递，
+// 适用于实时推送等允许丢失的场景。本地消息总线的订阅者本身即收到全部消息
+type BroadcastSubscriber interface {
+	MessageSubscriber
+	// Broadcast 是否以广播方式订阅
+	Broadcast() bool
+}
+
 // messageTask 内部消息任务
 type messageTask struct {
 	topic   string
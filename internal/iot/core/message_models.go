@@ -1,12 +1,26 @@
 package core
 
 import (
+	"strings"
 	"time"
 )
 
 // DeviceMessageTopic 设备消息主题 (与 Java IotDeviceMessage.MESSAGE_BUS_DEVICE_MESSAGE_TOPIC 对齐)
 const DeviceMessageTopic = "iot_device_message"
 
+// DeviceMessageStreamTopic 设备消息实时流主题
+// 上行处理完成与下行发送时发布，各节点以广播方式订阅，推送给管理后台订阅的 WebSocket 会话
+const DeviceMessageStreamTopic = "iot_device_message_stream"
+
+// CacheInvalidateTopic 本地缓存失效主题
+// 规则等配置变更时发布，各节点以广播方式订阅，清除本节点的本地缓存
+const CacheInvalidateTopic = "iot_cache_invalidate"
+
+// 本地缓存名称
+const (
+	CacheSceneRule = "scene_rule" // 场景联动规则
+)
+
 // BuildGatewayDeviceMessageTopic 构建网关下行消息主题
 func BuildGatewayDeviceMessageTopic(serverID string) string {
 	return DeviceMessageTopic + "_" + serverID
@@ -49,6 +63,28 @@ type IotDeviceMessage struct {
 	ReportTime time.Time `json:"reportTime,omitempty"`
 }
 
+// DeviceMessageStreamEvent 设备消息实时流事件
+type DeviceMessageStreamEvent struct {
+	// ProductID 设备所属产品 ID，用于按产品过滤
+	ProductID int64 `json:"productId"`
+
+	// Upstream 是否为上行消息
+	Upstream bool `json:"upstream"`
+
+	// Message 设备消息
+	Message *IotDeviceMessage `json:"message"`
+
+	// Code、Msg 上行消息的处理错误，处理成功时为空
+	Code *int   `json:"code,omitempty"`
+	Msg  string `json:"msg,omitempty"`
+}
+
+// CacheInvalidateEvent 本地缓存失效事件
+type CacheInvalidateEvent struct {
+	// Cache 缓存名称
+	Cache string `json:"cache"`
+}
+
 // BuildStateUpdateOnline 构建设备上线状态消息
 func BuildStateUpdateOnline() *IotDeviceMessage {
 	return &IotDeviceMessage{
@@ -65,8 +101,24 @@ func BuildStateOffline() *IotDeviceMessage {
 	}
 }
 
+// downstreamMethods 服务端主动下发给设备的请求方法
+var downstreamMethods = map[string]bool{
+	"thing.property.set":   true,
+	"thing.service.invoke": true,
+	"thing.config.push":    true,
+	"thing.ota.upgrade":    true,
+	"thing.shadow.delta":   true,
+}
+
 // IsUpstreamMessage 判断是否为上行消息
 func (m *IotDeviceMessage) IsUpstreamMessage() bool {
+	// 下行请求的回复（如 thing.property.set_reply）由设备发出，属于上行
+	if method, ok := strings.CutSuffix(m.Method, "_reply"); ok && downstreamMethods[method] {
+		return true
+	}
+	if downstreamMethods[m.Method] {
+		return false
+	}
 	// 上行消息特征：无 Code (非响应)
 	return m.Code == nil && m.Data == nil
 }
@@ -1,24 +1,40 @@
 package core
 
 import (
+	"time"
+
 	"github.com/google/wire"
+	"github.com/redis/go-redis/v9"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/config"
 )
 
+// MessageBusTypeEnum 消息总线类型
+const (
+	MessageBusTypeLocal = "local" // 进程内，单节点部署
+	MessageBusTypeRedis = "redis" // Redis Streams，多节点部署
+)
+
 // ProviderSet IOT Core 模块依赖注入
 var ProviderSet = wire.NewSet(
 	// MessageBus
-	ProvideLocalMessageBusConfig,
-	NewLocalMessageBus,
-	wire.Bind(new(MessageBus), new(*LocalMessageBus)),
+	ProvideMessageBus,
 
 	// MQ Producer Factory
 	NewMQProducerFactory,
 
 	// Device Auth
 	NewDeviceAuthUtils,
+	ProvideDeviceCA,
 )
 
+// ProvideMessageBus 按部署配置选择消息总线
+func ProvideMessageBus(rdb *redis.Client) MessageBus {
+	if config.C.IoT.Core.MessageBus.Type == MessageBusTypeRedis {
+		return NewRedisMessageBus(rdb, ProvideRedisMessageBusConfig())
+	}
+	return NewLocalMessageBus(ProvideLocalMessageBusConfig())
+}
+
 // ProvideLocalMessageBusConfig 提供消息总线默认配置
 func ProvideLocalMessageBusConfig() LocalMessageBusConfig {
 	cfg := config.C.IoT.Core.MessageBus
@@ -27,3 +43,46 @@ func ProvideLocalMessageBusConfig() LocalMessageBusConfig {
 		QueueSize: cfg.QueueSize,
 	}
 }
+
+// ProvideRedisMessageBusConfig 提供 Redis Streams 消息总线配置，未配置的项使用默认值
+func ProvideRedisMessageBusConfig() RedisMessageBusConfig {
+	cfg := config.C.IoT.Core.MessageBus.Redis
+	block, _ := time.ParseDuration(cfg.Block)
+	publishWait, _ := time.ParseDuration(cfg.PublishWait)
+	claimInterval, _ := time.ParseDuration(cfg.ClaimInterval)
+	claimIdle, _ := time.ParseDuration(cfg.ClaimIdle)
+	return RedisMessageBusConfig{
+		StreamPrefix:  cfg.StreamPrefix,
+		Consumer:      cfg.Consumer,
+		BatchSize:     cfg.BatchSize,
+		Block:         block,
+		MaxLen:        cfg.MaxLen,
+		MaxBacklog:    cfg.MaxBacklog,
+		PublishWait:   publishWait,
+		ClaimInterval: claimInterval,
+		ClaimIdle:     claimIdle,
+		MaxDeliveries: cfg.MaxDeliveries,
+	}
+}
+
+// ProvideDeviceCA 提供设备 CA，未配置的项使用默认值
+func ProvideDeviceCA() (*DeviceCA, error) {
+	cfg := config.C.IoT.CA
+	certFile := cfg.CertFile
+	if certFile == "" {
+		certFile = "data/iot-ca/ca.crt"
+	}
+	keyFile := cfg.KeyFile
+	if keyFile == "" {
+		keyFile = "data/iot-ca/ca.key"
+	}
+	validityDays := cfg.ValidityDays
+	if validityDays <= 0 {
+		validityDays = 3650
+	}
+	crlValidity, _ := time.ParseDuration(cfg.CRLValidity)
+	if crlValidity <= 0 {
+		crlValidity = 24 * time.Hour
+	}
+	return LoadOrCreateDeviceCA(certFile, keyFile, time.Duration(validityDays)*24*time.Hour, crlValidity)
+}
@@ -0,0 +1,442 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 消息总线中传输的消息类型，用于跨节点反序列化
+const (
+	busMessageTypeDeviceMessage     = "device_message"
+	busMessageTypeDownstreamCommand = "downstream_command"
+	busMessageTypeStreamEvent       = "device_message_stream"
+	busMessageTypeCacheInvalidate   = "cache_invalidate"
+)
+
+// RedisMessageBusConfig Redis Streams 消息总线配置
+type RedisMessageBusConfig struct {
+	StreamPrefix  string        // Stream 键前缀，默认 iot:message-bus:
+	Consumer      string        // 消费者名称（节点内唯一），默认 主机名-进程号
+	BatchSize     int64         // 单次读取条数，默认 10
+	Block         time.Duration // 读取阻塞时长，默认 2s
+	MaxLen        int64         // Stream 最大长度（近似裁剪），默认 100000
+	MaxBacklog    int64         // 分组积压（未投递 + 未确认）上限，超过后发布方等待，默认 10000
+	PublishWait   time.Duration // 发布方因积压或 Redis 异常等待的最长时长，默认 30s
+	ClaimInterval time.Duration // 检查未确认消息的间隔，默认 30s
+	ClaimIdle     time.Duration // 消息超过该时长未确认则重新投递，默认 60s
+	MaxDeliveries int64         // 最大投递次数，超过后确认并丢弃，默认 5
+}
+
+// RedisMessageBus 基于 Redis Streams 的消息总线实现，用于多节点部署
+// 每个主题对应一个 Stream，订阅者分组对应消费者组：同组订阅者在节点间负载均衡，
+// 处理完成后确认（XACK），未确认的消息超时后重新投递；分组积压过多时发布方等待而非丢弃
+type RedisMessageBus struct {
+	rdb    *redis.Client
+	config RedisMessageBusConfig
+
+	mu          sync.Mutex
+	subscribers []MessageSubscriber
+	started     bool
+
+	backlogMu sync.Mutex
+	backlogs  map[string]redisStreamBacklog
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// redisStreamBacklog Stream 积压量缓存，避免每次发布都查询
+type redisStreamBacklog struct {
+	value     int64
+	checkedAt time.Time
+}
+
+// NewRedisMessageBus 创建 Redis Streams 消息总线
+func NewRedisMessageBus(rdb *redis.Client, config RedisMessageBusConfig) *RedisMessageBus {
+	if config.StreamPrefix == "" {
+		config.StreamPrefix = "iot:message-bus:"
+	}
+	if config.Consumer == "" {
+		hostname, _ := os.Hostname()
+		config.Consumer = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 10
+	}
+	if config.Block <= 0 {
+		config.Block = 2 * time.Second
+	}
+	if config.MaxLen <= 0 {
+		config.MaxLen = 100000
+	}
+	if config.MaxBacklog <= 0 {
+		config.MaxBacklog = 10000
+	}
+	if config.PublishWait <= 0 {
+		config.PublishWait = 30 * time.Second
+	}
+	if config.ClaimInterval <= 0 {
+		config.ClaimInterval = 30 * time.Second
+	}
+	if config.ClaimIdle <= 0 {
+		config.ClaimIdle = 60 * time.Second
+	}
+	if config.MaxDeliveries <= 0 {
+		config.MaxDeliveries = 5
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &RedisMessageBus{
+		rdb:      rdb,
+		config:   config,
+		backlogs: make(map[string]redisStreamBacklog),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start 为已注册的订阅者创建消费者组并开始消费
+func (b *RedisMessageBus) Start() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.started = true
+	for _, sub := range b.subscribers {
+		b.startSubscriber(sub)
+	}
+	log.Printf("[RedisMessageBus] Started with %d subscribers, consumer=%s", len(b.subscribers), b.config.Consumer)
+}
+
+// Stop 停止消费，未确认的消息由其它节点或重启后重新投递
+func (b *RedisMessageBus) Stop() {
+	b.cancel()
+	b.wg.Wait()
+	log.Printf("[RedisMessageBus] Stopped")
+}
+
+// Post 发布消息到指定主题
+// 分组积压超过上限时等待消费，Redis 暂不可用时重试，最长等待 PublishWait
+func (b *RedisMessageBus) Post(topic string, message any) {
+	typ, payload, err := encodeBusMessage(message)
+	if err != nil {
+		log.Printf("[RedisMessageBus] Encode message for topic %s failed: %v", topic, err)
+		return
+	}
+	stream := b.streamKey(topic)
+	deadline := time.Now().Add(b.config.PublishWait)
+	b.waitForCapacity(stream, deadline)
+
+	backoff := 100 * time.Millisecond
+	for {
+		err := b.rdb.XAdd(b.ctx, &redis.XAddArgs{
+			Stream: stream,
+			MaxLen: b.config.MaxLen,
+			Approx: true,
+			Values: map[string]any{"type": typ, "payload": payload},
+		}).Err()
+		if err == nil {
+			return
+		}
+		if b.ctx.Err() != nil || time.Now().After(deadline) {
+			log.Printf("[RedisMessageBus] Publish message to topic %s failed: %v", topic, err)
+			return
+		}
+		log.Printf("[RedisMessageBus] Publish message to topic %s failed, retrying: %v", topic, err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, 5*time.Second)
+	}
+}
+
+// Register 注册消息订阅者，总线已启动时立即开始消费
+func (b *RedisMessageBus) Register(subscriber MessageSubscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, subscriber)
+	if b.started {
+		b.startSubscriber(subscriber)
+	}
+}
+
+// startSubscriber 创建消费者组，启动消费与重新投递协程；广播订阅者直接读取 Stream
+func (b *RedisMessageBus) startSubscriber(sub MessageSubscriber) {
+	stream := b.streamKey(sub.Topic())
+	if bs, ok := sub.(BroadcastSubscriber); ok && bs.Broadcast() {
+		b.wg.Add(1)
+		go b.consumeBroadcast(sub, stream)
+		return
+	}
+	if err := b.ensureGroup(stream, sub.Group()); err != nil {
+		log.Printf("[RedisMessageBus] Create group %s on %s failed: %v", sub.Group(), stream, err)
+	}
+	b.wg.Add(2)
+	go b.consume(sub, stream)
+	go b.reclaim(sub, stream)
+}
+
+// ensureGroup 创建消费者组（已存在时忽略），新建的组从最新消息开始消费
+func (b *RedisMessageBus) ensureGroup(stream, group string) error {
+	err := b.rdb.XGroupCreateMkStream(b.ctx, stream, group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
+
+// consume 读取分配给本节点的新消息
+func (b *RedisMessageBus) consume(sub MessageSubscriber, stream string) {
+	defer b.wg.Done()
+	for b.ctx.Err() == nil {
+		streams, err := b.rdb.XReadGroup(b.ctx, &redis.XReadGroupArgs{
+			Group:    sub.Group(),
+			Consumer: b.config.Consumer,
+			Streams:  []string{stream, ">"},
+			Count:    b.config.BatchSize,
+			Block:    b.config.Block,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) || b.ctx.Err() != nil {
+				continue
+			}
+			log.Printf("[RedisMessageBus] Read %s failed: %v", stream, err)
+			// Stream 被删除等情况下重建消费者组
+			if strings.HasPrefix(err.Error(), "NOGROUP") {
+				_ = b.ensureGroup(stream, sub.Group())
+			}
+			b.sleep(time.Second)
+			continue
+		}
+		for _, s := range streams {
+			for _, msg := range s.Messages {
+				b.dispatch(sub, stream, msg)
+			}
+		}
+	}
+}
+
+// consumeBroadcast 不使用消费者组读取 Stream，从订阅时刻之后的消息开始，不确认
+func (b *RedisMessageBus) consumeBroadcast(sub MessageSubscriber, stream string) {
+	defer b.wg.Done()
+	lastID := fmt.Sprintf("%d-0", time.Now().UnixMilli())
+	for b.ctx.Err() == nil {
+		streams, err := b.rdb.XRead(b.ctx, &redis.XReadArgs{
+			Streams: []string{stream, lastID},
+			Count:   b.config.BatchSize,
+			Block:   b.config.Block,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) || b.ctx.Err() != nil {
+				continue
+			}
+			log.Printf("[RedisMessageBus] Read %s failed: %v", stream, err)
+			b.sleep(time.Second)
+			continue
+		}
+		for _, s := range streams {
+			for _, msg := range s.Messages {
+				lastID = msg.ID
+				typ, _ := msg.Values["type"].(string)
+				payload, _ := msg.Values["payload"].(string)
+				message, err := decodeBusMessage(typ, []byte(payload))
+				if err != nil {
+					log.Printf("[RedisMessageBus] Decode message %s on %s failed: %v", msg.ID, stream, err)
+					continue
+				}
+				b.handle(sub, message)
+			}
+		}
+	}
+}
+
+// reclaim 定期接管超时未确认的消息（处理失败或节点宕机），超过最大投递次数的消息确认后丢弃
+func (b *RedisMessageBus) reclaim(sub MessageSubscriber, stream string) {
+	defer b.wg.Done()
+	ticker := time.NewTicker(b.config.ClaimInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pending, err := b.rdb.XPendingExt(b.ctx, &redis.XPendingExtArgs{
+			Stream: stream,
+			Group:  sub.Group(),
+			Idle:   b.config.ClaimIdle,
+			Start:  "-",
+			End:    "+",
+			Count:  100,
+		}).Result()
+		if err != nil {
+			if b.ctx.Err() == nil {
+				log.Printf("[RedisMessageBus] Query pending of %s failed: %v", stream, err)
+			}
+			continue
+		}
+
+		ids := make([]string, 0, len(pending))
+		for _, p := range pending {
+			if p.RetryCount >= b.config.MaxDeliveries {
+				log.Printf("[RedisMessageBus] Drop message %s on %s after %d deliveries", p.ID, stream, p.RetryCount)
+				b.rdb.XAck(b.ctx, stream, sub.Group(), p.ID)
+				continue
+			}
+			ids = append(ids, p.ID)
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		messages, err := b.rdb.XClaim(b.ctx, &redis.XClaimArgs{
+			Stream:   stream,
+			Group:    sub.Group(),
+			Consumer: b.config.Consumer,
+			MinIdle:  b.config.ClaimIdle,
+			Messages: ids,
+		}).Result()
+		if err != nil {
+			log.Printf("[RedisMessageBus] Claim pending of %s failed: %v", stream, err)
+			continue
+		}
+		for _, msg := range messages {
+			b.dispatch(sub, stream, msg)
+		}
+	}
+}
+
+// dispatch 投递消息给订阅者，处理成功后确认；处理 panic 时不确认，等待重新投递
+func (b *RedisMessageBus) dispatch(sub MessageSubscriber, stream string, msg redis.XMessage) {
+	typ, _ := msg.Values["type"].(string)
+	payload, _ := msg.Values["payload"].(string)
+	message, err := decodeBusMessage(typ, []byte(payload))
+	if err != nil {
+		// 无法解析的消息重试也无意义，直接确认
+		log.Printf("[RedisMessageBus] Decode message %s on %s failed: %v", msg.ID, stream, err)
+		b.rdb.XAck(b.ctx, stream, sub.Group(), msg.ID)
+		return
+	}
+
+	if !b.handle(sub, message) {
+		return
+	}
+	if err := b.rdb.XAck(b.ctx, stream, sub.Group(), msg.ID).Err(); err != nil {
+		log.Printf("[RedisMessageBus] Ack message %s on %s failed: %v", msg.ID, stream, err)
+	}
+}
+
+// handle 执行订阅者处理逻辑，返回是否处理成功
+func (b *RedisMessageBus) handle(sub MessageSubscriber, message any) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[RedisMessageBus] Panic recovered in subscriber %s: %v", sub.Group(), r)
+			ok = false
+		}
+	}()
+	sub.OnMessage(message)
+	return true
+}
+
+// waitForCapacity 分组积压超过上限时等待消费，直到积压回落或超过截止时间
+func (b *RedisMessageBus) waitForCapacity(stream string, deadline time.Time) {
+	for b.getBacklog(stream) >= b.config.MaxBacklog {
+		if b.ctx.Err() != nil || time.Now().After(deadline) {
+			log.Printf("[RedisMessageBus] Stream %s backlog exceeds %d, publishing anyway", stream, b.config.MaxBacklog)
+			return
+		}
+		b.sleep(100 * time.Millisecond)
+	}
+}
+
+// getBacklog 获取 Stream 各消费者组中最大的积压量（未投递 + 未确认），结果缓存 1 秒
+func (b *RedisMessageBus) getBacklog(stream string) int64 {
+	b.backlogMu.Lock()
+	defer b.backlogMu.Unlock()
+	if cached, ok := b.backlogs[stream]; ok && time.Since(cached.checkedAt) < time.Second {
+		return cached.value
+	}
+
+	var backlog int64
+	groups, err := b.rdb.XInfoGroups(b.ctx, stream).Result()
+	if err == nil {
+		for _, group := range groups {
+			// Redis 7 以下无法获取 lag，仅按未确认数计算
+			backlog = max(backlog, group.Pending+max(group.Lag, 0))
+		}
+	}
+	b.backlogs[stream] = redisStreamBacklog{value: backlog, checkedAt: time.Now()}
+	return backlog
+}
+
+// streamKey 主题对应的 Stream 键
+func (b *RedisMessageBus) streamKey(topic string) string {
+	return b.config.StreamPrefix + topic
+}
+
+// sleep 可被 Stop 中断的等待
+func (b *RedisMessageBus) sleep(d time.Duration) {
+	select {
+	case <-b.ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+// encodeBusMessage 序列化总线消息，返回消息类型与内容
+func encodeBusMessage(message any) (string, []byte, error) {
+	var typ string
+	switch message.(type) {
+	case *IotDeviceMessage:
+		typ = busMessageTypeDeviceMessage
+	case *DownstreamCommand:
+		typ = busMessageTypeDownstreamCommand
+	case *DeviceMessageStreamEvent:
+		typ = busMessageTypeStreamEvent
+	case *CacheInvalidateEvent:
+		typ = busMessageTypeCacheInvalidate
+	default:
+		return "", nil, fmt.Errorf("unsupported message type %T", message)
+	}
+	payload, err := json.Marshal(message)
+	return typ, payload, err
+}
+
+// decodeBusMessage 按消息类型反序列化总线消息
+func decodeBusMessage(typ string, payload []byte) (any, error) {
+	switch typ {
+	case busMessageTypeDeviceMessage:
+		var message IotDeviceMessage
+		if err := json.Unmarshal(payload, &message); err != nil {
+			return nil, err
+		}
+		return &message, nil
+	case busMessageTypeDownstreamCommand:
+		var command DownstreamCommand
+		if err := json.Unmarshal(payload, &command); err != nil {
+			return nil, err
+		}
+		return &command, nil
+	case busMessageTypeStreamEvent:
+		var event DeviceMessageStreamEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, err
+		}
+		return &event, nil
+	case busMessageTypeCacheInvalidate:
+		var event CacheInvalidateEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil, err
+		}
+		return &event, nil
+	default:
+		return nil, fmt.Errorf("unknown message type %q", typ)
+	}
+}
+
+var _ MessageBus = (*RedisMessageBus)(nil)
@@ -7,36 +7,61 @@ import (
 
 	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
 	"github.com/wxlbd/ruoyi-mall-go/internal/iot/gateway/codec"
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/sink"
 	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/config"
 )
 
 // IotGatewayBootstrapper IoT 网关启动器
 // 统一管理网关组件的生命周期
 type IotGatewayBootstrapper struct {
 	config               *MQTTClientConfig
+	brokerConfig         *EmbeddedBrokerConfig
+	httpConfig           *HttpGatewayConfig
 	messageBus           core.MessageBus
 	codecRegistry        *codec.CodecRegistry
 	mqttClient           *MQTTClient
+	embeddedBroker       *EmbeddedBroker
+	httpGateway          *HttpGateway
 	connectionManager    *ConnectionManager
 	subscribers          []core.MessageSubscriber
 	deviceService        *iotsvc.DeviceService
+	productService       *iotsvc.ProductService
 	deviceMessageService *iotsvc.DeviceMessageService
+	sceneRuleService     *iotsvc.SceneRuleService
+	dataRuleService      *iotsvc.DataRuleService
+	streamService        *iotsvc.DeviceMessageStreamService
+	dataSinkRegistry     *sink.DataSinkRegistry
 }
 
 // NewIotGatewayBootstrapper 创建网关启动器
 func NewIotGatewayBootstrapper(
 	config *MQTTClientConfig,
+	brokerConfig *EmbeddedBrokerConfig,
+	httpConfig *HttpGatewayConfig,
 	messageBus core.MessageBus,
 	codecRegistry *codec.CodecRegistry,
 	deviceService *iotsvc.DeviceService,
+	productService *iotsvc.ProductService,
 	deviceMessageService *iotsvc.DeviceMessageService,
+	sceneRuleService *iotsvc.SceneRuleService,
+	dataRuleService *iotsvc.DataRuleService,
+	streamService *iotsvc.DeviceMessageStreamService,
+	dataSinkRegistry *sink.DataSinkRegistry,
 ) *IotGatewayBootstrapper {
 	return &IotGatewayBootstrapper{
 		config:               config,
+		brokerConfig:         brokerConfig,
+		httpConfig:           httpConfig,
 		messageBus:           messageBus,
 		codecRegistry:        codecRegistry,
 		deviceService:        deviceService,
+		productService:       productService,
 		deviceMessageService: deviceMessageService,
+		sceneRuleService:     sceneRuleService,
+		dataRuleService:      dataRuleService,
+		streamService:        streamService,
+		dataSinkRegistry:     dataSinkRegistry,
 	}
 }
 
@@ -45,20 +70,52 @@ func (b *IotGatewayBootstrapper) Start(ctx context.Context) error {
 	log.Println("[IotGatewayBootstrapper] Starting IoT Gateway...")
 
 	// 1. 创建连接管理器
-	serverID := "iot-gateway-" + time.Now().Format("20060102150405")
+	// 多节点部署时各节点需配置不同的 server_id，下行指令按设备所连节点的 server_id 路由
+	serverID := config.C.IoT.Gateway.ServerID
+	if serverID == "" {
+		serverID = "iot-gateway-" + time.Now().Format("20060102150405")
+	}
 	b.connectionManager = NewConnectionManager(b.messageBus, serverID)
 
 	// 2. 启动心跳检查器 (60s 超时，30s 检查间隔)
 	b.connectionManager.StartHeartbeatChecker(60*time.Second, 30*time.Second)
 
-	// 3. 创建 MQTT 客户端
-	b.mqttClient = NewMQTTClient(b.config, b.messageBus, b.codecRegistry)
+	// 子设备经网关上下线，会话挂载到网关连接
+	b.deviceMessageService.SetSubDeviceSessionManager(b.connectionManager)
+
+	// 产品可选择编解码器（如 Binary），由产品配置决定报文格式
+	b.codecRegistry.SetProductCodecLoader(b.productService.LoadProductCodec)
+
+	// 3. 创建内置 Broker 或 MQTT 客户端
+	// MQTT 数据目的依赖网关的 MQTT 发布能力，在此处注册
+	var downstreamSender DownstreamSender
+	if b.brokerConfig != nil && b.brokerConfig.Enabled {
+		b.embeddedBroker = NewEmbeddedBroker(b.brokerConfig, b.messageBus, b.codecRegistry,
+			b.connectionManager, b.deviceService, serverID)
+		downstreamSender = b.embeddedBroker
+		b.dataSinkRegistry.Register(sink.NewMQTTDataSink(b.embeddedBroker))
+	} else {
+		b.mqttClient = NewMQTTClient(b.config, b.messageBus, b.codecRegistry, b.deviceService, serverID)
+		downstreamSender = b.mqttClient
+		b.dataSinkRegistry.Register(sink.NewMQTTDataSink(b.mqttClient))
+	}
+
+	// 启用 HTTP 接入时，存在 HTTP 会话的设备改为缓存下行指令等待拉取
+	if b.httpConfig != nil && b.httpConfig.Enabled {
+		b.httpGateway = NewHttpGateway(b.httpConfig, b.messageBus, b.codecRegistry,
+			b.connectionManager, b.deviceService, serverID)
+		downstreamSender = &httpDownstreamRouter{httpGateway: b.httpGateway, fallback: downstreamSender}
+	}
 
 	// 4. 注册消息订阅者
 	deviceMessageSub := NewDeviceMessageSubscriber(b.deviceService, b.deviceMessageService)
-	downstreamSub := NewDownstreamSubscriber(b.mqttClient, serverID)
+	downstreamSub := NewDownstreamSubscriber(downstreamSender, serverID)
+	sceneRuleSub := NewSceneRuleMessageSubscriber(b.sceneRuleService)
+	dataRuleSub := NewDataRuleMessageSubscriber(b.dataRuleService)
+	streamSub := NewDeviceMessageStreamSubscriber(b.streamService)
+	cacheInvalidateSub := NewCacheInvalidateSubscriber(b.sceneRuleService)
 
-	b.subscribers = []core.MessageSubscriber{deviceMessageSub, downstreamSub}
+	b.subscribers = []core.MessageSubscriber{deviceMessageSub, downstreamSub, sceneRuleSub, dataRuleSub, streamSub, cacheInvalidateSub}
 	for _, sub := range b.subscribers {
 		b.messageBus.Register(sub)
 		log.Printf("[IotGatewayBootstrapper] Registered subscriber: topic=%s, group=%s",
@@ -68,11 +125,22 @@ func (b *IotGatewayBootstrapper) Start(ctx context.Context) error {
 	// 启动消息总线
 	b.messageBus.Start()
 
-	// 5. 启动 MQTT 客户端
-	if err := b.mqttClient.Start(ctx); err != nil {
+	// 5. 启动内置 Broker 或 MQTT 客户端
+	if b.embeddedBroker != nil {
+		if err := b.embeddedBroker.Start(); err != nil {
+			return err
+		}
+	} else if err := b.mqttClient.Start(ctx); err != nil {
 		return err
 	}
 
+	// 6. 启动 HTTP 接入
+	if b.httpGateway != nil {
+		if err := b.httpGateway.Start(); err != nil {
+			return err
+		}
+	}
+
 	log.Println("[IotGatewayBootstrapper] IoT Gateway started successfully")
 	return nil
 }
@@ -85,6 +153,14 @@ func (b *IotGatewayBootstrapper) Stop() {
 		b.mqttClient.Stop()
 	}
 
+	if b.embeddedBroker != nil {
+		b.embeddedBroker.Stop()
+	}
+
+	if b.httpGateway != nil {
+		b.httpGateway.Stop()
+	}
+
 	if b.messageBus != nil {
 		b.messageBus.Stop()
 	}
@@ -97,7 +173,17 @@ func (b *IotGatewayBootstrapper) GetConnectionManager() *ConnectionManager {
 	return b.connectionManager
 }
 
-// GetMQTTClient 获取 MQTT 客户端
+// GetMQTTClient 获取 MQTT 客户端（内置 Broker 模式下为 nil）
 func (b *IotGatewayBootstrapper) GetMQTTClient() *MQTTClient {
 	return b.mqttClient
 }
+
+// GetEmbeddedBroker 获取内置 Broker（外部 Broker 模式下为 nil）
+func (b *IotGatewayBootstrapper) GetEmbeddedBroker() *EmbeddedBroker {
+	return b.embeddedBroker
+}
+
+// GetHttpGateway 获取 HTTP 接入网关（未启用时为 nil）
+func (b *IotGatewayBootstrapper) GetHttpGateway() *HttpGateway {
+	return b.httpGateway
+}
@@ -0,0 +1,53 @@
+package gateway
+
+import (
+	"log"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+)
+
+// CacheInvalidateSubscriber 本地缓存失效订阅者
+// 以广播方式订阅，配置变更时每个节点都清除本节点的本地缓存
+type CacheInvalidateSubscriber struct {
+	sceneRuleService *iotsvc.SceneRuleService
+}
+
+// NewCacheInvalidateSubscriber 创建本地缓存失效订阅者
+func NewCacheInvalidateSubscriber(sceneRuleService *iotsvc.SceneRuleService) *CacheInvalidateSubscriber {
+	return &CacheInvalidateSubscriber{
+		sceneRuleService: sceneRuleService,
+	}
+}
+
+// Topic 返回订阅的主题
+func (s *CacheInvalidateSubscriber) Topic() string {
+	return core.CacheInvalidateTopic
+}
+
+// Group 返回订阅者分组
+func (s *CacheInvalidateSubscriber) Group() string {
+	return "iot_cache_invalidate"
+}
+
+// Broadcast 以广播方式订阅
+func (s *CacheInvalidateSubscriber) Broadcast() bool {
+	return true
+}
+
+// OnMessage 清除本节点的本地缓存
+func (s *CacheInvalidateSubscriber) OnMessage(message any) {
+	event, ok := message.(*core.CacheInvalidateEvent)
+	if !ok {
+		log.Printf("[CacheInvalidateSubscriber] Invalid message type")
+		return
+	}
+	switch event.Cache {
+	case core.CacheSceneRule:
+		s.sceneRuleService.InvalidateLocalRuleCache()
+	default:
+		log.Printf("[CacheInvalidateSubscriber] Unknown cache: %s", event.Cache)
+	}
+}
+
+var _ core.BroadcastSubscriber = (*CacheInvalidateSubscriber)(nil)
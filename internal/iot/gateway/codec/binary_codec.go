@@ -0,0 +1,371 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+)
+
+// BinaryCodecType 可配置二进制编解码器类型
+const BinaryCodecType = "Binary"
+
+// 二进制字段类型
+const (
+	BinaryFieldTypeInt8    = "int8"
+	BinaryFieldTypeUint8   = "uint8"
+	BinaryFieldTypeInt16   = "int16"
+	BinaryFieldTypeUint16  = "uint16"
+	BinaryFieldTypeInt32   = "int32"
+	BinaryFieldTypeUint32  = "uint32"
+	BinaryFieldTypeFloat32 = "float32"
+	BinaryFieldTypeFloat64 = "float64"
+	BinaryFieldTypeBool    = "bool"
+	BinaryFieldTypeBytes   = "bytes" // 定长字节，解码为十六进制字符串
+)
+
+// 二进制帧校验方式
+const (
+	BinaryChecksumNone  = "none"
+	BinaryChecksumSum8  = "sum8"  // 累加和，取低 8 位
+	BinaryChecksumXor8  = "xor8"  // 异或
+	BinaryChecksumCrc16 = "crc16" // CRC-16/MODBUS
+)
+
+// BinaryCodecConfig 二进制帧布局声明（存储于产品的 codecConfig）
+// 帧结构：帧头 + 字段（按声明顺序紧密排列）+ 校验码，校验范围为校验码之前的全部字节
+type BinaryCodecConfig struct {
+	ByteOrder string        `json:"byteOrder"` // 字节序：big（默认）/ little
+	Header    string        `json:"header"`    // 帧头（十六进制），如 "AA55"
+	Method    string        `json:"method"`    // 上行帧对应的消息方法，默认 thing.property.post
+	Fields    []BinaryField `json:"fields"`    // 字段布局
+	Checksum  string        `json:"checksum"`  // 校验方式：none（默认）/ sum8 / xor8 / crc16
+}
+
+// BinaryField 二进制字段声明
+// 数值字段的物理值 = 原始值 * Scale + Offset
+type BinaryField struct {
+	Identifier string  `json:"identifier"` // 物模型属性标识符，为空表示保留字节
+	Type       string  `json:"type"`       // 字段类型
+	Length     int     `json:"length"`     // 字节长度，仅 bytes 类型需要
+	Scale      float64 `json:"scale"`      // 缩放系数，默认 1
+	Offset     float64 `json:"offset"`     // 偏移量
+}
+
+// BinaryCodec 可配置二进制编解码器
+// 按产品声明的字节布局、字节序、缩放与校验规则，将紧凑二进制帧映射为物模型属性
+type BinaryCodec struct {
+	config    *BinaryCodecConfig
+	byteOrder binary.ByteOrder
+	header    []byte
+	frameSize int
+}
+
+// NewBinaryCodec 根据产品声明的 JSON 配置创建二进制编解码器
+func NewBinaryCodec(config string) (DeviceMessageCodec, error) {
+	var cfg BinaryCodecConfig
+	if err := json.Unmarshal([]byte(config), &cfg); err != nil {
+		return nil, fmt.Errorf("invalid binary codec config: %w", err)
+	}
+	return NewBinaryCodecWithConfig(&cfg)
+}
+
+// NewBinaryCodecWithConfig 创建二进制编解码器，并校验布局声明
+func NewBinaryCodecWithConfig(cfg *BinaryCodecConfig) (*BinaryCodec, error) {
+	c := &BinaryCodec{config: cfg}
+	switch strings.ToLower(cfg.ByteOrder) {
+	case "", "big":
+		c.byteOrder = binary.BigEndian
+	case "little":
+		c.byteOrder = binary.LittleEndian
+	default:
+		return nil, fmt.Errorf("unsupported byte order: %s", cfg.ByteOrder)
+	}
+	header, err := hex.DecodeString(strings.ReplaceAll(cfg.Header, " ", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+	c.header = header
+	if cfg.Method == "" {
+		cfg.Method = consts.IotDeviceMessageMethodPropertyPost
+	}
+	if cfg.Checksum == "" {
+		cfg.Checksum = BinaryChecksumNone
+	}
+	if len(cfg.Fields) == 0 {
+		return nil, fmt.Errorf("fields is empty")
+	}
+
+	c.frameSize = len(header)
+	for i := range cfg.Fields {
+		field := &cfg.Fields[i]
+		size := binaryFieldSize(field)
+		if size <= 0 {
+			return nil, fmt.Errorf("invalid field %d: type=%s, length=%d", i, field.Type, field.Length)
+		}
+		if field.Scale == 0 {
+			field.Scale = 1
+		}
+		c.frameSize += size
+	}
+	checksumSize := binaryChecksumSize(cfg.Checksum)
+	if checksumSize < 0 {
+		return nil, fmt.Errorf("unsupported checksum: %s", cfg.Checksum)
+	}
+	c.frameSize += checksumSize
+	return c, nil
+}
+
+// Type 返回编解码器类型
+func (c *BinaryCodec) Type() string {
+	return BinaryCodecType
+}
+
+// Decode 解码二进制帧为设备消息，字段值写入 params
+func (c *BinaryCodec) Decode(frame []byte) (*core.IotDeviceMessage, error) {
+	if len(frame) != c.frameSize {
+		return nil, fmt.Errorf("frame length mismatch: expected %d, got %d", c.frameSize, len(frame))
+	}
+	if !bytes.HasPrefix(frame, c.header) {
+		return nil, fmt.Errorf("frame header mismatch: expected %X", c.header)
+	}
+	checksumSize := binaryChecksumSize(c.config.Checksum)
+	body := frame[:len(frame)-checksumSize]
+	if checksumSize > 0 && !bytes.Equal(c.checksum(body), frame[len(body):]) {
+		return nil, fmt.Errorf("checksum mismatch")
+	}
+
+	params := make(map[string]interface{}, len(c.config.Fields))
+	pos := len(c.header)
+	for i := range c.config.Fields {
+		field := &c.config.Fields[i]
+		size := binaryFieldSize(field)
+		if field.Identifier != "" {
+			params[field.Identifier] = c.readField(field, body[pos:pos+size])
+		}
+		pos += size
+	}
+	return &core.IotDeviceMessage{
+		Method: c.config.Method,
+		Params: params,
+	}, nil
+}
+
+// Encode 编码设备消息为二进制帧
+// 字段值取自 params（服务调用取自 params.inputParams），缺失的字段按 0 填充
+func (c *BinaryCodec) Encode(message *core.IotDeviceMessage) ([]byte, error) {
+	values := message.Params
+	if input, ok := values["inputParams"].(map[string]interface{}); ok {
+		values = input
+	}
+
+	frame := make([]byte, 0, c.frameSize)
+	frame = append(frame, c.header...)
+	for i := range c.config.Fields {
+		field := &c.config.Fields[i]
+		buf := make([]byte, binaryFieldSize(field))
+		if value, ok := values[field.Identifier]; ok && field.Identifier != "" {
+			if err := c.writeField(field, buf, value); err != nil {
+				return nil, fmt.Errorf("encode field %s failed: %w", field.Identifier, err)
+			}
+		}
+		frame = append(frame, buf...)
+	}
+	return append(frame, c.checksum(frame)...), nil
+}
+
+// readField 读取字段值，并换算为物理值
+func (c *BinaryCodec) readField(field *BinaryField, buf []byte) interface{} {
+	var raw float64
+	switch field.Type {
+	case BinaryFieldTypeBool:
+		return buf[0] != 0
+	case BinaryFieldTypeBytes:
+		return strings.ToUpper(hex.EncodeToString(buf))
+	case BinaryFieldTypeInt8:
+		raw = float64(int8(buf[0]))
+	case BinaryFieldTypeUint8:
+		raw = float64(buf[0])
+	case BinaryFieldTypeInt16:
+		raw = float64(int16(c.byteOrder.Uint16(buf)))
+	case BinaryFieldTypeUint16:
+		raw = float64(c.byteOrder.Uint16(buf))
+	case BinaryFieldTypeInt32:
+		raw = float64(int32(c.byteOrder.Uint32(buf)))
+	case BinaryFieldTypeUint32:
+		raw = float64(c.byteOrder.Uint32(buf))
+	case BinaryFieldTypeFloat32:
+		raw = float64(math.Float32frombits(c.byteOrder.Uint32(buf)))
+	case BinaryFieldTypeFloat64:
+		raw = math.Float64frombits(c.byteOrder.Uint64(buf))
+	}
+	value := raw*field.Scale + field.Offset
+	// 未缩放的整数字段保持整数，便于物模型校验与展示
+	if field.Scale == 1 && field.Offset == 0 && isBinaryIntegerType(field.Type) {
+		return int64(value)
+	}
+	return value
+}
+
+// writeField 将物理值换算为原始值后写入
+func (c *BinaryCodec) writeField(field *BinaryField, buf []byte, value interface{}) error {
+	switch field.Type {
+	case BinaryFieldTypeBool:
+		b, err := toBinaryBool(value)
+		if err != nil {
+			return err
+		}
+		if b {
+			buf[0] = 1
+		}
+		return nil
+	case BinaryFieldTypeBytes:
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("bytes field requires hex string")
+		}
+		data, err := hex.DecodeString(s)
+		if err != nil || len(data) != len(buf) {
+			return fmt.Errorf("bytes field requires %d bytes hex", len(buf))
+		}
+		copy(buf, data)
+		return nil
+	}
+
+	number, err := toBinaryFloat(value)
+	if err != nil {
+		return err
+	}
+	raw := (number - field.Offset) / field.Scale
+	switch field.Type {
+	case BinaryFieldTypeInt8, BinaryFieldTypeUint8:
+		buf[0] = byte(int64(math.Round(raw)))
+	case BinaryFieldTypeInt16, BinaryFieldTypeUint16:
+		c.byteOrder.PutUint16(buf, uint16(int64(math.Round(raw))))
+	case BinaryFieldTypeInt32, BinaryFieldTypeUint32:
+		c.byteOrder.PutUint32(buf, uint32(int64(math.Round(raw))))
+	case BinaryFieldTypeFloat32:
+		c.byteOrder.PutUint32(buf, math.Float32bits(float32(raw)))
+	case BinaryFieldTypeFloat64:
+		c.byteOrder.PutUint64(buf, math.Float64bits(raw))
+	}
+	return nil
+}
+
+// checksum 计算校验码
+func (c *BinaryCodec) checksum(data []byte) []byte {
+	switch c.config.Checksum {
+	case BinaryChecksumSum8:
+		var sum byte
+		for _, b := range data {
+			sum += b
+		}
+		return []byte{sum}
+	case BinaryChecksumXor8:
+		var x byte
+		for _, b := range data {
+			x ^= b
+		}
+		return []byte{x}
+	case BinaryChecksumCrc16:
+		crc := uint16(0xFFFF)
+		for _, b := range data {
+			crc ^= uint16(b)
+			for i := 0; i < 8; i++ {
+				if crc&1 != 0 {
+					crc = crc>>1 ^ 0xA001
+				} else {
+					crc >>= 1
+				}
+			}
+		}
+		buf := make([]byte, 2)
+		c.byteOrder.PutUint16(buf, crc)
+		return buf
+	}
+	return nil
+}
+
+// binaryFieldSize 字段字节长度，类型不支持时返回 0
+func binaryFieldSize(field *BinaryField) int {
+	switch field.Type {
+	case BinaryFieldTypeInt8, BinaryFieldTypeUint8, BinaryFieldTypeBool:
+		return 1
+	case BinaryFieldTypeInt16, BinaryFieldTypeUint16:
+		return 2
+	case BinaryFieldTypeInt32, BinaryFieldTypeUint32, BinaryFieldTypeFloat32:
+		return 4
+	case BinaryFieldTypeFloat64:
+		return 8
+	case BinaryFieldTypeBytes:
+		return field.Length
+	}
+	return 0
+}
+
+// binaryChecksumSize 校验码字节长度，校验方式不支持时返回 -1
+func binaryChecksumSize(checksum string) int {
+	switch checksum {
+	case BinaryChecksumNone:
+		return 0
+	case BinaryChecksumSum8, BinaryChecksumXor8:
+		return 1
+	case BinaryChecksumCrc16:
+		return 2
+	}
+	return -1
+}
+
+func isBinaryIntegerType(fieldType string) bool {
+	switch fieldType {
+	case BinaryFieldTypeInt8, BinaryFieldTypeUint8, BinaryFieldTypeInt16, BinaryFieldTypeUint16,
+		BinaryFieldTypeInt32, BinaryFieldTypeUint32:
+		return true
+	}
+	return false
+}
+
+func toBinaryFloat(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		return strconv.ParseFloat(v, 64)
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("unsupported value type %T", value)
+}
+
+func toBinaryBool(value interface{}) (bool, error) {
+	if b, ok := value.(bool); ok {
+		return b, nil
+	}
+	number, err := toBinaryFloat(value)
+	if err != nil {
+		return false, err
+	}
+	return number != 0, nil
+}
+
+var _ DeviceMessageCodec = (*BinaryCodec)(nil)
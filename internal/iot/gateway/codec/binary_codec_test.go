@@ -0,0 +1,140 @@
+package codec
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+)
+
+// 温湿度传感器帧：AA55 + 温度(int16, 0.1℃) + 湿度(uint8) + 保留(1 字节) + 开关(bool) + CRC16
+const testBinaryCodecConfig = `{
+	"byteOrder": "big",
+	"header": "AA55",
+	"fields": [
+		{"identifier": "temperature", "type": "int16", "scale": 0.1},
+		{"identifier": "humidity", "type": "uint8"},
+		{"type": "bytes", "length": 1},
+		{"identifier": "switch", "type": "bool"}
+	],
+	"checksum": "crc16"
+}`
+
+// TestBinaryCodecRoundTrip 验证编码后再解码得到相同的物模型属性
+func TestBinaryCodecRoundTrip(t *testing.T) {
+	c, err := NewBinaryCodec(testBinaryCodecConfig)
+	assert.NoError(t, err)
+
+	frame, err := c.Encode(&core.IotDeviceMessage{
+		Method: "thing.property.set",
+		Params: map[string]interface{}{"temperature": -12.5, "humidity": 63, "switch": true},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, frame, 9)
+	assert.Equal(t, "aa55ff833f0001", hex.EncodeToString(frame[:7]))
+
+	message, err := c.Decode(frame)
+	assert.NoError(t, err)
+	assert.Equal(t, "thing.property.post", message.Method)
+	assert.InDelta(t, -12.5, message.Params["temperature"], 1e-9)
+	assert.Equal(t, int64(63), message.Params["humidity"])
+	assert.Equal(t, true, message.Params["switch"])
+	assert.NotContains(t, message.Params, "")
+}
+
+// TestBinaryCodecLittleEndianAndChecksum 验证小端字节序与 sum8 校验
+func TestBinaryCodecLittleEndianAndChecksum(t *testing.T) {
+	c, err := NewBinaryCodec(`{"byteOrder":"little","fields":[{"identifier":"power","type":"uint16","scale":0.5,"offset":10}],"checksum":"sum8"}`)
+	assert.NoError(t, err)
+
+	// 原始值 0x0102 = 258 -> 258 * 0.5 + 10 = 139
+	frame, _ := hex.DecodeString("020103")
+	message, err := c.Decode(frame)
+	assert.NoError(t, err)
+	assert.InDelta(t, 139.0, message.Params["power"], 1e-9)
+
+	encoded, err := c.Encode(&core.IotDeviceMessage{Params: map[string]interface{}{"power": 139}})
+	assert.NoError(t, err)
+	assert.Equal(t, frame, encoded)
+}
+
+// TestBinaryCodecDecodeInvalidFrame 验证非法帧被拒绝
+func TestBinaryCodecDecodeInvalidFrame(t *testing.T) {
+	c, err := NewBinaryCodec(testBinaryCodecConfig)
+	assert.NoError(t, err)
+	frame, err := c.Encode(&core.IotDeviceMessage{Params: map[string]interface{}{"temperature": 20}})
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name  string
+		frame []byte
+	}{
+		{name: "长度不符", frame: frame[:len(frame)-1]},
+		{name: "帧头不符", frame: append([]byte{0xAB}, frame[1:]...)},
+		{name: "校验失败", frame: append(append([]byte{}, frame[:len(frame)-1]...), frame[len(frame)-1]^0xFF)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := c.Decode(tc.frame)
+			assert.Error(t, err)
+		})
+	}
+}
+
+// TestBinaryCodecInvalidConfig 验证非法布局声明被拒绝
+func TestBinaryCodecInvalidConfig(t *testing.T) {
+	for _, config := range []string{
+		`not json`,
+		`{"fields":[]}`,
+		`{"byteOrder":"middle","fields":[{"identifier":"a","type":"uint8"}]}`,
+		`{"fields":[{"identifier":"a","type":"int24"}]}`,
+		`{"fields":[{"identifier":"a","type":"uint8"}],"checksum":"md5"}`,
+	} {
+		_, err := NewBinaryCodec(config)
+		assert.Error(t, err, config)
+	}
+}
+
+// TestCodecRegistryGetByProduct 验证注册表按产品选择编解码器
+func TestCodecRegistryGetByProduct(t *testing.T) {
+	registry := DefaultRegistry()
+	registry.SetProductCodecLoader(func(productKey string) (string, string, error) {
+		if productKey == "binary" {
+			return BinaryCodecType, testBinaryCodecConfig, nil
+		}
+		return "", "", nil
+	})
+
+	c, err := registry.GetByProduct("binary", "Alink")
+	assert.NoError(t, err)
+	assert.Equal(t, BinaryCodecType, c.Type())
+	cached, _ := registry.GetByProduct("binary", "Alink")
+	assert.Same(t, c, cached)
+
+	c, err = registry.GetByProduct("json", "Alink")
+	assert.NoError(t, err)
+	assert.Equal(t, "Alink", c.Type())
+}
+
+// TestCodecRegistryEvictProduct 验证产品编解码器在移除后重新创建
+func TestCodecRegistryEvictProduct(t *testing.T) {
+	registry := DefaultRegistry()
+	registry.SetProductCodecLoader(func(productKey string) (string, string, error) {
+		return BinaryCodecType, testBinaryCodecConfig, nil
+	})
+
+	c, err := registry.GetByProduct("binary", "Alink")
+	assert.NoError(t, err)
+	registry.EvictProduct("binary")
+	recreated, err := registry.GetByProduct("binary", "Alink")
+	assert.NoError(t, err)
+	assert.NotSame(t, c, recreated)
+
+	// 调试等场景直接按配置解析，不写入产品缓存
+	resolved, err := registry.Resolve(BinaryCodecType, testBinaryCodecConfig)
+	assert.NoError(t, err)
+	assert.NotSame(t, recreated, resolved)
+	cached, _ := registry.GetByProduct("binary", "Alink")
+	assert.Same(t, recreated, cached)
+}
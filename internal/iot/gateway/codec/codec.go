@@ -1,6 +1,9 @@
 package codec
 
 import (
+	"fmt"
+	"sync"
+
 	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
 )
 
@@ -16,15 +19,37 @@ type DeviceMessageCodec interface {
 	Decode(bytes []byte) (*core.IotDeviceMessage, error)
 }
 
+// CodecFactory 可配置编解码器工厂，根据产品声明的编解码配置创建编解码器
+type CodecFactory func(config string) (DeviceMessageCodec, error)
+
+// ProductCodecLoader 加载产品选择的编解码类型与配置
+type ProductCodecLoader func(productKey string) (codecType string, config string, err error)
+
 // CodecRegistry 编解码器注册表
 type CodecRegistry struct {
-	codecs map[string]DeviceMessageCodec
+	codecs    map[string]DeviceMessageCodec
+	factories map[string]CodecFactory
+
+	mu            sync.RWMutex
+	productLoader ProductCodecLoader
+	// configured 产品按配置创建的编解码器缓存，key: productKey
+	// 产品配置变化时替换，产品更新、删除时由 EvictProduct 移除
+	configured map[string]*productCodec
+}
+
+// productCodec 产品按配置创建的编解码器
+type productCodec struct {
+	codecType string
+	config    string
+	codec     DeviceMessageCodec
 }
 
 // NewCodecRegistry 创建编解码器注册表
 func NewCodecRegistry() *CodecRegistry {
 	return &CodecRegistry{
-		codecs: make(map[string]DeviceMessageCodec),
+		codecs:     make(map[string]DeviceMessageCodec),
+		factories:  make(map[string]CodecFactory),
+		configured: make(map[string]*productCodec),
 	}
 }
 
@@ -33,14 +58,85 @@ func (r *CodecRegistry) Register(codec DeviceMessageCodec) {
 	r.codecs[codec.Type()] = codec
 }
 
+// RegisterFactory 注册可配置编解码器工厂
+func (r *CodecRegistry) RegisterFactory(codecType string, factory CodecFactory) {
+	r.factories[codecType] = factory
+}
+
+// SetProductCodecLoader 设置产品编解码配置加载器
+func (r *CodecRegistry) SetProductCodecLoader(loader ProductCodecLoader) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.productLoader = loader
+}
+
 // Get 获取指定类型的编解码器
 func (r *CodecRegistry) Get(codecType string) DeviceMessageCodec {
 	return r.codecs[codecType]
 }
 
-// DefaultRegistry 创建默认的编解码器注册表（包含 Alink）
+// Resolve 获取编解码器：可配置类型按配置创建新实例（不缓存），其余类型返回已注册的编解码器
+func (r *CodecRegistry) Resolve(codecType, config string) (DeviceMessageCodec, error) {
+	if factory, ok := r.factories[codecType]; ok {
+		return factory(config)
+	}
+	if codec := r.codecs[codecType]; codec != nil {
+		return codec, nil
+	}
+	return nil, fmt.Errorf("codec not found: %s", codecType)
+}
+
+// EvictProduct 移除产品按配置创建的编解码器（产品更新或删除时调用）
+func (r *CodecRegistry) EvictProduct(productKey string) {
+	r.mu.Lock()
+	delete(r.configured, productKey)
+	r.mu.Unlock()
+}
+
+// GetByProduct 获取产品选择的编解码器，产品未选择时使用 defaultType
+func (r *CodecRegistry) GetByProduct(productKey, defaultType string) (DeviceMessageCodec, error) {
+	r.mu.RLock()
+	loader := r.productLoader
+	r.mu.RUnlock()
+
+	codecType, config := defaultType, ""
+	if loader != nil && productKey != "" {
+		productCodecType, productConfig, err := loader(productKey)
+		if err != nil {
+			return nil, err
+		}
+		if productCodecType != "" {
+			codecType, config = productCodecType, productConfig
+		}
+	}
+	if codecType == "" {
+		codecType = "Alink"
+	}
+	if _, ok := r.factories[codecType]; !ok || productKey == "" {
+		return r.Resolve(codecType, config)
+	}
+
+	// 可配置类型按产品缓存，配置变化时替换
+	r.mu.RLock()
+	cached := r.configured[productKey]
+	r.mu.RUnlock()
+	if cached != nil && cached.codecType == codecType && cached.config == config {
+		return cached.codec, nil
+	}
+	codec, err := r.Resolve(codecType, config)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	r.configured[productKey] = &productCodec{codecType: codecType, config: config, codec: codec}
+	r.mu.Unlock()
+	return codec, nil
+}
+
+// DefaultRegistry 创建默认的编解码器注册表（包含 Alink 与可配置二进制编解码器）
 func DefaultRegistry() *CodecRegistry {
 	registry := NewCodecRegistry()
 	registry.Register(NewAlinkCodec())
+	registry.RegisterFactory(BinaryCodecType, NewBinaryCodec)
 	return registry
 }
@@ -5,7 +5,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
 	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
 )
 
 // ConnectionInfo 连接信息
@@ -18,6 +21,9 @@ type ConnectionInfo struct {
 	RemoteAddress string
 	ConnectedAt   time.Time
 	LastHeartbeat time.Time
+	// GatewayClientID 子设备所属网关的连接标识，直连设备为空
+	// 子设备没有独立连接，在线状态跟随网关连接
+	GatewayClientID string
 }
 
 // ConnectionManager 设备连接管理器
@@ -51,6 +57,17 @@ func (m *ConnectionManager) SetMaxConnections(max int) {
 
 // RegisterConnection 注册连接
 func (m *ConnectionManager) RegisterConnection(clientID string, info *ConnectionInfo) {
+	if !m.addConnection(clientID, info) {
+		return
+	}
+	log.Printf("[ConnectionManager] Device connected: clientID=%s, deviceId=%d", clientID, info.DeviceID)
+
+	// 发送设备上线消息
+	m.sendStateMessage(info, "online")
+}
+
+// addConnection 记录连接，超出连接数限制时返回 false
+func (m *ConnectionManager) addConnection(clientID string, info *ConnectionInfo) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -59,7 +76,7 @@ func (m *ConnectionManager) RegisterConnection(clientID string, info *Connection
 		// 如果是已存在的连接，允许覆盖（重连）
 		if _, exists := m.connections[clientID]; !exists {
 			log.Printf("[ConnectionManager] Connection rejected: max connections reached (%d)", m.maxConnections)
-			return
+			return false
 		}
 	}
 
@@ -67,28 +84,88 @@ func (m *ConnectionManager) RegisterConnection(clientID string, info *Connection
 	info.LastHeartbeat = time.Now()
 	m.connections[clientID] = info
 	m.deviceIndex[info.DeviceID] = clientID
-
-	log.Printf("[ConnectionManager] Device connected: clientID=%s, deviceId=%d", clientID, info.DeviceID)
-
-	// 发送设备上线消息
-	m.sendStateMessage(info, "online")
+	return true
 }
 
 // UnregisterConnection 注销连接
+// 网关连接注销时一并解除其下子设备的挂载，子设备的下线由设备消息服务按拓扑关系处理
 func (m *ConnectionManager) UnregisterConnection(clientID string) {
+	info := m.removeConnection(clientID)
+	if info == nil {
+		return
+	}
+	log.Printf("[ConnectionManager] Device disconnected: clientID=%s, deviceId=%d", clientID, info.DeviceID)
+	// 发送设备离线消息
+	m.sendStateMessage(info, "offline")
+}
+
+// removeConnection 移除连接及挂载在其下的子设备
+func (m *ConnectionManager) removeConnection(clientID string) *ConnectionInfo {
 	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	info := m.connections[clientID]
-	if info != nil {
-		delete(m.connections, clientID)
-		delete(m.deviceIndex, info.DeviceID)
+	if info == nil {
+		return nil
+	}
+	delete(m.connections, clientID)
+	delete(m.deviceIndex, info.DeviceID)
+	for subClientID, sub := range m.connections {
+		if sub.GatewayClientID == clientID {
+			delete(m.connections, subClientID)
+			delete(m.deviceIndex, sub.DeviceID)
+		}
+	}
+	return info
+}
+
+// LoginSubDevice 子设备通过网关上线，挂载到网关连接下，供内置 Broker 解析网关代理的子设备 Topic
+// 网关未在本节点建立连接时返回 ErrDeviceGatewayOffline；上下线状态由设备消息服务发送，此处不重复发送
+func (m *ConnectionManager) LoginSubDevice(gatewayID int64, sub *model.IotDeviceDO) error {
+	m.mu.Lock()
+	gatewayClientID, ok := m.deviceIndex[gatewayID]
+	gateway := m.connections[gatewayClientID]
+	if !ok || gateway == nil {
+		m.mu.Unlock()
+		return model.ErrDeviceGatewayOffline
+	}
+	clientID := buildSubDeviceClientID(sub.ProductKey, sub.DeviceName)
+	// 重复上线仅刷新心跳
+	if existing := m.connections[clientID]; existing != nil && existing.GatewayClientID == gatewayClientID {
+		existing.LastHeartbeat = time.Now()
+		m.mu.Unlock()
+		return nil
 	}
 	m.mu.Unlock()
 
-	if info != nil {
-		log.Printf("[ConnectionManager] Device disconnected: clientID=%s, deviceId=%d", clientID, info.DeviceID)
-		// 发送设备离线消息
-		m.sendStateMessage(info, "offline")
+	m.addConnection(clientID, &ConnectionInfo{
+		DeviceID:        sub.ID,
+		ProductKey:      sub.ProductKey,
+		DeviceName:      sub.DeviceName,
+		ClientID:        clientID,
+		Authenticated:   true,
+		RemoteAddress:   gateway.RemoteAddress,
+		GatewayClientID: gatewayClientID,
+	})
+	return nil
+}
+
+// LogoutSubDevice 解除子设备在网关连接下的挂载
+func (m *ConnectionManager) LogoutSubDevice(subDeviceID int64) {
+	info := m.GetConnectionByDeviceID(subDeviceID)
+	if info == nil || info.GatewayClientID == "" {
+		return
+	}
+	m.removeConnection(info.ClientID)
+}
+
+// GetSubDeviceConnection 获取网关连接下已上线的子设备
+func (m *ConnectionManager) GetSubDeviceConnection(gatewayClientID, productKey, deviceName string) *ConnectionInfo {
+	info := m.GetConnectionInfo(buildSubDeviceClientID(productKey, deviceName))
+	if info == nil || info.GatewayClientID != gatewayClientID {
+		return nil
 	}
+	return info
 }
 
 // UpdateHeartbeat 更新心跳时间
@@ -134,7 +211,7 @@ func (m *ConnectionManager) GetOnlineDeviceCount() int {
 // sendStateMessage 发送状态消息到消息总线
 func (m *ConnectionManager) sendStateMessage(info *ConnectionInfo, state string) {
 	message := &core.IotDeviceMessage{
-		Method:     "thing.lifecycle.state.update",
+		Method:     consts.IotDeviceMessageMethodStateUpdate,
 		Params:     map[string]any{"state": state},
 		DeviceID:   info.DeviceID,
 		ServerID:   m.serverID,
@@ -163,6 +240,10 @@ func (m *ConnectionManager) checkHeartbeats(timeout time.Duration) {
 	var expiredClients []string
 	now := time.Now()
 	for clientID, info := range m.connections {
+		// 子设备随网关连接一并下线，不单独检查
+		if info.GatewayClientID != "" {
+			continue
+		}
 		if now.Sub(info.LastHeartbeat) > timeout {
 			expiredClients = append(expiredClients, clientID)
 		}
@@ -175,3 +256,10 @@ func (m *ConnectionManager) checkHeartbeats(timeout time.Duration) {
 		m.UnregisterConnection(clientID)
 	}
 }
+
+// buildSubDeviceClientID 构建子设备在连接管理器中的客户端标识
+func buildSubDeviceClientID(productKey, deviceName string) string {
+	return "sub:" + productKey + "&" + deviceName
+}
+
+var _ iotsvc.SubDeviceSessionManager = (*ConnectionManager)(nil)
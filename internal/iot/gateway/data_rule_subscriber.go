@@ -0,0 +1,50 @@
+package gateway
+
+import (
+	"context"
+	"log"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+)
+
+// DataRuleMessageSubscriber 数据流转消息订阅者
+// 消费内部消息总线的上行报文，按数据流转规则转发到数据目的
+type DataRuleMessageSubscriber struct {
+	dataRuleService *iotsvc.DataRuleService
+}
+
+// NewDataRuleMessageSubscriber 创建数据流转消息订阅者
+func NewDataRuleMessageSubscriber(dataRuleService *iotsvc.DataRuleService) *DataRuleMessageSubscriber {
+	return &DataRuleMessageSubscriber{
+		dataRuleService: dataRuleService,
+	}
+}
+
+// Topic 返回订阅的主题
+func (s *DataRuleMessageSubscriber) Topic() string {
+	return core.DeviceMessageTopic
+}
+
+// Group 返回订阅者分组
+func (s *DataRuleMessageSubscriber) Group() string {
+	return "iot_data_rule_consumer"
+}
+
+// OnMessage 处理上行设备消息
+func (s *DataRuleMessageSubscriber) OnMessage(message any) {
+	msg, ok := message.(*core.IotDeviceMessage)
+	if !ok {
+		log.Printf("[DataRuleMessageSubscriber] Invalid message type")
+		return
+	}
+
+	// 仅处理上行消息
+	if !msg.IsUpstreamMessage() {
+		return
+	}
+
+	s.dataRuleService.ExecuteDataRule(context.Background(), msg)
+}
+
+var _ core.MessageSubscriber = (*DataRuleMessageSubscriber)(nil)
@@ -0,0 +1,48 @@
+package gateway
+
+import (
+	"log"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+)
+
+// DeviceMessageStreamSubscriber 设备消息实时流订阅者
+// 以广播方式订阅，每个节点都收到全部事件，推送给本节点上订阅的 WebSocket 会话
+type DeviceMessageStreamSubscriber struct {
+	streamService *iotsvc.DeviceMessageStreamService
+}
+
+// NewDeviceMessageStreamSubscriber 创建设备消息实时流订阅者
+func NewDeviceMessageStreamSubscriber(streamService *iotsvc.DeviceMessageStreamService) *DeviceMessageStreamSubscriber {
+	return &DeviceMessageStreamSubscriber{
+		streamService: streamService,
+	}
+}
+
+// Topic 返回订阅的主题
+func (s *DeviceMessageStreamSubscriber) Topic() string {
+	return core.DeviceMessageStreamTopic
+}
+
+// Group 返回订阅者分组
+func (s *DeviceMessageStreamSubscriber) Group() string {
+	return "iot_device_message_stream"
+}
+
+// Broadcast 以广播方式订阅
+func (s *DeviceMessageStreamSubscriber) Broadcast() bool {
+	return true
+}
+
+// OnMessage 推送设备消息实时流事件
+func (s *DeviceMessageStreamSubscriber) OnMessage(message any) {
+	event, ok := message.(*core.DeviceMessageStreamEvent)
+	if !ok {
+		log.Printf("[DeviceMessageStreamSubscriber] Invalid message type")
+		return
+	}
+	s.streamService.Push(event)
+}
+
+var _ core.BroadcastSubscriber = (*DeviceMessageStreamSubscriber)(nil)
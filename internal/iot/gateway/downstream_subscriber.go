@@ -6,18 +6,24 @@ import (
 	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
 )
 
+// DownstreamSender 下行消息发送器
+// 由 MQTTClient（外部 Broker 模式）与 EmbeddedBroker（内置 Broker 模式）实现
+type DownstreamSender interface {
+	SendDownstreamMessage(productKey, deviceName string, message *core.IotDeviceMessage) error
+}
+
 // DownstreamSubscriber 下行指令订阅者
-// 订阅内部消息总线的下行指令，通过 MQTT 客户端推送到设备
+// 订阅内部消息总线的下行指令，通过下行消息发送器推送到设备
 type DownstreamSubscriber struct {
-	mqttClient *MQTTClient
-	serverID   string
+	sender   DownstreamSender
+	serverID string
 }
 
 // NewDownstreamSubscriber 创建下行指令订阅者
-func NewDownstreamSubscriber(mqttClient *MQTTClient, serverID string) *DownstreamSubscriber {
+func NewDownstreamSubscriber(sender DownstreamSender, serverID string) *DownstreamSubscriber {
 	return &DownstreamSubscriber{
-		mqttClient: mqttClient,
-		serverID:   serverID,
+		sender:   sender,
+		serverID: serverID,
 	}
 }
 
@@ -42,7 +48,7 @@ func (s *DownstreamSubscriber) OnMessage(message any) {
 	log.Printf("[DownstreamSubscriber] Sending downstream command to device: %s.%s",
 		msg.ProductKey, msg.DeviceName)
 
-	err := s.mqttClient.SendDownstreamMessage(msg.ProductKey, msg.DeviceName, msg.Message)
+	err := s.sender.SendDownstreamMessage(msg.ProductKey, msg.DeviceName, msg.Message)
 	if err != nil {
 		log.Printf("[DownstreamSubscriber] Send downstream message failed: %v", err)
 	}
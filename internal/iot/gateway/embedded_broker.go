@@ -0,0 +1,425 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/gateway/codec"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+)
+
+const (
+	// embeddedBrokerRegisterSuffix 动态注册连接的 ClientID 后缀，如 {productKey}.{deviceName}|register
+	// 用户名同设备认证 {productKey}&{deviceName}，密码为产品密钥签名
+	embeddedBrokerRegisterSuffix = "|register"
+	// embeddedBrokerRegisterTopic 动态注册结果的下发 Topic，设备无需订阅
+	embeddedBrokerRegisterTopic = "/ext/register"
+	// embeddedBrokerRegisterLinger 下发注册结果后保留连接的时长，设备应主动断开后以设备密钥重新连接
+	embeddedBrokerRegisterLinger = 5 * time.Second
+)
+
+// EmbeddedBrokerConfig 内置 MQTT Broker 配置
+type EmbeddedBrokerConfig struct {
+	Enabled          bool   // 是否启用内置 Broker
+	Address          string // 监听地址，如 :1883
+	DefaultCodecType string // 默认编解码器类型
+	TopicPrefix      string // 主题前缀，默认 /sys
+	TLSAddress       string // TLS 监听地址，如 :8883，为空不启用
+	TLSCertFile      string // TLS 服务端证书
+	TLSKeyFile       string // TLS 服务端私钥
+}
+
+// EmbeddedBroker 内置 MQTT Broker (支持 MQTT 3.1.1 / 5)
+// 设备直接连接网关进程：CONNECT 使用设备密钥认证，会话注册到连接管理器，
+// 上行消息经编解码器解码后直接投递到消息总线，无需外部 EMQX；
+// ClientID 以 |register 结尾的连接为动态注册连接：使用产品密钥认证，连接建立后下发设备密钥，不注册为设备会话；
+// 配置 TLS 监听后，X.509 认证的设备通过双向 TLS 接入，以客户端证书（设备 CA 签发、未吊销）认证，无需密码
+type EmbeddedBroker struct {
+	config            *EmbeddedBrokerConfig
+	server            *mqtt.Server
+	messageBus        core.MessageBus
+	codecRegistry     *codec.CodecRegistry
+	connectionManager *ConnectionManager
+	deviceService     *iotsvc.DeviceService
+	authUtils         *core.DeviceAuthUtils
+	serverID          string
+
+	// authenticated 已通过认证、尚未建立会话的设备，key: clientID
+	authenticated sync.Map
+	// registered 已完成动态注册、等待下发注册结果的连接，key: clientID
+	registered sync.Map
+}
+
+// NewEmbeddedBroker 创建内置 MQTT Broker
+func NewEmbeddedBroker(
+	config *EmbeddedBrokerConfig,
+	messageBus core.MessageBus,
+	codecRegistry *codec.CodecRegistry,
+	connectionManager *ConnectionManager,
+	deviceService *iotsvc.DeviceService,
+	serverID string,
+) *EmbeddedBroker {
+	if config.Address == "" {
+		config.Address = ":1883"
+	}
+	if config.TopicPrefix == "" {
+		config.TopicPrefix = "/sys"
+	}
+	if config.DefaultCodecType == "" {
+		config.DefaultCodecType = "Alink"
+	}
+	return &EmbeddedBroker{
+		config:            config,
+		messageBus:        messageBus,
+		codecRegistry:     codecRegistry,
+		connectionManager: connectionManager,
+		deviceService:     deviceService,
+		authUtils:         core.NewDeviceAuthUtils(),
+		serverID:          serverID,
+	}
+}
+
+// Start 启动内置 Broker
+func (b *EmbeddedBroker) Start() error {
+	b.server = mqtt.New(&mqtt.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err := b.server.AddHook(&embeddedBrokerHook{broker: b}, nil); err != nil {
+		return err
+	}
+	tcp := listeners.NewTCP(listeners.Config{ID: "iot-tcp", Address: b.config.Address})
+	if err := b.server.AddListener(tcp); err != nil {
+		return fmt.Errorf("listen %s failed: %w", b.config.Address, err)
+	}
+	if b.config.TLSAddress != "" {
+		tlsConfig, err := b.buildTLSConfig()
+		if err != nil {
+			return err
+		}
+		tlsListener := listeners.NewTCP(listeners.Config{ID: "iot-tls", Address: b.config.TLSAddress, TLSConfig: tlsConfig})
+		if err := b.server.AddListener(tlsListener); err != nil {
+			return fmt.Errorf("listen %s failed: %w", b.config.TLSAddress, err)
+		}
+		log.Printf("[EmbeddedBroker] TLS listener started on %s", b.config.TLSAddress)
+	}
+
+	go func() {
+		if err := b.server.Serve(); err != nil {
+			log.Printf("[EmbeddedBroker] Serve failed: %v", err)
+		}
+	}()
+	log.Printf("[EmbeddedBroker] Started on %s", b.config.Address)
+	return nil
+}
+
+// Stop 停止内置 Broker
+func (b *EmbeddedBroker) Stop() {
+	if b.server != nil {
+		_ = b.server.Close()
+	}
+	log.Printf("[EmbeddedBroker] Stopped")
+}
+
+// Publish 发布消息到指定主题（供 MQTT 数据目的等复用）
+func (b *EmbeddedBroker) Publish(topic string, qos byte, payload []byte) error {
+	if b.server == nil {
+		return fmt.Errorf("embedded broker not started")
+	}
+	return b.server.Publish(topic, payload, false, qos)
+}
+
+// SendDownstreamMessage 发送下行指令到设备
+func (b *EmbeddedBroker) SendDownstreamMessage(productKey, deviceName string, message *core.IotDeviceMessage) error {
+	deviceCodec, err := b.codecRegistry.GetByProduct(productKey, b.config.DefaultCodecType)
+	if err != nil {
+		return err
+	}
+	payload, err := deviceCodec.Encode(message)
+	if err != nil {
+		return fmt.Errorf("encode message failed: %w", err)
+	}
+
+	topic := buildDownstreamTopic(b.config.TopicPrefix, productKey, deviceName, message)
+	log.Printf("[EmbeddedBroker] Publishing downstream message to topic: %s", topic)
+	return b.Publish(topic, 1, payload)
+}
+
+// buildTLSConfig 构建 TLS 监听配置
+// 客户端证书可选：携带证书的设备按设备 CA 校验，未携带证书的设备仍可使用设备密钥认证（单向 TLS）
+func (b *EmbeddedBroker) buildTLSConfig() (*tls.Config, error) {
+	serverCert, err := tls.LoadX509KeyPair(b.config.TLSCertFile, b.config.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load tls certificate failed: %w", err)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    b.deviceService.GetCACertPool(),
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// authenticate 校验 CONNECT 报文
+// 携带客户端证书的连接按证书认证；否则用户名格式 {productKey}&{deviceName}，密码为基于设备密钥的 HMAC-SHA256 签名
+func (b *EmbeddedBroker) authenticate(cl *mqtt.Client, pk packets.Packet) bool {
+	if cert := peerCertificate(cl); cert != nil && !strings.HasSuffix(cl.ID, embeddedBrokerRegisterSuffix) {
+		return b.authenticateCertificate(cl, pk, cert)
+	}
+	info, err := b.authUtils.ParseUsername(string(pk.Connect.Username))
+	if err != nil {
+		log.Printf("[EmbeddedBroker] Auth failed: clientID=%s, err=%v", cl.ID, err)
+		return false
+	}
+	if strings.HasSuffix(cl.ID, embeddedBrokerRegisterSuffix) {
+		return b.authenticateRegister(cl, info, string(pk.Connect.Password))
+	}
+	device, err := b.deviceService.GetByProductKeyAndName(context.Background(), info.ProductKey, info.DeviceName)
+	if err != nil || device == nil {
+		log.Printf("[EmbeddedBroker] Auth failed: device not found, productKey=%s, deviceName=%s", info.ProductKey, info.DeviceName)
+		return false
+	}
+	if !b.deviceService.IsSecretAuth(device) {
+		log.Printf("[EmbeddedBroker] Auth failed: x509 device requires client certificate, productKey=%s, deviceName=%s", info.ProductKey, info.DeviceName)
+		return false
+	}
+	if !b.authUtils.ValidatePassword(device.DeviceSecret, device.DeviceName, device.ProductKey, string(pk.Connect.Password)) {
+		log.Printf("[EmbeddedBroker] Auth failed: invalid password, productKey=%s, deviceName=%s", info.ProductKey, info.DeviceName)
+		return false
+	}
+	b.authenticated.Store(cl.ID, device)
+	return true
+}
+
+// authenticateCertificate 按 TLS 客户端证书认证设备
+// 证书指纹映射到设备，用户名可为空；非空时须与证书对应的设备一致
+func (b *EmbeddedBroker) authenticateCertificate(cl *mqtt.Client, pk packets.Packet, cert *x509.Certificate) bool {
+	device, err := b.deviceService.AuthByCertificate(context.Background(), cert)
+	if err != nil {
+		log.Printf("[EmbeddedBroker] Auth failed: clientID=%s, subject=%s, err=%v", cl.ID, cert.Subject.CommonName, err)
+		return false
+	}
+	if username := string(pk.Connect.Username); username != "" && username != core.BuildCertificateSubject(device.ProductKey, device.DeviceName) {
+		log.Printf("[EmbeddedBroker] Auth failed: username mismatch certificate, clientID=%s, username=%s", cl.ID, username)
+		return false
+	}
+	b.authenticated.Store(cl.ID, device)
+	return true
+}
+
+// peerCertificate 获取 TLS 连接的客户端证书，非 TLS 连接或未携带证书时返回 nil
+func peerCertificate(cl *mqtt.Client) *x509.Certificate {
+	tlsConn, ok := cl.Net.Conn.(*tls.Conn)
+	if !ok {
+		return nil
+	}
+	certs := tlsConn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil
+	}
+	return certs[0]
+}
+
+// authenticateRegister 动态注册连接认证：按产品密钥签名注册设备，注册结果在连接建立后下发
+func (b *EmbeddedBroker) authenticateRegister(cl *mqtt.Client, info *core.DeviceInfo, sign string) bool {
+	resp, err := b.deviceService.RegisterDevice(context.Background(), &iot2.IotDeviceRegisterReqDTO{
+		ProductKey: info.ProductKey,
+		DeviceName: info.DeviceName,
+		Sign:       sign,
+	}, consts.IotDeviceRegisterProtocolMqtt, cl.Net.Remote)
+	if err != nil {
+		return false
+	}
+	b.registered.Store(cl.ID, resp)
+	return true
+}
+
+// replyRegister 向动态注册连接下发设备密钥，随后断开连接
+// 报文格式: {"productKey": "...", "deviceName": "...", "deviceSecret": "..."}
+func (b *EmbeddedBroker) replyRegister(cl *mqtt.Client, resp *iot2.IotDeviceRegisterRespDTO) {
+	payload, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("[EmbeddedBroker] Encode register reply failed: clientID=%s, err=%v", cl.ID, err)
+		return
+	}
+	err = cl.WritePacket(packets.Packet{
+		FixedHeader: packets.FixedHeader{Type: packets.Publish},
+		TopicName:   embeddedBrokerRegisterTopic,
+		Payload:     payload,
+	})
+	if err != nil {
+		log.Printf("[EmbeddedBroker] Send register reply failed: clientID=%s, err=%v", cl.ID, err)
+	}
+	time.AfterFunc(embeddedBrokerRegisterLinger, func() {
+		if !cl.Closed() {
+			_ = b.server.DisconnectClient(cl, packets.CodeSuccess)
+		}
+	})
+}
+
+// onSessionEstablished 会话建立后注册连接（触发设备上线）
+// 动态注册连接只下发注册结果，不注册为设备会话
+func (b *EmbeddedBroker) onSessionEstablished(cl *mqtt.Client) {
+	if value, ok := b.registered.LoadAndDelete(cl.ID); ok {
+		b.replyRegister(cl, value.(*iot2.IotDeviceRegisterRespDTO))
+		return
+	}
+	value, ok := b.authenticated.LoadAndDelete(cl.ID)
+	if !ok {
+		return
+	}
+	device := value.(*model.IotDeviceDO)
+	b.connectionManager.RegisterConnection(cl.ID, &ConnectionInfo{
+		DeviceID:      device.ID,
+		ProductKey:    device.ProductKey,
+		DeviceName:    device.DeviceName,
+		ClientID:      cl.ID,
+		Authenticated: true,
+		RemoteAddress: cl.Net.Remote,
+	})
+}
+
+// onDisconnect 连接断开后注销连接（触发设备离线）
+func (b *EmbeddedBroker) onDisconnect(cl *mqtt.Client) {
+	// 同一 clientID 重连接管旧会话时，旧连接断开不应使设备离线
+	if cl.IsTakenOver() {
+		return
+	}
+	info := b.connectionManager.GetConnectionInfo(cl.ID)
+	if info == nil || info.RemoteAddress != cl.Net.Remote {
+		return
+	}
+	b.connectionManager.UnregisterConnection(cl.ID)
+}
+
+// checkTopic 校验设备只能访问自身的 Topic：{TopicPrefix}/{productKey}/{deviceName}/...
+// 网关设备还可以访问其下已上线子设备的 Topic
+func (b *EmbeddedBroker) checkTopic(cl *mqtt.Client, topic string) bool {
+	return b.resolveTopicDevice(cl, topic) != nil
+}
+
+// resolveTopicDevice 解析 Topic 对应的设备连接：连接设备自身，或其下已上线的子设备
+func (b *EmbeddedBroker) resolveTopicDevice(cl *mqtt.Client, topic string) *ConnectionInfo {
+	info := b.connectionManager.GetConnectionInfo(cl.ID)
+	if info == nil {
+		return nil
+	}
+	devicePrefix := fmt.Sprintf("%s/%s/%s/", b.config.TopicPrefix, info.ProductKey, info.DeviceName)
+	if strings.HasPrefix(topic, devicePrefix) {
+		return info
+	}
+	productKey, deviceName, ok := parseTopicDevice(b.config.TopicPrefix, topic)
+	if !ok {
+		return nil
+	}
+	return b.connectionManager.GetSubDeviceConnection(cl.ID, productKey, deviceName)
+}
+
+// onPublished 处理设备上行消息：解码后投递到消息总线
+// 网关代理子设备发布到子设备 Topic 的消息，归属到对应的子设备
+func (b *EmbeddedBroker) onPublished(cl *mqtt.Client, pk packets.Packet) {
+	info := b.resolveTopicDevice(cl, pk.TopicName)
+	if info == nil {
+		return
+	}
+	deviceCodec, err := b.codecRegistry.GetByProduct(info.ProductKey, b.config.DefaultCodecType)
+	if err != nil {
+		log.Printf("[EmbeddedBroker] Get codec failed: productKey=%s, err=%v", info.ProductKey, err)
+		return
+	}
+	message, err := deviceCodec.Decode(pk.Payload)
+	if err != nil {
+		log.Printf("[EmbeddedBroker] Decode message failed: topic=%s, err=%v", pk.TopicName, err)
+		return
+	}
+
+	message.DeviceID = info.DeviceID
+	message.ServerID = b.serverID
+	message.ReportTime = time.Now()
+	b.messageBus.Post(core.DeviceMessageTopic, message)
+}
+
+// parseTopicDevice 从 Topic 中解析设备信息
+// Topic 格式: {TopicPrefix}/{productKey}/{deviceName}/...
+func parseTopicDevice(prefix, topic string) (productKey, deviceName string, ok bool) {
+	relativeTopic, found := strings.CutPrefix(topic, prefix+"/")
+	if !found {
+		return "", "", false
+	}
+	parts := strings.SplitN(relativeTopic, "/", 3)
+	if len(parts) < 3 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// embeddedBrokerHook 内置 Broker 钩子，将 Broker 事件桥接到网关组件
+type embeddedBrokerHook struct {
+	mqtt.HookBase
+	broker *EmbeddedBroker
+}
+
+func (h *embeddedBrokerHook) ID() string {
+	return "iot-device-hook"
+}
+
+func (h *embeddedBrokerHook) Provides(b byte) bool {
+	return bytes.Contains([]byte{
+		mqtt.OnConnectAuthenticate,
+		mqtt.OnACLCheck,
+		mqtt.OnSessionEstablished,
+		mqtt.OnDisconnect,
+		mqtt.OnPacketRead,
+		mqtt.OnPublished,
+	}, []byte{b})
+}
+
+func (h *embeddedBrokerHook) OnConnectAuthenticate(cl *mqtt.Client, pk packets.Packet) bool {
+	return h.broker.authenticate(cl, pk)
+}
+
+func (h *embeddedBrokerHook) OnACLCheck(cl *mqtt.Client, topic string, write bool) bool {
+	return h.broker.checkTopic(cl, topic)
+}
+
+func (h *embeddedBrokerHook) OnSessionEstablished(cl *mqtt.Client, pk packets.Packet) {
+	h.broker.onSessionEstablished(cl)
+}
+
+func (h *embeddedBrokerHook) OnDisconnect(cl *mqtt.Client, err error, expire bool) {
+	h.broker.onDisconnect(cl)
+}
+
+func (h *embeddedBrokerHook) OnPacketRead(cl *mqtt.Client, pk packets.Packet) (packets.Packet, error) {
+	// 任意报文（包括 PINGREQ）均视为心跳
+	h.broker.connectionManager.UpdateHeartbeat(cl.ID)
+	return pk, nil
+}
+
+func (h *embeddedBrokerHook) OnPublished(cl *mqtt.Client, pk packets.Packet) {
+	// 网关自身（内联客户端）发布的下行消息无需处理
+	if cl.Net.Inline {
+		return
+	}
+	h.broker.onPublished(cl, pk)
+}
+
+var _ DownstreamSender = (*EmbeddedBroker)(nil)
+var _ DownstreamSender = (*MQTTClient)(nil)
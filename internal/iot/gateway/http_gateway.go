@@ -0,0 +1,402 @@
+package gateway
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/gateway/codec"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+	bizErrors "github.com/wxlbd/ruoyi-mall-go/pkg/errors"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/response"
+)
+
+const (
+	// httpGatewayMaxPending 每个设备最多缓存的待拉取下行指令数，超出时丢弃最早的指令
+	httpGatewayMaxPending = 100
+	// httpGatewayPendingCleanInterval 清理过期下行指令的间隔
+	httpGatewayPendingCleanInterval = time.Minute
+)
+
+// HttpGatewayConfig HTTP 设备接入配置
+type HttpGatewayConfig struct {
+	Enabled          bool          // 是否启用 HTTP 接入
+	Address          string        // 监听地址，如 :8092
+	TokenExpire      time.Duration // 设备 Token 有效期
+	DefaultCodecType string        // 默认编解码器类型
+	TLSCertFile      string        // HTTPS 服务端证书，与私钥均配置时以 HTTPS 监听
+	TLSKeyFile       string        // HTTPS 服务端私钥
+	PendingExpire    time.Duration // 待拉取下行指令的有效期，过期未拉取时丢弃
+}
+
+// HttpAuthReqVO HTTP 设备认证请求
+// sign 与 MQTT 密码算法一致：HMAC-SHA256(deviceSecret, deviceName{deviceName}productKey{productKey})
+type HttpAuthReqVO struct {
+	ProductKey string `json:"productKey" binding:"required"`
+	DeviceName string `json:"deviceName" binding:"required"`
+	Sign       string `json:"sign" binding:"required"`
+}
+
+// HttpAuthRespVO HTTP 设备认证响应
+type HttpAuthRespVO struct {
+	Token    string `json:"token"`
+	ExpireIn int64  `json:"expireIn"` // 有效期（秒）
+}
+
+// HttpGateway HTTP 设备接入网关 (对齐 Java IotHttpUpstreamProtocol)
+// 设备先通过 /auth 获取短期 Token，之后携带 Token 上报属性/事件、拉取待执行的下行指令；
+// 开启动态注册的产品，设备可先通过 /auth/register 使用产品密钥签名换取设备密钥；
+// /auth/crl 公开设备证书吊销列表。
+// 上行消息与 MQTT 路径一致：经编解码器解码后投递到消息总线
+type HttpGateway struct {
+	config            *HttpGatewayConfig
+	server            *http.Server
+	messageBus        core.MessageBus
+	codecRegistry     *codec.CodecRegistry
+	connectionManager *ConnectionManager
+	deviceService     *iotsvc.DeviceService
+	authUtils         *core.DeviceAuthUtils
+	serverID          string
+
+	// pending 待设备拉取的下行指令，key: {productKey}/{deviceName}
+	// 每个设备最多缓存 httpGatewayMaxPending 条，超过有效期未拉取的指令定期清理
+	mu      sync.Mutex
+	pending map[string][]*httpPendingMessage
+	stopCh  chan struct{}
+}
+
+// httpPendingMessage 待拉取的下行指令
+type httpPendingMessage struct {
+	message    *core.IotDeviceMessage
+	expireTime time.Time
+}
+
+// NewHttpGateway 创建 HTTP 设备接入网关
+func NewHttpGateway(
+	config *HttpGatewayConfig,
+	messageBus core.MessageBus,
+	codecRegistry *codec.CodecRegistry,
+	connectionManager *ConnectionManager,
+	deviceService *iotsvc.DeviceService,
+	serverID string,
+) *HttpGateway {
+	if config.Address == "" {
+		config.Address = ":8092"
+	}
+	if config.TokenExpire <= 0 {
+		config.TokenExpire = 2 * time.Hour
+	}
+	if config.DefaultCodecType == "" {
+		config.DefaultCodecType = "Alink"
+	}
+	if config.PendingExpire <= 0 {
+		config.PendingExpire = 10 * time.Minute
+	}
+	return &HttpGateway{
+		config:            config,
+		messageBus:        messageBus,
+		codecRegistry:     codecRegistry,
+		connectionManager: connectionManager,
+		deviceService:     deviceService,
+		authUtils:         core.NewDeviceAuthUtils(),
+		serverID:          serverID,
+		pending:           make(map[string][]*httpPendingMessage),
+		stopCh:            make(chan struct{}),
+	}
+}
+
+// Start 启动 HTTP 接入服务
+func (g *HttpGateway) Start() error {
+	engine := gin.New()
+	engine.Use(gin.Recovery())
+	engine.POST("/auth", g.auth)
+	engine.POST("/auth/register", g.register)
+	engine.GET("/auth/crl", g.crl)
+	engine.POST("/topic/sys/:productKey/:deviceName/*path", g.upstream)
+	engine.GET("/downstream/sys/:productKey/:deviceName", g.pull)
+
+	g.server = &http.Server{Addr: g.config.Address, Handler: engine}
+	useTLS := g.config.TLSCertFile != "" && g.config.TLSKeyFile != ""
+	if useTLS {
+		// 提前加载证书，配置错误时启动失败而不是在后台静默退出
+		if _, err := tls.LoadX509KeyPair(g.config.TLSCertFile, g.config.TLSKeyFile); err != nil {
+			return fmt.Errorf("load http gateway tls certificate: %w", err)
+		}
+	} else {
+		log.Printf("[HttpGateway] TLS certificate not configured, serving plain HTTP; terminate TLS in front of the gateway")
+	}
+	go func() {
+		var err error
+		if useTLS {
+			err = g.server.ListenAndServeTLS(g.config.TLSCertFile, g.config.TLSKeyFile)
+		} else {
+			err = g.server.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("[HttpGateway] Serve failed: %v", err)
+		}
+	}()
+	go g.cleanExpiredPending()
+	log.Printf("[HttpGateway] Started on %s (tls=%v)", g.config.Address, useTLS)
+	return nil
+}
+
+// Stop 停止 HTTP 接入服务
+func (g *HttpGateway) Stop() {
+	close(g.stopCh)
+	if g.server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = g.server.Shutdown(ctx)
+	}
+	log.Printf("[HttpGateway] Stopped")
+}
+
+// HasSession 判断设备当前是否通过 HTTP 接入（存在未超时的 HTTP 会话）
+func (g *HttpGateway) HasSession(productKey, deviceName string) bool {
+	return g.connectionManager.GetConnectionInfo(buildHttpClientID(productKey, deviceName)) != nil
+}
+
+// SendDownstreamMessage 缓存下行指令，等待设备拉取
+func (g *HttpGateway) SendDownstreamMessage(productKey, deviceName string, message *core.IotDeviceMessage) error {
+	key := productKey + "/" + deviceName
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	queue := append(g.pending[key], &httpPendingMessage{
+		message:    message,
+		expireTime: time.Now().Add(g.config.PendingExpire),
+	})
+	if len(queue) > httpGatewayMaxPending {
+		log.Printf("[HttpGateway] Pending queue full, dropping oldest: %s", key)
+		queue = queue[len(queue)-httpGatewayMaxPending:]
+	}
+	g.pending[key] = queue
+	return nil
+}
+
+// cleanExpiredPending 定期清理过期的下行指令，设备不再拉取时释放其队列
+func (g *HttpGateway) cleanExpiredPending() {
+	ticker := time.NewTicker(httpGatewayPendingCleanInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-g.stopCh:
+			return
+		case <-ticker.C:
+			now := time.Now()
+			g.mu.Lock()
+			for key, queue := range g.pending {
+				if queue = filterUnexpiredPending(queue, now); len(queue) == 0 {
+					delete(g.pending, key)
+				} else {
+					g.pending[key] = queue
+				}
+			}
+			g.mu.Unlock()
+		}
+	}
+}
+
+// filterUnexpiredPending 过滤掉已过期的下行指令，队列按入队时间有序
+func filterUnexpiredPending(queue []*httpPendingMessage, now time.Time) []*httpPendingMessage {
+	for i, pending := range queue {
+		if now.Before(pending.expireTime) {
+			return queue[i:]
+		}
+	}
+	return nil
+}
+
+// auth 设备认证，签发 Token
+func (g *HttpGateway) auth(c *gin.Context) {
+	var r HttpAuthReqVO
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, bizErrors.BindingErr(err))
+		return
+	}
+	device, err := g.deviceService.GetByProductKeyAndName(c, r.ProductKey, r.DeviceName)
+	if err != nil || device == nil {
+		response.WriteBizError(c, model.ErrDeviceAuthFail)
+		return
+	}
+	// X.509 认证的设备只能通过双向 TLS 接入
+	if !g.deviceService.IsSecretAuth(device) {
+		response.WriteBizError(c, model.ErrDeviceAuthTypeInvalid)
+		return
+	}
+	if !g.authUtils.ValidatePassword(device.DeviceSecret, device.DeviceName, device.ProductKey, r.Sign) {
+		response.WriteBizError(c, model.ErrDeviceAuthFail)
+		return
+	}
+
+	token, err := g.authUtils.GenerateToken(device.ProductKey, device.DeviceName, device.DeviceSecret, g.config.TokenExpire)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	g.touchSession(device, c.ClientIP())
+	response.WriteSuccess(c, &HttpAuthRespVO{
+		Token:    token,
+		ExpireIn: int64(g.config.TokenExpire / time.Second),
+	})
+}
+
+// crl 设备证书吊销列表，供外部 Broker（如 EMQX）等 TLS 终端定期拉取
+func (g *HttpGateway) crl(c *gin.Context) {
+	crl, err := g.deviceService.GetCRL(c)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	c.Data(http.StatusOK, "application/pkix-crl", crl)
+}
+
+// register 设备动态注册（一型一密），返回设备密钥，设备之后按 /auth 正常认证
+func (g *HttpGateway) register(c *gin.Context) {
+	var r iot2.IotDeviceRegisterReqDTO
+	if err := c.ShouldBindJSON(&r); err != nil {
+		response.WriteBizError(c, bizErrors.BindingErr(err))
+		return
+	}
+	resp, err := g.deviceService.RegisterDevice(c, &r, consts.IotDeviceRegisterProtocolHttp, c.ClientIP())
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	response.WriteSuccess(c, resp)
+}
+
+// upstream 设备上报消息
+func (g *HttpGateway) upstream(c *gin.Context) {
+	device, ok := g.authenticate(c)
+	if !ok {
+		return
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		response.WriteBizError(c, bizErrors.ErrParam)
+		return
+	}
+	deviceCodec, err := g.codecRegistry.GetByProduct(device.ProductKey, g.config.DefaultCodecType)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+	message, err := deviceCodec.Decode(body)
+	if err != nil {
+		log.Printf("[HttpGateway] Decode message failed: device=%s/%s, err=%v", device.ProductKey, device.DeviceName, err)
+		response.WriteBizError(c, model.ErrDeviceMessageDecodeErr)
+		return
+	}
+
+	message.DeviceID = device.ID
+	message.ServerID = g.serverID
+	message.ReportTime = time.Now()
+	g.messageBus.Post(core.DeviceMessageTopic, message)
+	response.WriteSuccess(c, message.ID)
+}
+
+// pull 设备拉取待执行的下行指令（拉取后即从缓存移除）
+func (g *HttpGateway) pull(c *gin.Context) {
+	device, ok := g.authenticate(c)
+	if !ok {
+		return
+	}
+	deviceCodec, err := g.codecRegistry.GetByProduct(device.ProductKey, g.config.DefaultCodecType)
+	if err != nil {
+		response.WriteBizError(c, err)
+		return
+	}
+
+	key := device.ProductKey + "/" + device.DeviceName
+	g.mu.Lock()
+	messages := filterUnexpiredPending(g.pending[key], time.Now())
+	delete(g.pending, key)
+	g.mu.Unlock()
+
+	list := make([]json.RawMessage, 0, len(messages))
+	for _, pending := range messages {
+		payload, err := deviceCodec.Encode(pending.message)
+		if err != nil {
+			log.Printf("[HttpGateway] Encode message failed: device=%s, err=%v", key, err)
+			continue
+		}
+		list = append(list, payload)
+	}
+	response.WriteSuccess(c, list)
+}
+
+// authenticate 校验请求携带的设备 Token，并确认与路径中的设备一致
+func (g *HttpGateway) authenticate(c *gin.Context) (*model.IotDeviceDO, bool) {
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	productKey, deviceName := c.Param("productKey"), c.Param("deviceName")
+	info, err := g.authUtils.ParseTokenDevice(token)
+	if err != nil || info.ProductKey != productKey || info.DeviceName != deviceName {
+		response.WriteBizError(c, model.ErrDeviceTokenInvalid)
+		return nil, false
+	}
+	device, err := g.deviceService.GetByProductKeyAndName(c, productKey, deviceName)
+	if err != nil || device == nil {
+		response.WriteBizError(c, model.ErrDeviceTokenInvalid)
+		return nil, false
+	}
+	if _, err := g.authUtils.VerifyToken(token, device.DeviceSecret); err != nil {
+		response.WriteBizError(c, model.ErrDeviceTokenInvalid)
+		return nil, false
+	}
+	g.touchSession(device, c.ClientIP())
+	return device, true
+}
+
+// touchSession 刷新设备的 HTTP 会话：会话不存在时注册（触发上线），否则更新心跳
+// HTTP 设备没有长连接，超过心跳超时未请求即视为离线
+func (g *HttpGateway) touchSession(device *model.IotDeviceDO, remoteAddress string) {
+	clientID := buildHttpClientID(device.ProductKey, device.DeviceName)
+	if g.connectionManager.GetConnectionInfo(clientID) != nil {
+		g.connectionManager.UpdateHeartbeat(clientID)
+		return
+	}
+	g.connectionManager.RegisterConnection(clientID, &ConnectionInfo{
+		DeviceID:      device.ID,
+		ProductKey:    device.ProductKey,
+		DeviceName:    device.DeviceName,
+		ClientID:      clientID,
+		Authenticated: true,
+		RemoteAddress: remoteAddress,
+	})
+}
+
+// buildHttpClientID 构建 HTTP 会话在连接管理器中的客户端标识
+func buildHttpClientID(productKey, deviceName string) string {
+	return "http:" + productKey + "&" + deviceName
+}
+
+// httpDownstreamRouter 下行指令路由
+// 设备存在 HTTP 会话时缓存指令等待拉取，否则交由 MQTT 路径下发
+type httpDownstreamRouter struct {
+	httpGateway *HttpGateway
+	fallback    DownstreamSender
+}
+
+func (r *httpDownstreamRouter) SendDownstreamMessage(productKey, deviceName string, message *core.IotDeviceMessage) error {
+	if r.httpGateway.HasSession(productKey, deviceName) {
+		return r.httpGateway.SendDownstreamMessage(productKey, deviceName, message)
+	}
+	return r.fallback.SendDownstreamMessage(productKey, deviceName, message)
+}
+
+var _ DownstreamSender = (*HttpGateway)(nil)
+var _ DownstreamSender = (*httpDownstreamRouter)(nil)
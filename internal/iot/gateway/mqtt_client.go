@@ -2,6 +2,7 @@ package gateway
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"strings"
@@ -9,10 +10,26 @@ import (
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
 	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
 	"github.com/wxlbd/ruoyi-mall-go/internal/iot/gateway/codec"
+	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
 )
 
+const (
+	// mqttClientConnectedTopic EMQX 客户端上线系统主题，需在 Broker ACL 中允许平台客户端订阅
+	mqttClientConnectedTopic = "$SYS/brokers/+/clients/+/connected"
+	// mqttClientDisconnectedTopic EMQX 客户端下线系统主题
+	mqttClientDisconnectedTopic = "$SYS/brokers/+/clients/+/disconnected"
+)
+
+// mqttClientEvent EMQX 客户端上下线事件
+type mqttClientEvent struct {
+	ClientID string `json:"clientid"`
+	Username string `json:"username"`
+	Reason   string `json:"reason"`
+}
+
 // MQTTClientConfig MQTT 客户端配置
 type MQTTClientConfig struct {
 	Broker           string        `yaml:"broker"`             // EMQX Broker 地址，如 tcp://localhost:1883
@@ -35,12 +52,15 @@ type MQTTClient struct {
 	client        mqtt.Client
 	messageBus    core.MessageBus
 	codecRegistry *codec.CodecRegistry
+	deviceService *iotsvc.DeviceService
+	authUtils     *core.DeviceAuthUtils
+	serverID      string
 	mu            sync.RWMutex
 	running       bool
 }
 
 // NewMQTTClient 创建 MQTT 客户端
-func NewMQTTClient(config *MQTTClientConfig, messageBus core.MessageBus, codecRegistry *codec.CodecRegistry) *MQTTClient {
+func NewMQTTClient(config *MQTTClientConfig, messageBus core.MessageBus, codecRegistry *codec.CodecRegistry, deviceService *iotsvc.DeviceService, serverID string) *MQTTClient {
 	if config.TopicPrefix == "" {
 		config.TopicPrefix = "/sys"
 	}
@@ -48,6 +68,9 @@ func NewMQTTClient(config *MQTTClientConfig, messageBus core.MessageBus, codecRe
 		config:        config,
 		messageBus:    messageBus,
 		codecRegistry: codecRegistry,
+		deviceService: deviceService,
+		authUtils:     core.NewDeviceAuthUtils(),
+		serverID:      serverID,
 	}
 }
 
@@ -126,6 +149,54 @@ func (c *MQTTClient) onConnect(client mqtt.Client) {
 			log.Printf("[MQTTClient] Subscribed to topic: %s", topic)
 		}
 	}
+
+	// 订阅 Broker 的客户端上下线事件，持久化设备在线状态
+	for _, topic := range []string{mqttClientConnectedTopic, mqttClientDisconnectedTopic} {
+		token := client.Subscribe(topic, 1, c.onClientEvent)
+		if token.Wait() && token.Error() != nil {
+			log.Printf("[MQTTClient] Subscribe to %s failed: %v", topic, token.Error())
+		}
+	}
+}
+
+// onClientEvent 设备连接、断开 Broker 时发送上下线消息
+func (c *MQTTClient) onClientEvent(client mqtt.Client, msg mqtt.Message) {
+	var event mqttClientEvent
+	if err := json.Unmarshal(msg.Payload(), &event); err != nil {
+		log.Printf("[MQTTClient] Decode client event failed: topic=%s, err=%v", msg.Topic(), err)
+		return
+	}
+	// 同一 clientID 重连接管旧会话时，旧会话断开不应使设备离线
+	online := strings.HasSuffix(msg.Topic(), "/connected")
+	if !online && (event.Reason == "takenover" || event.Reason == "discarded") {
+		return
+	}
+	// 动态注册连接不是设备会话
+	if strings.HasSuffix(event.ClientID, embeddedBrokerRegisterSuffix) {
+		return
+	}
+	// 非设备连接（如平台自身）的用户名无法解析，忽略
+	info, err := c.authUtils.ParseUsername(event.Username)
+	if err != nil {
+		return
+	}
+	device, err := c.deviceService.GetByProductKeyAndName(context.Background(), info.ProductKey, info.DeviceName)
+	if err != nil || device == nil {
+		return
+	}
+
+	state := "offline"
+	if online {
+		state = "online"
+	}
+	c.messageBus.Post(core.DeviceMessageTopic, &core.IotDeviceMessage{
+		Method:     consts.IotDeviceMessageMethodStateUpdate,
+		Params:     map[string]any{"state": state},
+		DeviceID:   device.ID,
+		TenantID:   device.TenantID,
+		ServerID:   c.serverID,
+		ReportTime: time.Now(),
+	})
 }
 
 // onConnectionLost 连接丢失回调
@@ -148,20 +219,16 @@ func (c *MQTTClient) onMessage(client mqtt.Client, msg mqtt.Message) {
 
 	// 1. 解析 Topic 获取 productKey 和 deviceName
 	// Topic 格式: {TopicPrefix}/{productKey}/{deviceName}/thing/event/property/post
-	_, _, err := c.parseTopicDeviceInfo(topic)
+	productKey, deviceName, err := c.parseTopicDeviceInfo(topic)
 	if err != nil {
 		// log.Printf("[MQTTClient] Parse topic failed: %v", err)
 		return
 	}
 
-	// 2. 使用编解码器解码消息
-	codecType := c.config.DefaultCodecType
-	if codecType == "" {
-		codecType = "Alink"
-	}
-	deviceCodec := c.codecRegistry.Get(codecType)
-	if deviceCodec == nil {
-		log.Printf("[MQTTClient] Codec not found: %s", codecType)
+	// 2. 使用产品选择的编解码器解码消息
+	deviceCodec, err := c.codecRegistry.GetByProduct(productKey, c.config.DefaultCodecType)
+	if err != nil {
+		log.Printf("[MQTTClient] Get codec failed: productKey=%s, err=%v", productKey, err)
 		return
 	}
 
@@ -172,6 +239,13 @@ func (c *MQTTClient) onMessage(client mqtt.Client, msg mqtt.Message) {
 	}
 
 	// 3. 补充消息元数据
+	// 消息归属于 Topic 中的设备（网关代理子设备时为子设备）
+	device, err := c.deviceService.GetByProductKeyAndName(context.Background(), productKey, deviceName)
+	if err != nil || device == nil {
+		log.Printf("[MQTTClient] Device not found: productKey=%s, deviceName=%s", productKey, deviceName)
+		return
+	}
+	message.DeviceID = device.ID
 	message.ReportTime = time.Now()
 
 	// log.Printf("[MQTTClient] Device message: productKey=%s, deviceName=%s, method=%s",
@@ -205,28 +279,12 @@ func (c *MQTTClient) parseTopicDeviceInfo(topic string) (productKey, deviceName
 
 // SendDownstreamMessage 发送下行指令到设备
 func (c *MQTTClient) SendDownstreamMessage(productKey, deviceName string, message *core.IotDeviceMessage) error {
-	// 阿里 Alink 协议下行 Topic 逻辑 (严格对齐 Java IotMqttTopicUtils.buildTopicByMethod)
-	// 逻辑：{TopicPrefix}/{productKey}/{deviceName}/ + strings.ReplaceAll(method, ".", "/") + (isReply ? "_reply" : "")
-	topicSuffix := strings.ReplaceAll(message.Method, ".", "/")
-	if message.Code != nil {
-		topicSuffix += "_reply"
-	}
-
-	prefix := c.config.TopicPrefix
-	if prefix == "" {
-		prefix = "/sys"
-	}
-
-	topic := fmt.Sprintf("%s/%s/%s/%s", prefix, productKey, deviceName, topicSuffix)
+	topic := buildDownstreamTopic(c.config.TopicPrefix, productKey, deviceName, message)
 
 	// 编码消息
-	codecType := c.config.DefaultCodecType
-	if codecType == "" {
-		codecType = "Alink"
-	}
-	deviceCodec := c.codecRegistry.Get(codecType)
-	if deviceCodec == nil {
-		return fmt.Errorf("codec not found: %s", codecType)
+	deviceCodec, err := c.codecRegistry.GetByProduct(productKey, c.config.DefaultCodecType)
+	if err != nil {
+		return err
 	}
 
 	payload, err := deviceCodec.Encode(message)
@@ -237,3 +295,17 @@ func (c *MQTTClient) SendDownstreamMessage(productKey, deviceName string, messag
 	log.Printf("[MQTTClient] Publishing downstream message to topic: %s", topic)
 	return c.Publish(topic, 1, payload)
 }
+
+// buildDownstreamTopic 构建下行消息 Topic
+// 阿里 Alink 协议下行 Topic 逻辑 (严格对齐 Java IotMqttTopicUtils.buildTopicByMethod)
+// 逻辑：{TopicPrefix}/{productKey}/{deviceName}/ + strings.ReplaceAll(method, ".", "/") + (isReply ? "_reply" : "")
+func buildDownstreamTopic(prefix, productKey, deviceName string, message *core.IotDeviceMessage) string {
+	topicSuffix := strings.ReplaceAll(message.Method, ".", "/")
+	if message.Code != nil {
+		topicSuffix += "_reply"
+	}
+	if prefix == "" {
+		prefix = "/sys"
+	}
+	return fmt.Sprintf("%s/%s/%s/%s", prefix, productKey, deviceName, topicSuffix)
+}
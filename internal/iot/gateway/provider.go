@@ -5,23 +5,30 @@ import (
 
 	"github.com/google/wire"
 	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
-	"github.com/wxlbd/ruoyi-mall-go/internal/iot/gateway/codec"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/config"
 )
 
 // ProviderSet IOT Gateway 模块依赖注入
+// 编解码器注册表由 IOT 服务 ProviderSet 提供，与产品服务共用同一实例
 var ProviderSet = wire.NewSet(
-	// Codec Registry
-	codec.DefaultRegistry,
-
 	// Connection Manager Provider
 	ProvideConnectionManager,
 
 	// MQTT Client Provider
 	ProvideMQTTClientConfig,
 
+	// Embedded Broker Provider
+	ProvideEmbeddedBrokerConfig,
+
+	// HTTP Gateway Provider
+	ProvideHttpGatewayConfig,
+
 	// Message Subscribers
 	NewDeviceMessageSubscriber,
+	NewSceneRuleMessageSubscriber,
+	NewDataRuleMessageSubscriber,
+	NewDeviceMessageStreamSubscriber,
+	NewCacheInvalidateSubscriber,
 )
 
 // ProvideConnectionManager 提供连接管理器
@@ -67,3 +74,44 @@ func ProvideMQTTClientConfig() *MQTTClientConfig {
 		TopicPrefix:      cfg.TopicPrefix,
 	}
 }
+
+// ProvideEmbeddedBrokerConfig 提供内置 MQTT Broker 配置
+// 编解码器与主题前缀沿用 MQTT 客户端配置
+func ProvideEmbeddedBrokerConfig() *EmbeddedBrokerConfig {
+	cfg := config.C.IoT.Gateway
+	return &EmbeddedBrokerConfig{
+		Enabled:          cfg.EmbeddedBroker.Enabled,
+		Address:          cfg.EmbeddedBroker.Address,
+		DefaultCodecType: cfg.MQTT.DefaultCodecType,
+		TopicPrefix:      cfg.MQTT.TopicPrefix,
+		TLSAddress:       cfg.EmbeddedBroker.TLSAddress,
+		TLSCertFile:      cfg.EmbeddedBroker.TLSCertFile,
+		TLSKeyFile:       cfg.EmbeddedBroker.TLSKeyFile,
+	}
+}
+
+// ProvideHttpGatewayConfig 提供 HTTP 设备接入配置
+// 编解码器沿用 MQTT 客户端配置
+func ProvideHttpGatewayConfig() *HttpGatewayConfig {
+	cfg := config.C.IoT.Gateway
+
+	tokenExpire, _ := time.ParseDuration(cfg.HTTP.TokenExpire)
+	if tokenExpire == 0 {
+		tokenExpire = 2 * time.Hour
+	}
+
+	pendingExpire, _ := time.ParseDuration(cfg.HTTP.PendingExpire)
+	if pendingExpire == 0 {
+		pendingExpire = 10 * time.Minute
+	}
+
+	return &HttpGatewayConfig{
+		Enabled:          cfg.HTTP.Enabled,
+		Address:          cfg.HTTP.Address,
+		TokenExpire:      tokenExpire,
+		DefaultCodecType: cfg.MQTT.DefaultCodecType,
+		TLSCertFile:      cfg.HTTP.TLSCertFile,
+		TLSKeyFile:       cfg.HTTP.TLSKeyFile,
+		PendingExpire:    pendingExpire,
+	}
+}
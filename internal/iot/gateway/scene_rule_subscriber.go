@@ -0,0 +1,50 @@
+package gateway
+
+import (
+	"context"
+	"log"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+)
+
+// SceneRuleMessageSubscriber 场景联动消息订阅者
+// 消费内部消息总线的上行报文，匹配并执行场景联动规则
+type SceneRuleMessageSubscriber struct {
+	sceneRuleService *iotsvc.SceneRuleService
+}
+
+// NewSceneRuleMessageSubscriber 创建场景联动消息订阅者
+func NewSceneRuleMessageSubscriber(sceneRuleService *iotsvc.SceneRuleService) *SceneRuleMessageSubscriber {
+	return &SceneRuleMessageSubscriber{
+		sceneRuleService: sceneRuleService,
+	}
+}
+
+// Topic 返回订阅的主题
+func (s *SceneRuleMessageSubscriber) Topic() string {
+	return core.DeviceMessageTopic
+}
+
+// Group 返回订阅者分组
+func (s *SceneRuleMessageSubscriber) Group() string {
+	return "iot_scene_rule_consumer"
+}
+
+// OnMessage 处理上行设备消息
+func (s *SceneRuleMessageSubscriber) OnMessage(message any) {
+	msg, ok := message.(*core.IotDeviceMessage)
+	if !ok {
+		log.Printf("[SceneRuleMessageSubscriber] Invalid message type")
+		return
+	}
+
+	// 仅处理上行消息
+	if !msg.IsUpstreamMessage() {
+		return
+	}
+
+	s.sceneRuleService.ExecuteSceneRuleByDevice(context.Background(), msg)
+}
+
+var _ core.MessageSubscriber = (*SceneRuleMessageSubscriber)(nil)
@@ -0,0 +1,69 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+)
+
+// FileDataSinkConfig 本地文件数据目的配置
+type FileDataSinkConfig struct {
+	Path string `json:"path"`
+}
+
+// FileDataSink 本地文件数据目的
+// 以 JSON Lines 格式将设备消息追加写入配置的文件
+type FileDataSink struct {
+	mu sync.Mutex
+}
+
+// NewFileDataSink 创建本地文件数据目的
+func NewFileDataSink() *FileDataSink {
+	return &FileDataSink{}
+}
+
+// Type 返回数据目的类型
+func (s *FileDataSink) Type() int8 {
+	return consts.IotDataSinkTypeFile
+}
+
+// Send 追加写入设备消息
+func (s *FileDataSink) Send(ctx context.Context, config []byte, message *core.IotDeviceMessage) error {
+	var cfg FileDataSinkConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return fmt.Errorf("invalid file sink config: %w", err)
+	}
+	if cfg.Path == "" {
+		return fmt.Errorf("file sink path is empty")
+	}
+
+	line, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	// 串行写入，避免多条消息的行交错
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+var _ DataSink = (*FileDataSink)(nil)
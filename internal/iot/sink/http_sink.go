@@ -0,0 +1,80 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+)
+
+// HttpDataSinkConfig HTTP 数据目的配置
+type HttpDataSinkConfig struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+}
+
+// HttpDataSink HTTP Webhook 数据目的
+// 将设备消息以 JSON 格式推送到配置的 URL
+type HttpDataSink struct {
+	client *http.Client
+}
+
+// NewHttpDataSink 创建 HTTP 数据目的
+func NewHttpDataSink() *HttpDataSink {
+	return &HttpDataSink{
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Type 返回数据目的类型
+func (s *HttpDataSink) Type() int8 {
+	return consts.IotDataSinkTypeHttp
+}
+
+// Send 推送设备消息
+func (s *HttpDataSink) Send(ctx context.Context, config []byte, message *core.IotDeviceMessage) error {
+	var cfg HttpDataSinkConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return fmt.Errorf("invalid http sink config: %w", err)
+	}
+	if cfg.URL == "" {
+		return fmt.Errorf("http sink url is empty")
+	}
+	method := cfg.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("http sink responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+var _ DataSink = (*HttpDataSink)(nil)
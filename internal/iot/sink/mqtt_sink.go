@@ -0,0 +1,57 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+)
+
+// MQTTPublisher MQTT 发布者接口
+// 由 gateway.MQTTClient 实现，避免 sink 包依赖网关包
+type MQTTPublisher interface {
+	Publish(topic string, qos byte, payload []byte) error
+}
+
+// MQTTDataSinkConfig MQTT 数据目的配置
+type MQTTDataSinkConfig struct {
+	Topic string `json:"topic"`
+	Qos   byte   `json:"qos"`
+}
+
+// MQTTDataSink MQTT 数据目的
+// 通过网关的 MQTT 客户端将设备消息重新发布到配置的主题
+type MQTTDataSink struct {
+	publisher MQTTPublisher
+}
+
+// NewMQTTDataSink 创建 MQTT 数据目的
+func NewMQTTDataSink(publisher MQTTPublisher) *MQTTDataSink {
+	return &MQTTDataSink{publisher: publisher}
+}
+
+// Type 返回数据目的类型
+func (s *MQTTDataSink) Type() int8 {
+	return consts.IotDataSinkTypeMqtt
+}
+
+// Send 发布设备消息
+func (s *MQTTDataSink) Send(ctx context.Context, config []byte, message *core.IotDeviceMessage) error {
+	var cfg MQTTDataSinkConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return fmt.Errorf("invalid mqtt sink config: %w", err)
+	}
+	if cfg.Topic == "" {
+		return fmt.Errorf("mqtt sink topic is empty")
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return s.publisher.Publish(cfg.Topic, cfg.Qos, payload)
+}
+
+var _ DataSink = (*MQTTDataSink)(nil)
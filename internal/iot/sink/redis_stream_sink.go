@@ -0,0 +1,102 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+)
+
+// RedisStreamDataSinkConfig Redis Stream 数据目的配置
+// Host 为空时使用系统默认的 Redis 连接
+type RedisStreamDataSinkConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Password string `json:"password"`
+	Database int    `json:"database"`
+	Topic    string `json:"topic"`
+	MaxLen   int64  `json:"maxLen"`
+}
+
+// RedisStreamDataSink Redis Stream 数据目的
+// 通过 XADD 将设备消息写入配置的 Stream
+type RedisStreamDataSink struct {
+	rdb     *redis.Client
+	mu      sync.Mutex
+	clients map[string]*redis.Client
+}
+
+// NewRedisStreamDataSink 创建 Redis Stream 数据目的
+func NewRedisStreamDataSink(rdb *redis.Client) *RedisStreamDataSink {
+	return &RedisStreamDataSink{
+		rdb:     rdb,
+		clients: make(map[string]*redis.Client),
+	}
+}
+
+// Type 返回数据目的类型
+func (s *RedisStreamDataSink) Type() int8 {
+	return consts.IotDataSinkTypeRedis
+}
+
+// Send 写入设备消息
+func (s *RedisStreamDataSink) Send(ctx context.Context, config []byte, message *core.IotDeviceMessage) error {
+	var cfg RedisStreamDataSinkConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return fmt.Errorf("invalid redis sink config: %w", err)
+	}
+	if cfg.Topic == "" {
+		return fmt.Errorf("redis sink topic is empty")
+	}
+	client := s.getClient(&cfg)
+	if client == nil {
+		return fmt.Errorf("redis sink client is not available")
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	args := &redis.XAddArgs{
+		Stream: cfg.Topic,
+		Values: map[string]any{"message": payload},
+	}
+	if cfg.MaxLen > 0 {
+		args.MaxLen = cfg.MaxLen
+		args.Approx = true
+	}
+	return client.XAdd(ctx, args).Err()
+}
+
+// getClient 获取配置对应的 Redis 客户端，按地址缓存复用
+func (s *RedisStreamDataSink) getClient(cfg *RedisStreamDataSinkConfig) *redis.Client {
+	if cfg.Host == "" {
+		return s.rdb
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = 6379
+	}
+	addr := cfg.Host + ":" + strconv.Itoa(port)
+	key := addr + "/" + strconv.Itoa(cfg.Database)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if client, ok := s.clients[key]; ok {
+		return client
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: cfg.Password,
+		DB:       cfg.Database,
+	})
+	s.clients[key] = client
+	return client
+}
+
+var _ DataSink = (*RedisStreamDataSink)(nil)
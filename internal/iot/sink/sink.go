@@ -0,0 +1,107 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+)
+
+// DataSink 数据流转目的接口 (对齐 Java IotDataRuleAction)
+type DataSink interface {
+	// Type 返回支持的数据目的类型
+	Type() int8
+
+	// Send 将设备消息投递到数据目的
+	// config 为数据目的配置 (IotDataSinkDO.Config)
+	Send(ctx context.Context, config []byte, message *core.IotDeviceMessage) error
+}
+
+// DataSinkRegistry 数据目的注册表
+// MQTT 等依赖网关运行时组件的数据目的会在网关启动后注册，因此需要加锁
+type DataSinkRegistry struct {
+	mu    sync.RWMutex
+	sinks map[int8]DataSink
+}
+
+// NewDataSinkRegistry 创建数据目的注册表
+func NewDataSinkRegistry() *DataSinkRegistry {
+	return &DataSinkRegistry{
+		sinks: make(map[int8]DataSink),
+	}
+}
+
+// Register 注册数据目的
+func (r *DataSinkRegistry) Register(sink DataSink) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sinks[sink.Type()] = sink
+}
+
+// Get 获取指定类型的数据目的
+func (r *DataSinkRegistry) Get(sinkType int8) DataSink {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.sinks[sinkType]
+}
+
+// DefaultRegistry 创建默认的数据目的注册表（包含 HTTP、Redis Stream、本地文件）
+func DefaultRegistry(rdb *redis.Client) *DataSinkRegistry {
+	registry := NewDataSinkRegistry()
+	registry.Register(NewHttpDataSink())
+	registry.Register(NewRedisStreamDataSink(rdb))
+	registry.Register(NewFileDataSink())
+	return registry
+}
+
+// RetryPolicy 数据目的投递重试策略
+type RetryPolicy struct {
+	// MaxRetries 最大重试次数（不含首次投递）
+	MaxRetries int
+	// InitialBackoff 首次重试间隔，之后每次翻倍
+	InitialBackoff time.Duration
+	// MaxBackoff 最大重试间隔
+	MaxBackoff time.Duration
+}
+
+const (
+	defaultMaxRetries     = 3
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = 30 * time.Second
+)
+
+// ParseRetryPolicy 从数据目的配置中解析重试策略
+// 支持配置项：retryTimes（重试次数，0 表示不重试）、retryInterval（首次重试间隔，毫秒）
+func ParseRetryPolicy(config []byte) RetryPolicy {
+	policy := RetryPolicy{
+		MaxRetries:     defaultMaxRetries,
+		InitialBackoff: defaultInitialBackoff,
+		MaxBackoff:     defaultMaxBackoff,
+	}
+	var raw struct {
+		RetryTimes    *int  `json:"retryTimes"`
+		RetryInterval int64 `json:"retryInterval"`
+	}
+	if err := json.Unmarshal(config, &raw); err != nil {
+		return policy
+	}
+	if raw.RetryTimes != nil {
+		policy.MaxRetries = max(*raw.RetryTimes, 0)
+	}
+	if raw.RetryInterval > 0 {
+		policy.InitialBackoff = time.Duration(raw.RetryInterval) * time.Millisecond
+	}
+	return policy
+}
+
+// Backoff 返回第 attempt 次重试前的等待时间（attempt 从 1 开始）
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < attempt && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, p.MaxBackoff)
+}
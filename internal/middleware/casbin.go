@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	stdctx "context"
 	"fmt"
 	"net/http"
 
@@ -35,31 +36,11 @@ func (m *CasbinMiddleware) RequirePermission(permission string) gin.HandlerFunc
 			return
 		}
 
-		// 1. 超级管理员直接放行
-		isSuper, err := m.permSvc.IsSuperAdmin(c.Request.Context(), user.UserID)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, response.Error(500, "判断超级管理员失败"))
-			return
-		}
-		if isSuper {
-			c.Next()
-			return
-		}
-
-		// 2. Casbin 鉴权
-		// Subject: user:{userId}
-		// Object: permission
-		// Action: access
-		// 注意：Adapter 加载的 g 策略是 g, user:{userId}, role:{roleId}
-		// Adapter 加载的 p 策略是 p, role:{roleId}, permission, access
-		// Casbin 会自动推导 user -> role -> permission
-		sub := fmt.Sprintf("user:%d", user.UserID)
-		ok, err := m.enforcer.Enforce(sub, permission, "access")
+		ok, err := m.HasPermission(c.Request.Context(), user.UserID, permission)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusInternalServerError, response.Error(500, "权限校验错误"))
 			return
 		}
-
 		if !ok {
 			c.AbortWithStatusJSON(http.StatusForbidden, response.Error(403, "权限不足"))
 			return
@@ -68,3 +49,25 @@ func (m *CasbinMiddleware) RequirePermission(permission string) gin.HandlerFunc
 		c.Next()
 	}
 }
+
+// HasPermission 判断用户是否拥有权限，超级管理员拥有全部权限
+// 供 HTTP 以外的入口（如 WebSocket 订阅）复用同一套鉴权
+func (m *CasbinMiddleware) HasPermission(ctx stdctx.Context, userID int64, permission string) (bool, error) {
+	// 1. 超级管理员直接放行
+	isSuper, err := m.permSvc.IsSuperAdmin(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if isSuper {
+		return true, nil
+	}
+
+	// 2. Casbin 鉴权
+	// Subject: user:{userId}
+	// Object: permission
+	// Action: access
+	// 注意：Adapter 加载的 g 策略是 g, user:{userId}, role:{roleId}
+	// Adapter 加载的 p 策略是 p, role:{roleId}, permission, access
+	// Casbin 会自动推导 user -> role -> permission
+	return m.enforcer.Enforce(fmt.Sprintf("user:%d", userID), permission, "access")
+}
@@ -23,6 +23,13 @@ type IotProductDO struct {
 	NetType      int8   `gorm:"column:net_type;not null;default:0;comment:联网方式" json:"netType"`
 	LocationType int8   `gorm:"column:location_type;not null;default:0;comment:定位方式" json:"locationType"`
 	CodecType    string `gorm:"column:codec_type;size:64;comment:数据格式" json:"codecType"`
+	CodecConfig  string `gorm:"column:codec_config;type:text;comment:编解码配置" json:"codecConfig"`
+	// PropertyRetentionDays 属性原始数据保留天数，0 表示永久保留；聚合数据不受影响
+	PropertyRetentionDays int `gorm:"column:property_retention_days;not null;default:0;comment:属性历史保留天数" json:"propertyRetentionDays"`
+	// ProductSecret 产品密钥（一型一密），设备动态注册时用于签名
+	ProductSecret string `gorm:"column:product_secret;size:64;comment:产品密钥" json:"productSecret"`
+	// RegisterEnabled 是否允许设备动态注册
+	RegisterEnabled bool `gorm:"column:register_enabled;not null;default:false;comment:是否开启动态注册" json:"registerEnabled"`
 }
 
 // TableName 表名
@@ -64,6 +71,52 @@ func (IotDeviceDO) TableName() string {
 	return "iot_device"
 }
 
+// IotDeviceCertificateDO IoT 设备证书 DO
+// X.509 认证设备的客户端证书，由设备 CA 签发；吊销的证书进入 CRL
+type IotDeviceCertificateDO struct {
+	TenantBaseDO
+	ID           int64      `gorm:"column:id;primaryKey;autoIncrement;comment:证书编号" json:"id"`
+	DeviceID     int64      `gorm:"column:device_id;not null;comment:设备编号" json:"deviceId"`
+	ProductKey   string     `gorm:"column:product_key;size:64;not null;comment:产品标识" json:"productKey"`
+	DeviceName   string     `gorm:"column:device_name;size:64;not null;comment:设备名称" json:"deviceName"`
+	SerialNumber string     `gorm:"column:serial_number;size:64;not null;comment:证书序列号（十六进制）" json:"serialNumber"`
+	Fingerprint  string     `gorm:"column:fingerprint;size:64;not null;comment:证书 SHA-256 指纹（十六进制）" json:"fingerprint"`
+	Subject      string     `gorm:"column:subject;size:255;comment:证书主题 CN" json:"subject"`
+	Certificate  string     `gorm:"column:certificate;type:text;comment:证书（PEM）" json:"certificate"`
+	PrivateKey   string     `gorm:"column:private_key;type:text;comment:私钥（PEM）" json:"-"`
+	NotBefore    time.Time  `gorm:"column:not_before;not null;comment:生效时间" json:"notBefore"`
+	NotAfter     time.Time  `gorm:"column:not_after;not null;comment:过期时间" json:"notAfter"`
+	Status       int8       `gorm:"column:status;not null;default:0;comment:证书状态" json:"status"`
+	RevokeTime   *time.Time `gorm:"column:revoke_time;comment:吊销时间" json:"revokeTime"`
+	RevokeReason string     `gorm:"column:revoke_reason;size:255;comment:吊销原因" json:"revokeReason"`
+}
+
+// TableName 表名
+func (IotDeviceCertificateDO) TableName() string {
+	return "iot_device_certificate"
+}
+
+// IotDeviceRegisterLogDO IoT 设备动态注册日志 DO
+// 记录每一次动态注册请求（含失败），用于审计
+type IotDeviceRegisterLogDO struct {
+	TenantBaseDO
+	ID         int64  `gorm:"column:id;primaryKey;autoIncrement;comment:日志编号" json:"id"`
+	ProductID  int64  `gorm:"column:product_id;comment:产品编号" json:"productId"`
+	ProductKey string `gorm:"column:product_key;size:64;not null;comment:产品标识" json:"productKey"`
+	DeviceName string `gorm:"column:device_name;size:64;not null;comment:设备名称" json:"deviceName"`
+	DeviceID   int64  `gorm:"column:device_id;comment:设备编号" json:"deviceId"`
+	Protocol   string `gorm:"column:protocol;size:16;not null;comment:注册协议" json:"protocol"`
+	ClientIP   string `gorm:"column:client_ip;size:64;comment:客户端 IP" json:"clientIp"`
+	Success    bool   `gorm:"column:success;not null;comment:是否成功" json:"success"`
+	Created    bool   `gorm:"column:created;not null;comment:是否新建设备" json:"created"`
+	ErrorMsg   string `gorm:"column:error_msg;size:255;comment:失败原因" json:"errorMsg"`
+}
+
+// TableName 表名
+func (IotDeviceRegisterLogDO) TableName() string {
+	return "iot_device_register_log"
+}
+
 // IotThingModelDO IoT 产品物模型功能 DO
 type IotThingModelDO struct {
 	BaseDO
@@ -128,6 +181,9 @@ type IotOtaTaskDO struct {
 	DeviceScope        int8   `gorm:"column:device_scope;not null;comment:设备升级范围" json:"deviceScope"`
 	DeviceTotalCount   int32  `gorm:"column:device_total_count;not null;default:0;comment:设备总数数量" json:"deviceTotalCount"`
 	DeviceSuccessCount int32  `gorm:"column:device_success_count;not null;default:0;comment:设备成功数量" json:"deviceSuccessCount"`
+	MaxConcurrency     int32  `gorm:"column:max_concurrency;not null;default:0;comment:最大并发升级设备数" json:"maxConcurrency"`
+	RolloutRate        int32  `gorm:"column:rollout_rate;not null;default:0;comment:每分钟最大推送设备数" json:"rolloutRate"`
+	Timeout            int32  `gorm:"column:timeout;not null;default:0;comment:升级超时时间(秒)" json:"timeout"`
 }
 
 // TableName 表名
@@ -138,14 +194,15 @@ func (IotOtaTaskDO) TableName() string {
 // IotOtaTaskRecordDO IoT OTA 升级任务记录 DO
 type IotOtaTaskRecordDO struct {
 	TenantBaseDO
-	ID             int64  `gorm:"column:id;primaryKey;autoIncrement;comment:升级记录编号" json:"id"`
-	FirmwareID     int64  `gorm:"column:firmware_id;not null;comment:固件编号" json:"firmwareId"`
-	TaskID         int64  `gorm:"column:task_id;not null;comment:任务编号" json:"taskId"`
-	DeviceID       int64  `gorm:"column:device_id;not null;comment:设备编号" json:"deviceId"`
-	FromFirmwareID int64  `gorm:"column:from_firmware_id;comment:来源的固件编号" json:"fromFirmwareId"`
-	Status         int8   `gorm:"column:status;not null;default:0;comment:升级状态" json:"status"`
-	Progress       int32  `gorm:"column:progress;not null;default:0;comment:升级进度" json:"progress"`
-	Description    string `gorm:"column:description;size:255;comment:升级进度描述" json:"description"`
+	ID             int64      `gorm:"column:id;primaryKey;autoIncrement;comment:升级记录编号" json:"id"`
+	FirmwareID     int64      `gorm:"column:firmware_id;not null;comment:固件编号" json:"firmwareId"`
+	TaskID         int64      `gorm:"column:task_id;not null;comment:任务编号" json:"taskId"`
+	DeviceID       int64      `gorm:"column:device_id;not null;comment:设备编号" json:"deviceId"`
+	FromFirmwareID int64      `gorm:"column:from_firmware_id;comment:来源的固件编号" json:"fromFirmwareId"`
+	Status         int8       `gorm:"column:status;not null;default:0;comment:升级状态" json:"status"`
+	Progress       int32      `gorm:"column:progress;not null;default:0;comment:升级进度" json:"progress"`
+	Description    string     `gorm:"column:description;size:255;comment:升级进度描述" json:"description"`
+	PushTime       *time.Time `gorm:"column:push_time;comment:推送时间" json:"pushTime"`
 }
 
 // TableName 表名
@@ -164,6 +221,8 @@ type IotAlertConfigDO struct {
 	SceneRuleIDs   datatypes.JSON `gorm:"column:scene_rule_ids;size:255;comment:关联的场景联动规则编号数组" json:"sceneRuleIds"`
 	ReceiveUserIDs datatypes.JSON `gorm:"column:receive_user_ids;size:255;comment:接收的用户编号数组" json:"receiveUserIds"`
 	ReceiveTypes   datatypes.JSON `gorm:"column:receive_types;size:255;comment:接收的类型数组" json:"receiveTypes"`
+	DedupWindow    int32          `gorm:"column:dedup_window;not null;default:0;comment:去重窗口(秒)" json:"dedupWindow"` // 0 表示不去重
+	SilenceWindow  int32          `gorm:"column:silence_window;not null;default:0;comment:通知静默窗口(秒)" json:"silenceWindow"`
 }
 
 // TableName 表名
@@ -223,6 +282,26 @@ func (IotDataSinkDO) TableName() string {
 	return "iot_data_sink"
 }
 
+// IotDataSinkDeadLetterDO IoT 数据流转死信 DO
+// 记录重试耗尽后仍投递失败的设备消息，便于排查与补发
+type IotDataSinkDeadLetterDO struct {
+	TenantBaseDO
+	ID         int64  `gorm:"column:id;primaryKey;autoIncrement;comment:死信编号" json:"id"`
+	RuleID     int64  `gorm:"column:rule_id;not null;comment:数据流转规则编号" json:"ruleId"`
+	SinkID     int64  `gorm:"column:sink_id;not null;comment:数据目的编号" json:"sinkId"`
+	SinkType   int8   `gorm:"column:sink_type;not null;comment:数据目的类型" json:"sinkType"`
+	DeviceID   int64  `gorm:"column:device_id;not null;comment:设备编号" json:"deviceId"`
+	MessageID  string `gorm:"column:message_id;size:64;comment:消息编号" json:"messageId"`
+	Message    string `gorm:"column:message;type:text;comment:设备消息(JSON)" json:"message"`
+	RetryCount int32  `gorm:"column:retry_count;not null;default:0;comment:重试次数" json:"retryCount"`
+	ErrorMsg   string `gorm:"column:error_msg;size:512;comment:失败原因" json:"errorMsg"`
+}
+
+// TableName 表名
+func (IotDataSinkDeadLetterDO) TableName() string {
+	return "iot_data_sink_dead_letter"
+}
+
 // IotSceneRuleDO IoT 场景联动规则 DO
 type IotSceneRuleDO struct {
 	TenantBaseDO
@@ -292,3 +371,98 @@ type IotDevicePropertyDO struct {
 func (IotDevicePropertyDO) TableName() string {
 	return "iot_device_property"
 }
+
+// IotDevicePropertyRollupDO IoT 设备属性聚合 DO
+// 数值型属性按分钟、小时、天分桶聚合，供长时间范围的曲线查询
+type IotDevicePropertyRollupDO struct {
+	TenantBaseDO
+	ID          int64     `gorm:"column:id;primaryKey;autoIncrement;comment:编号" json:"id"`
+	DeviceID    int64     `gorm:"column:device_id;not null;uniqueIndex:uk_rollup_bucket,priority:1;comment:设备编号" json:"deviceId"`
+	Identifier  string    `gorm:"column:identifier;size:64;not null;uniqueIndex:uk_rollup_bucket,priority:2;comment:属性标识符" json:"identifier"`
+	Granularity string    `gorm:"column:granularity;size:16;not null;uniqueIndex:uk_rollup_bucket,priority:3;comment:聚合粒度" json:"granularity"`
+	BucketTime  time.Time `gorm:"column:bucket_time;not null;uniqueIndex:uk_rollup_bucket,priority:4;comment:分桶开始时间" json:"bucketTime"`
+	MinValue    float64   `gorm:"column:min_value;not null;default:0;comment:最小值" json:"minValue"`
+	MaxValue    float64   `gorm:"column:max_value;not null;default:0;comment:最大值" json:"maxValue"`
+	SumValue    float64   `gorm:"column:sum_value;not null;default:0;comment:合计值" json:"sumValue"`
+	Count       int64     `gorm:"column:count;not null;default:0;comment:数据点数" json:"count"`
+	LastValue   float64   `gorm:"column:last_value;not null;default:0;comment:最后值" json:"lastValue"`
+	LastTime    time.Time `gorm:"column:last_time;not null;comment:最后值时间" json:"lastTime"`
+}
+
+// TableName 表名
+func (IotDevicePropertyRollupDO) TableName() string {
+	return "iot_device_property_rollup"
+}
+
+// IotDeviceShadowDO IoT 设备影子 DO
+// desired 为期望状态（管理端、场景联动设置），reported 为设备最近上报的状态，二者之差即为待下发的 delta
+type IotDeviceShadowDO struct {
+	TenantBaseDO
+	ID       int64             `gorm:"column:id;primaryKey;autoIncrement;comment:影子编号" json:"id"`
+	DeviceID int64             `gorm:"column:device_id;not null;uniqueIndex;comment:设备编号" json:"deviceId"`
+	Desired  datatypes.JSONMap `gorm:"column:desired;type:text;comment:期望状态(JSON)" json:"desired"`
+	Reported datatypes.JSONMap `gorm:"column:reported;type:text;comment:上报状态(JSON)" json:"reported"`
+	Version  int64             `gorm:"column:version;not null;default:0;comment:版本号" json:"version"`
+}
+
+// TableName 表名
+func (IotDeviceShadowDO) TableName() string {
+	return "iot_device_shadow"
+}
+
+// IotGeofenceDO IoT 地理围栏 DO
+// 作用范围为产品和/或设备分组（为 0 时不限）；圆形围栏由中心点与半径（米）确定，多边形围栏由顶点列表确定
+type IotGeofenceDO struct {
+	TenantBaseDO
+	ID              int64          `gorm:"column:id;primaryKey;autoIncrement;comment:围栏编号" json:"id"`
+	Name            string         `gorm:"column:name;size:64;not null;comment:围栏名称" json:"name"`
+	Type            int8           `gorm:"column:type;not null;comment:围栏类型" json:"type"`
+	ProductID       int64          `gorm:"column:product_id;not null;default:0;comment:产品编号" json:"productId"`
+	GroupID         int64          `gorm:"column:group_id;not null;default:0;comment:设备分组编号" json:"groupId"`
+	CenterLatitude  float64        `gorm:"column:center_latitude;type:decimal(10,8);comment:中心点纬度" json:"centerLatitude"`
+	CenterLongitude float64        `gorm:"column:center_longitude;type:decimal(11,8);comment:中心点经度" json:"centerLongitude"`
+	Radius          float64        `gorm:"column:radius;comment:半径（米）" json:"radius"`
+	Points          datatypes.JSON `gorm:"column:points;comment:多边形顶点" json:"points"`
+	Status          int8           `gorm:"column:status;not null;default:0;comment:状态" json:"status"`
+	Description     string         `gorm:"column:description;size:255;comment:描述" json:"description"`
+}
+
+// TableName 表名
+func (IotGeofenceDO) TableName() string {
+	return "iot_geofence"
+}
+
+// IotDeviceLocationDO IoT 设备位置轨迹 DO
+// 设备每次位置上报记录一个轨迹点
+type IotDeviceLocationDO struct {
+	TenantBaseDO
+	ID         int64     `gorm:"column:id;primaryKey;autoIncrement;comment:编号" json:"id"`
+	DeviceID   int64     `gorm:"column:device_id;not null;index:idx_device_report_time,priority:1;comment:设备编号" json:"deviceId"`
+	Latitude   float64   `gorm:"column:latitude;type:decimal(10,8);not null;comment:纬度" json:"latitude"`
+	Longitude  float64   `gorm:"column:longitude;type:decimal(11,8);not null;comment:经度" json:"longitude"`
+	ReportTime time.Time `gorm:"column:report_time;not null;index:idx_device_report_time,priority:2;comment:上报时间" json:"reportTime"`
+}
+
+// TableName 表名
+func (IotDeviceLocationDO) TableName() string {
+	return "iot_device_location"
+}
+
+// IotThingModelVersionDO IoT 产品物模型版本 DO
+// 物模型功能（iot_thing_model）为草稿，发布时将草稿的 TSL 快照为新版本；设备上下行按生效版本校验
+type IotThingModelVersionDO struct {
+	BaseDO
+	ID          int64          `gorm:"column:id;primaryKey;autoIncrement;comment:版本编号" json:"id"`
+	ProductID   int64          `gorm:"column:product_id;not null;uniqueIndex:uk_product_version,priority:1;comment:产品编号" json:"productId"`
+	ProductKey  string         `gorm:"column:product_key;size:64;not null;comment:产品标识" json:"productKey"`
+	Version     int            `gorm:"column:version;not null;uniqueIndex:uk_product_version,priority:2;comment:版本号" json:"version"`
+	TSL         datatypes.JSON `gorm:"column:tsl;type:json;comment:物模型 TSL 快照" json:"tsl"`
+	Status      int8           `gorm:"column:status;not null;default:0;comment:版本状态" json:"status"`
+	Remark      string         `gorm:"column:remark;size:255;comment:发布说明" json:"remark"`
+	PublishTime time.Time      `gorm:"column:publish_time;not null;comment:发布时间" json:"publishTime"`
+}
+
+// TableName 表名
+func (IotThingModelVersionDO) TableName() string {
+	return "iot_thing_model_version"
+}
@@ -11,6 +11,8 @@ var (
 	ErrProductStatusNotDelete          = errors.NewBizError(1050001002, "产品状是发布状态，不允许删除")
 	ErrProductStatusNotAllowThingModel = errors.NewBizError(1050001003, "产品状是发布状态，不允许操作物模型")
 	ErrProductDeleteFailHasDevice      = errors.NewBizError(1050001004, "产品下存在设备，不允许删除")
+	ErrProductCodecConfigInvalid       = errors.NewBizError(1050001005, "产品编解码配置不正确")
+	ErrProductCodecDebugFail           = errors.NewBizError(1050001006, "编解码调试失败")
 
 	// ========== 产品分类相关 1-050-002-000 ============
 	ErrProductCategoryNotExists = errors.NewBizError(1050002000, "产品分类不存在")
@@ -21,7 +23,13 @@ var (
 	ErrDataRuleNotExists  = errors.NewBizError(1050004100, "数据规则不存在")
 
 	// ========== 物模型 1-050-005-000 ============
-	ErrThingModelNotExists = errors.NewBizError(1050005000, "产品物模型不存在")
+	ErrThingModelNotExists   = errors.NewBizError(1050005000, "产品物模型不存在")
+	ErrThingModelDataInvalid = errors.NewBizError(1050005001, "设备上报数据不符合物模型")
+	ErrThingModelTSLInvalid  = errors.NewBizError(1050005002, "物模型 TSL 不正确")
+
+	ErrThingModelVersionNotExists = errors.NewBizError(1050005100, "物模型版本不存在")
+	ErrThingModelVersionNoChange  = errors.NewBizError(1050005101, "物模型草稿与生效版本一致，无需发布")
+	ErrThingModelVersionIsActive  = errors.NewBizError(1050005102, "该版本已是生效版本")
 
 	// ========== 告警配置 1-050-006-000 ============
 	ErrAlertConfigNotExists = errors.NewBizError(1050006000, "告警配置不存在")
@@ -31,15 +39,31 @@ var (
 	ErrSceneRuleNotExists = errors.NewBizError(1050007000, "场景规则不存在")
 
 	// ========== 设备 1-050-003-000 ============
-	ErrDeviceNotExists          = errors.NewBizError(1050003000, "设备不存在")
-	ErrDeviceNameExists         = errors.NewBizError(1050003001, "设备名称在同一产品下必须唯一")
-	ErrDeviceHasChildren        = errors.NewBizError(1050003002, "有子设备，不允许删除")
-	ErrDeviceKeyExists          = errors.NewBizError(1050003003, "设备标识已经存在")
-	ErrDeviceGatewayNotExists   = errors.NewBizError(1050003004, "网关设备不存在")
-	ErrDeviceNotGateway         = errors.NewBizError(1050003005, "设备不是网关设备")
-	ErrDeviceImportListIsEmpty  = errors.NewBizError(1050003006, "导入设备数据不能为空！")
-	ErrDeviceSerialNumberExists = errors.NewBizError(1050003008, "设备序列号已存在，序列号必须全局唯一")
-	ErrDeviceSecretInvalid      = errors.NewBizError(1050003009, "设备密钥不正确")
+	ErrDeviceNotExists             = errors.NewBizError(1050003000, "设备不存在")
+	ErrDeviceNameExists            = errors.NewBizError(1050003001, "设备名称在同一产品下必须唯一")
+	ErrDeviceHasChildren           = errors.NewBizError(1050003002, "有子设备，不允许删除")
+	ErrDeviceKeyExists             = errors.NewBizError(1050003003, "设备标识已经存在")
+	ErrDeviceGatewayNotExists      = errors.NewBizError(1050003004, "网关设备不存在")
+	ErrDeviceNotGateway            = errors.NewBizError(1050003005, "设备不是网关设备")
+	ErrDeviceImportListIsEmpty     = errors.NewBizError(1050003006, "导入设备数据不能为空！")
+	ErrDeviceSerialNumberExists    = errors.NewBizError(1050003008, "设备序列号已存在，序列号必须全局唯一")
+	ErrDeviceSecretInvalid         = errors.NewBizError(1050003009, "设备密钥不正确")
+	ErrDeviceNotSubDevice          = errors.NewBizError(1050003010, "设备不是网关子设备")
+	ErrDeviceSubNotInTopo          = errors.NewBizError(1050003011, "子设备未绑定到该网关")
+	ErrDeviceShadowVersionConflict = errors.NewBizError(1050003012, "设备影子版本号不一致，请刷新后重试")
+	ErrDevicePropertyNotNumeric    = errors.NewBizError(1050003013, "属性不是数值类型，不支持聚合查询")
+	ErrDeviceRegisterDisabled      = errors.NewBizError(1050003014, "产品未开启设备动态注册")
+	ErrDeviceRegisterSignInvalid   = errors.NewBizError(1050003015, "设备动态注册签名不正确")
+	ErrDeviceRegisterActivated     = errors.NewBizError(1050003016, "设备已激活，不允许重复动态注册")
+	ErrDeviceRegisterNameInvalid   = errors.NewBizError(1050003017, "设备名称只能包含字母、数字和 _.:@-，长度不超过 64")
+	ErrDeviceAuthTypeInvalid       = errors.NewBizError(1050003018, "设备认证类型不正确")
+	ErrDeviceAuthTypeNotX509       = errors.NewBizError(1050003019, "设备未使用 X.509 证书认证")
+	ErrDeviceCertificateInvalid    = errors.NewBizError(1050003020, "设备证书无效、已过期或已吊销")
+	ErrDeviceOffline               = errors.NewBizError(1050003021, "设备不在线")
+
+	// ========== 设备消息 1-050-008-000 ============
+	ErrDeviceServiceInvokeTimeout = errors.NewBizError(1050008000, "设备服务调用超时，设备未在规定时间内回复")
+	ErrDeviceServiceInvokeFail    = errors.NewBizError(1050008001, "设备服务调用失败")
 
 	// ========== OTA 固件 1-050-013-000 ============
 	ErrOtaFirmwareNotExists = errors.NewBizError(1050013000, "固件不存在")
@@ -47,6 +71,25 @@ var (
 	// ========== OTA 任务 1-050-014-000 ============
 	ErrOtaTaskNotExists             = errors.NewBizError(1050014000, "任务不存在")
 	ErrOtaTaskStatusNotAllowCancel  = errors.NewBizError(1050014001, "任务状态不支持取消")
+	ErrOtaTaskDeviceProductInvalid  = errors.NewBizError(1050014002, "设备不属于固件所属产品")
 	ErrOtaTaskRecordNotExists       = errors.NewBizError(1050014100, "升级记录不存在")
 	ErrOtaTaskRecordUpdateFailNoRec = errors.NewBizError(1050014101, "无进行中的升级记录")
+
+	// ========== 地理围栏 1-050-015-000 ============
+	ErrGeofenceNotExists     = errors.NewBizError(1050015000, "地理围栏不存在")
+	ErrGeofenceCircleInvalid = errors.NewBizError(1050015001, "圆形围栏的中心点坐标不正确或半径不大于 0")
+	ErrGeofencePointsInvalid = errors.NewBizError(1050015002, "多边形围栏至少需要 3 个坐标正确的顶点")
+	ErrGeofenceTypeInvalid   = errors.NewBizError(1050015003, "地理围栏类型不正确")
+
+	// ========== 设备分组 1-050-016-000 ============
+	ErrDeviceGroupNotExists           = errors.NewBizError(1050016000, "设备分组不存在")
+	ErrDeviceGroupBatchMethodInvalid  = errors.NewBizError(1050016002, "分组批量下发仅支持属性设置与服务调用")
+	ErrDeviceGroupBatchIdentifierNull = errors.NewBizError(1050016003, "服务调用的服务标识符不能为空")
+	ErrDeviceGroupBatchParamsNull     = errors.NewBizError(1050016004, "属性设置的属性值不能为空")
+
+	// ========== 网关 1-051-001-000 ============
+	ErrDeviceAuthFail         = errors.NewBizError(1051001000, "设备鉴权失败")
+	ErrDeviceTokenInvalid     = errors.NewBizError(1051001001, "设备 token 无效或已过期")
+	ErrDeviceMessageDecodeErr = errors.NewBizError(1051001002, "设备消息解码失败")
+	ErrDeviceGatewayOffline   = errors.NewBizError(1051001003, "网关设备不在线")
 )
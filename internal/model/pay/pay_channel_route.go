@@ -0,0 +1,38 @@
+package pay
+
+import (
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+)
+
+// PayChannelRouteRule 支付渠道路由规则
+// 用户选择渠道编码提交支付时，按优先级匹配第一条满足金额、时段、终端、租户条件的规则，
+// 在规则的候选渠道（同一渠道编码的多个商户号）间按权重轮询；没有匹配的规则时使用该编码的全部启用渠道
+// TableName: pay_channel_route_rule
+type PayChannelRouteRule struct {
+	ID          int64                    `gorm:"column:id;primaryKey;autoIncrement;comment:规则编号" json:"id"`
+	AppID       int64                    `gorm:"column:app_id;comment:应用编号" json:"appId"`
+	Name        string                   `gorm:"column:name;comment:规则名称" json:"name"`
+	ChannelCode string                   `gorm:"column:channel_code;comment:渠道编码" json:"channelCode"`
+	Sort        int                      `gorm:"column:sort;comment:优先级" json:"sort"`                             // 值越小越优先
+	MinPrice    int                      `gorm:"column:min_price;comment:最小金额" json:"minPrice"`                   // 单位：分，0 表示不限
+	MaxPrice    int                      `gorm:"column:max_price;comment:最大金额" json:"maxPrice"`                   // 单位：分，0 表示不限
+	StartTime   string                   `gorm:"column:start_time;comment:生效开始时间" json:"startTime"`               // 格式 HH:mm，为空表示全天
+	EndTime     string                   `gorm:"column:end_time;comment:生效结束时间" json:"endTime"`                   // 格式 HH:mm，早于开始时间表示跨天
+	Terminals   []int                    `gorm:"column:terminals;serializer:json;comment:终端" json:"terminals"`    // 枚举 consts.Terminal，为空表示不限
+	TenantIDs   []int64                  `gorm:"column:tenant_ids;serializer:json;comment:租户编号" json:"tenantIds"` // 为空表示不限
+	Targets     []*PayChannelRouteTarget `gorm:"column:targets;serializer:json;comment:候选渠道" json:"targets"`
+	Status      int                      `gorm:"column:status;comment:状态" json:"status"` // 枚举 consts.CommonStatus
+	Remark      string                   `gorm:"column:remark;comment:备注" json:"remark"`
+
+	model.TenantBaseDO
+}
+
+func (PayChannelRouteRule) TableName() string {
+	return "pay_channel_route_rule"
+}
+
+// PayChannelRouteTarget 路由规则中的候选渠道及权重
+type PayChannelRouteTarget struct {
+	ChannelID int64 `json:"channelId"` // 支付渠道编号
+	Weight    int   `json:"weight"`    // 轮询权重
+}
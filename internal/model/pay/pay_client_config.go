@@ -44,6 +44,12 @@ type PayClientConfig struct {
 	// 可选加密
 	EncryptType string `json:"encryptType,omitempty"` // 接口内容加密方式 "AES"
 	EncryptKey  string `json:"encryptKey,omitempty"`  // 接口内容加密私钥
+
+	// ========== 模拟支付配置 (渠道编码为 mock 时) ==========
+	MockOrderResult    string `json:"mockOrderResult,omitempty"`    // 模拟支付结果: success / failure / waiting，默认 success
+	MockRefundResult   string `json:"mockRefundResult,omitempty"`   // 模拟退款结果: success / failure / waiting，默认 success
+	MockTransferResult string `json:"mockTransferResult,omitempty"` // 模拟转账结果: success / failure / waiting，默认 success
+	MockNotifyDelay    int    `json:"mockNotifyDelay,omitempty"`    // 模拟异步通知的延迟，单位：毫秒
 }
 
 // ========== 常量定义 ==========
@@ -28,6 +28,8 @@ type PayOrder struct {
 	RefundPrice     int        `gorm:"column:refund_price"` // Unit: fen
 	ChannelUserID   string     `gorm:"column:channel_user_id"`
 	ChannelOrderNo  string     `gorm:"column:channel_order_no"`
+	ProfitSharing   bool       `gorm:"column:profit_sharing"` // 是否分账：提交时应用存在启用的分账规则
+	WalletPrice     int        `gorm:"column:wallet_price"`   // 组合支付时钱包支付的金额，渠道实付金额为 Price - WalletPrice
 	model.TenantBaseDO
 }
 
@@ -17,6 +17,9 @@ type PayOrderExtension struct {
 	ChannelErrorCode  string `gorm:"column:channel_error_code"`
 	ChannelErrorMsg   string `gorm:"column:channel_error_msg"`
 	ChannelNotifyData string `gorm:"column:channel_notify_data"`
+	WalletID          int64  `gorm:"column:wallet_id"`     // 组合支付的钱包编号
+	WalletPrice       int    `gorm:"column:wallet_price"`  // 组合支付的钱包金额，其余金额由渠道支付
+	WalletStatus      int    `gorm:"column:wallet_status"` // 组合支付的钱包状态，参见 consts.PayOrderWalletStatus
 	model.TenantBaseDO
 }
 
@@ -0,0 +1,132 @@
+package pay
+
+import (
+	"time"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+)
+
+// PayProfitSharingReceiver 分账接收方
+// 渠道接收方创建时同步添加到应用下对应的支付渠道；钱包接收方的账号为钱包编号
+// TableName: pay_profit_sharing_receiver
+type PayProfitSharingReceiver struct {
+	ID           int64  `gorm:"column:id;primaryKey;autoIncrement;comment:接收方编号" json:"id"`
+	AppID        int64  `gorm:"column:app_id;comment:应用编号" json:"appId"`
+	Name         string `gorm:"column:name;comment:接收方名称" json:"name"`
+	Type         int    `gorm:"column:type;comment:接收方类型" json:"type"` // 枚举 consts.PayProfitSharingReceiverType
+	Account      string `gorm:"column:account;comment:接收方账号" json:"account"`
+	RealName     string `gorm:"column:real_name;comment:接收方真实姓名或商户全称" json:"realName"`
+	RelationType string `gorm:"column:relation_type;comment:与分账方的关系类型" json:"relationType"` // 微信渠道必填，如 PARTNER、SUPPLIER
+	Status       int    `gorm:"column:status;comment:状态" json:"status"`                     // 枚举 consts.CommonStatus
+	Remark       string `gorm:"column:remark;comment:备注" json:"remark"`
+
+	model.TenantBaseDO
+}
+
+func (PayProfitSharingReceiver) TableName() string {
+	return "pay_profit_sharing_receiver"
+}
+
+// PayProfitSharingRule 分账规则
+// 每个支付应用最多启用一条规则，启用规则后提交的支付订单在支付成功后按规则分账
+// TableName: pay_profit_sharing_rule
+type PayProfitSharingRule struct {
+	ID        int64                           `gorm:"column:id;primaryKey;autoIncrement;comment:规则编号" json:"id"`
+	AppID     int64                           `gorm:"column:app_id;comment:应用编号" json:"appId"`
+	Name      string                          `gorm:"column:name;comment:规则名称" json:"name"`
+	Receivers []*PayProfitSharingRuleReceiver `gorm:"column:receivers;serializer:json;comment:分账接收方" json:"receivers"`
+	Status    int                             `gorm:"column:status;comment:状态" json:"status"` // 枚举 consts.CommonStatus
+	Remark    string                          `gorm:"column:remark;comment:备注" json:"remark"`
+
+	model.TenantBaseDO
+}
+
+func (PayProfitSharingRule) TableName() string {
+	return "pay_profit_sharing_rule"
+}
+
+// PayProfitSharingRuleReceiver 分账规则中的接收方及分账比例
+type PayProfitSharingRuleReceiver struct {
+	ReceiverID int64 `json:"receiverId"` // 分账接收方编号
+	Ratio      int   `json:"ratio"`      // 分账比例，单位：万分之一
+}
+
+// PayProfitSharingOrder 分账单
+// 每个支付订单对应一条分账单，以订单实付金额扣除已退款金额为分账基数
+// TableName: pay_profit_sharing_order
+type PayProfitSharingOrder struct {
+	ID               int64      `gorm:"column:id;primaryKey;autoIncrement;comment:分账单编号" json:"id"`
+	No               string     `gorm:"column:no;comment:分账单号" json:"no"`
+	AppID            int64      `gorm:"column:app_id;comment:应用编号" json:"appId"`
+	RuleID           int64      `gorm:"column:rule_id;comment:分账规则编号" json:"ruleId"`
+	OrderID          int64      `gorm:"column:order_id;comment:支付订单编号" json:"orderId"`
+	ChannelID        int64      `gorm:"column:channel_id;comment:渠道编号" json:"channelId"`
+	ChannelCode      string     `gorm:"column:channel_code;comment:渠道编码" json:"channelCode"`
+	OutTradeNo       string     `gorm:"column:out_trade_no;comment:外部订单号" json:"outTradeNo"`
+	ChannelOrderNo   string     `gorm:"column:channel_order_no;comment:渠道订单号" json:"channelOrderNo"`
+	Price            int        `gorm:"column:price;comment:分账基数" json:"price"`                  // 单位：分
+	SharingPrice     int        `gorm:"column:sharing_price;comment:分账金额" json:"sharingPrice"`   // 单位：分，各明细金额之和
+	RefundPrice      int        `gorm:"column:refund_price;comment:已处理的退款金额" json:"refundPrice"` // 单位：分，已据此生成分账回退单的订单退款金额
+	Status           int        `gorm:"column:status;comment:分账状态" json:"status"`                // 枚举 consts.PayProfitSharingStatus
+	ChannelSharingNo string     `gorm:"column:channel_sharing_no;comment:渠道分账单号" json:"channelSharingNo"`
+	SuccessTime      *time.Time `gorm:"column:success_time;comment:分账完成时间" json:"successTime"`
+	ErrorMsg         string     `gorm:"column:error_msg;comment:分账失败原因" json:"errorMsg"`
+
+	model.TenantBaseDO
+}
+
+func (PayProfitSharingOrder) TableName() string {
+	return "pay_profit_sharing_order"
+}
+
+// PayProfitSharingOrderItem 分账明细
+// 失败的明细重试时使用新的请求单号，分账回退时使用明细所在的请求单号
+// TableName: pay_profit_sharing_order_item
+type PayProfitSharingOrderItem struct {
+	ID              int64      `gorm:"column:id;primaryKey;autoIncrement;comment:明细编号" json:"id"`
+	SharingOrderID  int64      `gorm:"column:sharing_order_id;comment:分账单编号" json:"sharingOrderId"`
+	OrderID         int64      `gorm:"column:order_id;comment:支付订单编号" json:"orderId"`
+	OutSharingNo    string     `gorm:"column:out_sharing_no;comment:分账请求单号" json:"outSharingNo"`
+	ReceiverID      int64      `gorm:"column:receiver_id;comment:接收方编号" json:"receiverId"`
+	ReceiverType    int        `gorm:"column:receiver_type;comment:接收方类型" json:"receiverType"`
+	Account         string     `gorm:"column:account;comment:接收方账号" json:"account"`
+	Name            string     `gorm:"column:name;comment:接收方名称" json:"name"`
+	Ratio           int        `gorm:"column:ratio;comment:分账比例" json:"ratio"`               // 单位：万分之一
+	Price           int        `gorm:"column:price;comment:分账金额" json:"price"`               // 单位：分
+	ReturnPrice     int        `gorm:"column:return_price;comment:已回退金额" json:"returnPrice"` // 单位：分，不含失败的回退单
+	Status          int        `gorm:"column:status;comment:分账状态" json:"status"`             // 枚举 consts.PayProfitSharingStatus
+	ChannelDetailNo string     `gorm:"column:channel_detail_no;comment:渠道分账明细单号" json:"channelDetailNo"`
+	FailReason      string     `gorm:"column:fail_reason;comment:分账失败原因" json:"failReason"`
+	SuccessTime     *time.Time `gorm:"column:success_time;comment:分账成功时间" json:"successTime"`
+
+	model.TenantBaseDO
+}
+
+func (PayProfitSharingOrderItem) TableName() string {
+	return "pay_profit_sharing_order_item"
+}
+
+// PayProfitSharingReturn 分账回退单
+// 支付订单退款后，按退款金额占分账基数的比例从接收方回退已分账的金额
+// TableName: pay_profit_sharing_return
+type PayProfitSharingReturn struct {
+	ID              int64      `gorm:"column:id;primaryKey;autoIncrement;comment:回退单编号" json:"id"`
+	No              string     `gorm:"column:no;comment:回退单号" json:"no"`
+	AppID           int64      `gorm:"column:app_id;comment:应用编号" json:"appId"`
+	SharingOrderID  int64      `gorm:"column:sharing_order_id;comment:分账单编号" json:"sharingOrderId"`
+	ItemID          int64      `gorm:"column:item_id;comment:分账明细编号" json:"itemId"`
+	OrderID         int64      `gorm:"column:order_id;comment:支付订单编号" json:"orderId"`
+	ReceiverType    int        `gorm:"column:receiver_type;comment:接收方类型" json:"receiverType"`
+	Account         string     `gorm:"column:account;comment:接收方账号" json:"account"`
+	Price           int        `gorm:"column:price;comment:回退金额" json:"price"`   // 单位：分
+	Status          int        `gorm:"column:status;comment:回退状态" json:"status"` // 枚举 consts.PayProfitSharingStatus
+	ChannelReturnNo string     `gorm:"column:channel_return_no;comment:渠道回退单号" json:"channelReturnNo"`
+	FailReason      string     `gorm:"column:fail_reason;comment:回退失败原因" json:"failReason"`
+	SuccessTime     *time.Time `gorm:"column:success_time;comment:回退成功时间" json:"successTime"`
+
+	model.TenantBaseDO
+}
+
+func (PayProfitSharingReturn) TableName() string {
+	return "pay_profit_sharing_return"
+}
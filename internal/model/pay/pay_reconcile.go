@@ -0,0 +1,64 @@
+package pay
+
+import (
+	"time"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+)
+
+// PayReconcileBill 渠道对账单
+// 每个支付渠道（商户号）、账单日期对应一份对账单，重新对账时覆盖
+// TableName: pay_reconcile_bill
+type PayReconcileBill struct {
+	ID               int64     `gorm:"column:id;primaryKey;autoIncrement;comment:对账单编号" json:"id"`
+	AppID            int64     `gorm:"column:app_id;comment:应用编号" json:"appId"`
+	ChannelID        int64     `gorm:"column:channel_id;comment:渠道编号" json:"channelId"`
+	ChannelType      string    `gorm:"column:channel_type;comment:对账渠道类型" json:"channelType"` // 枚举 consts.PayReconcileChannel
+	BillDate         time.Time `gorm:"column:bill_date;type:date;comment:账单日期" json:"billDate"`
+	FileName         string    `gorm:"column:file_name;comment:账单文件名" json:"fileName"`
+	Source           int       `gorm:"column:source;comment:账单来源" json:"source"` // 枚举 consts.PayReconcileBillSource
+	Status           int       `gorm:"column:status;comment:对账状态" json:"status"` // 枚举 consts.PayReconcileBillStatus
+	ChannelCount     int       `gorm:"column:channel_count;comment:渠道交易笔数" json:"channelCount"`
+	ChannelAmount    int64     `gorm:"column:channel_amount;comment:渠道交易金额" json:"channelAmount"` // 单位：分，退款为负
+	LocalCount       int       `gorm:"column:local_count;comment:本地交易笔数" json:"localCount"`
+	LocalAmount      int64     `gorm:"column:local_amount;comment:本地交易金额" json:"localAmount"` // 单位：分，退款为负
+	MatchedCount     int       `gorm:"column:matched_count;comment:核对一致笔数" json:"matchedCount"`
+	DiscrepancyCount int       `gorm:"column:discrepancy_count;comment:差异笔数" json:"discrepancyCount"`
+	ErrorMsg         string    `gorm:"column:error_msg;comment:对账失败原因" json:"errorMsg"`
+
+	model.TenantBaseDO
+}
+
+func (PayReconcileBill) TableName() string {
+	return "pay_reconcile_bill"
+}
+
+// PayReconcileDiscrepancy 对账差异
+// TableName: pay_reconcile_discrepancy
+type PayReconcileDiscrepancy struct {
+	ID              int64      `gorm:"column:id;primaryKey;autoIncrement;comment:差异编号" json:"id"`
+	BillID          int64      `gorm:"column:bill_id;comment:对账单编号" json:"billId"`
+	AppID           int64      `gorm:"column:app_id;comment:应用编号" json:"appId"`
+	ChannelType     string     `gorm:"column:channel_type;comment:对账渠道类型" json:"channelType"`
+	BillDate        time.Time  `gorm:"column:bill_date;type:date;comment:账单日期" json:"billDate"`
+	BizType         int        `gorm:"column:biz_type;comment:业务类型" json:"bizType"` // 枚举 consts.PayReconcileBizType
+	Type            int        `gorm:"column:type;comment:差异类型" json:"type"`        // 枚举 consts.PayReconcileDiscrepancyType
+	LocalID         int64      `gorm:"column:local_id;comment:本地支付单或退款单编号" json:"localId"`
+	ChannelID       int64      `gorm:"column:channel_id;comment:本地渠道编号" json:"channelId"`
+	OutTradeNo      string     `gorm:"column:out_trade_no;comment:外部订单号" json:"outTradeNo"`
+	ChannelOrderNo  string     `gorm:"column:channel_order_no;comment:渠道订单号" json:"channelOrderNo"`
+	OutRefundNo     string     `gorm:"column:out_refund_no;comment:外部退款号" json:"outRefundNo"`
+	ChannelRefundNo string     `gorm:"column:channel_refund_no;comment:渠道退款单号" json:"channelRefundNo"`
+	LocalAmount     *int       `gorm:"column:local_amount;comment:本地金额" json:"localAmount"`     // 单位：分，本地缺失时为空
+	ChannelAmount   *int       `gorm:"column:channel_amount;comment:渠道金额" json:"channelAmount"` // 单位：分，渠道缺失时为空
+	LocalStatus     *int       `gorm:"column:local_status;comment:本地状态" json:"localStatus"`
+	Status          int        `gorm:"column:status;comment:处理状态" json:"status"` // 枚举 consts.PayReconcileDiscrepancyStatus
+	ResolveRemark   string     `gorm:"column:resolve_remark;comment:处理说明" json:"resolveRemark"`
+	ResolveTime     *time.Time `gorm:"column:resolve_time;comment:处理时间" json:"resolveTime"`
+
+	model.TenantBaseDO
+}
+
+func (PayReconcileDiscrepancy) TableName() string {
+	return "pay_reconcile_discrepancy"
+}
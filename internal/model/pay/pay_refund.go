@@ -24,6 +24,7 @@ type PayRefund struct {
 	Status            int        `gorm:"column:status;comment:退款状态" json:"status"`
 	PayPrice          int        `gorm:"column:pay_price;comment:支付金额" json:"payPrice"`
 	RefundPrice       int        `gorm:"column:refund_price;comment:退款金额" json:"refundPrice"`
+	WalletRefundPrice int        `gorm:"column:wallet_refund_price;comment:退回钱包的金额" json:"walletRefundPrice"`
 	Reason            string     `gorm:"column:reason;comment:退款原因" json:"reason"`
 	UserIP            string     `gorm:"column:user_ip;comment:用户 IP" json:"userIp"`
 	ChannelOrderNo    string     `gorm:"column:channel_order_no;comment:渠道订单号" json:"channelOrderNo"`
@@ -0,0 +1,19 @@
+package websocket
+
+import "encoding/json"
+
+// MessageListener WebSocket 消息监听器，处理客户端发送的指定类型消息
+// Java: WebSocketMessageListener
+type MessageListener func(session *Session, message *Message)
+
+// SessionCloseListener 会话关闭监听器，会话从 Manager 移除后调用
+type SessionCloseListener func(session *Session)
+
+// DecodeContent 将消息内容解析到 v
+func (m *Message) DecodeContent(v any) error {
+	data, err := json.Marshal(m.Content)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -33,6 +33,10 @@ type Manager struct {
 	sessions map[string]*Session  // sessionID -> Session
 	userMap  map[int64][]*Session // userID -> Sessions (一个用户可能有多个连接)
 	mu       sync.RWMutex
+
+	listeners      map[string]MessageListener // messageType -> Listener
+	closeListeners []SessionCloseListener
+	listenerMu     sync.RWMutex
 }
 
 // NewManager 创建新的会话管理器
@@ -40,9 +44,32 @@ func NewManager() *Manager {
 	return &Manager{
 		sessions: make(map[string]*Session),
 		userMap:  make(map[int64][]*Session),
+
+		listeners: make(map[string]MessageListener),
 	}
 }
 
+// RegisterListener 注册指定类型消息的监听器
+func (m *Manager) RegisterListener(messageType string, listener MessageListener) {
+	m.listenerMu.Lock()
+	defer m.listenerMu.Unlock()
+	m.listeners[messageType] = listener
+}
+
+// GetListener 获取指定类型消息的监听器，未注册时返回 nil
+func (m *Manager) GetListener(messageType string) MessageListener {
+	m.listenerMu.RLock()
+	defer m.listenerMu.RUnlock()
+	return m.listeners[messageType]
+}
+
+// RegisterCloseListener 注册会话关闭监听器
+func (m *Manager) RegisterCloseListener(listener SessionCloseListener) {
+	m.listenerMu.Lock()
+	defer m.listenerMu.Unlock()
+	m.closeListeners = append(m.closeListeners, listener)
+}
+
 // Add 添加会话
 func (m *Manager) Add(session *Session) {
 	m.mu.Lock()
@@ -51,13 +78,27 @@ func (m *Manager) Add(session *Session) {
 	m.userMap[session.UserID] = append(m.userMap[session.UserID], session)
 }
 
-// Remove 移除会话
+// Remove 移除会话，并通知会话关闭监听器
 func (m *Manager) Remove(sessionID string) {
+	session := m.remove(sessionID)
+	if session == nil {
+		return
+	}
+	m.listenerMu.RLock()
+	listeners := m.closeListeners
+	m.listenerMu.RUnlock()
+	for _, listener := range listeners {
+		listener(session)
+	}
+}
+
+// remove 从会话表中移除会话，返回被移除的会话
+func (m *Manager) remove(sessionID string) *Session {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	session, ok := m.sessions[sessionID]
 	if !ok {
-		return
+		return nil
 	}
 	delete(m.sessions, sessionID)
 
@@ -72,6 +113,7 @@ func (m *Manager) Remove(sessionID string) {
 	if len(m.userMap[session.UserID]) == 0 {
 		delete(m.userMap, session.UserID)
 	}
+	return session
 }
 
 // GetByUser 获取指定用户的所有会话
@@ -48,3 +48,8 @@ func (r *DataRuleRepositoryImpl) GetPage(ctx context.Context, req *iot.IotDataRu
 	list, total, err := db.Order(dr.ID.Desc()).FindByPage((req.PageNo-1)*req.PageSize, req.PageSize)
 	return &pagination.PageResult[*model.IotDataRuleDO]{List: list, Total: total}, err
 }
+
+func (r *DataRuleRepositoryImpl) GetListByStatus(ctx context.Context, status int8) ([]*model.IotDataRuleDO, error) {
+	dr := r.q.IotDataRuleDO
+	return dr.WithContext(ctx).Where(dr.Status.Eq(status)).Order(dr.ID.Desc()).Find()
+}
@@ -0,0 +1,39 @@
+package iot
+
+import (
+	"context"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/internal/repo/query"
+	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
+)
+
+type DataSinkDeadLetterRepositoryImpl struct {
+	q *query.Query
+}
+
+func NewDataSinkDeadLetterRepository(q *query.Query) iotsvc.DataSinkDeadLetterRepository {
+	return &DataSinkDeadLetterRepositoryImpl{q: q}
+}
+
+func (r *DataSinkDeadLetterRepositoryImpl) Create(ctx context.Context, deadLetter *model.IotDataSinkDeadLetterDO) error {
+	return r.q.IotDataSinkDeadLetterDO.WithContext(ctx).Create(deadLetter)
+}
+
+func (r *DataSinkDeadLetterRepositoryImpl) GetPage(ctx context.Context, req *iot.IotDataSinkDeadLetterPageReqVO) (*pagination.PageResult[*model.IotDataSinkDeadLetterDO], error) {
+	dl := r.q.IotDataSinkDeadLetterDO
+	db := dl.WithContext(ctx)
+	if req.RuleID != 0 {
+		db = db.Where(dl.RuleID.Eq(req.RuleID))
+	}
+	if req.SinkID != 0 {
+		db = db.Where(dl.SinkID.Eq(req.SinkID))
+	}
+	if req.DeviceID != 0 {
+		db = db.Where(dl.DeviceID.Eq(req.DeviceID))
+	}
+	list, total, err := db.Order(dl.ID.Desc()).FindByPage((req.PageNo-1)*req.PageSize, req.PageSize)
+	return &pagination.PageResult[*model.IotDataSinkDeadLetterDO]{List: list, Total: total}, err
+}
@@ -0,0 +1,70 @@
+package iot
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/internal/repo/query"
+	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+	"gorm.io/gorm"
+)
+
+type DeviceCertificateRepositoryImpl struct {
+	q *query.Query
+}
+
+func NewDeviceCertificateRepository(q *query.Query) iotsvc.DeviceCertificateRepository {
+	return &DeviceCertificateRepositoryImpl{q: q}
+}
+
+func (r *DeviceCertificateRepositoryImpl) Create(ctx context.Context, certificate *model.IotDeviceCertificateDO) error {
+	return r.q.IotDeviceCertificateDO.WithContext(ctx).Create(certificate)
+}
+
+// GetValidByDeviceID 获取设备最近签发的有效证书，不存在时返回 nil
+func (r *DeviceCertificateRepositoryImpl) GetValidByDeviceID(ctx context.Context, deviceID int64) (*model.IotDeviceCertificateDO, error) {
+	m := r.q.IotDeviceCertificateDO
+	certificate, err := m.WithContext(ctx).
+		Where(m.DeviceID.Eq(deviceID), m.Status.Eq(consts.IotDeviceCertificateStatusValid)).
+		Order(m.ID.Desc()).First()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return certificate, err
+}
+
+// GetByFingerprint 根据证书指纹获取证书，不存在时返回 nil
+func (r *DeviceCertificateRepositoryImpl) GetByFingerprint(ctx context.Context, fingerprint string) (*model.IotDeviceCertificateDO, error) {
+	m := r.q.IotDeviceCertificateDO
+	certificate, err := m.WithContext(ctx).Where(m.Fingerprint.Eq(fingerprint)).First()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return certificate, err
+}
+
+func (r *DeviceCertificateRepositoryImpl) RevokeByDeviceIDs(ctx context.Context, deviceIDs []int64, reason string) error {
+	if len(deviceIDs) == 0 {
+		return nil
+	}
+	m := r.q.IotDeviceCertificateDO
+	_, err := m.WithContext(ctx).
+		Where(m.DeviceID.In(deviceIDs...), m.Status.Eq(consts.IotDeviceCertificateStatusValid)).
+		Updates(map[string]any{
+			"status":        consts.IotDeviceCertificateStatusRevoked,
+			"revoke_time":   time.Now(),
+			"revoke_reason": reason,
+		})
+	return err
+}
+
+// ListRevoked 获取已吊销且未过期的证书，过期证书无需出现在 CRL 中
+func (r *DeviceCertificateRepositoryImpl) ListRevoked(ctx context.Context) ([]*model.IotDeviceCertificateDO, error) {
+	m := r.q.IotDeviceCertificateDO
+	return m.WithContext(ctx).
+		Where(m.Status.Eq(consts.IotDeviceCertificateStatusRevoked), m.NotAfter.Gt(time.Now())).
+		Order(m.ID.Asc()).Find()
+}
@@ -0,0 +1,46 @@
+package iot
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/internal/repo/query"
+	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+	"gorm.io/gorm"
+)
+
+type DeviceLocationRepositoryImpl struct {
+	q *query.Query
+}
+
+func NewDeviceLocationRepository(q *query.Query) iotsvc.DeviceLocationRepository {
+	return &DeviceLocationRepositoryImpl{q: q}
+}
+
+func (r *DeviceLocationRepositoryImpl) Create(ctx context.Context, location *model.IotDeviceLocationDO) error {
+	return r.q.IotDeviceLocationDO.WithContext(ctx).Create(location)
+}
+
+// GetLatestByDeviceID 获取设备最近上报的轨迹点，不存在时返回 nil
+func (r *DeviceLocationRepositoryImpl) GetLatestByDeviceID(ctx context.Context, deviceID int64) (*model.IotDeviceLocationDO, error) {
+	l := r.q.IotDeviceLocationDO
+	location, err := l.WithContext(ctx).Where(l.DeviceID.Eq(deviceID)).Order(l.ReportTime.Desc(), l.ID.Desc()).First()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return location, err
+}
+
+func (r *DeviceLocationRepositoryImpl) GetListByDeviceID(ctx context.Context, deviceID int64, startTime, endTime *time.Time) ([]*model.IotDeviceLocationDO, error) {
+	l := r.q.IotDeviceLocationDO
+	db := l.WithContext(ctx).Where(l.DeviceID.Eq(deviceID))
+	if startTime != nil {
+		db = db.Where(l.ReportTime.Gte(*startTime))
+	}
+	if endTime != nil {
+		db = db.Where(l.ReportTime.Lte(*endTime))
+	}
+	return db.Order(l.ReportTime.Asc(), l.ID.Asc()).Find()
+}
@@ -2,6 +2,7 @@ package iot
 
 import (
 	"context"
+	"time"
 
 	"github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
 	"github.com/wxlbd/ruoyi-mall-go/internal/model"
@@ -42,3 +43,25 @@ func (r *DevicePropertyRepositoryImpl) GetLatestProperties(ctx context.Context,
 func (r *DevicePropertyRepositoryImpl) SaveProperty(ctx context.Context, property *model.IotDevicePropertyDO) error {
 	return r.q.IotDevicePropertyDO.WithContext(ctx).Create(property)
 }
+
+func (r *DevicePropertyRepositoryImpl) GetListByTimeRange(ctx context.Context, startTime, endTime time.Time, lastID int64, limit int) ([]*model.IotDevicePropertyDO, error) {
+	m := r.q.IotDevicePropertyDO
+	return m.WithContext(ctx).
+		Where(m.ID.Gt(lastID), m.UpdateTime.Gte(startTime), m.UpdateTime.Lt(endTime)).
+		Order(m.ID).Limit(limit).Find()
+}
+
+func (r *DevicePropertyRepositoryImpl) DeleteByDeviceIDsAndTimeBefore(ctx context.Context, deviceIDs []int64, before time.Time, limit int) (int64, error) {
+	m := r.q.IotDevicePropertyDO
+	var ids []int64
+	if err := m.WithContext(ctx).Where(m.DeviceID.In(deviceIDs...), m.UpdateTime.Lt(before)).
+		Limit(limit).Pluck(m.ID, &ids); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	// 过期数据直接物理删除，释放存储空间
+	info, err := m.WithContext(ctx).Unscoped().Where(m.ID.In(ids...)).Delete()
+	return info.RowsAffected, err
+}
@@ -0,0 +1,64 @@
+package iot
+
+import (
+	"context"
+	"time"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/internal/repo/query"
+	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+	"gorm.io/gorm/clause"
+)
+
+type DevicePropertyRollupRepositoryImpl struct {
+	q *query.Query
+}
+
+func NewDevicePropertyRollupRepository(q *query.Query) iotsvc.DevicePropertyRollupRepository {
+	return &DevicePropertyRollupRepositoryImpl{q: q}
+}
+
+func (r *DevicePropertyRollupRepositoryImpl) Upsert(ctx context.Context, rollups []*model.IotDevicePropertyRollupDO) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+	return r.q.IotDevicePropertyRollupDO.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "device_id"}, {Name: "identifier"}, {Name: "granularity"}, {Name: "bucket_time"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"min_value", "max_value", "sum_value", "count", "last_value", "last_time", "update_time",
+		}),
+	}).CreateInBatches(rollups, 500)
+}
+
+func (r *DevicePropertyRollupRepositoryImpl) GetList(ctx context.Context, deviceID int64, identifier, granularity string, startTime, endTime *time.Time) ([]*model.IotDevicePropertyRollupDO, error) {
+	m := r.q.IotDevicePropertyRollupDO
+	db := m.WithContext(ctx).Where(m.DeviceID.Eq(deviceID), m.Identifier.Eq(identifier), m.Granularity.Eq(granularity))
+	if startTime != nil {
+		db = db.Where(m.BucketTime.Gte(*startTime))
+	}
+	if endTime != nil {
+		db = db.Where(m.BucketTime.Lte(*endTime))
+	}
+	return db.Order(m.BucketTime).Find()
+}
+
+func (r *DevicePropertyRollupRepositoryImpl) GetListByGranularityAndTimeRange(ctx context.Context, granularity string, startTime, endTime time.Time) ([]*model.IotDevicePropertyRollupDO, error) {
+	m := r.q.IotDevicePropertyRollupDO
+	return m.WithContext(ctx).
+		Where(m.Granularity.Eq(granularity), m.BucketTime.Gte(startTime), m.BucketTime.Lt(endTime)).
+		Find()
+}
+
+func (r *DevicePropertyRollupRepositoryImpl) DeleteByGranularityAndTimeBefore(ctx context.Context, granularity string, before time.Time, limit int) (int64, error) {
+	m := r.q.IotDevicePropertyRollupDO
+	var ids []int64
+	if err := m.WithContext(ctx).Where(m.Granularity.Eq(granularity), m.BucketTime.Lt(before)).
+		Limit(limit).Pluck(m.ID, &ids); err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	info, err := m.WithContext(ctx).Unscoped().Where(m.ID.In(ids...)).Delete()
+	return info.RowsAffected, err
+}
@@ -0,0 +1,42 @@
+package iot
+
+import (
+	"context"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/internal/repo/query"
+	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
+)
+
+type DeviceRegisterLogRepositoryImpl struct {
+	q *query.Query
+}
+
+func NewDeviceRegisterLogRepository(q *query.Query) iotsvc.DeviceRegisterLogRepository {
+	return &DeviceRegisterLogRepositoryImpl{q: q}
+}
+
+func (r *DeviceRegisterLogRepositoryImpl) Create(ctx context.Context, registerLog *model.IotDeviceRegisterLogDO) error {
+	return r.q.IotDeviceRegisterLogDO.WithContext(ctx).Create(registerLog)
+}
+
+func (r *DeviceRegisterLogRepositoryImpl) GetPage(ctx context.Context, req *iot.IotDeviceRegisterLogPageReqVO) (*pagination.PageResult[*model.IotDeviceRegisterLogDO], error) {
+	l := r.q.IotDeviceRegisterLogDO
+	db := l.WithContext(ctx)
+	if req.ProductKey != "" {
+		db = db.Where(l.ProductKey.Eq(req.ProductKey))
+	}
+	if req.DeviceName != "" {
+		db = db.Where(l.DeviceName.Like("%" + req.DeviceName + "%"))
+	}
+	if req.Protocol != "" {
+		db = db.Where(l.Protocol.Eq(req.Protocol))
+	}
+	if req.Success != nil {
+		db = db.Where(l.Success.Is(*req.Success))
+	}
+	list, total, err := db.Order(l.ID.Desc()).FindByPage((req.PageNo-1)*req.PageSize, req.PageSize)
+	return &pagination.PageResult[*model.IotDeviceRegisterLogDO]{List: list, Total: total}, err
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
 	"github.com/wxlbd/ruoyi-mall-go/internal/model"
 	"github.com/wxlbd/ruoyi-mall-go/internal/repo/query"
 	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
+	"gorm.io/datatypes"
+	"gorm.io/gen"
 )
 
 type DeviceRepositoryImpl struct {
@@ -65,10 +68,47 @@ func (r *DeviceRepositoryImpl) GetPage(ctx context.Context, req *iot.IotDevicePa
 	if req.Status != 0 {
 		db = db.Where(d.State.Eq(req.Status))
 	}
+	if req.GroupID != 0 {
+		db = db.Where(groupIDContains(req.GroupID)...)
+	}
 	list, total, err := db.Order(d.ID.Desc()).FindByPage((req.PageNo-1)*req.PageSize, req.PageSize)
 	return &pagination.PageResult[*model.IotDeviceDO]{List: list, Total: total}, err
 }
 
+func (r *DeviceRepositoryImpl) GetList(ctx context.Context, req *iot.IotDeviceExportReqVO) ([]*model.IotDeviceDO, error) {
+	d := r.q.IotDeviceDO
+	db := d.WithContext(ctx)
+	if req.DeviceName != "" {
+		db = db.Where(d.DeviceName.Like("%" + req.DeviceName + "%"))
+	}
+	if req.Nickname != "" {
+		db = db.Where(d.Nickname.Like("%" + req.Nickname + "%"))
+	}
+	if req.ProductID != 0 {
+		db = db.Where(d.ProductID.Eq(req.ProductID))
+	}
+	if req.DeviceType != 0 {
+		db = db.Where(d.DeviceType.Eq(req.DeviceType))
+	}
+	if req.Status != 0 {
+		db = db.Where(d.State.Eq(req.Status))
+	}
+	if req.GroupID != 0 {
+		db = db.Where(groupIDContains(req.GroupID)...)
+	}
+	return db.Order(d.ID.Desc()).Find()
+}
+
+func (r *DeviceRepositoryImpl) ListByGroupID(ctx context.Context, groupID int64) ([]*model.IotDeviceDO, error) {
+	d := r.q.IotDeviceDO
+	return d.WithContext(ctx).Where(groupIDContains(groupID)...).Order(d.ID.Asc()).Find()
+}
+
+// groupIDContains 设备分组编号（group_ids JSON 数组）包含指定分组
+func groupIDContains(groupID int64) []gen.Condition {
+	return gen.Cond(datatypes.JSONArrayQuery("group_ids").Contains(groupID))
+}
+
 func (r *DeviceRepositoryImpl) CountByProductID(ctx context.Context, productID int64) (int64, error) {
 	return r.q.IotDeviceDO.WithContext(ctx).Where(r.q.IotDeviceDO.ProductID.Eq(productID)).Count()
 }
@@ -77,6 +117,16 @@ func (r *DeviceRepositoryImpl) CountByGatewayID(ctx context.Context, gatewayID i
 	return r.q.IotDeviceDO.WithContext(ctx).Where(r.q.IotDeviceDO.GatewayID.Eq(gatewayID)).Count()
 }
 
+func (r *DeviceRepositoryImpl) ListByGatewayID(ctx context.Context, gatewayID int64) ([]*model.IotDeviceDO, error) {
+	return r.q.IotDeviceDO.WithContext(ctx).Where(r.q.IotDeviceDO.GatewayID.Eq(gatewayID)).Find()
+}
+
+// UpdateGatewayID 更新子设备所属网关（gatewayID 为 0 表示解除绑定）
+func (r *DeviceRepositoryImpl) UpdateGatewayID(ctx context.Context, ids []int64, gatewayID int64) error {
+	_, err := r.q.IotDeviceDO.WithContext(ctx).Where(r.q.IotDeviceDO.ID.In(ids...)).Update(r.q.IotDeviceDO.GatewayID, gatewayID)
+	return err
+}
+
 func (r *DeviceRepositoryImpl) Count(ctx context.Context, startTime *time.Time) (int64, error) {
 	d := r.q.IotDeviceDO
 	db := d.WithContext(ctx)
@@ -122,3 +172,11 @@ func (r *DeviceRepositoryImpl) ListByProductKeyAndNames(ctx context.Context, pro
 func (r *DeviceRepositoryImpl) GetByProductKeyAndName(ctx context.Context, productKey string, name string) (*model.IotDeviceDO, error) {
 	return r.q.IotDeviceDO.WithContext(ctx).Where(r.q.IotDeviceDO.ProductKey.Eq(productKey), r.q.IotDeviceDO.DeviceName.Eq(name)).First()
 }
+
+func (r *DeviceRepositoryImpl) UpdateLocation(ctx context.Context, id int64, latitude, longitude decimal.Decimal) error {
+	_, err := r.q.IotDeviceDO.WithContext(ctx).Where(r.q.IotDeviceDO.ID.Eq(id)).Updates(map[string]any{
+		"latitude":  latitude,
+		"longitude": longitude,
+	})
+	return err
+}
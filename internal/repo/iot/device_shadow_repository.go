@@ -0,0 +1,47 @@
+package iot
+
+import (
+	"context"
+	"errors"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/internal/repo/query"
+	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+	"gorm.io/gorm"
+)
+
+type DeviceShadowRepositoryImpl struct {
+	q *query.Query
+}
+
+func NewDeviceShadowRepository(q *query.Query) iotsvc.DeviceShadowRepository {
+	return &DeviceShadowRepositoryImpl{q: q}
+}
+
+// GetByDeviceID 获取设备影子，不存在时返回 nil
+func (r *DeviceShadowRepositoryImpl) GetByDeviceID(ctx context.Context, deviceID int64) (*model.IotDeviceShadowDO, error) {
+	m := r.q.IotDeviceShadowDO
+	shadow, err := m.WithContext(ctx).Where(m.DeviceID.Eq(deviceID)).First()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return shadow, err
+}
+
+func (r *DeviceShadowRepositoryImpl) Create(ctx context.Context, shadow *model.IotDeviceShadowDO) error {
+	return r.q.IotDeviceShadowDO.WithContext(ctx).Create(shadow)
+}
+
+// UpdateByVersion 按版本号乐观更新期望状态、上报状态与版本号
+func (r *DeviceShadowRepositoryImpl) UpdateByVersion(ctx context.Context, shadow *model.IotDeviceShadowDO, version int64) (bool, error) {
+	m := r.q.IotDeviceShadowDO
+	info, err := m.WithContext(ctx).Where(m.ID.Eq(shadow.ID), m.Version.Eq(version)).Updates(map[string]any{
+		"desired":  shadow.Desired,
+		"reported": shadow.Reported,
+		"version":  shadow.Version,
+	})
+	if err != nil {
+		return false, err
+	}
+	return info.RowsAffected > 0, nil
+}
@@ -0,0 +1,72 @@
+package iot
+
+import (
+	"context"
+	"errors"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/internal/repo/query"
+	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
+	"gorm.io/gorm"
+)
+
+type GeofenceRepositoryImpl struct {
+	q *query.Query
+}
+
+func NewGeofenceRepository(q *query.Query) iotsvc.GeofenceRepository {
+	return &GeofenceRepositoryImpl{q: q}
+}
+
+func (r *GeofenceRepositoryImpl) Create(ctx context.Context, geofence *model.IotGeofenceDO) error {
+	return r.q.IotGeofenceDO.WithContext(ctx).Create(geofence)
+}
+
+// Update 全字段更新，允许将产品、分组、状态等更新为零值
+func (r *GeofenceRepositoryImpl) Update(ctx context.Context, geofence *model.IotGeofenceDO) error {
+	g := r.q.IotGeofenceDO
+	_, err := g.WithContext(ctx).Where(g.ID.Eq(geofence.ID)).
+		Select(g.Name, g.Type, g.ProductID, g.GroupID, g.CenterLatitude, g.CenterLongitude, g.Radius, g.Points, g.Status, g.Description).
+		Updates(geofence)
+	return err
+}
+
+func (r *GeofenceRepositoryImpl) Delete(ctx context.Context, id int64) error {
+	_, err := r.q.IotGeofenceDO.WithContext(ctx).Where(r.q.IotGeofenceDO.ID.Eq(id)).Delete()
+	return err
+}
+
+// GetByID 获取地理围栏，不存在时返回 nil
+func (r *GeofenceRepositoryImpl) GetByID(ctx context.Context, id int64) (*model.IotGeofenceDO, error) {
+	geofence, err := r.q.IotGeofenceDO.WithContext(ctx).Where(r.q.IotGeofenceDO.ID.Eq(id)).First()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return geofence, err
+}
+
+func (r *GeofenceRepositoryImpl) GetPage(ctx context.Context, req *iot.IotGeofencePageReqVO) (*pagination.PageResult[*model.IotGeofenceDO], error) {
+	g := r.q.IotGeofenceDO
+	db := g.WithContext(ctx)
+	if req.Name != "" {
+		db = db.Where(g.Name.Like("%" + req.Name + "%"))
+	}
+	if req.Type != 0 {
+		db = db.Where(g.Type.Eq(req.Type))
+	}
+	if req.ProductID != 0 {
+		db = db.Where(g.ProductID.Eq(req.ProductID))
+	}
+	if req.Status != nil {
+		db = db.Where(g.Status.Eq(*req.Status))
+	}
+	list, total, err := db.Order(g.ID.Desc()).FindByPage((req.PageNo-1)*req.PageSize, req.PageSize)
+	return &pagination.PageResult[*model.IotGeofenceDO]{List: list, Total: total}, err
+}
+
+func (r *GeofenceRepositoryImpl) GetListByStatus(ctx context.Context, status int8) ([]*model.IotGeofenceDO, error) {
+	g := r.q.IotGeofenceDO
+	return g.WithContext(ctx).Where(g.Status.Eq(status)).Find()
+}
@@ -2,6 +2,7 @@ package iot
 
 import (
 	"context"
+	"time"
 
 	"github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
 	"github.com/wxlbd/ruoyi-mall-go/internal/model"
@@ -62,3 +63,37 @@ func (r *OtaTaskRecordRepositoryImpl) GetListByTaskIdAndStatus(ctx context.Conte
 	}
 	return tr.WithContext(ctx).Where(tr.TaskID.Eq(taskID), tr.Status.In(statusInt8...)).Find()
 }
+
+// GetListByTaskIdAndStatusWithLimit 根据任务ID和状态查询指定数量的升级记录（按编号升序）
+func (r *OtaTaskRecordRepositoryImpl) GetListByTaskIdAndStatusWithLimit(ctx context.Context, taskID int64, status int, limit int) ([]*model.IotOtaTaskRecordDO, error) {
+	tr := r.q.IotOtaTaskRecordDO
+	return tr.WithContext(ctx).Where(tr.TaskID.Eq(taskID), tr.Status.Eq(int8(status))).Order(tr.ID).Limit(limit).Find()
+}
+
+// CountByTaskIdAndStatus 根据任务ID和状态列表统计升级记录数量
+func (r *OtaTaskRecordRepositoryImpl) CountByTaskIdAndStatus(ctx context.Context, taskID int64, statuses []int) (int64, error) {
+	tr := r.q.IotOtaTaskRecordDO
+	statusInt8 := make([]int8, len(statuses))
+	for i, s := range statuses {
+		statusInt8[i] = int8(s)
+	}
+	return tr.WithContext(ctx).Where(tr.TaskID.Eq(taskID), tr.Status.In(statusInt8...)).Count()
+}
+
+// CountByTaskIdAndPushTimeAfter 统计任务在指定时间之后推送的升级记录数量
+func (r *OtaTaskRecordRepositoryImpl) CountByTaskIdAndPushTimeAfter(ctx context.Context, taskID int64, pushTime time.Time) (int64, error) {
+	tr := r.q.IotOtaTaskRecordDO
+	return tr.WithContext(ctx).Where(tr.TaskID.Eq(taskID), tr.PushTime.Gt(pushTime)).Count()
+}
+
+// UpdateStatusByTaskIdAndStatus 批量更新任务下指定状态的升级记录
+func (r *OtaTaskRecordRepositoryImpl) UpdateStatusByTaskIdAndStatus(ctx context.Context, taskID int64, whereStatuses []int, status int, description string) error {
+	tr := r.q.IotOtaTaskRecordDO
+	statusInt8 := make([]int8, len(whereStatuses))
+	for i, s := range whereStatuses {
+		statusInt8[i] = int8(s)
+	}
+	_, err := tr.WithContext(ctx).Where(tr.TaskID.Eq(taskID), tr.Status.In(statusInt8...)).
+		UpdateSimple(tr.Status.Value(int8(status)), tr.Description.Value(description))
+	return err
+}
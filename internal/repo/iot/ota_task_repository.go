@@ -22,6 +22,22 @@ func (r *OtaTaskRepositoryImpl) Create(ctx context.Context, task *model.IotOtaTa
 	return r.q.IotOtaTaskDO.WithContext(ctx).Create(task)
 }
 
+// CreateWithRecords 在同一事务中创建任务及其升级记录，避免任务已发布而记录缺失
+func (r *OtaTaskRepositoryImpl) CreateWithRecords(ctx context.Context, task *model.IotOtaTaskDO, records []*model.IotOtaTaskRecordDO) error {
+	return r.q.Transaction(func(tx *query.Query) error {
+		if err := tx.IotOtaTaskDO.WithContext(ctx).Create(task); err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+		for _, record := range records {
+			record.TaskID = task.ID
+		}
+		return tx.IotOtaTaskRecordDO.WithContext(ctx).Create(records...)
+	})
+}
+
 func (r *OtaTaskRepositoryImpl) Update(ctx context.Context, task *model.IotOtaTaskDO) error {
 	_, err := r.q.IotOtaTaskDO.WithContext(ctx).Where(r.q.IotOtaTaskDO.ID.Eq(task.ID)).Updates(task)
 	return err
@@ -45,3 +61,9 @@ func (r *OtaTaskRepositoryImpl) GetPage(ctx context.Context, req *iot.IotOtaTask
 	list, total, err := db.Order(t.ID.Desc()).FindByPage((req.PageNo-1)*req.PageSize, req.PageSize)
 	return &pagination.PageResult[*model.IotOtaTaskDO]{List: list, Total: total}, err
 }
+
+// GetListByStatus 根据状态查询升级任务列表
+func (r *OtaTaskRepositoryImpl) GetListByStatus(ctx context.Context, status int8) ([]*model.IotOtaTaskDO, error) {
+	t := r.q.IotOtaTaskDO
+	return t.WithContext(ctx).Where(t.Status.Eq(status)).Find()
+}
@@ -24,7 +24,10 @@ func (r *ProductRepositoryImpl) Create(ctx context.Context, product *model.IotPr
 }
 
 func (r *ProductRepositoryImpl) Update(ctx context.Context, product *model.IotProductDO) error {
-	_, err := r.q.IotProductDO.WithContext(ctx).Where(r.q.IotProductDO.ID.Eq(product.ID)).Updates(product)
+	p := r.q.IotProductDO
+	// 产品总是先查询再整体更新，需写入零值（如属性保留天数改回 0 永久保留）
+	_, err := p.WithContext(ctx).Where(p.ID.Eq(product.ID)).
+		Select(p.ALL).Omit(p.CreateTime, p.Creator).Updates(product)
 	return err
 }
 
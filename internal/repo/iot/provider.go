@@ -16,8 +16,16 @@ var ProviderSet = wire.NewSet(
 	NewAlertRecordRepository,
 	NewDataRuleRepository,
 	NewDataSinkRepository,
+	NewDataSinkDeadLetterRepository,
 	NewSceneRuleRepository,
 	NewProductCategoryRepository,
 	NewDeviceMessageRepository,
 	NewDevicePropertyRepository,
+	NewDeviceShadowRepository,
+	NewDevicePropertyRollupRepository,
+	NewDeviceRegisterLogRepository,
+	NewDeviceCertificateRepository,
+	NewGeofenceRepository,
+	NewDeviceLocationRepository,
+	NewThingModelVersionRepository,
 )
@@ -49,3 +49,17 @@ func (r *ThingModelRepositoryImpl) GetPage(ctx context.Context, req *iot.IotThin
 	list, total, err := tm.WithContext(ctx).Where(tm.ProductID.Eq(req.ProductID)).FindByPage((req.PageNo-1)*req.PageSize, req.PageSize)
 	return &pagination.PageResult[*model.IotThingModelDO]{List: list, Total: total}, err
 }
+
+// ReplaceByProductID 整体替换产品的物模型功能（导入 TSL、回滚版本时使用）
+func (r *ThingModelRepositoryImpl) ReplaceByProductID(ctx context.Context, productID int64, list []*model.IotThingModelDO) error {
+	return r.q.Transaction(func(tx *query.Query) error {
+		tm := tx.IotThingModelDO
+		if _, err := tm.WithContext(ctx).Where(tm.ProductID.Eq(productID)).Delete(); err != nil {
+			return err
+		}
+		if len(list) == 0 {
+			return nil
+		}
+		return tm.WithContext(ctx).Create(list...)
+	})
+}
@@ -0,0 +1,92 @@
+package iot
+
+import (
+	"context"
+	"errors"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/internal/repo/query"
+	iotsvc "github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+	"gorm.io/gorm"
+)
+
+type ThingModelVersionRepositoryImpl struct {
+	q *query.Query
+}
+
+func NewThingModelVersionRepository(q *query.Query) iotsvc.ThingModelVersionRepository {
+	return &ThingModelVersionRepositoryImpl{q: q}
+}
+
+// Publish 创建新版本并设为生效版本，原生效版本转为历史版本
+func (r *ThingModelVersionRepositoryImpl) Publish(ctx context.Context, version *model.IotThingModelVersionDO) error {
+	return r.q.Transaction(func(tx *query.Query) error {
+		v := tx.IotThingModelVersionDO
+		if _, err := v.WithContext(ctx).
+			Where(v.ProductID.Eq(version.ProductID), v.Status.Eq(consts.IotThingModelVersionStatusActive)).
+			Update(v.Status, consts.IotThingModelVersionStatusHistory); err != nil {
+			return err
+		}
+		version.Status = consts.IotThingModelVersionStatusActive
+		return v.WithContext(ctx).Create(version)
+	})
+}
+
+// Rollback 将指定版本设为生效版本（原生效版本转为历史版本），并在同一事务中整体替换产品的物模型草稿
+func (r *ThingModelVersionRepositoryImpl) Rollback(ctx context.Context, productID, id int64, drafts []*model.IotThingModelDO) error {
+	return r.q.Transaction(func(tx *query.Query) error {
+		v := tx.IotThingModelVersionDO
+		if _, err := v.WithContext(ctx).
+			Where(v.ProductID.Eq(productID), v.Status.Eq(consts.IotThingModelVersionStatusActive)).
+			Update(v.Status, consts.IotThingModelVersionStatusHistory); err != nil {
+			return err
+		}
+		if _, err := v.WithContext(ctx).Where(v.ID.Eq(id)).Update(v.Status, consts.IotThingModelVersionStatusActive); err != nil {
+			return err
+		}
+		tm := tx.IotThingModelDO
+		if _, err := tm.WithContext(ctx).Where(tm.ProductID.Eq(productID)).Delete(); err != nil {
+			return err
+		}
+		if len(drafts) == 0 {
+			return nil
+		}
+		return tm.WithContext(ctx).Create(drafts...)
+	})
+}
+
+// GetByID 获取物模型版本，不存在时返回 nil
+func (r *ThingModelVersionRepositoryImpl) GetByID(ctx context.Context, id int64) (*model.IotThingModelVersionDO, error) {
+	v := r.q.IotThingModelVersionDO
+	version, err := v.WithContext(ctx).Where(v.ID.Eq(id)).First()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return version, err
+}
+
+// GetActiveByProductID 获取产品的生效版本，不存在时返回 nil
+func (r *ThingModelVersionRepositoryImpl) GetActiveByProductID(ctx context.Context, productID int64) (*model.IotThingModelVersionDO, error) {
+	v := r.q.IotThingModelVersionDO
+	version, err := v.WithContext(ctx).
+		Where(v.ProductID.Eq(productID), v.Status.Eq(consts.IotThingModelVersionStatusActive)).First()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return version, err
+}
+
+// ListByProductID 获取产品的版本列表，按版本号倒序，不含 TSL 快照
+func (r *ThingModelVersionRepositoryImpl) ListByProductID(ctx context.Context, productID int64) ([]*model.IotThingModelVersionDO, error) {
+	v := r.q.IotThingModelVersionDO
+	return v.WithContext(ctx).Omit(v.TSL).Where(v.ProductID.Eq(productID)).Order(v.Version.Desc()).Find()
+}
+
+// GetMaxVersion 获取产品的最大版本号，没有版本时返回 0
+func (r *ThingModelVersionRepositoryImpl) GetMaxVersion(ctx context.Context, productID int64) (int, error) {
+	v := r.q.IotThingModelVersionDO
+	var maxVersion int
+	err := v.WithContext(ctx).Select(v.Version.Max().IfNull(0)).Where(v.ProductID.Eq(productID)).Scan(&maxVersion)
+	return maxVersion, err
+}
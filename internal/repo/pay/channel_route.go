@@ -0,0 +1,104 @@
+package pay
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// PayChannelHealth 支付渠道健康计数
+type PayChannelHealth struct {
+	SuccessCount       int64      // 下单成功次数
+	FailureCount       int64      // 下单失败次数
+	ContinuousFailures int64      // 连续失败次数，下单成功后清零
+	LastSuccessTime    *time.Time // 最近一次下单成功时间
+	LastFailureTime    *time.Time // 最近一次下单失败时间
+	LastFailureMsg     string     // 最近一次下单失败原因
+}
+
+// PayChannelRouteRedisDAO 支付渠道路由的 Redis DAO，记录渠道健康计数与规则的轮询序号
+type PayChannelRouteRedisDAO struct {
+	rdb *redis.Client
+}
+
+// NewPayChannelRouteRedisDAO 创建 PayChannelRouteRedisDAO
+func NewPayChannelRouteRedisDAO(rdb *redis.Client) *PayChannelRouteRedisDAO {
+	return &PayChannelRouteRedisDAO{rdb: rdb}
+}
+
+// NextSequence 获得路由规则的下一个轮询序号，从 1 开始
+func (dao *PayChannelRouteRedisDAO) NextSequence(ctx context.Context, ruleID int64) (int64, error) {
+	return dao.rdb.Incr(ctx, fmt.Sprintf("pay_channel_route:sequence:%d", ruleID)).Result()
+}
+
+// IncrSuccess 记录渠道下单成功
+func (dao *PayChannelRouteRedisDAO) IncrSuccess(ctx context.Context, channelID int64) error {
+	key := formatHealthKey(channelID)
+	pipe := dao.rdb.TxPipeline()
+	pipe.HIncrBy(ctx, key, "success_count", 1)
+	pipe.HSet(ctx, key, "continuous_failures", 0, "last_success_time", time.Now().Unix())
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// IncrFailure 记录渠道下单失败
+func (dao *PayChannelRouteRedisDAO) IncrFailure(ctx context.Context, channelID int64, msg string) error {
+	key := formatHealthKey(channelID)
+	pipe := dao.rdb.TxPipeline()
+	pipe.HIncrBy(ctx, key, "failure_count", 1)
+	pipe.HIncrBy(ctx, key, "continuous_failures", 1)
+	pipe.HSet(ctx, key, "last_failure_time", time.Now().Unix(), "last_failure_msg", msg)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// GetHealthMap 批量获得渠道健康计数，没有记录的渠道返回零值
+func (dao *PayChannelRouteRedisDAO) GetHealthMap(ctx context.Context, channelIDs []int64) (map[int64]*PayChannelHealth, error) {
+	result := make(map[int64]*PayChannelHealth, len(channelIDs))
+	if len(channelIDs) == 0 {
+		return result, nil
+	}
+	pipe := dao.rdb.Pipeline()
+	cmds := make([]*redis.MapStringStringCmd, 0, len(channelIDs))
+	for _, channelID := range channelIDs {
+		cmds = append(cmds, pipe.HGetAll(ctx, formatHealthKey(channelID)))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	for i, cmd := range cmds {
+		result[channelIDs[i]] = parseHealth(cmd.Val())
+	}
+	return result, nil
+}
+
+// DeleteHealth 清空渠道健康计数
+func (dao *PayChannelRouteRedisDAO) DeleteHealth(ctx context.Context, channelID int64) error {
+	return dao.rdb.Del(ctx, formatHealthKey(channelID)).Err()
+}
+
+func formatHealthKey(channelID int64) string {
+	return fmt.Sprintf("pay_channel_route:health:%d", channelID)
+}
+
+func parseHealth(values map[string]string) *PayChannelHealth {
+	health := &PayChannelHealth{LastFailureMsg: values["last_failure_msg"]}
+	health.SuccessCount, _ = strconv.ParseInt(values["success_count"], 10, 64)
+	health.FailureCount, _ = strconv.ParseInt(values["failure_count"], 10, 64)
+	health.ContinuousFailures, _ = strconv.ParseInt(values["continuous_failures"], 10, 64)
+	health.LastSuccessTime = parseUnixTime(values["last_success_time"])
+	health.LastFailureTime = parseUnixTime(values["last_failure_time"])
+	return health
+}
+
+func parseUnixTime(value string) *time.Time {
+	sec, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || sec <= 0 {
+		return nil
+	}
+	t := time.Unix(sec, 0)
+	return &t
+}
@@ -313,6 +313,29 @@ func (s *FileService) GetFilePresignedUrl(ctx context.Context, path string) (*in
 	}, nil
 }
 
+// GetFileDownloadUrl 获取文件的临时下载地址
+// 仅主存储中的文件会签发预签名 URL；本地存储不支持预签名，其它来源的 URL 原样返回
+func (s *FileService) GetFileDownloadUrl(ctx context.Context, fileURL string) (string, error) {
+	config, err := s.fileConfigService.GetMasterFileConfig(ctx)
+	if err != nil {
+		return fileURL, nil
+	}
+	if config.Storage == 10 { // Local
+		return fileURL, nil
+	}
+
+	configBytes, _ := json.Marshal(config.Config)
+	client, err := file.NewFileClient(config.ID, config.Storage, configBytes)
+	if err != nil {
+		return "", err
+	}
+	path, ok := strings.CutPrefix(fileURL, client.GetURL(""))
+	if !ok || path == "" {
+		return fileURL, nil
+	}
+	return client.GetPresignedURL(path)
+}
+
 func (s *FileService) CreateFileCallback(ctx context.Context, req *infra.FileCreateReq) (int64, error) {
 	// 验证配置是否存在
 	_, err := s.fileConfigService.GetFileConfig(ctx, req.ConfigID)
@@ -33,6 +33,8 @@ func (s *AlertConfigService) Create(ctx context.Context, r *iot2.IotAlertConfigS
 		SceneRuleIDs:   datatypes.JSON(sceneRuleIDs),
 		ReceiveUserIDs: datatypes.JSON(receiveUserIDs),
 		ReceiveTypes:   datatypes.JSON(receiveTypes),
+		DedupWindow:    r.DedupWindow,
+		SilenceWindow:  r.SilenceWindow,
 	}
 	if err := s.alertConfigRepo.Create(ctx, config); err != nil {
 		return 0, err
@@ -60,6 +62,8 @@ func (s *AlertConfigService) Update(ctx context.Context, r *iot2.IotAlertConfigS
 	c.SceneRuleIDs = datatypes.JSON(sceneRuleIDs)
 	c.ReceiveUserIDs = datatypes.JSON(receiveUserIDs)
 	c.ReceiveTypes = datatypes.JSON(receiveTypes)
+	c.DedupWindow = r.DedupWindow
+	c.SilenceWindow = r.SilenceWindow
 
 	return s.alertConfigRepo.Update(ctx, c)
 }
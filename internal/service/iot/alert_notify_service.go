@@ -0,0 +1,163 @@
+package iot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	iotcore "github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/system"
+)
+
+// IotAlertNotifyTemplateCode 告警通知模板编号
+// 站内信、邮件、短信模板需分别以该编号配置，可用参数见 buildAlertNotifyParams
+const IotAlertNotifyTemplateCode = "iot_alert"
+
+const (
+	alertDedupKeyPrefix   = "iot:alert:dedup:"
+	alertSilenceKeyPrefix = "iot:alert:silence:"
+
+	defaultAlertSilenceWindow = 5 * time.Minute
+)
+
+// AlertNotifyService 告警通知 service
+// 去重窗口内同一告警配置、同一设备的重复触发直接忽略，不再生成告警记录（去重窗口为 0 时不去重）；
+// 静默窗口内仍生成告警记录，但不再重复通知接收人。告警恢复时清除两个窗口
+type AlertNotifyService struct {
+	rdb        *redis.Client
+	notifySvc  *system.NotifyService
+	mailSvc    *system.MailService
+	smsSendSvc *system.SmsSendService
+	userSvc    *system.UserService
+}
+
+func NewAlertNotifyService(
+	rdb *redis.Client,
+	notifySvc *system.NotifyService,
+	mailSvc *system.MailService,
+	smsSendSvc *system.SmsSendService,
+	userSvc *system.UserService,
+) *AlertNotifyService {
+	return &AlertNotifyService{
+		rdb:        rdb,
+		notifySvc:  notifySvc,
+		mailSvc:    mailSvc,
+		smsSendSvc: smsSendSvc,
+		userSvc:    userSvc,
+	}
+}
+
+// TryAcquireAlert 尝试占用去重窗口，返回 false 表示窗口内已触发过，应忽略本次告警
+// 占用后告警记录未能生成时，需调用 ReleaseAlert 释放窗口，避免整个窗口内漏告警
+func (s *AlertNotifyService) TryAcquireAlert(ctx context.Context, config *model.IotAlertConfigDO, deviceID int64) bool {
+	if config.DedupWindow <= 0 {
+		return true
+	}
+	window := time.Duration(config.DedupWindow) * time.Second
+	ok, err := s.rdb.SetNX(ctx, buildAlertWindowKey(alertDedupKeyPrefix, config.ID, deviceID), "1", window).Result()
+	if err != nil {
+		// Redis 异常时不去重，宁可重复告警也不漏告警
+		log.Printf("[AlertNotifyService] Acquire dedup window failed: configId=%d, deviceId=%d, err=%v", config.ID, deviceID, err)
+		return true
+	}
+	return ok
+}
+
+// ReleaseAlert 释放去重窗口（告警记录生成失败时调用）
+func (s *AlertNotifyService) ReleaseAlert(ctx context.Context, config *model.IotAlertConfigDO, deviceID int64) {
+	if config.DedupWindow <= 0 {
+		return
+	}
+	if err := s.rdb.Del(ctx, buildAlertWindowKey(alertDedupKeyPrefix, config.ID, deviceID)).Err(); err != nil {
+		log.Printf("[AlertNotifyService] Release dedup window failed: configId=%d, deviceId=%d, err=%v", config.ID, deviceID, err)
+	}
+}
+
+// ClearAlertWindow 清除去重与静默窗口（告警恢复时调用）
+func (s *AlertNotifyService) ClearAlertWindow(ctx context.Context, configID, deviceID int64) {
+	s.rdb.Del(ctx,
+		buildAlertWindowKey(alertDedupKeyPrefix, configID, deviceID),
+		buildAlertWindowKey(alertSilenceKeyPrefix, configID, deviceID))
+}
+
+// SendAlertNotify 按告警配置的接收方式通知接收人，静默窗口内不重复通知
+// 通知异步发送，避免邮件、短信等外部调用阻塞设备消息处理
+func (s *AlertNotifyService) SendAlertNotify(ctx context.Context, config *model.IotAlertConfigDO, recordID int64, device *model.IotDeviceDO, message *iotcore.IotDeviceMessage) {
+	var receiveUserIDs []int64
+	var receiveTypes []int
+	_ = json.Unmarshal(config.ReceiveUserIDs, &receiveUserIDs)
+	_ = json.Unmarshal(config.ReceiveTypes, &receiveTypes)
+	if len(receiveUserIDs) == 0 || len(receiveTypes) == 0 {
+		return
+	}
+
+	window := defaultAlertSilenceWindow
+	if config.SilenceWindow > 0 {
+		window = time.Duration(config.SilenceWindow) * time.Second
+	}
+	ok, err := s.rdb.SetNX(ctx, buildAlertWindowKey(alertSilenceKeyPrefix, config.ID, device.ID), recordID, window).Result()
+	if err != nil {
+		log.Printf("[AlertNotifyService] Acquire silence window failed: configId=%d, deviceId=%d, err=%v", config.ID, device.ID, err)
+	} else if !ok {
+		log.Printf("[AlertNotifyService] Alert notify silenced: configId=%d, deviceId=%d, recordId=%d", config.ID, device.ID, recordID)
+		return
+	}
+
+	params := buildAlertNotifyParams(config, recordID, device, message)
+	go func() {
+		notifyCtx := context.Background()
+		for _, userID := range receiveUserIDs {
+			for _, receiveType := range receiveTypes {
+				if err := s.sendToUser(notifyCtx, receiveType, userID, params); err != nil {
+					log.Printf("[AlertNotifyService] Send alert notify failed: recordId=%d, userId=%d, receiveType=%d, err=%v",
+						recordID, userID, receiveType, err)
+				}
+			}
+		}
+	}()
+}
+
+// sendToUser 按接收方式向单个用户发送告警通知
+func (s *AlertNotifyService) sendToUser(ctx context.Context, receiveType int, userID int64, params map[string]any) error {
+	var err error
+	switch receiveType {
+	case consts.IotAlertReceiveTypeSms:
+		user, getErr := s.userSvc.GetUser(ctx, userID)
+		if getErr != nil {
+			return getErr
+		}
+		_, err = s.smsSendSvc.SendSingleSmsToAdmin(ctx, user.Mobile, userID, IotAlertNotifyTemplateCode, params)
+	case consts.IotAlertReceiveTypeMail:
+		// 收件人为空时，由邮件服务根据用户编号查询邮箱
+		_, err = s.mailSvc.SendSingleMail(ctx, nil, nil, nil, userID, consts.UserTypeAdmin, IotAlertNotifyTemplateCode, params)
+	case consts.IotAlertReceiveTypeNotify:
+		_, err = s.notifySvc.SendNotify(ctx, userID, consts.UserTypeAdmin, IotAlertNotifyTemplateCode, params)
+	default:
+		err = fmt.Errorf("unsupported receive type: %d", receiveType)
+	}
+	return err
+}
+
+// buildAlertNotifyParams 构建告警通知模板参数
+func buildAlertNotifyParams(config *model.IotAlertConfigDO, recordID int64, device *model.IotDeviceDO, message *iotcore.IotDeviceMessage) map[string]any {
+	deviceMessage, _ := json.Marshal(message)
+	return map[string]any{
+		"recordId":      recordID,
+		"configName":    config.Name,
+		"configLevel":   config.Level,
+		"productKey":    device.ProductKey,
+		"deviceName":    device.DeviceName,
+		"method":        message.Method,
+		"deviceMessage": string(deviceMessage),
+	}
+}
+
+// buildAlertWindowKey 构建告警窗口的 Redis Key
+func buildAlertWindowKey(prefix string, configID, deviceID int64) string {
+	return fmt.Sprintf("%s%d:%d", prefix, configID, deviceID)
+}
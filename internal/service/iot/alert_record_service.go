@@ -2,8 +2,10 @@ package iot
 
 import (
 	"context"
+	"encoding/json"
 
 	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	iotcore "github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
 	"github.com/wxlbd/ruoyi-mall-go/internal/model"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
 )
@@ -44,17 +46,34 @@ func (s *AlertRecordService) GetListBySceneRuleId(ctx context.Context, sceneRule
 	return s.alertRecordRepo.GetListBySceneRuleId(ctx, sceneRuleID, deviceID, processStatus)
 }
 
-func (s *AlertRecordService) CreateAlertRecord(ctx context.Context, config *model.IotAlertConfigDO, sceneRuleID int64, deviceMessage string) (int64, error) {
+// CreateAlertRecord 创建告警记录，记录触发告警的设备消息
+func (s *AlertRecordService) CreateAlertRecord(ctx context.Context, config *model.IotAlertConfigDO, sceneRuleID int64, device *model.IotDeviceDO, message *iotcore.IotDeviceMessage) (int64, error) {
+	deviceMessage, _ := json.Marshal(message)
 	record := &model.IotAlertRecordDO{
 		ConfigID:      config.ID,
 		ConfigName:    config.Name,
 		ConfigLevel:   config.Level,
 		SceneRuleID:   sceneRuleID,
-		DeviceMessage: deviceMessage,
+		ProductID:     device.ProductID,
+		DeviceID:      device.ID,
+		DeviceMessage: string(deviceMessage),
 		ProcessStatus: false,
 	}
+	record.TenantID = device.TenantID
 	if err := s.alertRecordRepo.Create(ctx, record); err != nil {
 		return 0, err
 	}
 	return record.ID, nil
 }
+
+// ProcessAlertRecordList 批量处理告警记录（用于告警恢复）
+func (s *AlertRecordService) ProcessAlertRecordList(ctx context.Context, records []*model.IotAlertRecordDO, processRemark string) error {
+	for _, record := range records {
+		record.ProcessStatus = true
+		record.ProcessRemark = processRemark
+		if err := s.alertRecordRepo.Update(ctx, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
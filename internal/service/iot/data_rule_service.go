@@ -3,20 +3,60 @@ package iot
 import (
 	"context"
 	"encoding/json"
+	"log"
+	"sync"
+	"time"
 
 	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	iotcore "github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/sink"
 	"github.com/wxlbd/ruoyi-mall-go/internal/model"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
 	"gorm.io/datatypes"
 )
 
+// dataRuleMaxConcurrentDelivery 数据流转并发投递上限，超出时阻塞消息消费以形成背压
+const dataRuleMaxConcurrentDelivery = 64
+
 type DataRuleService struct {
-	dataRuleRepo DataRuleRepository
+	dataRuleRepo     DataRuleRepository
+	deviceRepo       DeviceRepository
+	deadLetterRepo   DataSinkDeadLetterRepository
+	thingModelSvc    *ThingModelService
+	dataSinkSvc      *DataSinkService
+	dataSinkRegistry *sink.DataSinkRegistry
+
+	// 启用规则的本地缓存，规则变更时置空，下次执行时重新加载
+	mu        sync.RWMutex
+	ruleCache []*dataRuleCacheItem
+
+	deliverySem chan struct{}
 }
 
-func NewDataRuleService(dataRuleRepo DataRuleRepository) *DataRuleService {
+// dataRuleCacheItem 已解析数据源配置与数据目的的数据流转规则
+type dataRuleCacheItem struct {
+	rule          *model.IotDataRuleDO
+	sourceConfigs []iot2.IotDataRuleSourceConfig
+	sinkIDs       []int64
+}
+
+func NewDataRuleService(
+	dataRuleRepo DataRuleRepository,
+	deviceRepo DeviceRepository,
+	deadLetterRepo DataSinkDeadLetterRepository,
+	thingModelSvc *ThingModelService,
+	dataSinkSvc *DataSinkService,
+	dataSinkRegistry *sink.DataSinkRegistry,
+) *DataRuleService {
 	return &DataRuleService{
-		dataRuleRepo: dataRuleRepo,
+		dataRuleRepo:     dataRuleRepo,
+		deviceRepo:       deviceRepo,
+		deadLetterRepo:   deadLetterRepo,
+		thingModelSvc:    thingModelSvc,
+		dataSinkSvc:      dataSinkSvc,
+		dataSinkRegistry: dataSinkRegistry,
+		deliverySem:      make(chan struct{}, dataRuleMaxConcurrentDelivery),
 	}
 }
 
@@ -33,6 +73,7 @@ func (s *DataRuleService) Create(ctx context.Context, r *iot2.IotDataRuleSaveReq
 	if err := s.dataRuleRepo.Create(ctx, rule); err != nil {
 		return 0, err
 	}
+	s.invalidateRuleCache()
 	return rule.ID, nil
 }
 
@@ -54,11 +95,19 @@ func (s *DataRuleService) Update(ctx context.Context, r *iot2.IotDataRuleSaveReq
 	rule.SourceConfigs = datatypes.JSON(sourceConfigs)
 	rule.SinkIDs = datatypes.JSON(sinkIDs)
 
-	return s.dataRuleRepo.Update(ctx, rule)
+	if err := s.dataRuleRepo.Update(ctx, rule); err != nil {
+		return err
+	}
+	s.invalidateRuleCache()
+	return nil
 }
 
 func (s *DataRuleService) Delete(ctx context.Context, id int64) error {
-	return s.dataRuleRepo.Delete(ctx, id)
+	if err := s.dataRuleRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.invalidateRuleCache()
+	return nil
 }
 
 func (s *DataRuleService) Get(ctx context.Context, id int64) (*model.IotDataRuleDO, error) {
@@ -68,3 +117,185 @@ func (s *DataRuleService) Get(ctx context.Context, id int64) (*model.IotDataRule
 func (s *DataRuleService) GetPage(ctx context.Context, r *iot2.IotDataRulePageReqVO) (*pagination.PageResult[*model.IotDataRuleDO], error) {
 	return s.dataRuleRepo.GetPage(ctx, r)
 }
+
+// GetDeadLetterPage 获取数据流转死信分页
+func (s *DataRuleService) GetDeadLetterPage(ctx context.Context, r *iot2.IotDataSinkDeadLetterPageReqVO) (*pagination.PageResult[*model.IotDataSinkDeadLetterDO], error) {
+	return s.deadLetterRepo.GetPage(ctx, r)
+}
+
+// ExecuteDataRule 基于设备消息执行数据流转 (对齐 Java IotDataRuleService#executeDataRule)
+// 每个匹配规则的每个数据目的异步投递，失败按数据目的的重试策略退避重试，重试耗尽后记录死信
+func (s *DataRuleService) ExecuteDataRule(ctx context.Context, message *iotcore.IotDeviceMessage) {
+	rules, err := s.getEnabledRuleList(ctx)
+	if err != nil {
+		log.Printf("[DataRuleService] Load data rules failed: %v", err)
+		return
+	}
+	if len(rules) == 0 {
+		return
+	}
+
+	device, err := s.deviceRepo.GetByID(ctx, message.DeviceID)
+	if err != nil || device == nil {
+		log.Printf("[DataRuleService] Device not found: %d", message.DeviceID)
+		return
+	}
+	// 不符合物模型的属性、事件不流转
+	message, err = s.thingModelSvc.FilterUpstreamMessage(ctx, message, device.ProductID)
+	if err != nil {
+		log.Printf("[DataRuleService] Load thing model failed: productId=%d, err=%v", device.ProductID, err)
+		return
+	}
+	if message == nil {
+		return
+	}
+
+	for _, item := range rules {
+		if !s.matchSourceConfigs(item.sourceConfigs, device, message) {
+			continue
+		}
+		for _, sinkID := range item.sinkIDs {
+			dataSink, err := s.dataSinkSvc.GetEnabledDataSinkFromCache(ctx, sinkID)
+			if err != nil {
+				log.Printf("[DataRuleService] Load data sink failed: sinkId=%d, err=%v", sinkID, err)
+				continue
+			}
+			if dataSink == nil {
+				continue
+			}
+			s.deliverySem <- struct{}{}
+			go func(rule *model.IotDataRuleDO, dataSink *model.IotDataSinkDO) {
+				defer func() { <-s.deliverySem }()
+				s.deliver(rule, dataSink, device, message)
+			}(item.rule, dataSink)
+		}
+	}
+}
+
+// matchSourceConfigs 匹配数据源配置，任一配置满足即可
+func (s *DataRuleService) matchSourceConfigs(configs []iot2.IotDataRuleSourceConfig, device *model.IotDeviceDO, message *iotcore.IotDeviceMessage) bool {
+	for i := range configs {
+		if matchDataRuleSourceConfig(&configs[i], device, message) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchDataRuleSourceConfig 匹配单个数据源配置
+// 产品、设备为 0 时表示不限；标识符为空时表示不限
+func matchDataRuleSourceConfig(config *iot2.IotDataRuleSourceConfig, device *model.IotDeviceDO, message *iotcore.IotDeviceMessage) bool {
+	if config.Method != message.Method {
+		return false
+	}
+	if config.ProductID != 0 && config.ProductID != device.ProductID {
+		return false
+	}
+	if config.DeviceID != 0 && config.DeviceID != device.ID {
+		return false
+	}
+	if config.Identifier == "" {
+		return true
+	}
+
+	switch message.Method {
+	case consts.IotDeviceMessageMethodPropertyPost:
+		_, ok := message.Params[config.Identifier]
+		return ok
+	case consts.IotDeviceMessageMethodEventPost, consts.IotDeviceMessageMethodServiceInvoke:
+		identifier, _ := message.Params["identifier"].(string)
+		return identifier == config.Identifier
+	default:
+		return true
+	}
+}
+
+// deliver 投递消息到数据目的，失败时按重试策略退避重试，重试耗尽后记录死信
+func (s *DataRuleService) deliver(rule *model.IotDataRuleDO, dataSink *model.IotDataSinkDO, device *model.IotDeviceDO, message *iotcore.IotDeviceMessage) {
+	ctx := context.Background()
+	sinkImpl := s.dataSinkRegistry.Get(dataSink.Type)
+	if sinkImpl == nil {
+		log.Printf("[DataRuleService] Data sink type not supported: sinkId=%d, type=%d", dataSink.ID, dataSink.Type)
+		s.createDeadLetter(ctx, rule, dataSink, device, message, 0, "data sink type not supported")
+		return
+	}
+
+	policy := sink.ParseRetryPolicy(dataSink.Config)
+	var err error
+	for attempt := 0; attempt <= policy.MaxRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(policy.Backoff(attempt))
+		}
+		if err = sinkImpl.Send(ctx, dataSink.Config, message); err == nil {
+			return
+		}
+		log.Printf("[DataRuleService] Deliver failed: ruleId=%d, sinkId=%d, attempt=%d, err=%v",
+			rule.ID, dataSink.ID, attempt+1, err)
+	}
+	s.createDeadLetter(ctx, rule, dataSink, device, message, policy.MaxRetries, err.Error())
+}
+
+// createDeadLetter 记录投递失败的死信
+func (s *DataRuleService) createDeadLetter(ctx context.Context, rule *model.IotDataRuleDO, dataSink *model.IotDataSinkDO, device *model.IotDeviceDO, message *iotcore.IotDeviceMessage, retryCount int, errorMsg string) {
+	messageJSON, _ := json.Marshal(message)
+	// 按字符截断，避免截断多字节字符导致写入失败
+	if runes := []rune(errorMsg); len(runes) > 512 {
+		errorMsg = string(runes[:512])
+	}
+	deadLetter := &model.IotDataSinkDeadLetterDO{
+		RuleID:     rule.ID,
+		SinkID:     dataSink.ID,
+		SinkType:   dataSink.Type,
+		DeviceID:   device.ID,
+		MessageID:  message.ID,
+		Message:    string(messageJSON),
+		RetryCount: int32(retryCount),
+		ErrorMsg:   errorMsg,
+	}
+	// 异步投递无请求上下文，需显式设置租户
+	deadLetter.TenantID = device.TenantID
+	if err := s.deadLetterRepo.Create(ctx, deadLetter); err != nil {
+		log.Printf("[DataRuleService] Create dead letter failed: ruleId=%d, sinkId=%d, err=%v", rule.ID, dataSink.ID, err)
+	}
+}
+
+// getEnabledRuleList 获取启用的数据流转规则（带本地缓存）
+func (s *DataRuleService) getEnabledRuleList(ctx context.Context) ([]*dataRuleCacheItem, error) {
+	s.mu.RLock()
+	cache := s.ruleCache
+	s.mu.RUnlock()
+	if cache != nil {
+		return cache, nil
+	}
+
+	rules, err := s.dataRuleRepo.GetListByStatus(ctx, consts.CommonStatusEnable)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*dataRuleCacheItem, 0, len(rules))
+	for _, rule := range rules {
+		item := &dataRuleCacheItem{rule: rule}
+		if err := json.Unmarshal(rule.SourceConfigs, &item.sourceConfigs); err != nil {
+			log.Printf("[DataRuleService] Invalid source configs: ruleId=%d, err=%v", rule.ID, err)
+			continue
+		}
+		if err := json.Unmarshal(rule.SinkIDs, &item.sinkIDs); err != nil {
+			log.Printf("[DataRuleService] Invalid sink ids: ruleId=%d, err=%v", rule.ID, err)
+			continue
+		}
+		items = append(items, item)
+	}
+
+	s.mu.Lock()
+	s.ruleCache = items
+	s.mu.Unlock()
+	log.Printf("[DataRuleService] Data rules loaded: count=%d", len(items))
+	return items, nil
+}
+
+// invalidateRuleCache 清空规则缓存
+func (s *DataRuleService) invalidateRuleCache() {
+	s.mu.Lock()
+	s.ruleCache = nil
+	s.mu.Unlock()
+}
@@ -3,8 +3,10 @@ package iot
 import (
 	"context"
 	"encoding/json"
+	"sync"
 
 	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
 	"github.com/wxlbd/ruoyi-mall-go/internal/model"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
 	"gorm.io/datatypes"
@@ -12,6 +14,10 @@ import (
 
 type DataSinkService struct {
 	dataSinkRepo DataSinkRepository
+
+	// 启用数据目的的本地缓存，数据目的变更时置空，下次使用时重新加载
+	mu        sync.RWMutex
+	sinkCache map[int64]*model.IotDataSinkDO
 }
 
 func NewDataSinkService(dataSinkRepo DataSinkRepository) *DataSinkService {
@@ -32,6 +38,7 @@ func (s *DataSinkService) Create(ctx context.Context, r *iot2.IotDataSinkSaveReq
 	if err := s.dataSinkRepo.Create(ctx, sink); err != nil {
 		return 0, err
 	}
+	s.invalidateSinkCache()
 	return sink.ID, nil
 }
 
@@ -51,7 +58,11 @@ func (s *DataSinkService) Update(ctx context.Context, r *iot2.IotDataSinkSaveReq
 	sink.Type = r.Type
 	sink.Config = datatypes.JSON(config)
 
-	return s.dataSinkRepo.Update(ctx, sink)
+	if err := s.dataSinkRepo.Update(ctx, sink); err != nil {
+		return err
+	}
+	s.invalidateSinkCache()
+	return nil
 }
 
 func (s *DataSinkService) Delete(ctx context.Context, id int64) error {
@@ -62,7 +73,11 @@ func (s *DataSinkService) Delete(ctx context.Context, id int64) error {
 	if count > 0 {
 		return model.ErrDataSinkUsedByRule
 	}
-	return s.dataSinkRepo.Delete(ctx, id)
+	if err := s.dataSinkRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.invalidateSinkCache()
+	return nil
 }
 
 func (s *DataSinkService) Get(ctx context.Context, id int64) (*model.IotDataSinkDO, error) {
@@ -76,3 +91,31 @@ func (s *DataSinkService) GetPage(ctx context.Context, r *iot2.IotDataSinkPageRe
 func (s *DataSinkService) GetListByStatus(ctx context.Context, status int8) ([]*model.IotDataSinkDO, error) {
 	return s.dataSinkRepo.GetListByStatus(ctx, status)
 }
+
+// GetEnabledDataSinkFromCache 从缓存中获取启用的数据目的，不存在或未启用时返回 nil
+func (s *DataSinkService) GetEnabledDataSinkFromCache(ctx context.Context, id int64) (*model.IotDataSinkDO, error) {
+	s.mu.RLock()
+	cache := s.sinkCache
+	s.mu.RUnlock()
+	if cache == nil {
+		sinks, err := s.dataSinkRepo.GetListByStatus(ctx, consts.CommonStatusEnable)
+		if err != nil {
+			return nil, err
+		}
+		cache = make(map[int64]*model.IotDataSinkDO, len(sinks))
+		for _, sink := range sinks {
+			cache[sink.ID] = sink
+		}
+		s.mu.Lock()
+		s.sinkCache = cache
+		s.mu.Unlock()
+	}
+	return cache[id], nil
+}
+
+// invalidateSinkCache 清空数据目的缓存
+func (s *DataSinkService) invalidateSinkCache() {
+	s.mu.Lock()
+	s.sinkCache = nil
+	s.mu.Unlock()
+}
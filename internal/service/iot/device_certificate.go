@@ -0,0 +1,184 @@
+package iot
+
+import (
+	"context"
+	"crypto/x509"
+	"net"
+	"strconv"
+	"time"
+
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	iotcore "github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/config"
+)
+
+// 证书吊销原因
+const (
+	deviceCertificateRevokeReasonDeleted  = "设备删除"
+	deviceCertificateRevokeReasonRenewed  = "证书更新"
+	deviceCertificateRevokeReasonAuthType = "切换为密钥认证"
+)
+
+// normalizeAuthType 校验请求中的认证类型，为空时默认为密钥认证
+func normalizeAuthType(authType string) (string, error) {
+	switch authType {
+	case "", consts.IotDeviceAuthTypeSecret:
+		return consts.IotDeviceAuthTypeSecret, nil
+	case consts.IotDeviceAuthTypeX509:
+		return consts.IotDeviceAuthTypeX509, nil
+	default:
+		return "", model.ErrDeviceAuthTypeInvalid
+	}
+}
+
+// normalizeDeviceAuthType 设备的认证类型，历史设备未设置时视为密钥认证
+func normalizeDeviceAuthType(authType string) string {
+	if authType == consts.IotDeviceAuthTypeX509 {
+		return consts.IotDeviceAuthTypeX509
+	}
+	return consts.IotDeviceAuthTypeSecret
+}
+
+// IsSecretAuth 设备是否使用设备密钥认证
+func (s *DeviceService) IsSecretAuth(device *model.IotDeviceDO) bool {
+	return normalizeDeviceAuthType(device.AuthType) == consts.IotDeviceAuthTypeSecret
+}
+
+// issueCertificate 为设备签发客户端证书并保存
+func (s *DeviceService) issueCertificate(ctx context.Context, device *model.IotDeviceDO) (*model.IotDeviceCertificateDO, error) {
+	issued, err := s.deviceCA.Issue(device.ProductKey, device.DeviceName)
+	if err != nil {
+		return nil, err
+	}
+	certificate := &model.IotDeviceCertificateDO{
+		DeviceID:     device.ID,
+		ProductKey:   device.ProductKey,
+		DeviceName:   device.DeviceName,
+		SerialNumber: issued.SerialNumber,
+		Fingerprint:  issued.Fingerprint,
+		Subject:      issued.Subject,
+		Certificate:  issued.CertificatePEM,
+		PrivateKey:   issued.PrivateKeyPEM,
+		NotBefore:    issued.NotBefore,
+		NotAfter:     issued.NotAfter,
+		Status:       consts.IotDeviceCertificateStatusValid,
+	}
+	certificate.TenantID = device.TenantID
+	if err := s.certRepo.Create(ctx, certificate); err != nil {
+		return nil, err
+	}
+	return certificate, nil
+}
+
+// switchAuthType 切换设备认证类型：切换为 X.509 时签发证书，切换为密钥时吊销证书
+func (s *DeviceService) switchAuthType(ctx context.Context, device *model.IotDeviceDO, authType string) error {
+	if authType == consts.IotDeviceAuthTypeX509 {
+		_, err := s.issueCertificate(ctx, device)
+		return err
+	}
+	return s.certRepo.RevokeByDeviceIDs(ctx, []int64{device.ID}, deviceCertificateRevokeReasonAuthType)
+}
+
+// fillCertificateAuthInfo 填充 X.509 设备的证书认证信息，设备没有有效证书时补签
+func (s *DeviceService) fillCertificateAuthInfo(ctx context.Context, device *model.IotDeviceDO, authInfo *iot2.IotDeviceAuthInfoRespVO) error {
+	certificate, err := s.certRepo.GetValidByDeviceID(ctx, device.ID)
+	if err != nil {
+		return err
+	}
+	if certificate == nil {
+		if certificate, err = s.issueCertificate(ctx, device); err != nil {
+			return err
+		}
+	}
+	authInfo.Certificate = certificate.Certificate
+	authInfo.PrivateKey = certificate.PrivateKey
+	authInfo.CaCertificate = s.deviceCA.CertificatePEM()
+	authInfo.CertificateSerial = certificate.SerialNumber
+	authInfo.CertificateExpiresAt = &certificate.NotAfter
+	if _, port, err := net.SplitHostPort(config.C.IoT.Gateway.EmbeddedBroker.TLSAddress); err == nil {
+		authInfo.MqttTlsPort, _ = strconv.Atoi(port)
+	}
+	return nil
+}
+
+// RenewCertificate 为 X.509 设备重新签发证书，旧证书吊销并进入 CRL
+func (s *DeviceService) RenewCertificate(ctx context.Context, id int64) error {
+	device, err := s.deviceRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if device == nil {
+		return model.ErrDeviceNotExists
+	}
+	if s.IsSecretAuth(device) {
+		return model.ErrDeviceAuthTypeNotX509
+	}
+	if err := s.certRepo.RevokeByDeviceIDs(ctx, []int64{id}, deviceCertificateRevokeReasonRenewed); err != nil {
+		return err
+	}
+	_, err = s.issueCertificate(ctx, device)
+	return err
+}
+
+// AuthByCertificate 使用 TLS 客户端证书认证设备
+// 证书须由设备 CA 签发且在有效期内，按指纹匹配到未吊销的证书记录，主题 CN 与设备一致
+func (s *DeviceService) AuthByCertificate(ctx context.Context, cert *x509.Certificate) (*model.IotDeviceDO, error) {
+	if err := s.deviceCA.Verify(cert); err != nil {
+		return nil, model.ErrDeviceCertificateInvalid
+	}
+	certificate, err := s.certRepo.GetByFingerprint(ctx, iotcore.CertificateFingerprint(cert))
+	if err != nil {
+		return nil, err
+	}
+	if certificate == nil || certificate.Status != consts.IotDeviceCertificateStatusValid {
+		return nil, model.ErrDeviceCertificateInvalid
+	}
+	device, err := s.deviceRepo.GetByID(ctx, certificate.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device == nil {
+		return nil, model.ErrDeviceNotExists
+	}
+	if s.IsSecretAuth(device) || cert.Subject.CommonName != iotcore.BuildCertificateSubject(device.ProductKey, device.DeviceName) {
+		return nil, model.ErrDeviceCertificateInvalid
+	}
+	return device, nil
+}
+
+// GetCACertificate 获取设备 CA 证书（PEM）
+func (s *DeviceService) GetCACertificate() string {
+	return s.deviceCA.CertificatePEM()
+}
+
+// GetCACertPool 获取仅包含设备 CA 的证书池，用于 TLS 校验设备客户端证书
+func (s *DeviceService) GetCACertPool() *x509.CertPool {
+	return s.deviceCA.CertPool()
+}
+
+// GetCRL 按已吊销的设备证书生成证书吊销列表（PEM）
+// CRL 序号取生成时间的秒级时间戳，保证单调递增
+func (s *DeviceService) GetCRL(ctx context.Context) ([]byte, error) {
+	revoked, err := s.certRepo.ListRevoked(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]x509.RevocationListEntry, 0, len(revoked))
+	for _, certificate := range revoked {
+		serial, ok := iotcore.ParseSerialNumber(certificate.SerialNumber)
+		if !ok {
+			continue
+		}
+		revocationTime := certificate.UpdateTime
+		if certificate.RevokeTime != nil {
+			revocationTime = *certificate.RevokeTime
+		}
+		entries = append(entries, x509.RevocationListEntry{
+			SerialNumber:   serial,
+			RevocationTime: revocationTime,
+		})
+	}
+	return s.deviceCA.CreateCRL(entries, time.Now().Unix())
+}
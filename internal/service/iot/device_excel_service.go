@@ -0,0 +1,334 @@
+package iot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/shopspring/decimal"
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
+	"gorm.io/datatypes"
+)
+
+const (
+	// deviceImportResultCreate 导入结果：新增
+	deviceImportResultCreate = "create"
+	// deviceImportResultUpdate 导入结果：更新
+	deviceImportResultUpdate = "update"
+	// deviceImportResultFailure 导入结果：失败
+	deviceImportResultFailure = "failure"
+
+	// deviceImportQueryBatchSize 按设备名称批量查询已存在设备的批次大小
+	deviceImportQueryBatchSize = 500
+)
+
+// deviceImportRow 校验通过的导入行
+type deviceImportRow struct {
+	rowNo     int
+	product   *model.IotProductDO
+	excel     *iot2.IotDeviceImportExcelVO
+	groupIDs  []int64
+	latitude  *decimal.Decimal
+	longitude *decimal.Decimal
+}
+
+// GetImportTemplate 获得设备导入模板
+func (s *DeviceService) GetImportTemplate() []iot2.IotDeviceImportExcelVO {
+	return []iot2.IotDeviceImportExcelVO{
+		{
+			ProductKey:   "a1b2c3d4e5",
+			DeviceName:   "device-0001",
+			Nickname:     "1 号温湿度计",
+			SerialNumber: "SN0001",
+			GroupNames:   "一楼,机房",
+			Latitude:     "31.230416",
+			Longitude:    "121.473701",
+		},
+		{
+			ProductKey: "a1b2c3d4e5",
+			DeviceName: "device-0002",
+		},
+	}
+}
+
+// ImportDevices 导入设备，逐行校验并返回每行的导入结果
+// updateSupport 为 true 时，已存在的设备更新备注名称、序列号、分组与坐标；否则记为失败
+func (s *DeviceService) ImportDevices(ctx context.Context, list []iot2.IotDeviceImportExcelVO, updateSupport bool) (*iot2.IotDeviceImportRespVO, error) {
+	if len(list) == 0 {
+		return nil, model.ErrDeviceImportListIsEmpty
+	}
+
+	// 1. 加载产品与分组，用于校验产品标识并将分组名称转换为编号
+	products, err := s.productRepo.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	productMap := make(map[string]*model.IotProductDO, len(products))
+	for _, product := range products {
+		productMap[product.ProductKey] = product
+	}
+	groups, err := s.deviceGroupRepo.ListByStatus(ctx, consts.CommonStatusEnable)
+	if err != nil {
+		return nil, err
+	}
+	groupMap := make(map[string]int64, len(groups))
+	for _, group := range groups {
+		groupMap[group.Name] = group.ID
+	}
+
+	resp := &iot2.IotDeviceImportRespVO{
+		CreateDeviceNames:  []string{},
+		UpdateDeviceNames:  []string{},
+		FailureDeviceNames: map[string]string{},
+		Rows:               make([]*iot2.IotDeviceImportRowRespVO, 0, len(list)),
+	}
+	report := func(rowNo int, excel *iot2.IotDeviceImportExcelVO, result string, err error) {
+		row := &iot2.IotDeviceImportRowRespVO{
+			RowNo:      rowNo,
+			ProductKey: excel.ProductKey,
+			DeviceName: excel.DeviceName,
+			Result:     result,
+		}
+		switch result {
+		case deviceImportResultCreate:
+			resp.CreateDeviceNames = append(resp.CreateDeviceNames, excel.DeviceName)
+		case deviceImportResultUpdate:
+			resp.UpdateDeviceNames = append(resp.UpdateDeviceNames, excel.DeviceName)
+		default:
+			_, row.Msg = toReplyError(err)
+			name := excel.DeviceName
+			if name == "" {
+				name = fmt.Sprintf("第 %d 行", rowNo)
+			}
+			resp.FailureDeviceNames[name] = row.Msg
+		}
+		resp.Rows = append(resp.Rows, row)
+	}
+
+	// 2. 逐行校验，同一文件内的重复设备只导入第一行
+	rows := make([]*deviceImportRow, 0, len(list))
+	seen := make(map[string]int)
+	for i := range list {
+		excel := &list[i]
+		rowNo := i + 2 // 第 1 行为表头
+		if *excel == (iot2.IotDeviceImportExcelVO{}) {
+			continue
+		}
+		row, err := validateDeviceImportRow(excel, productMap, groupMap)
+		if err != nil {
+			report(rowNo, excel, deviceImportResultFailure, err)
+			continue
+		}
+		key := excel.ProductKey + "/" + excel.DeviceName
+		if firstRowNo, ok := seen[key]; ok {
+			report(rowNo, excel, deviceImportResultFailure,
+				errors.NewBizError(errors.ParamErrCode, fmt.Sprintf("与第 %d 行的设备重复", firstRowNo)))
+			continue
+		}
+		seen[key] = rowNo
+		row.rowNo = rowNo
+		rows = append(rows, row)
+	}
+
+	// 3. 批量查询已存在的设备
+	existing, err := s.getImportExistingDevices(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. 新增或更新设备
+	for _, row := range rows {
+		device := existing[row.excel.ProductKey+"/"+row.excel.DeviceName]
+		if device == nil {
+			if _, err := s.Create(ctx, row.toSaveReqVO()); err != nil {
+				report(row.rowNo, row.excel, deviceImportResultFailure, err)
+				continue
+			}
+			report(row.rowNo, row.excel, deviceImportResultCreate, nil)
+			continue
+		}
+		if !updateSupport {
+			report(row.rowNo, row.excel, deviceImportResultFailure, model.ErrDeviceNameExists)
+			continue
+		}
+		row.applyTo(device)
+		if err := s.deviceRepo.Update(ctx, device); err != nil {
+			report(row.rowNo, row.excel, deviceImportResultFailure, err)
+			continue
+		}
+		report(row.rowNo, row.excel, deviceImportResultUpdate, nil)
+	}
+
+	// 逐行结果按 Excel 行号排列
+	slices.SortFunc(resp.Rows, func(a, b *iot2.IotDeviceImportRowRespVO) int { return a.RowNo - b.RowNo })
+	return resp, nil
+}
+
+// GetExportList 获得设备导出列表，包含设备密钥，分组以名称展示
+func (s *DeviceService) GetExportList(ctx context.Context, r *iot2.IotDeviceExportReqVO) ([]*iot2.IotDeviceExcelVO, error) {
+	devices, err := s.deviceRepo.GetList(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	groupNames := make(map[int64]string)
+	for _, status := range []int8{consts.CommonStatusEnable, consts.CommonStatusDisable} {
+		groups, err := s.deviceGroupRepo.ListByStatus(ctx, status)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range groups {
+			groupNames[group.ID] = group.Name
+		}
+	}
+
+	list := make([]*iot2.IotDeviceExcelVO, 0, len(devices))
+	for _, device := range devices {
+		var groupIDs []int64
+		if len(device.GroupIDs) > 0 {
+			_ = json.Unmarshal(device.GroupIDs, &groupIDs)
+		}
+		names := make([]string, 0, len(groupIDs))
+		for _, groupID := range groupIDs {
+			if name, ok := groupNames[groupID]; ok {
+				names = append(names, name)
+			}
+		}
+		vo := &iot2.IotDeviceExcelVO{
+			ID:           device.ID,
+			ProductKey:   device.ProductKey,
+			DeviceName:   device.DeviceName,
+			Nickname:     device.Nickname,
+			SerialNumber: device.SerialNumber,
+			DeviceSecret: device.DeviceSecret,
+			AuthType:     normalizeDeviceAuthType(device.AuthType),
+			GroupNames:   strings.Join(names, ","),
+			State:        deviceStateName(device.State),
+			ActiveTime:   device.ActiveTime,
+			CreateTime:   device.CreateTime,
+		}
+		if device.Latitude != nil && device.Longitude != nil {
+			vo.Latitude, vo.Longitude = device.Latitude.String(), device.Longitude.String()
+		}
+		list = append(list, vo)
+	}
+	return list, nil
+}
+
+// deviceStateName 设备状态名称
+func deviceStateName(state int8) string {
+	switch state {
+	case consts.IotDeviceStateOnline:
+		return "在线"
+	case consts.IotDeviceStateOffline:
+		return "离线"
+	default:
+		return "未激活"
+	}
+}
+
+// getImportExistingDevices 按产品分批查询导入行中已存在的设备，key: productKey/deviceName
+func (s *DeviceService) getImportExistingDevices(ctx context.Context, rows []*deviceImportRow) (map[string]*model.IotDeviceDO, error) {
+	namesByProduct := make(map[string][]string)
+	for _, row := range rows {
+		namesByProduct[row.excel.ProductKey] = append(namesByProduct[row.excel.ProductKey], row.excel.DeviceName)
+	}
+	existing := make(map[string]*model.IotDeviceDO)
+	for productKey, names := range namesByProduct {
+		for start := 0; start < len(names); start += deviceImportQueryBatchSize {
+			end := min(start+deviceImportQueryBatchSize, len(names))
+			devices, err := s.deviceRepo.ListByProductKeyAndNames(ctx, productKey, names[start:end])
+			if err != nil {
+				return nil, err
+			}
+			for _, device := range devices {
+				existing[device.ProductKey+"/"+device.DeviceName] = device
+			}
+		}
+	}
+	return existing, nil
+}
+
+// validateDeviceImportRow 校验导入行：必填项、设备名称格式、产品、分组与坐标
+func validateDeviceImportRow(excel *iot2.IotDeviceImportExcelVO, productMap map[string]*model.IotProductDO,
+	groupMap map[string]int64) (*deviceImportRow, error) {
+	if excel.ProductKey == "" {
+		return nil, errors.NewBizError(errors.ParamErrCode, "产品标识不能为空")
+	}
+	if excel.DeviceName == "" {
+		return nil, errors.NewBizError(errors.ParamErrCode, "设备名称不能为空")
+	}
+	if !deviceNamePattern.MatchString(excel.DeviceName) {
+		return nil, model.ErrDeviceRegisterNameInvalid
+	}
+	product := productMap[excel.ProductKey]
+	if product == nil {
+		return nil, model.ErrProductNotExists
+	}
+	row := &deviceImportRow{product: product, excel: excel}
+
+	// 分组名称，支持中英文逗号分隔
+	for _, name := range strings.FieldsFunc(excel.GroupNames, func(r rune) bool { return r == ',' || r == '，' }) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		groupID, ok := groupMap[name]
+		if !ok {
+			return nil, errors.NewBizError(errors.ParamErrCode, fmt.Sprintf("设备分组【%s】不存在或已禁用", name))
+		}
+		row.groupIDs = append(row.groupIDs, groupID)
+	}
+
+	// 经纬度需同时填写
+	if excel.Latitude != "" || excel.Longitude != "" {
+		latitude, latErr := strconv.ParseFloat(excel.Latitude, 64)
+		longitude, lngErr := strconv.ParseFloat(excel.Longitude, 64)
+		if latErr != nil || lngErr != nil || !validCoordinate(latitude, longitude) {
+			return nil, errors.NewBizError(errors.ParamErrCode, "经纬度需同时填写，且纬度范围为 -90~90、经度范围为 -180~180")
+		}
+		lat, lng := decimal.NewFromFloat(latitude), decimal.NewFromFloat(longitude)
+		row.latitude, row.longitude = &lat, &lng
+	}
+	return row, nil
+}
+
+// toSaveReqVO 转换为设备创建请求：填写坐标时为手动定位，否则沿用产品的定位方式
+func (row *deviceImportRow) toSaveReqVO() *iot2.IotDeviceSaveReqVO {
+	r := &iot2.IotDeviceSaveReqVO{
+		DeviceName:   row.excel.DeviceName,
+		Nickname:     row.excel.Nickname,
+		SerialNumber: row.excel.SerialNumber,
+		GroupIDs:     row.groupIDs,
+		ProductID:    row.product.ID,
+		LocationType: row.product.LocationType,
+	}
+	if row.latitude != nil {
+		r.LocationType = consts.IotLocationTypeManual
+		r.Latitude, r.Longitude = row.latitude, row.longitude
+	}
+	return r
+}
+
+// applyTo 将导入行写入已存在的设备，未填写的列保持不变
+func (row *deviceImportRow) applyTo(device *model.IotDeviceDO) {
+	if row.excel.Nickname != "" {
+		device.Nickname = row.excel.Nickname
+	}
+	if row.excel.SerialNumber != "" {
+		device.SerialNumber = row.excel.SerialNumber
+	}
+	if len(row.groupIDs) > 0 {
+		groupIdsJson, _ := json.Marshal(row.groupIDs)
+		device.GroupIDs = datatypes.JSON(groupIdsJson)
+	}
+	if row.latitude != nil {
+		device.LocationType = consts.IotLocationTypeManual
+		device.Latitude, device.Longitude = row.latitude, row.longitude
+	}
+}
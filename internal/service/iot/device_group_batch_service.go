@@ -0,0 +1,131 @@
+package iot
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	iotcore "github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+)
+
+// deviceGroupBatchConcurrency 分组批量下发时同时等待回复的设备数
+const deviceGroupBatchConcurrency = 32
+
+// DeviceGroupBatchService 设备分组批量下发服务
+// 向分组内的每台设备下发属性设置或服务调用，等待设备回复后汇总逐台结果
+type DeviceGroupBatchService struct {
+	deviceGroupRepo  DeviceGroupRepository
+	deviceRepo       DeviceRepository
+	deviceMessageSvc *DeviceMessageService
+}
+
+func NewDeviceGroupBatchService(
+	deviceGroupRepo DeviceGroupRepository,
+	deviceRepo DeviceRepository,
+	deviceMessageSvc *DeviceMessageService,
+) *DeviceGroupBatchService {
+	return &DeviceGroupBatchService{
+		deviceGroupRepo:  deviceGroupRepo,
+		deviceRepo:       deviceRepo,
+		deviceMessageSvc: deviceMessageSvc,
+	}
+}
+
+// BatchSend 向分组内的设备批量下发属性设置或服务调用
+// 不在线的设备不下发，直接记为失败；其余设备并发下发，单台设备超时或回复错误不影响其它设备
+func (s *DeviceGroupBatchService) BatchSend(ctx context.Context, r *iot2.IotDeviceGroupBatchReqVO) (*iot2.IotDeviceGroupBatchRespVO, error) {
+	switch r.Method {
+	case consts.IotDeviceMessageMethodPropertySet:
+		if len(r.Params) == 0 {
+			return nil, model.ErrDeviceGroupBatchParamsNull
+		}
+	case consts.IotDeviceMessageMethodServiceInvoke:
+		if r.Identifier == "" {
+			return nil, model.ErrDeviceGroupBatchIdentifierNull
+		}
+	default:
+		return nil, model.ErrDeviceGroupBatchMethodInvalid
+	}
+	group, err := s.deviceGroupRepo.GetByID(ctx, r.GroupID)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, model.ErrDeviceGroupNotExists
+	}
+	devices, err := s.deviceRepo.ListByGroupID(ctx, r.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := time.Duration(r.Timeout) * time.Second
+	results := make([]*iot2.IotDeviceGroupBatchResultVO, len(devices))
+	sem := make(chan struct{}, deviceGroupBatchConcurrency)
+	var wg sync.WaitGroup
+	for i, device := range devices {
+		result := &iot2.IotDeviceGroupBatchResultVO{DeviceID: device.ID, DeviceName: device.DeviceName}
+		results[i] = result
+		if device.State != consts.IotDeviceStateOnline {
+			result.Code, result.Msg = toReplyError(model.ErrDeviceOffline)
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			s.sendToDevice(ctx, r, timeout, result)
+		}()
+	}
+	wg.Wait()
+
+	resp := &iot2.IotDeviceGroupBatchRespVO{Total: len(results), Results: results}
+	for _, result := range results {
+		if result.Success {
+			resp.SuccessCount++
+		} else {
+			resp.FailureCount++
+		}
+	}
+	log.Printf("[DeviceGroupBatchService] Batch %s to group %d: total=%d, success=%d, failure=%d",
+		r.Method, r.GroupID, resp.Total, resp.SuccessCount, resp.FailureCount)
+	return resp, nil
+}
+
+// sendToDevice 向单台设备下发并等待回复，结果写入 result
+func (s *DeviceGroupBatchService) sendToDevice(ctx context.Context, r *iot2.IotDeviceGroupBatchReqVO, timeout time.Duration, result *iot2.IotDeviceGroupBatchResultVO) {
+	var output any
+	var err error
+	if r.Method == consts.IotDeviceMessageMethodServiceInvoke {
+		result.RequestID, output, err = s.deviceMessageSvc.InvokeDeviceService(ctx, result.DeviceID, r.Identifier, r.Params, timeout)
+	} else {
+		message := &iotcore.IotDeviceMessage{
+			ID:       generateMessageID(),
+			Method:   consts.IotDeviceMessageMethodPropertySet,
+			DeviceID: result.DeviceID,
+			Params:   r.Params,
+		}
+		result.RequestID = message.ID
+		var reply *iotcore.IotDeviceMessage
+		if reply, err = s.deviceMessageSvc.SendDeviceMessageAndWait(ctx, message, timeout); err == nil {
+			// 设备回复错误码时，保留设备的错误码与错误信息
+			if reply.Code != nil && *reply.Code != 0 {
+				result.Code, result.Msg = *reply.Code, reply.Msg
+				return
+			}
+			output = reply.Data
+		}
+	}
+	if err != nil {
+		result.Code, result.Msg = toReplyError(err)
+		return
+	}
+	result.Success = true
+	result.Output = output
+}
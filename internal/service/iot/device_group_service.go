@@ -5,7 +5,6 @@ import (
 
 	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
 	"github.com/wxlbd/ruoyi-mall-go/internal/model"
-	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
 )
 
@@ -37,7 +36,7 @@ func (s *DeviceGroupService) Update(ctx context.Context, r *iot2.IotDeviceGroupS
 		return err
 	}
 	if group == nil {
-		return errors.NewBizError(1050001000, "设备分组不存在")
+		return model.ErrDeviceGroupNotExists
 	}
 	group.Name = r.Name
 	group.Status = r.Status
@@ -0,0 +1,144 @@
+package iot
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/shopspring/decimal"
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	iotcore "github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
+)
+
+// DeviceLocationService 设备位置服务
+// 处理设备位置上报：记录轨迹、更新设备坐标，并判定进出地理围栏
+type DeviceLocationService struct {
+	deviceLocationRepo DeviceLocationRepository
+	deviceRepo         DeviceRepository
+	geofenceSvc        *GeofenceService
+	messageBus         iotcore.MessageBus
+}
+
+func NewDeviceLocationService(
+	deviceLocationRepo DeviceLocationRepository,
+	deviceRepo DeviceRepository,
+	geofenceSvc *GeofenceService,
+	messageBus iotcore.MessageBus,
+) *DeviceLocationService {
+	return &DeviceLocationService{
+		deviceLocationRepo: deviceLocationRepo,
+		deviceRepo:         deviceRepo,
+		geofenceSvc:        geofenceSvc,
+		messageBus:         messageBus,
+	}
+}
+
+// HandleLocationPost 处理位置上报
+// params: latitude、longitude 必填，time 为可选的定位时间（毫秒时间戳），缺省取消息上报时间
+func (s *DeviceLocationService) HandleLocationPost(ctx context.Context, message *iotcore.IotDeviceMessage, device *model.IotDeviceDO) error {
+	latitude, latOk := toThingModelNumber(message.Params["latitude"])
+	longitude, lngOk := toThingModelNumber(message.Params["longitude"])
+	if !latOk || !lngOk || !validCoordinate(latitude, longitude) {
+		return errors.NewBizError(errors.ParamErrCode, "位置坐标不正确")
+	}
+	reportTime := message.ReportTime
+	if millis, ok := toThingModelNumber(message.Params["time"]); ok && millis > 0 {
+		reportTime = time.UnixMilli(int64(millis))
+	}
+	if reportTime.IsZero() {
+		reportTime = time.Now()
+	}
+
+	// 1. 上一个轨迹点，用于判定进出围栏
+	previous, err := s.deviceLocationRepo.GetLatestByDeviceID(ctx, device.ID)
+	if err != nil {
+		return err
+	}
+
+	// 2. 记录轨迹
+	location := &model.IotDeviceLocationDO{
+		DeviceID:   device.ID,
+		Latitude:   latitude,
+		Longitude:  longitude,
+		ReportTime: reportTime,
+	}
+	location.TenantID = device.TenantID
+	if err := s.deviceLocationRepo.Create(ctx, location); err != nil {
+		return err
+	}
+
+	// 3. 更新设备坐标（手动定位的设备保留人工设置的坐标）
+	if device.LocationType != consts.IotLocationTypeManual {
+		if err := s.deviceRepo.UpdateLocation(ctx, device.ID,
+			decimal.NewFromFloat(latitude), decimal.NewFromFloat(longitude)); err != nil {
+			return err
+		}
+	}
+
+	// 4. 判定进出地理围栏
+	s.evaluateGeofences(ctx, device, previous, location)
+	return nil
+}
+
+// evaluateGeofences 对比上一个轨迹点与当前轨迹点，发布进出围栏事件
+// 没有上一个轨迹点时视为位于所有围栏之外
+func (s *DeviceLocationService) evaluateGeofences(ctx context.Context, device *model.IotDeviceDO, previous, current *model.IotDeviceLocationDO) {
+	geofences, err := s.geofenceSvc.GetDeviceGeofences(ctx, device)
+	if err != nil {
+		log.Printf("[DeviceLocationService] Get geofences failed: deviceId=%d, err=%v", device.ID, err)
+		return
+	}
+	for _, g := range geofences {
+		wasInside := previous != nil && g.Contains(previous.Latitude, previous.Longitude)
+		isInside := g.Contains(current.Latitude, current.Longitude)
+		switch {
+		case !wasInside && isInside:
+			s.postGeofenceEvent(device, g, consts.IotGeofenceEventEnter, current)
+		case wasInside && !isInside:
+			s.postGeofenceEvent(device, g, consts.IotGeofenceEventExit, current)
+		}
+	}
+}
+
+// postGeofenceEvent 以设备上行消息的形式发布进出围栏事件，供场景联动与告警触发
+func (s *DeviceLocationService) postGeofenceEvent(device *model.IotDeviceDO, g *geofence, event string, location *model.IotDeviceLocationDO) {
+	log.Printf("[DeviceLocationService] Device %s geofence: deviceId=%d, geofenceId=%d", event, device.ID, g.ID)
+	s.messageBus.Post(iotcore.DeviceMessageTopic, &iotcore.IotDeviceMessage{
+		ID:       generateMessageID(),
+		Method:   consts.IotDeviceMessageMethodGeofencePost,
+		DeviceID: device.ID,
+		TenantID: device.TenantID,
+		Params: map[string]any{
+			"geofenceId":   g.ID,
+			"geofenceName": g.Name,
+			"type":         event,
+			"latitude":     location.Latitude,
+			"longitude":    location.Longitude,
+		},
+		ReportTime: time.Now(),
+	})
+}
+
+// GetTrack 获取设备位置轨迹，按上报时间升序
+func (s *DeviceLocationService) GetTrack(ctx context.Context, r *iot2.IotDeviceLocationTrackReqVO) ([]*iot2.IotDeviceLocationRespVO, error) {
+	var startTime, endTime *time.Time
+	if len(r.Times) == 2 {
+		startTime, endTime = r.Times[0], r.Times[1]
+	}
+	list, err := s.deviceLocationRepo.GetListByDeviceID(ctx, r.DeviceID, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*iot2.IotDeviceLocationRespVO, 0, len(list))
+	for _, location := range list {
+		result = append(result, &iot2.IotDeviceLocationRespVO{
+			Latitude:   location.Latitude,
+			Longitude:  location.Longitude,
+			ReportTime: location.ReportTime,
+		})
+	}
+	return result, nil
+}
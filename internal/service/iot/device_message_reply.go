@@ -0,0 +1,221 @@
+package iot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	iotcore "github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
+)
+
+const (
+	// deviceReplyDefaultTimeout 等待设备回复的默认超时时间
+	deviceReplyDefaultTimeout = 10 * time.Second
+	// deviceReplyMaxTimeout 等待设备回复的最大超时时间
+	deviceReplyMaxTimeout = 60 * time.Second
+	// deviceReplyPollInterval 查询回复日志的间隔：回复被其它节点消费时，只能通过消息日志获取
+	deviceReplyPollInterval = 500 * time.Millisecond
+)
+
+// DeviceServiceInvokeCallback 设备服务异步调用回调，output 为设备回复的输出参数
+type DeviceServiceInvokeCallback func(requestID string, output any, err error)
+
+// deviceReplyRegistry 本节点等待中的下行请求，key: requestId
+// 设备回复由本节点消费时直接唤醒等待方，否则由等待方轮询消息日志
+type deviceReplyRegistry struct {
+	mu      sync.Mutex
+	waiters map[string]chan *iotcore.IotDeviceMessage
+}
+
+func newDeviceReplyRegistry() *deviceReplyRegistry {
+	return &deviceReplyRegistry{waiters: make(map[string]chan *iotcore.IotDeviceMessage)}
+}
+
+// register 登记等待回复的请求
+func (r *deviceReplyRegistry) register(requestID string) chan *iotcore.IotDeviceMessage {
+	ch := make(chan *iotcore.IotDeviceMessage, 1)
+	r.mu.Lock()
+	r.waiters[requestID] = ch
+	r.mu.Unlock()
+	return ch
+}
+
+// unregister 取消登记（收到回复或超时后）
+func (r *deviceReplyRegistry) unregister(requestID string) {
+	r.mu.Lock()
+	delete(r.waiters, requestID)
+	r.mu.Unlock()
+}
+
+// resolve 唤醒等待该回复的请求，无等待方时返回 false
+func (r *deviceReplyRegistry) resolve(reply *iotcore.IotDeviceMessage) bool {
+	r.mu.Lock()
+	ch, ok := r.waiters[reply.RequestID]
+	delete(r.waiters, reply.RequestID)
+	r.mu.Unlock()
+	if !ok {
+		return false
+	}
+	ch <- reply
+	return true
+}
+
+// InvokeDeviceService 同步调用设备服务：下发 thing.service.invoke 并等待设备回复
+// 返回设备回复的输出参数；设备回复错误码时返回其错误，超时未回复返回 ErrDeviceServiceInvokeTimeout
+func (s *DeviceMessageService) InvokeDeviceService(ctx context.Context, deviceID int64, identifier string, inputParams map[string]any, timeout time.Duration) (string, any, error) {
+	message := buildServiceInvokeMessage(deviceID, identifier, inputParams)
+	reply, err := s.SendDeviceMessageAndWait(ctx, message, timeout)
+	if err != nil {
+		return message.RequestID, nil, err
+	}
+	output, err := parseServiceInvokeReply(reply)
+	return message.RequestID, output, err
+}
+
+// InvokeDeviceServiceAsync 异步调用设备服务：下发后立即返回 requestId，收到回复或超时后执行 callback
+func (s *DeviceMessageService) InvokeDeviceServiceAsync(ctx context.Context, deviceID int64, identifier string, inputParams map[string]any, timeout time.Duration, callback DeviceServiceInvokeCallback) (string, error) {
+	message := buildServiceInvokeMessage(deviceID, identifier, inputParams)
+	// 回调晚于当前请求结束，不能随请求上下文一起取消
+	waitCtx := context.WithoutCancel(ctx)
+	waitReply, err := s.sendAndRegisterReply(waitCtx, message)
+	if err != nil {
+		return "", err
+	}
+	go func() {
+		reply, err := waitReply(timeout)
+		var output any
+		if err == nil {
+			output, err = parseServiceInvokeReply(reply)
+		}
+		callback(message.RequestID, output, err)
+	}()
+	return message.RequestID, nil
+}
+
+// SendDeviceMessageAndWait 下发消息并等待设备回复（按 requestId 关联），适用于任意需要回复的下行请求
+func (s *DeviceMessageService) SendDeviceMessageAndWait(ctx context.Context, message *iotcore.IotDeviceMessage, timeout time.Duration) (*iotcore.IotDeviceMessage, error) {
+	waitReply, err := s.sendAndRegisterReply(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	return waitReply(timeout)
+}
+
+// sendAndRegisterReply 先登记等待再下发，避免设备回复早于登记；返回等待回复的函数
+func (s *DeviceMessageService) sendAndRegisterReply(ctx context.Context, message *iotcore.IotDeviceMessage) (func(timeout time.Duration) (*iotcore.IotDeviceMessage, error), error) {
+	device, err := s.deviceRepo.GetByID(ctx, message.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device == nil {
+		return nil, model.ErrDeviceNotExists
+	}
+	if message.ID == "" {
+		message.ID = generateMessageID()
+	}
+	if message.RequestID == "" {
+		message.RequestID = message.ID
+	}
+
+	ch := s.replyRegistry.register(message.RequestID)
+	if err := s.sendDeviceMessageInternal(ctx, message, device, ""); err != nil {
+		s.replyRegistry.unregister(message.RequestID)
+		return nil, err
+	}
+	return func(timeout time.Duration) (*iotcore.IotDeviceMessage, error) {
+		defer s.replyRegistry.unregister(message.RequestID)
+		return s.waitDeviceReply(ctx, message, ch, timeout)
+	}, nil
+}
+
+// waitDeviceReply 等待设备回复：本节点消费到回复时直接返回，否则定时查询其它节点记录的回复日志
+func (s *DeviceMessageService) waitDeviceReply(ctx context.Context, message *iotcore.IotDeviceMessage, ch chan *iotcore.IotDeviceMessage, timeout time.Duration) (*iotcore.IotDeviceMessage, error) {
+	if timeout <= 0 {
+		timeout = deviceReplyDefaultTimeout
+	}
+	if timeout > deviceReplyMaxTimeout {
+		timeout = deviceReplyMaxTimeout
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(deviceReplyPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case reply := <-ch:
+			return reply, nil
+		case <-ticker.C:
+			replies, err := s.deviceMessageRepo.GetListByRequestIdsAndReply(ctx, message.DeviceID, []string{message.RequestID}, true)
+			if err != nil {
+				log.Printf("[DeviceMessageService] Query reply of request %s failed: %v", message.RequestID, err)
+				continue
+			}
+			if len(replies) > 0 {
+				return buildReplyFromLog(replies[0]), nil
+			}
+		case <-timer.C:
+			log.Printf("[DeviceMessageService] Wait reply timeout: deviceId=%d, method=%s, requestId=%s",
+				message.DeviceID, message.Method, message.RequestID)
+			return nil, model.ErrDeviceServiceInvokeTimeout
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// buildServiceInvokeMessage 构建服务调用消息，报文格式: {"identifier": "reboot", "inputParams": {...}}
+func buildServiceInvokeMessage(deviceID int64, identifier string, inputParams map[string]any) *iotcore.IotDeviceMessage {
+	if inputParams == nil {
+		inputParams = map[string]any{}
+	}
+	return &iotcore.IotDeviceMessage{
+		ID:       generateMessageID(),
+		Method:   consts.IotDeviceMessageMethodServiceInvoke,
+		DeviceID: deviceID,
+		Params: map[string]any{
+			"identifier":  identifier,
+			"inputParams": inputParams,
+		},
+	}
+}
+
+// parseServiceInvokeReply 解析服务调用回复：错误码非 0 时返回设备的错误信息，否则返回输出参数
+func parseServiceInvokeReply(reply *iotcore.IotDeviceMessage) (any, error) {
+	if reply.Code != nil && *reply.Code != 0 {
+		msg := reply.Msg
+		if msg == "" {
+			msg = fmt.Sprintf("code=%d", *reply.Code)
+		}
+		return nil, errors.NewBizError(model.ErrDeviceServiceInvokeFail.Code,
+			fmt.Sprintf("%s：%s", model.ErrDeviceServiceInvokeFail.Msg, msg))
+	}
+	return reply.Data, nil
+}
+
+// buildReplyFromLog 将消息日志中的回复还原为设备消息
+func buildReplyFromLog(do *model.IotDeviceMessageDO) *iotcore.IotDeviceMessage {
+	reply := &iotcore.IotDeviceMessage{
+		ID:         do.ID,
+		RequestID:  do.RequestID,
+		Method:     do.Method,
+		Code:       do.Code,
+		Msg:        do.Msg,
+		DeviceID:   do.DeviceID,
+		ServerID:   do.ServerID,
+		ReportTime: time.UnixMilli(do.ReportTime),
+	}
+	if do.Data != "" {
+		var data any
+		if err := json.Unmarshal([]byte(do.Data), &data); err == nil {
+			reply.Data = data
+		}
+	}
+	return reply
+}
@@ -3,6 +3,7 @@ package iot
 import (
 	"context"
 	"encoding/json"
+	stderrors "errors"
 	"log"
 	"strings"
 	"time"
@@ -12,33 +13,60 @@ import (
 	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
 	iotcore "github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
 	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
 )
 
 type DeviceMessageService struct {
 	deviceMessageRepo DeviceMessageRepository
 	deviceRepo        DeviceRepository
+	deviceSvc         *DeviceService
 	devicePropertySvc *DevicePropertyService
+	thingModelSvc     *ThingModelService
+	deviceShadowSvc   *DeviceShadowService
 	otaTaskSvc        *OtaTaskService
+	deviceLocationSvc *DeviceLocationService
+	streamSvc         *DeviceMessageStreamService
 	messageBus        iotcore.MessageBus
+
+	// subDeviceSessionMgr 子设备会话管理，由网关启动时设置
+	subDeviceSessionMgr SubDeviceSessionManager
+	// replyRegistry 等待设备回复的下行请求
+	replyRegistry *deviceReplyRegistry
 }
 
 func NewDeviceMessageService(
 	deviceMessageRepo DeviceMessageRepository,
 	deviceRepo DeviceRepository,
+	deviceSvc *DeviceService,
 	devicePropertySvc *DevicePropertyService,
+	thingModelSvc *ThingModelService,
+	deviceShadowSvc *DeviceShadowService,
 	otaTaskSvc *OtaTaskService,
+	deviceLocationSvc *DeviceLocationService,
+	streamSvc *DeviceMessageStreamService,
 	messageBus iotcore.MessageBus,
 ) *DeviceMessageService {
 	return &DeviceMessageService{
 		deviceMessageRepo: deviceMessageRepo,
 		deviceRepo:        deviceRepo,
+		deviceSvc:         deviceSvc,
 		devicePropertySvc: devicePropertySvc,
+		thingModelSvc:     thingModelSvc,
+		deviceShadowSvc:   deviceShadowSvc,
 		otaTaskSvc:        otaTaskSvc,
+		deviceLocationSvc: deviceLocationSvc,
+		streamSvc:         streamSvc,
 		messageBus:        messageBus,
+		replyRegistry:     newDeviceReplyRegistry(),
 	}
 }
 
+// SetSubDeviceSessionManager 设置子设备会话管理
+func (s *DeviceMessageService) SetSubDeviceSessionManager(mgr SubDeviceSessionManager) {
+	s.subDeviceSessionMgr = mgr
+}
+
 // GetDeviceMessagePage 分页查询设备消息
 func (s *DeviceMessageService) GetDeviceMessagePage(ctx context.Context, req *iot.IotDeviceMessagePageReqVO) (*pagination.PageResult[*model.IotDeviceMessageDO], error) {
 	return s.deviceMessageRepo.GetPage(ctx, req)
@@ -126,8 +154,9 @@ func (s *DeviceMessageService) sendDeviceMessageInternal(ctx context.Context, me
 	}
 	s.messageBus.Post(gatewayTopic, command)
 
-	// 记录下行消息日志（异步）
-	go s.createDeviceLogAsync(ctx, message)
+	// 记录下行消息日志（异步），并推送到实时流
+	go s.createDeviceLogAsync(ctx, message, nil)
+	s.streamSvc.Publish(message, device, nil)
 
 	return nil
 }
@@ -137,6 +166,11 @@ func (s *DeviceMessageService) HandleUpstreamDeviceMessage(ctx context.Context,
 	log.Printf("[DeviceMessageService] Processing upstream message: deviceId=%d, method=%s",
 		device.ID, message.Method)
 
+	// 0. 下行请求的回复：唤醒本节点等待该回复的调用方
+	if isReplyMessage(message.Method) {
+		s.replyRegistry.resolve(message)
+	}
+
 	// 1. 处理消息
 	var replyData any
 	var err error
@@ -147,15 +181,38 @@ func (s *DeviceMessageService) HandleUpstreamDeviceMessage(ctx context.Context,
 	case consts.IotDeviceMessageMethodPropertyPost:
 		// 属性上报
 		err = s.handlePropertyPost(ctx, message, device)
+	case consts.IotDeviceMessageMethodShadowGet:
+		// 设备获取影子（上电同步）
+		replyData, err = s.handleShadowGet(ctx, device)
+	case consts.IotDeviceMessageMethodEventPost:
+		// 事件上报
+		err = s.handleEventPost(ctx, message, device)
 	case consts.IotDeviceMessageMethodOtaProgress:
 		// OTA 进度上报
 		err = s.handleOtaProgress(ctx, message, device)
+	case consts.IotDeviceMessageMethodOtaInform:
+		// OTA 固件版本上报
+		err = s.handleOtaInform(ctx, message, device)
+	case consts.IotDeviceMessageMethodTopoAdd, consts.IotDeviceMessageMethodTopoDelete, consts.IotDeviceMessageMethodTopoGet:
+		// 网关拓扑管理
+		replyData, err = s.handleTopo(ctx, message, device)
+	case consts.IotDeviceMessageMethodSubLogin, consts.IotDeviceMessageMethodSubLogout:
+		// 子设备上下线
+		replyData, err = s.handleSubDeviceSession(ctx, message, device)
+	case consts.IotDeviceMessageMethodLocationPost:
+		// 位置上报
+		err = s.deviceLocationSvc.HandleLocationPost(ctx, message, device)
+	case consts.IotDeviceMessageMethodGeofencePost:
+		// 进出地理围栏（平台生成），仅记录日志，由场景联动订阅处理
 	default:
-		log.Printf("[DeviceMessageService] Unknown method: %s", message.Method)
+		if !isReplyMessage(message.Method) {
+			log.Printf("[DeviceMessageService] Unknown method: %s", message.Method)
+		}
 	}
 
-	// 2. 记录消息日志
-	go s.createDeviceLogAsync(ctx, message)
+	// 2. 记录消息日志（处理失败时记录错误码与原因），并推送到实时流
+	go s.createDeviceLogAsync(ctx, message, err)
+	s.streamSvc.Publish(message, device, err)
 
 	// 3. 发送回复消息（如果需要）
 	if !isReplyMessage(message.Method) && !isReplyDisabled(message.Method) && message.ServerID != "" {
@@ -182,7 +239,29 @@ func (s *DeviceMessageService) handleStateUpdate(ctx context.Context, message *i
 
 	// 更新设备状态
 	device.State = stateValue
-	return s.deviceRepo.Update(ctx, device)
+	if err := s.deviceRepo.Update(ctx, device); err != nil {
+		return err
+	}
+
+	// 记录设备所连网关节点，多节点部署时下行指令据此路由
+	if stateValue == consts.IotDeviceStateOnline && message.ServerID != "" && s.devicePropertySvc != nil {
+		if err := s.devicePropertySvc.UpdateDeviceServerId(ctx, device.ID, message.ServerID); err != nil {
+			log.Printf("[DeviceMessageService] Update server id of device %d failed: %v", device.ID, err)
+		}
+	}
+
+	// 设备上线后下发离线期间设置的期望状态
+	if stateValue == consts.IotDeviceStateOnline {
+		if err := s.SendShadowDelta(ctx, device); err != nil {
+			log.Printf("[DeviceMessageService] Send shadow delta to device %d failed: %v", device.ID, err)
+		}
+	}
+
+	// 网关下线时按拓扑关系下线子设备，不依赖网关连接所在节点
+	if stateValue == consts.IotDeviceStateOffline && device.DeviceType == consts.IotProductDeviceTypeGateway {
+		s.logoutGatewaySubDevices(ctx, device, message.ServerID)
+	}
+	return nil
 }
 
 // handlePropertyPost 处理属性上报
@@ -191,9 +270,31 @@ func (s *DeviceMessageService) handlePropertyPost(ctx context.Context, message *
 		return nil
 	}
 
+	// 按物模型校验，不符合的属性不保存
+	params := message.Params
+	var violations ThingModelViolations
+	if s.thingModelSvc != nil {
+		tsl, err := s.thingModelSvc.GetTSLFromCache(ctx, device.ProductID)
+		if err != nil {
+			return err
+		}
+		params, violations = ValidatePropertyParams(tsl, message.Params)
+	}
+
 	// 调用属性服务保存属性
-	if s.devicePropertySvc != nil {
-		return s.devicePropertySvc.SaveDeviceProperty(ctx, device.ID, message.Params)
+	if s.devicePropertySvc != nil && len(params) > 0 {
+		if err := s.devicePropertySvc.SaveDeviceProperty(ctx, device.ID, params); err != nil {
+			return err
+		}
+	}
+	// 同步影子的上报状态
+	if s.deviceShadowSvc != nil && len(params) > 0 {
+		if _, err := s.deviceShadowSvc.UpdateReported(ctx, device, params); err != nil {
+			log.Printf("[DeviceMessageService] Update shadow reported for device %d failed: %v", device.ID, err)
+		}
+	}
+	if len(violations) > 0 {
+		return s.rejectThingModelData(message, device, violations)
 	}
 
 	log.Printf("[DeviceMessageService] Property post: deviceId=%d, params=%v",
@@ -201,6 +302,66 @@ func (s *DeviceMessageService) handlePropertyPost(ctx context.Context, message *
 	return nil
 }
 
+// handleShadowGet 处理设备获取影子，回复期望状态、上报状态、差量与版本号
+func (s *DeviceMessageService) handleShadowGet(ctx context.Context, device *model.IotDeviceDO) (any, error) {
+	shadow, err := s.deviceShadowSvc.GetShadow(ctx, device)
+	if err != nil {
+		return nil, err
+	}
+	return s.deviceShadowSvc.BuildShadowRespVO(shadow), nil
+}
+
+// SendShadowDelta 向设备下发影子差量，无差量时不下发
+// 下发报文格式: {"state": {"temperature": 26}, "version": 3}
+func (s *DeviceMessageService) SendShadowDelta(ctx context.Context, device *model.IotDeviceDO) error {
+	if s.deviceShadowSvc == nil {
+		return nil
+	}
+	shadow, err := s.deviceShadowSvc.GetShadow(ctx, device)
+	if err != nil {
+		return err
+	}
+	delta := s.deviceShadowSvc.GetDelta(shadow)
+	if len(delta) == 0 {
+		return nil
+	}
+	message := &iotcore.IotDeviceMessage{
+		Method: consts.IotDeviceMessageMethodShadowDelta,
+		Params: map[string]any{
+			"state":   delta,
+			"version": shadow.Version,
+		},
+	}
+	return s.sendDeviceMessageInternal(ctx, message, device, "")
+}
+
+// handleEventPost 处理事件上报
+// 事件报文格式: {"identifier": "alarm", "value": {"level": 1}, "time": 1700000000000}
+func (s *DeviceMessageService) handleEventPost(ctx context.Context, message *iotcore.IotDeviceMessage, device *model.IotDeviceDO) error {
+	if message.Params == nil || s.thingModelSvc == nil {
+		return nil
+	}
+	identifier, _ := message.Params["identifier"].(string)
+	value, _ := message.Params["value"].(map[string]any)
+
+	tsl, err := s.thingModelSvc.GetTSLFromCache(ctx, device.ProductID)
+	if err != nil {
+		return err
+	}
+	if violations := ValidateEventParams(tsl, identifier, value); len(violations) > 0 {
+		return s.rejectThingModelData(message, device, violations)
+	}
+	return nil
+}
+
+// rejectThingModelData 拒绝不符合物模型的上行数据：错误随消息日志记录，并作为回复返回设备
+func (s *DeviceMessageService) rejectThingModelData(message *iotcore.IotDeviceMessage, device *model.IotDeviceDO, violations ThingModelViolations) error {
+	bizErr := violations.BizError()
+	log.Printf("[DeviceMessageService] Thing model validation failed: deviceId=%d, method=%s, %s",
+		device.ID, message.Method, bizErr.Msg)
+	return bizErr
+}
+
 // handleOtaProgress 处理 OTA 进度上报
 func (s *DeviceMessageService) handleOtaProgress(ctx context.Context, message *iotcore.IotDeviceMessage, device *model.IotDeviceDO) error {
 	log.Printf("[DeviceMessageService] OTA progress: deviceId=%d, params=%v",
@@ -235,14 +396,138 @@ func (s *DeviceMessageService) handleOtaProgress(ctx context.Context, message *i
 	return nil
 }
 
+// handleOtaInform 处理设备上报的当前固件版本
+// 报文格式: {"version": "1.0.1"}
+func (s *DeviceMessageService) handleOtaInform(ctx context.Context, message *iotcore.IotDeviceMessage, device *model.IotDeviceDO) error {
+	version, _ := message.Params["version"].(string)
+	if version == "" || s.otaTaskSvc == nil {
+		return nil
+	}
+	return s.otaTaskSvc.ReportFirmwareVersion(ctx, device, version)
+}
+
+// handleTopo 处理网关拓扑添加、删除、查询
+func (s *DeviceMessageService) handleTopo(ctx context.Context, message *iotcore.IotDeviceMessage, gateway *model.IotDeviceDO) (any, error) {
+	if message.Method == consts.IotDeviceMessageMethodTopoGet {
+		devices, err := s.deviceSvc.GetSubDeviceList(ctx, gateway.ID)
+		if err != nil {
+			return nil, err
+		}
+		return buildSubDeviceReplyData(devices), nil
+	}
+
+	subs, err := parseSubDeviceParams(message.Params)
+	if err != nil {
+		return nil, err
+	}
+	if message.Method == consts.IotDeviceMessageMethodTopoAdd {
+		devices, err := s.deviceSvc.AddTopo(ctx, gateway, subs)
+		if err != nil {
+			return nil, err
+		}
+		return buildSubDeviceReplyData(devices), nil
+	}
+
+	devices, err := s.deviceSvc.DeleteTopo(ctx, gateway, subs)
+	if err != nil {
+		return nil, err
+	}
+	// 解除拓扑的子设备同时下线
+	for _, device := range devices {
+		if device.State == consts.IotDeviceStateOnline {
+			s.logoutSubDevice(ctx, device, message.ServerID)
+		}
+	}
+	return buildSubDeviceReplyData(devices), nil
+}
+
+// handleSubDeviceSession 处理子设备通过网关上线、下线
+// 网关是否在线以持久化的设备状态为准，不依赖网关连接所在节点（外部 Broker、多节点部署时网关连接不在本节点）
+func (s *DeviceMessageService) handleSubDeviceSession(ctx context.Context, message *iotcore.IotDeviceMessage, gateway *model.IotDeviceDO) (any, error) {
+	login := message.Method == consts.IotDeviceMessageMethodSubLogin
+	if login && gateway.State != consts.IotDeviceStateOnline {
+		return nil, model.ErrDeviceGatewayOffline
+	}
+	subs, err := parseSubDeviceParams(message.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]*model.IotDeviceDO, 0, len(subs))
+	for _, sub := range subs {
+		device, err := s.deviceSvc.ValidateSubDevice(ctx, gateway, sub, login)
+		if err != nil {
+			return nil, err
+		}
+		if login {
+			s.loginSubDevice(ctx, gateway, device, message.ServerID)
+		} else {
+			s.logoutSubDevice(ctx, device, message.ServerID)
+		}
+		devices = append(devices, device)
+	}
+	return buildSubDeviceReplyData(devices), nil
+}
+
+// loginSubDevice 子设备上线：网关连接在本节点时挂载到网关连接下，并发送上线状态消息持久化
+func (s *DeviceMessageService) loginSubDevice(ctx context.Context, gateway, device *model.IotDeviceDO, serverID string) {
+	if s.subDeviceSessionMgr != nil {
+		// 网关连接不在本节点时无需挂载，子设备下行经网关所在节点转发
+		if err := s.subDeviceSessionMgr.LoginSubDevice(gateway.ID, device); err != nil && !stderrors.Is(err, model.ErrDeviceGatewayOffline) {
+			log.Printf("[DeviceMessageService] Mount sub-device %d to gateway %d failed: %v", device.ID, gateway.ID, err)
+		}
+	}
+	if serverID == "" && s.devicePropertySvc != nil {
+		serverID = s.devicePropertySvc.GetDeviceServerId(ctx, gateway.ID)
+	}
+	s.postStateMessage(ctx, device, consts.IotDeviceStateOnline, serverID)
+}
+
+// logoutSubDevice 子设备下线：解除本节点的挂载，并发送下线状态消息持久化
+func (s *DeviceMessageService) logoutSubDevice(ctx context.Context, device *model.IotDeviceDO, serverID string) {
+	if s.subDeviceSessionMgr != nil {
+		s.subDeviceSessionMgr.LogoutSubDevice(device.ID)
+	}
+	s.postStateMessage(ctx, device, consts.IotDeviceStateOffline, serverID)
+}
+
+// logoutGatewaySubDevices 网关下线时，其下在线的子设备一并下线
+func (s *DeviceMessageService) logoutGatewaySubDevices(ctx context.Context, gateway *model.IotDeviceDO, serverID string) {
+	devices, err := s.deviceSvc.GetSubDeviceList(ctx, gateway.ID)
+	if err != nil {
+		log.Printf("[DeviceMessageService] Get sub-devices of gateway %d failed: %v", gateway.ID, err)
+		return
+	}
+	for _, device := range devices {
+		if device.State == consts.IotDeviceStateOnline {
+			s.logoutSubDevice(ctx, device, serverID)
+		}
+	}
+}
+
+// postStateMessage 发送设备上下线消息到消息总线，由状态处理持久化并通知其他订阅者
+func (s *DeviceMessageService) postStateMessage(ctx context.Context, device *model.IotDeviceDO, state int8, serverID string) {
+	stateName := "offline"
+	if state == consts.IotDeviceStateOnline {
+		stateName = "online"
+	}
+	message := &iotcore.IotDeviceMessage{
+		Method:   consts.IotDeviceMessageMethodStateUpdate,
+		Params:   map[string]any{"state": stateName},
+		ServerID: serverID,
+	}
+	if err := s.sendDeviceMessageInternal(ctx, message, device, serverID); err != nil {
+		log.Printf("[DeviceMessageService] Post state message of device %d failed: %v", device.ID, err)
+	}
+}
+
 // sendReplyMessage 发送回复消息
 func (s *DeviceMessageService) sendReplyMessage(ctx context.Context, message *iotcore.IotDeviceMessage, device *model.IotDeviceDO, data any, err error) {
 	replyMethod := message.Method + "_reply"
 	var code int
 	var msg string
 	if err != nil {
-		code = 500
-		msg = err.Error()
+		code, msg = toReplyError(err)
 	}
 
 	replyMessage := &iotcore.IotDeviceMessage{
@@ -276,8 +561,8 @@ func (s *DeviceMessageService) appendDeviceMessage(message *iotcore.IotDeviceMes
 	message.TenantID = device.TenantID
 }
 
-// createDeviceLogAsync 异步创建设备日志
-func (s *DeviceMessageService) createDeviceLogAsync(ctx context.Context, message *iotcore.IotDeviceMessage) {
+// createDeviceLogAsync 异步创建设备日志，handleErr 为上行消息的处理错误
+func (s *DeviceMessageService) createDeviceLogAsync(ctx context.Context, message *iotcore.IotDeviceMessage, handleErr error) {
 	// 转换为数据库对象
 	paramsJSON, _ := json.Marshal(message.Params)
 	dataJSON, _ := json.Marshal(message.Data)
@@ -299,11 +584,29 @@ func (s *DeviceMessageService) createDeviceLogAsync(ctx context.Context, message
 		TS:         message.ReportTime.UnixMilli(),
 	}
 
+	if handleErr != nil {
+		code, msg := toReplyError(handleErr)
+		if runes := []rune(msg); len(runes) > 255 {
+			msg = string(runes[:255])
+		}
+		messageDO.Code = &code
+		messageDO.Msg = msg
+	}
+
 	if err := s.deviceMessageRepo.Create(ctx, messageDO); err != nil {
 		log.Printf("[DeviceMessageService] Create device log failed: %v", err)
 	}
 }
 
+// toReplyError 转换处理错误为回复的错误码与描述：业务错误使用其错误码，其余为 500
+func toReplyError(err error) (int, string) {
+	var bizErr *errors.BizError
+	if stderrors.As(err, &bizErr) {
+		return bizErr.Code, bizErr.Msg
+	}
+	return 500, err.Error()
+}
+
 // generateMessageID 生成消息ID (对齐 Java Hutool fastSimpleUUID: 无连字符)
 func generateMessageID() string {
 	return strings.ReplaceAll(uuid.New().String(), "-", "")
@@ -318,7 +621,7 @@ func isReplyMessage(method string) bool {
 func isReplyDisabled(method string) bool {
 	// 某些消息类型不需要回复
 	disabledMethods := map[string]bool{
-		"thing.lifecycle.state.update": true,
+		consts.IotDeviceMessageMethodStateUpdate: true,
 	}
 	return disabledMethods[method]
 }
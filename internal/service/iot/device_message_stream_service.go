@@ -0,0 +1,211 @@
+package iot
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	iotcore "github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/internal/pkg/websocket"
+)
+
+// deviceMessageStreamPermission 订阅设备消息实时流所需权限，与设备消息查询一致
+const deviceMessageStreamPermission = "iot:device:message-query"
+
+// PermissionChecker 用户权限校验（由 Casbin 中间件实现）
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, userID int64, permission string) (bool, error)
+}
+
+// DeviceMessageStreamService 设备消息实时流服务
+// 管理后台通过 WebSocket 订阅设备上下行消息，用于设备调试：
+// 消息经 DeviceMessageService 处理或发送时发布到实时流主题，由各节点的广播订阅者推送给本节点的订阅会话
+type DeviceMessageStreamService struct {
+	wsManager         *websocket.Manager
+	permissionChecker PermissionChecker
+	messageBus        iotcore.MessageBus
+
+	mu            sync.RWMutex
+	subscriptions map[string]*deviceMessageSubscription // sessionID -> 订阅
+}
+
+// deviceMessageSubscription 会话的订阅过滤条件
+type deviceMessageSubscription struct {
+	session *websocket.Session
+	filter  iot2.IotDeviceMessageStreamSubscribeReqVO
+}
+
+// deviceMessageSubscribeResult 订阅结果
+type deviceMessageSubscribeResult struct {
+	Success bool   `json:"success"`
+	Msg     string `json:"msg,omitempty"`
+}
+
+func NewDeviceMessageStreamService(wsManager *websocket.Manager, permissionChecker PermissionChecker, messageBus iotcore.MessageBus) *DeviceMessageStreamService {
+	s := &DeviceMessageStreamService{
+		wsManager:         wsManager,
+		permissionChecker: permissionChecker,
+		messageBus:        messageBus,
+		subscriptions:     make(map[string]*deviceMessageSubscription),
+	}
+	wsManager.RegisterListener(consts.IotWebSocketMessageTypeDeviceMessageSubscribe, s.onSubscribe)
+	wsManager.RegisterListener(consts.IotWebSocketMessageTypeDeviceMessageUnsubscribe, s.onUnsubscribe)
+	wsManager.RegisterCloseListener(s.onSessionClose)
+	return s
+}
+
+// Publish 发布设备消息到实时流，handleErr 为上行消息的处理错误
+func (s *DeviceMessageStreamService) Publish(message *iotcore.IotDeviceMessage, device *model.IotDeviceDO, handleErr error) {
+	event := &iotcore.DeviceMessageStreamEvent{
+		ProductID: device.ProductID,
+		Upstream:  message.IsUpstreamMessage(),
+		Message:   message,
+	}
+	if handleErr != nil {
+		code, msg := toReplyError(handleErr)
+		event.Code, event.Msg = &code, msg
+	}
+	s.messageBus.Post(iotcore.DeviceMessageStreamTopic, event)
+}
+
+// Push 推送实时流事件给本节点匹配的订阅会话，推送失败的会话取消订阅
+func (s *DeviceMessageStreamService) Push(event *iotcore.DeviceMessageStreamEvent) {
+	if event.Message == nil {
+		return
+	}
+	s.mu.RLock()
+	matched := make([]*deviceMessageSubscription, 0)
+	for _, sub := range s.subscriptions {
+		if sub.matches(event) {
+			matched = append(matched, sub)
+		}
+	}
+	s.mu.RUnlock()
+	if len(matched) == 0 {
+		return
+	}
+
+	data, err := toWebSocketMessage(consts.IotWebSocketMessageTypeDeviceMessage, toDeviceMessageStreamVO(event))
+	if err != nil {
+		log.Printf("[DeviceMessageStreamService] Encode message failed: %v", err)
+		return
+	}
+	for _, sub := range matched {
+		if err := sub.session.Send(data); err != nil {
+			log.Printf("[DeviceMessageStreamService] Push to session %s failed, unsubscribe: %v", sub.session.ID, err)
+			s.unsubscribe(sub.session.ID)
+		}
+	}
+}
+
+// onSubscribe 处理订阅请求：仅管理员且拥有设备消息查询权限的会话可订阅，重复订阅时覆盖过滤条件
+func (s *DeviceMessageStreamService) onSubscribe(session *websocket.Session, message *websocket.Message) {
+	var filter iot2.IotDeviceMessageStreamSubscribeReqVO
+	if message.Content != nil {
+		if err := message.DecodeContent(&filter); err != nil {
+			s.replySubscribe(session, false, "订阅参数不正确")
+			return
+		}
+	}
+	if session.UserType != consts.UserTypeAdmin {
+		s.replySubscribe(session, false, "权限不足")
+		return
+	}
+	ok, err := s.permissionChecker.HasPermission(context.Background(), session.UserID, deviceMessageStreamPermission)
+	if err != nil {
+		log.Printf("[DeviceMessageStreamService] Check permission failed: userId=%d, err=%v", session.UserID, err)
+		s.replySubscribe(session, false, "权限校验错误")
+		return
+	}
+	if !ok {
+		s.replySubscribe(session, false, "权限不足")
+		return
+	}
+
+	s.mu.Lock()
+	s.subscriptions[session.ID] = &deviceMessageSubscription{session: session, filter: filter}
+	s.mu.Unlock()
+	log.Printf("[DeviceMessageStreamService] Session %s subscribed: userId=%d, productId=%d, deviceId=%d, method=%s",
+		session.ID, session.UserID, filter.ProductID, filter.DeviceID, filter.Method)
+	s.replySubscribe(session, true, "")
+}
+
+// onUnsubscribe 处理取消订阅请求
+func (s *DeviceMessageStreamService) onUnsubscribe(session *websocket.Session, _ *websocket.Message) {
+	s.unsubscribe(session.ID)
+}
+
+// onSessionClose 会话关闭时取消订阅
+func (s *DeviceMessageStreamService) onSessionClose(session *websocket.Session) {
+	s.unsubscribe(session.ID)
+}
+
+func (s *DeviceMessageStreamService) unsubscribe(sessionID string) {
+	s.mu.Lock()
+	delete(s.subscriptions, sessionID)
+	s.mu.Unlock()
+}
+
+// replySubscribe 回复订阅结果
+func (s *DeviceMessageStreamService) replySubscribe(session *websocket.Session, success bool, msg string) {
+	data, err := toWebSocketMessage(consts.IotWebSocketMessageTypeDeviceMessageSubscribe,
+		&deviceMessageSubscribeResult{Success: success, Msg: msg})
+	if err != nil {
+		return
+	}
+	_ = session.Send(data)
+}
+
+// matches 判断事件是否匹配订阅：同租户，产品、设备、方法为空时不限
+func (sub *deviceMessageSubscription) matches(event *iotcore.DeviceMessageStreamEvent) bool {
+	if sub.session.TenantID != event.Message.TenantID {
+		return false
+	}
+	if sub.filter.ProductID != 0 && sub.filter.ProductID != event.ProductID {
+		return false
+	}
+	if sub.filter.DeviceID != 0 && sub.filter.DeviceID != event.Message.DeviceID {
+		return false
+	}
+	return sub.filter.Method == "" || sub.filter.Method == event.Message.Method
+}
+
+// toDeviceMessageStreamVO 转换为与设备消息分页一致的响应格式
+func toDeviceMessageStreamVO(event *iotcore.DeviceMessageStreamEvent) *iot2.IotDeviceMessageRespVO {
+	message := event.Message
+	reportTime := message.ReportTime
+	ts := time.Now()
+	vo := &iot2.IotDeviceMessageRespVO{
+		ID:         message.ID,
+		ReportTime: &reportTime,
+		TS:         &ts,
+		DeviceID:   message.DeviceID,
+		ServerID:   message.ServerID,
+		Upstream:   event.Upstream,
+		Reply:      isReplyMessage(message.Method),
+		Identifier: getIdentifier(message),
+		RequestID:  message.RequestID,
+		Method:     message.Method,
+		Params:     message.Params,
+		Data:       message.Data,
+		Code:       message.Code,
+		Msg:        message.Msg,
+	}
+	if event.Code != nil {
+		vo.Code, vo.Msg = event.Code, event.Msg
+	}
+	return vo
+}
+
+// toWebSocketMessage 构建 WebSocket JSON 消息
+func toWebSocketMessage(msgType string, content any) ([]byte, error) {
+	wsMsg, err := websocket.NewMessage(msgType, content)
+	if err != nil {
+		return nil, err
+	}
+	return wsMsg.ToJSON()
+}
@@ -0,0 +1,338 @@
+package iot
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+)
+
+const (
+	// devicePropertyRollupBatchSize 聚合时单批读取的原始属性条数
+	devicePropertyRollupBatchSize = 1000
+	// devicePropertyPurgeBatchSize 过期清理时单批删除的条数
+	devicePropertyPurgeBatchSize = 1000
+	// devicePropertyPurgeDeviceChunk 过期清理时单次查询的设备数
+	devicePropertyPurgeDeviceChunk = 500
+	// DevicePropertyRollupDefaultLookback 聚合默认回溯时长，覆盖任务延迟与设备补报的数据
+	DevicePropertyRollupDefaultLookback = time.Hour
+)
+
+// devicePropertyRollupRetention 各粒度聚合数据的保留时长，未配置的粒度永久保留
+var devicePropertyRollupRetention = map[string]time.Duration{
+	consts.IotDevicePropertyGranularityMinute: 30 * 24 * time.Hour,
+	consts.IotDevicePropertyGranularityHour:   365 * 24 * time.Hour,
+}
+
+// DevicePropertyRollupService 设备属性时序聚合服务
+// 数值型属性按分钟聚合原始数据，再逐级合并为小时、天；原始数据按产品配置的保留天数清理
+type DevicePropertyRollupService struct {
+	propertyRepo  DevicePropertyRepository
+	rollupRepo    DevicePropertyRollupRepository
+	productRepo   ProductRepository
+	deviceRepo    DeviceRepository
+	thingModelSvc *ThingModelService
+}
+
+func NewDevicePropertyRollupService(
+	propertyRepo DevicePropertyRepository,
+	rollupRepo DevicePropertyRollupRepository,
+	productRepo ProductRepository,
+	deviceRepo DeviceRepository,
+	thingModelSvc *ThingModelService,
+) *DevicePropertyRollupService {
+	return &DevicePropertyRollupService{
+		propertyRepo:  propertyRepo,
+		rollupRepo:    rollupRepo,
+		productRepo:   productRepo,
+		deviceRepo:    deviceRepo,
+		thingModelSvc: thingModelSvc,
+	}
+}
+
+// GetPropertyAggregateList 查询数值型属性的聚合曲线
+func (s *DevicePropertyRollupService) GetPropertyAggregateList(ctx context.Context, req *iot2.IotDevicePropertyAggregateListReqVO) ([]*iot2.IotDevicePropertyAggregateRespVO, error) {
+	device, err := s.deviceRepo.GetByID(ctx, req.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+	if device == nil {
+		return nil, model.ErrDeviceNotExists
+	}
+	numeric, err := s.getNumericIdentifiers(ctx, device.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if !numeric[req.Identifier] {
+		return nil, model.ErrDevicePropertyNotNumeric
+	}
+
+	var startTime, endTime *time.Time
+	if len(req.Times) == 2 {
+		startTime, endTime = req.Times[0], req.Times[1]
+	}
+	list, err := s.rollupRepo.GetList(ctx, req.DeviceID, req.Identifier, req.Granularity, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*iot2.IotDevicePropertyAggregateRespVO, 0, len(list))
+	for _, rollup := range list {
+		vo := &iot2.IotDevicePropertyAggregateRespVO{
+			Time:      rollup.BucketTime.UnixMilli(),
+			MinValue:  rollup.MinValue,
+			MaxValue:  rollup.MaxValue,
+			LastValue: rollup.LastValue,
+			Count:     rollup.Count,
+		}
+		if rollup.Count > 0 {
+			vo.AvgValue = rollup.SumValue / float64(rollup.Count)
+		}
+		result = append(result, vo)
+	}
+	return result, nil
+}
+
+// RollupProperties 重算 [now-lookback, now] 内的分钟、小时、天聚合，返回写入的聚合条数
+// 聚合按分桶整体覆盖写入，重复执行结果一致
+func (s *DevicePropertyRollupService) RollupProperties(ctx context.Context, now time.Time, lookback time.Duration) (int, error) {
+	if lookback <= 0 {
+		lookback = DevicePropertyRollupDefaultLookback
+	}
+	// 从整点开始重算分钟聚合，保证小时聚合由完整的分钟聚合合并而来
+	startTime := truncateRollupBucket(now.Add(-lookback), consts.IotDevicePropertyGranularityHour)
+
+	// 1. 原始数据 -> 分钟
+	minutes, err := s.rollupRawProperties(ctx, startTime, now)
+	if err != nil {
+		return 0, err
+	}
+	// 2. 分钟 -> 小时
+	hours, err := s.rollupFrom(ctx, consts.IotDevicePropertyGranularityMinute, consts.IotDevicePropertyGranularityHour, startTime, now)
+	if err != nil {
+		return 0, err
+	}
+	// 3. 小时 -> 天
+	dayStartTime := truncateRollupBucket(startTime, consts.IotDevicePropertyGranularityDay)
+	days, err := s.rollupFrom(ctx, consts.IotDevicePropertyGranularityHour, consts.IotDevicePropertyGranularityDay, dayStartTime, now)
+	if err != nil {
+		return 0, err
+	}
+	return minutes + hours + days, nil
+}
+
+// PurgeExpiredProperties 清理超过保留期的原始属性与分钟、小时聚合，返回删除的条数
+func (s *DevicePropertyRollupService) PurgeExpiredProperties(ctx context.Context, now time.Time) (int64, error) {
+	var total int64
+
+	// 1. 按产品配置的保留天数清理原始数据
+	products, err := s.productRepo.ListAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, product := range products {
+		if product.PropertyRetentionDays <= 0 {
+			continue
+		}
+		devices, err := s.deviceRepo.ListByCondition(ctx, nil, &product.ID)
+		if err != nil {
+			return total, err
+		}
+		deviceIDs := make([]int64, 0, len(devices))
+		for _, device := range devices {
+			deviceIDs = append(deviceIDs, device.ID)
+		}
+		before := now.AddDate(0, 0, -product.PropertyRetentionDays)
+		for i := 0; i < len(deviceIDs); i += devicePropertyPurgeDeviceChunk {
+			chunk := deviceIDs[i:min(i+devicePropertyPurgeDeviceChunk, len(deviceIDs))]
+			count, err := purgeInBatches(func() (int64, error) {
+				return s.propertyRepo.DeleteByDeviceIDsAndTimeBefore(ctx, chunk, before, devicePropertyPurgeBatchSize)
+			})
+			total += count
+			if err != nil {
+				return total, err
+			}
+		}
+	}
+
+	// 2. 清理细粒度聚合
+	for granularity, retention := range devicePropertyRollupRetention {
+		before := now.Add(-retention)
+		count, err := purgeInBatches(func() (int64, error) {
+			return s.rollupRepo.DeleteByGranularityAndTimeBefore(ctx, granularity, before, devicePropertyPurgeBatchSize)
+		})
+		total += count
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// rollupRawProperties 将 [startTime, endTime) 内的原始数值属性聚合为分钟聚合
+func (s *DevicePropertyRollupService) rollupRawProperties(ctx context.Context, startTime, endTime time.Time) (int, error) {
+	buckets := make(map[devicePropertyRollupKey]*model.IotDevicePropertyRollupDO)
+	deviceProducts := make(map[int64]int64)
+	productNumerics := make(map[int64]map[string]bool)
+
+	var lastID int64
+	for {
+		list, err := s.propertyRepo.GetListByTimeRange(ctx, startTime, endTime, lastID, devicePropertyRollupBatchSize)
+		if err != nil {
+			return 0, err
+		}
+		for _, property := range list {
+			lastID = property.ID
+			if property.UpdateTime == nil {
+				continue
+			}
+			// 仅聚合物模型中定义为数值型的属性
+			productID, ok := deviceProducts[property.DeviceID]
+			if !ok {
+				device, err := s.deviceRepo.GetByID(ctx, property.DeviceID)
+				if err != nil {
+					return 0, err
+				}
+				if device != nil {
+					productID = device.ProductID
+				}
+				deviceProducts[property.DeviceID] = productID
+			}
+			numeric, ok := productNumerics[productID]
+			if !ok {
+				if numeric, err = s.getNumericIdentifiers(ctx, productID); err != nil {
+					return 0, err
+				}
+				productNumerics[productID] = numeric
+			}
+			if !numeric[property.Identifier] {
+				continue
+			}
+			value, err := strconv.ParseFloat(strings.TrimSpace(property.Value), 64)
+			if err != nil {
+				continue
+			}
+
+			point := &model.IotDevicePropertyRollupDO{
+				DeviceID:    property.DeviceID,
+				Identifier:  property.Identifier,
+				Granularity: consts.IotDevicePropertyGranularityMinute,
+				BucketTime:  truncateRollupBucket(*property.UpdateTime, consts.IotDevicePropertyGranularityMinute),
+				MinValue:    value,
+				MaxValue:    value,
+				SumValue:    value,
+				Count:       1,
+				LastValue:   value,
+				LastTime:    *property.UpdateTime,
+			}
+			point.TenantID = property.TenantID
+			mergeRollupBucket(buckets, point)
+		}
+		if len(list) < devicePropertyRollupBatchSize {
+			break
+		}
+	}
+	return s.saveRollupBuckets(ctx, buckets)
+}
+
+// rollupFrom 将 [startTime, endTime) 内的 from 粒度聚合合并为 to 粒度聚合
+func (s *DevicePropertyRollupService) rollupFrom(ctx context.Context, from, to string, startTime, endTime time.Time) (int, error) {
+	list, err := s.rollupRepo.GetListByGranularityAndTimeRange(ctx, from, startTime, endTime)
+	if err != nil {
+		return 0, err
+	}
+	buckets := make(map[devicePropertyRollupKey]*model.IotDevicePropertyRollupDO)
+	for _, rollup := range list {
+		rollup.ID = 0
+		rollup.Granularity = to
+		rollup.BucketTime = truncateRollupBucket(rollup.BucketTime, to)
+		mergeRollupBucket(buckets, rollup)
+	}
+	return s.saveRollupBuckets(ctx, buckets)
+}
+
+// saveRollupBuckets 写入聚合结果
+func (s *DevicePropertyRollupService) saveRollupBuckets(ctx context.Context, buckets map[devicePropertyRollupKey]*model.IotDevicePropertyRollupDO) (int, error) {
+	rollups := make([]*model.IotDevicePropertyRollupDO, 0, len(buckets))
+	for _, rollup := range buckets {
+		rollups = append(rollups, rollup)
+	}
+	if err := s.rollupRepo.Upsert(ctx, rollups); err != nil {
+		return 0, err
+	}
+	return len(rollups), nil
+}
+
+// getNumericIdentifiers 获取产品物模型中数值型（int、float、double）属性的标识符
+func (s *DevicePropertyRollupService) getNumericIdentifiers(ctx context.Context, productID int64) (map[string]bool, error) {
+	numeric := make(map[string]bool)
+	if productID == 0 {
+		return numeric, nil
+	}
+	tsl, err := s.thingModelSvc.GetTSLFromCache(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if tsl == nil {
+		return numeric, nil
+	}
+	for _, property := range tsl.Properties {
+		switch property.DataType {
+		case consts.IotDataSpecsDataTypeInt, consts.IotDataSpecsDataTypeFloat, consts.IotDataSpecsDataTypeDouble:
+			numeric[property.Identifier] = true
+		}
+	}
+	return numeric, nil
+}
+
+// devicePropertyRollupKey 聚合分桶的唯一键
+type devicePropertyRollupKey struct {
+	deviceID   int64
+	identifier string
+	bucketTime int64
+}
+
+// mergeRollupBucket 将 rollup 合并到对应分桶
+func mergeRollupBucket(buckets map[devicePropertyRollupKey]*model.IotDevicePropertyRollupDO, rollup *model.IotDevicePropertyRollupDO) {
+	key := devicePropertyRollupKey{deviceID: rollup.DeviceID, identifier: rollup.Identifier, bucketTime: rollup.BucketTime.Unix()}
+	bucket, ok := buckets[key]
+	if !ok {
+		buckets[key] = rollup
+		return
+	}
+	bucket.MinValue = min(bucket.MinValue, rollup.MinValue)
+	bucket.MaxValue = max(bucket.MaxValue, rollup.MaxValue)
+	bucket.SumValue += rollup.SumValue
+	bucket.Count += rollup.Count
+	if rollup.LastTime.After(bucket.LastTime) {
+		bucket.LastValue = rollup.LastValue
+		bucket.LastTime = rollup.LastTime
+	}
+}
+
+// truncateRollupBucket 计算时间所在分桶的开始时间（按本地时区对齐小时、天）
+func truncateRollupBucket(t time.Time, granularity string) time.Time {
+	switch granularity {
+	case consts.IotDevicePropertyGranularityDay:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	case consts.IotDevicePropertyGranularityHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, t.Location())
+	}
+}
+
+// purgeInBatches 分批删除直到无剩余数据
+func purgeInBatches(deleteBatch func() (int64, error)) (int64, error) {
+	var total int64
+	for {
+		count, err := deleteBatch()
+		total += count
+		if err != nil || count < devicePropertyPurgeBatchSize {
+			return total, err
+		}
+	}
+}
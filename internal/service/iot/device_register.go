@@ -0,0 +1,117 @@
+package iot
+
+import (
+	"context"
+	"log"
+	"regexp"
+
+	"github.com/google/uuid"
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
+)
+
+// deviceNamePattern 动态注册的设备名称：不能包含 Topic 分隔符与用户名分隔符（/ + # &）
+var deviceNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.:@-]{1,64}$`)
+
+// RegisterDevice 设备动态注册（一型一密）
+// 设备使用产品密钥签名：sign = HMAC-SHA256(productSecret, deviceName{deviceName}productKey{productKey})；
+// 产品开启动态注册后，设备不存在时自动创建，已存在但未激活时返回其密钥（注册结果丢失后可重试），已激活的设备不允许重复注册。
+// 每次注册请求（含失败）均记录注册日志
+func (s *DeviceService) RegisterDevice(ctx context.Context, r *iot2.IotDeviceRegisterReqDTO, protocol, clientIP string) (*iot2.IotDeviceRegisterRespDTO, error) {
+	registerLog := &model.IotDeviceRegisterLogDO{
+		ProductKey: r.ProductKey,
+		DeviceName: r.DeviceName,
+		Protocol:   protocol,
+		ClientIP:   clientIP,
+	}
+	device, err := s.registerDevice(ctx, r, registerLog)
+	registerLog.Success = err == nil
+	if err != nil {
+		registerLog.ErrorMsg = err.Error()
+		if bizErr, ok := err.(*errors.BizError); ok {
+			registerLog.ErrorMsg = bizErr.Msg
+		}
+		log.Printf("[DeviceService] Register device failed: productKey=%s, deviceName=%s, protocol=%s, ip=%s, err=%v",
+			r.ProductKey, r.DeviceName, protocol, clientIP, err)
+	}
+	if logErr := s.registerLogRepo.Create(ctx, registerLog); logErr != nil {
+		log.Printf("[DeviceService] Save register log failed: productKey=%s, deviceName=%s, err=%v", r.ProductKey, r.DeviceName, logErr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &iot2.IotDeviceRegisterRespDTO{
+		ProductKey:   device.ProductKey,
+		DeviceName:   device.DeviceName,
+		DeviceSecret: device.DeviceSecret,
+	}, nil
+}
+
+// registerDevice 校验注册请求并获取或创建设备，注册结果回填到注册日志
+func (s *DeviceService) registerDevice(ctx context.Context, r *iot2.IotDeviceRegisterReqDTO, registerLog *model.IotDeviceRegisterLogDO) (*model.IotDeviceDO, error) {
+	// 1. 校验产品与签名
+	product, err := s.productRepo.GetByKey(ctx, r.ProductKey)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, model.ErrProductNotExists
+	}
+	registerLog.ProductID = product.ID
+	registerLog.TenantID = product.TenantID
+	if !product.RegisterEnabled {
+		return nil, model.ErrDeviceRegisterDisabled
+	}
+	if product.ProductSecret == "" || !s.authUtils.ValidatePassword(product.ProductSecret, r.DeviceName, r.ProductKey, r.Sign) {
+		return nil, model.ErrDeviceRegisterSignInvalid
+	}
+	if !deviceNamePattern.MatchString(r.DeviceName) {
+		return nil, model.ErrDeviceRegisterNameInvalid
+	}
+
+	// 2. 设备已存在：未激活时返回已有密钥
+	device, err := s.deviceRepo.GetByProductKeyAndName(ctx, r.ProductKey, r.DeviceName)
+	if err != nil {
+		return nil, err
+	}
+	if device != nil {
+		if device.State != consts.IotDeviceStateInactive {
+			return nil, model.ErrDeviceRegisterActivated
+		}
+		registerLog.DeviceID = device.ID
+		return device, nil
+	}
+
+	// 3. 自动创建设备，归属产品所在租户
+	device = &model.IotDeviceDO{
+		DeviceName:   r.DeviceName,
+		ProductID:    product.ID,
+		ProductKey:   product.ProductKey,
+		DeviceType:   product.DeviceType,
+		State:        consts.IotDeviceStateInactive,
+		DeviceSecret: uuid.New().String(),
+		AuthType:     consts.IotDeviceAuthTypeSecret,
+		LocationType: product.LocationType,
+	}
+	device.TenantID = product.TenantID
+	if err := s.deviceRepo.Create(ctx, device); err != nil {
+		// 同一设备并发注册时，以先创建成功的为准
+		existing, _ := s.deviceRepo.GetByProductKeyAndName(ctx, r.ProductKey, r.DeviceName)
+		if existing == nil {
+			return nil, err
+		}
+		device = existing
+	} else {
+		registerLog.Created = true
+	}
+	registerLog.DeviceID = device.ID
+	return device, nil
+}
+
+// GetRegisterLogPage 获取设备动态注册日志分页
+func (s *DeviceService) GetRegisterLogPage(ctx context.Context, r *iot2.IotDeviceRegisterLogPageReqVO) (*pagination.PageResult[*model.IotDeviceRegisterLogDO], error) {
+	return s.registerLogRepo.GetPage(ctx, r)
+}
@@ -18,16 +18,25 @@ import (
 )
 
 type DeviceService struct {
-	productRepo ProductRepository
-	deviceRepo  DeviceRepository
-	authUtils   *iotcore.DeviceAuthUtils
+	productRepo     ProductRepository
+	deviceRepo      DeviceRepository
+	registerLogRepo DeviceRegisterLogRepository
+	certRepo        DeviceCertificateRepository
+	deviceGroupRepo DeviceGroupRepository
+	authUtils       *iotcore.DeviceAuthUtils
+	deviceCA        *iotcore.DeviceCA
 }
 
-func NewDeviceService(productRepo ProductRepository, deviceRepo DeviceRepository, authUtils *iotcore.DeviceAuthUtils) *DeviceService {
+func NewDeviceService(productRepo ProductRepository, deviceRepo DeviceRepository, registerLogRepo DeviceRegisterLogRepository,
+	certRepo DeviceCertificateRepository, deviceGroupRepo DeviceGroupRepository, authUtils *iotcore.DeviceAuthUtils, deviceCA *iotcore.DeviceCA) *DeviceService {
 	return &DeviceService{
-		productRepo: productRepo,
-		deviceRepo:  deviceRepo,
-		authUtils:   authUtils,
+		productRepo:     productRepo,
+		deviceRepo:      deviceRepo,
+		registerLogRepo: registerLogRepo,
+		certRepo:        certRepo,
+		deviceGroupRepo: deviceGroupRepo,
+		authUtils:       authUtils,
+		deviceCA:        deviceCA,
 	}
 }
 
@@ -44,6 +53,10 @@ func (s *DeviceService) Create(ctx context.Context, r *iot2.IotDeviceSaveReqVO)
 	if exists != nil {
 		return 0, model.ErrDeviceNameExists
 	}
+	authType, err := normalizeAuthType(r.AuthType)
+	if err != nil {
+		return 0, err
+	}
 
 	groupIdsJson, _ := json.Marshal(r.GroupIDs)
 	device := &model.IotDeviceDO{
@@ -58,6 +71,7 @@ func (s *DeviceService) Create(ctx context.Context, r *iot2.IotDeviceSaveReqVO)
 		GatewayID:    r.GatewayID,
 		State:        0,
 		DeviceSecret: uuid.New().String(),
+		AuthType:     authType,
 		Config:       datatypes.JSON(r.Config),
 		LocationType: r.LocationType,
 		Latitude:     r.Latitude,
@@ -66,6 +80,12 @@ func (s *DeviceService) Create(ctx context.Context, r *iot2.IotDeviceSaveReqVO)
 	if err := s.deviceRepo.Create(ctx, device); err != nil {
 		return 0, err
 	}
+	// X.509 认证的设备签发客户端证书
+	if authType == consts.IotDeviceAuthTypeX509 {
+		if _, err := s.issueCertificate(ctx, device); err != nil {
+			return 0, err
+		}
+	}
 	return device.ID, nil
 }
 
@@ -89,6 +109,20 @@ func (s *DeviceService) Update(ctx context.Context, r *iot2.IotDeviceSaveReqVO)
 	device.Latitude = r.Latitude
 	device.Longitude = r.Longitude
 
+	// 切换认证类型：切换为 X.509 时签发证书，切换为密钥时吊销证书
+	if r.AuthType != "" {
+		authType, err := normalizeAuthType(r.AuthType)
+		if err != nil {
+			return err
+		}
+		if authType != normalizeDeviceAuthType(device.AuthType) {
+			if err := s.switchAuthType(ctx, device, authType); err != nil {
+				return err
+			}
+		}
+		device.AuthType = authType
+	}
+
 	return s.deviceRepo.Update(ctx, device)
 }
 
@@ -110,11 +144,17 @@ func (s *DeviceService) Delete(ctx context.Context, id int64) error {
 			return model.ErrDeviceHasChildren
 		}
 	}
-	return s.deviceRepo.Delete(ctx, id)
+	if err := s.deviceRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	return s.certRepo.RevokeByDeviceIDs(ctx, []int64{id}, deviceCertificateRevokeReasonDeleted)
 }
 
 func (s *DeviceService) DeleteList(ctx context.Context, ids []int64) error {
-	return s.deviceRepo.DeleteList(ctx, ids)
+	if err := s.deviceRepo.DeleteList(ctx, ids); err != nil {
+		return err
+	}
+	return s.certRepo.RevokeByDeviceIDs(ctx, ids, deviceCertificateRevokeReasonDeleted)
 }
 
 func (s *DeviceService) Get(ctx context.Context, id int64) (*model.IotDeviceDO, error) {
@@ -148,13 +188,20 @@ func (s *DeviceService) GetAuthInfo(ctx context.Context, id int64) (*iot2.IotDev
 		}
 	}
 
-	return &iot2.IotDeviceAuthInfoRespVO{
+	authInfo := &iot2.IotDeviceAuthInfoRespVO{
 		ProductKey:   device.ProductKey,
 		DeviceName:   device.DeviceName,
 		DeviceSecret: device.DeviceSecret,
 		MqttHost:     host,
 		MqttPort:     port,
-	}, nil
+		AuthType:     normalizeDeviceAuthType(device.AuthType),
+	}
+	if authInfo.AuthType == consts.IotDeviceAuthTypeX509 {
+		if err := s.fillCertificateAuthInfo(ctx, device, authInfo); err != nil {
+			return nil, err
+		}
+	}
+	return authInfo, nil
 }
 
 // UpdateDeviceActiveTime 更新设备最后活跃时间
@@ -170,6 +217,10 @@ func (s *DeviceService) Auth(ctx context.Context, productKey, deviceName, passwo
 	if device == nil {
 		return nil, model.ErrDeviceNotExists
 	}
+	// X.509 认证的设备只能使用证书接入
+	if !s.IsSecretAuth(device) {
+		return nil, model.ErrDeviceAuthTypeInvalid
+	}
 
 	// 校验密码
 	content := s.authUtils.BuildAuthContent(deviceName, productKey)
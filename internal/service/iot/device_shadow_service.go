@@ -0,0 +1,180 @@
+package iot
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"gorm.io/datatypes"
+)
+
+// deviceShadowMaxRetry 影子并发更新（版本冲突）时的最大重试次数
+const deviceShadowMaxRetry = 3
+
+// DeviceShadowService 设备影子服务
+// 期望状态可在设备离线时设置，设备上线或主动获取时下发与上报状态的差量（delta）；
+// 设备上报的属性与期望值一致后，对应的期望值自动清除
+type DeviceShadowService struct {
+	shadowRepo    DeviceShadowRepository
+	thingModelSvc *ThingModelService
+}
+
+func NewDeviceShadowService(shadowRepo DeviceShadowRepository, thingModelSvc *ThingModelService) *DeviceShadowService {
+	return &DeviceShadowService{
+		shadowRepo:    shadowRepo,
+		thingModelSvc: thingModelSvc,
+	}
+}
+
+// GetShadow 获取设备影子，尚未创建时返回空影子
+func (s *DeviceShadowService) GetShadow(ctx context.Context, device *model.IotDeviceDO) (*model.IotDeviceShadowDO, error) {
+	shadow, err := s.shadowRepo.GetByDeviceID(ctx, device.ID)
+	if err != nil {
+		return nil, err
+	}
+	if shadow == nil {
+		return newDeviceShadow(device), nil
+	}
+	if shadow.Desired == nil {
+		shadow.Desired = datatypes.JSONMap{}
+	}
+	if shadow.Reported == nil {
+		shadow.Reported = datatypes.JSONMap{}
+	}
+	return shadow, nil
+}
+
+// UpdateDesired 更新期望状态，值为 nil 的属性清除其期望值
+// version 大于 0 时校验影子当前版本号
+func (s *DeviceShadowService) UpdateDesired(ctx context.Context, device *model.IotDeviceDO, desired map[string]any, version int64) (*model.IotDeviceShadowDO, error) {
+	// 1. 按物模型校验期望值
+	values := make(map[string]any, len(desired))
+	for identifier, value := range desired {
+		if value != nil {
+			values[identifier] = value
+		}
+	}
+	tsl, err := s.thingModelSvc.GetTSLFromCache(ctx, device.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if _, violations := ValidatePropertyParams(tsl, values); len(violations) > 0 {
+		return nil, violations.BizError()
+	}
+
+	// 2. 合并到影子
+	return s.saveShadow(ctx, device, func(shadow *model.IotDeviceShadowDO) (bool, error) {
+		if version > 0 && shadow.Version != version {
+			return false, model.ErrDeviceShadowVersionConflict
+		}
+		for identifier, value := range desired {
+			// 与上报值已一致的期望值无需下发
+			if value == nil || shadowValueEqual(shadow.Reported[identifier], value) {
+				delete(shadow.Desired, identifier)
+				continue
+			}
+			shadow.Desired[identifier] = value
+		}
+		return true, nil
+	})
+}
+
+// UpdateReported 合并设备上报的属性，已与上报值一致的期望值随之清除
+func (s *DeviceShadowService) UpdateReported(ctx context.Context, device *model.IotDeviceDO, reported map[string]any) (*model.IotDeviceShadowDO, error) {
+	return s.saveShadow(ctx, device, func(shadow *model.IotDeviceShadowDO) (bool, error) {
+		changed := false
+		for identifier, value := range reported {
+			if !shadowValueEqual(shadow.Reported[identifier], value) {
+				shadow.Reported[identifier] = value
+				changed = true
+			}
+			if desired, ok := shadow.Desired[identifier]; ok && shadowValueEqual(desired, value) {
+				delete(shadow.Desired, identifier)
+				changed = true
+			}
+		}
+		return changed, nil
+	})
+}
+
+// GetDelta 计算期望状态中与上报状态不一致的部分
+func (s *DeviceShadowService) GetDelta(shadow *model.IotDeviceShadowDO) map[string]any {
+	delta := make(map[string]any)
+	for identifier, value := range shadow.Desired {
+		if reported, ok := shadow.Reported[identifier]; !ok || !shadowValueEqual(reported, value) {
+			delta[identifier] = value
+		}
+	}
+	return delta
+}
+
+// BuildShadowRespVO 构建设备影子响应
+func (s *DeviceShadowService) BuildShadowRespVO(shadow *model.IotDeviceShadowDO) *iot2.IotDeviceShadowRespVO {
+	resp := &iot2.IotDeviceShadowRespVO{
+		DeviceID: shadow.DeviceID,
+		Desired:  shadow.Desired,
+		Reported: shadow.Reported,
+		Delta:    s.GetDelta(shadow),
+		Version:  shadow.Version,
+	}
+	if shadow.ID != 0 {
+		resp.UpdateTime = &shadow.UpdateTime
+	}
+	return resp
+}
+
+// saveShadow 读取影子并应用修改，按版本号乐观更新，冲突时重新读取后重试
+func (s *DeviceShadowService) saveShadow(ctx context.Context, device *model.IotDeviceDO, apply func(shadow *model.IotDeviceShadowDO) (bool, error)) (*model.IotDeviceShadowDO, error) {
+	for i := 0; i < deviceShadowMaxRetry; i++ {
+		shadow, err := s.GetShadow(ctx, device)
+		if err != nil {
+			return nil, err
+		}
+		changed, err := apply(shadow)
+		if err != nil {
+			return nil, err
+		}
+		if !changed {
+			return shadow, nil
+		}
+
+		version := shadow.Version
+		shadow.Version++
+		if shadow.ID == 0 {
+			// 并发创建时唯一索引冲突，重新读取后按更新处理
+			if err := s.shadowRepo.Create(ctx, shadow); err != nil {
+				log.Printf("[DeviceShadowService] Create shadow for device %d failed, retrying: %v", device.ID, err)
+				continue
+			}
+			return shadow, nil
+		}
+		ok, err := s.shadowRepo.UpdateByVersion(ctx, shadow, version)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return shadow, nil
+		}
+	}
+	return nil, model.ErrDeviceShadowVersionConflict
+}
+
+// newDeviceShadow 创建空的设备影子
+func newDeviceShadow(device *model.IotDeviceDO) *model.IotDeviceShadowDO {
+	shadow := &model.IotDeviceShadowDO{
+		DeviceID: device.ID,
+		Desired:  datatypes.JSONMap{},
+		Reported: datatypes.JSONMap{},
+	}
+	shadow.TenantID = device.TenantID
+	return shadow
+}
+
+// shadowValueEqual 比较影子中的属性值（按 JSON 序列化结果比较，兼容数值类型差异）
+func shadowValueEqual(a, b any) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
@@ -0,0 +1,137 @@
+package iot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+)
+
+// IotSubDeviceDTO 网关上报的子设备信息
+// 拓扑添加、子设备上线需携带 sign（算法同设备 MQTT 密码，使用子设备密钥）
+type IotSubDeviceDTO struct {
+	ProductKey string `json:"productKey"`
+	DeviceName string `json:"deviceName"`
+	Sign       string `json:"sign,omitempty"`
+}
+
+// SubDeviceSessionManager 子设备会话管理（由网关连接管理器实现）
+type SubDeviceSessionManager interface {
+	// LoginSubDevice 子设备通过网关上线
+	LoginSubDevice(gatewayID int64, sub *model.IotDeviceDO) error
+	// LogoutSubDevice 子设备下线
+	LogoutSubDevice(subDeviceID int64)
+}
+
+// AddTopo 添加网关与子设备的拓扑关系
+func (s *DeviceService) AddTopo(ctx context.Context, gateway *model.IotDeviceDO, subs []*IotSubDeviceDTO) ([]*model.IotDeviceDO, error) {
+	if gateway.DeviceType != consts.IotProductDeviceTypeGateway {
+		return nil, model.ErrDeviceNotGateway
+	}
+	devices := make([]*model.IotDeviceDO, 0, len(subs))
+	ids := make([]int64, 0, len(subs))
+	for _, sub := range subs {
+		device, err := s.getSubDevice(ctx, sub)
+		if err != nil {
+			return nil, err
+		}
+		if !s.authUtils.ValidatePassword(device.DeviceSecret, device.DeviceName, device.ProductKey, sub.Sign) {
+			return nil, model.ErrDeviceSecretInvalid
+		}
+		devices = append(devices, device)
+		ids = append(ids, device.ID)
+	}
+	if len(ids) == 0 {
+		return devices, nil
+	}
+	if err := s.deviceRepo.UpdateGatewayID(ctx, ids, gateway.ID); err != nil {
+		return nil, err
+	}
+	for _, device := range devices {
+		device.GatewayID = gateway.ID
+	}
+	return devices, nil
+}
+
+// DeleteTopo 删除网关与子设备的拓扑关系
+func (s *DeviceService) DeleteTopo(ctx context.Context, gateway *model.IotDeviceDO, subs []*IotSubDeviceDTO) ([]*model.IotDeviceDO, error) {
+	devices := make([]*model.IotDeviceDO, 0, len(subs))
+	ids := make([]int64, 0, len(subs))
+	for _, sub := range subs {
+		device, err := s.ValidateSubDevice(ctx, gateway, sub, false)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, device)
+		ids = append(ids, device.ID)
+	}
+	if len(ids) == 0 {
+		return devices, nil
+	}
+	if err := s.deviceRepo.UpdateGatewayID(ctx, ids, 0); err != nil {
+		return nil, err
+	}
+	for _, device := range devices {
+		device.GatewayID = 0
+	}
+	return devices, nil
+}
+
+// GetSubDeviceList 获取网关下的子设备列表
+func (s *DeviceService) GetSubDeviceList(ctx context.Context, gatewayID int64) ([]*model.IotDeviceDO, error) {
+	return s.deviceRepo.ListByGatewayID(ctx, gatewayID)
+}
+
+// ValidateSubDevice 校验子设备属于该网关，checkSign 为 true 时同时校验签名
+func (s *DeviceService) ValidateSubDevice(ctx context.Context, gateway *model.IotDeviceDO, sub *IotSubDeviceDTO, checkSign bool) (*model.IotDeviceDO, error) {
+	device, err := s.getSubDevice(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+	if device.GatewayID != gateway.ID {
+		return nil, model.ErrDeviceSubNotInTopo
+	}
+	if checkSign && !s.authUtils.ValidatePassword(device.DeviceSecret, device.DeviceName, device.ProductKey, sub.Sign) {
+		return nil, model.ErrDeviceSecretInvalid
+	}
+	return device, nil
+}
+
+// getSubDevice 获取子设备，并校验设备类型
+func (s *DeviceService) getSubDevice(ctx context.Context, sub *IotSubDeviceDTO) (*model.IotDeviceDO, error) {
+	device, err := s.deviceRepo.GetByProductKeyAndName(ctx, sub.ProductKey, sub.DeviceName)
+	if err != nil || device == nil {
+		return nil, model.ErrDeviceNotExists
+	}
+	if device.DeviceType != consts.IotProductDeviceTypeSub {
+		return nil, model.ErrDeviceNotSubDevice
+	}
+	return device, nil
+}
+
+// parseSubDeviceParams 解析拓扑/子设备会话报文中的子设备列表
+// 报文格式: {"subDevices": [{"productKey": "...", "deviceName": "...", "sign": "..."}]}
+func parseSubDeviceParams(params map[string]any) ([]*IotSubDeviceDTO, error) {
+	var req struct {
+		SubDevices []*IotSubDeviceDTO `json:"subDevices"`
+	}
+	b, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &req); err != nil {
+		return nil, fmt.Errorf("invalid subDevices params: %w", err)
+	}
+	return req.SubDevices, nil
+}
+
+// buildSubDeviceReplyData 构建子设备列表的回复数据
+func buildSubDeviceReplyData(devices []*model.IotDeviceDO) []*IotSubDeviceDTO {
+	list := make([]*IotSubDeviceDTO, 0, len(devices))
+	for _, device := range devices {
+		list = append(list, &IotSubDeviceDTO{ProductKey: device.ProductKey, DeviceName: device.DeviceName})
+	}
+	return list
+}
@@ -0,0 +1,270 @@
+package iot
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"slices"
+	"sync"
+
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
+	"gorm.io/datatypes"
+)
+
+// earthRadiusMeters 地球平均半径（米），用于 haversine 距离计算
+const earthRadiusMeters = 6371000
+
+type GeofenceService struct {
+	geofenceRepo GeofenceRepository
+
+	// enabledCache 启用状态的围栏（已解析顶点），围栏变更时清除
+	cacheMu      sync.RWMutex
+	enabledCache []*geofence
+}
+
+// geofence 解析后的地理围栏，用于位置判定
+type geofence struct {
+	*model.IotGeofenceDO
+	points []iot2.IotGeofencePoint
+}
+
+func NewGeofenceService(geofenceRepo GeofenceRepository) *GeofenceService {
+	return &GeofenceService{
+		geofenceRepo: geofenceRepo,
+	}
+}
+
+func (s *GeofenceService) Create(ctx context.Context, r *iot2.IotGeofenceSaveReqVO) (int64, error) {
+	if err := validateGeofence(r); err != nil {
+		return 0, err
+	}
+	g := &model.IotGeofenceDO{}
+	fillGeofence(g, r)
+	if err := s.geofenceRepo.Create(ctx, g); err != nil {
+		return 0, err
+	}
+	s.invalidateCache()
+	return g.ID, nil
+}
+
+func (s *GeofenceService) Update(ctx context.Context, r *iot2.IotGeofenceSaveReqVO) error {
+	g, err := s.geofenceRepo.GetByID(ctx, r.ID)
+	if err != nil {
+		return err
+	}
+	if g == nil {
+		return model.ErrGeofenceNotExists
+	}
+	if err := validateGeofence(r); err != nil {
+		return err
+	}
+	fillGeofence(g, r)
+	if err := s.geofenceRepo.Update(ctx, g); err != nil {
+		return err
+	}
+	s.invalidateCache()
+	return nil
+}
+
+func (s *GeofenceService) Delete(ctx context.Context, id int64) error {
+	g, err := s.geofenceRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if g == nil {
+		return model.ErrGeofenceNotExists
+	}
+	if err := s.geofenceRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.invalidateCache()
+	return nil
+}
+
+func (s *GeofenceService) Get(ctx context.Context, id int64) (*iot2.IotGeofenceRespVO, error) {
+	g, err := s.geofenceRepo.GetByID(ctx, id)
+	if err != nil || g == nil {
+		return nil, err
+	}
+	return toGeofenceRespVO(g), nil
+}
+
+func (s *GeofenceService) GetPage(ctx context.Context, r *iot2.IotGeofencePageReqVO) (*pagination.PageResult[*iot2.IotGeofenceRespVO], error) {
+	page, err := s.geofenceRepo.GetPage(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*iot2.IotGeofenceRespVO, 0, len(page.List))
+	for _, g := range page.List {
+		list = append(list, toGeofenceRespVO(g))
+	}
+	return &pagination.PageResult[*iot2.IotGeofenceRespVO]{List: list, Total: page.Total}, nil
+}
+
+// GetDeviceGeofences 获取作用于设备的启用围栏：同租户，产品与分组为 0 或与设备匹配
+func (s *GeofenceService) GetDeviceGeofences(ctx context.Context, device *model.IotDeviceDO) ([]*geofence, error) {
+	geofences, err := s.getEnabledFromCache(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var groupIDs []int64
+	if len(device.GroupIDs) > 0 {
+		_ = json.Unmarshal(device.GroupIDs, &groupIDs)
+	}
+	result := make([]*geofence, 0)
+	for _, g := range geofences {
+		if g.TenantID != device.TenantID {
+			continue
+		}
+		if g.ProductID != 0 && g.ProductID != device.ProductID {
+			continue
+		}
+		if g.GroupID != 0 && !slices.Contains(groupIDs, g.GroupID) {
+			continue
+		}
+		result = append(result, g)
+	}
+	return result, nil
+}
+
+// getEnabledFromCache 获取启用的围栏（缓存）
+func (s *GeofenceService) getEnabledFromCache(ctx context.Context) ([]*geofence, error) {
+	s.cacheMu.RLock()
+	cached := s.enabledCache
+	s.cacheMu.RUnlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	list, err := s.geofenceRepo.GetListByStatus(ctx, consts.CommonStatusEnable)
+	if err != nil {
+		return nil, err
+	}
+	geofences := make([]*geofence, 0, len(list))
+	for _, g := range list {
+		geofences = append(geofences, &geofence{IotGeofenceDO: g, points: parseGeofencePoints(g.Points)})
+	}
+	s.cacheMu.Lock()
+	s.enabledCache = geofences
+	s.cacheMu.Unlock()
+	return geofences, nil
+}
+
+// invalidateCache 围栏变更后清除缓存
+func (s *GeofenceService) invalidateCache() {
+	s.cacheMu.Lock()
+	s.enabledCache = nil
+	s.cacheMu.Unlock()
+}
+
+// Contains 判断坐标是否位于围栏内（含边界）
+func (g *geofence) Contains(latitude, longitude float64) bool {
+	switch g.Type {
+	case consts.IotGeofenceTypeCircle:
+		return haversineDistance(g.CenterLatitude, g.CenterLongitude, latitude, longitude) <= g.Radius
+	case consts.IotGeofenceTypePolygon:
+		return polygonContains(g.points, latitude, longitude)
+	default:
+		return false
+	}
+}
+
+// haversineDistance 计算两个经纬度坐标之间的球面距离（米）
+func haversineDistance(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLng := (lng2 - lng1) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// polygonContains 射线法判断坐标是否位于多边形内，经度为 x 轴、纬度为 y 轴
+func polygonContains(points []iot2.IotGeofencePoint, latitude, longitude float64) bool {
+	if len(points) < 3 {
+		return false
+	}
+	inside := false
+	for i, j := 0, len(points)-1; i < len(points); j, i = i, i+1 {
+		pi, pj := points[i], points[j]
+		if (pi.Latitude > latitude) != (pj.Latitude > latitude) &&
+			longitude < (pj.Longitude-pi.Longitude)*(latitude-pi.Latitude)/(pj.Latitude-pi.Latitude)+pi.Longitude {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// validCoordinate 校验经纬度范围
+func validCoordinate(latitude, longitude float64) bool {
+	return latitude >= -90 && latitude <= 90 && longitude >= -180 && longitude <= 180
+}
+
+// validateGeofence 按围栏类型校验中心点、半径或顶点
+func validateGeofence(r *iot2.IotGeofenceSaveReqVO) error {
+	switch r.Type {
+	case consts.IotGeofenceTypeCircle:
+		if !validCoordinate(r.CenterLatitude, r.CenterLongitude) || r.Radius <= 0 {
+			return model.ErrGeofenceCircleInvalid
+		}
+	case consts.IotGeofenceTypePolygon:
+		if len(r.Points) < 3 {
+			return model.ErrGeofencePointsInvalid
+		}
+		for _, p := range r.Points {
+			if !validCoordinate(p.Latitude, p.Longitude) {
+				return model.ErrGeofencePointsInvalid
+			}
+		}
+	default:
+		return model.ErrGeofenceTypeInvalid
+	}
+	return nil
+}
+
+// fillGeofence 将保存请求写入围栏 DO，只保留与围栏类型相关的字段
+func fillGeofence(g *model.IotGeofenceDO, r *iot2.IotGeofenceSaveReqVO) {
+	g.Name = r.Name
+	g.Type = r.Type
+	g.ProductID = r.ProductID
+	g.GroupID = r.GroupID
+	g.Status = r.Status
+	g.Description = r.Description
+	g.CenterLatitude, g.CenterLongitude, g.Radius, g.Points = 0, 0, 0, nil
+	if r.Type == consts.IotGeofenceTypeCircle {
+		g.CenterLatitude = r.CenterLatitude
+		g.CenterLongitude = r.CenterLongitude
+		g.Radius = r.Radius
+	} else {
+		pointsJson, _ := json.Marshal(r.Points)
+		g.Points = datatypes.JSON(pointsJson)
+	}
+}
+
+// parseGeofencePoints 解析多边形顶点
+func parseGeofencePoints(data datatypes.JSON) []iot2.IotGeofencePoint {
+	var points []iot2.IotGeofencePoint
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &points)
+	}
+	return points
+}
+
+func toGeofenceRespVO(g *model.IotGeofenceDO) *iot2.IotGeofenceRespVO {
+	return &iot2.IotGeofenceRespVO{
+		ID:              g.ID,
+		Name:            g.Name,
+		Type:            g.Type,
+		ProductID:       g.ProductID,
+		GroupID:         g.GroupID,
+		CenterLatitude:  g.CenterLatitude,
+		CenterLongitude: g.CenterLongitude,
+		Radius:          g.Radius,
+		Points:          parseGeofencePoints(g.Points),
+		Status:          g.Status,
+		Description:     g.Description,
+		CreateTime:      g.CreateTime,
+	}
+}
@@ -0,0 +1,31 @@
+package job
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+)
+
+// IotDevicePropertyPurgeJob 设备属性过期清理 Job
+// 按产品配置的保留天数清理原始属性，并清理过期的分钟、小时聚合
+type IotDevicePropertyPurgeJob struct {
+	rollupService *iot.DevicePropertyRollupService
+}
+
+func NewIotDevicePropertyPurgeJob(rollupService *iot.DevicePropertyRollupService) *IotDevicePropertyPurgeJob {
+	return &IotDevicePropertyPurgeJob{rollupService: rollupService}
+}
+
+func (j *IotDevicePropertyPurgeJob) Execute(ctx context.Context, param string) error {
+	count, err := j.rollupService.PurgeExpiredProperties(ctx, time.Now())
+	if count > 0 {
+		log.Printf("[IotDevicePropertyPurgeJob] Purged %d expired property records", count)
+	}
+	return err
+}
+
+func (j *IotDevicePropertyPurgeJob) GetHandlerName() string {
+	return "iotDevicePropertyPurgeJob"
+}
@@ -0,0 +1,38 @@
+package job
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+)
+
+// IotDevicePropertyRollupJob 设备属性聚合 Job
+// 重算最近一段时间（参数为回溯分钟数，默认 60）的分钟、小时、天聚合
+type IotDevicePropertyRollupJob struct {
+	rollupService *iot.DevicePropertyRollupService
+}
+
+func NewIotDevicePropertyRollupJob(rollupService *iot.DevicePropertyRollupService) *IotDevicePropertyRollupJob {
+	return &IotDevicePropertyRollupJob{rollupService: rollupService}
+}
+
+func (j *IotDevicePropertyRollupJob) Execute(ctx context.Context, param string) error {
+	lookback := iot.DevicePropertyRollupDefaultLookback
+	if minutes, err := strconv.Atoi(strings.TrimSpace(param)); err == nil && minutes > 0 {
+		lookback = time.Duration(minutes) * time.Minute
+	}
+	count, err := j.rollupService.RollupProperties(ctx, time.Now(), lookback)
+	if err != nil {
+		return err
+	}
+	log.Printf("[IotDevicePropertyRollupJob] Rolled up %d property buckets", count)
+	return nil
+}
+
+func (j *IotDevicePropertyRollupJob) GetHandlerName() string {
+	return "iotDevicePropertyRollupJob"
+}
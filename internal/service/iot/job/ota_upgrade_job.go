@@ -0,0 +1,37 @@
+package job
+
+import (
+	"context"
+	"log"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/iot"
+)
+
+// IotOtaUpgradeJob OTA 升级推送 Job
+// 按任务配置的速率与并发推送升级消息，并将超时未完成的升级记录置为失败
+type IotOtaUpgradeJob struct {
+	otaTaskService       *iot.OtaTaskService
+	deviceMessageService *iot.DeviceMessageService
+}
+
+func NewIotOtaUpgradeJob(otaTaskService *iot.OtaTaskService, deviceMessageService *iot.DeviceMessageService) *IotOtaUpgradeJob {
+	return &IotOtaUpgradeJob{
+		otaTaskService:       otaTaskService,
+		deviceMessageService: deviceMessageService,
+	}
+}
+
+func (j *IotOtaUpgradeJob) Execute(ctx context.Context, param string) error {
+	count, err := j.otaTaskService.ExecuteUpgrade(ctx, j.deviceMessageService.SendDeviceMessageCore)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		log.Printf("[IotOtaUpgradeJob] Pushed upgrade to %d devices", count)
+	}
+	return nil
+}
+
+func (j *IotOtaUpgradeJob) GetHandlerName() string {
+	return "iotOtaUpgradeJob"
+}
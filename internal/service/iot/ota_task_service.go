@@ -3,18 +3,40 @@ package iot
 import (
 	"context"
 	"log"
+	"time"
 
 	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
 	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	iotcore "github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
 	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/infra"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
 )
 
+const (
+	// otaDefaultTimeout 升级记录默认超时时间（秒）：推送后超过该时间无进度上报即判定失败
+	otaDefaultTimeout = 30 * 60
+	// otaPushBatchSize 单个任务每轮最多推送的设备数
+	otaPushBatchSize = 100
+)
+
+// otaInProgressStatuses 已推送、尚未结束的升级记录状态
+var otaInProgressStatuses = []int{
+	consts.IotOtaRecordStatusPushed,
+	consts.IotOtaRecordStatusDownloading,
+	consts.IotOtaRecordStatusVerifying,
+	consts.IotOtaRecordStatusUpgrading,
+}
+
+// OtaUpgradeSender 下发 OTA 升级消息（由 DeviceMessageService.SendDeviceMessageCore 实现）
+type OtaUpgradeSender func(ctx context.Context, message *iotcore.IotDeviceMessage) error
+
 type OtaTaskService struct {
 	otaFirmwareRepo   OtaFirmwareRepository
 	otaTaskRepo       OtaTaskRepository
 	otaTaskRecordRepo OtaTaskRecordRepository
 	deviceSvc         *DeviceService
+	fileSvc           *infra.FileService
 }
 
 func NewOtaTaskService(
@@ -22,12 +44,14 @@ func NewOtaTaskService(
 	otaTaskRepo OtaTaskRepository,
 	otaTaskRecordRepo OtaTaskRecordRepository,
 	deviceSvc *DeviceService,
+	fileSvc *infra.FileService,
 ) *OtaTaskService {
 	return &OtaTaskService{
 		otaFirmwareRepo:   otaFirmwareRepo,
 		otaTaskRepo:       otaTaskRepo,
 		otaTaskRecordRepo: otaTaskRecordRepo,
 		deviceSvc:         deviceSvc,
+		fileSvc:           fileSvc,
 	}
 }
 
@@ -40,39 +64,57 @@ func (s *OtaTaskService) Create(ctx context.Context, r *iot2.IotOtaTaskCreateReq
 		return 0, model.ErrOtaFirmwareNotExists
 	}
 
-	task := &model.IotOtaTaskDO{
-		Name:        r.Name,
-		Description: r.Description,
-		FirmwareID:  r.FirmwareID,
-		Status:      consts.IotOtaTaskStatusWait,
-		DeviceScope: r.DeviceScope,
-	}
-
-	if err := s.otaTaskRepo.Create(ctx, task); err != nil {
-		return 0, err
-	}
-
-	// Upgrade records logic (simplified, ideally in transaction)
-	var deviceIDs []int64
+	// 1. 确定升级设备：全部设备取固件所属产品下的设备
+	var devices []*model.IotDeviceDO
 	if r.DeviceScope == consts.IotOtaDeviceScopeAll {
-		// All devices for product logic...
+		devices, err = s.deviceSvc.GetListByCondition(ctx, nil, &firmware.ProductID)
+		if err != nil {
+			return 0, err
+		}
 	} else {
-		deviceIDs = r.DeviceIDs
-	}
-
-	if len(deviceIDs) > 0 {
-		records := make([]*model.IotOtaTaskRecordDO, 0, len(deviceIDs))
-		for _, dID := range deviceIDs {
-			records = append(records, &model.IotOtaTaskRecordDO{
-				FirmwareID: r.FirmwareID,
-				TaskID:     task.ID,
-				DeviceID:   dID,
-				Status:     consts.IotOtaRecordStatusWait,
-			})
+		for _, deviceID := range r.DeviceIDs {
+			device, err := s.deviceSvc.Get(ctx, deviceID)
+			if err != nil || device == nil {
+				return 0, model.ErrDeviceNotExists
+			}
+			// 固件只能升级其所属产品下的设备
+			if device.ProductID != firmware.ProductID {
+				return 0, model.ErrOtaTaskDeviceProductInvalid
+			}
+			devices = append(devices, device)
 		}
-		s.otaTaskRecordRepo.Create(ctx, records)
-		task.DeviceTotalCount = int32(len(deviceIDs))
-		s.otaTaskRepo.Update(ctx, task)
+	}
+
+	// 2. 创建任务及升级记录：存在升级设备时直接进入发布中，由升级 Job 按速率推送
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = otaDefaultTimeout
+	}
+	task := &model.IotOtaTaskDO{
+		Name:             r.Name,
+		Description:      r.Description,
+		FirmwareID:       r.FirmwareID,
+		Status:           consts.IotOtaTaskStatusWait,
+		DeviceScope:      r.DeviceScope,
+		DeviceTotalCount: int32(len(devices)),
+		MaxConcurrency:   r.MaxConcurrency,
+		RolloutRate:      r.RolloutRate,
+		Timeout:          timeout,
+	}
+	if len(devices) > 0 {
+		task.Status = consts.IotOtaTaskStatusRunning
+	}
+	records := make([]*model.IotOtaTaskRecordDO, 0, len(devices))
+	for _, device := range devices {
+		records = append(records, &model.IotOtaTaskRecordDO{
+			FirmwareID:     r.FirmwareID,
+			DeviceID:       device.ID,
+			FromFirmwareID: device.FirmwareID,
+			Status:         consts.IotOtaRecordStatusWait,
+		})
+	}
+	if err := s.otaTaskRepo.CreateWithRecords(ctx, task, records); err != nil {
+		return 0, err
 	}
 
 	return task.ID, nil
@@ -94,11 +136,18 @@ func (s *OtaTaskService) Cancel(ctx context.Context, id int64) error {
 	if task == nil {
 		return model.ErrOtaTaskNotExists
 	}
-	if task.Status != consts.IotOtaTaskStatusWait {
+	if task.Status != consts.IotOtaTaskStatusWait && task.Status != consts.IotOtaTaskStatusRunning {
 		return model.ErrOtaTaskStatusNotAllowCancel
 	}
 	task.Status = consts.IotOtaTaskStatusCancel
-	return s.otaTaskRepo.Update(ctx, task)
+	if err := s.otaTaskRepo.Update(ctx, task); err != nil {
+		return err
+	}
+	// 尚未开始升级的设备一并取消；已在下载、升级中的设备不受影响，继续上报进度
+	return s.otaTaskRecordRepo.UpdateStatusByTaskIdAndStatus(ctx, id, []int{
+		consts.IotOtaRecordStatusWait,
+		consts.IotOtaRecordStatusPushed,
+	}, consts.IotOtaRecordStatusCanceled, "任务已取消")
 }
 
 func (s *OtaTaskService) GetRecordPage(ctx context.Context, r *iot2.IotOtaTaskRecordPageReqVO) (*pagination.PageResult[*model.IotOtaTaskRecordDO], error) {
@@ -112,11 +161,9 @@ func (s *OtaTaskService) GetRecordPage(ctx context.Context, r *iot2.IotOtaTaskRe
 // description: 状态描述
 func (s *OtaTaskService) UpdateOtaRecordProgress(ctx context.Context, device *model.IotDeviceDO, version string, status int, progress int, description string) error {
 	// 1. 查询进行中的 OTA 升级记录
-	records, err := s.otaTaskRecordRepo.GetListByDeviceIdAndStatus(ctx, device.ID, []int{
+	records, err := s.otaTaskRecordRepo.GetListByDeviceIdAndStatus(ctx, device.ID, append([]int{
 		consts.IotOtaRecordStatusWait,
-		consts.IotOtaRecordStatusPushed,
-		consts.IotOtaRecordStatusUpgrading,
-	})
+	}, otaInProgressStatuses...))
 	if err != nil {
 		return err
 	}
@@ -148,13 +195,9 @@ func (s *OtaTaskService) UpdateOtaRecordProgress(ctx context.Context, device *mo
 		return err
 	}
 
-	// 4. 如果升级成功，更新设备固件版本
+	// 4. 如果升级成功，更新设备固件版本与任务成功数
 	if status == consts.IotOtaRecordStatusSuccess {
-		if err := s.deviceSvc.UpdateDeviceFirmware(ctx, device.ID, firmware.ID); err != nil {
-			log.Printf("[OtaTaskService] Failed to update device %d firmware: %v", device.ID, err)
-		} else {
-			log.Printf("[OtaTaskService] Device %d OTA success, firmware updated to %d", device.ID, firmware.ID)
-		}
+		s.onRecordSuccess(ctx, device, record, firmware)
 	}
 
 	// 5. 检查是否所有记录都已完成，更新任务状态
@@ -166,27 +209,194 @@ func (s *OtaTaskService) UpdateOtaRecordProgress(ctx context.Context, device *mo
 // checkAndUpdateTaskStatus 检查并更新任务状态
 func (s *OtaTaskService) checkAndUpdateTaskStatus(ctx context.Context, taskID int64) {
 	// 查询是否还有进行中的记录
-	inProgressRecords, err := s.otaTaskRecordRepo.GetListByTaskIdAndStatus(ctx, taskID, []int{
+	inProgressCount, err := s.otaTaskRecordRepo.CountByTaskIdAndStatus(ctx, taskID, append([]int{
 		consts.IotOtaRecordStatusWait,
-		consts.IotOtaRecordStatusPushed,
-		consts.IotOtaRecordStatusUpgrading,
-	})
+	}, otaInProgressStatuses...))
 	if err != nil {
 		log.Printf("[OtaTaskService] Check task status error: %v", err)
 		return
 	}
 
 	// 如果还有进行中的记录，不更新任务状态
-	if len(inProgressRecords) > 0 {
+	if inProgressCount > 0 {
 		return
 	}
 
-	// 所有记录都已完成，更新任务状态为已结束
+	// 所有记录都已完成，更新任务状态为已结束（已取消的任务保持取消状态）
 	task, err := s.otaTaskRepo.GetByID(ctx, taskID)
-	if err != nil || task == nil {
+	if err != nil || task == nil || task.Status != consts.IotOtaTaskStatusRunning {
 		return
 	}
 	task.Status = consts.IotOtaTaskStatusDone
 	s.otaTaskRepo.Update(ctx, task)
 	log.Printf("[OtaTaskService] Task %d completed", taskID)
 }
+
+// ReportFirmwareVersion 处理设备上报的当前固件版本
+// 版本与进行中升级记录的目标固件一致时，视为升级成功
+func (s *OtaTaskService) ReportFirmwareVersion(ctx context.Context, device *model.IotDeviceDO, version string) error {
+	records, err := s.otaTaskRecordRepo.GetListByDeviceIdAndStatus(ctx, device.ID, otaInProgressStatuses)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		firmware, err := s.otaFirmwareRepo.GetByID(ctx, record.FirmwareID)
+		if err != nil || firmware == nil || firmware.Version != version {
+			continue
+		}
+		record.Status = consts.IotOtaRecordStatusSuccess
+		record.Progress = 100
+		record.Description = "设备上报版本 " + version
+		if err := s.otaTaskRecordRepo.Update(ctx, record); err != nil {
+			return err
+		}
+		s.onRecordSuccess(ctx, device, record, firmware)
+		s.checkAndUpdateTaskStatus(ctx, record.TaskID)
+	}
+	return nil
+}
+
+// onRecordSuccess 升级成功：更新设备固件版本，累加任务成功设备数
+func (s *OtaTaskService) onRecordSuccess(ctx context.Context, device *model.IotDeviceDO, record *model.IotOtaTaskRecordDO, firmware *model.IotOtaFirmwareDO) {
+	if err := s.deviceSvc.UpdateDeviceFirmware(ctx, device.ID, firmware.ID); err != nil {
+		log.Printf("[OtaTaskService] Failed to update device %d firmware: %v", device.ID, err)
+	} else {
+		log.Printf("[OtaTaskService] Device %d OTA success, firmware updated to %d", device.ID, firmware.ID)
+	}
+	task, err := s.otaTaskRepo.GetByID(ctx, record.TaskID)
+	if err != nil || task == nil {
+		return
+	}
+	task.DeviceSuccessCount++
+	if err := s.otaTaskRepo.Update(ctx, task); err != nil {
+		log.Printf("[OtaTaskService] Failed to update task %d success count: %v", task.ID, err)
+	}
+}
+
+// ExecuteUpgrade 推进所有发布中的升级任务：将超时的记录置为失败，并在速率与并发限制内推送升级消息
+// 返回本轮推送的设备数
+func (s *OtaTaskService) ExecuteUpgrade(ctx context.Context, send OtaUpgradeSender) (int, error) {
+	tasks, err := s.otaTaskRepo.GetListByStatus(ctx, consts.IotOtaTaskStatusRunning)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, task := range tasks {
+		n, err := s.executeTaskUpgrade(ctx, task, send)
+		if err != nil {
+			log.Printf("[OtaTaskService] Execute task %d upgrade failed: %v", task.ID, err)
+			continue
+		}
+		count += n
+	}
+	return count, nil
+}
+
+// executeTaskUpgrade 推进单个升级任务
+func (s *OtaTaskService) executeTaskUpgrade(ctx context.Context, task *model.IotOtaTaskDO, send OtaUpgradeSender) (int, error) {
+	// 1. 超时未上报进度的记录置为失败
+	if err := s.failTimeoutRecords(ctx, task); err != nil {
+		return 0, err
+	}
+
+	// 2. 计算本轮可推送的设备数：并发限制按进行中的记录数，速率限制按最近一分钟的推送数
+	limit := int64(otaPushBatchSize)
+	if task.MaxConcurrency > 0 {
+		inProgress, err := s.otaTaskRecordRepo.CountByTaskIdAndStatus(ctx, task.ID, otaInProgressStatuses)
+		if err != nil {
+			return 0, err
+		}
+		limit = min(limit, int64(task.MaxConcurrency)-inProgress)
+	}
+	if task.RolloutRate > 0 {
+		pushed, err := s.otaTaskRecordRepo.CountByTaskIdAndPushTimeAfter(ctx, task.ID, time.Now().Add(-time.Minute))
+		if err != nil {
+			return 0, err
+		}
+		limit = min(limit, int64(task.RolloutRate)-pushed)
+	}
+	if limit <= 0 {
+		return 0, nil
+	}
+
+	records, err := s.otaTaskRecordRepo.GetListByTaskIdAndStatusWithLimit(ctx, task.ID, consts.IotOtaRecordStatusWait, int(limit))
+	if err != nil {
+		return 0, err
+	}
+	if len(records) == 0 {
+		s.checkAndUpdateTaskStatus(ctx, task.ID)
+		return 0, nil
+	}
+
+	// 3. 构建升级消息参数
+	firmware, err := s.otaFirmwareRepo.GetByID(ctx, task.FirmwareID)
+	if err != nil {
+		return 0, err
+	}
+	if firmware == nil {
+		return 0, model.ErrOtaFirmwareNotExists
+	}
+	fileURL, err := s.fileSvc.GetFileDownloadUrl(ctx, firmware.FileURL)
+	if err != nil {
+		log.Printf("[OtaTaskService] Presign firmware %d url failed, using original url: %v", firmware.ID, err)
+		fileURL = firmware.FileURL
+	}
+	params := map[string]any{
+		"version":             firmware.Version,
+		"fileUrl":             fileURL,
+		"fileSize":            firmware.FileSize,
+		"fileDigestAlgorithm": firmware.FileDigestAlgorithm,
+		"fileDigestValue":     firmware.FileDigestValue,
+	}
+
+	// 4. 逐个推送
+	count := 0
+	for _, record := range records {
+		now := time.Now()
+		record.PushTime = &now
+		err := send(ctx, &iotcore.IotDeviceMessage{
+			Method:   consts.IotDeviceMessageMethodOtaUpgrade,
+			Params:   params,
+			DeviceID: record.DeviceID,
+		})
+		if err != nil {
+			log.Printf("[OtaTaskService] Push upgrade to device %d failed: %v", record.DeviceID, err)
+			record.Status = consts.IotOtaRecordStatusFail
+			record.Description = "推送失败: " + err.Error()
+		} else {
+			record.Status = consts.IotOtaRecordStatusPushed
+			record.Description = "升级消息已推送"
+			count++
+		}
+		if err := s.otaTaskRecordRepo.Update(ctx, record); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// failTimeoutRecords 将超过任务超时时间未上报进度的记录置为失败
+func (s *OtaTaskService) failTimeoutRecords(ctx context.Context, task *model.IotOtaTaskDO) error {
+	timeout := task.Timeout
+	if timeout <= 0 {
+		timeout = otaDefaultTimeout
+	}
+	records, err := s.otaTaskRecordRepo.GetListByTaskIdAndStatus(ctx, task.ID, otaInProgressStatuses)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(-time.Duration(timeout) * time.Second)
+	for _, record := range records {
+		// 最近一次进度上报（或推送）的时间
+		if record.UpdateTime.After(deadline) {
+			continue
+		}
+		record.Status = consts.IotOtaRecordStatusFail
+		record.Description = "升级超时"
+		if err := s.otaTaskRecordRepo.Update(ctx, record); err != nil {
+			return err
+		}
+		log.Printf("[OtaTaskService] Record %d of task %d timed out", record.ID, task.ID)
+	}
+	return nil
+}
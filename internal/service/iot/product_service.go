@@ -2,23 +2,37 @@ package iot
 
 import (
 	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
 
 	"github.com/google/uuid"
 	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
 	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	iotcore "github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/gateway/codec"
 	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
 )
 
 type ProductService struct {
-	productRepo ProductRepository
-	deviceRepo  DeviceRepository
+	productRepo   ProductRepository
+	deviceRepo    DeviceRepository
+	codecRegistry *codec.CodecRegistry
+
+	// productCache 产品缓存（网关按 productKey 获取编解码配置），key: productKey
+	cacheMu      sync.RWMutex
+	productCache map[string]*model.IotProductDO
 }
 
-func NewProductService(productRepo ProductRepository, deviceRepo DeviceRepository) *ProductService {
+func NewProductService(productRepo ProductRepository, deviceRepo DeviceRepository, codecRegistry *codec.CodecRegistry) *ProductService {
 	return &ProductService{
-		productRepo: productRepo,
-		deviceRepo:  deviceRepo,
+		productRepo:   productRepo,
+		deviceRepo:    deviceRepo,
+		codecRegistry: codecRegistry,
+		productCache:  make(map[string]*model.IotProductDO),
 	}
 }
 
@@ -31,6 +45,9 @@ func (s *ProductService) Create(ctx context.Context, r *iot2.IotProductSaveReqVO
 	} else {
 		r.ProductKey = uuid.New().String()[0:8]
 	}
+	if err := s.validateCodecConfig(r.CodecType, r.CodecConfig); err != nil {
+		return 0, err
+	}
 
 	product := &model.IotProductDO{
 		Name:         r.Name,
@@ -44,6 +61,11 @@ func (s *ProductService) Create(ctx context.Context, r *iot2.IotProductSaveReqVO
 		NetType:      r.NetType,
 		LocationType: r.LocationType,
 		CodecType:    r.CodecType,
+		CodecConfig:  r.CodecConfig,
+
+		PropertyRetentionDays: r.PropertyRetentionDays,
+		ProductSecret:         newProductSecret(),
+		RegisterEnabled:       r.RegisterEnabled,
 	}
 	if err := s.productRepo.Create(ctx, product); err != nil {
 		return 0, err
@@ -62,6 +84,9 @@ func (s *ProductService) Update(ctx context.Context, r *iot2.IotProductSaveReqVO
 	if product.Status == consts.IotProductStatusPublished {
 		return model.ErrProductStatusNotDelete
 	}
+	if err := s.validateCodecConfig(r.CodecType, r.CodecConfig); err != nil {
+		return err
+	}
 
 	product.Name = r.Name
 	product.CategoryID = r.CategoryID
@@ -72,8 +97,15 @@ func (s *ProductService) Update(ctx context.Context, r *iot2.IotProductSaveReqVO
 	product.NetType = r.NetType
 	product.LocationType = r.LocationType
 	product.CodecType = r.CodecType
+	product.CodecConfig = r.CodecConfig
+	product.PropertyRetentionDays = r.PropertyRetentionDays
+	product.RegisterEnabled = r.RegisterEnabled
 
-	return s.productRepo.Update(ctx, product)
+	if err := s.productRepo.Update(ctx, product); err != nil {
+		return err
+	}
+	s.invalidateProductCache(product.ProductKey)
+	return nil
 }
 
 func (s *ProductService) UpdateStatus(ctx context.Context, id int64, status int8) error {
@@ -86,7 +118,29 @@ func (s *ProductService) UpdateStatus(ctx context.Context, id int64, status int8
 	}
 
 	product.Status = status
-	return s.productRepo.Update(ctx, product)
+	if err := s.productRepo.Update(ctx, product); err != nil {
+		return err
+	}
+	s.invalidateProductCache(product.ProductKey)
+	return nil
+}
+
+// ResetSecret 重置产品密钥（一型一密），返回新密钥；已注册设备使用各自的设备密钥，不受影响
+func (s *ProductService) ResetSecret(ctx context.Context, id int64) (string, error) {
+	product, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if product == nil {
+		return "", model.ErrProductNotExists
+	}
+
+	product.ProductSecret = newProductSecret()
+	if err := s.productRepo.Update(ctx, product); err != nil {
+		return "", err
+	}
+	s.invalidateProductCache(product.ProductKey)
+	return product.ProductSecret, nil
 }
 
 func (s *ProductService) Delete(ctx context.Context, id int64) error {
@@ -108,7 +162,11 @@ func (s *ProductService) Delete(ctx context.Context, id int64) error {
 		return model.ErrProductDeleteFailHasDevice
 	}
 
-	return s.productRepo.Delete(ctx, id)
+	if err := s.productRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.invalidateProductCache(product.ProductKey)
+	return nil
 }
 
 func (s *ProductService) Get(ctx context.Context, id int64) (*model.IotProductDO, error) {
@@ -126,3 +184,102 @@ func (s *ProductService) GetSimpleList(ctx context.Context) ([]*model.IotProduct
 func (s *ProductService) GetPage(ctx context.Context, r *iot2.IotProductPageReqVO) (*pagination.PageResult[*model.IotProductDO], error) {
 	return s.productRepo.GetPage(ctx, r)
 }
+
+// GetByKeyFromCache 获取产品（缓存）
+func (s *ProductService) GetByKeyFromCache(ctx context.Context, productKey string) (*model.IotProductDO, error) {
+	s.cacheMu.RLock()
+	product, ok := s.productCache[productKey]
+	s.cacheMu.RUnlock()
+	if ok {
+		return product, nil
+	}
+	product, err := s.productRepo.GetByKey(ctx, productKey)
+	if err != nil {
+		return nil, err
+	}
+	if product == nil {
+		return nil, model.ErrProductNotExists
+	}
+	s.cacheMu.Lock()
+	s.productCache[productKey] = product
+	s.cacheMu.Unlock()
+	return product, nil
+}
+
+// LoadProductCodec 加载产品选择的编解码类型与配置，供网关 CodecRegistry 使用
+func (s *ProductService) LoadProductCodec(productKey string) (string, string, error) {
+	product, err := s.GetByKeyFromCache(context.Background(), productKey)
+	if err != nil {
+		return "", "", err
+	}
+	return product.CodecType, product.CodecConfig, nil
+}
+
+// DebugCodec 编解码调试：按产品（或请求指定）的编解码配置解码十六进制报文，或将消息编码为十六进制报文
+func (s *ProductService) DebugCodec(ctx context.Context, r *iot2.IotProductCodecDebugReqVO) (*iot2.IotProductCodecDebugRespVO, error) {
+	codecType, codecConfig := r.CodecType, r.CodecConfig
+	if codecType == "" {
+		product, err := s.productRepo.GetByID(ctx, r.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if product == nil {
+			return nil, model.ErrProductNotExists
+		}
+		codecType, codecConfig = product.CodecType, product.CodecConfig
+	}
+	deviceCodec, err := s.codecRegistry.Resolve(codecType, codecConfig)
+	if err != nil {
+		return nil, errors.NewBizError(model.ErrProductCodecConfigInvalid.Code, fmt.Sprintf("产品编解码配置不正确: %v", err))
+	}
+
+	resp := &iot2.IotProductCodecDebugRespVO{CodecType: codecType}
+	if r.Direction == "decode" {
+		payload, err := hex.DecodeString(strings.ReplaceAll(r.Payload, " ", ""))
+		if err != nil {
+			return nil, errors.NewBizError(model.ErrProductCodecDebugFail.Code, "报文不是合法的十六进制")
+		}
+		message, err := deviceCodec.Decode(payload)
+		if err != nil {
+			return nil, errors.NewBizError(model.ErrProductCodecDebugFail.Code, fmt.Sprintf("编解码调试失败: %v", err))
+		}
+		resp.Payload = strings.ToUpper(hex.EncodeToString(payload))
+		resp.Method = message.Method
+		resp.Params = message.Params
+		return resp, nil
+	}
+
+	message := &iotcore.IotDeviceMessage{Method: r.Method, Params: r.Params}
+	payload, err := deviceCodec.Encode(message)
+	if err != nil {
+		return nil, errors.NewBizError(model.ErrProductCodecDebugFail.Code, fmt.Sprintf("编解码调试失败: %v", err))
+	}
+	resp.Payload = strings.ToUpper(hex.EncodeToString(payload))
+	resp.Method = r.Method
+	resp.Params = r.Params
+	return resp, nil
+}
+
+// validateCodecConfig 校验产品的编解码配置（仅可配置编解码器需要）
+func (s *ProductService) validateCodecConfig(codecType, codecConfig string) error {
+	if codecType != codec.BinaryCodecType {
+		return nil
+	}
+	if _, err := s.codecRegistry.Resolve(codecType, codecConfig); err != nil {
+		return errors.NewBizError(model.ErrProductCodecConfigInvalid.Code, fmt.Sprintf("产品编解码配置不正确: %v", err))
+	}
+	return nil
+}
+
+// newProductSecret 生成产品密钥
+func newProductSecret() string {
+	return strings.ReplaceAll(uuid.New().String(), "-", "")
+}
+
+// invalidateProductCache 清除产品缓存，并移除产品按旧配置创建的编解码器
+func (s *ProductService) invalidateProductCache(productKey string) {
+	s.cacheMu.Lock()
+	delete(s.productCache, productKey)
+	s.cacheMu.Unlock()
+	s.codecRegistry.EvictProduct(productKey)
+}
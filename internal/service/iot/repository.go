@@ -5,6 +5,7 @@ import (
 
 	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
 	"github.com/wxlbd/ruoyi-mall-go/internal/model"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
@@ -25,12 +26,20 @@ type DeviceRepository interface {
 	Create(ctx context.Context, device *model.IotDeviceDO) error
 	Update(ctx context.Context, device *model.IotDeviceDO) error
 	UpdateActiveTime(ctx context.Context, id int64, activeTime time.Time) error
+	// UpdateLocation 更新设备当前坐标
+	UpdateLocation(ctx context.Context, id int64, latitude, longitude decimal.Decimal) error
 	Delete(ctx context.Context, id int64) error
 	DeleteList(ctx context.Context, ids []int64) error
 	GetByID(ctx context.Context, id int64) (*model.IotDeviceDO, error)
 	GetPage(ctx context.Context, req *iot.IotDevicePageReqVO) (*pagination.PageResult[*model.IotDeviceDO], error)
+	// GetList 按导出条件查询设备列表（不分页）
+	GetList(ctx context.Context, req *iot.IotDeviceExportReqVO) ([]*model.IotDeviceDO, error)
+	// ListByGroupID 查询分组下的设备
+	ListByGroupID(ctx context.Context, groupID int64) ([]*model.IotDeviceDO, error)
 	CountByProductID(ctx context.Context, productID int64) (int64, error)
 	CountByGatewayID(ctx context.Context, gatewayID int64) (int64, error)
+	ListByGatewayID(ctx context.Context, gatewayID int64) ([]*model.IotDeviceDO, error)
+	UpdateGatewayID(ctx context.Context, ids []int64, gatewayID int64) error
 	ListByCondition(ctx context.Context, deviceType *int8, productID *int64) ([]*model.IotDeviceDO, error)
 	ListByProductKeyAndNames(ctx context.Context, productKey string, names []string) ([]*model.IotDeviceDO, error)
 	GetByProductKeyAndName(ctx context.Context, productKey string, name string) (*model.IotDeviceDO, error)
@@ -46,6 +55,19 @@ type ThingModelRepository interface {
 	ListByProductID(ctx context.Context, productID int64) ([]*model.IotThingModelDO, error)
 	ListByProductIDAndType(ctx context.Context, productID int64, tmType int8) ([]*model.IotThingModelDO, error)
 	GetPage(ctx context.Context, req *iot.IotThingModelPageReqVO) (*pagination.PageResult[*model.IotThingModelDO], error)
+	// ReplaceByProductID 整体替换产品的物模型功能
+	ReplaceByProductID(ctx context.Context, productID int64, list []*model.IotThingModelDO) error
+}
+
+type ThingModelVersionRepository interface {
+	// Publish 创建新版本并设为生效版本
+	Publish(ctx context.Context, version *model.IotThingModelVersionDO) error
+	// Rollback 将指定版本设为生效版本，并在同一事务中整体替换产品的物模型草稿
+	Rollback(ctx context.Context, productID, id int64, drafts []*model.IotThingModelDO) error
+	GetByID(ctx context.Context, id int64) (*model.IotThingModelVersionDO, error)
+	GetActiveByProductID(ctx context.Context, productID int64) (*model.IotThingModelVersionDO, error)
+	ListByProductID(ctx context.Context, productID int64) ([]*model.IotThingModelVersionDO, error)
+	GetMaxVersion(ctx context.Context, productID int64) (int, error)
 }
 
 type DeviceGroupRepository interface {
@@ -67,10 +89,13 @@ type OtaFirmwareRepository interface {
 
 type OtaTaskRepository interface {
 	Create(ctx context.Context, task *model.IotOtaTaskDO) error
+	// CreateWithRecords 在同一事务中创建任务及其升级记录
+	CreateWithRecords(ctx context.Context, task *model.IotOtaTaskDO, records []*model.IotOtaTaskRecordDO) error
 	Update(ctx context.Context, task *model.IotOtaTaskDO) error
 	Delete(ctx context.Context, id int64) error
 	GetByID(ctx context.Context, id int64) (*model.IotOtaTaskDO, error)
 	GetPage(ctx context.Context, req *iot.IotOtaTaskPageReqVO) (*pagination.PageResult[*model.IotOtaTaskDO], error)
+	GetListByStatus(ctx context.Context, status int8) ([]*model.IotOtaTaskDO, error)
 }
 
 type OtaTaskRecordRepository interface {
@@ -80,6 +105,10 @@ type OtaTaskRecordRepository interface {
 	CreateBatch(ctx context.Context, records []*model.IotOtaTaskRecordDO) error
 	GetListByDeviceIdAndStatus(ctx context.Context, deviceID int64, statuses []int) ([]*model.IotOtaTaskRecordDO, error)
 	GetListByTaskIdAndStatus(ctx context.Context, taskID int64, statuses []int) ([]*model.IotOtaTaskRecordDO, error)
+	GetListByTaskIdAndStatusWithLimit(ctx context.Context, taskID int64, status int, limit int) ([]*model.IotOtaTaskRecordDO, error)
+	CountByTaskIdAndStatus(ctx context.Context, taskID int64, statuses []int) (int64, error)
+	CountByTaskIdAndPushTimeAfter(ctx context.Context, taskID int64, pushTime time.Time) (int64, error)
+	UpdateStatusByTaskIdAndStatus(ctx context.Context, taskID int64, whereStatuses []int, status int, description string) error
 }
 
 type AlertConfigRepository interface {
@@ -106,6 +135,7 @@ type DataRuleRepository interface {
 	Delete(ctx context.Context, id int64) error
 	GetByID(ctx context.Context, id int64) (*model.IotDataRuleDO, error)
 	GetPage(ctx context.Context, req *iot.IotDataRulePageReqVO) (*pagination.PageResult[*model.IotDataRuleDO], error)
+	GetListByStatus(ctx context.Context, status int8) ([]*model.IotDataRuleDO, error)
 }
 
 type DataSinkRepository interface {
@@ -118,6 +148,11 @@ type DataSinkRepository interface {
 	GetListByStatus(ctx context.Context, status int8) ([]*model.IotDataSinkDO, error)
 }
 
+type DataSinkDeadLetterRepository interface {
+	Create(ctx context.Context, deadLetter *model.IotDataSinkDeadLetterDO) error
+	GetPage(ctx context.Context, req *iot.IotDataSinkDeadLetterPageReqVO) (*pagination.PageResult[*model.IotDataSinkDeadLetterDO], error)
+}
+
 type SceneRuleRepository interface {
 	Create(ctx context.Context, rule *model.IotSceneRuleDO) error
 	Update(ctx context.Context, rule *model.IotSceneRuleDO) error
@@ -152,4 +187,58 @@ type DevicePropertyRepository interface {
 	GetHistoryList(ctx context.Context, req *iot.IotDevicePropertyHistoryListReqVO) ([]*model.IotDevicePropertyDO, error)
 	GetLatestProperties(ctx context.Context, deviceID int64) ([]*model.IotDevicePropertyDO, error)
 	SaveProperty(ctx context.Context, property *model.IotDevicePropertyDO) error
+	// GetListByTimeRange 按编号游标分批查询 [startTime, endTime) 内的属性记录
+	GetListByTimeRange(ctx context.Context, startTime, endTime time.Time, lastID int64, limit int) ([]*model.IotDevicePropertyDO, error)
+	// DeleteByDeviceIDsAndTimeBefore 物理删除设备早于 before 的属性记录，单次最多删除 limit 条
+	DeleteByDeviceIDsAndTimeBefore(ctx context.Context, deviceIDs []int64, before time.Time, limit int) (int64, error)
+}
+
+type DevicePropertyRollupRepository interface {
+	// Upsert 按设备、标识符、粒度、分桶时间写入聚合，已存在时覆盖
+	Upsert(ctx context.Context, rollups []*model.IotDevicePropertyRollupDO) error
+	GetList(ctx context.Context, deviceID int64, identifier, granularity string, startTime, endTime *time.Time) ([]*model.IotDevicePropertyRollupDO, error)
+	GetListByGranularityAndTimeRange(ctx context.Context, granularity string, startTime, endTime time.Time) ([]*model.IotDevicePropertyRollupDO, error)
+	// DeleteByGranularityAndTimeBefore 物理删除早于 before 的聚合，单次最多删除 limit 条
+	DeleteByGranularityAndTimeBefore(ctx context.Context, granularity string, before time.Time, limit int) (int64, error)
+}
+
+type DeviceShadowRepository interface {
+	GetByDeviceID(ctx context.Context, deviceID int64) (*model.IotDeviceShadowDO, error)
+	Create(ctx context.Context, shadow *model.IotDeviceShadowDO) error
+	// UpdateByVersion 按版本号乐观更新，版本号不一致时返回 false
+	UpdateByVersion(ctx context.Context, shadow *model.IotDeviceShadowDO, version int64) (bool, error)
+}
+
+type DeviceRegisterLogRepository interface {
+	Create(ctx context.Context, registerLog *model.IotDeviceRegisterLogDO) error
+	GetPage(ctx context.Context, req *iot.IotDeviceRegisterLogPageReqVO) (*pagination.PageResult[*model.IotDeviceRegisterLogDO], error)
+}
+
+type DeviceCertificateRepository interface {
+	Create(ctx context.Context, certificate *model.IotDeviceCertificateDO) error
+	// GetValidByDeviceID 获取设备当前有效的证书，不存在时返回 nil
+	GetValidByDeviceID(ctx context.Context, deviceID int64) (*model.IotDeviceCertificateDO, error)
+	// GetByFingerprint 根据证书指纹获取证书（含已吊销），不存在时返回 nil
+	GetByFingerprint(ctx context.Context, fingerprint string) (*model.IotDeviceCertificateDO, error)
+	// RevokeByDeviceIDs 吊销设备的全部有效证书
+	RevokeByDeviceIDs(ctx context.Context, deviceIDs []int64, reason string) error
+	// ListRevoked 获取已吊销且未过期的证书，用于生成 CRL
+	ListRevoked(ctx context.Context) ([]*model.IotDeviceCertificateDO, error)
+}
+
+type GeofenceRepository interface {
+	Create(ctx context.Context, geofence *model.IotGeofenceDO) error
+	Update(ctx context.Context, geofence *model.IotGeofenceDO) error
+	Delete(ctx context.Context, id int64) error
+	GetByID(ctx context.Context, id int64) (*model.IotGeofenceDO, error)
+	GetPage(ctx context.Context, req *iot.IotGeofencePageReqVO) (*pagination.PageResult[*model.IotGeofenceDO], error)
+	GetListByStatus(ctx context.Context, status int8) ([]*model.IotGeofenceDO, error)
+}
+
+type DeviceLocationRepository interface {
+	Create(ctx context.Context, location *model.IotDeviceLocationDO) error
+	// GetLatestByDeviceID 获取设备最近的轨迹点，不存在时返回 nil
+	GetLatestByDeviceID(ctx context.Context, deviceID int64) (*model.IotDeviceLocationDO, error)
+	// GetListByDeviceID 按上报时间升序获取设备轨迹，时间为 nil 时不限
+	GetListByDeviceID(ctx context.Context, deviceID int64, startTime, endTime *time.Time) ([]*model.IotDeviceLocationDO, error)
 }
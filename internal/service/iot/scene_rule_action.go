@@ -0,0 +1,261 @@
+package iot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	iotcore "github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+)
+
+// SceneRuleAction 场景联动执行器接口 (对齐 Java IotSceneRuleAction)
+type SceneRuleAction interface {
+	// Type 返回支持的执行器类型
+	Type() int8
+	// Execute 执行动作
+	// device 为触发规则的设备，message 为触发规则的设备消息
+	Execute(ctx context.Context, rule *model.IotSceneRuleDO, action *iot2.IotSceneRuleAction, device *model.IotDeviceDO, message *iotcore.IotDeviceMessage) error
+}
+
+// ProvideSceneRuleActions 聚合所有场景联动执行器，供 Wire 使用
+func ProvideSceneRuleActions(
+	propertySet *DevicePropertySetSceneRuleAction,
+	serviceInvoke *DeviceServiceInvokeSceneRuleAction,
+	shadowDesired *DeviceShadowDesiredSceneRuleAction,
+	alertTrigger *AlertTriggerSceneRuleAction,
+	alertRecover *AlertRecoverSceneRuleAction,
+) []SceneRuleAction {
+	return []SceneRuleAction{propertySet, serviceInvoke, shadowDesired, alertTrigger, alertRecover}
+}
+
+// ================= 设备控制 =================
+
+// DevicePropertySetSceneRuleAction 设备属性设置执行器
+type DevicePropertySetSceneRuleAction struct {
+	deviceSvc        *DeviceService
+	deviceMessageSvc *DeviceMessageService
+}
+
+func NewDevicePropertySetSceneRuleAction(deviceSvc *DeviceService, deviceMessageSvc *DeviceMessageService) *DevicePropertySetSceneRuleAction {
+	return &DevicePropertySetSceneRuleAction{
+		deviceSvc:        deviceSvc,
+		deviceMessageSvc: deviceMessageSvc,
+	}
+}
+
+func (a *DevicePropertySetSceneRuleAction) Type() int8 {
+	return consts.IotSceneRuleActionTypeDevicePropertySet
+}
+
+func (a *DevicePropertySetSceneRuleAction) Execute(ctx context.Context, rule *model.IotSceneRuleDO, action *iot2.IotSceneRuleAction, device *model.IotDeviceDO, message *iotcore.IotDeviceMessage) error {
+	var params map[string]any
+	if err := json.Unmarshal([]byte(action.Params), &params); err != nil {
+		return fmt.Errorf("invalid property set params: %w", err)
+	}
+	return sendSceneRuleDeviceMessage(ctx, a.deviceSvc, a.deviceMessageSvc, action, consts.IotDeviceMessageMethodPropertySet, params)
+}
+
+// DeviceServiceInvokeSceneRuleAction 设备服务调用执行器
+type DeviceServiceInvokeSceneRuleAction struct {
+	deviceSvc        *DeviceService
+	deviceMessageSvc *DeviceMessageService
+}
+
+func NewDeviceServiceInvokeSceneRuleAction(deviceSvc *DeviceService, deviceMessageSvc *DeviceMessageService) *DeviceServiceInvokeSceneRuleAction {
+	return &DeviceServiceInvokeSceneRuleAction{
+		deviceSvc:        deviceSvc,
+		deviceMessageSvc: deviceMessageSvc,
+	}
+}
+
+func (a *DeviceServiceInvokeSceneRuleAction) Type() int8 {
+	return consts.IotSceneRuleActionTypeDeviceServiceInvoke
+}
+
+func (a *DeviceServiceInvokeSceneRuleAction) Execute(ctx context.Context, rule *model.IotSceneRuleDO, action *iot2.IotSceneRuleAction, device *model.IotDeviceDO, message *iotcore.IotDeviceMessage) error {
+	var inputParams map[string]any
+	if action.Params != "" {
+		if err := json.Unmarshal([]byte(action.Params), &inputParams); err != nil {
+			return fmt.Errorf("invalid service invoke params: %w", err)
+		}
+	}
+	params := map[string]any{
+		"identifier":  action.Identifier,
+		"inputParams": inputParams,
+	}
+	return sendSceneRuleDeviceMessage(ctx, a.deviceSvc, a.deviceMessageSvc, action, consts.IotDeviceMessageMethodServiceInvoke, params)
+}
+
+// DeviceShadowDesiredSceneRuleAction 设备影子期望状态设置执行器
+// 与属性设置不同，设备离线时期望状态保留在影子中，待设备上线后下发
+type DeviceShadowDesiredSceneRuleAction struct {
+	deviceSvc        *DeviceService
+	deviceShadowSvc  *DeviceShadowService
+	deviceMessageSvc *DeviceMessageService
+}
+
+func NewDeviceShadowDesiredSceneRuleAction(deviceSvc *DeviceService, deviceShadowSvc *DeviceShadowService, deviceMessageSvc *DeviceMessageService) *DeviceShadowDesiredSceneRuleAction {
+	return &DeviceShadowDesiredSceneRuleAction{
+		deviceSvc:        deviceSvc,
+		deviceShadowSvc:  deviceShadowSvc,
+		deviceMessageSvc: deviceMessageSvc,
+	}
+}
+
+func (a *DeviceShadowDesiredSceneRuleAction) Type() int8 {
+	return consts.IotSceneRuleActionTypeDeviceShadowDesired
+}
+
+func (a *DeviceShadowDesiredSceneRuleAction) Execute(ctx context.Context, rule *model.IotSceneRuleDO, action *iot2.IotSceneRuleAction, device *model.IotDeviceDO, message *iotcore.IotDeviceMessage) error {
+	var desired map[string]any
+	if err := json.Unmarshal([]byte(action.Params), &desired); err != nil {
+		return fmt.Errorf("invalid shadow desired params: %w", err)
+	}
+	devices, err := getSceneRuleTargetDevices(ctx, a.deviceSvc, action)
+	if err != nil {
+		return err
+	}
+	for _, target := range devices {
+		if _, err := a.deviceShadowSvc.UpdateDesired(ctx, target, desired, 0); err != nil {
+			log.Printf("[SceneRuleAction] Update shadow desired of device %d failed: %v", target.ID, err)
+			continue
+		}
+		if target.State == consts.IotDeviceStateOnline {
+			if err := a.deviceMessageSvc.SendShadowDelta(ctx, target); err != nil {
+				log.Printf("[SceneRuleAction] Send shadow delta to device %d failed: %v", target.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// getSceneRuleTargetDevices 获取执行器指定的设备
+// action.DeviceID 为 0 时，返回产品下的所有设备
+func getSceneRuleTargetDevices(ctx context.Context, deviceSvc *DeviceService, action *iot2.IotSceneRuleAction) ([]*model.IotDeviceDO, error) {
+	if action.DeviceID != 0 {
+		device, err := deviceSvc.Get(ctx, action.DeviceID)
+		if err != nil {
+			return nil, err
+		}
+		return []*model.IotDeviceDO{device}, nil
+	}
+	return deviceSvc.GetListByCondition(ctx, nil, &action.ProductID)
+}
+
+// sendSceneRuleDeviceMessage 向执行器指定的设备下发消息
+func sendSceneRuleDeviceMessage(ctx context.Context, deviceSvc *DeviceService, deviceMessageSvc *DeviceMessageService, action *iot2.IotSceneRuleAction, method string, params map[string]any) error {
+	devices, err := getSceneRuleTargetDevices(ctx, deviceSvc, action)
+	if err != nil {
+		return err
+	}
+
+	for _, target := range devices {
+		message := &iotcore.IotDeviceMessage{
+			Method:   method,
+			Params:   params,
+			DeviceID: target.ID,
+		}
+		if err := deviceMessageSvc.SendDeviceMessageCore(ctx, message); err != nil {
+			log.Printf("[SceneRuleAction] Send %s to device %d failed: %v", method, target.ID, err)
+		}
+	}
+	return nil
+}
+
+// ================= 告警 =================
+
+// AlertTriggerSceneRuleAction 告警触发执行器
+type AlertTriggerSceneRuleAction struct {
+	alertConfigSvc *AlertConfigService
+	alertRecordSvc *AlertRecordService
+	alertNotifySvc *AlertNotifyService
+}
+
+func NewAlertTriggerSceneRuleAction(alertConfigSvc *AlertConfigService, alertRecordSvc *AlertRecordService, alertNotifySvc *AlertNotifyService) *AlertTriggerSceneRuleAction {
+	return &AlertTriggerSceneRuleAction{
+		alertConfigSvc: alertConfigSvc,
+		alertRecordSvc: alertRecordSvc,
+		alertNotifySvc: alertNotifySvc,
+	}
+}
+
+func (a *AlertTriggerSceneRuleAction) Type() int8 {
+	return consts.IotSceneRuleActionTypeAlertTrigger
+}
+
+func (a *AlertTriggerSceneRuleAction) Execute(ctx context.Context, rule *model.IotSceneRuleDO, action *iot2.IotSceneRuleAction, device *model.IotDeviceDO, message *iotcore.IotDeviceMessage) error {
+	configs, err := getSceneRuleAlertConfigs(ctx, a.alertConfigSvc, rule, action)
+	if err != nil {
+		return err
+	}
+	for _, config := range configs {
+		// 去重窗口内的重复触发直接忽略
+		if !a.alertNotifySvc.TryAcquireAlert(ctx, config, device.ID) {
+			continue
+		}
+		recordID, err := a.alertRecordSvc.CreateAlertRecord(ctx, config, rule.ID, device, message)
+		if err != nil {
+			log.Printf("[AlertTriggerSceneRuleAction] Create alert record failed: configId=%d, err=%v", config.ID, err)
+			a.alertNotifySvc.ReleaseAlert(ctx, config, device.ID)
+			continue
+		}
+		a.alertNotifySvc.SendAlertNotify(ctx, config, recordID, device, message)
+	}
+	return nil
+}
+
+// AlertRecoverSceneRuleAction 告警恢复执行器
+type AlertRecoverSceneRuleAction struct {
+	alertRecordSvc *AlertRecordService
+	alertNotifySvc *AlertNotifyService
+}
+
+func NewAlertRecoverSceneRuleAction(alertRecordSvc *AlertRecordService, alertNotifySvc *AlertNotifyService) *AlertRecoverSceneRuleAction {
+	return &AlertRecoverSceneRuleAction{
+		alertRecordSvc: alertRecordSvc,
+		alertNotifySvc: alertNotifySvc,
+	}
+}
+
+func (a *AlertRecoverSceneRuleAction) Type() int8 {
+	return consts.IotSceneRuleActionTypeAlertRecover
+}
+
+func (a *AlertRecoverSceneRuleAction) Execute(ctx context.Context, rule *model.IotSceneRuleDO, action *iot2.IotSceneRuleAction, device *model.IotDeviceDO, message *iotcore.IotDeviceMessage) error {
+	processStatus := false
+	records, err := a.alertRecordSvc.GetListBySceneRuleId(ctx, rule.ID, &device.ID, &processStatus)
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	if err := a.alertRecordSvc.ProcessAlertRecordList(ctx, records, "告警自动恢复"); err != nil {
+		return err
+	}
+	// 恢复后清除窗口，使再次发生的告警能够立即通知
+	for _, record := range records {
+		a.alertNotifySvc.ClearAlertWindow(ctx, record.ConfigID, record.DeviceID)
+	}
+	return nil
+}
+
+// getSceneRuleAlertConfigs 获取动作关联的告警配置
+// 动作指定了告警配置时仅使用该配置，否则使用关联该场景规则的全部启用配置
+func getSceneRuleAlertConfigs(ctx context.Context, alertConfigSvc *AlertConfigService, rule *model.IotSceneRuleDO, action *iot2.IotSceneRuleAction) ([]*model.IotAlertConfigDO, error) {
+	if action.AlertConfigID != 0 {
+		config, err := alertConfigSvc.Get(ctx, action.AlertConfigID)
+		if err != nil {
+			return nil, err
+		}
+		if config == nil || config.Status != consts.CommonStatusEnable {
+			return nil, nil
+		}
+		return []*model.IotAlertConfigDO{config}, nil
+	}
+	return alertConfigSvc.GetListBySceneRuleIdAndStatus(ctx, rule.ID, consts.CommonStatusEnable)
+}
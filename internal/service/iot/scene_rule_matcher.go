@@ -0,0 +1,99 @@
+package iot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+)
+
+// evaluateSceneRuleOperator 按运算符比较源值与参数 (对齐 Java IotSceneRuleMatcherHelper)
+// param 为字符串形式：in/not in 使用逗号分隔多个值，between/not between 使用逗号分隔上下限
+func evaluateSceneRuleOperator(operator string, source any, param string) bool {
+	if operator == consts.IotSceneRuleConditionOperatorNotNull {
+		return source != nil && toSceneRuleString(source) != ""
+	}
+	if source == nil {
+		return false
+	}
+	sourceStr := toSceneRuleString(source)
+
+	switch operator {
+	case "", consts.IotSceneRuleConditionOperatorEquals:
+		return compareSceneRuleValue(sourceStr, param) == 0
+	case consts.IotSceneRuleConditionOperatorNotEquals:
+		return compareSceneRuleValue(sourceStr, param) != 0
+	case consts.IotSceneRuleConditionOperatorGt:
+		return compareSceneRuleValue(sourceStr, param) > 0
+	case consts.IotSceneRuleConditionOperatorGte:
+		return compareSceneRuleValue(sourceStr, param) >= 0
+	case consts.IotSceneRuleConditionOperatorLt:
+		return compareSceneRuleValue(sourceStr, param) < 0
+	case consts.IotSceneRuleConditionOperatorLte:
+		return compareSceneRuleValue(sourceStr, param) <= 0
+	case consts.IotSceneRuleConditionOperatorIn:
+		return containsSceneRuleValue(sourceStr, param)
+	case consts.IotSceneRuleConditionOperatorNotIn:
+		return !containsSceneRuleValue(sourceStr, param)
+	case consts.IotSceneRuleConditionOperatorBetween:
+		return betweenSceneRuleValue(sourceStr, param)
+	case consts.IotSceneRuleConditionOperatorNotBetween:
+		return !betweenSceneRuleValue(sourceStr, param)
+	case consts.IotSceneRuleConditionOperatorLike:
+		return strings.Contains(sourceStr, param)
+	default:
+		return false
+	}
+}
+
+// compareSceneRuleValue 比较两个值：均为数字时按数值比较，否则按字符串比较
+func compareSceneRuleValue(source, target string) int {
+	sourceNum, err1 := strconv.ParseFloat(strings.TrimSpace(source), 64)
+	targetNum, err2 := strconv.ParseFloat(strings.TrimSpace(target), 64)
+	if err1 == nil && err2 == nil {
+		switch {
+		case sourceNum > targetNum:
+			return 1
+		case sourceNum < targetNum:
+			return -1
+		default:
+			return 0
+		}
+	}
+	return strings.Compare(source, target)
+}
+
+// containsSceneRuleValue 判断源值是否在逗号分隔的参数列表中
+func containsSceneRuleValue(source, param string) bool {
+	for _, item := range strings.Split(param, ",") {
+		if compareSceneRuleValue(source, strings.TrimSpace(item)) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// betweenSceneRuleValue 判断源值是否在 [min, max] 区间内
+func betweenSceneRuleValue(source, param string) bool {
+	parts := strings.Split(param, ",")
+	if len(parts) != 2 {
+		return false
+	}
+	return compareSceneRuleValue(source, strings.TrimSpace(parts[0])) >= 0 &&
+		compareSceneRuleValue(source, strings.TrimSpace(parts[1])) <= 0
+}
+
+// toSceneRuleString 将任意值转换为字符串，用于规则比较
+func toSceneRuleString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
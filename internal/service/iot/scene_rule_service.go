@@ -3,20 +3,62 @@ package iot
 import (
 	"context"
 	"encoding/json"
+	"log"
+	"sync"
+	"time"
 
 	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	iotcore "github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
 	"github.com/wxlbd/ruoyi-mall-go/internal/model"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
 	"gorm.io/datatypes"
 )
 
 type SceneRuleService struct {
-	sceneRuleRepo SceneRuleRepository
+	sceneRuleRepo     SceneRuleRepository
+	deviceRepo        DeviceRepository
+	deviceMessageRepo DeviceMessageRepository
+	devicePropertySvc *DevicePropertyService
+	thingModelSvc     *ThingModelService
+	messageBus        iotcore.MessageBus
+	actions           map[int8]SceneRuleAction
+
+	// 启用规则的本地缓存，规则变更时置空，下次执行时重新加载
+	// ruleCacheVersion 每次失效时递增，加载期间发生失效时丢弃加载结果，避免写回旧规则
+	mu               sync.RWMutex
+	ruleCache        []*sceneRuleCacheItem
+	ruleCacheVersion int64
+}
+
+// sceneRuleCacheItem 已解析触发器与执行器的场景规则
+type sceneRuleCacheItem struct {
+	rule     *model.IotSceneRuleDO
+	triggers []iot2.IotSceneRuleTrigger
+	actions  []iot2.IotSceneRuleAction
 }
 
-func NewSceneRuleService(sceneRuleRepo SceneRuleRepository) *SceneRuleService {
+func NewSceneRuleService(
+	sceneRuleRepo SceneRuleRepository,
+	deviceRepo DeviceRepository,
+	deviceMessageRepo DeviceMessageRepository,
+	devicePropertySvc *DevicePropertyService,
+	thingModelSvc *ThingModelService,
+	messageBus iotcore.MessageBus,
+	actions []SceneRuleAction,
+) *SceneRuleService {
+	actionMap := make(map[int8]SceneRuleAction, len(actions))
+	for _, action := range actions {
+		actionMap[action.Type()] = action
+	}
 	return &SceneRuleService{
-		sceneRuleRepo: sceneRuleRepo,
+		sceneRuleRepo:     sceneRuleRepo,
+		deviceRepo:        deviceRepo,
+		deviceMessageRepo: deviceMessageRepo,
+		devicePropertySvc: devicePropertySvc,
+		thingModelSvc:     thingModelSvc,
+		messageBus:        messageBus,
+		actions:           actionMap,
 	}
 }
 
@@ -33,6 +75,7 @@ func (s *SceneRuleService) Create(ctx context.Context, r *iot2.IotSceneRuleSaveR
 	if err := s.sceneRuleRepo.Create(ctx, rule); err != nil {
 		return 0, err
 	}
+	s.invalidateRuleCache()
 	return rule.ID, nil
 }
 
@@ -54,7 +97,11 @@ func (s *SceneRuleService) Update(ctx context.Context, r *iot2.IotSceneRuleSaveR
 	rule.Triggers = datatypes.JSON(triggers)
 	rule.Actions = datatypes.JSON(actions)
 
-	return s.sceneRuleRepo.Update(ctx, rule)
+	if err := s.sceneRuleRepo.Update(ctx, rule); err != nil {
+		return err
+	}
+	s.invalidateRuleCache()
+	return nil
 }
 
 func (s *SceneRuleService) UpdateStatus(ctx context.Context, id int64, status int8) error {
@@ -66,11 +113,19 @@ func (s *SceneRuleService) UpdateStatus(ctx context.Context, id int64, status in
 		return model.ErrSceneRuleNotExists
 	}
 	rule.Status = status
-	return s.sceneRuleRepo.Update(ctx, rule)
+	if err := s.sceneRuleRepo.Update(ctx, rule); err != nil {
+		return err
+	}
+	s.invalidateRuleCache()
+	return nil
 }
 
 func (s *SceneRuleService) Delete(ctx context.Context, id int64) error {
-	return s.sceneRuleRepo.Delete(ctx, id)
+	if err := s.sceneRuleRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.invalidateRuleCache()
+	return nil
 }
 
 func (s *SceneRuleService) Get(ctx context.Context, id int64) (*model.IotSceneRuleDO, error) {
@@ -84,3 +139,258 @@ func (s *SceneRuleService) GetPage(ctx context.Context, r *iot2.IotSceneRulePage
 func (s *SceneRuleService) GetListByStatus(ctx context.Context, status int8) ([]*model.IotSceneRuleDO, error) {
 	return s.sceneRuleRepo.GetListByStatus(ctx, status)
 }
+
+// ExecuteSceneRuleByDevice 基于设备上行消息执行场景规则
+// 规则之间相互独立；规则内任一触发器匹配（含其条件组）即执行该规则的全部动作
+func (s *SceneRuleService) ExecuteSceneRuleByDevice(ctx context.Context, message *iotcore.IotDeviceMessage) {
+	rules, err := s.getEnabledRuleList(ctx)
+	if err != nil {
+		log.Printf("[SceneRuleService] Load scene rules failed: %v", err)
+		return
+	}
+	if len(rules) == 0 {
+		return
+	}
+
+	device, err := s.deviceRepo.GetByID(ctx, message.DeviceID)
+	if err != nil || device == nil {
+		log.Printf("[SceneRuleService] Device not found: %d", message.DeviceID)
+		return
+	}
+	// 不符合物模型的属性、事件不触发联动
+	message, err = s.thingModelSvc.FilterUpstreamMessage(ctx, message, device.ProductID)
+	if err != nil {
+		log.Printf("[SceneRuleService] Load thing model failed: productId=%d, err=%v", device.ProductID, err)
+		return
+	}
+	if message == nil {
+		return
+	}
+	if message.Method == consts.IotDeviceMessageMethodServiceInvokeReply {
+		message = s.fillServiceInvokeIdentifier(ctx, message)
+	}
+
+	for _, item := range rules {
+		if item.rule.TenantID != device.TenantID {
+			continue
+		}
+		if !s.matchTriggers(ctx, item.triggers, device, message) {
+			continue
+		}
+		log.Printf("[SceneRuleService] Scene rule matched: ruleId=%d, deviceId=%d, method=%s",
+			item.rule.ID, device.ID, message.Method)
+		s.executeActions(ctx, item, device, message)
+	}
+}
+
+// matchTriggers 匹配触发器，任一触发器满足即可
+func (s *SceneRuleService) matchTriggers(ctx context.Context, triggers []iot2.IotSceneRuleTrigger, device *model.IotDeviceDO, message *iotcore.IotDeviceMessage) bool {
+	for i := range triggers {
+		trigger := &triggers[i]
+		if !s.matchTrigger(trigger, device, message) {
+			continue
+		}
+		if s.matchConditionGroups(ctx, trigger.ConditionGroups, device, message) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTrigger 匹配单个触发器与设备消息
+func (s *SceneRuleService) matchTrigger(trigger *iot2.IotSceneRuleTrigger, device *model.IotDeviceDO, message *iotcore.IotDeviceMessage) bool {
+	// 产品、设备为 0 时表示不限
+	if trigger.ProductID != 0 && trigger.ProductID != device.ProductID {
+		return false
+	}
+	if trigger.DeviceID != 0 && trigger.DeviceID != device.ID {
+		return false
+	}
+
+	switch trigger.Type {
+	case consts.IotSceneRuleTriggerTypeDeviceStateUpdate:
+		if message.Method != consts.IotDeviceMessageMethodStateUpdate {
+			return false
+		}
+		return trigger.Operator == "" || evaluateSceneRuleOperator(trigger.Operator, message.Params["state"], trigger.Value)
+	case consts.IotSceneRuleTriggerTypeDevicePropertyPost:
+		if message.Method != consts.IotDeviceMessageMethodPropertyPost {
+			return false
+		}
+		value, ok := message.Params[trigger.Identifier]
+		if !ok {
+			return false
+		}
+		return trigger.Operator == "" || evaluateSceneRuleOperator(trigger.Operator, value, trigger.Value)
+	case consts.IotSceneRuleTriggerTypeDeviceEventPost:
+		return message.Method == consts.IotDeviceMessageMethodEventPost &&
+			message.Params["identifier"] == trigger.Identifier
+	case consts.IotSceneRuleTriggerTypeDeviceServiceInvoke:
+		// 服务调用为下行消息，以设备的调用回复作为触发
+		return message.Method == consts.IotDeviceMessageMethodServiceInvokeReply &&
+			message.Params["identifier"] == trigger.Identifier
+	case consts.IotSceneRuleTriggerTypeDeviceGeofence:
+		// 标识符为事件类型（enter/exit），值为围栏编号，为空时不限
+		if message.Method != consts.IotDeviceMessageMethodGeofencePost {
+			return false
+		}
+		if trigger.Identifier != "" && message.Params["type"] != trigger.Identifier {
+			return false
+		}
+		return trigger.Value == "" || toSceneRuleString(message.Params["geofenceId"]) == trigger.Value
+	default:
+		// 定时触发不由设备消息驱动
+		return false
+	}
+}
+
+// fillServiceInvokeIdentifier 服务调用回复通常不含服务标识符，从调用请求的消息日志中补充
+// 返回补充后的副本，不修改原消息：其它订阅者可能同时持有该消息
+func (s *SceneRuleService) fillServiceInvokeIdentifier(ctx context.Context, message *iotcore.IotDeviceMessage) *iotcore.IotDeviceMessage {
+	if _, ok := message.Params["identifier"]; ok || message.RequestID == "" {
+		return message
+	}
+	requests, err := s.deviceMessageRepo.GetListByRequestIdsAndReply(ctx, message.DeviceID, []string{message.RequestID}, false)
+	if err != nil || len(requests) == 0 {
+		log.Printf("[SceneRuleService] Service invoke request not found: deviceId=%d, requestId=%s, err=%v",
+			message.DeviceID, message.RequestID, err)
+		return message
+	}
+	var requestParams map[string]any
+	if err := json.Unmarshal([]byte(requests[0].Params), &requestParams); err != nil {
+		return message
+	}
+
+	filled := *message
+	filled.Params = make(map[string]any, len(message.Params)+1)
+	for k, v := range message.Params {
+		filled.Params[k] = v
+	}
+	filled.Params["identifier"] = requestParams["identifier"]
+	return &filled
+}
+
+// matchConditionGroups 匹配条件组：组与组之间为 OR，组内条件之间为 AND
+func (s *SceneRuleService) matchConditionGroups(ctx context.Context, groups [][]iot2.IotSceneRuleTriggerCondition, device *model.IotDeviceDO, message *iotcore.IotDeviceMessage) bool {
+	if len(groups) == 0 {
+		return true
+	}
+	for _, group := range groups {
+		matched := true
+		for i := range group {
+			if !s.matchCondition(ctx, &group[i], device, message) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// matchCondition 匹配单个条件
+func (s *SceneRuleService) matchCondition(ctx context.Context, condition *iot2.IotSceneRuleTriggerCondition, device *model.IotDeviceDO, message *iotcore.IotDeviceMessage) bool {
+	if condition.Type == consts.IotSceneRuleConditionTypeCurrentTime {
+		return evaluateSceneRuleOperator(condition.Operator, time.Now().Format(time.TimeOnly), condition.Param)
+	}
+
+	// 条件未指定设备时，使用触发设备
+	target := device
+	if condition.DeviceID != 0 && condition.DeviceID != device.ID {
+		other, err := s.deviceRepo.GetByID(ctx, condition.DeviceID)
+		if err != nil || other == nil {
+			return false
+		}
+		target = other
+	}
+
+	switch condition.Type {
+	case consts.IotSceneRuleConditionTypeDeviceState:
+		return evaluateSceneRuleOperator(condition.Operator, target.State, condition.Param)
+	case consts.IotSceneRuleConditionTypeDeviceProperty:
+		// 优先使用本次上报的属性值，避免读取到尚未落库的旧值
+		if target.ID == device.ID && message.Method == consts.IotDeviceMessageMethodPropertyPost {
+			if value, ok := message.Params[condition.Identifier]; ok {
+				return evaluateSceneRuleOperator(condition.Operator, value, condition.Param)
+			}
+		}
+		properties, err := s.devicePropertySvc.GetLatestDeviceProperties(ctx, target.ID)
+		if err != nil {
+			return false
+		}
+		property, ok := properties[condition.Identifier]
+		if !ok {
+			return false
+		}
+		return evaluateSceneRuleOperator(condition.Operator, property.Value, condition.Param)
+	default:
+		return false
+	}
+}
+
+// executeActions 执行规则的全部动作，单个动作失败不影响其他动作
+func (s *SceneRuleService) executeActions(ctx context.Context, item *sceneRuleCacheItem, device *model.IotDeviceDO, message *iotcore.IotDeviceMessage) {
+	for i := range item.actions {
+		action := &item.actions[i]
+		executor, ok := s.actions[action.Type]
+		if !ok {
+			log.Printf("[SceneRuleService] Unsupported action type: ruleId=%d, type=%d", item.rule.ID, action.Type)
+			continue
+		}
+		if err := executor.Execute(ctx, item.rule, action, device, message); err != nil {
+			log.Printf("[SceneRuleService] Execute action failed: ruleId=%d, type=%d, err=%v", item.rule.ID, action.Type, err)
+		}
+	}
+}
+
+// getEnabledRuleList 获取启用的场景规则（带本地缓存）
+func (s *SceneRuleService) getEnabledRuleList(ctx context.Context) ([]*sceneRuleCacheItem, error) {
+	s.mu.RLock()
+	cache, version := s.ruleCache, s.ruleCacheVersion
+	s.mu.RUnlock()
+	if cache != nil {
+		return cache, nil
+	}
+
+	rules, err := s.sceneRuleRepo.GetListByStatus(ctx, consts.CommonStatusEnable)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]*sceneRuleCacheItem, 0, len(rules))
+	for _, rule := range rules {
+		item := &sceneRuleCacheItem{rule: rule}
+		if err := json.Unmarshal(rule.Triggers, &item.triggers); err != nil {
+			log.Printf("[SceneRuleService] Invalid triggers: ruleId=%d, err=%v", rule.ID, err)
+			continue
+		}
+		if err := json.Unmarshal(rule.Actions, &item.actions); err != nil {
+			log.Printf("[SceneRuleService] Invalid actions: ruleId=%d, err=%v", rule.ID, err)
+			continue
+		}
+		items = append(items, item)
+	}
+
+	s.mu.Lock()
+	if s.ruleCacheVersion == version {
+		s.ruleCache = items
+	}
+	s.mu.Unlock()
+	log.Printf("[SceneRuleService] Scene rules loaded: count=%d", len(items))
+	return items, nil
+}
+
+// invalidateRuleCache 规则变更后清空本节点缓存，并通知其它节点清空
+func (s *SceneRuleService) invalidateRuleCache() {
+	s.InvalidateLocalRuleCache()
+	s.messageBus.Post(iotcore.CacheInvalidateTopic, &iotcore.CacheInvalidateEvent{Cache: iotcore.CacheSceneRule})
+}
+
+// InvalidateLocalRuleCache 清空本节点的规则缓存
+func (s *SceneRuleService) InvalidateLocalRuleCache() {
+	s.mu.Lock()
+	s.ruleCache = nil
+	s.ruleCacheVersion++
+	s.mu.Unlock()
+}
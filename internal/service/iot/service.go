@@ -3,25 +3,42 @@ package iot
 import (
 	"github.com/google/wire"
 	iotcore "github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/gateway/codec"
+	"github.com/wxlbd/ruoyi-mall-go/internal/iot/sink"
 )
 
 // ProviderSet 提供所有 IOT 服务的依赖注入
 var ProviderSet = wire.NewSet(
 	iotcore.ProviderSet,
+	codec.DefaultRegistry,
 	NewProductService,
 	NewDeviceService,
 	NewThingModelService,
 	NewDeviceGroupService,
+	NewDeviceGroupBatchService,
 	NewOtaFirmwareService,
 	NewOtaTaskService,
 	NewAlertConfigService,
 	NewAlertRecordService,
+	NewAlertNotifyService,
 	NewDataSinkService,
 	NewDataRuleService,
+	sink.DefaultRegistry,
 	NewSceneRuleService,
+	NewDevicePropertySetSceneRuleAction,
+	NewDeviceServiceInvokeSceneRuleAction,
+	NewDeviceShadowDesiredSceneRuleAction,
+	NewAlertTriggerSceneRuleAction,
+	NewAlertRecoverSceneRuleAction,
+	ProvideSceneRuleActions,
 	NewProductCategoryService,
 	NewStatisticsService,
 	NewDeviceMessageService,
+	NewDeviceMessageStreamService,
 	NewDevicePropertyService,
+	NewDeviceShadowService,
+	NewDevicePropertyRollupService,
+	NewGeofenceService,
+	NewDeviceLocationService,
 	NewIotDeviceCommonApiImpl,
 )
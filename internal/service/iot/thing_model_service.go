@@ -2,9 +2,11 @@ package iot
 
 import (
 	"context"
+	"sync"
 
 	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
 	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	iotcore "github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
 	"github.com/wxlbd/ruoyi-mall-go/internal/model"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
 	"gorm.io/datatypes"
@@ -13,12 +15,19 @@ import (
 type ThingModelService struct {
 	productRepo    ProductRepository
 	thingModelRepo ThingModelRepository
+	versionRepo    ThingModelVersionRepository
+
+	// tslCache 生效的物模型 TSL 缓存（上行消息校验使用），key: productId
+	cacheMu  sync.RWMutex
+	tslCache map[int64]*iot2.IotThingModelTSLRespVO
 }
 
-func NewThingModelService(productRepo ProductRepository, thingModelRepo ThingModelRepository) *ThingModelService {
+func NewThingModelService(productRepo ProductRepository, thingModelRepo ThingModelRepository, versionRepo ThingModelVersionRepository) *ThingModelService {
 	return &ThingModelService{
 		productRepo:    productRepo,
 		thingModelRepo: thingModelRepo,
+		versionRepo:    versionRepo,
+		tslCache:       make(map[int64]*iot2.IotThingModelTSLRespVO),
 	}
 }
 
@@ -57,6 +66,7 @@ func (s *ThingModelService) Create(ctx context.Context, r *iot2.IotThingModelSav
 	if err := s.thingModelRepo.Create(ctx, thingModel); err != nil {
 		return 0, err
 	}
+	s.invalidateTSLCache(thingModel.ProductID)
 	return thingModel.ID, nil
 }
 
@@ -92,7 +102,11 @@ func (s *ThingModelService) Update(ctx context.Context, r *iot2.IotThingModelSav
 		tm.Service = datatypes.NewJSONType(*r.Service)
 	}
 
-	return s.thingModelRepo.Update(ctx, tm)
+	if err := s.thingModelRepo.Update(ctx, tm); err != nil {
+		return err
+	}
+	s.invalidateTSLCache(tm.ProductID)
+	return nil
 }
 
 func (s *ThingModelService) Delete(ctx context.Context, id int64) error {
@@ -110,7 +124,11 @@ func (s *ThingModelService) Delete(ctx context.Context, id int64) error {
 	if product != nil && product.Status == consts.IotProductStatusPublished {
 		return model.ErrProductStatusNotAllowThingModel
 	}
-	return s.thingModelRepo.Delete(ctx, id)
+	if err := s.thingModelRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.invalidateTSLCache(tm.ProductID)
+	return nil
 }
 
 func (s *ThingModelService) Get(ctx context.Context, id int64) (*model.IotThingModelDO, error) {
@@ -158,6 +176,46 @@ func (s *ThingModelService) GetTSL(ctx context.Context, productId int64) (*iot2.
 	return tsl, nil
 }
 
+// FilterUpstreamMessage 按产品生效的物模型过滤上行消息，返回 nil 表示消息不符合物模型
+func (s *ThingModelService) FilterUpstreamMessage(ctx context.Context, message *iotcore.IotDeviceMessage, productId int64) (*iotcore.IotDeviceMessage, error) {
+	if message.Method != consts.IotDeviceMessageMethodPropertyPost && message.Method != consts.IotDeviceMessageMethodEventPost {
+		return message, nil
+	}
+	tsl, err := s.GetTSLFromCache(ctx, productId)
+	if err != nil {
+		return nil, err
+	}
+	filtered, _ := FilterThingModelMessage(tsl, message)
+	return filtered, nil
+}
+
+// GetTSLFromCache 获取生效的物模型 TSL（缓存）
+// 产品已发布物模型版本时使用生效版本，否则使用草稿
+func (s *ThingModelService) GetTSLFromCache(ctx context.Context, productId int64) (*iot2.IotThingModelTSLRespVO, error) {
+	s.cacheMu.RLock()
+	tsl, ok := s.tslCache[productId]
+	s.cacheMu.RUnlock()
+	if ok {
+		return tsl, nil
+	}
+
+	tsl, err := s.getActiveTSL(ctx, productId)
+	if err != nil {
+		return nil, err
+	}
+	s.cacheMu.Lock()
+	s.tslCache[productId] = tsl
+	s.cacheMu.Unlock()
+	return tsl, nil
+}
+
+// invalidateTSLCache 物模型或生效版本变更后清除 TSL 缓存
+func (s *ThingModelService) invalidateTSLCache(productId int64) {
+	s.cacheMu.Lock()
+	delete(s.tslCache, productId)
+	s.cacheMu.Unlock()
+}
+
 func (s *ThingModelService) GetThingModelListByProductIdAndType(ctx context.Context, productId int64, tmType int8) ([]*model.IotThingModelDO, error) {
 	return s.thingModelRepo.ListByProductIDAndType(ctx, productId, tmType)
 }
@@ -0,0 +1,310 @@
+package iot
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/dto"
+	iotcore "github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
+)
+
+// ThingModelViolations 物模型校验未通过的标识符及原因，key: 标识符
+type ThingModelViolations map[string]string
+
+// BizError 汇总为业务错误，用于回复设备与记录消息日志
+func (v ThingModelViolations) BizError() *errors.BizError {
+	identifiers := make([]string, 0, len(v))
+	for identifier := range v {
+		identifiers = append(identifiers, identifier)
+	}
+	sort.Strings(identifiers)
+	details := make([]string, 0, len(identifiers))
+	for _, identifier := range identifiers {
+		details = append(details, identifier+": "+v[identifier])
+	}
+	return errors.NewBizError(model.ErrThingModelDataInvalid.Code,
+		fmt.Sprintf("%s（%s）", model.ErrThingModelDataInvalid.Msg, strings.Join(details, "；")))
+}
+
+// ValidatePropertyParams 按物模型校验属性上报参数，返回校验通过的属性与未通过的原因
+// 产品未定义任何属性时不做校验，全部视为通过
+func ValidatePropertyParams(tsl *iot2.IotThingModelTSLRespVO, params map[string]any) (map[string]any, ThingModelViolations) {
+	if tsl == nil || len(tsl.Properties) == 0 {
+		return params, nil
+	}
+	properties := make(map[string]*dto.ThingModelProperty, len(tsl.Properties))
+	for i := range tsl.Properties {
+		properties[tsl.Properties[i].Identifier] = &tsl.Properties[i]
+	}
+
+	valid := make(map[string]any, len(params))
+	violations := ThingModelViolations{}
+	for identifier, value := range params {
+		property, ok := properties[identifier]
+		if !ok {
+			violations[identifier] = "物模型未定义该属性"
+			continue
+		}
+		if err := validateThingModelValue(property.DataType, property.DataSpecs, property.DataSpecsList, value); err != nil {
+			violations[identifier] = err.Error()
+			continue
+		}
+		valid[identifier] = value
+	}
+	if len(violations) == 0 {
+		return valid, nil
+	}
+	return valid, violations
+}
+
+// ValidateEventParams 按物模型校验事件上报参数
+// 产品未定义任何事件时不做校验
+func ValidateEventParams(tsl *iot2.IotThingModelTSLRespVO, identifier string, value map[string]any) ThingModelViolations {
+	if tsl == nil || len(tsl.Events) == 0 {
+		return nil
+	}
+	var event *dto.ThingModelEvent
+	for i := range tsl.Events {
+		if tsl.Events[i].Identifier == identifier {
+			event = &tsl.Events[i]
+			break
+		}
+	}
+	if event == nil {
+		return ThingModelViolations{identifier: "物模型未定义该事件"}
+	}
+
+	params := make(map[string]*dto.ThingModelParam, len(event.OutputParams))
+	for i := range event.OutputParams {
+		params[event.OutputParams[i].Identifier] = &event.OutputParams[i]
+	}
+	violations := ThingModelViolations{}
+	for name, v := range value {
+		param, ok := params[name]
+		if !ok {
+			violations[identifier+"."+name] = "物模型未定义该事件参数"
+			continue
+		}
+		if err := validateThingModelValue(param.DataType, param.DataSpecs, param.DataSpecsList, v); err != nil {
+			violations[identifier+"."+name] = err.Error()
+		}
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	return violations
+}
+
+// FilterThingModelMessage 按物模型过滤上行消息，供消息总线的各订阅者在处理前调用
+// 属性上报仅保留校验通过的属性，返回副本而不修改原消息（原消息可能被其他订阅者并发读取）；
+// 属性全部未通过或事件不符合物模型时返回 nil，消息不应继续处理；其他方法原样返回
+func FilterThingModelMessage(tsl *iot2.IotThingModelTSLRespVO, message *iotcore.IotDeviceMessage) (*iotcore.IotDeviceMessage, ThingModelViolations) {
+	switch message.Method {
+	case consts.IotDeviceMessageMethodPropertyPost:
+		if len(message.Params) == 0 {
+			return message, nil
+		}
+		valid, violations := ValidatePropertyParams(tsl, message.Params)
+		if len(violations) == 0 {
+			return message, nil
+		}
+		if len(valid) == 0 {
+			return nil, violations
+		}
+		filtered := *message
+		filtered.Params = valid
+		return &filtered, violations
+	case consts.IotDeviceMessageMethodEventPost:
+		if message.Params == nil {
+			return message, nil
+		}
+		identifier, _ := message.Params["identifier"].(string)
+		value, _ := message.Params["value"].(map[string]any)
+		if violations := ValidateEventParams(tsl, identifier, value); len(violations) > 0 {
+			return nil, violations
+		}
+	}
+	return message, nil
+}
+
+// validateThingModelValue 按数据类型与数据规范校验单个值
+func validateThingModelValue(dataType string, specs *dto.ThingModelDataSpecs, specsList []dto.ThingModelDataSpecs, value any) error {
+	switch dataType {
+	case consts.IotDataSpecsDataTypeInt:
+		number, ok := toThingModelNumber(value)
+		if !ok || number != math.Trunc(number) {
+			return fmt.Errorf("应为整数")
+		}
+		return validateThingModelRange(specs, number)
+	case consts.IotDataSpecsDataTypeFloat, consts.IotDataSpecsDataTypeDouble:
+		number, ok := toThingModelNumber(value)
+		if !ok {
+			return fmt.Errorf("应为数值")
+		}
+		return validateThingModelRange(specs, number)
+	case consts.IotDataSpecsDataTypeBool, consts.IotDataSpecsDataTypeEnum:
+		if b, ok := value.(bool); ok && dataType == consts.IotDataSpecsDataTypeBool {
+			value = 0
+			if b {
+				value = 1
+			}
+		}
+		number, ok := toThingModelNumber(value)
+		if !ok || number != math.Trunc(number) {
+			return fmt.Errorf("应为枚举值")
+		}
+		if len(specsList) == 0 {
+			return nil
+		}
+		for _, item := range specsList {
+			if item.Value != nil && float64(item.Value.Value) == number {
+				return nil
+			}
+		}
+		return fmt.Errorf("枚举值 %v 不在物模型定义范围内", value)
+	case consts.IotDataSpecsDataTypeText:
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("应为文本")
+		}
+		if specs != nil && specs.MaxLength != nil && specs.MaxLength.Value > 0 && utf8.RuneCountInString(text) > specs.MaxLength.Value {
+			return fmt.Errorf("长度超过 %d", specs.MaxLength.Value)
+		}
+		return nil
+	case consts.IotDataSpecsDataTypeDate:
+		if text, ok := value.(string); ok {
+			if _, err := strconv.ParseInt(text, 10, 64); err != nil {
+				return fmt.Errorf("应为毫秒时间戳")
+			}
+			return nil
+		}
+		if number, ok := toThingModelNumber(value); !ok || number < 0 {
+			return fmt.Errorf("应为毫秒时间戳")
+		}
+		return nil
+	case consts.IotDataSpecsDataTypeStruct:
+		return validateThingModelStruct(specsList, value)
+	case consts.IotDataSpecsDataTypeArray:
+		return validateThingModelArray(specs, value)
+	default:
+		// 未知类型不做限制，兼容后续新增的数据类型
+		return nil
+	}
+}
+
+// validateThingModelRange 校验数值范围
+func validateThingModelRange(specs *dto.ThingModelDataSpecs, number float64) error {
+	if specs == nil {
+		return nil
+	}
+	if specs.Min != nil && number < specs.Min.Value {
+		return fmt.Errorf("%v 小于最小值 %v", number, specs.Min.Value)
+	}
+	if specs.Max != nil && number > specs.Max.Value {
+		return fmt.Errorf("%v 大于最大值 %v", number, specs.Max.Value)
+	}
+	return nil
+}
+
+// validateThingModelStruct 校验结构体：成员须在物模型中定义，且成员值符合各自的数据规范
+func validateThingModelStruct(members []dto.ThingModelDataSpecs, value any) error {
+	fields, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Errorf("应为结构体")
+	}
+	defined := make(map[string]*dto.ThingModelDataSpecs, len(members))
+	for i := range members {
+		defined[members[i].Identifier] = &members[i]
+	}
+	for name, v := range fields {
+		member, ok := defined[name]
+		if !ok {
+			return fmt.Errorf("结构体成员 %s 未定义", name)
+		}
+		// 成员的数值范围、文本长度可能声明在 dataSpecs 中，也可能直接平铺在成员上
+		specs := member.DataSpecs
+		if specs == nil {
+			specs = member
+		}
+		if err := validateThingModelValue(structMemberDataType(member), specs, member.DataSpecsList, v); err != nil {
+			return fmt.Errorf("结构体成员 %s %s", name, err.Error())
+		}
+	}
+	return nil
+}
+
+// validateThingModelArray 校验数组：元素个数不超过 size，元素符合 childDataType
+func validateThingModelArray(specs *dto.ThingModelDataSpecs, value any) error {
+	items, ok := value.([]any)
+	if !ok {
+		return fmt.Errorf("应为数组")
+	}
+	if specs == nil {
+		return nil
+	}
+	if specs.Size != nil && specs.Size.Value > 0 && len(items) > specs.Size.Value {
+		return fmt.Errorf("元素个数超过 %d", specs.Size.Value)
+	}
+	for i, item := range items {
+		// 数组元素为结构体时，dataSpecsList 为结构体成员
+		if err := validateThingModelValue(specs.ChildDataType, nil, specs.DataSpecsList, item); err != nil {
+			return fmt.Errorf("第 %d 个元素%s", i+1, err.Error())
+		}
+	}
+	return nil
+}
+
+// structMemberDataType 结构体成员的数据类型：优先取 childDataType（对齐 Java ThingModelStructDataSpecs）
+func structMemberDataType(member *dto.ThingModelDataSpecs) string {
+	if member.ChildDataType != "" {
+		return member.ChildDataType
+	}
+	if member.DataSpecs != nil && member.DataSpecs.DataType != "" {
+		return member.DataSpecs.DataType
+	}
+	return member.DataType
+}
+
+// toThingModelNumber 将设备上报的数值（JSON 解码或二进制编解码结果）转换为 float64
+func toThingModelNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,139 @@
+package iot
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	iotcore "github.com/wxlbd/ruoyi-mall-go/internal/iot/core"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+)
+
+// 温控器物模型：数值范围、枚举、文本长度、结构体与数组
+const testThingModelTSL = `{
+	"properties": [
+		{"identifier": "temperature", "dataType": "float", "dataSpecs": {"dataType": "float", "min": "-40", "max": "125"}},
+		{"identifier": "humidity", "dataType": "int", "dataSpecs": {"dataType": "int", "min": 0, "max": 100}},
+		{"identifier": "mode", "dataType": "enum", "dataSpecsList": [{"dataType": "enum", "name": "制冷", "value": 0}, {"dataType": "enum", "name": "制热", "value": 1}]},
+		{"identifier": "power", "dataType": "bool", "dataSpecsList": [{"dataType": "bool", "name": "关", "value": 0}, {"dataType": "bool", "name": "开", "value": 1}]},
+		{"identifier": "label", "dataType": "text", "dataSpecs": {"dataType": "text", "maxLength": 4}},
+		{"identifier": "location", "dataType": "struct", "dataSpecsList": [
+			{"identifier": "lng", "childDataType": "double", "dataSpecs": {"dataType": "double", "min": -180, "max": 180}},
+			{"identifier": "lat", "childDataType": "double", "dataSpecs": {"dataType": "double", "min": -90, "max": 90}}
+		]},
+		{"identifier": "history", "dataType": "array", "dataSpecs": {"dataType": "array", "childDataType": "int", "size": 3}}
+	],
+	"events": [
+		{"identifier": "alarm", "type": "alert", "outputParams": [
+			{"identifier": "level", "dataType": "int", "dataSpecs": {"dataType": "int", "min": 1, "max": 3}}
+		]}
+	]
+}`
+
+func newTestTSL(t *testing.T) *iot2.IotThingModelTSLRespVO {
+	var tsl iot2.IotThingModelTSLRespVO
+	assert.NoError(t, json.Unmarshal([]byte(testThingModelTSL), &tsl))
+	return &tsl
+}
+
+// TestValidatePropertyParams 验证属性按物模型校验，合法属性保留、非法属性给出原因
+func TestValidatePropertyParams(t *testing.T) {
+	tsl := newTestTSL(t)
+
+	valid, violations := ValidatePropertyParams(tsl, map[string]any{
+		"temperature": 25.5,
+		"humidity":    int64(60),
+		"mode":        float64(1),
+		"power":       true,
+		"label":       "客厅",
+		"location":    map[string]any{"lng": 120.1, "lat": 30.2},
+		"history":     []any{float64(1), float64(2)},
+	})
+	assert.Empty(t, violations)
+	assert.Len(t, valid, 7)
+
+	testCases := []struct {
+		name       string
+		identifier string
+		value      any
+	}{
+		{name: "未定义属性", identifier: "unknown", value: 1},
+		{name: "超出最大值", identifier: "temperature", value: 200.0},
+		{name: "整数含小数", identifier: "humidity", value: 60.5},
+		{name: "数值为字符串", identifier: "humidity", value: "60"},
+		{name: "枚举值未定义", identifier: "mode", value: float64(2)},
+		{name: "文本超长", identifier: "label", value: "客厅温控器"},
+		{name: "结构体成员越界", identifier: "location", value: map[string]any{"lng": 120.1, "lat": 95.0}},
+		{name: "结构体成员未定义", identifier: "location", value: map[string]any{"alt": 10.0}},
+		{name: "数组超长", identifier: "history", value: []any{1, 2, 3, 4}},
+		{name: "数组元素类型错误", identifier: "history", value: []any{"a"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			valid, violations := ValidatePropertyParams(tsl, map[string]any{"temperature": 20.0, tc.identifier: tc.value})
+			assert.Contains(t, violations, tc.identifier)
+			assert.NotContains(t, valid, tc.identifier)
+			if tc.identifier != "temperature" {
+				assert.Contains(t, valid, "temperature")
+			}
+		})
+	}
+}
+
+// TestValidatePropertyParamsWithoutTSL 验证产品未定义属性时不做校验
+func TestValidatePropertyParamsWithoutTSL(t *testing.T) {
+	params := map[string]any{"anything": "goes"}
+	valid, violations := ValidatePropertyParams(&iot2.IotThingModelTSLRespVO{}, params)
+	assert.Empty(t, violations)
+	assert.Equal(t, params, valid)
+}
+
+// TestValidateEventParams 验证事件输出参数校验
+func TestValidateEventParams(t *testing.T) {
+	tsl := newTestTSL(t)
+
+	assert.Empty(t, ValidateEventParams(tsl, "alarm", map[string]any{"level": float64(2)}))
+	assert.Contains(t, ValidateEventParams(tsl, "alarm", map[string]any{"level": float64(5)}), "alarm.level")
+	assert.Contains(t, ValidateEventParams(tsl, "alarm", map[string]any{"extra": 1}), "alarm.extra")
+	assert.Contains(t, ValidateEventParams(tsl, "fire", nil), "fire")
+
+	bizErr := ValidateEventParams(tsl, "fire", nil).BizError()
+	assert.Equal(t, model.ErrThingModelDataInvalid.Code, bizErr.Code)
+	assert.Contains(t, bizErr.Msg, "fire")
+}
+
+// TestFilterThingModelMessage 验证上行消息按物模型过滤：非法属性剔除且不修改原消息，非法事件丢弃
+func TestFilterThingModelMessage(t *testing.T) {
+	tsl := newTestTSL(t)
+
+	message := &iotcore.IotDeviceMessage{
+		Method: consts.IotDeviceMessageMethodPropertyPost,
+		Params: map[string]any{"temperature": 25.5, "humidity": 200.0},
+	}
+	filtered, violations := FilterThingModelMessage(tsl, message)
+	assert.Contains(t, violations, "humidity")
+	assert.Equal(t, map[string]any{"temperature": 25.5}, filtered.Params)
+	assert.Contains(t, message.Params, "humidity")
+
+	filtered, _ = FilterThingModelMessage(tsl, &iotcore.IotDeviceMessage{
+		Method: consts.IotDeviceMessageMethodPropertyPost,
+		Params: map[string]any{"unknown": 1},
+	})
+	assert.Nil(t, filtered)
+
+	filtered, _ = FilterThingModelMessage(tsl, &iotcore.IotDeviceMessage{
+		Method: consts.IotDeviceMessageMethodEventPost,
+		Params: map[string]any{"identifier": "alarm", "value": map[string]any{"level": float64(5)}},
+	})
+	assert.Nil(t, filtered)
+
+	valid := &iotcore.IotDeviceMessage{
+		Method: consts.IotDeviceMessageMethodEventPost,
+		Params: map[string]any{"identifier": "alarm", "value": map[string]any{"level": float64(2)}},
+	}
+	filtered, violations = FilterThingModelMessage(tsl, valid)
+	assert.Empty(t, violations)
+	assert.Same(t, valid, filtered)
+}
@@ -0,0 +1,359 @@
+package iot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	iot2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/iot"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/dto"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
+	"gorm.io/datatypes"
+)
+
+// tslItem TSL 中的单个功能，用于导入与版本对比
+type tslItem struct {
+	tmType     int8
+	identifier string
+	name       string
+	data       any
+}
+
+// ExportTSL 导出物模型 TSL，versionID 为 0 时导出草稿
+func (s *ThingModelService) ExportTSL(ctx context.Context, productID, versionID int64) (*iot2.IotThingModelTSLRespVO, error) {
+	if versionID == 0 {
+		return s.GetTSL(ctx, productID)
+	}
+	version, err := s.getVersion(ctx, versionID)
+	if err != nil {
+		return nil, err
+	}
+	if version.ProductID != productID {
+		return nil, model.ErrThingModelVersionNotExists
+	}
+	return parseVersionTSL(version), nil
+}
+
+// ImportTSL 导入物模型 TSL，整体覆盖产品的物模型草稿
+// 与已有功能标识与类型相同的功能保留其描述；已发布的产品不允许操作物模型
+func (s *ThingModelService) ImportTSL(ctx context.Context, r *iot2.IotThingModelTSLImportReqVO) error {
+	product, err := s.productRepo.GetByID(ctx, r.ProductID)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return model.ErrProductNotExists
+	}
+	if product.Status == consts.IotProductStatusPublished {
+		return model.ErrProductStatusNotAllowThingModel
+	}
+	if err := validateTSL(r.TSL); err != nil {
+		return err
+	}
+	if err := s.replaceDraft(ctx, product, r.TSL); err != nil {
+		return err
+	}
+	s.invalidateTSLCache(product.ID)
+	return nil
+}
+
+// PublishVersion 将物模型草稿发布为新版本，新版本立即生效
+func (s *ThingModelService) PublishVersion(ctx context.Context, r *iot2.IotThingModelVersionPublishReqVO) (int64, error) {
+	draft, err := s.GetTSL(ctx, r.ProductID)
+	if err != nil {
+		return 0, err
+	}
+	active, err := s.versionRepo.GetActiveByProductID(ctx, r.ProductID)
+	if err != nil {
+		return 0, err
+	}
+	if active != nil && len(diffTSL(parseVersionTSL(active), draft)) == 0 {
+		return 0, model.ErrThingModelVersionNoChange
+	}
+	maxVersion, err := s.versionRepo.GetMaxVersion(ctx, r.ProductID)
+	if err != nil {
+		return 0, err
+	}
+
+	tslJson, _ := json.Marshal(draft)
+	version := &model.IotThingModelVersionDO{
+		ProductID:   draft.ProductID,
+		ProductKey:  draft.ProductKey,
+		Version:     maxVersion + 1,
+		TSL:         datatypes.JSON(tslJson),
+		Remark:      r.Remark,
+		PublishTime: time.Now(),
+	}
+	if err := s.versionRepo.Publish(ctx, version); err != nil {
+		return 0, err
+	}
+	s.invalidateTSLCache(r.ProductID)
+	return version.ID, nil
+}
+
+// RollbackVersion 回滚到历史版本：该版本重新生效，并将草稿恢复为该版本的 TSL
+func (s *ThingModelService) RollbackVersion(ctx context.Context, id int64) error {
+	version, err := s.getVersion(ctx, id)
+	if err != nil {
+		return err
+	}
+	if version.Status == consts.IotThingModelVersionStatusActive {
+		return model.ErrThingModelVersionIsActive
+	}
+	product, err := s.productRepo.GetByID(ctx, version.ProductID)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return model.ErrProductNotExists
+	}
+
+	drafts, err := s.buildDraft(ctx, product, parseVersionTSL(version))
+	if err != nil {
+		return err
+	}
+	// 版本生效与草稿恢复在同一事务中完成，提交后再失效缓存，避免缓存加载到中间状态
+	if err := s.versionRepo.Rollback(ctx, version.ProductID, version.ID, drafts); err != nil {
+		return err
+	}
+	s.invalidateTSLCache(version.ProductID)
+	return nil
+}
+
+// GetVersion 获取物模型版本，包含 TSL 快照
+func (s *ThingModelService) GetVersion(ctx context.Context, id int64) (*iot2.IotThingModelVersionRespVO, error) {
+	version, err := s.versionRepo.GetByID(ctx, id)
+	if err != nil || version == nil {
+		return nil, err
+	}
+	vo := toThingModelVersionRespVO(version)
+	vo.TSL = parseVersionTSL(version)
+	return vo, nil
+}
+
+// GetVersionList 获取产品的物模型版本列表，按版本号倒序
+func (s *ThingModelService) GetVersionList(ctx context.Context, productID int64) ([]*iot2.IotThingModelVersionRespVO, error) {
+	list, err := s.versionRepo.ListByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*iot2.IotThingModelVersionRespVO, 0, len(list))
+	for _, version := range list {
+		result = append(result, toThingModelVersionRespVO(version))
+	}
+	return result, nil
+}
+
+// DiffVersion 对比两个物模型版本
+// 基准版本不传时为生效版本（没有生效版本时视为空物模型），目标版本不传时为草稿
+func (s *ThingModelService) DiffVersion(ctx context.Context, r *iot2.IotThingModelVersionDiffReqVO) (*iot2.IotThingModelVersionDiffRespVO, error) {
+	resp := &iot2.IotThingModelVersionDiffRespVO{}
+
+	var base *model.IotThingModelVersionDO
+	var err error
+	if r.BaseVersionID != 0 {
+		base, err = s.getProductVersion(ctx, r.ProductID, r.BaseVersionID)
+	} else {
+		base, err = s.versionRepo.GetActiveByProductID(ctx, r.ProductID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	baseTSL := &iot2.IotThingModelTSLRespVO{}
+	if base != nil {
+		resp.BaseVersion = base.Version
+		baseTSL = parseVersionTSL(base)
+	}
+
+	var targetTSL *iot2.IotThingModelTSLRespVO
+	if r.TargetVersionID != 0 {
+		target, err := s.getProductVersion(ctx, r.ProductID, r.TargetVersionID)
+		if err != nil {
+			return nil, err
+		}
+		resp.TargetVersion = target.Version
+		targetTSL = parseVersionTSL(target)
+	} else if targetTSL, err = s.GetTSL(ctx, r.ProductID); err != nil {
+		return nil, err
+	}
+
+	resp.Items = diffTSL(baseTSL, targetTSL)
+	return resp, nil
+}
+
+// getActiveTSL 获取生效的物模型 TSL：产品已发布物模型版本时使用生效版本，否则使用草稿
+func (s *ThingModelService) getActiveTSL(ctx context.Context, productID int64) (*iot2.IotThingModelTSLRespVO, error) {
+	active, err := s.versionRepo.GetActiveByProductID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if active == nil {
+		return s.GetTSL(ctx, productID)
+	}
+	return parseVersionTSL(active), nil
+}
+
+// replaceDraft 使用 TSL 整体替换物模型草稿
+func (s *ThingModelService) replaceDraft(ctx context.Context, product *model.IotProductDO, tsl *iot2.IotThingModelTSLRespVO) error {
+	list, err := s.buildDraft(ctx, product, tsl)
+	if err != nil {
+		return err
+	}
+	return s.thingModelRepo.ReplaceByProductID(ctx, product.ID, list)
+}
+
+// buildDraft 将 TSL 转换为物模型草稿功能列表，标识与类型相同的已有功能保留其描述
+func (s *ThingModelService) buildDraft(ctx context.Context, product *model.IotProductDO, tsl *iot2.IotThingModelTSLRespVO) ([]*model.IotThingModelDO, error) {
+	existing, err := s.thingModelRepo.ListByProductID(ctx, product.ID)
+	if err != nil {
+		return nil, err
+	}
+	descriptions := make(map[string]string, len(existing))
+	for _, tm := range existing {
+		descriptions[fmt.Sprintf("%d:%s", tm.Type, tm.Identifier)] = tm.Description
+	}
+
+	items := tslItems(tsl)
+	list := make([]*model.IotThingModelDO, 0, len(items))
+	for _, item := range items {
+		tm := &model.IotThingModelDO{
+			Identifier:  item.identifier,
+			Name:        item.name,
+			Description: descriptions[fmt.Sprintf("%d:%s", item.tmType, item.identifier)],
+			ProductID:   product.ID,
+			ProductKey:  product.ProductKey,
+			Type:        item.tmType,
+		}
+		switch data := item.data.(type) {
+		case dto.ThingModelProperty:
+			tm.Property = datatypes.NewJSONType(data)
+		case dto.ThingModelService:
+			tm.Service = datatypes.NewJSONType(data)
+		case dto.ThingModelEvent:
+			tm.Event = datatypes.NewJSONType(data)
+		}
+		list = append(list, tm)
+	}
+	return list, nil
+}
+
+// getVersion 获取物模型版本，不存在时返回 ErrThingModelVersionNotExists
+func (s *ThingModelService) getVersion(ctx context.Context, id int64) (*model.IotThingModelVersionDO, error) {
+	version, err := s.versionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if version == nil {
+		return nil, model.ErrThingModelVersionNotExists
+	}
+	return version, nil
+}
+
+// getProductVersion 获取指定产品的物模型版本
+func (s *ThingModelService) getProductVersion(ctx context.Context, productID, id int64) (*model.IotThingModelVersionDO, error) {
+	version, err := s.getVersion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if version.ProductID != productID {
+		return nil, model.ErrThingModelVersionNotExists
+	}
+	return version, nil
+}
+
+// validateTSL 校验导入的 TSL：功能标识与名称必填，功能标识在产品内唯一，属性需指定数据类型
+func validateTSL(tsl *iot2.IotThingModelTSLRespVO) error {
+	seen := make(map[string]bool)
+	for _, item := range tslItems(tsl) {
+		if item.identifier == "" || item.name == "" {
+			return errors.NewBizError(model.ErrThingModelTSLInvalid.Code,
+				fmt.Sprintf("%s：功能标识与功能名称不能为空", model.ErrThingModelTSLInvalid.Msg))
+		}
+		if seen[item.identifier] {
+			return errors.NewBizError(model.ErrThingModelTSLInvalid.Code,
+				fmt.Sprintf("%s：功能标识【%s】重复", model.ErrThingModelTSLInvalid.Msg, item.identifier))
+		}
+		seen[item.identifier] = true
+		if property, ok := item.data.(dto.ThingModelProperty); ok && property.DataType == "" {
+			return errors.NewBizError(model.ErrThingModelTSLInvalid.Code,
+				fmt.Sprintf("%s：属性【%s】未指定数据类型", model.ErrThingModelTSLInvalid.Msg, item.identifier))
+		}
+	}
+	return nil
+}
+
+// tslItems 按属性、服务、事件的顺序展开 TSL 中的功能
+func tslItems(tsl *iot2.IotThingModelTSLRespVO) []tslItem {
+	items := make([]tslItem, 0, len(tsl.Properties)+len(tsl.Services)+len(tsl.Events))
+	for _, p := range tsl.Properties {
+		items = append(items, tslItem{tmType: consts.IotThingModelTypeProperty, identifier: p.Identifier, name: p.Name, data: p})
+	}
+	for _, svc := range tsl.Services {
+		items = append(items, tslItem{tmType: consts.IotThingModelTypeService, identifier: svc.Identifier, name: svc.Name, data: svc})
+	}
+	for _, e := range tsl.Events {
+		items = append(items, tslItem{tmType: consts.IotThingModelTypeEvent, identifier: e.Identifier, name: e.Name, data: e})
+	}
+	return items
+}
+
+// diffTSL 对比两个 TSL，按功能类型与标识匹配，返回新增、修改与删除的功能
+func diffTSL(base, target *iot2.IotThingModelTSLRespVO) []*iot2.IotThingModelVersionDiffItemVO {
+	key := func(item tslItem) string { return fmt.Sprintf("%d:%s", item.tmType, item.identifier) }
+	targetItems := make(map[string]tslItem)
+	for _, item := range tslItems(target) {
+		targetItems[key(item)] = item
+	}
+
+	diffs := make([]*iot2.IotThingModelVersionDiffItemVO, 0)
+	baseKeys := make(map[string]bool)
+	for _, before := range tslItems(base) {
+		baseKeys[key(before)] = true
+		after, ok := targetItems[key(before)]
+		if !ok {
+			diffs = append(diffs, &iot2.IotThingModelVersionDiffItemVO{Type: before.tmType, Identifier: before.identifier,
+				Name: before.name, DiffType: consts.IotThingModelDiffTypeDelete, Before: before.data})
+			continue
+		}
+		beforeJson, _ := json.Marshal(before.data)
+		afterJson, _ := json.Marshal(after.data)
+		if !bytes.Equal(beforeJson, afterJson) {
+			diffs = append(diffs, &iot2.IotThingModelVersionDiffItemVO{Type: after.tmType, Identifier: after.identifier,
+				Name: after.name, DiffType: consts.IotThingModelDiffTypeUpdate, Before: before.data, After: after.data})
+		}
+	}
+	for _, after := range tslItems(target) {
+		if !baseKeys[key(after)] {
+			diffs = append(diffs, &iot2.IotThingModelVersionDiffItemVO{Type: after.tmType, Identifier: after.identifier,
+				Name: after.name, DiffType: consts.IotThingModelDiffTypeCreate, After: after.data})
+		}
+	}
+	return diffs
+}
+
+// parseVersionTSL 解析版本的 TSL 快照
+func parseVersionTSL(version *model.IotThingModelVersionDO) *iot2.IotThingModelTSLRespVO {
+	tsl := &iot2.IotThingModelTSLRespVO{}
+	if len(version.TSL) > 0 {
+		_ = json.Unmarshal(version.TSL, tsl)
+	}
+	tsl.ProductID, tsl.ProductKey = version.ProductID, version.ProductKey
+	return tsl
+}
+
+func toThingModelVersionRespVO(version *model.IotThingModelVersionDO) *iot2.IotThingModelVersionRespVO {
+	return &iot2.IotThingModelVersionRespVO{
+		ID:          version.ID,
+		ProductID:   version.ProductID,
+		ProductKey:  version.ProductKey,
+		Version:     version.Version,
+		Status:      version.Status,
+		Remark:      version.Remark,
+		PublishTime: version.PublishTime,
+		Creator:     version.Creator,
+	}
+}
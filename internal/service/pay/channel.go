@@ -3,8 +3,10 @@ package pay
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	pay2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/pay"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
 	"github.com/wxlbd/ruoyi-mall-go/internal/model/pay"
 	"github.com/wxlbd/ruoyi-mall-go/internal/repo/query"
 	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client"
@@ -27,13 +29,15 @@ func NewPayChannelService(q *query.Query, clientFactory *client.PayClientFactory
 
 // CreateChannel 创建支付渠道
 func (s *PayChannelService) CreateChannel(ctx context.Context, req *pay2.PayChannelCreateReq) (int64, error) {
-	// 1. 校验是否重复 (AppID + Code)
-	exists, err := s.GetChannelByAppIdAndCode(ctx, req.AppID, req.Code)
-	if err != nil {
-		return 0, err
-	}
-	if exists != nil {
-		return 0, pkgErrors.NewBizError(1006002000, "支付渠道已存在") // PAY_CHANNEL_EXIST_SAME_CHANNEL_ERROR
+	// 1. 校验是否重复 (AppID + Code)，支付宝、微信渠道允许配置多个商户号，由路由规则分配
+	if !consts.IsPayChannelAlipay(req.Code) && !consts.IsPayChannelWeixin(req.Code) {
+		exists, err := s.GetChannelByAppIdAndCode(ctx, req.AppID, req.Code)
+		if err != nil {
+			return 0, err
+		}
+		if exists != nil {
+			return 0, pkgErrors.NewBizError(1006002000, "支付渠道已存在") // PAY_CHANNEL_EXIST_SAME_CHANNEL_ERROR
+		}
 	}
 
 	// 2. 插入
@@ -45,8 +49,7 @@ func (s *PayChannelService) CreateChannel(ctx context.Context, req *pay2.PayChan
 		AppID:   req.AppID,
 		Config:  req.Config,
 	}
-	err = s.q.PayChannel.WithContext(ctx).Create(channel)
-	if err != nil {
+	if err := s.q.PayChannel.WithContext(ctx).Create(channel); err != nil {
 		return 0, err
 	}
 	return channel.ID, nil
@@ -66,7 +69,13 @@ func (s *PayChannelService) UpdateChannel(ctx context.Context, req *pay2.PayChan
 		Remark:  req.Remark,
 		Config:  req.Config,
 	})
-	return err
+	if err != nil {
+		return err
+	}
+
+	// 3. 移除旧配置的支付客户端，下次使用时按新配置创建
+	s.clientFactory.RemovePayClient(req.ID)
+	return nil
 }
 
 // DeleteChannel 删除支付渠道
@@ -76,8 +85,11 @@ func (s *PayChannelService) DeleteChannel(ctx context.Context, id int64) error {
 		return err
 	}
 	// 2. 删除
-	_, err := s.q.PayChannel.WithContext(ctx).Where(s.q.PayChannel.ID.Eq(id)).Delete()
-	return err
+	if _, err := s.q.PayChannel.WithContext(ctx).Where(s.q.PayChannel.ID.Eq(id)).Delete(); err != nil {
+		return err
+	}
+	s.clientFactory.RemovePayClient(id)
+	return nil
 }
 
 // GetChannel 获得支付渠道
@@ -102,9 +114,12 @@ func (s *PayChannelService) GetChannelListByAppIds(ctx context.Context, appIds [
 
 // Private Methods
 
-// GetChannelByAppIdAndCode 根据 AppID 和 Code 获得支付渠道
+// GetChannelByAppIdAndCode 根据 AppID 和 Code 获得支付渠道，同一编码配置多个商户号时返回最早创建的渠道
 func (s *PayChannelService) GetChannelByAppIdAndCode(ctx context.Context, appId int64, code string) (*pay.PayChannel, error) {
-	channel, err := s.q.PayChannel.WithContext(ctx).Where(s.q.PayChannel.AppID.Eq(appId), s.q.PayChannel.Code.Eq(code)).First()
+	channel, err := s.q.PayChannel.WithContext(ctx).
+		Where(s.q.PayChannel.AppID.Eq(appId), s.q.PayChannel.Code.Eq(code)).
+		Order(s.q.PayChannel.ID).
+		First()
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil // 对齐Java: 查询不到返回null而不是异常
@@ -114,6 +129,15 @@ func (s *PayChannelService) GetChannelByAppIdAndCode(ctx context.Context, appId
 	return channel, nil
 }
 
+// GetChannelList 获得指定应用的支付渠道列表，code 为空时不过滤渠道编码
+func (s *PayChannelService) GetChannelList(ctx context.Context, appId int64, code string) ([]*pay.PayChannel, error) {
+	do := s.q.PayChannel.WithContext(ctx).Where(s.q.PayChannel.AppID.Eq(appId))
+	if code != "" {
+		do = do.Where(s.q.PayChannel.Code.Eq(code))
+	}
+	return do.Order(s.q.PayChannel.ID).Find()
+}
+
 // GetEnableChannelList 获得指定应用的开启的支付渠道列表
 func (s *PayChannelService) GetEnableChannelList(ctx context.Context, appId int64) ([]*pay.PayChannel, error) {
 	return s.q.PayChannel.WithContext(ctx).
@@ -144,8 +168,35 @@ func (s *PayChannelService) ValidPayChannel(ctx context.Context, id int64) (*pay
 	return channel, nil
 }
 
+// GetCombinePayClient 获得支付应用的钱包渠道及其组合支付客户端，不存在时返回 nil
+// 不校验渠道状态：钱包渠道关闭后，已冻结的钱包金额仍需扣款、解冻或退款
+func (s *PayChannelService) GetCombinePayClient(ctx context.Context, appID int64) (*pay.PayChannel, client.CombinePayClient) {
+	channel, err := s.GetChannelByAppIdAndCode(ctx, appID, consts.PayChannelWallet)
+	if err != nil || channel == nil {
+		return nil, nil
+	}
+	combineClient, _ := s.GetPayClient(channel.ID).(client.CombinePayClient)
+	if combineClient == nil {
+		return nil, nil
+	}
+	return channel, combineClient
+}
+
 // GetPayClient 获得支付客户端
 // 对齐 Java: PayChannelService.getPayClient(Long id)
+// 客户端不存在时（例如服务重启后），按渠道配置创建；渠道不存在或创建失败时返回 nil
 func (s *PayChannelService) GetPayClient(channelID int64) client.PayClient {
-	return s.clientFactory.GetPayClient(channelID)
+	if payClient := s.clientFactory.GetPayClient(channelID); payClient != nil {
+		return payClient
+	}
+	channel, err := s.GetChannel(context.Background(), channelID)
+	if err != nil || channel == nil {
+		return nil
+	}
+	payClient, err := s.clientFactory.CreateOrUpdatePayClient(channel.ID, channel.Code, channel.Config.ToJSON())
+	if err != nil {
+		fmt.Printf("[GetPayClient][渠道(%d) 创建支付客户端失败: %v]\n", channelID, err)
+		return nil
+	}
+	return payClient
 }
@@ -0,0 +1,422 @@
+package pay
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"slices"
+	"time"
+
+	pay2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/pay"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model/pay"
+	payrepo "github.com/wxlbd/ruoyi-mall-go/internal/repo/pay"
+	"github.com/wxlbd/ruoyi-mall-go/internal/repo/query"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrPayChannelRouteRuleNotFound     = errors.NewBizError(1007013000, "支付路由规则不存在")                         // PAY_CHANNEL_ROUTE_RULE_NOT_FOUND
+	ErrPayChannelRouteTimeInvalid      = errors.NewBizError(1007013001, "生效时段格式不正确，开始、结束时间须同时为 HH:mm 或同时为空") // PAY_CHANNEL_ROUTE_TIME_INVALID
+	ErrPayChannelRoutePriceInvalid     = errors.NewBizError(1007013002, "最大金额不能小于最小金额")                      // PAY_CHANNEL_ROUTE_PRICE_INVALID
+	ErrPayChannelRouteTargetInvalid    = errors.NewBizError(1007013003, "候选渠道不存在，或不是该支付应用下同一渠道编码的渠道")        // PAY_CHANNEL_ROUTE_TARGET_INVALID
+	ErrPayChannelRouteTargetDuplicate  = errors.NewBizError(1007013004, "候选渠道重复")                            // PAY_CHANNEL_ROUTE_TARGET_DUPLICATE
+	ErrPayChannelRouteChannelNotFound  = errors.NewBizError(1007013005, "没有可用的支付渠道")                         // PAY_CHANNEL_ROUTE_CHANNEL_NOT_FOUND
+	ErrPayChannelRouteChannelCodeWrong = errors.NewBizError(1007013006, "钱包、模拟渠道不支持路由")                      // PAY_CHANNEL_ROUTE_CHANNEL_CODE_WRONG
+)
+
+const (
+	// payChannelFailureThreshold 渠道连续下单失败达到该次数后暂停路由
+	payChannelFailureThreshold = 3
+	// payChannelFailureCooldown 暂停路由的冷却时间，过后重新参与路由，下单成功即恢复
+	payChannelFailureCooldown = time.Minute
+	// payChannelRouteTimeLayout 路由规则生效时段的格式
+	payChannelRouteTimeLayout = "15:04"
+)
+
+// PayChannelRouteService 支付渠道路由服务
+// 同一渠道编码可配置多个商户号（渠道），提交支付时按路由规则排列候选渠道：匹配规则的候选渠道按权重轮询出首选渠道，
+// 其余渠道作为备选；连续下单失败的渠道排到最后。支付订单服务依次尝试，渠道下单失败时自动切换到下一个渠道
+type PayChannelRouteService struct {
+	q          *query.Query
+	appSvc     *PayAppService
+	channelSvc *PayChannelService
+	routeDAO   *payrepo.PayChannelRouteRedisDAO
+}
+
+func NewPayChannelRouteService(q *query.Query, appSvc *PayAppService, channelSvc *PayChannelService, routeDAO *payrepo.PayChannelRouteRedisDAO) *PayChannelRouteService {
+	return &PayChannelRouteService{
+		q:          q,
+		appSvc:     appSvc,
+		channelSvc: channelSvc,
+		routeDAO:   routeDAO,
+	}
+}
+
+// ========== 路由规则 ==========
+
+// CreateRule 创建路由规则
+func (s *PayChannelRouteService) CreateRule(ctx context.Context, req *pay2.PayChannelRouteRuleCreateReq) (int64, error) {
+	if _, err := s.appSvc.ValidPayApp(ctx, req.AppID); err != nil {
+		return 0, err
+	}
+	if req.ChannelCode == consts.PayChannelWallet || req.ChannelCode == consts.PayChannelMock {
+		return 0, ErrPayChannelRouteChannelCodeWrong
+	}
+	if err := validateRouteCondition(req.MinPrice, req.MaxPrice, req.StartTime, req.EndTime); err != nil {
+		return 0, err
+	}
+	targets, err := s.validateRuleTargets(ctx, req.AppID, req.ChannelCode, req.Targets)
+	if err != nil {
+		return 0, err
+	}
+
+	rule := &pay.PayChannelRouteRule{
+		AppID:       req.AppID,
+		Name:        req.Name,
+		ChannelCode: req.ChannelCode,
+		Sort:        req.Sort,
+		MinPrice:    req.MinPrice,
+		MaxPrice:    req.MaxPrice,
+		StartTime:   req.StartTime,
+		EndTime:     req.EndTime,
+		Terminals:   req.Terminals,
+		TenantIDs:   req.TenantIDs,
+		Targets:     targets,
+		Status:      *req.Status,
+		Remark:      req.Remark,
+	}
+	if err := s.q.PayChannelRouteRule.WithContext(ctx).Create(rule); err != nil {
+		return 0, err
+	}
+	return rule.ID, nil
+}
+
+// UpdateRule 更新路由规则
+func (s *PayChannelRouteService) UpdateRule(ctx context.Context, req *pay2.PayChannelRouteRuleUpdateReq) error {
+	rule, err := s.validateRuleExists(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+	if err := validateRouteCondition(req.MinPrice, req.MaxPrice, req.StartTime, req.EndTime); err != nil {
+		return err
+	}
+	targets, err := s.validateRuleTargets(ctx, rule.AppID, rule.ChannelCode, req.Targets)
+	if err != nil {
+		return err
+	}
+
+	rule.Name = req.Name
+	rule.Sort = req.Sort
+	rule.MinPrice = req.MinPrice
+	rule.MaxPrice = req.MaxPrice
+	rule.StartTime = req.StartTime
+	rule.EndTime = req.EndTime
+	rule.Terminals = req.Terminals
+	rule.TenantIDs = req.TenantIDs
+	rule.Targets = targets
+	rule.Status = *req.Status
+	rule.Remark = req.Remark
+	q := s.q.PayChannelRouteRule
+	_, err = q.WithContext(ctx).Where(q.ID.Eq(rule.ID)).
+		Select(q.Name, q.Sort, q.MinPrice, q.MaxPrice, q.StartTime, q.EndTime,
+			q.Terminals, q.TenantIDs, q.Targets, q.Status, q.Remark).
+		Updates(rule)
+	return err
+}
+
+// DeleteRule 删除路由规则
+func (s *PayChannelRouteService) DeleteRule(ctx context.Context, id int64) error {
+	if _, err := s.validateRuleExists(ctx, id); err != nil {
+		return err
+	}
+	_, err := s.q.PayChannelRouteRule.WithContext(ctx).Where(s.q.PayChannelRouteRule.ID.Eq(id)).Delete()
+	return err
+}
+
+// GetRule 获得路由规则
+func (s *PayChannelRouteService) GetRule(ctx context.Context, id int64) (*pay.PayChannelRouteRule, error) {
+	return s.validateRuleExists(ctx, id)
+}
+
+// GetRulePage 获得路由规则分页
+func (s *PayChannelRouteService) GetRulePage(ctx context.Context, req *pay2.PayChannelRouteRulePageReq) (*pagination.PageResult[*pay.PayChannelRouteRule], error) {
+	q := s.q.PayChannelRouteRule
+	do := q.WithContext(ctx)
+	if req.AppID > 0 {
+		do = do.Where(q.AppID.Eq(req.AppID))
+	}
+	if req.Name != "" {
+		do = do.Where(q.Name.Like("%" + req.Name + "%"))
+	}
+	if req.ChannelCode != "" {
+		do = do.Where(q.ChannelCode.Eq(req.ChannelCode))
+	}
+	if req.Status != nil {
+		do = do.Where(q.Status.Eq(*req.Status))
+	}
+
+	total, err := do.Count()
+	if err != nil {
+		return nil, err
+	}
+	list, err := do.Limit(req.GetLimit()).Offset(req.GetOffset()).Order(q.Sort, q.ID).Find()
+	if err != nil {
+		return nil, err
+	}
+	return &pagination.PageResult[*pay.PayChannelRouteRule]{
+		List:  list,
+		Total: total,
+	}, nil
+}
+
+func (s *PayChannelRouteService) validateRuleExists(ctx context.Context, id int64) (*pay.PayChannelRouteRule, error) {
+	rule, err := s.q.PayChannelRouteRule.WithContext(ctx).Where(s.q.PayChannelRouteRule.ID.Eq(id)).First()
+	if err != nil {
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPayChannelRouteRuleNotFound
+		}
+		return nil, err
+	}
+	return rule, nil
+}
+
+// validateRuleTargets 校验候选渠道属于该支付应用且渠道编码一致，不校验渠道状态：路由时跳过关闭的渠道
+func (s *PayChannelRouteService) validateRuleTargets(ctx context.Context, appID int64, channelCode string, reqs []*pay2.PayChannelRouteTargetReq) ([]*pay.PayChannelRouteTarget, error) {
+	channelIDs := make([]int64, 0, len(reqs))
+	for _, req := range reqs {
+		if slices.Contains(channelIDs, req.ChannelID) {
+			return nil, ErrPayChannelRouteTargetDuplicate
+		}
+		channelIDs = append(channelIDs, req.ChannelID)
+	}
+	channels, err := s.q.PayChannel.WithContext(ctx).Where(s.q.PayChannel.ID.In(channelIDs...)).Find()
+	if err != nil {
+		return nil, err
+	}
+	if len(channels) != len(channelIDs) {
+		return nil, ErrPayChannelRouteTargetInvalid
+	}
+	for _, channel := range channels {
+		if channel.AppID != appID || channel.Code != channelCode {
+			return nil, ErrPayChannelRouteTargetInvalid
+		}
+	}
+
+	targets := make([]*pay.PayChannelRouteTarget, 0, len(reqs))
+	for _, req := range reqs {
+		targets = append(targets, &pay.PayChannelRouteTarget{ChannelID: req.ChannelID, Weight: req.Weight})
+	}
+	return targets, nil
+}
+
+// validateRouteCondition 校验金额区间与生效时段
+func validateRouteCondition(minPrice, maxPrice int, startTime, endTime string) error {
+	if maxPrice > 0 && maxPrice < minPrice {
+		return ErrPayChannelRoutePriceInvalid
+	}
+	if startTime == "" && endTime == "" {
+		return nil
+	}
+	if _, err := time.Parse(payChannelRouteTimeLayout, startTime); err != nil {
+		return ErrPayChannelRouteTimeInvalid
+	}
+	if _, err := time.Parse(payChannelRouteTimeLayout, endTime); err != nil {
+		return ErrPayChannelRouteTimeInvalid
+	}
+	return nil
+}
+
+// ========== 渠道路由 ==========
+
+// RouteChannels 获得支付订单可用的渠道，按尝试顺序排列
+// price 为渠道实付金额，terminal 为提交支付的终端，tenantID 为支付订单的租户
+func (s *PayChannelRouteService) RouteChannels(ctx context.Context, appID int64, channelCode string, price, terminal int, tenantID int64) ([]*pay.PayChannel, error) {
+	channels, err := s.q.PayChannel.WithContext(ctx).
+		Where(s.q.PayChannel.AppID.Eq(appID), s.q.PayChannel.Code.Eq(channelCode),
+			s.q.PayChannel.Status.Eq(consts.CommonStatusEnable)).
+		Order(s.q.PayChannel.ID).
+		Find()
+	if err != nil {
+		return nil, err
+	}
+	if len(channels) == 0 {
+		return nil, ErrPayChannelRouteChannelNotFound
+	}
+
+	// 1. 按优先级匹配路由规则，规则的候选渠道均不可用时继续匹配下一条规则
+	q := s.q.PayChannelRouteRule
+	rules, err := q.WithContext(ctx).
+		Where(q.AppID.Eq(appID), q.ChannelCode.Eq(channelCode), q.Status.Eq(consts.CommonStatusEnable)).
+		Order(q.Sort, q.ID).
+		Find()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	routed := channels
+	for _, rule := range rules {
+		if !matchRouteRule(rule, price, terminal, tenantID, now) {
+			continue
+		}
+		if targets := s.routeTargets(ctx, rule, channels); len(targets) > 0 {
+			routed = targets
+			break
+		}
+	}
+
+	// 2. 暂停路由的渠道排到最后，所有渠道都暂停时仍按原顺序尝试
+	return s.sortByHealth(ctx, routed), nil
+}
+
+// routeTargets 按权重轮询出规则的首选渠道，其余候选渠道按权重从高到低作为备选
+func (s *PayChannelRouteService) routeTargets(ctx context.Context, rule *pay.PayChannelRouteRule, channels []*pay.PayChannel) []*pay.PayChannel {
+	channelMap := make(map[int64]*pay.PayChannel, len(channels))
+	for _, channel := range channels {
+		channelMap[channel.ID] = channel
+	}
+	var targets []*pay.PayChannelRouteTarget
+	for _, target := range rule.Targets {
+		if channelMap[target.ChannelID] != nil && target.Weight > 0 {
+			targets = append(targets, target)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	sequence, err := s.routeDAO.NextSequence(ctx, rule.ID)
+	if err != nil {
+		fmt.Printf("[routeTargets][rule(%d) 获取轮询序号失败: %v]\n", rule.ID, err)
+	}
+	first := targets[smoothWeightedIndex(targets, sequence)]
+	slices.SortStableFunc(targets, func(a, b *pay.PayChannelRouteTarget) int {
+		return b.Weight - a.Weight
+	})
+
+	result := []*pay.PayChannel{channelMap[first.ChannelID]}
+	for _, target := range targets {
+		if target != first {
+			result = append(result, channelMap[target.ChannelID])
+		}
+	}
+	return result
+}
+
+// sortByHealth 将暂停路由的渠道排到最后，保持其余顺序
+func (s *PayChannelRouteService) sortByHealth(ctx context.Context, channels []*pay.PayChannel) []*pay.PayChannel {
+	channelIDs := make([]int64, 0, len(channels))
+	for _, channel := range channels {
+		channelIDs = append(channelIDs, channel.ID)
+	}
+	healthMap, err := s.routeDAO.GetHealthMap(ctx, channelIDs)
+	if err != nil {
+		fmt.Printf("[sortByHealth][获取渠道健康计数失败: %v]\n", err)
+		return channels
+	}
+	result := make([]*pay.PayChannel, 0, len(channels))
+	var paused []*pay.PayChannel
+	for _, channel := range channels {
+		if IsPayChannelAvailable(healthMap[channel.ID]) {
+			result = append(result, channel)
+		} else {
+			paused = append(paused, channel)
+		}
+	}
+	return append(result, paused...)
+}
+
+// RecordChannelSuccess 记录渠道下单成功，记录失败时仅打印日志
+func (s *PayChannelRouteService) RecordChannelSuccess(ctx context.Context, channelID int64) {
+	if err := s.routeDAO.IncrSuccess(ctx, channelID); err != nil {
+		fmt.Printf("[RecordChannelSuccess][channel(%d) 记录健康计数失败: %v]\n", channelID, err)
+	}
+}
+
+// RecordChannelFailure 记录渠道下单失败，记录失败时仅打印日志
+func (s *PayChannelRouteService) RecordChannelFailure(ctx context.Context, channelID int64, msg string) {
+	if err := s.routeDAO.IncrFailure(ctx, channelID, msg); err != nil {
+		fmt.Printf("[RecordChannelFailure][channel(%d) 记录健康计数失败: %v]\n", channelID, err)
+	}
+}
+
+// ========== 渠道健康 ==========
+
+// GetChannelHealthMap 获得渠道的健康计数
+func (s *PayChannelRouteService) GetChannelHealthMap(ctx context.Context, channelIDs []int64) (map[int64]*payrepo.PayChannelHealth, error) {
+	return s.routeDAO.GetHealthMap(ctx, channelIDs)
+}
+
+// ResetChannelHealth 清空渠道的健康计数，立即恢复路由
+func (s *PayChannelRouteService) ResetChannelHealth(ctx context.Context, channelID int64) error {
+	if _, err := s.channelSvc.validateChannelExists(ctx, channelID); err != nil {
+		return err
+	}
+	return s.routeDAO.DeleteHealth(ctx, channelID)
+}
+
+// IsPayChannelAvailable 判断渠道是否参与路由：连续失败未达到阈值，或已过冷却时间
+func IsPayChannelAvailable(health *payrepo.PayChannelHealth) bool {
+	if health == nil || health.ContinuousFailures < payChannelFailureThreshold || health.LastFailureTime == nil {
+		return true
+	}
+	return time.Since(*health.LastFailureTime) >= payChannelFailureCooldown
+}
+
+// matchRouteRule 判断支付是否满足路由规则的金额、时段、终端、租户条件
+func matchRouteRule(rule *pay.PayChannelRouteRule, price, terminal int, tenantID int64, now time.Time) bool {
+	if price < rule.MinPrice || (rule.MaxPrice > 0 && price > rule.MaxPrice) {
+		return false
+	}
+	if len(rule.Terminals) > 0 && !slices.Contains(rule.Terminals, terminal) {
+		return false
+	}
+	if len(rule.TenantIDs) > 0 && !slices.Contains(rule.TenantIDs, tenantID) {
+		return false
+	}
+	return matchRouteTime(rule.StartTime, rule.EndTime, now)
+}
+
+// matchRouteTime 判断当前时间是否在生效时段内，结束时间早于开始时间表示跨天
+func matchRouteTime(startTime, endTime string, now time.Time) bool {
+	start, err1 := time.Parse(payChannelRouteTimeLayout, startTime)
+	end, err2 := time.Parse(payChannelRouteTimeLayout, endTime)
+	if err1 != nil || err2 != nil {
+		return true
+	}
+	minutes := now.Hour()*60 + now.Minute()
+	startMinutes, endMinutes := start.Hour()*60+start.Minute(), end.Hour()*60+end.Minute()
+	if startMinutes <= endMinutes {
+		return minutes >= startMinutes && minutes < endMinutes
+	}
+	return minutes >= startMinutes || minutes < endMinutes
+}
+
+// smoothWeightedIndex 平滑加权轮询，返回第 sequence 次选中的候选渠道下标
+// 在一个权重之和的周期内模拟轮询，各渠道选中次数与权重成正比且交错分布
+func smoothWeightedIndex(targets []*pay.PayChannelRouteTarget, sequence int64) int {
+	total := 0
+	for _, target := range targets {
+		total += target.Weight
+	}
+	round := int((sequence - 1) % int64(total))
+	if round < 0 {
+		round += total
+	}
+	current := make([]int, len(targets))
+	selected := 0
+	for i := 0; i <= round; i++ {
+		selected = 0
+		for j, target := range targets {
+			current[j] += target.Weight
+			if current[j] > current[selected] {
+				selected = j
+			}
+		}
+		current[selected] -= total
+	}
+	return selected
+}
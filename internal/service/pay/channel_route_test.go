@@ -0,0 +1,65 @@
+package pay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model/pay"
+)
+
+// TestSmoothWeightedIndex 验证平滑加权轮询：一个周期内选中次数与权重成正比，且选中顺序交错
+func TestSmoothWeightedIndex(t *testing.T) {
+	testCases := []struct {
+		name     string
+		weights  []int
+		expected []int
+	}{
+		{name: "单个渠道", weights: []int{3}, expected: []int{0, 0, 0}},
+		{name: "等权重", weights: []int{1, 1}, expected: []int{0, 1, 0, 1}},
+		{name: "权重 5:1:1", weights: []int{5, 1, 1}, expected: []int{0, 0, 1, 0, 2, 0, 0}},
+		{name: "权重 2:1", weights: []int{2, 1}, expected: []int{0, 1, 0, 0, 1, 0}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			targets := make([]*pay.PayChannelRouteTarget, 0, len(tc.weights))
+			for _, weight := range tc.weights {
+				targets = append(targets, &pay.PayChannelRouteTarget{Weight: weight})
+			}
+			for i, expected := range tc.expected {
+				assert.Equal(t, expected, smoothWeightedIndex(targets, int64(i+1)), "sequence %d", i+1)
+			}
+		})
+	}
+}
+
+// TestMatchRouteTime 验证生效时段匹配，包括跨天时段与未配置时段
+func TestMatchRouteTime(t *testing.T) {
+	at := func(clock string) time.Time {
+		parsed, _ := time.Parse("15:04", clock)
+		return time.Date(2024, 1, 1, parsed.Hour(), parsed.Minute(), 0, 0, time.Local)
+	}
+	testCases := []struct {
+		name      string
+		startTime string
+		endTime   string
+		now       string
+		expected  bool
+	}{
+		{name: "未配置时段", now: "03:00", expected: true},
+		{name: "当天时段内", startTime: "09:00", endTime: "18:00", now: "12:30", expected: true},
+		{name: "当天时段开始时刻", startTime: "09:00", endTime: "18:00", now: "09:00", expected: true},
+		{name: "当天时段结束时刻", startTime: "09:00", endTime: "18:00", now: "18:00", expected: false},
+		{name: "当天时段外", startTime: "09:00", endTime: "18:00", now: "20:00", expected: false},
+		{name: "跨天时段午夜前", startTime: "22:00", endTime: "06:00", now: "23:30", expected: true},
+		{name: "跨天时段午夜", startTime: "22:00", endTime: "06:00", now: "00:00", expected: true},
+		{name: "跨天时段午夜后", startTime: "22:00", endTime: "06:00", now: "05:59", expected: true},
+		{name: "跨天时段结束时刻", startTime: "22:00", endTime: "06:00", now: "06:00", expected: false},
+		{name: "跨天时段外", startTime: "22:00", endTime: "06:00", now: "12:00", expected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, matchRouteTime(tc.startTime, tc.endTime, at(tc.now)))
+		})
+	}
+}
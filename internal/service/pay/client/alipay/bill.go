@@ -0,0 +1,156 @@
+package alipay
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client"
+
+	"github.com/smartwalle/alipay/v3"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+func init() {
+	client.RegisterBillParser(consts.PayReconcileChannelAlipay, ParseBill)
+}
+
+// DownloadBill 下载交易对账单
+// 支付宝返回的是 zip 压缩包，内含业务明细与业务明细(汇总)两个 GBK 编码的 CSV 文件，由 ParseBill 解压解析
+func (c *AlipayPayClient) DownloadBill(ctx context.Context, billDate time.Time) (string, []byte, error) {
+	resp, err := c.client.BillDownloadURLQuery(ctx, alipay.BillDownloadURLQuery{
+		BillType: "trade",
+		BillDate: billDate.Format(time.DateOnly),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if resp.Code != alipay.CodeSuccess {
+		return "", nil, fmt.Errorf("查询对账单下载地址失败: %s - %s", resp.Code, resp.SubMsg)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, resp.BillDownloadURL, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	httpResp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", nil, fmt.Errorf("下载对账单失败: %w", err)
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("下载对账单失败: HTTP %d", httpResp.StatusCode)
+	}
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return "", nil, fmt.Errorf("下载对账单失败: %w", err)
+	}
+	return fmt.Sprintf("alipay_%s.zip", billDate.Format("20060102")), data, nil
+}
+
+// ParseBill 解析支付宝业务明细对账单
+// 支持下载得到的 zip 压缩包，也支持解压后的业务明细 CSV 文件；以 # 开头的行为说明行，按表头列名取值
+func ParseBill(data []byte) ([]*client.BillRecord, error) {
+	if bytes.HasPrefix(data, []byte("PK")) {
+		detail, err := unzipBillDetail(data)
+		if err != nil {
+			return nil, err
+		}
+		data = detail
+	}
+	if !utf8.Valid(data) {
+		decoded, err := simplifiedchinese.GBK.NewDecoder().Bytes(data)
+		if err != nil {
+			return nil, fmt.Errorf("对账单编码不正确: %w", err)
+		}
+		data = decoded
+	}
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("解析对账单失败: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("对账单内容为空")
+	}
+
+	header := make(map[string]int, len(rows[0]))
+	for i, name := range rows[0] {
+		header[strings.TrimSpace(name)] = i
+	}
+	columns := []string{"支付宝交易号", "商户订单号", "业务类型", "完成时间", "订单金额（元）", "退款批次号/请求号"}
+	for _, name := range columns {
+		if _, ok := header[name]; !ok {
+			return nil, fmt.Errorf("对账单缺少列: %s", name)
+		}
+	}
+	get := func(row []string, name string) string {
+		if i := header[name]; i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	records := make([]*client.BillRecord, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		record := &client.BillRecord{
+			OutTradeNo:     get(row, "商户订单号"),
+			ChannelOrderNo: get(row, "支付宝交易号"),
+			TradeTime:      client.ParseBillTime(get(row, "完成时间")),
+		}
+		switch get(row, "业务类型") {
+		case "交易":
+			record.BizType = consts.PayReconcileBizTypeOrder
+		case "退款":
+			// 支付宝没有独立的退款单号，以交易号作为渠道退款单号，与退款查询接口保持一致
+			record.BizType = consts.PayReconcileBizTypeRefund
+			record.OutRefundNo = get(row, "退款批次号/请求号")
+			record.ChannelRefundNo = record.ChannelOrderNo
+		default:
+			continue
+		}
+		if record.Amount, err = client.ParseBillAmount(get(row, "订单金额（元）")); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// unzipBillDetail 从对账单压缩包中取出业务明细文件，跳过汇总文件
+func unzipBillDetail(data []byte) ([]byte, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("解压对账单失败: %w", err)
+	}
+	for _, f := range reader.File {
+		name := f.Name
+		if f.NonUTF8 {
+			if decoded, err := simplifiedchinese.GBK.NewDecoder().String(name); err == nil {
+				name = decoded
+			}
+		}
+		if !strings.HasSuffix(name, ".csv") || strings.Contains(name, "汇总") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("解压对账单失败: %w", err)
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+	return nil, fmt.Errorf("对账单压缩包中没有业务明细文件")
+}
@@ -0,0 +1,87 @@
+package alipay
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// 支付宝业务明细对账单：# 开头为说明行，包含交易、退款与需跳过的其它业务类型
+const testAlipayBill = `#支付宝业务明细查询
+#账号：[20880000000000000156]
+#起始日期：[2024年01月01日 00:00:00]   终止日期：[2024年01月02日 00:00:00]
+#-----------------------------------------业务明细列表----------------------------------------
+支付宝交易号,商户订单号,业务类型,商品名称,创建时间,完成时间,门店编号,门店名称,操作员,终端号,对方账户,订单金额（元）,商家实收（元）,退款批次号/请求号
+2024010122001400001,P202401010001,交易,测试商品,2024-01-01 10:00:00,2024-01-01 10:00:05,,,,,buyer@example.com,12.34,12.34,
+2024010122001400001,P202401010001,退款,测试商品,2024-01-01 11:00:00,2024-01-01 11:00:03,,,,,buyer@example.com,-2.30,-2.30,R202401010001
+2024010122001400002,P202401010002,其它,测试商品,2024-01-01 12:00:00,2024-01-01 12:00:00,,,,,buyer@example.com,1.00,1.00,
+#-----------------------------------------业务明细列表结束------------------------------------
+#交易合计：1笔，商家实收共12.34元
+`
+
+// TestParseBill 验证支付宝对账单解析：支持 UTF-8、GBK 编码的 CSV 与 zip 压缩包
+func TestParseBill(t *testing.T) {
+	gbk, err := simplifiedchinese.GBK.NewEncoder().Bytes([]byte(testAlipayBill))
+	assert.NoError(t, err)
+
+	testCases := []struct {
+		name string
+		data []byte
+	}{
+		{name: "UTF-8 明细", data: []byte(testAlipayBill)},
+		{name: "GBK 明细", data: gbk},
+		{name: "zip 压缩包", data: zipBill(t, map[string][]byte{
+			"20880000000000000156_20240101_业务明细(汇总).csv": []byte("汇总"),
+			"20880000000000000156_20240101_业务明细.csv":     gbk,
+		})},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			records, err := client.ParseBill(consts.PayReconcileChannelAlipay, tc.data)
+			assert.NoError(t, err)
+			if !assert.Len(t, records, 2) {
+				return
+			}
+
+			order := records[0]
+			assert.Equal(t, consts.PayReconcileBizTypeOrder, order.BizType)
+			assert.Equal(t, "P202401010001", order.OutTradeNo)
+			assert.Equal(t, "2024010122001400001", order.ChannelOrderNo)
+			assert.Equal(t, 1234, order.Amount)
+			if assert.NotNil(t, order.TradeTime) {
+				assert.Equal(t, "2024-01-01 10:00:05", order.TradeTime.Format("2006-01-02 15:04:05"))
+			}
+
+			refund := records[1]
+			assert.Equal(t, consts.PayReconcileBizTypeRefund, refund.BizType)
+			assert.Equal(t, "R202401010001", refund.OutRefundNo)
+			assert.Equal(t, "2024010122001400001", refund.ChannelRefundNo)
+			assert.Equal(t, 230, refund.Amount)
+		})
+	}
+}
+
+// TestParseBillMissingColumn 验证缺少必需列时解析失败
+func TestParseBillMissingColumn(t *testing.T) {
+	_, err := client.ParseBill(consts.PayReconcileChannelAlipay, []byte("支付宝交易号,商户订单号\n1,2\n"))
+	assert.ErrorContains(t, err, "对账单缺少列")
+}
+
+// zipBill 将对账单文件打包为 zip，模拟支付宝下载的压缩包
+func zipBill(t *testing.T, files map[string][]byte) []byte {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := writer.Create(name)
+		assert.NoError(t, err)
+		_, err = f.Write(content)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+	return buf.Bytes()
+}
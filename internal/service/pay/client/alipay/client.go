@@ -318,6 +318,22 @@ func (c *AlipayPayClient) GetOrder(ctx context.Context, outTradeNo string) (*cli
 	}, nil
 }
 
+// ClosedOrder 关闭订单；用户未扫码时交易不存在，视为关闭成功
+func (c *AlipayPayClient) ClosedOrder(ctx context.Context, outTradeNo string) error {
+	p := alipay.TradeClose{}
+	p.OutTradeNo = outTradeNo
+
+	resp, err := c.client.TradeClose(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	if resp.Code != alipay.CodeSuccess && resp.SubCode != "ACQ.TRADE_NOT_EXIST" {
+		return fmt.Errorf("关闭订单失败: %s - %s", resp.Code, resp.SubMsg)
+	}
+	return nil
+}
+
 func (c *AlipayPayClient) GetRefund(ctx context.Context, outTradeNo, outRefundNo string) (*client.RefundResp, error) {
 	p := alipay.TradeFastPayRefundQuery{}
 	p.OutTradeNo = outTradeNo
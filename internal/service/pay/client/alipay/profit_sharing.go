@@ -0,0 +1,206 @@
+package alipay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client"
+
+	"github.com/smartwalle/alipay/v3"
+)
+
+// royaltyRelationBindRsp 分账关系绑定接口的响应
+type royaltyRelationBindRsp struct {
+	alipay.Error
+	ResultCode string `json:"result_code"`
+}
+
+// orderSettleQueryRsp 交易分账查询接口的响应
+type orderSettleQueryRsp struct {
+	alipay.Error
+	OutRequestNo      string `json:"out_request_no"`
+	SettleNo          string `json:"settle_no"`
+	RoyaltyDetailList []struct {
+		TransIn   string `json:"trans_in"`
+		Amount    string `json:"amount"`
+		State     string `json:"state"`
+		DetailID  string `json:"detail_id"`
+		ExecuteDt string `json:"execute_dt"`
+		ErrorCode string `json:"error_code"`
+		ErrorDesc string `json:"error_desc"`
+	} `json:"royalty_detail_list"`
+}
+
+// AddProfitSharingReceiver 绑定分账关系，接收方为支付宝 userId
+func (c *AlipayPayClient) AddProfitSharingReceiver(ctx context.Context, req *client.ProfitSharingReceiverReq) error {
+	if req.Type != consts.PayProfitSharingReceiverTypeAlipayUser {
+		return fmt.Errorf("支付宝分账不支持的接收方类型: %d", req.Type)
+	}
+	receiver := map[string]string{
+		"type":    "userId",
+		"account": req.Account,
+	}
+	if req.RealName != "" {
+		receiver["name"] = req.RealName
+	}
+	payload := alipay.NewPayload("alipay.trade.royalty.relation.bind")
+	payload.AddBizField("receiver_list", []map[string]string{receiver})
+	payload.AddBizField("out_request_no", fmt.Sprintf("B%d", time.Now().UnixNano()))
+
+	var rsp royaltyRelationBindRsp
+	if err := c.client.Request(ctx, payload, &rsp); err != nil {
+		return fmt.Errorf("绑定支付宝分账关系失败: %w", err)
+	}
+	if rsp.Code != alipay.CodeSuccess {
+		return fmt.Errorf("绑定支付宝分账关系失败: %s - %s", rsp.Code, rsp.SubMsg)
+	}
+	return nil
+}
+
+// UnifiedProfitSharing 交易分账，同步执行，受理成功即分账成功
+// 支付宝下单时未冻结资金，没有分账明细时无需完结
+func (c *AlipayPayClient) UnifiedProfitSharing(ctx context.Context, req *client.UnifiedProfitSharingReq) (*client.ProfitSharingResp, error) {
+	if len(req.Items) == 0 {
+		return &client.ProfitSharingResp{
+			Status:       consts.PayProfitSharingStatusSuccess,
+			OutSharingNo: req.OutSharingNo,
+		}, nil
+	}
+
+	params := make([]*alipay.RoyaltyParameter, 0, len(req.Items))
+	for _, item := range req.Items {
+		if item.Type != consts.PayProfitSharingReceiverTypeAlipayUser {
+			return nil, fmt.Errorf("支付宝分账不支持的接收方类型: %d", item.Type)
+		}
+		params = append(params, &alipay.RoyaltyParameter{
+			TransIn: item.Account,
+			Amount:  float64(item.Price) / 100.0,
+			Desc:    item.Description,
+		})
+	}
+	rsp, err := c.client.TradeOrderSettle(ctx, alipay.TradeOrderSettle{
+		OutRequestNo:      req.OutSharingNo,
+		TradeNo:           req.ChannelOrderNo,
+		RoyaltyParameters: params,
+	})
+	if err != nil {
+		var aliErr *alipay.Error
+		if !errors.As(err, &aliErr) {
+			return nil, err
+		}
+		return toProfitSharingFailureResp(req.OutSharingNo, aliErr), nil
+	}
+	if rsp.Code != alipay.CodeSuccess {
+		return toProfitSharingFailureResp(req.OutSharingNo, &rsp.Error), nil
+	}
+
+	now := time.Now()
+	result := &client.ProfitSharingResp{
+		Status:           consts.PayProfitSharingStatusSuccess,
+		OutSharingNo:     req.OutSharingNo,
+		ChannelSharingNo: rsp.TradeNo,
+		RawData:          rsp,
+	}
+	for _, item := range req.Items {
+		result.Items = append(result.Items, &client.ProfitSharingItemResp{
+			Type:        item.Type,
+			Account:     item.Account,
+			Price:       item.Price,
+			Status:      consts.PayProfitSharingStatusSuccess,
+			SuccessTime: now,
+		})
+	}
+	return result, nil
+}
+
+// GetProfitSharing 查询交易分账结果，没有分账明细时未请求过支付宝，直接返回成功
+func (c *AlipayPayClient) GetProfitSharing(ctx context.Context, req *client.UnifiedProfitSharingReq) (*client.ProfitSharingResp, error) {
+	if len(req.Items) == 0 {
+		return &client.ProfitSharingResp{
+			Status:       consts.PayProfitSharingStatusSuccess,
+			OutSharingNo: req.OutSharingNo,
+		}, nil
+	}
+	payload := alipay.NewPayload("alipay.trade.order.settle.query")
+	payload.AddBizField("out_request_no", req.OutSharingNo)
+	payload.AddBizField("trade_no", req.ChannelOrderNo)
+
+	var rsp orderSettleQueryRsp
+	if err := c.client.Request(ctx, payload, &rsp); err != nil {
+		return nil, err
+	}
+	if rsp.Code != alipay.CodeSuccess {
+		return toProfitSharingFailureResp(req.OutSharingNo, &rsp.Error), nil
+	}
+
+	result := &client.ProfitSharingResp{
+		Status:           consts.PayProfitSharingStatusSuccess,
+		OutSharingNo:     rsp.OutRequestNo,
+		ChannelSharingNo: rsp.SettleNo,
+		RawData:          rsp,
+	}
+	for _, detail := range rsp.RoyaltyDetailList {
+		item := &client.ProfitSharingItemResp{
+			Type:            consts.PayProfitSharingReceiverTypeAlipayUser,
+			Account:         detail.TransIn,
+			ChannelDetailNo: detail.DetailID,
+			Status:          consts.PayProfitSharingStatusProcessing,
+		}
+		if amount, err := client.ParseBillAmount(detail.Amount); err == nil {
+			item.Price = amount
+		}
+		switch detail.State {
+		case "SUCCESS":
+			item.Status = consts.PayProfitSharingStatusSuccess
+			if t := client.ParseBillTime(detail.ExecuteDt); t != nil {
+				item.SuccessTime = *t
+			}
+		case "FAIL":
+			item.Status = consts.PayProfitSharingStatusFailure
+			item.FailReason = detail.ErrorDesc
+		default:
+			result.Status = consts.PayProfitSharingStatusProcessing
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result, nil
+}
+
+// UnifiedProfitSharingReturn 支付宝没有独立的分账回退接口，分账金额需在退款时通过退分账参数退回
+func (c *AlipayPayClient) UnifiedProfitSharingReturn(ctx context.Context, req *client.UnifiedProfitSharingReturnReq) (*client.ProfitSharingReturnResp, error) {
+	return toProfitSharingReturnNotSupportResp(req.OutReturnNo), nil
+}
+
+// GetProfitSharingReturn 支付宝没有独立的分账回退接口
+func (c *AlipayPayClient) GetProfitSharingReturn(ctx context.Context, req *client.UnifiedProfitSharingReturnReq) (*client.ProfitSharingReturnResp, error) {
+	return toProfitSharingReturnNotSupportResp(req.OutReturnNo), nil
+}
+
+func toProfitSharingFailureResp(outSharingNo string, aliErr *alipay.Error) *client.ProfitSharingResp {
+	errorCode := aliErr.SubCode
+	if errorCode == "" {
+		errorCode = string(aliErr.Code)
+	}
+	errorMsg := aliErr.SubMsg
+	if errorMsg == "" {
+		errorMsg = aliErr.Msg
+	}
+	return &client.ProfitSharingResp{
+		Status:           consts.PayProfitSharingStatusFailure,
+		OutSharingNo:     outSharingNo,
+		ChannelErrorCode: errorCode,
+		ChannelErrorMsg:  errorMsg,
+	}
+}
+
+func toProfitSharingReturnNotSupportResp(outReturnNo string) *client.ProfitSharingReturnResp {
+	return &client.ProfitSharingReturnResp{
+		Status:           consts.PayProfitSharingStatusFailure,
+		OutReturnNo:      outReturnNo,
+		ChannelErrorCode: "RETURN_NOT_SUPPORT",
+		ChannelErrorMsg:  "支付宝不支持单独回退分账，请线下与接收方结算",
+	}
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// BillRecord 渠道对账单中的一笔成功交易
+type BillRecord struct {
+	BizType         int        // 业务类型，枚举 consts.PayReconcileBizType
+	OutTradeNo      string     // 外部订单号，即支付订单拓展的编号
+	ChannelOrderNo  string     // 渠道订单号
+	OutRefundNo     string     // 外部退款号，仅退款
+	ChannelRefundNo string     // 渠道退款单号，仅退款
+	Amount          int        // 交易金额，单位：分，退款也为正数
+	TradeTime       *time.Time // 交易完成时间
+}
+
+// BillClient 支持下载交易对账单的支付客户端
+type BillClient interface {
+	// DownloadBill 下载指定日期的交易对账单原始文件，文件格式由 BillParser 解析
+	DownloadBill(ctx context.Context, billDate time.Time) (fileName string, data []byte, err error)
+}
+
+// BillParser 对账单文件的解析函数，各渠道实现通过 RegisterBillParser 注册
+type BillParser func(data []byte) ([]*BillRecord, error)
+
+var billParsers = make(map[string]BillParser)
+
+// RegisterBillParser 注册对账渠道类型对应的对账单解析函数
+func RegisterBillParser(channelType string, parser BillParser) {
+	billParsers[channelType] = parser
+}
+
+// ParseBill 解析对账单文件
+func ParseBill(channelType string, data []byte) ([]*BillRecord, error) {
+	parser, ok := billParsers[channelType]
+	if !ok {
+		return nil, fmt.Errorf("channel type %s not supported", channelType)
+	}
+	return parser(data)
+}
+
+// ParseBillAmount 将对账单中以元为单位的金额转换为分，负数取绝对值
+func ParseBillAmount(s string) (int, error) {
+	amount, err := decimal.NewFromString(strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("金额格式不正确: %s", s)
+	}
+	return int(amount.Abs().Shift(2).Round(0).IntPart()), nil
+}
+
+// ParseBillTime 解析对账单中的时间，格式为 yyyy-MM-dd HH:mm:ss，空值返回 nil
+func ParseBillTime(s string) *time.Time {
+	t, err := time.ParseInLocation(time.DateTime, strings.TrimSpace(s), time.Local)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
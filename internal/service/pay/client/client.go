@@ -19,6 +19,9 @@ type PayClient interface {
 	// GetOrder 获得支付订单信息
 	GetOrder(ctx context.Context, outTradeNo string) (*OrderResp, error)
 
+	// ClosedOrder 关闭支付订单，关闭后用户无法再支付；已支付时返回错误
+	ClosedOrder(ctx context.Context, outTradeNo string) error
+
 	// GetRefund 获得退款订单信息
 	GetRefund(ctx context.Context, outTradeNo, outRefundNo string) (*RefundResp, error)
 
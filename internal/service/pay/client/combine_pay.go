@@ -0,0 +1,21 @@
+package client
+
+import "context"
+
+// CombinePayClient 支持组合支付的钱包客户端
+// 组合支付时订单的一部分金额由钱包支付，其余金额由外部渠道支付：
+// 提交时先冻结钱包金额，渠道支付成功后扣款，渠道支付关闭或过期后解冻
+// 钱包金额、钱包编号以支付订单拓展为准，状态迁移与钱包变动在同一事务内完成，重复调用不会重复变动
+type CombinePayClient interface {
+	// FreezeCombinePay 冻结支付订单拓展的钱包金额
+	FreezeCombinePay(ctx context.Context, outTradeNo string) error
+
+	// ConfirmCombinePay 渠道支付成功后，将冻结的钱包金额转为支出
+	ConfirmCombinePay(ctx context.Context, outTradeNo string) error
+
+	// UnfreezeCombinePay 渠道支付关闭后，将冻结的钱包金额退回余额
+	UnfreezeCombinePay(ctx context.Context, outTradeNo string) error
+
+	// RefundCombinePay 将退款单中钱包部分的金额退回支付时扣款的钱包
+	RefundCombinePay(ctx context.Context, outRefundNo string) error
+}
@@ -26,6 +26,7 @@ type UnifiedOrderReq struct {
 	ExpireTime    time.Time         `json:"expireTime"`    // 支付过期时间
 	ChannelExtras map[string]string `json:"channelExtras"` // 支付渠道的额外参数
 	DisplayMode   string            `json:"displayMode"`   // 展示模式
+	ProfitSharing bool              `json:"profitSharing"` // 是否分账，需要分账的订单在下单时告知渠道冻结资金
 }
 
 // OrderResp 渠道支付订单 Response DTO
@@ -78,6 +79,7 @@ type UnifiedTransferReq struct {
 	ChannelUserID string            `json:"channelUserId"` // 渠道用户编号
 	UserName      string            `json:"userName"`      // 收款人姓名
 	UserAccount   string            `json:"userAccount"`   // 收款人账号 (Alipay need)
+	NotifyURL     string            `json:"notifyUrl"`     // 转账结果的 notify 回调地址
 }
 
 // TransferResp 渠道转账 Response DTO
@@ -1,7 +1,7 @@
 package client
 
 import (
-	"errors"
+	"fmt"
 	"sync"
 )
 
@@ -24,15 +24,12 @@ func (f *PayClientFactory) GetPayClient(channelID int64) PayClient {
 	return f.clients[channelID]
 }
 
-// RegisterClient 注册客户端 (用于扩展，避免硬编码 switch)
-// Currently simplifying: we will hardcode the switch in CreateOrUpdate for simplicity unless we want a registry pattern.
-// Given Go's static nature, a registry or simple switch in a "provider" package implies circular deps if not careful.
-// Best approach: Define 'Creator' function type.
-
+// ClientCreator 支付客户端的创建函数，各渠道实现通过 RegisterCreator 注册
 type ClientCreator func(channelID int64, config string) (PayClient, error)
 
 var creators = make(map[string]ClientCreator)
 
+// RegisterCreator 注册渠道编码对应的支付客户端创建函数
 func RegisterCreator(channelCode string, creator ClientCreator) {
 	creators[channelCode] = creator
 }
@@ -42,25 +39,9 @@ func (f *PayClientFactory) CreateOrUpdatePayClient(channelID int64, channelCode
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
 
-	client := f.clients[channelID]
-	if client != nil {
-		// Verify if change is needed, implementation dependent. For now, we recreate.
-		// Real logic: client.Refresh(config)
-	}
-
 	creator, ok := creators[channelCode]
 	if !ok {
-		// Use "mock" as fallback or specific codes
-		if channelCode == "mock" {
-			// return new MockClient
-		}
-		// Try generic creators if needed, or specific prefixes?
-		// For now if not found, error
-		// return nil, fmt.Errorf("channel code %s not supported", channelCode)
-
-		// Temporary Logic: If no creator found, return logic for Mock?
-		// We will implement Alipay/WxPay creators and register them at init.
-		return nil, errors.New("channel not supported")
+		return nil, fmt.Errorf("channel code %s not supported", channelCode)
 	}
 
 	newClient, err := creator(channelID, config)
@@ -73,3 +54,10 @@ func (f *PayClientFactory) CreateOrUpdatePayClient(channelID int64, channelCode
 	f.clients[channelID] = newClient
 	return newClient, nil
 }
+
+// RemovePayClient 移除支付客户端，渠道配置变更后调用，下次使用时按最新配置重新创建
+func (f *PayClientFactory) RemovePayClient(channelID int64) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	delete(f.clients, channelID)
+}
@@ -0,0 +1,410 @@
+package mock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client"
+)
+
+func init() {
+	client.RegisterCreator(consts.PayChannelMock, NewMockPayClientAsClient)
+}
+
+// 模拟结果
+const (
+	ResultSuccess = "success" // 成功
+	ResultFailure = "failure" // 失败
+	ResultWaiting = "waiting" // 一直处理中，不发送异步通知
+)
+
+// ChannelExtrasResult 渠道额外参数中的模拟结果，优先于渠道配置，便于单笔指定结果
+const ChannelExtrasResult = "mockResult"
+
+// notifyTimeout 发送异步通知的超时时间
+const notifyTimeout = 10 * time.Second
+
+// MockPayClientConfig 模拟支付客户端配置
+type MockPayClientConfig struct {
+	OrderResult    string `json:"mockOrderResult"`    // 模拟支付结果，默认 success
+	RefundResult   string `json:"mockRefundResult"`   // 模拟退款结果，默认 success
+	TransferResult string `json:"mockTransferResult"` // 模拟转账结果，默认 success
+	NotifyDelay    int    `json:"mockNotifyDelay"`    // 异步通知的延迟，单位：毫秒
+}
+
+// MockPayClient 模拟支付客户端
+// 不对接真实渠道：下单、退款、转账均先返回处理中，延迟后按配置的结果完成，并向回调地址发送异步通知。
+// 交易记录保存在内存中，供查询与回调解析使用；回调解析只认可本客户端产生的结果，无法伪造
+type MockPayClient struct {
+	*client.BaseClient
+	config *MockPayClientConfig
+}
+
+func NewMockPayClientAsClient(channelID int64, config string) (client.PayClient, error) {
+	return &MockPayClient{
+		BaseClient: client.NewBaseClient(channelID, consts.PayChannelMock, config),
+	}, nil
+}
+
+func (c *MockPayClient) Init() error {
+	cfg := &MockPayClientConfig{}
+	if c.Config != "" {
+		if err := json.Unmarshal([]byte(c.Config), cfg); err != nil {
+			return fmt.Errorf("解析模拟支付配置失败: %w", err)
+		}
+	}
+	for _, result := range []*string{&cfg.OrderResult, &cfg.RefundResult, &cfg.TransferResult} {
+		switch *result {
+		case "":
+			*result = ResultSuccess
+		case ResultSuccess, ResultFailure, ResultWaiting:
+		default:
+			return fmt.Errorf("不支持的模拟结果: %s", *result)
+		}
+	}
+	if cfg.NotifyDelay < 0 {
+		cfg.NotifyDelay = 0
+	}
+	c.config = cfg
+	return nil
+}
+
+func (c *MockPayClient) UnifiedOrder(ctx context.Context, req *client.UnifiedOrderReq) (*client.OrderResp, error) {
+	resp := &client.OrderResp{
+		Status:     consts.PayOrderStatusWaiting,
+		OutTradeNo: req.OutTradeNo,
+	}
+	store.saveOrder(resp)
+
+	result := c.resolveResult(c.config.OrderResult, req.ChannelExtras)
+	if result != ResultWaiting {
+		c.settleLater(req.NotifyURL, func() any {
+			settled := &client.OrderResp{OutTradeNo: req.OutTradeNo}
+			if result == ResultSuccess {
+				settled.Status = consts.PayOrderStatusSuccess
+				settled.ChannelOrderNo = "MOCK-P-" + req.OutTradeNo
+				settled.ChannelUserID = "mock"
+				settled.SuccessTime = time.Now()
+			} else {
+				settled.Status = consts.PayOrderStatusClosed
+				settled.ChannelErrorCode = "MOCK_FAILURE"
+				settled.ChannelErrorMsg = "模拟支付失败"
+			}
+			return store.settleOrder(settled)
+		})
+	}
+	return copyOrder(resp), nil
+}
+
+func (c *MockPayClient) UnifiedRefund(ctx context.Context, req *client.UnifiedRefundReq) (*client.RefundResp, error) {
+	if _, ok := store.getOrder(req.OutTradeNo); !ok {
+		return nil, fmt.Errorf("模拟支付订单不存在: %s", req.OutTradeNo)
+	}
+	resp := &client.RefundResp{
+		Status:      consts.PayRefundStatusWaiting,
+		OutTradeNo:  req.OutTradeNo,
+		OutRefundNo: req.OutRefundNo,
+	}
+	store.saveRefund(resp)
+
+	result := c.config.RefundResult
+	if result != ResultWaiting {
+		c.settleLater(req.NotifyURL, func() any {
+			settled := &client.RefundResp{OutTradeNo: req.OutTradeNo, OutRefundNo: req.OutRefundNo}
+			if result == ResultSuccess {
+				settled.Status = consts.PayRefundStatusSuccess
+				settled.ChannelRefundNo = "MOCK-R-" + req.OutRefundNo
+				settled.SuccessTime = time.Now()
+			} else {
+				settled.Status = consts.PayRefundStatusFailure
+				settled.ChannelErrorCode = "MOCK_FAILURE"
+				settled.ChannelErrorMsg = "模拟退款失败"
+			}
+			store.saveRefund(settled)
+			return settled
+		})
+	}
+	return copyRefund(resp), nil
+}
+
+func (c *MockPayClient) UnifiedTransfer(ctx context.Context, req *client.UnifiedTransferReq) (*client.TransferResp, error) {
+	resp := &client.TransferResp{
+		Status:     consts.PayTransferStatusProcessing,
+		OutTradeNo: req.OutTradeNo,
+	}
+	store.saveTransfer(resp)
+
+	result := c.resolveResult(c.config.TransferResult, req.ChannelExtras)
+	if result != ResultWaiting {
+		c.settleLater(req.NotifyURL, func() any {
+			settled := &client.TransferResp{OutTradeNo: req.OutTradeNo}
+			if result == ResultSuccess {
+				settled.Status = consts.PayTransferStatusSuccess
+				settled.ChannelTransferNo = "MOCK-T-" + req.OutTradeNo
+				settled.SuccessTime = time.Now()
+			} else {
+				settled.Status = consts.PayTransferStatusClosed
+				settled.ChannelErrorCode = "MOCK_FAILURE"
+				settled.ChannelErrorMsg = "模拟转账失败"
+			}
+			store.saveTransfer(settled)
+			return settled
+		})
+	}
+	return copyTransfer(resp), nil
+}
+
+// GetOrder 查询模拟支付订单；不存在时（例如服务重启后）返回关闭状态，订单同步任务会忽略该状态
+func (c *MockPayClient) GetOrder(ctx context.Context, outTradeNo string) (*client.OrderResp, error) {
+	if resp, ok := store.getOrder(outTradeNo); ok {
+		return resp, nil
+	}
+	return &client.OrderResp{
+		Status:           consts.PayOrderStatusClosed,
+		OutTradeNo:       outTradeNo,
+		ChannelErrorCode: "ORDER_NOT_EXIST",
+		ChannelErrorMsg:  "模拟支付订单不存在",
+	}, nil
+}
+
+// ClosedOrder 关闭模拟支付订单，之后不再完成支付；已支付时关闭失败，不存在时视为已关闭
+func (c *MockPayClient) ClosedOrder(ctx context.Context, outTradeNo string) error {
+	if resp, ok := store.closeOrder(outTradeNo); ok && resp.Status == consts.PayOrderStatusSuccess {
+		return errors.New("模拟支付订单已支付，无法关闭")
+	}
+	return nil
+}
+
+// GetRefund 查询模拟退款；不存在时返回退款失败
+func (c *MockPayClient) GetRefund(ctx context.Context, outTradeNo, outRefundNo string) (*client.RefundResp, error) {
+	if resp, ok := store.getRefund(outRefundNo); ok {
+		return resp, nil
+	}
+	return &client.RefundResp{
+		Status:           consts.PayRefundStatusFailure,
+		OutTradeNo:       outTradeNo,
+		OutRefundNo:      outRefundNo,
+		ChannelErrorCode: "REFUND_NOT_EXIST",
+		ChannelErrorMsg:  "模拟退款不存在",
+	}, nil
+}
+
+// GetTransfer 查询模拟转账；不存在时返回转账关闭
+func (c *MockPayClient) GetTransfer(ctx context.Context, outTradeNo string) (*client.TransferResp, error) {
+	if resp, ok := store.getTransfer(outTradeNo); ok {
+		return resp, nil
+	}
+	return &client.TransferResp{
+		Status:           consts.PayTransferStatusClosed,
+		OutTradeNo:       outTradeNo,
+		ChannelErrorCode: "TRANSFER_NOT_EXIST",
+		ChannelErrorMsg:  "模拟转账不存在",
+	}, nil
+}
+
+func (c *MockPayClient) ParseOrderNotify(req *client.NotifyData) (*client.OrderResp, error) {
+	var notify client.OrderResp
+	if err := json.Unmarshal([]byte(req.Body), &notify); err != nil {
+		return nil, fmt.Errorf("解析 Body 失败: %w", err)
+	}
+	resp, ok := store.getOrder(notify.OutTradeNo)
+	if !ok || resp.Status == consts.PayOrderStatusWaiting {
+		return nil, errors.New("模拟支付订单不存在或未完成")
+	}
+	resp.RawData = req.Body
+	return resp, nil
+}
+
+func (c *MockPayClient) ParseRefundNotify(req *client.NotifyData) (*client.RefundResp, error) {
+	var notify client.RefundResp
+	if err := json.Unmarshal([]byte(req.Body), &notify); err != nil {
+		return nil, fmt.Errorf("解析 Body 失败: %w", err)
+	}
+	resp, ok := store.getRefund(notify.OutRefundNo)
+	if !ok || resp.Status == consts.PayRefundStatusWaiting {
+		return nil, errors.New("模拟退款不存在或未完成")
+	}
+	resp.RawData = req.Body
+	return resp, nil
+}
+
+func (c *MockPayClient) ParseTransferNotify(req *client.NotifyData) (*client.TransferResp, error) {
+	var notify client.TransferResp
+	if err := json.Unmarshal([]byte(req.Body), &notify); err != nil {
+		return nil, fmt.Errorf("解析 Body 失败: %w", err)
+	}
+	resp, ok := store.getTransfer(notify.OutTradeNo)
+	if !ok || resp.Status == consts.PayTransferStatusProcessing {
+		return nil, errors.New("模拟转账不存在或未完成")
+	}
+	resp.RawData = req.Body
+	resp.ChannelNotifyData = req.Body
+	return resp, nil
+}
+
+// resolveResult 渠道额外参数中指定了合法的模拟结果时使用该结果，否则使用渠道配置
+func (c *MockPayClient) resolveResult(configured string, channelExtras map[string]string) string {
+	switch result := channelExtras[ChannelExtrasResult]; result {
+	case ResultSuccess, ResultFailure, ResultWaiting:
+		return result
+	default:
+		return configured
+	}
+}
+
+// settleLater 延迟后完成交易，并向回调地址发送异步通知
+// 回调地址为空时只完成交易，由同步任务通过查询接口获取结果
+func (c *MockPayClient) settleLater(notifyURL string, settle func() any) {
+	delay := time.Duration(c.config.NotifyDelay) * time.Millisecond
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("[MockPayClient] Settle panic: channelId=%d, err=%v", c.ChannelID, r)
+			}
+		}()
+		time.Sleep(delay)
+		notify := settle()
+		if notifyURL == "" {
+			return
+		}
+		if err := postNotify(notifyURL, notify); err != nil {
+			log.Printf("[MockPayClient] Notify failed: channelId=%d, url=%s, err=%v", c.ChannelID, notifyURL, err)
+		}
+	}()
+}
+
+// postNotify 以 JSON 格式 POST 异步通知
+func postNotify(notifyURL string, notify any) error {
+	body, err := json.Marshal(notify)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, notifyURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// store 模拟交易记录，按外部单号保存；包级共享，渠道配置变更重建客户端后仍可查询
+var store = &tradeStore{
+	orders:    make(map[string]*client.OrderResp),
+	refunds:   make(map[string]*client.RefundResp),
+	transfers: make(map[string]*client.TransferResp),
+}
+
+type tradeStore struct {
+	mu        sync.RWMutex
+	orders    map[string]*client.OrderResp
+	refunds   map[string]*client.RefundResp
+	transfers map[string]*client.TransferResp
+}
+
+func (s *tradeStore) saveOrder(resp *client.OrderResp) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orders[resp.OutTradeNo] = copyOrder(resp)
+}
+
+func (s *tradeStore) getOrder(outTradeNo string) (*client.OrderResp, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	resp, ok := s.orders[outTradeNo]
+	if !ok {
+		return nil, false
+	}
+	return copyOrder(resp), true
+}
+
+// settleOrder 完成处理中的支付订单，订单已关闭时保持不变，返回最终的订单
+func (s *tradeStore) settleOrder(resp *client.OrderResp) *client.OrderResp {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if current, ok := s.orders[resp.OutTradeNo]; ok && current.Status != consts.PayOrderStatusWaiting {
+		return copyOrder(current)
+	}
+	s.orders[resp.OutTradeNo] = copyOrder(resp)
+	return copyOrder(resp)
+}
+
+// closeOrder 关闭处理中的支付订单，返回关闭后的订单
+func (s *tradeStore) closeOrder(outTradeNo string) (*client.OrderResp, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp, ok := s.orders[outTradeNo]
+	if !ok {
+		return nil, false
+	}
+	if resp.Status == consts.PayOrderStatusWaiting {
+		resp.Status = consts.PayOrderStatusClosed
+		resp.ChannelErrorCode = "ORDER_CLOSED"
+		resp.ChannelErrorMsg = "模拟支付订单已关闭"
+	}
+	return copyOrder(resp), true
+}
+
+func (s *tradeStore) saveRefund(resp *client.RefundResp) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refunds[resp.OutRefundNo] = copyRefund(resp)
+}
+
+func (s *tradeStore) getRefund(outRefundNo string) (*client.RefundResp, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	resp, ok := s.refunds[outRefundNo]
+	if !ok {
+		return nil, false
+	}
+	return copyRefund(resp), true
+}
+
+func (s *tradeStore) saveTransfer(resp *client.TransferResp) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transfers[resp.OutTradeNo] = copyTransfer(resp)
+}
+
+func (s *tradeStore) getTransfer(outTradeNo string) (*client.TransferResp, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	resp, ok := s.transfers[outTradeNo]
+	if !ok {
+		return nil, false
+	}
+	return copyTransfer(resp), true
+}
+
+func copyOrder(resp *client.OrderResp) *client.OrderResp {
+	c := *resp
+	return &c
+}
+
+func copyRefund(resp *client.RefundResp) *client.RefundResp {
+	c := *resp
+	return &c
+}
+
+func copyTransfer(resp *client.TransferResp) *client.TransferResp {
+	c := *resp
+	return &c
+}
@@ -0,0 +1,151 @@
+package mock
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client"
+)
+
+// newTestClient 创建模拟支付客户端，以及接收异步通知的回调服务
+func newTestClient(t *testing.T, config string) (*MockPayClient, string, <-chan string) {
+	payClient, err := NewMockPayClientAsClient(1, config)
+	assert.NoError(t, err)
+	assert.NoError(t, payClient.Init())
+
+	notifies := make(chan string, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		notifies <- string(body)
+	}))
+	t.Cleanup(server.Close)
+	return payClient.(*MockPayClient), server.URL, notifies
+}
+
+// waitNotify 等待异步通知
+func waitNotify(t *testing.T, notifies <-chan string) string {
+	select {
+	case body := <-notifies:
+		return body
+	case <-time.After(5 * time.Second):
+		t.Fatal("等待异步通知超时")
+		return ""
+	}
+}
+
+// TestMockPayClientOrderAndRefund 验证下单、支付通知、退款、退款通知的完整流程
+func TestMockPayClientOrderAndRefund(t *testing.T) {
+	ctx := context.Background()
+	payClient, notifyURL, notifies := newTestClient(t, "")
+
+	// 1. 下单先返回处理中，随后通知支付成功
+	orderResp, err := payClient.UnifiedOrder(ctx, &client.UnifiedOrderReq{
+		OutTradeNo: "T-ORDER-1",
+		Price:      100,
+		NotifyURL:  notifyURL,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, consts.PayOrderStatusWaiting, orderResp.Status)
+
+	orderNotify, err := payClient.ParseOrderNotify(&client.NotifyData{Body: waitNotify(t, notifies)})
+	assert.NoError(t, err)
+	assert.Equal(t, consts.PayOrderStatusSuccess, orderNotify.Status)
+	assert.Equal(t, "MOCK-P-T-ORDER-1", orderNotify.ChannelOrderNo)
+
+	orderResp, err = payClient.GetOrder(ctx, "T-ORDER-1")
+	assert.NoError(t, err)
+	assert.Equal(t, consts.PayOrderStatusSuccess, orderResp.Status)
+
+	// 2. 退款先返回处理中，随后通知退款成功
+	refundResp, err := payClient.UnifiedRefund(ctx, &client.UnifiedRefundReq{
+		OutTradeNo:  "T-ORDER-1",
+		OutRefundNo: "T-REFUND-1",
+		PayPrice:    100,
+		RefundPrice: 40,
+		NotifyURL:   notifyURL,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, consts.PayRefundStatusWaiting, refundResp.Status)
+
+	refundNotify, err := payClient.ParseRefundNotify(&client.NotifyData{Body: waitNotify(t, notifies)})
+	assert.NoError(t, err)
+	assert.Equal(t, consts.PayRefundStatusSuccess, refundNotify.Status)
+	assert.Equal(t, "MOCK-R-T-REFUND-1", refundNotify.ChannelRefundNo)
+
+	refundResp, err = payClient.GetRefund(ctx, "T-ORDER-1", "T-REFUND-1")
+	assert.NoError(t, err)
+	assert.Equal(t, consts.PayRefundStatusSuccess, refundResp.Status)
+}
+
+// TestMockPayClientOrderFailure 验证渠道额外参数指定的失败结果优先于渠道配置
+func TestMockPayClientOrderFailure(t *testing.T) {
+	payClient, notifyURL, notifies := newTestClient(t, `{"mockOrderResult":"success"}`)
+
+	_, err := payClient.UnifiedOrder(context.Background(), &client.UnifiedOrderReq{
+		OutTradeNo:    "T-ORDER-2",
+		Price:         100,
+		NotifyURL:     notifyURL,
+		ChannelExtras: map[string]string{ChannelExtrasResult: ResultFailure},
+	})
+	assert.NoError(t, err)
+
+	orderNotify, err := payClient.ParseOrderNotify(&client.NotifyData{Body: waitNotify(t, notifies)})
+	assert.NoError(t, err)
+	assert.Equal(t, consts.PayOrderStatusClosed, orderNotify.Status)
+	assert.Equal(t, "MOCK_FAILURE", orderNotify.ChannelErrorCode)
+}
+
+// TestMockPayClientClosedOrder 验证关闭后的订单不再完成支付，已支付的订单无法关闭
+func TestMockPayClientClosedOrder(t *testing.T) {
+	ctx := context.Background()
+	payClient, notifyURL, notifies := newTestClient(t, `{"mockNotifyDelay":100}`)
+
+	// 1. 处理中的订单关闭后，延迟通知为关闭
+	_, err := payClient.UnifiedOrder(ctx, &client.UnifiedOrderReq{OutTradeNo: "T-ORDER-4", Price: 100, NotifyURL: notifyURL})
+	assert.NoError(t, err)
+	assert.NoError(t, payClient.ClosedOrder(ctx, "T-ORDER-4"))
+
+	orderNotify, err := payClient.ParseOrderNotify(&client.NotifyData{Body: waitNotify(t, notifies)})
+	assert.NoError(t, err)
+	assert.Equal(t, consts.PayOrderStatusClosed, orderNotify.Status)
+
+	// 2. 已支付的订单关闭失败
+	_, err = payClient.UnifiedOrder(ctx, &client.UnifiedOrderReq{OutTradeNo: "T-ORDER-5", Price: 100, NotifyURL: notifyURL})
+	assert.NoError(t, err)
+	waitNotify(t, notifies)
+	assert.Error(t, payClient.ClosedOrder(ctx, "T-ORDER-5"))
+
+	// 3. 不存在的订单视为已关闭
+	assert.NoError(t, payClient.ClosedOrder(ctx, "T-ORDER-NOT-EXIST"))
+}
+
+// TestMockPayClientRejectForgedNotify 验证回调只认可本客户端产生的结果
+func TestMockPayClientRejectForgedNotify(t *testing.T) {
+	payClient, _, _ := newTestClient(t, `{"mockOrderResult":"waiting"}`)
+
+	_, err := payClient.ParseOrderNotify(&client.NotifyData{Body: `{"status":10,"outTradeNo":"T-FORGED"}`})
+	assert.Error(t, err)
+
+	// 一直处理中的订单不接受通知
+	_, err = payClient.UnifiedOrder(context.Background(), &client.UnifiedOrderReq{OutTradeNo: "T-ORDER-3", Price: 100})
+	assert.NoError(t, err)
+	_, err = payClient.ParseOrderNotify(&client.NotifyData{Body: `{"status":10,"outTradeNo":"T-ORDER-3"}`})
+	assert.Error(t, err)
+
+	// 退款需存在支付订单
+	_, err = payClient.UnifiedRefund(context.Background(), &client.UnifiedRefundReq{OutTradeNo: "T-FORGED", OutRefundNo: "T-REFUND-X"})
+	assert.Error(t, err)
+}
+
+// TestMockPayClientInvalidConfig 验证不支持的模拟结果配置初始化失败
+func TestMockPayClientInvalidConfig(t *testing.T) {
+	payClient, err := NewMockPayClientAsClient(1, `{"mockOrderResult":"unknown"}`)
+	assert.NoError(t, err)
+	assert.Error(t, payClient.Init())
+}
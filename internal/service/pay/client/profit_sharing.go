@@ -0,0 +1,94 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// ProfitSharingReceiverReq 分账接收方 Request DTO
+type ProfitSharingReceiverReq struct {
+	Type         int    `json:"type"`         // 接收方类型，枚举 consts.PayProfitSharingReceiverType
+	Account      string `json:"account"`      // 接收方账号：钱包编号、微信商户号或 openid、支付宝 userId
+	RealName     string `json:"realName"`     // 接收方真实姓名或商户全称
+	RelationType string `json:"relationType"` // 与分账方的关系类型，仅微信
+}
+
+// ProfitSharingItemReq 分账明细 Request DTO
+type ProfitSharingItemReq struct {
+	ProfitSharingReceiverReq
+	OutDetailNo string `json:"outDetailNo"` // 外部分账明细单号，钱包分账以此作为入账的业务编号
+	Price       int    `json:"price"`       // 分账金额，单位：分
+	Description string `json:"description"` // 分账描述
+}
+
+// UnifiedProfitSharingReq 统一分账 Request DTO
+type UnifiedProfitSharingReq struct {
+	OutTradeNo     string                  `json:"outTradeNo"`     // 外部订单号
+	ChannelOrderNo string                  `json:"channelOrderNo"` // 渠道订单号
+	OutSharingNo   string                  `json:"outSharingNo"`   // 外部分账请求单号
+	Items          []*ProfitSharingItemReq `json:"items"`          // 分账明细，为空时仅完结分账，解冻订单剩余资金
+}
+
+// ProfitSharingItemResp 渠道分账明细 Response DTO
+type ProfitSharingItemResp struct {
+	Type            int       `json:"type"`            // 接收方类型
+	Account         string    `json:"account"`         // 接收方账号
+	Price           int       `json:"price"`           // 分账金额，单位：分
+	Status          int       `json:"status"`          // 分账状态，枚举 consts.PayProfitSharingStatus
+	ChannelDetailNo string    `json:"channelDetailNo"` // 渠道分账明细单号
+	FailReason      string    `json:"failReason"`      // 分账失败原因
+	SuccessTime     time.Time `json:"successTime"`     // 分账成功时间
+}
+
+// ProfitSharingResp 渠道分账 Response DTO
+// 调用渠道失败且可确定渠道未受理时返回失败状态；无法确定结果时返回 error，由同步任务查询确认
+type ProfitSharingResp struct {
+	Status           int                      `json:"status"`           // 分账状态，枚举 consts.PayProfitSharingStatus
+	OutSharingNo     string                   `json:"outSharingNo"`     // 外部分账请求单号
+	ChannelSharingNo string                   `json:"channelSharingNo"` // 渠道分账单号
+	Items            []*ProfitSharingItemResp `json:"items"`            // 分账明细结果
+	RawData          interface{}              `json:"rawData"`          // 原始的同步结果
+	ChannelErrorCode string                   `json:"channelErrorCode"` // 调用渠道的错误码
+	ChannelErrorMsg  string                   `json:"channelErrorMsg"`  // 调用渠道报错时，错误信息
+}
+
+// UnifiedProfitSharingReturnReq 统一分账回退 Request DTO
+type UnifiedProfitSharingReturnReq struct {
+	OutTradeNo     string                   `json:"outTradeNo"`     // 外部订单号
+	ChannelOrderNo string                   `json:"channelOrderNo"` // 渠道订单号
+	OutSharingNo   string                   `json:"outSharingNo"`   // 原分账请求单号
+	OutReturnNo    string                   `json:"outReturnNo"`    // 外部回退单号
+	Receiver       ProfitSharingReceiverReq `json:"receiver"`       // 回退的接收方
+	Price          int                      `json:"price"`          // 回退金额，单位：分
+	Description    string                   `json:"description"`    // 回退描述
+}
+
+// ProfitSharingReturnResp 渠道分账回退 Response DTO
+type ProfitSharingReturnResp struct {
+	Status           int         `json:"status"`           // 回退状态，枚举 consts.PayProfitSharingStatus
+	OutReturnNo      string      `json:"outReturnNo"`      // 外部回退单号
+	ChannelReturnNo  string      `json:"channelReturnNo"`  // 渠道回退单号
+	SuccessTime      time.Time   `json:"successTime"`      // 回退成功时间
+	RawData          interface{} `json:"rawData"`          // 原始的同步结果
+	ChannelErrorCode string      `json:"channelErrorCode"` // 调用渠道的错误码
+	ChannelErrorMsg  string      `json:"channelErrorMsg"`  // 调用渠道报错时，错误信息
+}
+
+// ProfitSharingClient 支持分账的支付客户端
+// 订单以单次分账完结：分账后订单剩余的冻结资金解冻给商户，不再追加分账
+type ProfitSharingClient interface {
+	// AddProfitSharingReceiver 添加分账接收方，建立分账关系
+	AddProfitSharingReceiver(ctx context.Context, req *ProfitSharingReceiverReq) error
+
+	// UnifiedProfitSharing 调用支付渠道，进行分账
+	UnifiedProfitSharing(ctx context.Context, req *UnifiedProfitSharingReq) (*ProfitSharingResp, error)
+
+	// GetProfitSharing 获得分账结果
+	GetProfitSharing(ctx context.Context, req *UnifiedProfitSharingReq) (*ProfitSharingResp, error)
+
+	// UnifiedProfitSharingReturn 调用支付渠道，从接收方回退分账金额
+	UnifiedProfitSharingReturn(ctx context.Context, req *UnifiedProfitSharingReturnReq) (*ProfitSharingReturnResp, error)
+
+	// GetProfitSharingReturn 获得分账回退结果
+	GetProfitSharingReturn(ctx context.Context, req *UnifiedProfitSharingReturnReq) (*ProfitSharingReturnResp, error)
+}
@@ -0,0 +1,142 @@
+package weixin
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client"
+
+	"github.com/wechatpay-apiv3/wechatpay-go/core"
+	wxconsts "github.com/wechatpay-apiv3/wechatpay-go/core/consts"
+	"github.com/wechatpay-apiv3/wechatpay-go/core/option"
+)
+
+func init() {
+	client.RegisterBillParser(consts.PayReconcileChannelWeixin, ParseBill)
+}
+
+// tradeBillResp 申请交易账单接口的响应
+type tradeBillResp struct {
+	DownloadURL string `json:"download_url"`
+	HashType    string `json:"hash_type"`
+	HashValue   string `json:"hash_value"`
+}
+
+// DownloadBill 下载交易对账单（账单类型 ALL）
+// 先申请交易账单获得下载地址，再下载账单文件；下载接口不返回签名，需使用不验签的客户端，并按摘要校验文件
+func (c *WxPayClient) DownloadBill(ctx context.Context, billDate time.Time) (string, []byte, error) {
+	query := url.Values{}
+	query.Set("bill_date", billDate.Format(time.DateOnly))
+	query.Set("bill_type", "ALL")
+	result, err := c.coreClient.Get(ctx, wxconsts.WechatPayAPIServer+"/v3/bill/tradebill?"+query.Encode())
+	if err != nil {
+		return "", nil, fmt.Errorf("申请交易账单失败: %w", err)
+	}
+	var bill tradeBillResp
+	if err := core.UnMarshalResponse(result.Response, &bill); err != nil {
+		return "", nil, fmt.Errorf("申请交易账单失败: %w", err)
+	}
+
+	downloadClient, err := core.NewClient(ctx,
+		option.WithMerchantCredential(c.config.MchID, c.config.CertSerialNo, c.privateKey),
+		option.WithoutValidator())
+	if err != nil {
+		return "", nil, fmt.Errorf("创建微信支付下载客户端失败: %w", err)
+	}
+	result, err = downloadClient.Get(ctx, bill.DownloadURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("下载交易账单失败: %w", err)
+	}
+	defer result.Response.Body.Close()
+	data, err := io.ReadAll(result.Response.Body)
+	if err != nil {
+		return "", nil, fmt.Errorf("下载交易账单失败: %w", err)
+	}
+
+	if strings.EqualFold(bill.HashType, "SHA1") {
+		sum := sha1.Sum(data)
+		if !strings.EqualFold(hex.EncodeToString(sum[:]), bill.HashValue) {
+			return "", nil, fmt.Errorf("交易账单摘要校验失败")
+		}
+	}
+	return fmt.Sprintf("wx_%s.csv", billDate.Format("20060102")), data, nil
+}
+
+// ParseBill 解析微信支付交易账单（账单类型 ALL）
+// 明细行的每个值以 ` 开头，明细之后为“总交易单数”开头的汇总部分；交易状态 SUCCESS 为支付，REFUND 为退款
+func ParseBill(data []byte) ([]*client.BillRecord, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("解析对账单失败: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("对账单内容为空")
+	}
+
+	header := make(map[string]int, len(rows[0]))
+	for i, name := range rows[0] {
+		header[strings.TrimSpace(name)] = i
+	}
+	columns := []string{"交易时间", "微信订单号", "商户订单号", "交易状态", "应结订单金额", "微信退款单号", "商户退款单号", "退款金额"}
+	for _, name := range columns {
+		if _, ok := header[name]; !ok {
+			return nil, fmt.Errorf("对账单缺少列: %s", name)
+		}
+	}
+	get := func(row []string, name string) string {
+		if i, ok := header[name]; ok && i < len(row) {
+			return strings.TrimPrefix(strings.TrimSpace(row[i]), "`")
+		}
+		return ""
+	}
+	// 优先使用订单金额、申请退款金额（含代金券），与本地支付单、退款单金额口径一致；老版本账单没有这两列
+	getAmount := func(row []string, name, fallback string) (int, error) {
+		value := get(row, name)
+		if value == "" {
+			value = get(row, fallback)
+		}
+		return client.ParseBillAmount(value)
+	}
+
+	records := make([]*client.BillRecord, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		if len(row) > 0 && strings.TrimSpace(row[0]) == "总交易单数" {
+			break
+		}
+		record := &client.BillRecord{
+			OutTradeNo:     get(row, "商户订单号"),
+			ChannelOrderNo: get(row, "微信订单号"),
+			TradeTime:      client.ParseBillTime(get(row, "交易时间")),
+		}
+		switch get(row, "交易状态") {
+		case "SUCCESS":
+			record.BizType = consts.PayReconcileBizTypeOrder
+			record.Amount, err = getAmount(row, "订单金额", "应结订单金额")
+		case "REFUND":
+			record.BizType = consts.PayReconcileBizTypeRefund
+			record.OutRefundNo = get(row, "商户退款单号")
+			record.ChannelRefundNo = get(row, "微信退款单号")
+			record.Amount, err = getAmount(row, "申请退款金额", "退款金额")
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
@@ -0,0 +1,54 @@
+package weixin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client"
+)
+
+// 微信支付交易账单（ALL）：明细值以 ` 开头，明细之后为汇总部分
+const testWeixinBill = "\xef\xbb\xbf" + `交易时间,公众账号ID,商户号,特约商户号,设备号,微信订单号,商户订单号,用户标识,交易类型,交易状态,付款银行,货币种类,应结订单金额,代金券金额,微信退款单号,商户退款单号,退款金额,充值券退款金额,退款类型,退款状态,商品名称,商户数据包,手续费,费率,订单金额,申请退款金额
+` + "`2024-01-01 10:00:05,`wx0000000000000001,`1900000001,`0,`,`4200000001202401010001,`P202401010001,`oUser,`JSAPI,`SUCCESS,`OTHERS,`CNY,`11.34,`1.00,`0,`0,`0.00,`0.00,`,`,`测试商品,`,`0.07,`0.60%,`12.34,`0.00\n" +
+	"`2024-01-01 11:00:03,`wx0000000000000001,`1900000001,`0,`,`4200000001202401010001,`P202401010001,`oUser,`JSAPI,`REFUND,`OTHERS,`CNY,`0.00,`0.00,`50000000012024010100001,`R202401010001,`2.30,`0.00,`ORIGINAL,`SUCCESS,`测试商品,`,`-0.01,`0.60%,`0.00,`2.30\n" +
+	"`2024-01-01 12:00:00,`wx0000000000000001,`1900000001,`0,`,`4200000001202401010002,`P202401010002,`oUser,`JSAPI,`REVOKED,`OTHERS,`CNY,`0.00,`0.00,`0,`0,`0.00,`0.00,`,`,`测试商品,`,`0.00,`0.60%,`1.00,`0.00\n" +
+	"总交易单数,应结订单总金额,退款总金额,充值券退款总金额,手续费总金额,订单总金额,申请退款总金额\n" +
+	"`2,`11.34,`2.30,`0.00,`0.06,`12.34,`2.30\n"
+
+// 老版本账单没有订单金额、申请退款金额两列
+const testWeixinLegacyBill = `交易时间,微信订单号,商户订单号,交易状态,应结订单金额,微信退款单号,商户退款单号,退款金额
+` + "`2024-01-01 10:00:05,`4200000001202401010001,`P202401010001,`SUCCESS,`12.34,`0,`0,`0.00\n"
+
+// TestParseBill 验证微信支付对账单解析：支付与退款取订单金额口径，跳过撤销与汇总部分
+func TestParseBill(t *testing.T) {
+	records, err := client.ParseBill(consts.PayReconcileChannelWeixin, []byte(testWeixinBill))
+	assert.NoError(t, err)
+	if !assert.Len(t, records, 2) {
+		return
+	}
+
+	order := records[0]
+	assert.Equal(t, consts.PayReconcileBizTypeOrder, order.BizType)
+	assert.Equal(t, "P202401010001", order.OutTradeNo)
+	assert.Equal(t, "4200000001202401010001", order.ChannelOrderNo)
+	assert.Equal(t, 1234, order.Amount)
+	if assert.NotNil(t, order.TradeTime) {
+		assert.Equal(t, "2024-01-01 10:00:05", order.TradeTime.Format("2006-01-02 15:04:05"))
+	}
+
+	refund := records[1]
+	assert.Equal(t, consts.PayReconcileBizTypeRefund, refund.BizType)
+	assert.Equal(t, "R202401010001", refund.OutRefundNo)
+	assert.Equal(t, "50000000012024010100001", refund.ChannelRefundNo)
+	assert.Equal(t, 230, refund.Amount)
+}
+
+// TestParseBillLegacy 验证老版本账单回退使用应结订单金额
+func TestParseBillLegacy(t *testing.T) {
+	records, err := client.ParseBill(consts.PayReconcileChannelWeixin, []byte(testWeixinLegacyBill))
+	assert.NoError(t, err)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, 1234, records[0].Amount)
+	}
+}
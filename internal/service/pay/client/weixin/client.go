@@ -142,15 +142,13 @@ func (c *WxPayClient) nativeOrder(ctx context.Context, req *client.UnifiedOrderR
 			Total:    core.Int64(int64(req.Price)),
 			Currency: core.String("CNY"),
 		},
+		SettleInfo: &native.SettleInfo{
+			ProfitSharing: core.Bool(req.ProfitSharing),
+		},
 	})
 
 	if err != nil {
-		return &client.OrderResp{
-			Status:           consts.PayOrderStatusClosed, // CLOSED
-			OutTradeNo:       req.OutTradeNo,
-			ChannelErrorCode: "NATIVE_PREPAY_ERROR",
-			ChannelErrorMsg:  err.Error(),
-		}, nil
+		return toPrepayErrorResp(req.OutTradeNo, "NATIVE_PREPAY_ERROR", err)
 	}
 
 	_ = result
@@ -186,18 +184,16 @@ func (c *WxPayClient) jsapiOrder(ctx context.Context, req *client.UnifiedOrderRe
 			Total:    core.Int64(int64(req.Price)),
 			Currency: core.String("CNY"),
 		},
+		SettleInfo: &jsapi.SettleInfo{
+			ProfitSharing: core.Bool(req.ProfitSharing),
+		},
 		Payer: &jsapi.Payer{
 			Openid: core.String(openID),
 		},
 	})
 
 	if err != nil {
-		return &client.OrderResp{
-			Status:           consts.PayOrderStatusClosed, // CLOSED
-			OutTradeNo:       req.OutTradeNo,
-			ChannelErrorCode: "JSAPI_PREPAY_ERROR",
-			ChannelErrorMsg:  err.Error(),
-		}, nil
+		return toPrepayErrorResp(req.OutTradeNo, "JSAPI_PREPAY_ERROR", err)
 	}
 
 	_ = result
@@ -241,16 +237,14 @@ func (c *WxPayClient) h5Order(ctx context.Context, req *client.UnifiedOrderReq)
 			Total:    core.Int64(int64(req.Price)),
 			Currency: core.String("CNY"),
 		},
+		SettleInfo: &h5.SettleInfo{
+			ProfitSharing: core.Bool(req.ProfitSharing),
+		},
 		SceneInfo: sceneInfo,
 	})
 
 	if err != nil {
-		return &client.OrderResp{
-			Status:           consts.PayOrderStatusClosed, // CLOSED
-			OutTradeNo:       req.OutTradeNo,
-			ChannelErrorCode: "H5_PREPAY_ERROR",
-			ChannelErrorMsg:  err.Error(),
-		}, nil
+		return toPrepayErrorResp(req.OutTradeNo, "H5_PREPAY_ERROR", err)
 	}
 	_ = result
 
@@ -276,15 +270,13 @@ func (c *WxPayClient) appOrder(ctx context.Context, req *client.UnifiedOrderReq)
 			Total:    core.Int64(int64(req.Price)),
 			Currency: core.String("CNY"),
 		},
+		SettleInfo: &app.SettleInfo{
+			ProfitSharing: core.Bool(req.ProfitSharing),
+		},
 	})
 
 	if err != nil {
-		return &client.OrderResp{
-			Status:           consts.PayOrderStatusClosed, // CLOSED
-			OutTradeNo:       req.OutTradeNo,
-			ChannelErrorCode: "APP_PREPAY_ERROR",
-			ChannelErrorMsg:  err.Error(),
-		}, nil
+		return toPrepayErrorResp(req.OutTradeNo, "APP_PREPAY_ERROR", err)
 	}
 	_ = result
 
@@ -296,6 +288,23 @@ func (c *WxPayClient) appOrder(ctx context.Context, req *client.UnifiedOrderReq)
 	}, nil
 }
 
+// toPrepayErrorResp 微信明确拒绝下单时返回关闭状态及渠道错误码；超时等无法确定结果的异常直接返回 error，不视为渠道业务失败
+func toPrepayErrorResp(outTradeNo, errorCode string, err error) (*client.OrderResp, error) {
+	apiErr, ok := isWxDefiniteError(err)
+	if !ok {
+		return nil, err
+	}
+	if apiErr.Code != "" {
+		errorCode = apiErr.Code
+	}
+	return &client.OrderResp{
+		Status:           consts.PayOrderStatusClosed, // CLOSED
+		OutTradeNo:       outTradeNo,
+		ChannelErrorCode: errorCode,
+		ChannelErrorMsg:  err.Error(),
+	}, nil
+}
+
 // UnifiedRefund 统一退款
 func (c *WxPayClient) UnifiedRefund(ctx context.Context, req *client.UnifiedRefundReq) (*client.RefundResp, error) {
 	svc := refunddomestic.RefundsApiService{Client: c.coreClient}
@@ -371,6 +380,17 @@ func (c *WxPayClient) GetOrder(ctx context.Context, outTradeNo string) (*client.
 	}, nil
 }
 
+// ClosedOrder 关闭订单
+func (c *WxPayClient) ClosedOrder(ctx context.Context, outTradeNo string) error {
+	svc := jsapi.JsapiApiService{Client: c.coreClient}
+
+	_, err := svc.CloseOrder(ctx, jsapi.CloseOrderRequest{
+		OutTradeNo: core.String(outTradeNo),
+		Mchid:      core.String(c.config.MchID),
+	})
+	return err
+}
+
 // GetRefund 查询退款
 func (c *WxPayClient) GetRefund(ctx context.Context, outTradeNo, outRefundNo string) (*client.RefundResp, error) {
 	return nil, errors.New("退款查询功能暂未实现")
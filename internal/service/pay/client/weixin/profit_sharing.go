@@ -0,0 +1,264 @@
+package weixin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client"
+
+	"github.com/wechatpay-apiv3/wechatpay-go/core"
+	"github.com/wechatpay-apiv3/wechatpay-go/services/profitsharing"
+)
+
+// AddProfitSharingReceiver 添加分账接收方，重复添加时微信直接返回成功
+func (c *WxPayClient) AddProfitSharingReceiver(ctx context.Context, req *client.ProfitSharingReceiverReq) error {
+	receiverType, err := toWxReceiverType(req.Type)
+	if err != nil {
+		return err
+	}
+	addReq := profitsharing.AddReceiverRequest{
+		Appid:        core.String(c.config.AppID),
+		Type:         receiverType.Ptr(),
+		Account:      core.String(req.Account),
+		RelationType: profitsharing.ReceiverRelationType(req.RelationType).Ptr(),
+	}
+	if req.RealName != "" {
+		addReq.Name = core.String(req.RealName)
+	}
+	if req.RelationType == string(profitsharing.RECEIVERRELATIONTYPE_CUSTOM) {
+		addReq.CustomRelation = core.String("自定义")
+	}
+	svc := profitsharing.ReceiversApiService{Client: c.coreClient}
+	if _, _, err := svc.AddReceiver(ctx, addReq); err != nil {
+		return fmt.Errorf("添加微信分账接收方失败: %w", err)
+	}
+	return nil
+}
+
+// UnifiedProfitSharing 请求分账，分账后解冻订单剩余资金；没有分账明细时直接解冻
+func (c *WxPayClient) UnifiedProfitSharing(ctx context.Context, req *client.UnifiedProfitSharingReq) (*client.ProfitSharingResp, error) {
+	svc := profitsharing.OrdersApiService{Client: c.coreClient}
+	if len(req.Items) == 0 {
+		resp, _, err := svc.UnfreezeOrder(ctx, profitsharing.UnfreezeOrderRequest{
+			TransactionId: core.String(req.ChannelOrderNo),
+			OutOrderNo:    core.String(req.OutSharingNo),
+			Description:   core.String("解冻全部剩余资金"),
+		})
+		if err != nil {
+			return toProfitSharingErrorResp(req.OutSharingNo, err)
+		}
+		return toProfitSharingResp(resp), nil
+	}
+
+	receivers := make([]profitsharing.CreateOrderReceiver, 0, len(req.Items))
+	for _, item := range req.Items {
+		receiverType, err := toWxReceiverType(item.Type)
+		if err != nil {
+			return nil, err
+		}
+		receiver := profitsharing.CreateOrderReceiver{
+			Type:        core.String(string(receiverType)),
+			Account:     core.String(item.Account),
+			Amount:      core.Int64(int64(item.Price)),
+			Description: core.String(item.Description),
+		}
+		if item.RealName != "" {
+			receiver.Name = core.String(item.RealName)
+		}
+		receivers = append(receivers, receiver)
+	}
+	resp, _, err := svc.CreateOrder(ctx, profitsharing.CreateOrderRequest{
+		Appid:           core.String(c.config.AppID),
+		TransactionId:   core.String(req.ChannelOrderNo),
+		OutOrderNo:      core.String(req.OutSharingNo),
+		Receivers:       receivers,
+		UnfreezeUnsplit: core.Bool(true),
+	})
+	if err != nil {
+		return toProfitSharingErrorResp(req.OutSharingNo, err)
+	}
+	return toProfitSharingResp(resp), nil
+}
+
+// GetProfitSharing 查询分账结果
+func (c *WxPayClient) GetProfitSharing(ctx context.Context, req *client.UnifiedProfitSharingReq) (*client.ProfitSharingResp, error) {
+	svc := profitsharing.OrdersApiService{Client: c.coreClient}
+	resp, _, err := svc.QueryOrder(ctx, profitsharing.QueryOrderRequest{
+		TransactionId: core.String(req.ChannelOrderNo),
+		OutOrderNo:    core.String(req.OutSharingNo),
+	})
+	if err != nil {
+		return toProfitSharingErrorResp(req.OutSharingNo, err)
+	}
+	return toProfitSharingResp(resp), nil
+}
+
+// UnifiedProfitSharingReturn 请求分账回退，微信仅支持从商户接收方回退
+func (c *WxPayClient) UnifiedProfitSharingReturn(ctx context.Context, req *client.UnifiedProfitSharingReturnReq) (*client.ProfitSharingReturnResp, error) {
+	if req.Receiver.Type != consts.PayProfitSharingReceiverTypeWxMerchant {
+		return &client.ProfitSharingReturnResp{
+			Status:           consts.PayProfitSharingStatusFailure,
+			OutReturnNo:      req.OutReturnNo,
+			ChannelErrorCode: "RECEIVER_NOT_SUPPORT",
+			ChannelErrorMsg:  "微信分账仅支持从商户接收方回退",
+		}, nil
+	}
+	svc := profitsharing.ReturnOrdersApiService{Client: c.coreClient}
+	resp, _, err := svc.CreateReturnOrder(ctx, profitsharing.CreateReturnOrderRequest{
+		OutOrderNo:  core.String(req.OutSharingNo),
+		OutReturnNo: core.String(req.OutReturnNo),
+		ReturnMchid: core.String(req.Receiver.Account),
+		Amount:      core.Int64(int64(req.Price)),
+		Description: core.String(req.Description),
+	})
+	if err != nil {
+		return toProfitSharingReturnErrorResp(req.OutReturnNo, err)
+	}
+	return toProfitSharingReturnResp(resp), nil
+}
+
+// GetProfitSharingReturn 查询分账回退结果
+func (c *WxPayClient) GetProfitSharingReturn(ctx context.Context, req *client.UnifiedProfitSharingReturnReq) (*client.ProfitSharingReturnResp, error) {
+	svc := profitsharing.ReturnOrdersApiService{Client: c.coreClient}
+	resp, _, err := svc.QueryReturnOrder(ctx, profitsharing.QueryReturnOrderRequest{
+		OutOrderNo:  core.String(req.OutSharingNo),
+		OutReturnNo: core.String(req.OutReturnNo),
+	})
+	if err != nil {
+		return toProfitSharingReturnErrorResp(req.OutReturnNo, err)
+	}
+	return toProfitSharingReturnResp(resp), nil
+}
+
+func toWxReceiverType(receiverType int) (profitsharing.ReceiverType, error) {
+	switch receiverType {
+	case consts.PayProfitSharingReceiverTypeWxMerchant:
+		return profitsharing.RECEIVERTYPE_MERCHANT_ID, nil
+	case consts.PayProfitSharingReceiverTypeWxPersonal:
+		return profitsharing.RECEIVERTYPE_PERSONAL_OPENID, nil
+	default:
+		return "", fmt.Errorf("微信分账不支持的接收方类型: %d", receiverType)
+	}
+}
+
+// isWxDefiniteError 判断是否为微信明确拒绝的请求：4xx 应答说明请求未被受理，其它错误无法确定结果
+func isWxDefiniteError(err error) (*core.APIError, bool) {
+	var apiErr *core.APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode >= 400 && apiErr.StatusCode < 500 &&
+		apiErr.StatusCode != http.StatusTooManyRequests {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+func toProfitSharingErrorResp(outSharingNo string, err error) (*client.ProfitSharingResp, error) {
+	apiErr, ok := isWxDefiniteError(err)
+	if !ok {
+		return nil, err
+	}
+	return &client.ProfitSharingResp{
+		Status:           consts.PayProfitSharingStatusFailure,
+		OutSharingNo:     outSharingNo,
+		ChannelErrorCode: apiErr.Code,
+		ChannelErrorMsg:  apiErr.Message,
+	}, nil
+}
+
+func toProfitSharingReturnErrorResp(outReturnNo string, err error) (*client.ProfitSharingReturnResp, error) {
+	apiErr, ok := isWxDefiniteError(err)
+	if !ok {
+		return nil, err
+	}
+	return &client.ProfitSharingReturnResp{
+		Status:           consts.PayProfitSharingStatusFailure,
+		OutReturnNo:      outReturnNo,
+		ChannelErrorCode: apiErr.Code,
+		ChannelErrorMsg:  apiErr.Message,
+	}, nil
+}
+
+// toProfitSharingResp 转换分账结果：分账单完成且明细均已完结时为成功，明细结果以各自状态为准
+func toProfitSharingResp(resp *profitsharing.OrdersEntity) *client.ProfitSharingResp {
+	result := &client.ProfitSharingResp{
+		Status:  consts.PayProfitSharingStatusProcessing,
+		RawData: resp,
+	}
+	if resp.OutOrderNo != nil {
+		result.OutSharingNo = *resp.OutOrderNo
+	}
+	if resp.OrderId != nil {
+		result.ChannelSharingNo = *resp.OrderId
+	}
+	if resp.State != nil && *resp.State == profitsharing.ORDERSTATUS_FINISHED {
+		result.Status = consts.PayProfitSharingStatusSuccess
+	}
+	for _, receiver := range resp.Receivers {
+		item := &client.ProfitSharingItemResp{
+			Status: consts.PayProfitSharingStatusProcessing,
+		}
+		if receiver.Type != nil {
+			switch *receiver.Type {
+			case profitsharing.RECEIVERTYPE_MERCHANT_ID:
+				item.Type = consts.PayProfitSharingReceiverTypeWxMerchant
+			case profitsharing.RECEIVERTYPE_PERSONAL_OPENID:
+				item.Type = consts.PayProfitSharingReceiverTypeWxPersonal
+			}
+		}
+		if receiver.Account != nil {
+			item.Account = *receiver.Account
+		}
+		if receiver.Amount != nil {
+			item.Price = int(*receiver.Amount)
+		}
+		if receiver.DetailId != nil {
+			item.ChannelDetailNo = *receiver.DetailId
+		}
+		if receiver.Result != nil {
+			switch *receiver.Result {
+			case profitsharing.DETAILSTATUS_SUCCESS:
+				item.Status = consts.PayProfitSharingStatusSuccess
+				if receiver.FinishTime != nil {
+					item.SuccessTime = *receiver.FinishTime
+				}
+			case profitsharing.DETAILSTATUS_CLOSED:
+				item.Status = consts.PayProfitSharingStatusFailure
+				if receiver.FailReason != nil {
+					item.FailReason = string(*receiver.FailReason)
+				}
+			}
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result
+}
+
+func toProfitSharingReturnResp(resp *profitsharing.ReturnOrdersEntity) *client.ProfitSharingReturnResp {
+	result := &client.ProfitSharingReturnResp{
+		Status:  consts.PayProfitSharingStatusProcessing,
+		RawData: resp,
+	}
+	if resp.OutReturnNo != nil {
+		result.OutReturnNo = *resp.OutReturnNo
+	}
+	if resp.ReturnId != nil {
+		result.ChannelReturnNo = *resp.ReturnId
+	}
+	if resp.Result != nil {
+		switch *resp.Result {
+		case profitsharing.RETURNORDERSTATUS_SUCCESS:
+			result.Status = consts.PayProfitSharingStatusSuccess
+			if resp.FinishTime != nil {
+				result.SuccessTime = *resp.FinishTime
+			}
+		case profitsharing.RETURNORDERSTATUS_FAILED:
+			result.Status = consts.PayProfitSharingStatusFailure
+			if resp.FailReason != nil {
+				result.ChannelErrorMsg = string(*resp.FailReason)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,28 @@
+package job
+
+import (
+	"context"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay"
+)
+
+// PayProfitSharingJob 支付分账 Job
+// 为支付成功的订单生成分账单并分账，同步分账结果，订单退款后回退分账
+type PayProfitSharingJob struct {
+	payProfitSharingService *pay.PayProfitSharingService
+}
+
+func NewPayProfitSharingJob(payProfitSharingService *pay.PayProfitSharingService) *PayProfitSharingJob {
+	return &PayProfitSharingJob{
+		payProfitSharingService: payProfitSharingService,
+	}
+}
+
+func (j *PayProfitSharingJob) Execute(ctx context.Context, param string) error {
+	_, err := j.payProfitSharingService.ExecuteProfitSharing(ctx)
+	return err
+}
+
+func (j *PayProfitSharingJob) GetHandlerName() string {
+	return "payProfitSharingJob"
+}
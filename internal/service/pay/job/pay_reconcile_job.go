@@ -0,0 +1,40 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay"
+)
+
+// PayReconcileJob 支付渠道对账 Job
+// 下载各支付应用的渠道对账单并对账；参数为账单日期 (yyyy-MM-dd)，缺省为前一天
+type PayReconcileJob struct {
+	payReconcileService *pay.PayReconcileService
+}
+
+func NewPayReconcileJob(payReconcileService *pay.PayReconcileService) *PayReconcileJob {
+	return &PayReconcileJob{
+		payReconcileService: payReconcileService,
+	}
+}
+
+func (j *PayReconcileJob) Execute(ctx context.Context, param string) error {
+	now := time.Now()
+	billDate := time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, time.Local)
+	if param = strings.TrimSpace(param); param != "" {
+		date, err := time.ParseInLocation(time.DateOnly, param, time.Local)
+		if err != nil {
+			return fmt.Errorf("账单日期格式不正确: %s", param)
+		}
+		billDate = date
+	}
+	_, err := j.payReconcileService.ReconcileBills(ctx, billDate)
+	return err
+}
+
+func (j *PayReconcileJob) GetHandlerName() string {
+	return "payReconcileJob"
+}
@@ -26,9 +26,11 @@ type PayOrderService struct {
 	clientFac  *client.PayClientFactory
 	notifySvc  *PayNotifyService
 	noDAO      *payrepo.PayNoRedisDAO
+	routeSvc   *PayChannelRouteService
+	refundSvc  *PayRefundService
 }
 
-func NewPayOrderService(q *query.Query, appSvc *PayAppService, channelSvc *PayChannelService, clientFac *client.PayClientFactory, notifySvc *PayNotifyService, noDAO *payrepo.PayNoRedisDAO) *PayOrderService {
+func NewPayOrderService(q *query.Query, appSvc *PayAppService, channelSvc *PayChannelService, clientFac *client.PayClientFactory, notifySvc *PayNotifyService, noDAO *payrepo.PayNoRedisDAO, routeSvc *PayChannelRouteService) *PayOrderService {
 	return &PayOrderService{
 		q:          q,
 		appSvc:     appSvc,
@@ -36,9 +38,14 @@ func NewPayOrderService(q *query.Query, appSvc *PayAppService, channelSvc *PayCh
 		clientFac:  clientFac,
 		notifySvc:  notifySvc,
 		noDAO:      noDAO,
+		routeSvc:   routeSvc,
 	}
 }
 
+func (s *PayOrderService) SetRefundService(refundSvc *PayRefundService) {
+	s.refundSvc = refundSvc
+}
+
 func (s *PayOrderService) GetOrder(ctx context.Context, id int64) (*pay.PayOrder, error) {
 	return s.q.PayOrder.WithContext(ctx).Where(s.q.PayOrder.ID.Eq(id)).First()
 }
@@ -131,77 +138,124 @@ func (s *PayOrderService) CreateOrder(ctx context.Context, reqDTO *pay2.PayOrder
 // ... GetOrderCountByAppId ...
 
 // SubmitOrder 提交支付订单
+// 按路由规则依次尝试候选渠道，渠道明确返回业务错误时关闭渠道订单并切换到下一个渠道；
+// 网络等异常时渠道订单可能已创建，不切换渠道，直接返回，最后一个渠道的结果直接返回
 func (s *PayOrderService) SubmitOrder(ctx context.Context, reqVO *pay2.PayOrderSubmitReq, userIP string) (*pay2.PayOrderSubmitResp, error) {
 	order, err := s.validateOrderCanSubmit(ctx, reqVO.ID)
 	if err != nil {
 		return nil, err
 	}
 
-	channel, err := s.validateChannelCanSubmit(ctx, order.AppID, reqVO.ChannelCode)
+	channels, err := s.validateChannelCanSubmit(ctx, order, reqVO)
 	if err != nil {
 		return nil, err
 	}
-
-	// Generate No
-	no, err := s.generateNo(ctx)
+	combineClient, walletID, err := s.validateCombinePay(ctx, order, channels[0], reqVO)
 	if err != nil {
 		return nil, err
 	}
-
-	// Create Extension
-	ext := &pay.PayOrderExtension{
-		OrderID:     order.ID,
-		No:          no,
-		ChannelID:   channel.ID,
-		ChannelCode: channel.Code,
-		UserIP:      userIP,
-		Status:      PayOrderStatusWaiting,
-	}
-	if err := s.q.PayOrderExtension.WithContext(ctx).Create(ext); err != nil {
+	profitSharing, err := s.markOrderProfitSharing(ctx, order)
+	if err != nil {
 		return nil, err
 	}
 
-	// Get Pay Client
-	payClient := s.clientFac.GetPayClient(channel.ID)
-	if payClient == nil {
-		// Lazy create if not exists
-		var err error
-		payClient, err = s.clientFac.CreateOrUpdatePayClient(channel.ID, channel.Code, channel.Config.ToJSON())
+	var unifiedResp *client.OrderResp
+	var unifiedErr error
+	var channel *pay.PayChannel
+	for i := range channels {
+		channel = channels[i]
+
+		// Generate No
+		no, err := s.generateNo(ctx)
 		if err != nil {
 			return nil, err
 		}
-	}
 
-	// Call UnifiedOrder (对齐 Java: 使用渠道特定的回调 URL)
-	unifiedReq := &client.UnifiedOrderReq{
-		UserIP:      userIP,
-		OutTradeNo:  no,
-		Subject:     order.Subject,
-		Body:        order.Body,
-		NotifyURL:   s.genChannelOrderNotifyUrl(channel), // 对齐 Java: 渠道回调 URL
-		ReturnURL:   reqVO.ReturnUrl,
-		Price:       order.Price,
-		ExpireTime:  order.ExpireTime,
-		DisplayMode: reqVO.DisplayMode,
+		// Create Extension
+		ext := &pay.PayOrderExtension{
+			OrderID:     order.ID,
+			No:          no,
+			ChannelID:   channel.ID,
+			ChannelCode: channel.Code,
+			UserIP:      userIP,
+			Status:      PayOrderStatusWaiting,
+		}
+		if combineClient != nil {
+			ext.WalletID = walletID
+			ext.WalletPrice = reqVO.WalletPrice
+		}
+		if len(reqVO.ChannelExtras) > 0 {
+			channelExtras, _ := json.Marshal(reqVO.ChannelExtras)
+			ext.ChannelExtras = string(channelExtras)
+		}
+		if err := s.q.PayOrderExtension.WithContext(ctx).Create(ext); err != nil {
+			return nil, err
+		}
+
+		// 组合支付：先冻结钱包金额，渠道只支付剩余金额；渠道下单失败时，由订单过期解冻
+		if combineClient != nil {
+			if err := combineClient.FreezeCombinePay(ctx, ext.No); err != nil {
+				return nil, err
+			}
+		}
+
+		// Get Pay Client
+		payClient := s.clientFac.GetPayClient(channel.ID)
+		if payClient == nil {
+			// Lazy create if not exists
+			var err error
+			payClient, err = s.clientFac.CreateOrUpdatePayClient(channel.ID, channel.Code, channel.Config.ToJSON())
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// Call UnifiedOrder (对齐 Java: 使用渠道特定的回调 URL)
+		unifiedReq := &client.UnifiedOrderReq{
+			UserIP:        userIP,
+			OutTradeNo:    no,
+			Subject:       order.Subject,
+			Body:          order.Body,
+			NotifyURL:     s.genChannelOrderNotifyUrl(channel), // 对齐 Java: 渠道回调 URL
+			ReturnURL:     reqVO.ReturnUrl,
+			Price:         order.Price - ext.WalletPrice,
+			ExpireTime:    order.ExpireTime,
+			ChannelExtras: reqVO.ChannelExtras,
+			DisplayMode:   reqVO.DisplayMode,
+			ProfitSharing: profitSharing,
+		}
+		unifiedResp, unifiedErr = payClient.UnifiedOrder(ctx, unifiedReq)
+
+		// 记录渠道健康计数，渠道明确返回业务错误且还有候选渠道时，关闭本次拓展单并切换渠道
+		failureMsg := unifiedOrderFailureMsg(unifiedResp, unifiedErr)
+		if failureMsg == "" {
+			s.routeSvc.RecordChannelSuccess(ctx, channel.ID)
+			break
+		}
+		s.routeSvc.RecordChannelFailure(ctx, channel.ID, failureMsg)
+		if unifiedErr != nil || i == len(channels)-1 {
+			break
+		}
+		// 先在渠道关闭订单，关闭失败时用户仍可能完成支付，不再切换渠道
+		if err := payClient.ClosedOrder(ctx, ext.No); err != nil {
+			fmt.Printf("[SubmitOrder][order(%d) channel(%d) 关闭渠道订单失败，不切换渠道: %v]\n", order.ID, channel.ID, err)
+			break
+		}
+		fmt.Printf("[SubmitOrder][order(%d) channel(%d) 下单失败，切换渠道: %s]\n", order.ID, channel.ID, failureMsg)
+		if err := s.closeFailoverOrderExtension(ctx, ext, unifiedResp, failureMsg); err != nil {
+			return nil, err
+		}
 	}
-	unifiedResp, err := payClient.UnifiedOrder(ctx, unifiedReq)
-	if err != nil {
-		return nil, err
+	if unifiedErr != nil {
+		return nil, unifiedErr
 	}
 
 	// ✅ 新增：处理直接支付成功的场景（对应 Java 163-180 行）
 	if unifiedResp != nil {
-		// 7.1 尝试异步处理支付结果（兼容并发）
-		go func() {
-			defer func() {
-				if r := recover(); r != nil {
-					// 记录日志但不中断主流程
-					fmt.Printf("[NotifyOrder Panic] order(%d) channel(%d): %v\n", order.ID, channel.ID, r)
-				}
-			}()
-			// 发起通知处理
-			s.NotifyOrder(ctx, channel.ID, unifiedResp)
-		}()
+		// 7.1 直接处理支付结果，钱包、条码等同步支付成功的渠道在此完成订单（兼容并发，失败时由回调或同步任务兜底）
+		if err := s.NotifyOrder(ctx, channel.ID, unifiedResp); err != nil {
+			fmt.Printf("[SubmitOrder][order(%d) channel(%d) 处理支付结果失败: %v]\n", order.ID, channel.ID, err)
+		}
 
 		// 7.2 检查渠道错误码并抛出异常
 		if unifiedResp.ChannelErrorCode != "" {
@@ -263,7 +317,7 @@ func (s *PayOrderService) ValidateOrderActuallyPaid(ctx context.Context, orderID
 	}
 
 	// 4. 获取支付客户端
-	payClient := s.clientFac.GetPayClient(ext.ChannelID)
+	payClient := s.channelSvc.GetPayClient(ext.ChannelID)
 	if payClient == nil {
 		// 如果客户端不存在，则无法查询，直接返回
 		return order, nil
@@ -293,9 +347,59 @@ func (s *PayOrderService) ValidateOrderActuallyPaid(ctx context.Context, orderID
 	return order, nil
 }
 
-func (s *PayOrderService) validateChannelCanSubmit(ctx context.Context, appId int64, channelCode string) (*pay.PayChannel, error) {
-	// app validation is implicit or done separately
-	return s.channelSvc.GetChannelByAppIdAndCode(ctx, appId, channelCode)
+// validateChannelCanSubmit 按路由规则获得可提交的支付渠道，按尝试顺序排列，至少包含一个渠道
+func (s *PayOrderService) validateChannelCanSubmit(ctx context.Context, order *pay.PayOrder, reqVO *pay2.PayOrderSubmitReq) ([]*pay.PayChannel, error) {
+	return s.routeSvc.RouteChannels(ctx, order.AppID, reqVO.ChannelCode,
+		order.Price-reqVO.WalletPrice, reqVO.Terminal, order.TenantID)
+}
+
+// closeFailoverOrderExtension 渠道下单失败切换渠道时，关闭本次的拓展单并解冻组合支付的钱包金额，支付订单保持待支付
+// 之后仍收到该拓展单的支付成功回调时，按重复支付处理
+func (s *PayOrderService) closeFailoverOrderExtension(ctx context.Context, ext *pay.PayOrderExtension, resp *client.OrderResp, failureMsg string) error {
+	if _, err := s.q.PayOrderExtension.WithContext(ctx).
+		Where(s.q.PayOrderExtension.ID.Eq(ext.ID), s.q.PayOrderExtension.Status.Eq(PayOrderStatusWaiting)).
+		Updates(map[string]interface{}{
+			"status":             PayOrderStatusClosed,
+			"channel_error_code": resp.ChannelErrorCode,
+			"channel_error_msg":  failureMsg,
+		}); err != nil {
+		return err
+	}
+	if ext.WalletPrice > 0 {
+		return s.settleOrderCombinePay(ctx, ext.OrderID)
+	}
+	return nil
+}
+
+// unifiedOrderFailureMsg 获得渠道下单失败的原因，下单成功时返回空
+func unifiedOrderFailureMsg(resp *client.OrderResp, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	if resp != nil && resp.ChannelErrorCode != "" && resp.Status != PayOrderStatusSuccess {
+		return fmt.Sprintf("[%s] %s", resp.ChannelErrorCode, resp.ChannelErrorMsg)
+	}
+	return ""
+}
+
+// markOrderProfitSharing 支付应用存在启用的分账规则时，标记订单需要分账，渠道下单时冻结资金用于分账
+func (s *PayOrderService) markOrderProfitSharing(ctx context.Context, order *pay.PayOrder) (bool, error) {
+	rule := s.q.PayProfitSharingRule
+	count, err := rule.WithContext(ctx).
+		Where(rule.AppID.Eq(order.AppID), rule.Status.Eq(consts.CommonStatusEnable)).
+		Count()
+	if err != nil {
+		return false, err
+	}
+	profitSharing := count > 0
+	if profitSharing != order.ProfitSharing {
+		if _, err := s.q.PayOrder.WithContext(ctx).Where(s.q.PayOrder.ID.Eq(order.ID)).
+			Update(s.q.PayOrder.ProfitSharing, profitSharing); err != nil {
+			return false, err
+		}
+		order.ProfitSharing = profitSharing
+	}
+	return profitSharing, nil
 }
 
 // genChannelOrderNotifyUrl 根据支付渠道生成回调地址
@@ -319,6 +423,7 @@ func (s *PayOrderService) GetOrderExtension(ctx context.Context, id int64) (*pay
 // 返回值：清理的过期订单数量
 func (s *PayOrderService) ExpireOrder(ctx context.Context) (int64, error) {
 	var expiredCount int64
+	var orderIDs []int64
 
 	err := s.q.Transaction(func(tx *query.Query) error {
 		now := time.Now()
@@ -341,7 +446,7 @@ func (s *PayOrderService) ExpireOrder(ctx context.Context) (int64, error) {
 		expiredCount = int64(len(expiredOrders))
 
 		// 2. 批量更新订单状态为关闭
-		orderIDs := make([]int64, 0, len(expiredOrders))
+		orderIDs = make([]int64, 0, len(expiredOrders))
 		for _, order := range expiredOrders {
 			orderIDs = append(orderIDs, order.ID)
 		}
@@ -367,8 +472,13 @@ func (s *PayOrderService) ExpireOrder(ctx context.Context) (int64, error) {
 
 		return err
 	})
+	if err != nil {
+		return expiredCount, err
+	}
 
-	return expiredCount, err
+	// 4. 解冻过期订单中组合支付的钱包金额
+	s.syncCombinePay(ctx, orderIDs)
+	return expiredCount, nil
 }
 
 // SyncOrderQuietly 同步订单的支付状态 (Quietly)
@@ -392,7 +502,7 @@ func (s *PayOrderService) SyncOrderQuietly(ctx context.Context, id int64) {
 // 对齐 Java: PayOrderServiceImpl.syncOrder(PayOrderExtensionDO)
 func (s *PayOrderService) syncOrder(ctx context.Context, orderExtension *pay.PayOrderExtension) bool {
 	// 1.1 查询支付订单信息
-	payClient := s.clientFac.GetPayClient(orderExtension.ChannelID)
+	payClient := s.channelSvc.GetPayClient(orderExtension.ChannelID)
 	if payClient == nil {
 		return false
 	}
@@ -427,7 +537,7 @@ func (s *PayOrderService) NotifyOrder(ctx context.Context, channelID int64, noti
 	}
 
 	// 使用 GORM 事务包装（对齐 Java @Transactional）
-	return s.q.Transaction(func(tx *query.Query) error {
+	err = s.q.Transaction(func(tx *query.Query) error {
 		switch notify.Status {
 		case PayOrderStatusSuccess:
 			// 情况一: 支付成功的回调
@@ -441,17 +551,34 @@ func (s *PayOrderService) NotifyOrder(ctx context.Context, channelID int64, noti
 			return nil
 		}
 	})
+	if err != nil {
+		return err
+	}
+
+	// 组合支付：事务提交后扣款或解冻钱包金额，失败时返回错误，由渠道回调重试或同步任务补偿
+	if err := s.settleCombinePay(ctx, notify); err != nil {
+		return err
+	}
+
+	// 重复支付：事务提交后自动退款，失败时返回错误，由渠道回调重试或同步任务补偿
+	return s.refundDuplicatePay(ctx, notify)
 }
 
 // notifyOrderSuccessTx 在事务内处理支付成功的回调
 func (s *PayOrderService) notifyOrderSuccessTx(ctx context.Context, tx *query.Query, channel *pay.PayChannel, notify *client.OrderResp) error {
 	// 1. 更新 PayOrderExtension 支付成功
-	orderExtension, err := s.updateOrderExtensionSuccessTx(ctx, tx, notify)
+	orderExtension, oldStatus, err := s.updateOrderExtensionSuccessTx(ctx, tx, notify)
 	if err != nil {
 		return err
 	}
 
-	// 2. 更新 PayOrder 支付成功
+	// 2. 重复支付：只记录拓展单支付成功，不更新 PayOrder，事务提交后自动退款
+	duplicate, err := s.isDuplicatePayTx(ctx, tx, orderExtension, oldStatus)
+	if err != nil || duplicate {
+		return err
+	}
+
+	// 3. 更新 PayOrder 支付成功
 	paid, err := s.updateOrderSuccessTx(ctx, tx, channel, orderExtension, notify)
 	if err != nil {
 		return err
@@ -461,47 +588,66 @@ func (s *PayOrderService) notifyOrderSuccessTx(ctx context.Context, tx *query.Qu
 		return nil
 	}
 
-	// 3. 插入支付通知记录
+	// 4. 插入支付通知记录
 	s.notifySvc.CreatePayNotifyTask(ctx, PayNotifyTypeOrder, orderExtension.OrderID)
 
 	return nil
 }
 
 // updateOrderExtensionSuccessTx 在事务内更新 PayOrderExtension 支付成功
-func (s *PayOrderService) updateOrderExtensionSuccessTx(ctx context.Context, tx *query.Query, notify *client.OrderResp) (*pay.PayOrderExtension, error) {
+// 返回值: 拓展单更新前的状态，已关闭的拓展单（如切换渠道时关闭）仍被支付时按重复支付处理
+func (s *PayOrderService) updateOrderExtensionSuccessTx(ctx context.Context, tx *query.Query, notify *client.OrderResp) (*pay.PayOrderExtension, int, error) {
 	// 1. 查询 PayOrderExtension
 	orderExtension, err := tx.PayOrderExtension.WithContext(ctx).
 		Where(tx.PayOrderExtension.No.Eq(notify.OutTradeNo)).
 		First()
 	if err != nil {
-		return nil, fmt.Errorf("支付订单拓展不存在")
+		return nil, 0, fmt.Errorf("支付订单拓展不存在")
 	}
 
 	// 如果已经是成功，直接返回，不用重复更新
 	if orderExtension.Status == PayOrderStatusSuccess {
-		return orderExtension, nil
+		return orderExtension, orderExtension.Status, nil
 	}
 
-	// 校验状态，必须是待支付
-	if orderExtension.Status != PayOrderStatusWaiting {
-		return nil, fmt.Errorf("支付订单拓展状态不是待支付")
+	// 校验状态，必须是待支付或已关闭
+	if orderExtension.Status != PayOrderStatusWaiting && orderExtension.Status != PayOrderStatusClosed {
+		return nil, 0, fmt.Errorf("支付订单拓展状态不是待支付")
 	}
 
 	// 2. 更新 PayOrderExtension (使用乐观锁)
 	notifyDataJSON, _ := json.Marshal(notify)
 	result, err := tx.PayOrderExtension.WithContext(ctx).
-		Where(tx.PayOrderExtension.ID.Eq(orderExtension.ID), tx.PayOrderExtension.Status.Eq(PayOrderStatusWaiting)).
+		Where(tx.PayOrderExtension.ID.Eq(orderExtension.ID), tx.PayOrderExtension.Status.Eq(orderExtension.Status)).
 		Updates(map[string]interface{}{
 			"status":              PayOrderStatusSuccess,
 			"channel_notify_data": string(notifyDataJSON),
 		})
 
 	if err != nil || result.RowsAffected == 0 {
-		return nil, fmt.Errorf("支付订单拓展状态不是待支付")
+		return nil, 0, fmt.Errorf("支付订单拓展状态已改变")
 	}
 
+	oldStatus := orderExtension.Status
 	orderExtension.Status = PayOrderStatusSuccess
-	return orderExtension, nil
+	return orderExtension, oldStatus, nil
+}
+
+// isDuplicatePayTx 在事务内判断拓展单的支付是否为重复支付
+// 拓展单此前已关闭，或支付订单已被其它拓展单支付、已关闭时，支付订单无法采用该拓展单；
+// 此前已成功的拓展单未被支付订单采用时，同样是已记录过的重复支付
+func (s *PayOrderService) isDuplicatePayTx(ctx context.Context, tx *query.Query, orderExtension *pay.PayOrderExtension, oldStatus int) (bool, error) {
+	if oldStatus == PayOrderStatusClosed {
+		return true, nil
+	}
+	order, err := tx.PayOrder.WithContext(ctx).
+		Where(tx.PayOrder.ID.Eq(orderExtension.OrderID)).
+		First()
+	if err != nil {
+		return false, fmt.Errorf("支付订单不存在")
+	}
+	return order.ExtensionID != orderExtension.ID &&
+		(oldStatus == PayOrderStatusSuccess || order.Status != PayOrderStatusWaiting), nil
 }
 
 // updateOrderSuccessTx 在事务内更新 PayOrder 支付成功
@@ -525,8 +671,8 @@ func (s *PayOrderService) updateOrderSuccessTx(ctx context.Context, tx *query.Qu
 		return false, fmt.Errorf("支付订单状态不是待支付")
 	}
 
-	// 2. 更新 PayOrder (使用乐观锁)
-	channelFeePrice := int(float64(order.Price) * channel.FeeRate / 100.0)
+	// 2. 更新 PayOrder (使用乐观锁)，组合支付的渠道手续费按渠道实付金额计算
+	channelFeePrice := int(float64(order.Price-orderExtension.WalletPrice) * channel.FeeRate / 100.0)
 	now := time.Now()
 
 	result, err := tx.PayOrder.WithContext(ctx).
@@ -542,6 +688,7 @@ func (s *PayOrderService) updateOrderSuccessTx(ctx context.Context, tx *query.Qu
 			"channel_user_id":   notify.ChannelUserID,
 			"channel_fee_rate":  channel.FeeRate,
 			"channel_fee_price": channelFeePrice,
+			"wallet_price":      orderExtension.WalletPrice,
 		})
 
 	if err != nil || result.RowsAffected == 0 {
@@ -709,9 +856,6 @@ func (s *PayOrderService) SyncOrder(ctx context.Context, minCreateTime time.Time
 	if err != nil {
 		return 0, err
 	}
-	if len(extensions) == 0 {
-		return 0, nil
-	}
 
 	// 2. 遍历执行
 	count := 0
@@ -720,5 +864,57 @@ func (s *PayOrderService) SyncOrder(ctx context.Context, minCreateTime time.Time
 			count++
 		}
 	}
+
+	// 3. 补偿结算钱包金额仍处于冻结的组合支付订单
+	if err := s.syncOrderCombinePay(ctx, minCreateTime); err != nil {
+		return count, err
+	}
+
+	// 4. 补偿退回重复支付的拓展单
+	if err := s.syncOrderDuplicatePay(ctx, minCreateTime); err != nil {
+		return count, err
+	}
 	return count, nil
 }
+
+// refundDuplicatePay 支付结果处理完成后，自动退回重复支付的拓展单
+func (s *PayOrderService) refundDuplicatePay(ctx context.Context, notify *client.OrderResp) error {
+	if notify.Status != PayOrderStatusSuccess {
+		return nil
+	}
+	orderExtension, err := s.q.PayOrderExtension.WithContext(ctx).
+		Where(s.q.PayOrderExtension.No.Eq(notify.OutTradeNo)).
+		First()
+	if err != nil {
+		return fmt.Errorf("支付订单拓展不存在")
+	}
+	return s.refundSvc.RefundDuplicatePay(ctx, orderExtension)
+}
+
+// syncOrderDuplicatePay 补偿退回指定时间之后创建、支付成功但未被支付订单采用的拓展单，失败时仅打印日志，等待下次补偿
+func (s *PayOrderService) syncOrderDuplicatePay(ctx context.Context, minCreateTime time.Time) error {
+	extensions, err := s.q.PayOrderExtension.WithContext(ctx).
+		Where(s.q.PayOrderExtension.Status.Eq(PayOrderStatusSuccess),
+			s.q.PayOrderExtension.CreateTime.Gte(minCreateTime)).
+		Find()
+	if err != nil || len(extensions) == 0 {
+		return err
+	}
+	orderIDs := make([]int64, 0, len(extensions))
+	for _, ext := range extensions {
+		orderIDs = append(orderIDs, ext.OrderID)
+	}
+	orders, err := s.GetOrderMap(ctx, orderIDs)
+	if err != nil {
+		return err
+	}
+	for _, ext := range extensions {
+		if order := orders[ext.OrderID]; order == nil || order.ExtensionID == ext.ID {
+			continue
+		}
+		if err := s.refundSvc.RefundDuplicatePay(ctx, ext); err != nil {
+			fmt.Printf("[syncOrderDuplicatePay][拓展单(%d) 重复支付退款失败: %v]\n", ext.ID, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package pay
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	pay2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/pay"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model/pay"
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
+)
+
+// 组合支付：订单的一部分金额由钱包支付，其余金额由外部渠道支付
+// 提交时冻结钱包金额，渠道支付成功后扣款，渠道支付关闭或订单过期后解冻；
+// 钱包变动均在支付结果事务提交之后进行，失败时由渠道回调重试或同步任务补偿
+
+var (
+	ErrPayOrderWalletPriceInvalid   = errors.NewBizError(1006001010, "钱包支付金额必须小于订单金额")     // PAY_ORDER_WALLET_PRICE_INVALID
+	ErrPayOrderWalletChannelInvalid = errors.NewBizError(1006001011, "钱包渠道不支持组合支付")        // PAY_ORDER_WALLET_CHANNEL_INVALID
+	ErrPayOrderWalletChannelDisable = errors.NewBizError(1006001012, "支付应用未开启钱包渠道，无法组合支付") // PAY_ORDER_WALLET_CHANNEL_DISABLE
+	ErrPayOrderWalletNotFound       = errors.NewBizError(1006001013, "组合支付的钱包不存在")         // PAY_ORDER_WALLET_NOT_FOUND
+)
+
+// validateCombinePay 校验组合支付，未使用钱包时返回 nil
+func (s *PayOrderService) validateCombinePay(ctx context.Context, order *pay.PayOrder, channel *pay.PayChannel, reqVO *pay2.PayOrderSubmitReq) (client.CombinePayClient, int64, error) {
+	if reqVO.WalletPrice <= 0 {
+		return nil, 0, nil
+	}
+	if channel.Code == consts.PayChannelWallet {
+		return nil, 0, ErrPayOrderWalletChannelInvalid
+	}
+	if reqVO.WalletPrice >= order.Price {
+		return nil, 0, ErrPayOrderWalletPriceInvalid
+	}
+	walletID, _ := strconv.ParseInt(reqVO.ChannelExtras[consts.PayChannelExtrasWalletID], 10, 64)
+	if walletID <= 0 {
+		return nil, 0, ErrPayOrderWalletNotFound
+	}
+	walletChannel, combineClient := s.channelSvc.GetCombinePayClient(ctx, order.AppID)
+	if combineClient == nil || walletChannel.Status != consts.CommonStatusEnable {
+		return nil, 0, ErrPayOrderWalletChannelDisable
+	}
+	return combineClient, walletID, nil
+}
+
+// settleCombinePay 支付结果处理完成后，结算支付订单拓展所属订单的冻结钱包金额
+func (s *PayOrderService) settleCombinePay(ctx context.Context, notify *client.OrderResp) error {
+	if notify.Status != PayOrderStatusSuccess && notify.Status != PayOrderStatusClosed {
+		return nil
+	}
+	orderExtension, err := s.q.PayOrderExtension.WithContext(ctx).
+		Where(s.q.PayOrderExtension.No.Eq(notify.OutTradeNo)).
+		First()
+	if err != nil {
+		return fmt.Errorf("支付订单拓展不存在")
+	}
+	if orderExtension.WalletPrice <= 0 {
+		return nil
+	}
+	return s.settleOrderCombinePay(ctx, orderExtension.OrderID)
+}
+
+// settleOrderCombinePay 结算订单下冻结中的钱包金额
+// 订单最终采用的拓展单扣款；拓展单已关闭或被重复支付，或订单已被其它拓展单支付、已关闭时解冻；其余继续冻结
+func (s *PayOrderService) settleOrderCombinePay(ctx context.Context, orderID int64) error {
+	order, err := s.GetOrder(ctx, orderID)
+	if err != nil {
+		return fmt.Errorf("支付订单不存在")
+	}
+	extensions, err := s.q.PayOrderExtension.WithContext(ctx).
+		Where(s.q.PayOrderExtension.OrderID.Eq(orderID),
+			s.q.PayOrderExtension.WalletStatus.Eq(consts.PayOrderWalletStatusFrozen)).
+		Find()
+	if err != nil || len(extensions) == 0 {
+		return err
+	}
+	_, combineClient := s.channelSvc.GetCombinePayClient(ctx, order.AppID)
+	if combineClient == nil {
+		return fmt.Errorf("支付应用(%d) 找不到钱包渠道的支付客户端", order.AppID)
+	}
+
+	for _, ext := range extensions {
+		switch {
+		case ext.Status == PayOrderStatusSuccess && order.ExtensionID == ext.ID:
+			err = combineClient.ConfirmCombinePay(ctx, ext.No)
+		case ext.Status != PayOrderStatusWaiting || order.Status != PayOrderStatusWaiting:
+			err = combineClient.UnfreezeCombinePay(ctx, ext.No)
+		default:
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("支付订单拓展(%d) 结算钱包金额失败: %w", ext.ID, err)
+		}
+	}
+	return nil
+}
+
+// syncCombinePay 补偿结算订单中冻结的钱包金额，失败时仅打印日志，等待下次补偿
+func (s *PayOrderService) syncCombinePay(ctx context.Context, orderIDs []int64) {
+	for _, orderID := range orderIDs {
+		if err := s.settleOrderCombinePay(ctx, orderID); err != nil {
+			fmt.Printf("[syncCombinePay][order(%d) 结算钱包金额失败: %v]\n", orderID, err)
+		}
+	}
+}
+
+// syncOrderCombinePay 补偿结算指定时间之后创建、钱包金额仍处于冻结的组合支付订单
+func (s *PayOrderService) syncOrderCombinePay(ctx context.Context, minCreateTime time.Time) error {
+	var orderIDs []int64
+	err := s.q.PayOrderExtension.WithContext(ctx).
+		Where(s.q.PayOrderExtension.WalletStatus.Eq(consts.PayOrderWalletStatusFrozen),
+			s.q.PayOrderExtension.CreateTime.Gte(minCreateTime)).
+		Distinct(s.q.PayOrderExtension.OrderID).
+		Pluck(s.q.PayOrderExtension.OrderID, &orderIDs)
+	if err != nil {
+		return err
+	}
+	s.syncCombinePay(ctx, orderIDs)
+	return nil
+}
@@ -0,0 +1,426 @@
+package pay
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+
+	pay2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/pay"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model/pay"
+	payrepo "github.com/wxlbd/ruoyi-mall-go/internal/repo/pay"
+	"github.com/wxlbd/ruoyi-mall-go/internal/repo/query"
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrProfitSharingReceiverNotFound        = errors.NewBizError(1007012000, "分账接收方不存在")                 // PROFIT_SHARING_RECEIVER_NOT_FOUND
+	ErrProfitSharingReceiverExists          = errors.NewBizError(1007012001, "分账接收方已存在")                 // PROFIT_SHARING_RECEIVER_EXISTS
+	ErrProfitSharingReceiverTypeInvalid     = errors.NewBizError(1007012002, "分账接收方类型不正确")               // PROFIT_SHARING_RECEIVER_TYPE_INVALID
+	ErrProfitSharingReceiverChannelNotFound = errors.NewBizError(1007012003, "支付应用没有支持该接收方类型的分账渠道")      // PROFIT_SHARING_RECEIVER_CHANNEL_NOT_FOUND
+	ErrProfitSharingReceiverUsed            = errors.NewBizError(1007012004, "分账接收方正在被分账规则使用，无法删除")      // PROFIT_SHARING_RECEIVER_USED
+	ErrProfitSharingRuleNotFound            = errors.NewBizError(1007012005, "分账规则不存在")                  // PROFIT_SHARING_RULE_NOT_FOUND
+	ErrProfitSharingRuleEnableExists        = errors.NewBizError(1007012006, "支付应用已存在启用的分账规则")           // PROFIT_SHARING_RULE_ENABLE_EXISTS
+	ErrProfitSharingRuleReceiverInvalid     = errors.NewBizError(1007012007, "分账规则的接收方不存在、已禁用或不属于该支付应用") // PROFIT_SHARING_RULE_RECEIVER_INVALID
+	ErrProfitSharingRuleReceiverDuplicate   = errors.NewBizError(1007012008, "分账规则的接收方重复")               // PROFIT_SHARING_RULE_RECEIVER_DUPLICATE
+	ErrProfitSharingRuleRatioExceed         = errors.NewBizError(1007012009, "分账比例之和不能超过 100%")          // PROFIT_SHARING_RULE_RATIO_EXCEED
+	ErrProfitSharingOrderNotFound           = errors.NewBizError(1007012010, "分账单不存在")                   // PROFIT_SHARING_ORDER_NOT_FOUND
+	ErrProfitSharingOrderRetryStatusInvalid = errors.NewBizError(1007012011, "只有分账失败的分账单可以重试")           // PROFIT_SHARING_ORDER_RETRY_STATUS_INVALID
+)
+
+// PayProfitSharingService 支付分账服务
+// 支付应用启用分账规则后，提交的支付订单在支付成功后按规则生成分账单，由定时任务调用渠道分账；
+// 支付渠道不支持的接收方（钱包接收方）通过应用的钱包渠道入账。订单退款后按比例从接收方回退分账金额
+type PayProfitSharingService struct {
+	q          *query.Query
+	appSvc     *PayAppService
+	channelSvc *PayChannelService
+	noDAO      *payrepo.PayNoRedisDAO
+}
+
+func NewPayProfitSharingService(q *query.Query, appSvc *PayAppService, channelSvc *PayChannelService, noDAO *payrepo.PayNoRedisDAO) *PayProfitSharingService {
+	return &PayProfitSharingService{
+		q:          q,
+		appSvc:     appSvc,
+		channelSvc: channelSvc,
+		noDAO:      noDAO,
+	}
+}
+
+// ========== 分账接收方 ==========
+
+// CreateReceiver 创建分账接收方，同时添加到应用下对应的支付渠道
+func (s *PayProfitSharingService) CreateReceiver(ctx context.Context, req *pay2.PayProfitSharingReceiverCreateReq) (int64, error) {
+	if _, err := s.appSvc.ValidPayApp(ctx, req.AppID); err != nil {
+		return 0, err
+	}
+	if !isProfitSharingReceiverTypeValid(req.Type) {
+		return 0, ErrProfitSharingReceiverTypeInvalid
+	}
+	q := s.q.PayProfitSharingReceiver
+	count, err := q.WithContext(ctx).
+		Where(q.AppID.Eq(req.AppID), q.Type.Eq(req.Type), q.Account.Eq(req.Account)).
+		Count()
+	if err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		return 0, ErrProfitSharingReceiverExists
+	}
+
+	receiver := &pay.PayProfitSharingReceiver{
+		AppID:        req.AppID,
+		Name:         req.Name,
+		Type:         req.Type,
+		Account:      req.Account,
+		RealName:     req.RealName,
+		RelationType: req.RelationType,
+		Status:       *req.Status,
+		Remark:       req.Remark,
+	}
+	if err := s.bindReceiver(ctx, receiver); err != nil {
+		return 0, err
+	}
+	if err := q.WithContext(ctx).Create(receiver); err != nil {
+		return 0, err
+	}
+	return receiver.ID, nil
+}
+
+// UpdateReceiver 更新分账接收方，启用状态下重新添加到支付渠道
+func (s *PayProfitSharingService) UpdateReceiver(ctx context.Context, req *pay2.PayProfitSharingReceiverUpdateReq) error {
+	receiver, err := s.validateReceiverExists(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+	receiver.Name = req.Name
+	receiver.RealName = req.RealName
+	receiver.RelationType = req.RelationType
+	receiver.Status = *req.Status
+	receiver.Remark = req.Remark
+	if receiver.Status == consts.CommonStatusEnable {
+		if err := s.bindReceiver(ctx, receiver); err != nil {
+			return err
+		}
+	}
+	q := s.q.PayProfitSharingReceiver
+	_, err = q.WithContext(ctx).Where(q.ID.Eq(req.ID)).Updates(map[string]interface{}{
+		"name":          receiver.Name,
+		"real_name":     receiver.RealName,
+		"relation_type": receiver.RelationType,
+		"status":        receiver.Status,
+		"remark":        receiver.Remark,
+	})
+	return err
+}
+
+// DeleteReceiver 删除分账接收方，被分账规则使用时不允许删除
+func (s *PayProfitSharingService) DeleteReceiver(ctx context.Context, id int64) error {
+	receiver, err := s.validateReceiverExists(ctx, id)
+	if err != nil {
+		return err
+	}
+	rules, err := s.q.PayProfitSharingRule.WithContext(ctx).
+		Where(s.q.PayProfitSharingRule.AppID.Eq(receiver.AppID)).
+		Find()
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		for _, ruleReceiver := range rule.Receivers {
+			if ruleReceiver.ReceiverID == id {
+				return ErrProfitSharingReceiverUsed
+			}
+		}
+	}
+	_, err = s.q.PayProfitSharingReceiver.WithContext(ctx).Where(s.q.PayProfitSharingReceiver.ID.Eq(id)).Delete()
+	return err
+}
+
+// GetReceiver 获得分账接收方
+func (s *PayProfitSharingService) GetReceiver(ctx context.Context, id int64) (*pay.PayProfitSharingReceiver, error) {
+	return s.validateReceiverExists(ctx, id)
+}
+
+// GetReceiverMap 获得分账接收方 Map
+func (s *PayProfitSharingService) GetReceiverMap(ctx context.Context, ids []int64) (map[int64]*pay.PayProfitSharingReceiver, error) {
+	receiverMap := make(map[int64]*pay.PayProfitSharingReceiver, len(ids))
+	if len(ids) == 0 {
+		return receiverMap, nil
+	}
+	list, err := s.q.PayProfitSharingReceiver.WithContext(ctx).Where(s.q.PayProfitSharingReceiver.ID.In(ids...)).Find()
+	if err != nil {
+		return nil, err
+	}
+	for _, receiver := range list {
+		receiverMap[receiver.ID] = receiver
+	}
+	return receiverMap, nil
+}
+
+// GetReceiverPage 获得分账接收方分页
+func (s *PayProfitSharingService) GetReceiverPage(ctx context.Context, req *pay2.PayProfitSharingReceiverPageReq) (*pagination.PageResult[*pay.PayProfitSharingReceiver], error) {
+	q := s.q.PayProfitSharingReceiver
+	do := q.WithContext(ctx)
+	if req.AppID > 0 {
+		do = do.Where(q.AppID.Eq(req.AppID))
+	}
+	if req.Name != "" {
+		do = do.Where(q.Name.Like("%" + req.Name + "%"))
+	}
+	if req.Type != nil {
+		do = do.Where(q.Type.Eq(*req.Type))
+	}
+	if req.Account != "" {
+		do = do.Where(q.Account.Eq(req.Account))
+	}
+	if req.Status != nil {
+		do = do.Where(q.Status.Eq(*req.Status))
+	}
+
+	total, err := do.Count()
+	if err != nil {
+		return nil, err
+	}
+	list, err := do.Limit(req.GetLimit()).Offset(req.GetOffset()).Order(q.ID.Desc()).Find()
+	if err != nil {
+		return nil, err
+	}
+	return &pagination.PageResult[*pay.PayProfitSharingReceiver]{
+		List:  list,
+		Total: total,
+	}, nil
+}
+
+func (s *PayProfitSharingService) validateReceiverExists(ctx context.Context, id int64) (*pay.PayProfitSharingReceiver, error) {
+	receiver, err := s.q.PayProfitSharingReceiver.WithContext(ctx).Where(s.q.PayProfitSharingReceiver.ID.Eq(id)).First()
+	if err != nil {
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProfitSharingReceiverNotFound
+		}
+		return nil, err
+	}
+	return receiver, nil
+}
+
+// bindReceiver 将接收方添加到应用下所有支持该接收方类型的已启用渠道，钱包接收方由钱包渠道校验钱包是否存在
+func (s *PayProfitSharingService) bindReceiver(ctx context.Context, receiver *pay.PayProfitSharingReceiver) error {
+	channels, err := s.channelSvc.GetEnableChannelList(ctx, receiver.AppID)
+	if err != nil {
+		return err
+	}
+	req := toProfitSharingReceiverReq(receiver)
+	bound := false
+	for _, channel := range channels {
+		if receiver.Type == consts.PayProfitSharingReceiverTypeWallet {
+			if channel.Code != consts.PayChannelWallet {
+				continue
+			}
+		} else if !consts.IsPayProfitSharingReceiverMatchChannel(receiver.Type, channel.Code) {
+			continue
+		}
+		sharingClient, ok := s.channelSvc.GetPayClient(channel.ID).(client.ProfitSharingClient)
+		if !ok {
+			continue
+		}
+		if err := sharingClient.AddProfitSharingReceiver(ctx, req); err != nil {
+			return errors.NewBizError(1007012012, fmt.Sprintf("添加分账接收方到渠道(%s)失败：%v", channel.Code, err)) // PROFIT_SHARING_RECEIVER_BIND_FAIL
+		}
+		bound = true
+	}
+	if !bound {
+		return ErrProfitSharingReceiverChannelNotFound
+	}
+	return nil
+}
+
+// ========== 分账规则 ==========
+
+// CreateRule 创建分账规则
+func (s *PayProfitSharingService) CreateRule(ctx context.Context, req *pay2.PayProfitSharingRuleCreateReq) (int64, error) {
+	if _, err := s.appSvc.ValidPayApp(ctx, req.AppID); err != nil {
+		return 0, err
+	}
+	receivers, err := s.validateRuleReceivers(ctx, req.AppID, req.Receivers)
+	if err != nil {
+		return 0, err
+	}
+	if *req.Status == consts.CommonStatusEnable {
+		if err := s.validateRuleEnableUnique(ctx, req.AppID, 0); err != nil {
+			return 0, err
+		}
+	}
+
+	rule := &pay.PayProfitSharingRule{
+		AppID:     req.AppID,
+		Name:      req.Name,
+		Receivers: receivers,
+		Status:    *req.Status,
+		Remark:    req.Remark,
+	}
+	if err := s.q.PayProfitSharingRule.WithContext(ctx).Create(rule); err != nil {
+		return 0, err
+	}
+	return rule.ID, nil
+}
+
+// UpdateRule 更新分账规则，已生成的分账单不受影响
+func (s *PayProfitSharingService) UpdateRule(ctx context.Context, req *pay2.PayProfitSharingRuleUpdateReq) error {
+	rule, err := s.validateRuleExists(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+	receivers, err := s.validateRuleReceivers(ctx, rule.AppID, req.Receivers)
+	if err != nil {
+		return err
+	}
+	if *req.Status == consts.CommonStatusEnable {
+		if err := s.validateRuleEnableUnique(ctx, rule.AppID, rule.ID); err != nil {
+			return err
+		}
+	}
+
+	rule.Name = req.Name
+	rule.Receivers = receivers
+	rule.Status = *req.Status
+	rule.Remark = req.Remark
+	q := s.q.PayProfitSharingRule
+	_, err = q.WithContext(ctx).Where(q.ID.Eq(rule.ID)).
+		Select(q.Name, q.Receivers, q.Status, q.Remark).
+		Updates(rule)
+	return err
+}
+
+// DeleteRule 删除分账规则
+func (s *PayProfitSharingService) DeleteRule(ctx context.Context, id int64) error {
+	if _, err := s.validateRuleExists(ctx, id); err != nil {
+		return err
+	}
+	_, err := s.q.PayProfitSharingRule.WithContext(ctx).Where(s.q.PayProfitSharingRule.ID.Eq(id)).Delete()
+	return err
+}
+
+// GetRule 获得分账规则
+func (s *PayProfitSharingService) GetRule(ctx context.Context, id int64) (*pay.PayProfitSharingRule, error) {
+	return s.validateRuleExists(ctx, id)
+}
+
+// GetRulePage 获得分账规则分页
+func (s *PayProfitSharingService) GetRulePage(ctx context.Context, req *pay2.PayProfitSharingRulePageReq) (*pagination.PageResult[*pay.PayProfitSharingRule], error) {
+	q := s.q.PayProfitSharingRule
+	do := q.WithContext(ctx)
+	if req.AppID > 0 {
+		do = do.Where(q.AppID.Eq(req.AppID))
+	}
+	if req.Name != "" {
+		do = do.Where(q.Name.Like("%" + req.Name + "%"))
+	}
+	if req.Status != nil {
+		do = do.Where(q.Status.Eq(*req.Status))
+	}
+
+	total, err := do.Count()
+	if err != nil {
+		return nil, err
+	}
+	list, err := do.Limit(req.GetLimit()).Offset(req.GetOffset()).Order(q.ID.Desc()).Find()
+	if err != nil {
+		return nil, err
+	}
+	return &pagination.PageResult[*pay.PayProfitSharingRule]{
+		List:  list,
+		Total: total,
+	}, nil
+}
+
+// getEnableRule 获得支付应用启用的分账规则，不存在时返回 nil
+func (s *PayProfitSharingService) getEnableRule(ctx context.Context, appID int64) (*pay.PayProfitSharingRule, error) {
+	q := s.q.PayProfitSharingRule
+	rule, err := q.WithContext(ctx).Where(q.AppID.Eq(appID), q.Status.Eq(consts.CommonStatusEnable)).First()
+	if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return rule, err
+}
+
+func (s *PayProfitSharingService) validateRuleExists(ctx context.Context, id int64) (*pay.PayProfitSharingRule, error) {
+	rule, err := s.q.PayProfitSharingRule.WithContext(ctx).Where(s.q.PayProfitSharingRule.ID.Eq(id)).First()
+	if err != nil {
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProfitSharingRuleNotFound
+		}
+		return nil, err
+	}
+	return rule, nil
+}
+
+// validateRuleEnableUnique 校验支付应用只有一条启用的分账规则
+func (s *PayProfitSharingService) validateRuleEnableUnique(ctx context.Context, appID, excludeID int64) error {
+	rule, err := s.getEnableRule(ctx, appID)
+	if err != nil {
+		return err
+	}
+	if rule != nil && rule.ID != excludeID {
+		return ErrProfitSharingRuleEnableExists
+	}
+	return nil
+}
+
+// validateRuleReceivers 校验分账规则的接收方均为该应用下启用的接收方，且分账比例之和不超过 100%
+func (s *PayProfitSharingService) validateRuleReceivers(ctx context.Context, appID int64, reqs []*pay2.PayProfitSharingRuleReceiverReq) ([]*pay.PayProfitSharingRuleReceiver, error) {
+	ids := make([]int64, 0, len(reqs))
+	totalRatio := 0
+	for _, req := range reqs {
+		ids = append(ids, req.ReceiverID)
+		totalRatio += req.Ratio
+	}
+	if totalRatio > consts.PayProfitSharingRatioBase {
+		return nil, ErrProfitSharingRuleRatioExceed
+	}
+	receiverMap, err := s.GetReceiverMap(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	receivers := make([]*pay.PayProfitSharingRuleReceiver, 0, len(reqs))
+	seen := make(map[int64]bool, len(reqs))
+	for _, req := range reqs {
+		if seen[req.ReceiverID] {
+			return nil, ErrProfitSharingRuleReceiverDuplicate
+		}
+		seen[req.ReceiverID] = true
+		receiver, ok := receiverMap[req.ReceiverID]
+		if !ok || receiver.AppID != appID || receiver.Status != consts.CommonStatusEnable {
+			return nil, ErrProfitSharingRuleReceiverInvalid
+		}
+		receivers = append(receivers, &pay.PayProfitSharingRuleReceiver{
+			ReceiverID: req.ReceiverID,
+			Ratio:      req.Ratio,
+		})
+	}
+	return receivers, nil
+}
+
+func isProfitSharingReceiverTypeValid(receiverType int) bool {
+	switch receiverType {
+	case consts.PayProfitSharingReceiverTypeWallet,
+		consts.PayProfitSharingReceiverTypeWxMerchant,
+		consts.PayProfitSharingReceiverTypeWxPersonal,
+		consts.PayProfitSharingReceiverTypeAlipayUser:
+		return true
+	}
+	return false
+}
+
+func toProfitSharingReceiverReq(receiver *pay.PayProfitSharingReceiver) *client.ProfitSharingReceiverReq {
+	return &client.ProfitSharingReceiverReq{
+		Type:         receiver.Type,
+		Account:      receiver.Account,
+		RealName:     receiver.RealName,
+		RelationType: receiver.RelationType,
+	}
+}
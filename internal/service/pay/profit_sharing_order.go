@@ -0,0 +1,859 @@
+package pay
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	pay2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/pay"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model/pay"
+	"github.com/wxlbd/ruoyi-mall-go/internal/repo/query"
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
+
+	"gorm.io/gorm"
+)
+
+const (
+	profitSharingNoPrefix       = "S"
+	profitSharingReturnNoPrefix = "SR"
+	// profitSharingDelay 支付成功后延迟分账的时间，微信要求支付成功一段时间后才能请求分账
+	profitSharingDelay = 2 * time.Minute
+	// profitSharingBatchSize 定时任务单次处理的分账单数量
+	profitSharingBatchSize = 100
+)
+
+// ExecuteProfitSharing 执行分账：生成分账单、调用渠道分账、同步分账结果，并为退款的订单回退分账
+// 返回本次处理完结（成功或失败）的分账单与回退单数量
+func (s *PayProfitSharingService) ExecuteProfitSharing(ctx context.Context) (int, error) {
+	if _, err := s.CreateSharingOrders(ctx); err != nil {
+		return 0, err
+	}
+	count, err := s.ExecuteSharingOrders(ctx)
+	if err != nil {
+		return count, err
+	}
+	syncCount, err := s.SyncSharingOrders(ctx)
+	count += syncCount
+	if err != nil {
+		return count, err
+	}
+	if _, err := s.CreateSharingReturns(ctx); err != nil {
+		return count, err
+	}
+	returnCount, err := s.ExecuteSharingReturns(ctx)
+	count += returnCount
+	return count, err
+}
+
+// ========== 分账单 ==========
+
+// CreateSharingOrders 为支付成功且需要分账的订单生成分账单，返回生成的数量
+func (s *PayProfitSharingService) CreateSharingOrders(ctx context.Context) (int, error) {
+	o := s.q.PayOrder
+	sharedOrderIDs := s.q.PayProfitSharingOrder.WithContext(ctx).Select(s.q.PayProfitSharingOrder.OrderID)
+	orders, err := o.WithContext(ctx).
+		Where(o.ProfitSharing.Is(true),
+			o.Status.In(PayOrderStatusSuccess, PayOrderStatusRefund),
+			o.Price.GtCol(o.RefundPrice),
+			o.SuccessTime.Lte(time.Now().Add(-profitSharingDelay))).
+		Where(o.Columns(o.ID).NotIn(sharedOrderIDs)).
+		Limit(profitSharingBatchSize).
+		Find()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, order := range orders {
+		if err := s.createSharingOrder(ctx, order); err != nil {
+			fmt.Printf("[CreateSharingOrders][order(%d) 生成分账单失败: %v]\n", order.ID, err)
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+// createSharingOrder 按应用当前启用的分账规则生成分账单
+// 规则已停用时仍生成没有明细的分账单，用于完结分账，解冻渠道冻结的订单资金
+func (s *PayProfitSharingService) createSharingOrder(ctx context.Context, order *pay.PayOrder) error {
+	rule, err := s.getEnableRule(ctx, order.AppID)
+	if err != nil {
+		return err
+	}
+	no, err := s.noDAO.Generate(ctx, profitSharingNoPrefix)
+	if err != nil {
+		return err
+	}
+
+	sharingOrder := &pay.PayProfitSharingOrder{
+		No:             no,
+		AppID:          order.AppID,
+		OrderID:        order.ID,
+		ChannelID:      order.ChannelID,
+		ChannelCode:    order.ChannelCode,
+		OutTradeNo:     order.No,
+		ChannelOrderNo: order.ChannelOrderNo,
+		Price:          order.Price - order.RefundPrice,
+		RefundPrice:    order.RefundPrice,
+		Status:         consts.PayProfitSharingStatusWaiting,
+	}
+	sharingOrder.TenantID = order.TenantID
+
+	var items []*pay.PayProfitSharingOrderItem
+	if rule != nil {
+		// 渠道接收方从渠道实付金额中分账，钱包接收方从订单金额中分账
+		channelPrice, err := s.getChannelSharingPrice(ctx, order)
+		if err != nil {
+			return err
+		}
+		sharingOrder.RuleID = rule.ID
+		ids := make([]int64, 0, len(rule.Receivers))
+		for _, ruleReceiver := range rule.Receivers {
+			ids = append(ids, ruleReceiver.ReceiverID)
+		}
+		receiverMap, err := s.GetReceiverMap(ctx, ids)
+		if err != nil {
+			return err
+		}
+		for _, ruleReceiver := range rule.Receivers {
+			receiver, ok := receiverMap[ruleReceiver.ReceiverID]
+			if !ok || receiver.Status != consts.CommonStatusEnable ||
+				!consts.IsPayProfitSharingReceiverMatchChannel(receiver.Type, order.ChannelCode) {
+				continue
+			}
+			base := channelPrice
+			if receiver.Type == consts.PayProfitSharingReceiverTypeWallet {
+				base = sharingOrder.Price
+			}
+			price := int(int64(base) * int64(ruleReceiver.Ratio) / consts.PayProfitSharingRatioBase)
+			if price <= 0 {
+				continue
+			}
+			item := &pay.PayProfitSharingOrderItem{
+				OrderID:      order.ID,
+				OutSharingNo: no,
+				ReceiverID:   receiver.ID,
+				ReceiverType: receiver.Type,
+				Account:      receiver.Account,
+				Name:         receiver.Name,
+				Ratio:        ruleReceiver.Ratio,
+				Price:        price,
+				Status:       consts.PayProfitSharingStatusWaiting,
+			}
+			item.TenantID = order.TenantID
+			items = append(items, item)
+			sharingOrder.SharingPrice += price
+		}
+	}
+
+	return s.q.Transaction(func(tx *query.Query) error {
+		if err := tx.PayProfitSharingOrder.WithContext(ctx).Create(sharingOrder); err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		for _, item := range items {
+			item.SharingOrderID = sharingOrder.ID
+		}
+		return tx.PayProfitSharingOrderItem.WithContext(ctx).Create(items...)
+	})
+}
+
+// getChannelSharingPrice 获得渠道实付金额中未退款的部分：组合支付扣除钱包支付的金额，已退款金额扣除退回钱包的部分
+func (s *PayProfitSharingService) getChannelSharingPrice(ctx context.Context, order *pay.PayOrder) (int, error) {
+	if order.WalletPrice <= 0 {
+		return order.Price - order.RefundPrice, nil
+	}
+	var walletRefundPrice int
+	r := s.q.PayRefund
+	err := r.WithContext(ctx).
+		Select(r.WalletRefundPrice.Sum().IfNull(0)).
+		Where(r.OrderID.Eq(order.ID), r.Status.Eq(consts.PayRefundStatusSuccess)).
+		Scan(&walletRefundPrice)
+	if err != nil {
+		return 0, err
+	}
+	return order.Price - order.WalletPrice - (order.RefundPrice - walletRefundPrice), nil
+}
+
+// ExecuteSharingOrders 对等待分账的分账单发起分账，返回完结的数量
+func (s *PayProfitSharingService) ExecuteSharingOrders(ctx context.Context) (int, error) {
+	q := s.q.PayProfitSharingOrder
+	list, err := q.WithContext(ctx).
+		Where(q.Status.Eq(consts.PayProfitSharingStatusWaiting)).
+		Limit(profitSharingBatchSize).
+		Find()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, sharingOrder := range list {
+		finished, err := s.executeSharingOrder(ctx, sharingOrder)
+		if err != nil {
+			fmt.Printf("[ExecuteSharingOrders][sharingOrder(%d) 分账失败: %v]\n", sharingOrder.ID, err)
+			continue
+		}
+		if finished {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// executeSharingOrder 对分账单中等待分账的明细发起分账：渠道接收方通过订单的支付渠道分账，钱包接收方通过钱包渠道入账
+// 分账单没有渠道明细时也会请求一次支付渠道，用于完结分账
+func (s *PayProfitSharingService) executeSharingOrder(ctx context.Context, sharingOrder *pay.PayProfitSharingOrder) (bool, error) {
+	// 1. 订单已全额退款时，无需分账
+	order, err := s.q.PayOrder.WithContext(ctx).Where(s.q.PayOrder.ID.Eq(sharingOrder.OrderID)).First()
+	if err != nil {
+		return false, err
+	}
+	if order.RefundPrice >= order.Price {
+		return true, s.closeSharingOrder(ctx, sharingOrder, "支付订单已全额退款")
+	}
+
+	// 2. 按接收方分组，分别请求分账
+	items, err := s.getSharingOrderItems(ctx, sharingOrder.ID)
+	if err != nil {
+		return false, err
+	}
+	var channelItems, walletItems []*pay.PayProfitSharingOrderItem
+	for _, item := range items {
+		if item.Status != consts.PayProfitSharingStatusWaiting {
+			continue
+		}
+		if item.ReceiverType == consts.PayProfitSharingReceiverTypeWallet {
+			walletItems = append(walletItems, item)
+		} else {
+			channelItems = append(channelItems, item)
+		}
+	}
+
+	// 重试时渠道明细可能均已完结，此时无需再请求渠道
+	channelStatus := consts.PayProfitSharingStatusSuccess
+	if len(channelItems) > 0 || !hasChannelSharingItems(items) {
+		if channelClient, ok := s.channelSvc.GetPayClient(sharingOrder.ChannelID).(client.ProfitSharingClient); ok {
+			resp, err := channelClient.UnifiedProfitSharing(ctx, s.buildSharingReq(sharingOrder, channelItems))
+			if err != nil {
+				fmt.Printf("[executeSharingOrder][sharingOrder(%d) 调用渠道分账异常，等待同步: %v]\n", sharingOrder.ID, err)
+			}
+			channelStatus = s.applySharingResp(sharingOrder, channelItems, resp, err)
+		} else {
+			s.failSharingItems(channelItems, "支付渠道不支持分账")
+		}
+	}
+	if len(walletItems) > 0 {
+		if walletClient := s.getWalletSharingClient(ctx, sharingOrder.AppID); walletClient != nil {
+			resp, err := walletClient.UnifiedProfitSharing(ctx, s.buildSharingReq(sharingOrder, walletItems))
+			s.applySharingResp(sharingOrder, walletItems, resp, err)
+		} else {
+			s.failSharingItems(walletItems, "支付应用未开启钱包渠道")
+		}
+	}
+
+	// 3. 更新分账明细与分账单
+	if err := s.updateSharingItems(ctx, append(channelItems, walletItems...)); err != nil {
+		return false, err
+	}
+	return s.updateSharingOrderStatus(ctx, sharingOrder, items, channelStatus)
+}
+
+// SyncSharingOrders 同步分账中的分账单结果，返回完结的数量
+func (s *PayProfitSharingService) SyncSharingOrders(ctx context.Context) (int, error) {
+	q := s.q.PayProfitSharingOrder
+	list, err := q.WithContext(ctx).
+		Where(q.Status.Eq(consts.PayProfitSharingStatusProcessing)).
+		Limit(profitSharingBatchSize).
+		Find()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, sharingOrder := range list {
+		finished, err := s.syncSharingOrder(ctx, sharingOrder)
+		if err != nil {
+			fmt.Printf("[SyncSharingOrders][sharingOrder(%d) 同步分账结果失败: %v]\n", sharingOrder.ID, err)
+			continue
+		}
+		if finished {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// syncSharingOrder 按分账请求单号查询分账中的明细；分账单没有渠道明细时，查询完结分账的结果
+func (s *PayProfitSharingService) syncSharingOrder(ctx context.Context, sharingOrder *pay.PayProfitSharingOrder) (bool, error) {
+	items, err := s.getSharingOrderItems(ctx, sharingOrder.ID)
+	if err != nil {
+		return false, err
+	}
+	channelGroups := make(map[string][]*pay.PayProfitSharingOrderItem)
+	walletGroups := make(map[string][]*pay.PayProfitSharingOrderItem)
+	var channelNos, walletNos []string
+	for _, item := range items {
+		if item.Status != consts.PayProfitSharingStatusProcessing {
+			continue
+		}
+		if item.ReceiverType == consts.PayProfitSharingReceiverTypeWallet {
+			if _, ok := walletGroups[item.OutSharingNo]; !ok {
+				walletNos = append(walletNos, item.OutSharingNo)
+			}
+			walletGroups[item.OutSharingNo] = append(walletGroups[item.OutSharingNo], item)
+		} else {
+			if _, ok := channelGroups[item.OutSharingNo]; !ok {
+				channelNos = append(channelNos, item.OutSharingNo)
+			}
+			channelGroups[item.OutSharingNo] = append(channelGroups[item.OutSharingNo], item)
+		}
+	}
+
+	channelStatus := consts.PayProfitSharingStatusSuccess
+	channelClient, _ := s.channelSvc.GetPayClient(sharingOrder.ChannelID).(client.ProfitSharingClient)
+	if channelClient != nil {
+		if !hasChannelSharingItems(items) {
+			// 没有渠道明细时，分账单仅用于完结分账，查询完结分账的结果
+			resp, err := channelClient.GetProfitSharing(ctx, s.buildSharingReq(sharingOrder, nil))
+			channelStatus = s.applySharingResp(sharingOrder, nil, resp, err)
+		}
+		for _, no := range channelNos {
+			groupItems := channelGroups[no]
+			resp, err := channelClient.GetProfitSharing(ctx, s.buildSharingReq(sharingOrder, groupItems))
+			if status := s.applySharingResp(sharingOrder, groupItems, resp, err); status == consts.PayProfitSharingStatusProcessing {
+				channelStatus = status
+			}
+		}
+	}
+	if walletClient := s.getWalletSharingClient(ctx, sharingOrder.AppID); walletClient != nil {
+		for _, no := range walletNos {
+			groupItems := walletGroups[no]
+			resp, err := walletClient.GetProfitSharing(ctx, s.buildSharingReq(sharingOrder, groupItems))
+			s.applySharingResp(sharingOrder, groupItems, resp, err)
+		}
+	}
+
+	var changed []*pay.PayProfitSharingOrderItem
+	for _, no := range channelNos {
+		changed = append(changed, channelGroups[no]...)
+	}
+	for _, no := range walletNos {
+		changed = append(changed, walletGroups[no]...)
+	}
+	if err := s.updateSharingItems(ctx, changed); err != nil {
+		return false, err
+	}
+	return s.updateSharingOrderStatus(ctx, sharingOrder, items, channelStatus)
+}
+
+// RetrySharingOrder 重试分账失败的分账单，失败的明细使用新的请求单号重新分账
+// 微信渠道在首次分账时已解冻订单剩余资金，重试渠道明细可能因资金不足再次失败，此时需线下与接收方结算
+func (s *PayProfitSharingService) RetrySharingOrder(ctx context.Context, id int64) error {
+	sharingOrder, err := s.validateSharingOrderExists(ctx, id)
+	if err != nil {
+		return err
+	}
+	if sharingOrder.Status != consts.PayProfitSharingStatusFailure {
+		return ErrProfitSharingOrderRetryStatusInvalid
+	}
+	no, err := s.noDAO.Generate(ctx, profitSharingNoPrefix)
+	if err != nil {
+		return err
+	}
+
+	q := s.q.PayProfitSharingOrder
+	item := s.q.PayProfitSharingOrderItem
+	err = s.q.Transaction(func(tx *query.Query) error {
+		if _, err := tx.PayProfitSharingOrderItem.WithContext(ctx).
+			Where(item.SharingOrderID.Eq(id), item.Status.Eq(consts.PayProfitSharingStatusFailure)).
+			Updates(map[string]interface{}{
+				"out_sharing_no":    no,
+				"status":            consts.PayProfitSharingStatusWaiting,
+				"channel_detail_no": "",
+				"fail_reason":       "",
+			}); err != nil {
+			return err
+		}
+		result, err := tx.PayProfitSharingOrder.WithContext(ctx).
+			Where(q.ID.Eq(id), q.Status.Eq(consts.PayProfitSharingStatusFailure)).
+			Updates(map[string]interface{}{
+				"status":    consts.PayProfitSharingStatusWaiting,
+				"error_msg": "",
+			})
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected == 0 {
+			return ErrProfitSharingOrderRetryStatusInvalid
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sharingOrder.Status = consts.PayProfitSharingStatusWaiting
+	sharingOrder.ErrorMsg = ""
+	_, err = s.executeSharingOrder(ctx, sharingOrder)
+	return err
+}
+
+// GetSharingOrder 获得分账单
+func (s *PayProfitSharingService) GetSharingOrder(ctx context.Context, id int64) (*pay.PayProfitSharingOrder, error) {
+	return s.validateSharingOrderExists(ctx, id)
+}
+
+// GetSharingOrderItems 获得分账单的分账明细
+func (s *PayProfitSharingService) GetSharingOrderItems(ctx context.Context, sharingOrderID int64) ([]*pay.PayProfitSharingOrderItem, error) {
+	return s.getSharingOrderItems(ctx, sharingOrderID)
+}
+
+// GetSharingReturns 获得分账单的回退单
+func (s *PayProfitSharingService) GetSharingReturns(ctx context.Context, sharingOrderID int64) ([]*pay.PayProfitSharingReturn, error) {
+	q := s.q.PayProfitSharingReturn
+	return q.WithContext(ctx).Where(q.SharingOrderID.Eq(sharingOrderID)).Order(q.ID).Find()
+}
+
+// GetSharingOrderPage 获得分账单分页
+func (s *PayProfitSharingService) GetSharingOrderPage(ctx context.Context, req *pay2.PayProfitSharingOrderPageReq) (*pagination.PageResult[*pay.PayProfitSharingOrder], error) {
+	q := s.q.PayProfitSharingOrder
+	do := q.WithContext(ctx)
+	if req.AppID > 0 {
+		do = do.Where(q.AppID.Eq(req.AppID))
+	}
+	if req.OrderID > 0 {
+		do = do.Where(q.OrderID.Eq(req.OrderID))
+	}
+	if req.No != "" {
+		do = do.Where(q.No.Eq(req.No))
+	}
+	if req.OutTradeNo != "" {
+		do = do.Where(q.OutTradeNo.Eq(req.OutTradeNo))
+	}
+	if req.ChannelCode != "" {
+		do = do.Where(q.ChannelCode.Eq(req.ChannelCode))
+	}
+	if req.Status != nil {
+		do = do.Where(q.Status.Eq(*req.Status))
+	}
+
+	total, err := do.Count()
+	if err != nil {
+		return nil, err
+	}
+	list, err := do.Limit(req.GetLimit()).Offset(req.GetOffset()).Order(q.ID.Desc()).Find()
+	if err != nil {
+		return nil, err
+	}
+	return &pagination.PageResult[*pay.PayProfitSharingOrder]{
+		List:  list,
+		Total: total,
+	}, nil
+}
+
+func (s *PayProfitSharingService) validateSharingOrderExists(ctx context.Context, id int64) (*pay.PayProfitSharingOrder, error) {
+	sharingOrder, err := s.q.PayProfitSharingOrder.WithContext(ctx).Where(s.q.PayProfitSharingOrder.ID.Eq(id)).First()
+	if err != nil {
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProfitSharingOrderNotFound
+		}
+		return nil, err
+	}
+	return sharingOrder, nil
+}
+
+func (s *PayProfitSharingService) getSharingOrderItems(ctx context.Context, sharingOrderID int64) ([]*pay.PayProfitSharingOrderItem, error) {
+	q := s.q.PayProfitSharingOrderItem
+	return q.WithContext(ctx).Where(q.SharingOrderID.Eq(sharingOrderID)).Order(q.ID).Find()
+}
+
+// getWalletSharingClient 获得支付应用钱包渠道的分账客户端，钱包渠道不存在或未启用时返回 nil
+func (s *PayProfitSharingService) getWalletSharingClient(ctx context.Context, appID int64) client.ProfitSharingClient {
+	channel, err := s.channelSvc.GetChannelByAppIdAndCode(ctx, appID, consts.PayChannelWallet)
+	if err != nil || channel == nil || channel.Status != consts.CommonStatusEnable {
+		return nil
+	}
+	sharingClient, _ := s.channelSvc.GetPayClient(channel.ID).(client.ProfitSharingClient)
+	return sharingClient
+}
+
+// buildSharingReq 构建分账请求，明细的请求单号一致；没有明细时使用分账单号
+func (s *PayProfitSharingService) buildSharingReq(sharingOrder *pay.PayProfitSharingOrder, items []*pay.PayProfitSharingOrderItem) *client.UnifiedProfitSharingReq {
+	req := &client.UnifiedProfitSharingReq{
+		OutTradeNo:     sharingOrder.OutTradeNo,
+		ChannelOrderNo: sharingOrder.ChannelOrderNo,
+		OutSharingNo:   sharingOrder.No,
+	}
+	for _, item := range items {
+		req.OutSharingNo = item.OutSharingNo
+		req.Items = append(req.Items, &client.ProfitSharingItemReq{
+			ProfitSharingReceiverReq: client.ProfitSharingReceiverReq{
+				Type:    item.ReceiverType,
+				Account: item.Account,
+			},
+			OutDetailNo: strconv.FormatInt(item.ID, 10),
+			Price:       item.Price,
+			Description: "分账给" + item.Name,
+		})
+	}
+	return req
+}
+
+// applySharingResp 将分账结果写入明细，返回请求的整体状态
+// 调用异常时明细置为分账中，等待同步；渠道明确拒绝时明细分账失败
+func (s *PayProfitSharingService) applySharingResp(sharingOrder *pay.PayProfitSharingOrder, items []*pay.PayProfitSharingOrderItem,
+	resp *client.ProfitSharingResp, err error) int {
+	if err != nil || resp == nil {
+		for _, item := range items {
+			item.Status = consts.PayProfitSharingStatusProcessing
+		}
+		return consts.PayProfitSharingStatusProcessing
+	}
+	if resp.ChannelSharingNo != "" {
+		sharingOrder.ChannelSharingNo = resp.ChannelSharingNo
+	}
+	if resp.Status == consts.PayProfitSharingStatusFailure && len(resp.Items) == 0 {
+		reason := fmt.Sprintf("[%s] %s", resp.ChannelErrorCode, resp.ChannelErrorMsg)
+		s.failSharingItems(items, reason)
+		if len(items) == 0 {
+			sharingOrder.ErrorMsg = reason
+		}
+		return consts.PayProfitSharingStatusFailure
+	}
+
+	for _, item := range items {
+		var itemResp *client.ProfitSharingItemResp
+		for _, r := range resp.Items {
+			if r.Type == item.ReceiverType && r.Account == item.Account {
+				itemResp = r
+				break
+			}
+		}
+		if itemResp == nil {
+			item.Status = consts.PayProfitSharingStatusProcessing
+			continue
+		}
+		item.Status = itemResp.Status
+		item.ChannelDetailNo = itemResp.ChannelDetailNo
+		item.FailReason = itemResp.FailReason
+		if itemResp.Status == consts.PayProfitSharingStatusSuccess {
+			successTime := itemResp.SuccessTime
+			if successTime.IsZero() {
+				successTime = time.Now()
+			}
+			item.SuccessTime = &successTime
+		}
+	}
+	return resp.Status
+}
+
+// hasChannelSharingItems 分账单是否有通过支付渠道分账的明细
+func hasChannelSharingItems(items []*pay.PayProfitSharingOrderItem) bool {
+	for _, item := range items {
+		if item.ReceiverType != consts.PayProfitSharingReceiverTypeWallet {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *PayProfitSharingService) failSharingItems(items []*pay.PayProfitSharingOrderItem, reason string) {
+	for _, item := range items {
+		item.Status = consts.PayProfitSharingStatusFailure
+		item.FailReason = reason
+	}
+}
+
+func (s *PayProfitSharingService) updateSharingItems(ctx context.Context, items []*pay.PayProfitSharingOrderItem) error {
+	q := s.q.PayProfitSharingOrderItem
+	for _, item := range items {
+		if _, err := q.WithContext(ctx).Where(q.ID.Eq(item.ID)).Updates(map[string]interface{}{
+			"status":            item.Status,
+			"channel_detail_no": item.ChannelDetailNo,
+			"fail_reason":       item.FailReason,
+			"success_time":      item.SuccessTime,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateSharingOrderStatus 根据明细与完结分账的结果汇总分账单状态，返回分账单是否完结
+func (s *PayProfitSharingService) updateSharingOrderStatus(ctx context.Context, sharingOrder *pay.PayProfitSharingOrder,
+	items []*pay.PayProfitSharingOrderItem, channelStatus int) (bool, error) {
+	status := consts.PayProfitSharingStatusSuccess
+	if channelStatus == consts.PayProfitSharingStatusFailure {
+		status = consts.PayProfitSharingStatusFailure
+	}
+	errorMsg := sharingOrder.ErrorMsg
+	for _, item := range items {
+		switch item.Status {
+		case consts.PayProfitSharingStatusWaiting, consts.PayProfitSharingStatusProcessing:
+			channelStatus = consts.PayProfitSharingStatusProcessing
+		case consts.PayProfitSharingStatusFailure:
+			status = consts.PayProfitSharingStatusFailure
+			if errorMsg == "" {
+				errorMsg = fmt.Sprintf("%s: %s", item.Name, item.FailReason)
+			}
+		}
+	}
+	if channelStatus == consts.PayProfitSharingStatusProcessing {
+		status = consts.PayProfitSharingStatusProcessing
+	}
+
+	updates := map[string]interface{}{
+		"status":             status,
+		"channel_sharing_no": sharingOrder.ChannelSharingNo,
+		"error_msg":          errorMsg,
+	}
+	if status == consts.PayProfitSharingStatusSuccess {
+		updates["success_time"] = time.Now()
+	}
+	q := s.q.PayProfitSharingOrder
+	if _, err := q.WithContext(ctx).Where(q.ID.Eq(sharingOrder.ID), q.Status.Eq(sharingOrder.Status)).Updates(updates); err != nil {
+		return false, err
+	}
+	sharingOrder.Status = status
+	sharingOrder.ErrorMsg = errorMsg
+	return status != consts.PayProfitSharingStatusProcessing, nil
+}
+
+// closeSharingOrder 关闭分账单，未完结的明细一并关闭
+func (s *PayProfitSharingService) closeSharingOrder(ctx context.Context, sharingOrder *pay.PayProfitSharingOrder, reason string) error {
+	return s.q.Transaction(func(tx *query.Query) error {
+		item := tx.PayProfitSharingOrderItem
+		if _, err := item.WithContext(ctx).
+			Where(item.SharingOrderID.Eq(sharingOrder.ID),
+				item.Status.In(consts.PayProfitSharingStatusWaiting, consts.PayProfitSharingStatusProcessing)).
+			Updates(map[string]interface{}{
+				"status":      consts.PayProfitSharingStatusClosed,
+				"fail_reason": reason,
+			}); err != nil {
+			return err
+		}
+		q := tx.PayProfitSharingOrder
+		_, err := q.WithContext(ctx).Where(q.ID.Eq(sharingOrder.ID), q.Status.Eq(sharingOrder.Status)).
+			Updates(map[string]interface{}{
+				"status":    consts.PayProfitSharingStatusClosed,
+				"error_msg": reason,
+			})
+		return err
+	})
+}
+
+// ========== 分账回退 ==========
+
+// CreateSharingReturns 为分账成功后发生退款的订单生成分账回退单，返回处理的分账单数量
+// 按订单退款金额占分账基数的比例，从分账成功的接收方回退对应金额；订单全额退款时回退全部分账金额
+func (s *PayProfitSharingService) CreateSharingReturns(ctx context.Context) (int, error) {
+	so := s.q.PayProfitSharingOrder
+	o := s.q.PayOrder
+	list, err := so.WithContext(ctx).Select(so.ALL).
+		Join(o, o.ID.EqCol(so.OrderID)).
+		Where(so.Status.Eq(consts.PayProfitSharingStatusSuccess), o.RefundPrice.GtCol(so.RefundPrice)).
+		Limit(profitSharingBatchSize).
+		Find()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, sharingOrder := range list {
+		if err := s.createSharingReturn(ctx, sharingOrder); err != nil {
+			fmt.Printf("[CreateSharingReturns][sharingOrder(%d) 生成分账回退单失败: %v]\n", sharingOrder.ID, err)
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (s *PayProfitSharingService) createSharingReturn(ctx context.Context, sharingOrder *pay.PayProfitSharingOrder) error {
+	order, err := s.q.PayOrder.WithContext(ctx).Where(s.q.PayOrder.ID.Eq(sharingOrder.OrderID)).First()
+	if err != nil {
+		return err
+	}
+	items, err := s.getSharingOrderItems(ctx, sharingOrder.ID)
+	if err != nil {
+		return err
+	}
+	existReturns, err := s.GetSharingReturns(ctx, sharingOrder.ID)
+	if err != nil {
+		return err
+	}
+	returnedPrices := make(map[int64]int, len(items))
+	for _, r := range existReturns {
+		if r.Status != consts.PayProfitSharingStatusFailure {
+			returnedPrices[r.ItemID] += r.Price
+		}
+	}
+
+	// 分账基数中已退款的部分：分账单生成前的退款不计入分账基数
+	base := int64(sharingOrder.Price)
+	refunded := int64(order.RefundPrice - (order.Price - sharingOrder.Price))
+	if refunded > base {
+		refunded = base
+	}
+	var returns []*pay.PayProfitSharingReturn
+	for _, item := range items {
+		if item.Status != consts.PayProfitSharingStatusSuccess || base <= 0 {
+			continue
+		}
+		target := int(int64(item.Price) * refunded / base)
+		price := target - returnedPrices[item.ID]
+		if price <= 0 {
+			continue
+		}
+		no, err := s.noDAO.Generate(ctx, profitSharingReturnNoPrefix)
+		if err != nil {
+			return err
+		}
+		r := &pay.PayProfitSharingReturn{
+			No:             no,
+			AppID:          sharingOrder.AppID,
+			SharingOrderID: sharingOrder.ID,
+			ItemID:         item.ID,
+			OrderID:        sharingOrder.OrderID,
+			ReceiverType:   item.ReceiverType,
+			Account:        item.Account,
+			Price:          price,
+			Status:         consts.PayProfitSharingStatusWaiting,
+		}
+		r.TenantID = sharingOrder.TenantID
+		returns = append(returns, r)
+	}
+
+	return s.q.Transaction(func(tx *query.Query) error {
+		q := tx.PayProfitSharingOrder
+		result, err := q.WithContext(ctx).
+			Where(q.ID.Eq(sharingOrder.ID), q.RefundPrice.Eq(sharingOrder.RefundPrice)).
+			Update(q.RefundPrice, order.RefundPrice)
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("分账单(%d) 已处理退款金额发生变化", sharingOrder.ID)
+		}
+		if len(returns) == 0 {
+			return nil
+		}
+		return tx.PayProfitSharingReturn.WithContext(ctx).Create(returns...)
+	})
+}
+
+// ExecuteSharingReturns 发起等待中的分账回退，并同步回退中的结果，返回完结的数量
+func (s *PayProfitSharingService) ExecuteSharingReturns(ctx context.Context) (int, error) {
+	q := s.q.PayProfitSharingReturn
+	list, err := q.WithContext(ctx).
+		Where(q.Status.In(consts.PayProfitSharingStatusWaiting, consts.PayProfitSharingStatusProcessing)).
+		Limit(profitSharingBatchSize).
+		Find()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, r := range list {
+		finished, err := s.executeSharingReturn(ctx, r)
+		if err != nil {
+			fmt.Printf("[ExecuteSharingReturns][return(%d) 分账回退失败: %v]\n", r.ID, err)
+			continue
+		}
+		if finished {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// executeSharingReturn 等待中的回退单请求回退，回退中的回退单查询结果；回退成功时累加明细的已回退金额
+func (s *PayProfitSharingService) executeSharingReturn(ctx context.Context, r *pay.PayProfitSharingReturn) (bool, error) {
+	sharingOrder, err := s.validateSharingOrderExists(ctx, r.SharingOrderID)
+	if err != nil {
+		return false, err
+	}
+	item, err := s.q.PayProfitSharingOrderItem.WithContext(ctx).Where(s.q.PayProfitSharingOrderItem.ID.Eq(r.ItemID)).First()
+	if err != nil {
+		return false, err
+	}
+
+	var sharingClient client.ProfitSharingClient
+	if r.ReceiverType == consts.PayProfitSharingReceiverTypeWallet {
+		sharingClient = s.getWalletSharingClient(ctx, r.AppID)
+	} else {
+		sharingClient, _ = s.channelSvc.GetPayClient(sharingOrder.ChannelID).(client.ProfitSharingClient)
+	}
+	if sharingClient == nil {
+		return s.updateSharingReturn(ctx, r, &client.ProfitSharingReturnResp{
+			Status:          consts.PayProfitSharingStatusFailure,
+			ChannelErrorMsg: "支付渠道不支持分账回退",
+		})
+	}
+
+	req := &client.UnifiedProfitSharingReturnReq{
+		OutTradeNo:     sharingOrder.OutTradeNo,
+		ChannelOrderNo: sharingOrder.ChannelOrderNo,
+		OutSharingNo:   item.OutSharingNo,
+		OutReturnNo:    r.No,
+		Receiver: client.ProfitSharingReceiverReq{
+			Type:    r.ReceiverType,
+			Account: r.Account,
+		},
+		Price:       r.Price,
+		Description: "订单退款，回退分账",
+	}
+	var resp *client.ProfitSharingReturnResp
+	if r.Status == consts.PayProfitSharingStatusWaiting {
+		resp, err = sharingClient.UnifiedProfitSharingReturn(ctx, req)
+	} else {
+		resp, err = sharingClient.GetProfitSharingReturn(ctx, req)
+	}
+	if err != nil {
+		fmt.Printf("[executeSharingReturn][return(%d) 调用渠道分账回退异常，等待同步: %v]\n", r.ID, err)
+		resp = &client.ProfitSharingReturnResp{Status: consts.PayProfitSharingStatusProcessing}
+	}
+	return s.updateSharingReturn(ctx, r, resp)
+}
+
+func (s *PayProfitSharingService) updateSharingReturn(ctx context.Context, r *pay.PayProfitSharingReturn, resp *client.ProfitSharingReturnResp) (bool, error) {
+	updates := map[string]interface{}{
+		"status":            resp.Status,
+		"channel_return_no": resp.ChannelReturnNo,
+	}
+	switch resp.Status {
+	case consts.PayProfitSharingStatusSuccess:
+		successTime := resp.SuccessTime
+		if successTime.IsZero() {
+			successTime = time.Now()
+		}
+		updates["success_time"] = successTime
+	case consts.PayProfitSharingStatusFailure:
+		updates["fail_reason"] = resp.ChannelErrorMsg
+		if resp.ChannelErrorCode != "" {
+			updates["fail_reason"] = fmt.Sprintf("[%s] %s", resp.ChannelErrorCode, resp.ChannelErrorMsg)
+		}
+	}
+
+	err := s.q.Transaction(func(tx *query.Query) error {
+		q := tx.PayProfitSharingReturn
+		result, err := q.WithContext(ctx).Where(q.ID.Eq(r.ID), q.Status.Eq(r.Status)).Updates(updates)
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected == 0 || resp.Status != consts.PayProfitSharingStatusSuccess {
+			return nil
+		}
+		item := tx.PayProfitSharingOrderItem
+		_, err = item.WithContext(ctx).Where(item.ID.Eq(r.ItemID)).
+			UpdateSimple(item.ReturnPrice.Add(r.Price))
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp.Status == consts.PayProfitSharingStatusSuccess || resp.Status == consts.PayProfitSharingStatusFailure, nil
+}
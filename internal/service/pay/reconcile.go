@@ -0,0 +1,733 @@
+package pay
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"time"
+
+	pay2 "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/pay"
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model/pay"
+	"github.com/wxlbd/ruoyi-mall-go/internal/repo/query"
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
+
+	"gorm.io/gorm"
+)
+
+// reconcileQueryBatchSize 按单号批量查询本地单据时，单次 IN 查询的单号数量
+const reconcileQueryBatchSize = 500
+
+var (
+	ErrReconcileChannelTypeInvalid   = errors.NewBizError(1007011000, "对账渠道类型不正确")              // RECONCILE_CHANNEL_TYPE_INVALID
+	ErrReconcileBillDateInvalid      = errors.NewBizError(1007011001, "账单日期不正确，格式为 yyyy-MM-dd") // RECONCILE_BILL_DATE_INVALID
+	ErrReconcileBillClientNotFound   = errors.NewBizError(1007011002, "支付渠道不支持下载对账单")           // RECONCILE_BILL_CLIENT_NOT_FOUND
+	ErrReconcileDiscrepancyNotFound  = errors.NewBizError(1007011003, "对账差异不存在")                // RECONCILE_DISCREPANCY_NOT_FOUND
+	ErrReconcileDiscrepancyResolved  = errors.NewBizError(1007011004, "对账差异已处理")                // RECONCILE_DISCREPANCY_RESOLVED
+	ErrReconcileDiscrepancyNotSynced = errors.NewBizError(1007011005, "该差异无法从渠道同步修复，请人工核实后忽略")  // RECONCILE_DISCREPANCY_CANNOT_SYNC
+)
+
+// PayReconcileService 支付渠道对账服务
+// 按支付渠道（商户号）将渠道对账单与本地支付单、退款单逐笔核对，记录本地缺失、渠道缺失、金额不一致、状态不一致四类差异
+type PayReconcileService struct {
+	q          *query.Query
+	appSvc     *PayAppService
+	channelSvc *PayChannelService
+	orderSvc   *PayOrderService
+	refundSvc  *PayRefundService
+}
+
+func NewPayReconcileService(q *query.Query, appSvc *PayAppService, channelSvc *PayChannelService, orderSvc *PayOrderService, refundSvc *PayRefundService) *PayReconcileService {
+	return &PayReconcileService{
+		q:          q,
+		appSvc:     appSvc,
+		channelSvc: channelSvc,
+		orderSvc:   orderSvc,
+		refundSvc:  refundSvc,
+	}
+}
+
+// UploadBill 上传对账单并对账，返回对账单编号
+func (s *PayReconcileService) UploadBill(ctx context.Context, req *pay2.PayReconcileBillUploadReq, fileName string, data []byte) (int64, error) {
+	channel, billDate, err := s.validateBillReq(ctx, req.ChannelID, req.BillDate)
+	if err != nil {
+		return 0, err
+	}
+	return s.reconcile(ctx, channel, billDate, consts.PayReconcileBillSourceUpload, fileName, data)
+}
+
+// DownloadBill 从渠道下载对账单并对账，返回对账单编号
+func (s *PayReconcileService) DownloadBill(ctx context.Context, req *pay2.PayReconcileBillDownloadReq) (int64, error) {
+	channel, billDate, err := s.validateBillReq(ctx, req.ChannelID, req.BillDate)
+	if err != nil {
+		return 0, err
+	}
+	return s.downloadAndReconcile(ctx, channel, billDate)
+}
+
+// ReconcileBills 下载所有启用渠道指定日期的对账单并对账，返回对账成功的对账单数量
+// 同一类型的渠道可以配置多个商户号，每个商户号各有一份对账单，逐个渠道下载
+func (s *PayReconcileService) ReconcileBills(ctx context.Context, billDate time.Time) (int, error) {
+	channels, err := s.q.PayChannel.WithContext(ctx).
+		Where(s.q.PayChannel.Status.Eq(consts.CommonStatusEnable)).
+		Find()
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, channel := range channels {
+		if consts.GetPayReconcileChannel(channel.Code) == "" {
+			continue
+		}
+		if _, err := s.downloadAndReconcile(ctx, channel, billDate); err != nil {
+			fmt.Printf("[ReconcileBills][应用(%d) 渠道(%d) 对账失败: %v]\n", channel.AppID, channel.ID, err)
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (s *PayReconcileService) validateBillReq(ctx context.Context, channelID int64, billDate string) (*pay.PayChannel, time.Time, error) {
+	channel, err := s.channelSvc.GetChannel(ctx, channelID)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if channel == nil {
+		return nil, time.Time{}, errors.NewBizError(1006002002, "支付渠道不存在") // PAY_CHANNEL_NOT_FOUND
+	}
+	if consts.GetPayReconcileChannel(channel.Code) == "" {
+		return nil, time.Time{}, ErrReconcileChannelTypeInvalid
+	}
+	date, err := time.ParseInLocation(time.DateOnly, billDate, time.Local)
+	if err != nil {
+		return nil, time.Time{}, ErrReconcileBillDateInvalid
+	}
+	return channel, date, nil
+}
+
+// downloadAndReconcile 下载支付渠道（商户号）的对账单，并对账
+func (s *PayReconcileService) downloadAndReconcile(ctx context.Context, channel *pay.PayChannel, billDate time.Time) (int64, error) {
+	billClient, ok := s.channelSvc.GetPayClient(channel.ID).(client.BillClient)
+	if !ok {
+		return 0, ErrReconcileBillClientNotFound
+	}
+
+	fileName, data, err := billClient.DownloadBill(ctx, billDate)
+	if err != nil {
+		bill := s.newBill(channel, billDate, consts.PayReconcileBillSourceDownload, "")
+		return s.failBill(ctx, bill, err)
+	}
+	return s.reconcile(ctx, channel, billDate, consts.PayReconcileBillSourceDownload, fileName, data)
+}
+
+// reconcile 解析对账单并与本地单据核对，保存对账结果
+// 对账成功时覆盖该支付渠道、日期之前的对账结果，已忽略的差异保留忽略状态；对账失败时只记录失败的对账单
+func (s *PayReconcileService) reconcile(ctx context.Context, channel *pay.PayChannel, billDate time.Time,
+	source int, fileName string, data []byte) (int64, error) {
+	bill := s.newBill(channel, billDate, source, fileName)
+	records, err := client.ParseBill(bill.ChannelType, data)
+	if err != nil {
+		return s.failBill(ctx, bill, err)
+	}
+
+	var discrepancies []*pay.PayReconcileDiscrepancy
+	for _, bizType := range []int{consts.PayReconcileBizTypeOrder, consts.PayReconcileBizTypeRefund} {
+		var list []*pay.PayReconcileDiscrepancy
+		if bizType == consts.PayReconcileBizTypeOrder {
+			list, err = s.reconcileOrders(ctx, bill, records)
+		} else {
+			list, err = s.reconcileRefunds(ctx, bill, records)
+		}
+		if err != nil {
+			return s.failBill(ctx, bill, err)
+		}
+		discrepancies = append(discrepancies, list...)
+	}
+	bill.ChannelCount = len(records)
+	bill.DiscrepancyCount = len(discrepancies)
+	bill.Status = consts.PayReconcileBillStatusSuccess
+
+	err = s.q.Transaction(func(tx *query.Query) error {
+		// 1. 删除之前的对账结果，记录已忽略的差异
+		oldBills, err := tx.PayReconcileBill.WithContext(ctx).
+			Where(tx.PayReconcileBill.ChannelID.Eq(bill.ChannelID), tx.PayReconcileBill.BillDate.Eq(billDate)).
+			Find()
+		if err != nil {
+			return err
+		}
+		ignored := make(map[string]*pay.PayReconcileDiscrepancy)
+		if len(oldBills) > 0 {
+			oldBillIDs := make([]int64, 0, len(oldBills))
+			for _, oldBill := range oldBills {
+				oldBillIDs = append(oldBillIDs, oldBill.ID)
+			}
+			oldDiscrepancies, err := tx.PayReconcileDiscrepancy.WithContext(ctx).
+				Where(tx.PayReconcileDiscrepancy.BillID.In(oldBillIDs...),
+					tx.PayReconcileDiscrepancy.Status.Eq(consts.PayReconcileDiscrepancyStatusIgnored)).
+				Find()
+			if err != nil {
+				return err
+			}
+			for _, d := range oldDiscrepancies {
+				ignored[discrepancyKey(d)] = d
+			}
+			if _, err := tx.PayReconcileDiscrepancy.WithContext(ctx).
+				Where(tx.PayReconcileDiscrepancy.BillID.In(oldBillIDs...)).Delete(); err != nil {
+				return err
+			}
+			if _, err := tx.PayReconcileBill.WithContext(ctx).
+				Where(tx.PayReconcileBill.ID.In(oldBillIDs...)).Delete(); err != nil {
+				return err
+			}
+		}
+
+		// 2. 保存对账单与差异
+		if err := tx.PayReconcileBill.WithContext(ctx).Create(bill); err != nil {
+			return err
+		}
+		if len(discrepancies) == 0 {
+			return nil
+		}
+		for _, d := range discrepancies {
+			d.BillID = bill.ID
+			if old, ok := ignored[discrepancyKey(d)]; ok {
+				d.Status = old.Status
+				d.ResolveRemark = old.ResolveRemark
+				d.ResolveTime = old.ResolveTime
+			}
+		}
+		return tx.PayReconcileDiscrepancy.WithContext(ctx).CreateInBatches(discrepancies, 100)
+	})
+	if err != nil {
+		return 0, err
+	}
+	fmt.Printf("[reconcile][应用(%d) 渠道(%d) 日期(%s) 对账完成: 渠道 %d 笔, 本地 %d 笔, 一致 %d 笔, 差异 %d 笔]\n",
+		bill.AppID, bill.ChannelID, billDate.Format(time.DateOnly), bill.ChannelCount, bill.LocalCount, bill.MatchedCount, bill.DiscrepancyCount)
+	return bill.ID, nil
+}
+
+func (s *PayReconcileService) newBill(channel *pay.PayChannel, billDate time.Time, source int, fileName string) *pay.PayReconcileBill {
+	return &pay.PayReconcileBill{
+		AppID:       channel.AppID,
+		ChannelID:   channel.ID,
+		ChannelType: consts.GetPayReconcileChannel(channel.Code),
+		BillDate:    billDate,
+		FileName:    fileName,
+		Source:      source,
+		Status:      consts.PayReconcileBillStatusProcessing,
+	}
+}
+
+// failBill 记录对账失败的对账单，返回对账单编号与失败原因
+func (s *PayReconcileService) failBill(ctx context.Context, bill *pay.PayReconcileBill, cause error) (int64, error) {
+	bill.Status = consts.PayReconcileBillStatusFailure
+	bill.ErrorMsg = cause.Error()
+	if len(bill.ErrorMsg) > 1024 {
+		bill.ErrorMsg = bill.ErrorMsg[:1024]
+	}
+	if err := s.q.PayReconcileBill.WithContext(ctx).Create(bill); err != nil {
+		return 0, err
+	}
+	return bill.ID, errors.NewBizError(errors.ParamErrCode, "对账失败: "+cause.Error())
+}
+
+// reconcileOrders 核对支付单
+// 渠道记录优先按渠道订单号匹配本地支付单；本地尚未记录渠道订单号时（未收到回调），按外部订单号匹配支付订单拓展
+func (s *PayReconcileService) reconcileOrders(ctx context.Context, bill *pay.PayReconcileBill, records []*client.BillRecord) ([]*pay.PayReconcileDiscrepancy, error) {
+	var channelOrderNos, outTradeNos []string
+	for _, record := range records {
+		if record.BizType == consts.PayReconcileBizTypeOrder {
+			channelOrderNos = append(channelOrderNos, record.ChannelOrderNo)
+			outTradeNos = append(outTradeNos, record.OutTradeNo)
+		}
+	}
+
+	// 1. 查询本地单据
+	ordersByChannelNo := make(map[string]*pay.PayOrder)
+	err := batchQuery(channelOrderNos, func(nos []string) error {
+		list, err := s.q.PayOrder.WithContext(ctx).
+			Where(s.q.PayOrder.AppID.Eq(bill.AppID), s.q.PayOrder.ChannelOrderNo.In(nos...)).
+			Find()
+		for _, order := range list {
+			ordersByChannelNo[order.ChannelOrderNo] = order
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	extensions := make(map[string]*pay.PayOrderExtension)
+	err = batchQuery(outTradeNos, func(nos []string) error {
+		list, err := s.q.PayOrderExtension.WithContext(ctx).Where(s.q.PayOrderExtension.No.In(nos...)).Find()
+		for _, extension := range list {
+			extensions[extension.No] = extension
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	orderIDs := make([]int64, 0, len(extensions))
+	for _, extension := range extensions {
+		orderIDs = append(orderIDs, extension.OrderID)
+	}
+	ordersByID, err := s.orderSvc.GetOrderMap(ctx, orderIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 逐笔核对渠道记录
+	var discrepancies []*pay.PayReconcileDiscrepancy
+	matched := make(map[int64]bool)
+	for _, record := range records {
+		if record.BizType != consts.PayReconcileBizTypeOrder {
+			continue
+		}
+		bill.ChannelAmount += int64(record.Amount)
+		d := s.newDiscrepancy(bill, consts.PayReconcileBizTypeOrder, record)
+		order := ordersByChannelNo[record.ChannelOrderNo]
+		extension := extensions[record.OutTradeNo]
+		if order == nil && extension != nil {
+			order = ordersByID[extension.OrderID]
+		}
+		if order == nil || order.AppID != bill.AppID {
+			d.Type = consts.PayReconcileDiscrepancyTypeLocalMissing
+			discrepancies = append(discrepancies, d)
+			continue
+		}
+		matched[order.ID] = true
+		// 组合支付的钱包部分不经过渠道，按渠道实付金额核对
+		channelPrice := order.Price - order.WalletPrice
+		d.LocalID, d.ChannelID = order.ID, order.ChannelID
+		d.LocalAmount, d.LocalStatus = &channelPrice, &order.Status
+
+		switch {
+		case extension != nil && order.ExtensionID != extension.ID:
+			// 渠道成功的拓展单不是支付单最终采用的拓展单：未收到回调，或同一支付单被重复支付
+			d.ChannelID = extension.ChannelID
+			d.LocalStatus = &extension.Status
+			d.Type = consts.PayReconcileDiscrepancyTypeStatusMismatch
+		case order.Status != PayOrderStatusSuccess && order.Status != PayOrderStatusRefund:
+			d.Type = consts.PayReconcileDiscrepancyTypeStatusMismatch
+		case channelPrice != record.Amount:
+			d.Type = consts.PayReconcileDiscrepancyTypeAmountMismatch
+		default:
+			bill.MatchedCount++
+			continue
+		}
+		discrepancies = append(discrepancies, d)
+	}
+
+	// 3. 本地当日支付成功、渠道没有记录的支付单
+	start, end := bill.BillDate, bill.BillDate.AddDate(0, 0, 1)
+	localOrders, err := s.q.PayOrder.WithContext(ctx).
+		Where(s.q.PayOrder.AppID.Eq(bill.AppID),
+			s.q.PayOrder.ChannelID.Eq(bill.ChannelID),
+			s.q.PayOrder.Status.In(PayOrderStatusSuccess, PayOrderStatusRefund),
+			s.q.PayOrder.SuccessTime.Gte(start), s.q.PayOrder.SuccessTime.Lt(end)).
+		Find()
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range localOrders {
+		channelPrice := order.Price - order.WalletPrice
+		bill.LocalCount++
+		bill.LocalAmount += int64(channelPrice)
+		if matched[order.ID] {
+			continue
+		}
+		discrepancies = append(discrepancies, &pay.PayReconcileDiscrepancy{
+			AppID:          bill.AppID,
+			ChannelType:    bill.ChannelType,
+			BillDate:       bill.BillDate,
+			BizType:        consts.PayReconcileBizTypeOrder,
+			Type:           consts.PayReconcileDiscrepancyTypeChannelMissing,
+			LocalID:        order.ID,
+			ChannelID:      order.ChannelID,
+			OutTradeNo:     order.No,
+			ChannelOrderNo: order.ChannelOrderNo,
+			LocalAmount:    &channelPrice,
+			LocalStatus:    &order.Status,
+			Status:         consts.PayReconcileDiscrepancyStatusPending,
+		})
+	}
+	return discrepancies, nil
+}
+
+// reconcileRefunds 核对退款单
+// 支付宝账单没有独立的渠道退款单号，两个渠道的账单都会回传外部退款号，因此按外部退款号匹配
+func (s *PayReconcileService) reconcileRefunds(ctx context.Context, bill *pay.PayReconcileBill, records []*client.BillRecord) ([]*pay.PayReconcileDiscrepancy, error) {
+	var outRefundNos []string
+	for _, record := range records {
+		if record.BizType == consts.PayReconcileBizTypeRefund {
+			outRefundNos = append(outRefundNos, record.OutRefundNo)
+		}
+	}
+
+	// 1. 查询本地单据
+	refunds := make(map[string]*pay.PayRefund)
+	err := batchQuery(outRefundNos, func(nos []string) error {
+		list, err := s.q.PayRefund.WithContext(ctx).
+			Where(s.q.PayRefund.AppID.Eq(bill.AppID), s.q.PayRefund.No.In(nos...)).
+			Find()
+		for _, refund := range list {
+			refunds[refund.No] = refund
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. 逐笔核对渠道记录
+	var discrepancies []*pay.PayReconcileDiscrepancy
+	matched := make(map[int64]bool)
+	for _, record := range records {
+		if record.BizType != consts.PayReconcileBizTypeRefund {
+			continue
+		}
+		bill.ChannelAmount -= int64(record.Amount)
+		d := s.newDiscrepancy(bill, consts.PayReconcileBizTypeRefund, record)
+		refund := refunds[record.OutRefundNo]
+		if refund == nil {
+			d.Type = consts.PayReconcileDiscrepancyTypeLocalMissing
+			discrepancies = append(discrepancies, d)
+			continue
+		}
+		matched[refund.ID] = true
+		channelRefundPrice := refund.RefundPrice - refund.WalletRefundPrice
+		d.LocalID, d.ChannelID = refund.ID, refund.ChannelID
+		d.LocalAmount, d.LocalStatus = &channelRefundPrice, &refund.Status
+
+		switch {
+		case refund.Status != consts.PayRefundStatusSuccess:
+			d.Type = consts.PayReconcileDiscrepancyTypeStatusMismatch
+		case channelRefundPrice != record.Amount:
+			d.Type = consts.PayReconcileDiscrepancyTypeAmountMismatch
+		default:
+			bill.MatchedCount++
+			continue
+		}
+		discrepancies = append(discrepancies, d)
+	}
+
+	// 3. 本地当日退款成功、渠道没有记录的退款单
+	start, end := bill.BillDate, bill.BillDate.AddDate(0, 0, 1)
+	localRefunds, err := s.q.PayRefund.WithContext(ctx).
+		Where(s.q.PayRefund.AppID.Eq(bill.AppID),
+			s.q.PayRefund.ChannelID.Eq(bill.ChannelID),
+			s.q.PayRefund.Status.Eq(consts.PayRefundStatusSuccess),
+			s.q.PayRefund.SuccessTime.Gte(start), s.q.PayRefund.SuccessTime.Lt(end)).
+		Find()
+	if err != nil {
+		return nil, err
+	}
+	for _, refund := range localRefunds {
+		// 全部退回钱包的退款单没有渠道退款
+		channelRefundPrice := refund.RefundPrice - refund.WalletRefundPrice
+		if channelRefundPrice <= 0 {
+			continue
+		}
+		bill.LocalCount++
+		bill.LocalAmount -= int64(channelRefundPrice)
+		if matched[refund.ID] {
+			continue
+		}
+		discrepancies = append(discrepancies, &pay.PayReconcileDiscrepancy{
+			AppID:           bill.AppID,
+			ChannelType:     bill.ChannelType,
+			BillDate:        bill.BillDate,
+			BizType:         consts.PayReconcileBizTypeRefund,
+			Type:            consts.PayReconcileDiscrepancyTypeChannelMissing,
+			LocalID:         refund.ID,
+			ChannelID:       refund.ChannelID,
+			OutTradeNo:      refund.OrderNo,
+			ChannelOrderNo:  refund.ChannelOrderNo,
+			OutRefundNo:     refund.No,
+			ChannelRefundNo: refund.ChannelRefundNo,
+			LocalAmount:     &channelRefundPrice,
+			LocalStatus:     &refund.Status,
+			Status:          consts.PayReconcileDiscrepancyStatusPending,
+		})
+	}
+	return discrepancies, nil
+}
+
+func (s *PayReconcileService) newDiscrepancy(bill *pay.PayReconcileBill, bizType int, record *client.BillRecord) *pay.PayReconcileDiscrepancy {
+	amount := record.Amount
+	return &pay.PayReconcileDiscrepancy{
+		AppID:           bill.AppID,
+		ChannelType:     bill.ChannelType,
+		BillDate:        bill.BillDate,
+		BizType:         bizType,
+		OutTradeNo:      record.OutTradeNo,
+		ChannelOrderNo:  record.ChannelOrderNo,
+		OutRefundNo:     record.OutRefundNo,
+		ChannelRefundNo: record.ChannelRefundNo,
+		ChannelAmount:   &amount,
+		Status:          consts.PayReconcileDiscrepancyStatusPending,
+	}
+}
+
+// SyncDiscrepancy 从渠道同步单据状态，修复状态不一致或渠道缺失的差异
+// 通过 PayClient 查询渠道的真实结果并按回调流程更新本地单据，同步后单据与渠道一致才标记为已修复
+func (s *PayReconcileService) SyncDiscrepancy(ctx context.Context, id int64) error {
+	d, err := s.validateDiscrepancyPending(ctx, id)
+	if err != nil {
+		return err
+	}
+	if d.LocalID == 0 || d.Type == consts.PayReconcileDiscrepancyTypeAmountMismatch {
+		return ErrReconcileDiscrepancyNotSynced
+	}
+
+	var remark string
+	if d.BizType == consts.PayReconcileBizTypeOrder {
+		remark, err = s.syncOrderDiscrepancy(ctx, d)
+	} else {
+		remark, err = s.syncRefundDiscrepancy(ctx, d)
+	}
+	if err != nil {
+		return err
+	}
+	return s.resolveDiscrepancy(ctx, d.ID, consts.PayReconcileDiscrepancyStatusRepaired, remark)
+}
+
+func (s *PayReconcileService) syncOrderDiscrepancy(ctx context.Context, d *pay.PayReconcileDiscrepancy) (string, error) {
+	extension, err := s.q.PayOrderExtension.WithContext(ctx).Where(s.q.PayOrderExtension.No.Eq(d.OutTradeNo)).First()
+	if err != nil {
+		return "", fmt.Errorf("支付订单拓展不存在: %w", err)
+	}
+	payClient := s.channelSvc.GetPayClient(extension.ChannelID)
+	if payClient == nil {
+		return "", errors.NewBizError(1006002000, "支付渠道找不到对应的支付客户端") // PAY_CHANNEL_CLIENT_NOT_FOUND
+	}
+	resp, err := payClient.GetOrder(ctx, extension.No)
+	if err != nil {
+		return "", err
+	}
+	if resp.Status != PayOrderStatusSuccess {
+		return "", errors.NewBizError(ErrReconcileDiscrepancyNotSynced.Code, "渠道查询结果不是支付成功，请人工核实")
+	}
+	if d.Type == consts.PayReconcileDiscrepancyTypeChannelMissing {
+		// 渠道确认支付成功，说明是对账单遗漏（例如跨日入账），本地无需变更
+		return "渠道查询确认支付成功", nil
+	}
+	if err := s.orderSvc.NotifyOrder(ctx, extension.ChannelID, resp); err != nil {
+		return "", errors.NewBizError(ErrReconcileDiscrepancyNotSynced.Code, "同步支付结果失败: "+err.Error())
+	}
+	order, err := s.orderSvc.GetOrder(ctx, extension.OrderID)
+	if err != nil {
+		return "", err
+	}
+	if order.ExtensionID != extension.ID || (order.Status != PayOrderStatusSuccess && order.Status != PayOrderStatusRefund) {
+		return "", errors.NewBizError(ErrReconcileDiscrepancyNotSynced.Code, "同步后支付单仍与渠道不一致，请人工核实")
+	}
+	return "从渠道同步支付结果", nil
+}
+
+func (s *PayReconcileService) syncRefundDiscrepancy(ctx context.Context, d *pay.PayReconcileDiscrepancy) (string, error) {
+	refund, err := s.refundSvc.GetRefund(ctx, d.LocalID)
+	if err != nil {
+		return "", fmt.Errorf("退款订单不存在: %w", err)
+	}
+	payClient := s.channelSvc.GetPayClient(refund.ChannelID)
+	if payClient == nil {
+		return "", errors.NewBizError(1006002000, "支付渠道找不到对应的支付客户端") // PAY_CHANNEL_CLIENT_NOT_FOUND
+	}
+	resp, err := payClient.GetRefund(ctx, refund.OrderNo, refund.No)
+	if err != nil {
+		return "", err
+	}
+	if resp.Status != consts.PayRefundStatusSuccess {
+		return "", errors.NewBizError(ErrReconcileDiscrepancyNotSynced.Code, "渠道查询结果不是退款成功，请人工核实")
+	}
+	if d.Type == consts.PayReconcileDiscrepancyTypeChannelMissing {
+		return "渠道查询确认退款成功", nil
+	}
+	if err := s.refundSvc.NotifyRefund(ctx, refund.ChannelID, resp); err != nil {
+		return "", errors.NewBizError(ErrReconcileDiscrepancyNotSynced.Code, "同步退款结果失败: "+err.Error())
+	}
+	return "从渠道同步退款结果", nil
+}
+
+// IgnoreDiscrepancy 忽略对账差异，需填写处理说明
+func (s *PayReconcileService) IgnoreDiscrepancy(ctx context.Context, req *pay2.PayReconcileDiscrepancyIgnoreReq) error {
+	if _, err := s.validateDiscrepancyPending(ctx, req.ID); err != nil {
+		return err
+	}
+	return s.resolveDiscrepancy(ctx, req.ID, consts.PayReconcileDiscrepancyStatusIgnored, req.Remark)
+}
+
+func (s *PayReconcileService) validateDiscrepancyPending(ctx context.Context, id int64) (*pay.PayReconcileDiscrepancy, error) {
+	d, err := s.q.PayReconcileDiscrepancy.WithContext(ctx).Where(s.q.PayReconcileDiscrepancy.ID.Eq(id)).First()
+	if err != nil {
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReconcileDiscrepancyNotFound
+		}
+		return nil, err
+	}
+	if d.Status != consts.PayReconcileDiscrepancyStatusPending {
+		return nil, ErrReconcileDiscrepancyResolved
+	}
+	return d, nil
+}
+
+func (s *PayReconcileService) resolveDiscrepancy(ctx context.Context, id int64, status int, remark string) error {
+	now := time.Now()
+	result, err := s.q.PayReconcileDiscrepancy.WithContext(ctx).
+		Where(s.q.PayReconcileDiscrepancy.ID.Eq(id),
+			s.q.PayReconcileDiscrepancy.Status.Eq(consts.PayReconcileDiscrepancyStatusPending)).
+		Updates(map[string]interface{}{
+			"status":         status,
+			"resolve_remark": remark,
+			"resolve_time":   &now,
+		})
+	if err != nil {
+		return err
+	}
+	if result.RowsAffected == 0 {
+		return ErrReconcileDiscrepancyResolved
+	}
+	return nil
+}
+
+// GetBill 获得对账单
+func (s *PayReconcileService) GetBill(ctx context.Context, id int64) (*pay.PayReconcileBill, error) {
+	return s.q.PayReconcileBill.WithContext(ctx).Where(s.q.PayReconcileBill.ID.Eq(id)).First()
+}
+
+// GetBillPage 获得对账单分页
+func (s *PayReconcileService) GetBillPage(ctx context.Context, req *pay2.PayReconcileBillPageReq) (*pagination.PageResult[*pay.PayReconcileBill], error) {
+	q := s.q.PayReconcileBill.WithContext(ctx)
+	if req.AppID > 0 {
+		q = q.Where(s.q.PayReconcileBill.AppID.Eq(req.AppID))
+	}
+	if req.ChannelID > 0 {
+		q = q.Where(s.q.PayReconcileBill.ChannelID.Eq(req.ChannelID))
+	}
+	if req.ChannelType != "" {
+		q = q.Where(s.q.PayReconcileBill.ChannelType.Eq(req.ChannelType))
+	}
+	if req.Status != nil {
+		q = q.Where(s.q.PayReconcileBill.Status.Eq(*req.Status))
+	}
+	if start, end, ok := parseBillDateRange(req.BillDate); ok {
+		q = q.Where(s.q.PayReconcileBill.BillDate.Between(start, end))
+	}
+
+	total, err := q.Count()
+	if err != nil {
+		return nil, err
+	}
+	list, err := q.Limit(req.GetLimit()).Offset(req.GetOffset()).
+		Order(s.q.PayReconcileBill.BillDate.Desc(), s.q.PayReconcileBill.ID.Desc()).
+		Find()
+	if err != nil {
+		return nil, err
+	}
+	return &pagination.PageResult[*pay.PayReconcileBill]{
+		List:  list,
+		Total: total,
+	}, nil
+}
+
+// GetDiscrepancyPage 获得对账差异分页
+func (s *PayReconcileService) GetDiscrepancyPage(ctx context.Context, req *pay2.PayReconcileDiscrepancyPageReq) (*pagination.PageResult[*pay.PayReconcileDiscrepancy], error) {
+	q := s.buildDiscrepancyQuery(ctx, &req.PayReconcileDiscrepancyExportReq)
+	total, err := q.Count()
+	if err != nil {
+		return nil, err
+	}
+	list, err := q.Limit(req.GetLimit()).Offset(req.GetOffset()).Order(s.q.PayReconcileDiscrepancy.ID.Desc()).Find()
+	if err != nil {
+		return nil, err
+	}
+	return &pagination.PageResult[*pay.PayReconcileDiscrepancy]{
+		List:  list,
+		Total: total,
+	}, nil
+}
+
+// GetDiscrepancyList 获得对账差异列表 (Export)
+func (s *PayReconcileService) GetDiscrepancyList(ctx context.Context, req *pay2.PayReconcileDiscrepancyExportReq) ([]*pay.PayReconcileDiscrepancy, error) {
+	return s.buildDiscrepancyQuery(ctx, req).Order(s.q.PayReconcileDiscrepancy.ID.Desc()).Find()
+}
+
+func (s *PayReconcileService) buildDiscrepancyQuery(ctx context.Context, req *pay2.PayReconcileDiscrepancyExportReq) query.IPayReconcileDiscrepancyDo {
+	d := s.q.PayReconcileDiscrepancy
+	q := d.WithContext(ctx)
+	if req.BillID > 0 {
+		q = q.Where(d.BillID.Eq(req.BillID))
+	}
+	if req.AppID > 0 {
+		q = q.Where(d.AppID.Eq(req.AppID))
+	}
+	if req.ChannelType != "" {
+		q = q.Where(d.ChannelType.Eq(req.ChannelType))
+	}
+	if req.BizType != nil {
+		q = q.Where(d.BizType.Eq(*req.BizType))
+	}
+	if req.Type != nil {
+		q = q.Where(d.Type.Eq(*req.Type))
+	}
+	if req.Status != nil {
+		q = q.Where(d.Status.Eq(*req.Status))
+	}
+	if req.OutTradeNo != "" {
+		q = q.Where(d.OutTradeNo.Eq(req.OutTradeNo))
+	}
+	if req.ChannelOrderNo != "" {
+		q = q.Where(d.ChannelOrderNo.Eq(req.ChannelOrderNo))
+	}
+	if start, end, ok := parseBillDateRange(req.BillDate); ok {
+		q = q.Where(d.BillDate.Between(start, end))
+	}
+	return q
+}
+
+// discrepancyKey 差异的业务唯一标识，用于重新对账时保留忽略状态
+func discrepancyKey(d *pay.PayReconcileDiscrepancy) string {
+	return fmt.Sprintf("%d:%d:%s:%s:%s", d.BizType, d.Type, d.OutTradeNo, d.OutRefundNo, d.ChannelOrderNo)
+}
+
+// parseBillDateRange 解析 yyyy-MM-dd 格式的账单日期范围
+func parseBillDateRange(dates []string) (time.Time, time.Time, bool) {
+	if len(dates) != 2 {
+		return time.Time{}, time.Time{}, false
+	}
+	start, err1 := time.ParseInLocation(time.DateOnly, dates[0], time.Local)
+	end, err2 := time.ParseInLocation(time.DateOnly, dates[1], time.Local)
+	if err1 != nil || err2 != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
+
+// batchQuery 按批次执行单号 IN 查询，跳过空单号
+func batchQuery(nos []string, fn func(nos []string) error) error {
+	batch := make([]string, 0, reconcileQueryBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := fn(batch)
+		batch = batch[:0]
+		return err
+	}
+	for _, no := range nos {
+		if no == "" {
+			continue
+		}
+		batch = append(batch, no)
+		if len(batch) == reconcileQueryBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return flush()
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	stdErrors "errors"
 	"fmt"
+	"time"
 
 	"github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/pay"
 	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
@@ -17,6 +18,7 @@ import (
 	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PayRefundService struct {
@@ -29,7 +31,7 @@ type PayRefundService struct {
 }
 
 func NewPayRefundService(q *query.Query, appSvc *PayAppService, channelSvc *PayChannelService, orderSvc *PayOrderService, notifySvc *PayNotifyService, noDAO *payrepo.PayNoRedisDAO) *PayRefundService {
-	return &PayRefundService{
+	s := &PayRefundService{
 		q:          q,
 		appSvc:     appSvc,
 		channelSvc: channelSvc,
@@ -37,6 +39,8 @@ func NewPayRefundService(q *query.Query, appSvc *PayAppService, channelSvc *PayC
 		notifySvc:  notifySvc,
 		noDAO:      noDAO,
 	}
+	s.orderSvc.SetRefundService(s)
+	return s
 }
 
 // CreateRefund 创建退款单
@@ -75,6 +79,12 @@ func (s *PayRefundService) CreateRefund(ctx context.Context, reqDTO *pay.PayRefu
 		return 0, err
 	}
 
+	// 1.5 组合支付：按比例计算退回钱包的金额
+	walletRefundPrice, err := s.calculateWalletRefundPrice(ctx, payOrder, reqDTO.Price)
+	if err != nil {
+		return 0, err
+	}
+
 	// 2.1 创建退款单
 	// Generate Refund No (R + time + 6 digits)
 	no, err := s.noDAO.Generate(ctx, "R")
@@ -83,33 +93,40 @@ func (s *PayRefundService) CreateRefund(ctx context.Context, reqDTO *pay.PayRefu
 	}
 
 	refund := &payModel.PayRefund{
-		No:               no,
-		AppID:            app.ID,
-		OrderID:          payOrder.ID,
-		OrderNo:          payOrder.No,
-		MerchantOrderId:  reqDTO.MerchantOrderId,
-		MerchantRefundId: reqDTO.MerchantRefundId,
-		NotifyURL:        app.RefundNotifyURL,
-		Status:           consts.PayRefundStatusWaiting,
-		PayPrice:         payOrder.Price,
-		RefundPrice:      reqDTO.Price,
-		Reason:           reqDTO.Reason,
-		UserIP:           reqDTO.UserIP,
-		ChannelID:        payOrder.ChannelID,
-		ChannelCode:      payOrder.ChannelCode,
-		ChannelOrderNo:   payOrder.ChannelOrderNo,
+		No:                no,
+		AppID:             app.ID,
+		OrderID:           payOrder.ID,
+		OrderNo:           payOrder.No,
+		MerchantOrderId:   reqDTO.MerchantOrderId,
+		MerchantRefundId:  reqDTO.MerchantRefundId,
+		NotifyURL:         app.RefundNotifyURL,
+		Status:            consts.PayRefundStatusWaiting,
+		PayPrice:          payOrder.Price,
+		RefundPrice:       reqDTO.Price,
+		WalletRefundPrice: walletRefundPrice,
+		Reason:            reqDTO.Reason,
+		UserIP:            reqDTO.UserIP,
+		ChannelID:         payOrder.ChannelID,
+		ChannelCode:       payOrder.ChannelCode,
+		ChannelOrderNo:    payOrder.ChannelOrderNo,
 	}
 	if err := s.q.PayRefund.WithContext(ctx).Create(refund); err != nil {
 		return 0, err
 	}
 
-	// 2.2 向渠道发起退款申请
+	// 2.2 向渠道发起退款申请，组合支付只退渠道实付的部分；全部退回钱包时无需调用渠道
+	if refund.RefundPrice == refund.WalletRefundPrice {
+		if err := s.NotifyRefund(ctx, channel.ID, walletRefundResp(refund)); err != nil {
+			return 0, err
+		}
+		return refund.ID, nil
+	}
 	unifiedReqDTO := &client.UnifiedRefundReq{
 		OutTradeNo:  payOrder.No,
 		OutRefundNo: refund.No,
 		Reason:      reqDTO.Reason,
-		PayPrice:    payOrder.Price,
-		RefundPrice: reqDTO.Price,
+		PayPrice:    payOrder.Price - payOrder.WalletPrice,
+		RefundPrice: reqDTO.Price - walletRefundPrice,
 		NotifyURL:   s.genChannelRefundNotifyUrl(channel),
 	}
 	refundRespDTO, err := payClient.UnifiedRefund(ctx, unifiedReqDTO)
@@ -125,6 +142,96 @@ func (s *PayRefundService) CreateRefund(ctx context.Context, reqDTO *pay.PayRefu
 	return refund.ID, nil
 }
 
+// RefundDuplicatePay 自动退回重复支付的拓展单：拓展单支付成功，但未被支付订单采用
+// 只退渠道实付的部分，钱包部分由组合支付结算解冻；退款单不计入支付订单的退款金额，也不通知业务方；同一拓展单只退款一次
+func (s *PayRefundService) RefundDuplicatePay(ctx context.Context, orderExtension *payModel.PayOrderExtension) error {
+	// 1.1 校验是否为重复支付
+	payOrder, err := s.orderSvc.GetOrder(ctx, orderExtension.OrderID)
+	if err != nil {
+		return fmt.Errorf("支付订单不存在")
+	}
+	if orderExtension.Status != consts.PayOrderStatusSuccess || payOrder.ExtensionID == orderExtension.ID {
+		return nil
+	}
+	// 1.2 校验支付渠道
+	channel, err := s.channelSvc.GetChannel(ctx, orderExtension.ChannelID)
+	if err != nil {
+		return err
+	}
+	payClient := s.channelSvc.GetPayClient(channel.ID)
+	if payClient == nil {
+		return fmt.Errorf("渠道编号(%d) 找不到对应的支付客户端", channel.ID)
+	}
+
+	// 2.1 锁定拓展单后校验是否已退款并创建退款单，并发处理同一笔重复支付时只创建一笔退款；退款失败时重新发起
+	channelPrice := payOrder.Price - orderExtension.WalletPrice
+	var refund *payModel.PayRefund
+	err = s.q.Transaction(func(tx *query.Query) error {
+		e := tx.PayOrderExtension
+		if _, err := e.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where(e.ID.Eq(orderExtension.ID)).First(); err != nil {
+			return err
+		}
+		count, err := tx.PayRefund.WithContext(ctx).
+			Where(tx.PayRefund.OrderID.Eq(payOrder.ID), tx.PayRefund.OrderNo.Eq(orderExtension.No),
+				tx.PayRefund.Status.Neq(consts.PayRefundStatusFailure)).
+			Count()
+		if err != nil || count > 0 {
+			return err
+		}
+		no, err := s.noDAO.Generate(ctx, "R")
+		if err != nil {
+			return fmt.Errorf("failed to generate refund no: %w", err)
+		}
+		refund = &payModel.PayRefund{
+			No:               no,
+			AppID:            payOrder.AppID,
+			OrderID:          payOrder.ID,
+			OrderNo:          orderExtension.No,
+			MerchantOrderId:  payOrder.MerchantOrderId,
+			MerchantRefundId: no,
+			Status:           consts.PayRefundStatusWaiting,
+			PayPrice:         channelPrice,
+			RefundPrice:      channelPrice,
+			Reason:           duplicatePayRefundReason,
+			UserIP:           orderExtension.UserIP,
+			ChannelID:        channel.ID,
+			ChannelCode:      channel.Code,
+		}
+		return tx.PayRefund.WithContext(ctx).Create(refund)
+	})
+	if err != nil || refund == nil {
+		return err
+	}
+
+	// 2.2 向渠道发起退款申请，异常时由退款同步任务获取结果
+	refundRespDTO, err := payClient.UnifiedRefund(ctx, &client.UnifiedRefundReq{
+		OutTradeNo:  orderExtension.No,
+		OutRefundNo: refund.No,
+		Reason:      duplicatePayRefundReason,
+		PayPrice:    channelPrice,
+		RefundPrice: channelPrice,
+		NotifyURL:   s.genChannelRefundNotifyUrl(channel),
+	})
+	if err != nil {
+		fmt.Printf("[RefundDuplicatePay][退款 id(%d) 拓展单(%d) 发生异常: %v]\n", refund.ID, orderExtension.ID, err)
+		return nil
+	}
+	return s.NotifyRefund(ctx, channel.ID, refundRespDTO)
+}
+
+// duplicatePayRefundReason 重复支付自动退款的原因
+const duplicatePayRefundReason = "重复支付自动退款"
+
+// isDuplicatePayRefundTx 在事务内判断是否为重复支付的自动退款单：退款的拓展单不是支付订单最终采用的拓展单
+func isDuplicatePayRefundTx(ctx context.Context, tx *query.Query, refund *payModel.PayRefund) (bool, error) {
+	payOrder, err := tx.PayOrder.WithContext(ctx).Where(tx.PayOrder.ID.Eq(refund.OrderID)).First()
+	if err != nil {
+		return false, fmt.Errorf("支付订单不存在")
+	}
+	return payOrder.No != refund.OrderNo, nil
+}
+
 func (s *PayRefundService) validatePayOrderCanRefund(ctx context.Context, appId int64, reqDTO *pay.PayRefundCreateReq) (*payModel.PayOrder, error) {
 	// Query PayOrder
 	payOrder, err := s.q.PayOrder.WithContext(ctx).
@@ -276,7 +383,7 @@ func (s *PayRefundService) NotifyRefund(ctx context.Context, channelID int64, no
 	}
 
 	// 使用事务包装（对齐 Java @Transactional）
-	return s.q.Transaction(func(tx *query.Query) error {
+	err = s.q.Transaction(func(tx *query.Query) error {
 		// 情况一：退款成功
 		if notify.Status == consts.PayRefundStatusSuccess {
 			return s.notifyRefundSuccessTx(ctx, tx, channel, notify)
@@ -289,6 +396,21 @@ func (s *PayRefundService) NotifyRefund(ctx context.Context, channelID int64, no
 
 		return nil
 	})
+	if err != nil || notify.Status != consts.PayRefundStatusSuccess {
+		return err
+	}
+
+	// 组合支付：事务提交后退回钱包部分，失败时返回错误，由渠道回调重试或退款同步任务补偿；同一退款单只退回一次
+	refund, err := s.q.PayRefund.WithContext(ctx).
+		Where(s.q.PayRefund.AppID.Eq(channel.AppID), s.q.PayRefund.No.Eq(notify.OutRefundNo)).
+		First()
+	if err != nil {
+		return fmt.Errorf("退款订单不存在")
+	}
+	if refund.WalletRefundPrice <= 0 {
+		return nil
+	}
+	return s.refundCombinePay(ctx, refund)
 }
 
 // notifyRefundSuccessTx 在事务内处理退款成功
@@ -326,6 +448,12 @@ func (s *PayRefundService) notifyRefundSuccessTx(ctx context.Context, tx *query.
 		return fmt.Errorf("退款订单状态不是待退款")
 	}
 
+	// 重复支付的自动退款单，不计入支付订单的退款金额，也不通知业务方
+	duplicate, err := isDuplicatePayRefundTx(ctx, tx, refund)
+	if err != nil || duplicate {
+		return err
+	}
+
 	// 2. 更新订单退款金额
 	if err := s.orderSvc.UpdateOrderRefundPrice(ctx, refund.OrderID, refund.RefundPrice); err != nil {
 		return err
@@ -373,6 +501,12 @@ func (s *PayRefundService) notifyRefundFailureTx(ctx context.Context, tx *query.
 		return fmt.Errorf("退款订单状态不是待退款")
 	}
 
+	// 重复支付的自动退款单，不通知业务方
+	duplicate, err := isDuplicatePayRefundTx(ctx, tx, refund)
+	if err != nil || duplicate {
+		return err
+	}
+
 	// 2. 插入退款通知记录
 	s.notifySvc.CreatePayNotifyTask(ctx, PayNotifyTypeRefund, refund.ID)
 
@@ -388,9 +522,6 @@ func (s *PayRefundService) SyncRefund(ctx context.Context) (int, error) {
 	if err != nil {
 		return 0, err
 	}
-	if len(refunds) == 0 {
-		return 0, nil
-	}
 
 	// 2. 遍历执行
 	count := 0
@@ -404,9 +535,35 @@ func (s *PayRefundService) SyncRefund(ctx context.Context) (int, error) {
 			count++
 		}
 	}
+
+	// 3. 补偿退回钱包部分失败的组合支付退款单
+	if err := s.syncRefundCombinePay(ctx, time.Now().Add(-refundCombinePaySyncWindow)); err != nil {
+		return count, err
+	}
 	return count, nil
 }
 
+// refundCombinePaySyncWindow 补偿组合支付退款单退回钱包的时间范围
+const refundCombinePaySyncWindow = 24 * time.Hour
+
+// syncRefundCombinePay 补偿指定时间之后退款成功、钱包部分可能尚未退回的组合支付退款单，失败时仅打印日志，等待下次补偿
+func (s *PayRefundService) syncRefundCombinePay(ctx context.Context, minSuccessTime time.Time) error {
+	refunds, err := s.q.PayRefund.WithContext(ctx).
+		Where(s.q.PayRefund.Status.Eq(consts.PayRefundStatusSuccess),
+			s.q.PayRefund.WalletRefundPrice.Gt(0),
+			s.q.PayRefund.SuccessTime.Gte(minSuccessTime)).
+		Find()
+	if err != nil {
+		return err
+	}
+	for _, refund := range refunds {
+		if err := s.refundCombinePay(ctx, refund); err != nil {
+			fmt.Printf("[syncRefundCombinePay][退款订单(%d) 退回钱包失败: %v]\n", refund.ID, err)
+		}
+	}
+	return nil
+}
+
 func (s *PayRefundService) syncRefund(ctx context.Context, refund *payModel.PayRefund) (bool, error) {
 	// 1.1 查询退款订单信息
 	payClient := s.channelSvc.GetPayClient(refund.ChannelID)
@@ -414,9 +571,13 @@ func (s *PayRefundService) syncRefund(ctx context.Context, refund *payModel.PayR
 		return false, fmt.Errorf("渠道编号(%d) 找不到对应的支付客户端", refund.ChannelID)
 	}
 
-	respDTO, err := payClient.GetRefund(ctx, refund.OrderNo, refund.No)
-	if err != nil {
-		return false, err
+	// 全部退回钱包的退款单没有渠道退款，直接视为渠道退款成功
+	respDTO := walletRefundResp(refund)
+	if refund.RefundPrice != refund.WalletRefundPrice {
+		var err error
+		if respDTO, err = payClient.GetRefund(ctx, refund.OrderNo, refund.No); err != nil {
+			return false, err
+		}
 	}
 
 	// 1.2 回调退款结果
@@ -427,3 +588,45 @@ func (s *PayRefundService) syncRefund(ctx context.Context, refund *payModel.PayR
 	// 2. 如果同步到，则返回 true
 	return respDTO.Status == consts.PayRefundStatusSuccess || respDTO.Status == consts.PayRefundStatusFailure, nil
 }
+
+// calculateWalletRefundPrice 计算组合支付订单退回钱包的金额
+// 按累计退款金额占订单金额的比例计算钱包应退的累计金额，扣除已退回钱包的金额；全额退款时钱包金额恰好退完
+func (s *PayRefundService) calculateWalletRefundPrice(ctx context.Context, payOrder *payModel.PayOrder, refundPrice int) (int, error) {
+	if payOrder.WalletPrice <= 0 {
+		return 0, nil
+	}
+	var refundedPrice int
+	err := s.q.PayRefund.WithContext(ctx).
+		Select(s.q.PayRefund.WalletRefundPrice.Sum().IfNull(0)).
+		Where(s.q.PayRefund.OrderID.Eq(payOrder.ID), s.q.PayRefund.Status.Eq(consts.PayRefundStatusSuccess)).
+		Scan(&refundedPrice)
+	if err != nil {
+		return 0, err
+	}
+	return walletRefundPrice(payOrder, refundPrice, refundedPrice), nil
+}
+
+// walletRefundPrice 按订单已退款金额、本次退款金额与已退回钱包的金额，计算本次退回钱包的金额
+func walletRefundPrice(payOrder *payModel.PayOrder, refundPrice, refundedWalletPrice int) int {
+	target := int(int64(payOrder.RefundPrice+refundPrice) * int64(payOrder.WalletPrice) / int64(payOrder.Price))
+	return min(max(target-refundedWalletPrice, 0), refundPrice)
+}
+
+// refundCombinePay 将退款单中钱包部分的金额退回钱包
+func (s *PayRefundService) refundCombinePay(ctx context.Context, refund *payModel.PayRefund) error {
+	_, combineClient := s.channelSvc.GetCombinePayClient(ctx, refund.AppID)
+	if combineClient == nil {
+		return fmt.Errorf("支付应用(%d) 找不到钱包渠道的支付客户端", refund.AppID)
+	}
+	return combineClient.RefundCombinePay(ctx, refund.No)
+}
+
+// walletRefundResp 全部退回钱包的退款单，构造渠道退款成功的结果
+func walletRefundResp(refund *payModel.PayRefund) *client.RefundResp {
+	return &client.RefundResp{
+		Status:      consts.PayRefundStatusSuccess,
+		OutTradeNo:  refund.OrderNo,
+		OutRefundNo: refund.No,
+		SuccessTime: time.Now(),
+	}
+}
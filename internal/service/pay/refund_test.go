@@ -0,0 +1,35 @@
+package pay
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	payModel "github.com/wxlbd/ruoyi-mall-go/internal/model/pay"
+)
+
+// TestWalletRefundPrice 验证组合支付订单按比例计算退回钱包的金额，多次部分退款后全额退完
+func TestWalletRefundPrice(t *testing.T) {
+	testCases := []struct {
+		name                string
+		price               int
+		walletPrice         int
+		refundedPrice       int
+		refundedWalletPrice int
+		refundPrice         int
+		expected            int
+	}{
+		{name: "全额退款", price: 1000, walletPrice: 300, refundPrice: 1000, expected: 300},
+		{name: "首次部分退款", price: 1000, walletPrice: 300, refundPrice: 500, expected: 150},
+		{name: "剩余全部退款", price: 1000, walletPrice: 300, refundedPrice: 500, refundedWalletPrice: 150, refundPrice: 500, expected: 150},
+		{name: "比例向下取整", price: 1000, walletPrice: 333, refundPrice: 1, expected: 0},
+		{name: "取整差额在最后一次补齐", price: 1000, walletPrice: 333, refundedPrice: 999, refundedWalletPrice: 332, refundPrice: 1, expected: 1},
+		{name: "钱包金额不超过本次退款金额", price: 1000, walletPrice: 1000, refundPrice: 200, expected: 200},
+		{name: "已多退钱包时本次不退钱包", price: 1000, walletPrice: 300, refundedPrice: 500, refundedWalletPrice: 200, refundPrice: 100, expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			payOrder := &payModel.PayOrder{Price: tc.price, WalletPrice: tc.walletPrice, RefundPrice: tc.refundedPrice}
+			assert.Equal(t, tc.expected, walletRefundPrice(payOrder, tc.refundPrice, tc.refundedWalletPrice))
+		})
+	}
+}
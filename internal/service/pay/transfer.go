@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 
 	reqPay "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/pay"
 	respPay "github.com/wxlbd/ruoyi-mall-go/internal/api/contract/admin/pay"
@@ -11,6 +12,7 @@ import (
 	modelPay "github.com/wxlbd/ruoyi-mall-go/internal/model/pay"
 	repoPay "github.com/wxlbd/ruoyi-mall-go/internal/repo/pay"
 	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client"
+	"github.com/wxlbd/ruoyi-mall-go/pkg/config"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
 
 	"github.com/samber/lo"
@@ -238,6 +240,7 @@ func (s *PayTransferService) CreateTransfer(ctx context.Context, req *reqPay.Pay
 		UserAccount:   transfer.UserAccount,
 		UserIP:        req.UserIP,
 		ChannelExtras: req.ChannelExtras,
+		NotifyURL:     s.genChannelTransferNotifyUrl(channel),
 	}
 	unifiedTransferResp, err = payClient.UnifiedTransfer(ctx, unifiedReq)
 	if err != nil {
@@ -261,6 +264,12 @@ func (s *PayTransferService) CreateTransfer(ctx context.Context, req *reqPay.Pay
 	}, nil
 }
 
+// genChannelTransferNotifyUrl 根据支付渠道生成转账回调地址
+// 对齐 Java: payProperties.getTransferNotifyUrl() + "/" + channel.getId()
+func (s *PayTransferService) genChannelTransferNotifyUrl(channel *modelPay.PayChannel) string {
+	return fmt.Sprintf("%s/%d", config.C.Pay.TransferNotifyURL, channel.ID)
+}
+
 // validateTransferCanCreate 校验转账单是否可以创建
 // 对齐 Java: PayTransferServiceImpl.validateTransferCanCreate
 func (s *PayTransferService) validateTransferCanCreate(ctx context.Context, req *reqPay.PayTransferCreateReq, appId int64) (*modelPay.PayTransfer, error) {
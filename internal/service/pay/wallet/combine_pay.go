@@ -0,0 +1,135 @@
+package wallet
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"strconv"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model/pay"
+	"github.com/wxlbd/ruoyi-mall-go/internal/repo/query"
+
+	"gorm.io/gorm"
+)
+
+// 组合支付：钱包部分先冻结，渠道支付成功后扣款，渠道支付关闭后解冻
+// 以支付订单拓展的钱包状态作为幂等条件，状态迁移与钱包变动在同一事务内完成
+
+// FreezeCombinePay 冻结钱包金额，余额不足时冻结失败
+func (c *WalletPayClient) FreezeCombinePay(ctx context.Context, outTradeNo string) error {
+	return c.updateCombinePayStatus(ctx, outTradeNo, consts.PayOrderWalletStatusNone, consts.PayOrderWalletStatusFrozen,
+		func(tx *query.Query, orderExtension *pay.PayOrderExtension) error {
+			w := tx.PayWallet
+			res, err := w.WithContext(ctx).
+				Where(w.ID.Eq(orderExtension.WalletID), w.Balance.Gte(orderExtension.WalletPrice)).
+				Updates(map[string]interface{}{
+					"balance":      gorm.Expr("balance - ?", orderExtension.WalletPrice),
+					"freeze_price": gorm.Expr("freeze_price + ?", orderExtension.WalletPrice),
+				})
+			if err != nil {
+				return err
+			}
+			if res.RowsAffected == 0 {
+				return ErrWalletBalanceNotEnough
+			}
+			return nil
+		})
+}
+
+// ConfirmCombinePay 冻结金额转为支出，并记录支付流水；流水业务编号为支付订单编号，与钱包渠道支付一致
+func (c *WalletPayClient) ConfirmCombinePay(ctx context.Context, outTradeNo string) error {
+	return c.updateCombinePayStatus(ctx, outTradeNo, consts.PayOrderWalletStatusFrozen, consts.PayOrderWalletStatusPaid,
+		func(tx *query.Query, orderExtension *pay.PayOrderExtension) error {
+			w := tx.PayWallet
+			res, err := w.WithContext(ctx).
+				Where(w.ID.Eq(orderExtension.WalletID), w.FreezePrice.Gte(orderExtension.WalletPrice)).
+				Updates(map[string]interface{}{
+					"freeze_price":  gorm.Expr("freeze_price - ?", orderExtension.WalletPrice),
+					"total_expense": gorm.Expr("total_expense + ?", orderExtension.WalletPrice),
+				})
+			if err != nil {
+				return err
+			}
+			if res.RowsAffected == 0 {
+				return stdErrors.New("insufficient frozen balance to confirm")
+			}
+			wallet, err := w.WithContext(ctx).Where(w.ID.Eq(orderExtension.WalletID)).First()
+			if err != nil {
+				return err
+			}
+			_, err = NewPayWalletTransactionService(tx).CreateWalletTransaction(ctx, wallet, consts.PayWalletBizTypePayment,
+				strconv.FormatInt(orderExtension.OrderID, 10), "组合支付", -orderExtension.WalletPrice)
+			return err
+		})
+}
+
+// UnfreezeCombinePay 冻结金额退回余额
+func (c *WalletPayClient) UnfreezeCombinePay(ctx context.Context, outTradeNo string) error {
+	return c.updateCombinePayStatus(ctx, outTradeNo, consts.PayOrderWalletStatusFrozen, consts.PayOrderWalletStatusUnfrozen,
+		func(tx *query.Query, orderExtension *pay.PayOrderExtension) error {
+			w := tx.PayWallet
+			res, err := w.WithContext(ctx).
+				Where(w.ID.Eq(orderExtension.WalletID), w.FreezePrice.Gte(orderExtension.WalletPrice)).
+				Updates(map[string]interface{}{
+					"balance":      gorm.Expr("balance + ?", orderExtension.WalletPrice),
+					"freeze_price": gorm.Expr("freeze_price - ?", orderExtension.WalletPrice),
+				})
+			if err != nil {
+				return err
+			}
+			if res.RowsAffected == 0 {
+				return stdErrors.New("insufficient frozen balance to unfreeze")
+			}
+			return nil
+		})
+}
+
+// RefundCombinePay 将钱包部分的退款金额退回支付时扣款的钱包，同一退款单只退回一次
+func (c *WalletPayClient) RefundCombinePay(ctx context.Context, outRefundNo string) error {
+	refund, err := c.walletSvc.q.PayRefund.WithContext(ctx).
+		Where(c.walletSvc.q.PayRefund.No.Eq(outRefundNo)).
+		First()
+	if err != nil {
+		return fmt.Errorf("退款订单不存在: %w", err)
+	}
+	if refund.WalletRefundPrice <= 0 {
+		return nil
+	}
+	payTransaction, err := c.getTransaction(ctx, consts.PayWalletBizTypePayment, refund.OrderID)
+	if err != nil {
+		return err
+	}
+	if payTransaction == nil {
+		return ErrWalletTransactionNotFound
+	}
+	// 锁定钱包后校验退款流水并退回，已退回时不再重复入账
+	_, err = c.walletSvc.AddWalletBalance(ctx, payTransaction.WalletID, strconv.FormatInt(refund.ID, 10),
+		consts.PayWalletBizTypePaymentRefund, refund.WalletRefundPrice)
+	return err
+}
+
+// updateCombinePayStatus 迁移组合支付的钱包状态，并在同一事务内变动钱包；已处于目标状态时直接返回
+func (c *WalletPayClient) updateCombinePayStatus(ctx context.Context, outTradeNo string, fromStatus, toStatus int,
+	updateWallet func(tx *query.Query, orderExtension *pay.PayOrderExtension) error) error {
+	return c.walletSvc.q.Transaction(func(tx *query.Query) error {
+		e := tx.PayOrderExtension
+		orderExtension, err := e.WithContext(ctx).Where(e.No.Eq(outTradeNo)).First()
+		if err != nil {
+			return fmt.Errorf("支付订单拓展不存在: %w", err)
+		}
+		if orderExtension.WalletPrice <= 0 || orderExtension.WalletStatus == toStatus {
+			return nil
+		}
+		res, err := e.WithContext(ctx).
+			Where(e.ID.Eq(orderExtension.ID), e.WalletStatus.Eq(fromStatus)).
+			Update(e.WalletStatus, toStatus)
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected == 0 {
+			return fmt.Errorf("支付订单拓展(%d) 钱包状态(%d) 不能变更为(%d)", orderExtension.ID, orderExtension.WalletStatus, toStatus)
+		}
+		return updateWallet(tx, orderExtension)
+	})
+}
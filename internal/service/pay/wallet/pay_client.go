@@ -0,0 +1,260 @@
+package wallet
+
+import (
+	"context"
+	stdErrors "errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model/pay"
+	"github.com/wxlbd/ruoyi-mall-go/internal/repo/query"
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client"
+
+	"gorm.io/gorm"
+)
+
+// WalletPayClient 钱包支付客户端
+// 对齐 Java: WalletPayClient
+// 支付、退款、转账均同步结算到会员钱包，不存在异步通知；查询以钱包流水为准
+type WalletPayClient struct {
+	*client.BaseClient
+	walletSvc *PayWalletService
+}
+
+func (s *PayWalletService) newWalletPayClient(channelID int64, config string) (client.PayClient, error) {
+	return &WalletPayClient{
+		BaseClient: client.NewBaseClient(channelID, consts.PayChannelWallet, config),
+		walletSvc:  s,
+	}, nil
+}
+
+func (c *WalletPayClient) Init() error {
+	return nil
+}
+
+// UnifiedOrder 扣减钱包余额完成支付，钱包编号来自渠道额外参数
+func (c *WalletPayClient) UnifiedOrder(ctx context.Context, req *client.UnifiedOrderReq) (*client.OrderResp, error) {
+	walletID, err := strconv.ParseInt(req.ChannelExtras[consts.PayChannelExtrasWalletID], 10, 64)
+	if err != nil || walletID <= 0 {
+		return nil, ErrWalletNotFound
+	}
+	orderExtension, err := c.walletSvc.q.PayOrderExtension.WithContext(ctx).
+		Where(c.walletSvc.q.PayOrderExtension.No.Eq(req.OutTradeNo)).
+		First()
+	if err != nil {
+		return nil, fmt.Errorf("支付订单拓展不存在: %w", err)
+	}
+
+	// 同一支付订单只扣减一次，重复提交时返回已有的支付流水
+	transaction, err := c.walletSvc.ReduceWalletBalance(ctx, walletID, orderExtension.OrderID,
+		consts.PayWalletBizTypePayment, req.Price)
+	if err != nil {
+		return nil, err
+	}
+	return toOrderResp(req.OutTradeNo, transaction), nil
+}
+
+// UnifiedRefund 将退款金额退回支付时扣款的钱包
+func (c *WalletPayClient) UnifiedRefund(ctx context.Context, req *client.UnifiedRefundReq) (*client.RefundResp, error) {
+	refund, err := c.walletSvc.q.PayRefund.WithContext(ctx).
+		Where(c.walletSvc.q.PayRefund.No.Eq(req.OutRefundNo)).
+		First()
+	if err != nil {
+		return nil, fmt.Errorf("退款订单不存在: %w", err)
+	}
+	payTransaction, err := c.getTransaction(ctx, consts.PayWalletBizTypePayment, refund.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if payTransaction == nil {
+		return nil, ErrWalletTransactionNotFound
+	}
+
+	// 同一退款单只退回一次
+	transaction, err := c.walletSvc.AddWalletBalance(ctx, payTransaction.WalletID, strconv.FormatInt(refund.ID, 10),
+		consts.PayWalletBizTypePaymentRefund, req.RefundPrice)
+	if err != nil {
+		return nil, err
+	}
+	return toRefundResp(req.OutTradeNo, req.OutRefundNo, transaction), nil
+}
+
+// UnifiedTransfer 转账到钱包，收款人账号为钱包编号
+func (c *WalletPayClient) UnifiedTransfer(ctx context.Context, req *client.UnifiedTransferReq) (*client.TransferResp, error) {
+	walletID, err := strconv.ParseInt(req.UserAccount, 10, 64)
+	if err != nil || walletID <= 0 {
+		return nil, ErrWalletNotFound
+	}
+	transfer, err := c.walletSvc.q.PayTransfer.WithContext(ctx).
+		Where(c.walletSvc.q.PayTransfer.No.Eq(req.OutTradeNo)).
+		First()
+	if err != nil {
+		return nil, fmt.Errorf("转账单不存在: %w", err)
+	}
+
+	// 同一转账单只入账一次
+	transaction, err := c.walletSvc.AddWalletBalance(ctx, walletID, strconv.FormatInt(transfer.ID, 10),
+		consts.PayWalletBizTypeTransfer, req.Price)
+	if err != nil {
+		return nil, err
+	}
+	return toTransferResp(req.OutTradeNo, transaction), nil
+}
+
+// GetOrder 查询支付结果，存在支付流水即支付成功，否则视为关闭
+func (c *WalletPayClient) GetOrder(ctx context.Context, outTradeNo string) (*client.OrderResp, error) {
+	orderExtension, err := c.walletSvc.q.PayOrderExtension.WithContext(ctx).
+		Where(c.walletSvc.q.PayOrderExtension.No.Eq(outTradeNo)).
+		First()
+	if err != nil {
+		return nil, fmt.Errorf("支付订单拓展不存在: %w", err)
+	}
+	transaction, err := c.getTransaction(ctx, consts.PayWalletBizTypePayment, orderExtension.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	return toOrderResp(outTradeNo, transaction), nil
+}
+
+// ClosedOrder 钱包支付同步完成，不存在待支付的交易；存在支付流水时关闭失败
+func (c *WalletPayClient) ClosedOrder(ctx context.Context, outTradeNo string) error {
+	resp, err := c.GetOrder(ctx, outTradeNo)
+	if err != nil {
+		return err
+	}
+	if resp.Status == consts.PayOrderStatusSuccess {
+		return stdErrors.New("钱包支付订单已支付，无法关闭")
+	}
+	return nil
+}
+
+// GetRefund 查询退款结果，存在退款流水即退款成功，否则视为失败
+func (c *WalletPayClient) GetRefund(ctx context.Context, outTradeNo, outRefundNo string) (*client.RefundResp, error) {
+	refund, err := c.walletSvc.q.PayRefund.WithContext(ctx).
+		Where(c.walletSvc.q.PayRefund.No.Eq(outRefundNo)).
+		First()
+	if err != nil {
+		return nil, fmt.Errorf("退款订单不存在: %w", err)
+	}
+	transaction, err := c.getTransaction(ctx, consts.PayWalletBizTypePaymentRefund, refund.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toRefundResp(outTradeNo, outRefundNo, transaction), nil
+}
+
+// GetTransfer 查询转账结果，存在转账流水即转账成功，否则视为关闭
+func (c *WalletPayClient) GetTransfer(ctx context.Context, outTradeNo string) (*client.TransferResp, error) {
+	transfer, err := c.walletSvc.q.PayTransfer.WithContext(ctx).
+		Where(c.walletSvc.q.PayTransfer.No.Eq(outTradeNo)).
+		First()
+	if err != nil {
+		return nil, fmt.Errorf("转账单不存在: %w", err)
+	}
+	transaction, err := c.getTransaction(ctx, consts.PayWalletBizTypeTransfer, transfer.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toTransferResp(outTradeNo, transaction), nil
+}
+
+func (c *WalletPayClient) ParseOrderNotify(req *client.NotifyData) (*client.OrderResp, error) {
+	return nil, stdErrors.New("钱包支付无异步通知")
+}
+
+func (c *WalletPayClient) ParseRefundNotify(req *client.NotifyData) (*client.RefundResp, error) {
+	return nil, stdErrors.New("钱包退款无异步通知")
+}
+
+func (c *WalletPayClient) ParseTransferNotify(req *client.NotifyData) (*client.TransferResp, error) {
+	return nil, stdErrors.New("钱包转账无异步通知")
+}
+
+// getTransaction 获得业务对应的钱包流水，不存在时返回 nil
+func (c *WalletPayClient) getTransaction(ctx context.Context, bizType int, bizID int64) (*pay.PayWalletTransaction, error) {
+	return getWalletTransaction(ctx, c.walletSvc.q, bizType, bizID)
+}
+
+// getWalletTransaction 在指定查询（可为事务）中获得业务对应的钱包流水，不存在时返回 nil
+func getWalletTransaction(ctx context.Context, tx *query.Query, bizType int, bizID int64) (*pay.PayWalletTransaction, error) {
+	return getWalletTransactionByBizID(ctx, tx, bizType, strconv.FormatInt(bizID, 10))
+}
+
+// getWalletTransactionByBizID 在指定查询（可为事务）中按业务编号获得钱包流水，不存在时返回 nil
+func getWalletTransactionByBizID(ctx context.Context, tx *query.Query, bizType int, bizID string) (*pay.PayWalletTransaction, error) {
+	q := tx.PayWalletTransaction
+	transaction, err := q.WithContext(ctx).
+		Where(q.BizType.Eq(bizType), q.BizID.Eq(bizID)).
+		First()
+	if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return transaction, err
+}
+
+func toOrderResp(outTradeNo string, transaction *pay.PayWalletTransaction) *client.OrderResp {
+	if transaction == nil {
+		return &client.OrderResp{
+			Status:           consts.PayOrderStatusClosed,
+			OutTradeNo:       outTradeNo,
+			ChannelErrorCode: "WALLET_TRANSACTION_NOT_FOUND",
+			ChannelErrorMsg:  "钱包支付流水不存在",
+		}
+	}
+	return &client.OrderResp{
+		Status:         consts.PayOrderStatusSuccess,
+		OutTradeNo:     outTradeNo,
+		ChannelOrderNo: transaction.No,
+		ChannelUserID:  strconv.FormatInt(transaction.WalletID, 10),
+		SuccessTime:    successTime(transaction),
+		RawData:        transaction,
+	}
+}
+
+func toRefundResp(outTradeNo, outRefundNo string, transaction *pay.PayWalletTransaction) *client.RefundResp {
+	if transaction == nil {
+		return &client.RefundResp{
+			Status:           consts.PayRefundStatusFailure,
+			OutTradeNo:       outTradeNo,
+			OutRefundNo:      outRefundNo,
+			ChannelErrorCode: "WALLET_TRANSACTION_NOT_FOUND",
+			ChannelErrorMsg:  "钱包退款流水不存在",
+		}
+	}
+	return &client.RefundResp{
+		Status:          consts.PayRefundStatusSuccess,
+		OutTradeNo:      outTradeNo,
+		OutRefundNo:     outRefundNo,
+		ChannelRefundNo: transaction.No,
+		SuccessTime:     successTime(transaction),
+		RawData:         transaction,
+	}
+}
+
+func toTransferResp(outTradeNo string, transaction *pay.PayWalletTransaction) *client.TransferResp {
+	if transaction == nil {
+		return &client.TransferResp{
+			Status:           consts.PayTransferStatusClosed,
+			OutTradeNo:       outTradeNo,
+			ChannelErrorCode: "WALLET_TRANSACTION_NOT_FOUND",
+			ChannelErrorMsg:  "钱包转账流水不存在",
+		}
+	}
+	return &client.TransferResp{
+		Status:            consts.PayTransferStatusSuccess,
+		OutTradeNo:        outTradeNo,
+		ChannelTransferNo: transaction.No,
+		SuccessTime:       successTime(transaction),
+		RawData:           transaction,
+	}
+}
+
+// successTime 以流水创建时间作为成功时间
+func successTime(transaction *pay.PayWalletTransaction) time.Time {
+	if transaction.CreateTime.IsZero() {
+		return time.Now()
+	}
+	return transaction.CreateTime
+}
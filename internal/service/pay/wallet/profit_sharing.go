@@ -0,0 +1,157 @@
+package wallet
+
+import (
+	"context"
+	stdErrors "errors"
+	"strconv"
+
+	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
+	"github.com/wxlbd/ruoyi-mall-go/internal/model/pay"
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client"
+
+	"gorm.io/gorm"
+)
+
+// 钱包分账：内部接收方的分账金额直接记入会员钱包，回退时从钱包扣回，均同步完成
+// 钱包流水以分账明细单号、回退单号作为业务编号，重复请求不会重复入账
+
+// AddProfitSharingReceiver 校验接收方钱包存在
+func (c *WalletPayClient) AddProfitSharingReceiver(ctx context.Context, req *client.ProfitSharingReceiverReq) error {
+	_, err := c.getSharingWallet(ctx, req.Account)
+	return err
+}
+
+// UnifiedProfitSharing 将分账金额记入各接收方钱包
+func (c *WalletPayClient) UnifiedProfitSharing(ctx context.Context, req *client.UnifiedProfitSharingReq) (*client.ProfitSharingResp, error) {
+	resp := &client.ProfitSharingResp{
+		Status:       consts.PayProfitSharingStatusSuccess,
+		OutSharingNo: req.OutSharingNo,
+	}
+	for _, item := range req.Items {
+		itemResp, err := c.profitSharingItem(ctx, item)
+		if err != nil {
+			return nil, err
+		}
+		resp.Items = append(resp.Items, itemResp)
+	}
+	return resp, nil
+}
+
+// GetProfitSharing 查询分账结果，存在入账流水即分账成功
+func (c *WalletPayClient) GetProfitSharing(ctx context.Context, req *client.UnifiedProfitSharingReq) (*client.ProfitSharingResp, error) {
+	resp := &client.ProfitSharingResp{
+		Status:       consts.PayProfitSharingStatusSuccess,
+		OutSharingNo: req.OutSharingNo,
+	}
+	for _, item := range req.Items {
+		itemResp := &client.ProfitSharingItemResp{
+			Type:    item.Type,
+			Account: item.Account,
+			Price:   item.Price,
+			Status:  consts.PayProfitSharingStatusFailure,
+		}
+		transaction, err := getWalletTransactionByBizID(ctx, c.walletSvc.q, consts.PayWalletBizTypeProfitSharing, item.OutDetailNo)
+		if err != nil {
+			return nil, err
+		}
+		if transaction != nil {
+			itemResp.Status = consts.PayProfitSharingStatusSuccess
+			itemResp.ChannelDetailNo = transaction.No
+			itemResp.SuccessTime = successTime(transaction)
+		} else {
+			itemResp.FailReason = "钱包分账流水不存在"
+		}
+		resp.Items = append(resp.Items, itemResp)
+	}
+	return resp, nil
+}
+
+// UnifiedProfitSharingReturn 从接收方钱包扣回分账金额，余额不足时回退失败
+func (c *WalletPayClient) UnifiedProfitSharingReturn(ctx context.Context, req *client.UnifiedProfitSharingReturnReq) (*client.ProfitSharingReturnResp, error) {
+	wallet, err := c.getSharingWallet(ctx, req.Receiver.Account)
+	if err != nil {
+		return toProfitSharingReturnFailureResp(req.OutReturnNo, err), nil
+	}
+	// 同一回退单只扣回一次，扣回前锁定钱包并校验余额
+	transaction, err := c.walletSvc.AddWalletBalance(ctx, wallet.ID, req.OutReturnNo,
+		consts.PayWalletBizTypeProfitSharingReturn, -req.Price)
+	if stdErrors.Is(err, ErrWalletBalanceNotEnough) {
+		return toProfitSharingReturnFailureResp(req.OutReturnNo, err), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toProfitSharingReturnResp(req.OutReturnNo, transaction), nil
+}
+
+// GetProfitSharingReturn 查询分账回退结果，存在扣回流水即回退成功
+func (c *WalletPayClient) GetProfitSharingReturn(ctx context.Context, req *client.UnifiedProfitSharingReturnReq) (*client.ProfitSharingReturnResp, error) {
+	transaction, err := getWalletTransactionByBizID(ctx, c.walletSvc.q, consts.PayWalletBizTypeProfitSharingReturn, req.OutReturnNo)
+	if err != nil {
+		return nil, err
+	}
+	return toProfitSharingReturnResp(req.OutReturnNo, transaction), nil
+}
+
+// profitSharingItem 单个明细入账，钱包不存在时该明细分账失败
+// 以明细单号作为业务编号，同一分账单中同一钱包的多条明细分别入账
+func (c *WalletPayClient) profitSharingItem(ctx context.Context, item *client.ProfitSharingItemReq) (*client.ProfitSharingItemResp, error) {
+	resp := &client.ProfitSharingItemResp{
+		Type:    item.Type,
+		Account: item.Account,
+		Price:   item.Price,
+	}
+	wallet, err := c.getSharingWallet(ctx, item.Account)
+	if err != nil {
+		resp.Status = consts.PayProfitSharingStatusFailure
+		resp.FailReason = err.Error()
+		return resp, nil
+	}
+	transaction, err := c.walletSvc.AddWalletBalance(ctx, wallet.ID, item.OutDetailNo,
+		consts.PayWalletBizTypeProfitSharing, item.Price)
+	if err != nil {
+		return nil, err
+	}
+	resp.Status = consts.PayProfitSharingStatusSuccess
+	resp.ChannelDetailNo = transaction.No
+	resp.SuccessTime = successTime(transaction)
+	return resp, nil
+}
+
+// getSharingWallet 获得分账接收方钱包，账号为钱包编号
+func (c *WalletPayClient) getSharingWallet(ctx context.Context, account string) (*pay.PayWallet, error) {
+	walletID, err := strconv.ParseInt(account, 10, 64)
+	if err != nil || walletID <= 0 {
+		return nil, ErrWalletNotFound
+	}
+	wallet, err := c.walletSvc.GetWallet(ctx, walletID)
+	if stdErrors.Is(err, gorm.ErrRecordNotFound) || (err == nil && wallet == nil) {
+		return nil, ErrWalletNotFound
+	}
+	return wallet, err
+}
+
+func toProfitSharingReturnResp(outReturnNo string, transaction *pay.PayWalletTransaction) *client.ProfitSharingReturnResp {
+	if transaction == nil {
+		return &client.ProfitSharingReturnResp{
+			Status:          consts.PayProfitSharingStatusFailure,
+			OutReturnNo:     outReturnNo,
+			ChannelErrorMsg: "钱包分账回退流水不存在",
+		}
+	}
+	return &client.ProfitSharingReturnResp{
+		Status:          consts.PayProfitSharingStatusSuccess,
+		OutReturnNo:     outReturnNo,
+		ChannelReturnNo: transaction.No,
+		SuccessTime:     successTime(transaction),
+		RawData:         transaction,
+	}
+}
+
+func toProfitSharingReturnFailureResp(outReturnNo string, err error) *client.ProfitSharingReturnResp {
+	return &client.ProfitSharingReturnResp{
+		Status:          consts.PayProfitSharingStatusFailure,
+		OutReturnNo:     outReturnNo,
+		ChannelErrorMsg: err.Error(),
+	}
+}
@@ -111,29 +111,29 @@ func (s *PayWalletRechargeService) UpdateWalletRechargerPaid(ctx context.Context
 		return stdErrors.New("支付订单未支付")
 	}
 
-	// 2. 更新钱包充值的支付状态
+	// 2. 更新钱包充值的支付状态，并在同一事务内增加钱包余额
 	now := time.Now()
-	res, err := s.q.PayWalletRecharge.WithContext(ctx).
-		Where(s.q.PayWalletRecharge.ID.Eq(id), s.q.PayWalletRecharge.PayStatus.Is(false)).
-		Updates(map[string]interface{}{
-			"pay_status":       true,
-			"pay_order_id":     payOrderID,
-			"pay_time":         now,
-			"pay_channel_code": payOrder.ChannelCode,
-		})
-	if err != nil {
-		return err
-	}
-	if res.RowsAffected == 0 {
-		return stdErrors.New("更新充值状态失败(非未支付状态)")
-	}
+	return s.q.Transaction(func(tx *query.Query) error {
+		res, err := tx.PayWalletRecharge.WithContext(ctx).
+			Where(tx.PayWalletRecharge.ID.Eq(id), tx.PayWalletRecharge.PayStatus.Is(false)).
+			Updates(map[string]interface{}{
+				"pay_status":       true,
+				"pay_order_id":     payOrderID,
+				"pay_time":         now,
+				"pay_channel_code": payOrder.ChannelCode,
+			})
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected == 0 {
+			return stdErrors.New("更新充值状态失败(非未支付状态)")
+		}
 
-	// 3. 更新钱包余额
-	err = s.walletSvc.AddWalletBalance(ctx, recharge.WalletID, strconv.FormatInt(id, 10), consts.PayWalletBizTypeRecharge, recharge.TotalPrice)
-	if err != nil {
+		// 3. 更新钱包余额
+		_, err = s.walletSvc.AddWalletBalanceTx(ctx, tx, recharge.WalletID, strconv.FormatInt(id, 10),
+			consts.PayWalletBizTypeRecharge, recharge.TotalPrice)
 		return err
-	}
-	return nil
+	})
 }
 
 // RefundWalletRecharge 发起钱包充值退款
@@ -11,11 +11,13 @@ import (
 	"github.com/wxlbd/ruoyi-mall-go/internal/consts"
 	"github.com/wxlbd/ruoyi-mall-go/internal/model/pay"
 	"github.com/wxlbd/ruoyi-mall-go/internal/repo/query"
+	"github.com/wxlbd/ruoyi-mall-go/internal/service/pay/client"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/errors"
 	"github.com/wxlbd/ruoyi-mall-go/pkg/pagination"
 
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 const (
@@ -23,6 +25,15 @@ const (
 	walletLockTimeout   = 5 * 1000 // 5 seconds in milliseconds
 )
 
+// ErrWalletNotFound 用户钱包不存在
+var ErrWalletNotFound = errors.NewBizError(1007007000, "用户钱包不存在") // WALLET_NOT_FOUND
+
+// ErrWalletBalanceNotEnough 钱包余额不足
+var ErrWalletBalanceNotEnough = errors.NewBizError(1007007001, "钱包余额不足") // WALLET_BALANCE_NOT_ENOUGH
+
+// ErrWalletTransactionNotFound 未找到对应的钱包交易
+var ErrWalletTransactionNotFound = errors.NewBizError(1007007002, "未找到对应的钱包交易") // WALLET_TRANSACTION_NOT_FOUND
+
 type PayWalletService struct {
 	q              *query.Query
 	rdb            *redis.Client
@@ -30,7 +41,10 @@ type PayWalletService struct {
 }
 
 func NewPayWalletService(q *query.Query, rdb *redis.Client, transactionSvc *PayWalletTransactionService) *PayWalletService {
-	return &PayWalletService{q: q, rdb: rdb, transactionSvc: transactionSvc}
+	s := &PayWalletService{q: q, rdb: rdb, transactionSvc: transactionSvc}
+	// 钱包支付客户端依赖钱包服务，无法在 init 中注册，在此注册
+	client.RegisterCreator(consts.PayChannelWallet, s.newWalletPayClient)
+	return s
 }
 
 // GetOrCreateWallet 获得会员钱包，不存在则创建
@@ -121,101 +135,129 @@ func (s *PayWalletService) GetWalletPage(ctx context.Context, req *pay2.PayWalle
 	return pagination.NewPageResult(list, total), nil
 }
 
-// AddWalletBalance 增加钱包余额
+// AddWalletBalance 变动钱包余额，同一业务只变动一次，已变动时返回已有的流水
 // price: 变动金额 (正数增加，负数减少)
-func (s *PayWalletService) AddWalletBalance(ctx context.Context, walletID int64, bizID string, bizType int, price int) error {
-	// 1. 获取钱包
-	wallet, err := s.GetWallet(ctx, walletID)
-	if err != nil {
+func (s *PayWalletService) AddWalletBalance(ctx context.Context, walletID int64, bizID string, bizType int, price int) (*pay.PayWalletTransaction, error) {
+	var transaction *pay.PayWalletTransaction
+	err := s.q.Transaction(func(tx *query.Query) error {
+		var err error
+		transaction, err = s.AddWalletBalanceTx(ctx, tx, walletID, bizID, bizType, price)
 		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// AddWalletBalanceTx 在调用方的事务内变动钱包余额，同一业务只变动一次，已变动时返回已有的流水
+// 锁定钱包行后再查询流水并变动余额，查询、变动与记录流水在同一事务内完成，避免并发重复入账
+func (s *PayWalletService) AddWalletBalanceTx(ctx context.Context, tx *query.Query, walletID int64, bizID string, bizType int, price int) (*pay.PayWalletTransaction, error) {
+	// 1. 锁定钱包
+	w := tx.PayWallet
+	wallet, err := w.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where(w.ID.Eq(walletID)).First()
+	if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrWalletNotFound
+	}
+	if err != nil {
+		return nil, err
 	}
-	if wallet == nil {
-		return stdErrors.New("wallet not found")
+
+	// 2. 已存在该业务的流水时不再变动
+	if transaction, err := getWalletTransactionByBizID(ctx, tx, bizType, bizID); err != nil || transaction != nil {
+		return transaction, err
 	}
 
-	// 2. 更新余额
+	// 3. 校验余额
 	switch bizType {
 	case consts.PayWalletBizTypePayment:
 		if wallet.Balance < -price { // price is negative for payment
-			return stdErrors.New("insufficient balance")
+			return nil, ErrWalletBalanceNotEnough
 		}
-	case consts.PayWalletBizTypePaymentRefund:
-		// Refund adds back checks?
-	case consts.PayWalletBizTypeRecharge:
-		// Recharge adds
-	case consts.PayWalletBizTypeUpdateBalance:
+	case consts.PayWalletBizTypeUpdateBalance, consts.PayWalletBizTypeProfitSharingReturn:
 		if price < 0 && wallet.Balance < -price {
-			return stdErrors.New("insufficient balance")
+			return nil, ErrWalletBalanceNotEnough
 		}
 	}
 
-	// Optimistic Update
-	result, err := s.q.PayWallet.WithContext(ctx).
-		Where(s.q.PayWallet.ID.Eq(wallet.ID)).
-		Updates(map[string]interface{}{
-			"balance": gorm.Expr("balance + ?", price),
-			"total_expense": gorm.Expr("total_expense + ?", func() int {
-				if price < 0 {
-					return -price
-				}
-				return 0
-			}()),
-			"total_recharge": gorm.Expr("total_recharge + ?", func() int {
-				if price > 0 {
-					return price
-				}
-				return 0
-			}()),
-		})
-	if err != nil {
-		return err
+	// 4. 更新余额
+	// 支付退款冲减累计支出，分账回退冲减累计充值；其它业务收入计入累计充值、支出计入累计支出
+	expense, recharge := 0, 0
+	switch {
+	case bizType == consts.PayWalletBizTypePaymentRefund:
+		expense = -price
+	case bizType == consts.PayWalletBizTypeProfitSharingReturn:
+		recharge = price
+	case price < 0:
+		expense = -price
+	default:
+		recharge = price
 	}
-	if result.RowsAffected == 0 {
-		return stdErrors.New("update wallet balance failed")
+	if _, err := w.WithContext(ctx).Where(w.ID.Eq(walletID)).
+		Updates(map[string]interface{}{
+			"balance":        gorm.Expr("balance + ?", price),
+			"total_expense":  gorm.Expr("total_expense + ?", expense),
+			"total_recharge": gorm.Expr("total_recharge + ?", recharge),
+		}); err != nil {
+		return nil, err
 	}
 
-	// 3. 记录流水
-	wallet.Balance += price // Approximate new balance for log
+	// 5. 记录流水
+	wallet.Balance += price
 	title := "钱包余额更新"
-	if bizType == consts.PayWalletBizTypeUpdateBalance {
+	switch bizType {
+	case consts.PayWalletBizTypeUpdateBalance:
 		title = "管理员修改"
+	case consts.PayWalletBizTypeProfitSharing:
+		title = "分账收入"
+	case consts.PayWalletBizTypeProfitSharingReturn:
+		title = "分账回退"
 	}
-	_, err = s.transactionSvc.CreateWalletTransaction(ctx, wallet, bizType, bizID, title, price)
-	return err
+	return NewPayWalletTransactionService(tx).CreateWalletTransaction(ctx, wallet, bizType, bizID, title, price)
 }
 
-// ReduceWalletBalance 扣减钱包余额
+// ReduceWalletBalance 扣减钱包余额，同一业务只扣减一次，已扣减时返回已有的流水
+// 锁定钱包行后再查询流水并扣款，查询、扣款与记录流水在同一事务内完成，避免并发重复提交导致重复扣款
 func (s *PayWalletService) ReduceWalletBalance(ctx context.Context, walletID int64, bizID int64, bizType int, price int) (*pay.PayWalletTransaction, error) {
-	// 1. 获取钱包
-	wallet, err := s.GetWallet(ctx, walletID)
-	if err != nil {
-		return nil, err
-	}
-	if wallet == nil {
-		return nil, errors.ErrNotFound
-	}
+	var transaction *pay.PayWalletTransaction
+	err := s.q.Transaction(func(tx *query.Query) error {
+		// 1. 锁定钱包
+		w := tx.PayWallet
+		wallet, err := w.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where(w.ID.Eq(walletID)).First()
+		if stdErrors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
 
-	// 2. 扣除余额 (这里简化处理，直接更新，实际应该考虑分布式锁避免并发扣减导致负数，虽然数据库层会有 check constraint 或 unsigned int 保护，但业务层也应校验)
-	// GORM updates
-	res, err := s.q.PayWallet.WithContext(ctx).
-		Where(s.q.PayWallet.ID.Eq(walletID)).
-		Updates(map[string]interface{}{
-			"balance":       gorm.Expr("balance - ?", price),       // 余额减少
-			"total_expense": gorm.Expr("total_expense + ?", price), // 支出增加
-		})
+		// 2. 已存在该业务的流水时不再扣减
+		if transaction, err = getWalletTransaction(ctx, tx, bizType, bizID); err != nil || transaction != nil {
+			return err
+		}
+
+		// 3. 扣除余额
+		if wallet.Balance < price {
+			return ErrWalletBalanceNotEnough
+		}
+		if _, err := w.WithContext(ctx).Where(w.ID.Eq(walletID)).
+			Updates(map[string]interface{}{
+				"balance":       gorm.Expr("balance - ?", price),       // 余额减少
+				"total_expense": gorm.Expr("total_expense + ?", price), // 支出增加
+			}); err != nil {
+			return err
+		}
 
+		// 4. 生成钱包流水
+		wallet.Balance -= price
+		transaction, err = NewPayWalletTransactionService(tx).CreateWalletTransaction(ctx, wallet, bizType,
+			strconv.FormatInt(bizID, 10), "钱包支出", -price)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	if res.RowsAffected == 0 {
-		return nil, stdErrors.New("insufficient balance")
-	}
-
-	// 3. 生成钱包流水
-	wallet.Balance -= price
-	title := "钱包支出"
-	// if bizType == ... // 可以根据 bizType 设置 title
-	return s.transactionSvc.CreateWalletTransaction(ctx, wallet, bizType, strconv.FormatInt(bizID, 10), title, -price)
+	return transaction, nil
 }
 
 // FreezePrice 冻结钱包余额
@@ -23,6 +23,16 @@ type Config struct {
 type IoTConfig struct {
 	Core    IoTCoreConfig    `mapstructure:"core"`
 	Gateway IoTGatewayConfig `mapstructure:"gateway"`
+	CA      DeviceCAConfig   `mapstructure:"ca"`
+}
+
+// DeviceCAConfig 设备 CA 配置，用于签发 X.509 认证设备的客户端证书
+// 证书与私钥文件不存在时自动生成自签名 CA 并写入该路径
+type DeviceCAConfig struct {
+	CertFile     string `mapstructure:"cert_file"`     // CA 证书文件，默认 data/iot-ca/ca.crt
+	KeyFile      string `mapstructure:"key_file"`      // CA 私钥文件，默认 data/iot-ca/ca.key
+	ValidityDays int    `mapstructure:"validity_days"` // 设备证书有效天数，默认 3650
+	CRLValidity  string `mapstructure:"crl_validity"`  // CRL 有效期（NextUpdate），默认 "24h"
 }
 
 type IoTCoreConfig struct {
@@ -30,14 +40,53 @@ type IoTCoreConfig struct {
 }
 
 type MessageBusConfig struct {
-	WorkerNum int `mapstructure:"worker_num"`
-	QueueSize int `mapstructure:"queue_size"`
+	Type      string                `mapstructure:"type"` // local（默认，单节点）或 redis（多节点）
+	WorkerNum int                   `mapstructure:"worker_num"`
+	QueueSize int                   `mapstructure:"queue_size"`
+	Redis     RedisMessageBusConfig `mapstructure:"redis"`
+}
+
+// RedisMessageBusConfig Redis Streams 消息总线配置，复用系统的 Redis 连接
+type RedisMessageBusConfig struct {
+	StreamPrefix  string `mapstructure:"stream_prefix"`  // Stream 键前缀，默认 iot:message-bus:
+	Consumer      string `mapstructure:"consumer"`       // 消费者名称，默认 主机名-进程号
+	BatchSize     int64  `mapstructure:"batch_size"`     // 单次读取条数，默认 10
+	Block         string `mapstructure:"block"`          // 读取阻塞时长，默认 "2s"
+	MaxLen        int64  `mapstructure:"max_len"`        // Stream 最大长度，默认 100000
+	MaxBacklog    int64  `mapstructure:"max_backlog"`    // 积压上限，超过后发布方等待，默认 10000
+	PublishWait   string `mapstructure:"publish_wait"`   // 发布最长等待时长，默认 "30s"
+	ClaimInterval string `mapstructure:"claim_interval"` // 检查未确认消息的间隔，默认 "30s"
+	ClaimIdle     string `mapstructure:"claim_idle"`     // 未确认消息重新投递的超时时长，默认 "60s"
+	MaxDeliveries int64  `mapstructure:"max_deliveries"` // 最大投递次数，默认 5
 }
 
 type IoTGatewayConfig struct {
-	ServerID       string           `mapstructure:"server_id"`
-	MaxConnections int              `mapstructure:"max_connections"`
-	MQTT           MQTTClientConfig `mapstructure:"mqtt"`
+	ServerID       string               `mapstructure:"server_id"`
+	MaxConnections int                  `mapstructure:"max_connections"`
+	MQTT           MQTTClientConfig     `mapstructure:"mqtt"`
+	EmbeddedBroker EmbeddedBrokerConfig `mapstructure:"embedded_broker"`
+	HTTP           HttpGatewayConfig    `mapstructure:"http"`
+}
+
+// HttpGatewayConfig HTTP 设备接入配置
+// 供仅支持 HTTP(S) 的设备上报数据、拉取下行指令
+type HttpGatewayConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	Address       string `mapstructure:"address"`        // 监听地址，默认 :8092
+	TokenExpire   string `mapstructure:"token_expire"`   // 设备 Token 有效期，默认 "2h"
+	TLSCertFile   string `mapstructure:"tls_cert_file"`  // HTTPS 服务端证书，与私钥均配置时以 HTTPS 监听
+	TLSKeyFile    string `mapstructure:"tls_key_file"`   // HTTPS 服务端私钥
+	PendingExpire string `mapstructure:"pending_expire"` // 待拉取下行指令的有效期，默认 "10m"
+}
+
+// EmbeddedBrokerConfig 内置 MQTT Broker 配置
+// 启用后网关不再连接外部 Broker，设备直接连接网关进程
+type EmbeddedBrokerConfig struct {
+	Enabled     bool   `mapstructure:"enabled"`
+	Address     string `mapstructure:"address"`       // 监听地址，默认 :1883
+	TLSAddress  string `mapstructure:"tls_address"`   // TLS 监听地址，如 :8883，为空不启用
+	TLSCertFile string `mapstructure:"tls_cert_file"` // TLS 服务端证书
+	TLSKeyFile  string `mapstructure:"tls_key_file"`  // TLS 服务端私钥
 }
 
 // MQTTClientConfig MQTT 客户端配置 (复用 internal 定义，或者搬迁到这里)
@@ -104,10 +153,11 @@ type Kd100Config struct {
 }
 
 type PayConfig struct {
-	OrderNotifyURL  string `mapstructure:"order_notify_url"`
-	RefundNotifyURL string `mapstructure:"refund_notify_url"`
-	OrderNoPrefix   string `mapstructure:"order_no_prefix"`
-	WalletPayAppKey string `mapstructure:"wallet_pay_app_key"`
+	OrderNotifyURL    string `mapstructure:"order_notify_url"`
+	RefundNotifyURL   string `mapstructure:"refund_notify_url"`
+	TransferNotifyURL string `mapstructure:"transfer_notify_url"`
+	OrderNoPrefix     string `mapstructure:"order_no_prefix"`
+	WalletPayAppKey   string `mapstructure:"wallet_pay_app_key"`
 }
 
 func Load() error {
@@ -0,0 +1,29 @@
+package excel
+
+import (
+	"encoding/csv"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WriteCSV 写入 CSV 并输出到 HTTP 响应（带 UTF-8 BOM，便于 Excel 正确识别中文）
+func WriteCSV(c *gin.Context, fileName string, headers []string, rows [][]string) error {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fileName))
+	c.Header("Expires", "0")
+	c.Header("Cache-Control", "must-revalidate")
+	c.Header("Pragma", "public")
+
+	if _, err := c.Writer.Write([]byte("\xEF\xBB\xBF")); err != nil {
+		return err
+	}
+	w := csv.NewWriter(c.Writer)
+	if err := w.Write(headers); err != nil {
+		return err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	return w.Error()
+}
@@ -2,7 +2,10 @@ package excel
 
 import (
 	"fmt"
+	"io"
 	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -101,3 +104,100 @@ func writeResponse(c *gin.Context, fileName string, f *excelize.File) error {
 
 	return f.Write(c.Writer)
 }
+
+// ReadExcel 读取 Excel 第一个 Sheet 到结构体切片，data 须为切片指针
+// 第一行为表头，按字段的 label 标签匹配列（与 WriteExcel 对应），表头中不存在的字段保持零值
+// 数据行顺序与 Excel 一致（第 i 个元素对应第 i+2 行），空行同样保留为零值元素
+func ReadExcel(r io.Reader, data interface{}) error {
+	ptr := reflect.ValueOf(data)
+	if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("data must be a pointer to slice")
+	}
+	slice := ptr.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	sheets := f.GetSheetList()
+	if len(sheets) == 0 {
+		return nil
+	}
+	rows, err := f.GetRows(sheets[0])
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	// 表头列 -> 字段下标
+	labelIndex := make(map[string]int)
+	for i := 0; i < elemType.NumField(); i++ {
+		if label := elemType.Field(i).Tag.Get("label"); label != "" {
+			labelIndex[label] = i
+		}
+	}
+	columns := make(map[int]int)
+	for col, header := range rows[0] {
+		if i, ok := labelIndex[strings.TrimSpace(header)]; ok {
+			columns[col] = i
+		}
+	}
+
+	for rowNo, row := range rows[1:] {
+		item := reflect.New(elemType).Elem()
+		for col, cell := range row {
+			i, ok := columns[col]
+			if !ok {
+				continue
+			}
+			if err := setCellValue(item.Field(i), strings.TrimSpace(cell)); err != nil {
+				return fmt.Errorf("第 %d 行【%s】格式不正确: %w", rowNo+2, elemType.Field(i).Tag.Get("label"), err)
+			}
+		}
+		if isPtr {
+			item = item.Addr()
+		}
+		slice.Set(reflect.Append(slice, item))
+	}
+	return nil
+}
+
+// setCellValue 将单元格文本写入字段，支持字符串、整数、浮点数与布尔类型
+func setCellValue(field reflect.Value, cell string) error {
+	if cell == "" {
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(cell)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(cell, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(cell, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(v)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(cell)
+		if err != nil {
+			return err
+		}
+		field.SetBool(v)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
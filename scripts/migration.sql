@@ -41,3 +41,507 @@ ADD INDEX `idx_status` (`status`);
 -- ----------------------------
 -- End of Migration
 -- ----------------------------
+
+-- ----------------------------
+-- Table structure for iot_data_sink_dead_letter
+-- ----------------------------
+DROP TABLE IF EXISTS `iot_data_sink_dead_letter`;
+CREATE TABLE `iot_data_sink_dead_letter` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '死信编号',
+  `rule_id` bigint NOT NULL COMMENT '数据流转规则编号',
+  `sink_id` bigint NOT NULL COMMENT '数据目的编号',
+  `sink_type` tinyint NOT NULL COMMENT '数据目的类型',
+  `device_id` bigint NOT NULL COMMENT '设备编号',
+  `message_id` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT NULL COMMENT '消息编号',
+  `message` text CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci COMMENT '设备消息(JSON)',
+  `retry_count` int NOT NULL DEFAULT '0' COMMENT '重试次数',
+  `error_msg` varchar(512) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT NULL COMMENT '失败原因',
+  `creator` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '创建者',
+  `create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updater` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '更新者',
+  `update_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  `deleted` bit(1) NOT NULL DEFAULT b'0' COMMENT '是否删除',
+  `tenant_id` bigint NOT NULL DEFAULT '0' COMMENT '租户编号',
+  PRIMARY KEY (`id`),
+  KEY `idx_sink_id` (`sink_id`),
+  KEY `idx_rule_id` (`rule_id`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='IoT 数据流转死信';
+
+-- ----------------------------
+-- Migration: Add alert de-duplication and silence windows to iot_alert_config
+-- ----------------------------
+ALTER TABLE `iot_alert_config`
+ADD COLUMN `dedup_window` int NOT NULL DEFAULT '0' COMMENT '去重窗口(秒)' AFTER `receive_types`,
+ADD COLUMN `silence_window` int NOT NULL DEFAULT '0' COMMENT '通知静默窗口(秒)' AFTER `dedup_window`;
+
+-- ----------------------------
+-- Migration: Add configurable codec layout to iot_product
+-- ----------------------------
+ALTER TABLE `iot_product`
+ADD COLUMN `codec_config` text CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci COMMENT '编解码配置' AFTER `codec_type`;
+
+-- ----------------------------
+-- Migration: Add rollout control to iot_ota_task and push time to iot_ota_task_record
+-- ----------------------------
+ALTER TABLE `iot_ota_task`
+ADD COLUMN `max_concurrency` int NOT NULL DEFAULT '0' COMMENT '最大并发升级设备数' AFTER `device_success_count`,
+ADD COLUMN `rollout_rate` int NOT NULL DEFAULT '0' COMMENT '每分钟最大推送设备数' AFTER `max_concurrency`,
+ADD COLUMN `timeout` int NOT NULL DEFAULT '0' COMMENT '升级超时时间(秒)' AFTER `rollout_rate`;
+
+ALTER TABLE `iot_ota_task_record`
+ADD COLUMN `push_time` datetime DEFAULT NULL COMMENT '推送时间' AFTER `description`;
+
+-- ----------------------------
+-- Table structure for iot_device_shadow
+-- ----------------------------
+DROP TABLE IF EXISTS `iot_device_shadow`;
+CREATE TABLE `iot_device_shadow` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '影子编号',
+  `device_id` bigint NOT NULL COMMENT '设备编号',
+  `desired` text CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci COMMENT '期望状态(JSON)',
+  `reported` text CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci COMMENT '上报状态(JSON)',
+  `version` bigint NOT NULL DEFAULT '0' COMMENT '版本号',
+  `creator` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '创建者',
+  `create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updater` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '更新者',
+  `update_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  `deleted` bit(1) NOT NULL DEFAULT b'0' COMMENT '是否删除',
+  `tenant_id` bigint NOT NULL DEFAULT '0' COMMENT '租户编号',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_device_id` (`device_id`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='IoT 设备影子';
+
+-- ----------------------------
+-- Migration: Add property retention to iot_product
+-- ----------------------------
+ALTER TABLE `iot_product`
+ADD COLUMN `property_retention_days` int NOT NULL DEFAULT '0' COMMENT '属性历史保留天数' AFTER `codec_config`;
+
+-- 聚合与过期清理按时间范围扫描属性历史
+ALTER TABLE `iot_device_property`
+ADD INDEX `idx_update_time` (`update_time`);
+
+-- ----------------------------
+-- Table structure for iot_device_property_rollup
+-- ----------------------------
+DROP TABLE IF EXISTS `iot_device_property_rollup`;
+CREATE TABLE `iot_device_property_rollup` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '编号',
+  `device_id` bigint NOT NULL COMMENT '设备编号',
+  `identifier` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '属性标识符',
+  `granularity` varchar(16) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '聚合粒度',
+  `bucket_time` datetime NOT NULL COMMENT '分桶开始时间',
+  `min_value` double NOT NULL DEFAULT '0' COMMENT '最小值',
+  `max_value` double NOT NULL DEFAULT '0' COMMENT '最大值',
+  `sum_value` double NOT NULL DEFAULT '0' COMMENT '合计值',
+  `count` bigint NOT NULL DEFAULT '0' COMMENT '数据点数',
+  `last_value` double NOT NULL DEFAULT '0' COMMENT '最后值',
+  `last_time` datetime NOT NULL COMMENT '最后值时间',
+  `creator` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '创建者',
+  `create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updater` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '更新者',
+  `update_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  `deleted` bit(1) NOT NULL DEFAULT b'0' COMMENT '是否删除',
+  `tenant_id` bigint NOT NULL DEFAULT '0' COMMENT '租户编号',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_rollup_bucket` (`device_id`, `identifier`, `granularity`, `bucket_time`),
+  KEY `idx_granularity_bucket_time` (`granularity`, `bucket_time`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='IoT 设备属性聚合';
+
+-- ----------------------------
+-- Migration: Add dynamic registration to iot_product
+-- ----------------------------
+ALTER TABLE `iot_product`
+ADD COLUMN `product_secret` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '产品密钥' AFTER `property_retention_days`,
+ADD COLUMN `register_enabled` bit(1) NOT NULL DEFAULT b'0' COMMENT '是否开启动态注册' AFTER `product_secret`;
+
+-- 存量产品生成产品密钥
+UPDATE `iot_product` SET `product_secret` = REPLACE(UUID(), '-', '') WHERE `product_secret` IS NULL OR `product_secret` = '';
+
+-- ----------------------------
+-- Table structure for iot_device_register_log
+-- ----------------------------
+DROP TABLE IF EXISTS `iot_device_register_log`;
+CREATE TABLE `iot_device_register_log` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '编号',
+  `product_id` bigint NOT NULL DEFAULT '0' COMMENT '产品编号',
+  `product_key` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '产品标识',
+  `device_name` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '设备名称',
+  `device_id` bigint NOT NULL DEFAULT '0' COMMENT '设备编号',
+  `protocol` varchar(16) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL DEFAULT '' COMMENT '注册协议',
+  `client_ip` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '客户端 IP',
+  `success` bit(1) NOT NULL DEFAULT b'0' COMMENT '是否成功',
+  `created` bit(1) NOT NULL DEFAULT b'0' COMMENT '是否新建设备',
+  `error_msg` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '失败原因',
+  `creator` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '创建者',
+  `create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updater` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '更新者',
+  `update_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  `deleted` bit(1) NOT NULL DEFAULT b'0' COMMENT '是否删除',
+  `tenant_id` bigint NOT NULL DEFAULT '0' COMMENT '租户编号',
+  PRIMARY KEY (`id`),
+  KEY `idx_product_key_device_name` (`product_key`, `device_name`),
+  KEY `idx_create_time` (`create_time`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='IoT 设备动态注册日志';
+
+-- ----------------------------
+-- Migration: Default auth type for existing iot_device
+-- ----------------------------
+UPDATE `iot_device` SET `auth_type` = 'secret' WHERE `auth_type` IS NULL OR `auth_type` = '';
+
+-- ----------------------------
+-- Table structure for iot_device_certificate
+-- ----------------------------
+DROP TABLE IF EXISTS `iot_device_certificate`;
+CREATE TABLE `iot_device_certificate` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '编号',
+  `device_id` bigint NOT NULL COMMENT '设备编号',
+  `product_key` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '产品标识',
+  `device_name` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '设备名称',
+  `serial_number` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '证书序列号（十六进制）',
+  `fingerprint` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '证书 SHA-256 指纹（十六进制）',
+  `subject` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '证书主题 CN',
+  `certificate` text CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci COMMENT '证书（PEM）',
+  `private_key` text CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci COMMENT '私钥（PEM）',
+  `not_before` datetime NOT NULL COMMENT '生效时间',
+  `not_after` datetime NOT NULL COMMENT '过期时间',
+  `status` tinyint NOT NULL DEFAULT '0' COMMENT '证书状态',
+  `revoke_time` datetime DEFAULT NULL COMMENT '吊销时间',
+  `revoke_reason` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '吊销原因',
+  `creator` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '创建者',
+  `create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updater` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '更新者',
+  `update_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  `deleted` bit(1) NOT NULL DEFAULT b'0' COMMENT '是否删除',
+  `tenant_id` bigint NOT NULL DEFAULT '0' COMMENT '租户编号',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_fingerprint` (`fingerprint`),
+  KEY `idx_device_id_status` (`device_id`, `status`),
+  KEY `idx_status_not_after` (`status`, `not_after`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='IoT 设备证书';
+
+-- ----------------------------
+-- Table structure for iot_geofence
+-- ----------------------------
+DROP TABLE IF EXISTS `iot_geofence`;
+CREATE TABLE `iot_geofence` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '围栏编号',
+  `name` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '围栏名称',
+  `type` tinyint NOT NULL COMMENT '围栏类型',
+  `product_id` bigint NOT NULL DEFAULT '0' COMMENT '产品编号',
+  `group_id` bigint NOT NULL DEFAULT '0' COMMENT '设备分组编号',
+  `center_latitude` decimal(10,8) DEFAULT NULL COMMENT '中心点纬度',
+  `center_longitude` decimal(11,8) DEFAULT NULL COMMENT '中心点经度',
+  `radius` double DEFAULT NULL COMMENT '半径（米）',
+  `points` json DEFAULT NULL COMMENT '多边形顶点',
+  `status` tinyint NOT NULL DEFAULT '0' COMMENT '状态',
+  `description` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '描述',
+  `creator` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '创建者',
+  `create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updater` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '更新者',
+  `update_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  `deleted` bit(1) NOT NULL DEFAULT b'0' COMMENT '是否删除',
+  `tenant_id` bigint NOT NULL DEFAULT '0' COMMENT '租户编号',
+  PRIMARY KEY (`id`),
+  KEY `idx_status` (`status`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='IoT 地理围栏';
+
+-- ----------------------------
+-- Table structure for iot_device_location
+-- ----------------------------
+DROP TABLE IF EXISTS `iot_device_location`;
+CREATE TABLE `iot_device_location` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '编号',
+  `device_id` bigint NOT NULL COMMENT '设备编号',
+  `latitude` decimal(10,8) NOT NULL COMMENT '纬度',
+  `longitude` decimal(11,8) NOT NULL COMMENT '经度',
+  `report_time` datetime(3) NOT NULL COMMENT '上报时间',
+  `creator` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '创建者',
+  `create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updater` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '更新者',
+  `update_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  `deleted` bit(1) NOT NULL DEFAULT b'0' COMMENT '是否删除',
+  `tenant_id` bigint NOT NULL DEFAULT '0' COMMENT '租户编号',
+  PRIMARY KEY (`id`),
+  KEY `idx_device_report_time` (`device_id`, `report_time`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='IoT 设备位置轨迹';
+
+-- ----------------------------
+-- Table structure for iot_thing_model_version
+-- ----------------------------
+DROP TABLE IF EXISTS `iot_thing_model_version`;
+CREATE TABLE `iot_thing_model_version` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '版本编号',
+  `product_id` bigint NOT NULL COMMENT '产品编号',
+  `product_key` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '产品标识',
+  `version` int NOT NULL COMMENT '版本号',
+  `tsl` json DEFAULT NULL COMMENT '物模型 TSL 快照',
+  `status` tinyint NOT NULL DEFAULT '0' COMMENT '版本状态',
+  `remark` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '发布说明',
+  `publish_time` datetime NOT NULL COMMENT '发布时间',
+  `creator` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '创建者',
+  `create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updater` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '更新者',
+  `update_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  `deleted` bit(1) NOT NULL DEFAULT b'0' COMMENT '是否删除',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_product_version` (`product_id`, `version`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='IoT 产品物模型版本';
+
+-- ----------------------------
+-- Table structure for pay_reconcile_bill
+-- ----------------------------
+DROP TABLE IF EXISTS `pay_reconcile_bill`;
+CREATE TABLE `pay_reconcile_bill` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '对账单编号',
+  `app_id` bigint NOT NULL COMMENT '应用编号',
+  `channel_id` bigint NOT NULL COMMENT '渠道编号',
+  `channel_type` varchar(16) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '对账渠道类型',
+  `bill_date` date NOT NULL COMMENT '账单日期',
+  `file_name` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '账单文件名',
+  `source` tinyint NOT NULL COMMENT '账单来源',
+  `status` tinyint NOT NULL DEFAULT '0' COMMENT '对账状态',
+  `channel_count` int NOT NULL DEFAULT '0' COMMENT '渠道交易笔数',
+  `channel_amount` bigint NOT NULL DEFAULT '0' COMMENT '渠道交易金额',
+  `local_count` int NOT NULL DEFAULT '0' COMMENT '本地交易笔数',
+  `local_amount` bigint NOT NULL DEFAULT '0' COMMENT '本地交易金额',
+  `matched_count` int NOT NULL DEFAULT '0' COMMENT '核对一致笔数',
+  `discrepancy_count` int NOT NULL DEFAULT '0' COMMENT '差异笔数',
+  `error_msg` varchar(1024) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '对账失败原因',
+  `creator` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '创建者',
+  `create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updater` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '更新者',
+  `update_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  `deleted` bit(1) NOT NULL DEFAULT b'0' COMMENT '是否删除',
+  `tenant_id` bigint NOT NULL DEFAULT '0' COMMENT '租户编号',
+  PRIMARY KEY (`id`),
+  KEY `idx_channel_date` (`channel_id`, `bill_date`),
+  KEY `idx_app_date` (`app_id`, `bill_date`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='支付渠道对账单';
+
+-- ----------------------------
+-- Table structure for pay_reconcile_discrepancy
+-- ----------------------------
+DROP TABLE IF EXISTS `pay_reconcile_discrepancy`;
+CREATE TABLE `pay_reconcile_discrepancy` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '差异编号',
+  `bill_id` bigint NOT NULL COMMENT '对账单编号',
+  `app_id` bigint NOT NULL COMMENT '应用编号',
+  `channel_type` varchar(16) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '对账渠道类型',
+  `bill_date` date NOT NULL COMMENT '账单日期',
+  `biz_type` tinyint NOT NULL COMMENT '业务类型',
+  `type` tinyint NOT NULL COMMENT '差异类型',
+  `local_id` bigint NOT NULL DEFAULT '0' COMMENT '本地支付单或退款单编号',
+  `channel_id` bigint NOT NULL DEFAULT '0' COMMENT '本地渠道编号',
+  `out_trade_no` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '外部订单号',
+  `channel_order_no` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '渠道订单号',
+  `out_refund_no` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '外部退款号',
+  `channel_refund_no` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '渠道退款单号',
+  `local_amount` int DEFAULT NULL COMMENT '本地金额',
+  `channel_amount` int DEFAULT NULL COMMENT '渠道金额',
+  `local_status` tinyint DEFAULT NULL COMMENT '本地状态',
+  `status` tinyint NOT NULL DEFAULT '0' COMMENT '处理状态',
+  `resolve_remark` varchar(512) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '处理说明',
+  `resolve_time` datetime DEFAULT NULL COMMENT '处理时间',
+  `creator` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '创建者',
+  `create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updater` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '更新者',
+  `update_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  `deleted` bit(1) NOT NULL DEFAULT b'0' COMMENT '是否删除',
+  `tenant_id` bigint NOT NULL DEFAULT '0' COMMENT '租户编号',
+  PRIMARY KEY (`id`),
+  KEY `idx_bill_id` (`bill_id`),
+  KEY `idx_app_date_status` (`app_id`, `bill_date`, `status`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='支付对账差异';
+
+-- ----------------------------
+-- Migration: Add profit sharing flag to pay_order
+-- ----------------------------
+ALTER TABLE `pay_order`
+ADD COLUMN `profit_sharing` bit(1) NOT NULL DEFAULT b'0' COMMENT '是否分账' AFTER `channel_order_no`;
+
+-- ----------------------------
+-- Table structure for pay_profit_sharing_receiver
+-- ----------------------------
+DROP TABLE IF EXISTS `pay_profit_sharing_receiver`;
+CREATE TABLE `pay_profit_sharing_receiver` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '接收方编号',
+  `app_id` bigint NOT NULL COMMENT '应用编号',
+  `name` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '接收方名称',
+  `type` tinyint NOT NULL COMMENT '接收方类型',
+  `account` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '接收方账号',
+  `real_name` varchar(128) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '接收方真实姓名或商户全称',
+  `relation_type` varchar(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '与分账方的关系类型',
+  `status` tinyint NOT NULL DEFAULT '0' COMMENT '状态',
+  `remark` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '备注',
+  `creator` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '创建者',
+  `create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updater` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '更新者',
+  `update_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  `deleted` bit(1) NOT NULL DEFAULT b'0' COMMENT '是否删除',
+  `tenant_id` bigint NOT NULL DEFAULT '0' COMMENT '租户编号',
+  PRIMARY KEY (`id`),
+  KEY `idx_app_id` (`app_id`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='支付分账接收方';
+
+-- ----------------------------
+-- Table structure for pay_profit_sharing_rule
+-- ----------------------------
+DROP TABLE IF EXISTS `pay_profit_sharing_rule`;
+CREATE TABLE `pay_profit_sharing_rule` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '规则编号',
+  `app_id` bigint NOT NULL COMMENT '应用编号',
+  `name` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '规则名称',
+  `receivers` varchar(2048) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '分账接收方',
+  `status` tinyint NOT NULL DEFAULT '0' COMMENT '状态',
+  `remark` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '备注',
+  `creator` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '创建者',
+  `create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updater` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '更新者',
+  `update_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  `deleted` bit(1) NOT NULL DEFAULT b'0' COMMENT '是否删除',
+  `tenant_id` bigint NOT NULL DEFAULT '0' COMMENT '租户编号',
+  PRIMARY KEY (`id`),
+  KEY `idx_app_id` (`app_id`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='支付分账规则';
+
+-- ----------------------------
+-- Table structure for pay_profit_sharing_order
+-- ----------------------------
+DROP TABLE IF EXISTS `pay_profit_sharing_order`;
+CREATE TABLE `pay_profit_sharing_order` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '分账单编号',
+  `no` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '分账单号',
+  `app_id` bigint NOT NULL COMMENT '应用编号',
+  `rule_id` bigint NOT NULL COMMENT '分账规则编号',
+  `order_id` bigint NOT NULL COMMENT '支付订单编号',
+  `channel_id` bigint NOT NULL COMMENT '渠道编号',
+  `channel_code` varchar(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '渠道编码',
+  `out_trade_no` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '外部订单号',
+  `channel_order_no` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '渠道订单号',
+  `price` int NOT NULL COMMENT '分账基数',
+  `sharing_price` int NOT NULL DEFAULT '0' COMMENT '分账金额',
+  `refund_price` int NOT NULL DEFAULT '0' COMMENT '已处理的退款金额',
+  `status` tinyint NOT NULL DEFAULT '0' COMMENT '分账状态',
+  `channel_sharing_no` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '渠道分账单号',
+  `success_time` datetime DEFAULT NULL COMMENT '分账完成时间',
+  `error_msg` varchar(1024) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '分账失败原因',
+  `creator` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '创建者',
+  `create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updater` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '更新者',
+  `update_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  `deleted` bit(1) NOT NULL DEFAULT b'0' COMMENT '是否删除',
+  `tenant_id` bigint NOT NULL DEFAULT '0' COMMENT '租户编号',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `uk_order_id` (`order_id`),
+  KEY `idx_status` (`status`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='支付分账单';
+
+-- ----------------------------
+-- Table structure for pay_profit_sharing_order_item
+-- ----------------------------
+DROP TABLE IF EXISTS `pay_profit_sharing_order_item`;
+CREATE TABLE `pay_profit_sharing_order_item` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '明细编号',
+  `sharing_order_id` bigint NOT NULL COMMENT '分账单编号',
+  `order_id` bigint NOT NULL COMMENT '支付订单编号',
+  `out_sharing_no` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '分账请求单号',
+  `receiver_id` bigint NOT NULL COMMENT '接收方编号',
+  `receiver_type` tinyint NOT NULL COMMENT '接收方类型',
+  `account` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '接收方账号',
+  `name` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '接收方名称',
+  `ratio` int NOT NULL COMMENT '分账比例',
+  `price` int NOT NULL COMMENT '分账金额',
+  `return_price` int NOT NULL DEFAULT '0' COMMENT '已回退金额',
+  `status` tinyint NOT NULL DEFAULT '0' COMMENT '分账状态',
+  `channel_detail_no` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '渠道分账明细单号',
+  `fail_reason` varchar(512) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '分账失败原因',
+  `success_time` datetime DEFAULT NULL COMMENT '分账成功时间',
+  `creator` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '创建者',
+  `create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updater` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '更新者',
+  `update_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  `deleted` bit(1) NOT NULL DEFAULT b'0' COMMENT '是否删除',
+  `tenant_id` bigint NOT NULL DEFAULT '0' COMMENT '租户编号',
+  PRIMARY KEY (`id`),
+  KEY `idx_sharing_order_id` (`sharing_order_id`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='支付分账明细';
+
+-- ----------------------------
+-- Table structure for pay_profit_sharing_return
+-- ----------------------------
+DROP TABLE IF EXISTS `pay_profit_sharing_return`;
+CREATE TABLE `pay_profit_sharing_return` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '回退单编号',
+  `no` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '回退单号',
+  `app_id` bigint NOT NULL COMMENT '应用编号',
+  `sharing_order_id` bigint NOT NULL COMMENT '分账单编号',
+  `item_id` bigint NOT NULL COMMENT '分账明细编号',
+  `order_id` bigint NOT NULL COMMENT '支付订单编号',
+  `receiver_type` tinyint NOT NULL COMMENT '接收方类型',
+  `account` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '接收方账号',
+  `price` int NOT NULL COMMENT '回退金额',
+  `status` tinyint NOT NULL DEFAULT '0' COMMENT '回退状态',
+  `channel_return_no` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '渠道回退单号',
+  `fail_reason` varchar(512) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '回退失败原因',
+  `success_time` datetime DEFAULT NULL COMMENT '回退成功时间',
+  `creator` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '创建者',
+  `create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updater` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '更新者',
+  `update_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  `deleted` bit(1) NOT NULL DEFAULT b'0' COMMENT '是否删除',
+  `tenant_id` bigint NOT NULL DEFAULT '0' COMMENT '租户编号',
+  PRIMARY KEY (`id`),
+  KEY `idx_sharing_order_id` (`sharing_order_id`),
+  KEY `idx_status` (`status`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='支付分账回退单';
+
+-- ----------------------------
+-- Migration: Add combined wallet payment columns
+-- ----------------------------
+ALTER TABLE `pay_order`
+ADD COLUMN `wallet_price` int NOT NULL DEFAULT '0' COMMENT '钱包支付金额' AFTER `profit_sharing`;
+
+ALTER TABLE `pay_order_extension`
+ADD COLUMN `wallet_id` bigint NOT NULL DEFAULT '0' COMMENT '组合支付的钱包编号' AFTER `channel_notify_data`,
+ADD COLUMN `wallet_price` int NOT NULL DEFAULT '0' COMMENT '组合支付的钱包金额' AFTER `wallet_id`,
+ADD COLUMN `wallet_status` tinyint NOT NULL DEFAULT '0' COMMENT '组合支付的钱包状态' AFTER `wallet_price`;
+
+ALTER TABLE `pay_refund`
+ADD COLUMN `wallet_refund_price` int NOT NULL DEFAULT '0' COMMENT '退回钱包的金额' AFTER `refund_price`;
+
+-- ----------------------------
+-- Table structure for pay_channel_route_rule
+-- ----------------------------
+DROP TABLE IF EXISTS `pay_channel_route_rule`;
+CREATE TABLE `pay_channel_route_rule` (
+  `id` bigint NOT NULL AUTO_INCREMENT COMMENT '规则编号',
+  `app_id` bigint NOT NULL COMMENT '应用编号',
+  `name` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '规则名称',
+  `channel_code` varchar(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '渠道编码',
+  `sort` int NOT NULL DEFAULT '0' COMMENT '优先级',
+  `min_price` int NOT NULL DEFAULT '0' COMMENT '最小金额',
+  `max_price` int NOT NULL DEFAULT '0' COMMENT '最大金额',
+  `start_time` varchar(5) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '生效开始时间',
+  `end_time` varchar(5) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '生效结束时间',
+  `terminals` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT NULL COMMENT '终端',
+  `tenant_ids` varchar(1024) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT NULL COMMENT '租户编号',
+  `targets` varchar(2048) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci NOT NULL COMMENT '候选渠道',
+  `status` tinyint NOT NULL DEFAULT '0' COMMENT '状态',
+  `remark` varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '备注',
+  `creator` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '创建者',
+  `create_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+  `updater` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci DEFAULT '' COMMENT '更新者',
+  `update_time` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+  `deleted` bit(1) NOT NULL DEFAULT b'0' COMMENT '是否删除',
+  `tenant_id` bigint NOT NULL DEFAULT '0' COMMENT '租户编号',
+  PRIMARY KEY (`id`),
+  KEY `idx_app_id_channel_code` (`app_id`, `channel_code`)
+) ENGINE=InnoDB AUTO_INCREMENT=1 DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='支付渠道路由规则';
+
+-- ----------------------------
+-- Migration: Unique wallet transaction per business
+-- 同一业务只记录一条钱包流水，执行前需清理历史上重复的流水
+-- ----------------------------
+ALTER TABLE `pay_wallet_transaction`
+ADD UNIQUE KEY `uk_biz_type_biz_id` (`biz_type`, `biz_id`);